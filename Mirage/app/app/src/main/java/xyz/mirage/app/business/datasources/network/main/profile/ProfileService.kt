@@ -6,13 +6,14 @@ import retrofit2.http.Path
 import retrofit2.http.Query
 import xyz.mirage.app.business.datasources.network.main.post.dto.ProfileDto
 import xyz.mirage.app.business.datasources.network.main.post.response.PostListResponse
+import xyz.mirage.app.business.datasources.network.main.profile.response.ProfileListResponse
 
 interface ProfileService {
 
     @GET("profiles")
     suspend fun searchProfiles(
         @Query("search") search: String
-    ): List<ProfileDto>
+    ): ProfileListResponse
 
     @GET("profiles/{username}")
     suspend fun getProfile(
@@ -0,0 +1,12 @@
+package xyz.mirage.app.business.datasources.network.main.profile.response
+
+import com.squareup.moshi.Json
+import xyz.mirage.app.business.datasources.network.main.post.dto.ProfileDto
+
+data class ProfileListResponse(
+    @Json(name = "profiles")
+    val profiles: List<ProfileDto>,
+
+    @Json(name = "hasMore")
+    val hasMore: Boolean
+)
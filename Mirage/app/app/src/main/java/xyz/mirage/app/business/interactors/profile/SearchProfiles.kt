@@ -21,7 +21,7 @@ class SearchProfiles(
 
         val profiles = service.searchProfiles(
             search = search,
-        ).map { it.toProfile() }
+        ).profiles.map { it.toProfile() }
 
         cache.insertProfiles(profiles = profiles.map { it.toEntity() })
 
@@ -2,7 +2,8 @@ package xyz.mirage.app.datasource.network
 
 import xyz.mirage.app.business.domain.models.Profile
 
-const val profileListResponse: String = "[\n" +
+const val profileListResponse: String = "{\n" +
+        "    \"profiles\": [\n" +
         "    {\n" +
         "        \"id\": \"1411674329300602880\",\n" +
         "        \"username\": \"yqbww\",\n" +
@@ -123,7 +124,9 @@ const val profileListResponse: String = "[\n" +
         "        \"following\": false,\n" +
         "        \"createdAt\": \"2021-07-04T13:13:23.704286Z\"\n" +
         "    }\n" +
-        "]"
+        "    ],\n" +
+        "    \"hasMore\": false\n" +
+        "}"
 
 const val followedProfile: String = "    {\n" +
         "        \"id\": \"1411674502525358080\",\n" +
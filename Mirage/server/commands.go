@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/repository"
+	"github.com/sentrionic/mirage/service"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// defaultWorkerConcurrency is the number of jobs the worker
+// processes at once if WORKER_CONCURRENCY is not set
+const defaultWorkerConcurrency = 4
+
+// runCommand runs a maintenance command instead of starting the server.
+//
+// Commands:
+//
+//	rebuild-trends       recreates the trend buckets in Redis from the posts table
+//	cleanup-uploads      deletes expired direct uploads and media no post uses anymore
+//	worker               processes background jobs until it receives SIGINT or SIGTERM
+//	retry-dead-jobs      moves the jobs that failed too often back to the queue
+//	rebuild-timelines    recomputes the popular accounts and rebuilds the precomputed home timelines
+//	reindex-profiles     sets the normalized display names and bios that profile search matches
+//	drop-legacy-follows  drops the followers and followee tables after their follows were copied
+func runCommand(name string, d *dataSources) error {
+	switch name {
+	case "rebuild-trends":
+		log.Println("Rebuilding trends")
+		trendService := service.NewTrendService(&service.TrSConfig{
+			TrendRepository: repository.NewTrendRepository(d.RedisClient),
+			PostRepository:  repository.NewPostRepository(d.DB),
+		})
+		return trendService.Rebuild()
+	case "cleanup-uploads":
+		bucketName := os.Getenv("AWS_STORAGE_BUCKET_NAME")
+		mediaService := service.NewMediaService(&service.MSConfig{
+			UploadRepository: repository.NewUploadRepository(d.DB),
+			FileRepository:   repository.NewFileRepository(d.S3Session, bucketName),
+			PostRepository:   repository.NewPostRepository(d.DB),
+		})
+		deleted, err := mediaService.CleanupUploads()
+		log.Printf("Deleted %d expired uploads\n", deleted)
+		if err != nil {
+			return err
+		}
+		deleted, err = mediaService.CleanupOrphanedMedia()
+		log.Printf("Deleted the objects of %d orphaned media\n", deleted)
+		return err
+	case "worker":
+		concurrency := defaultWorkerConcurrency
+		if value := os.Getenv("WORKER_CONCURRENCY"); value != "" {
+			c, err := strconv.Atoi(value)
+			if err != nil || c < 1 {
+				return fmt.Errorf("could not parse WORKER_CONCURRENCY as positive int: %v", value)
+			}
+			concurrency = c
+		}
+
+		jobService := newJobService(d)
+
+		ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+		defer stop()
+
+		log.Printf("Processing jobs with %d workers\n", concurrency)
+		jobService.Work(ctx, concurrency)
+		return nil
+	case "retry-dead-jobs":
+		retried, err := newJobService(d).RetryDead()
+		log.Printf("Moved %d dead jobs back to the queue\n", retried)
+		return err
+	case "rebuild-timelines":
+		timelineService := service.NewTimelineService(&service.TlSConfig{
+			TimelineRepository: repository.NewTimelineRepository(d.RedisClient),
+			PostRepository:     repository.NewPostRepository(d.DB),
+			UserRepository:     repository.NewUserRepository(d.DB),
+		})
+		rebuilt, err := timelineService.RebuildAll()
+		log.Printf("Rebuilt %d timelines\n", rebuilt)
+		return err
+	case "reindex-profiles":
+		userService := service.NewUserService(&service.USConfig{
+			UserRepository: repository.NewUserRepository(d.DB),
+		})
+		updated, err := userService.ReindexProfiles()
+		log.Printf("Reindexed %d profiles\n", updated)
+		return err
+	case "drop-legacy-follows":
+		dropped, err := dropLegacyFollows(d.DB)
+		log.Printf("Dropped %d legacy follow tables\n", dropped)
+		return err
+	default:
+		return fmt.Errorf("unknown command: %v", name)
+	}
+}
+
+// newJobService returns a job service with the
+// handlers of all job types registered
+func newJobService(d *dataSources) model.JobService {
+	jobService := service.NewJobService(&service.JSConfig{
+		JobQueue: repository.NewJobQueue(d.RedisClient),
+	})
+
+	bucketName := os.Getenv("AWS_STORAGE_BUCKET_NAME")
+	fileRepository := repository.NewFileRepository(d.S3Session, bucketName)
+	postRepository := repository.NewPostRepository(d.DB)
+	userRepository := repository.NewUserRepository(d.DB)
+
+	// the services register their handlers with the job service
+	service.NewTimelineService(&service.TlSConfig{
+		TimelineRepository: repository.NewTimelineRepository(d.RedisClient),
+		PostRepository:     postRepository,
+		UserRepository:     userRepository,
+		JobService:         jobService,
+	})
+
+	service.NewPostService(&service.PSConfig{
+		PostRepository: postRepository,
+		FileRepository: fileRepository,
+		LinkService: service.NewLinkService(&service.LSConfig{
+			Fetcher:        service.NewLinkFetcher(5 * time.Second),
+			LinkRepository: repository.NewLinkRepository(d.RedisClient),
+			FileRepository: fileRepository,
+		}),
+		JobService: jobService,
+	})
+
+	service.NewUserService(&service.USConfig{
+		UserRepository:   userRepository,
+		FileRepository:   fileRepository,
+		UploadRepository: repository.NewUploadRepository(d.DB),
+		JobService:       jobService,
+	})
+
+	return jobService
+}
@@ -37,7 +37,14 @@ func initDS() (*dataSources, error) {
 		&model.User{},
 		&model.Post{},
 		&model.File{},
+		&model.OrphanedMedia{},
 		&model.Retweet{},
+		&model.Like{},
+		&model.Follow{},
+		&model.Block{},
+		&model.AccessToken{},
+		&model.Upload{},
+		&model.SavedSearch{},
 	); err != nil {
 		return nil, fmt.Errorf("error migrating models: %w", err)
 	}
@@ -46,6 +53,24 @@ func initDS() (*dataSources, error) {
 		return nil, fmt.Errorf("error creating join table: %w", err)
 	}
 
+	if err := db.SetupJoinTable(&model.Post{}, "Likes", &model.Like{}); err != nil {
+		return nil, fmt.Errorf("error creating join table: %w", err)
+	}
+
+	for _, field := range []string{"Followers", "Followee"} {
+		if err := db.SetupJoinTable(&model.User{}, field, &model.Follow{}); err != nil {
+			return nil, fmt.Errorf("error creating join table: %w", err)
+		}
+	}
+
+	if err := createExpressionIndexes(db); err != nil {
+		return nil, fmt.Errorf("error creating indexes: %w", err)
+	}
+
+	if err := runDataMigrations(db); err != nil {
+		return nil, fmt.Errorf("error migrating data: %w", err)
+	}
+
 	// Initialize redis connection
 	redisURL := os.Getenv("REDIS_URL")
 	opt, err := redis.ParseURL(redisURL)
@@ -99,3 +124,28 @@ func (d *dataSources) close() error {
 
 	return nil
 }
+
+// createExpressionIndexes creates the indexes the model tags can't express: the
+// trigram indexes that let the LIKE '%term%' filters of profile search use an
+// index and the index of the month and day filters of on-this-day
+func createExpressionIndexes(db *gorm.DB) error {
+	statements := []string{
+		"CREATE EXTENSION IF NOT EXISTS pg_trgm",
+		"CREATE INDEX IF NOT EXISTS idx_users_username_trgm ON users USING gin (lower(username) gin_trgm_ops)",
+		"CREATE INDEX IF NOT EXISTS idx_users_search_name_trgm ON users USING gin (search_name gin_trgm_ops)",
+		"CREATE INDEX IF NOT EXISTS idx_users_search_bio_trgm ON users USING gin (search_bio gin_trgm_ops)",
+		`CREATE INDEX IF NOT EXISTS idx_posts_user_month_day ON posts (
+			user_id,
+			EXTRACT(MONTH FROM created_at AT TIME ZONE 'UTC'),
+			EXTRACT(DAY FROM created_at AT TIME ZONE 'UTC')
+		)`,
+	}
+
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
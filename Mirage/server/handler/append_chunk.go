@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// AppendChunk handler stores the raw request body as the chunk with the given index
+func (h *Handler) AppendChunk(c *gin.Context) {
+	userId := c.MustGet("userId").(string)
+	mediaId := c.Param("id")
+
+	index, err := strconv.Atoi(c.Param("index"))
+
+	if err != nil || index < 0 {
+		e := apperrors.NewBadRequest("invalid chunk index")
+		c.JSON(e.Status(), gin.H{
+			"error": e,
+		})
+		return
+	}
+
+	chunk, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, model.ChunkSize))
+
+	if err != nil {
+		var e *apperrors.Error
+		var maxBytesErr *http.MaxBytesError
+
+		if errors.As(err, &maxBytesErr) {
+			e = apperrors.NewPayloadTooLarge(model.ChunkSize, c.Request.ContentLength)
+		} else {
+			log.Printf("Unable to read chunk %d of upload: %v\n%v", index, mediaId, err)
+			e = apperrors.NewBadRequest("unable to read chunk")
+		}
+
+		c.JSON(e.Status(), gin.H{
+			"error": e,
+		})
+		return
+	}
+
+	upload, err := h.MediaService.AppendChunk(userId, mediaId, index, chunk)
+
+	if err != nil {
+		log.Printf("Unable to append chunk %d to upload: %v\n%v", index, mediaId, err)
+
+		c.JSON(apperrors.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, upload.NewChunkedUploadResponse())
+}
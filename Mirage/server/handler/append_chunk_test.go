@@ -0,0 +1,167 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-contrib/sessions/cookie"
+	"github.com/gin-gonic/gin"
+	"github.com/sentrionic/mirage/mocks"
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"github.com/sentrionic/mirage/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/iotest"
+)
+
+func TestHandler_AppendChunk(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	uid, _ := service.GenerateId()
+
+	t.Run("Success", func(t *testing.T) {
+		chunk := []byte("chunk of a video")
+		upload := &model.ChunkedUpload{ID: "10", UserID: uid, Size: 100, NextChunk: 1, Received: int64(len(chunk))}
+
+		mockMediaService := new(mocks.MediaService)
+		mockMediaService.On("AppendChunk", uid, "10", 0, chunk).Return(upload, nil)
+
+		rr := httptest.NewRecorder()
+		router := gin.Default()
+		store := cookie.NewStore([]byte("secret"))
+		router.Use(sessions.Sessions("mqk", store))
+
+		router.Use(func(c *gin.Context) {
+			session := sessions.Default(c)
+			session.Set("userId", uid)
+		})
+
+		NewHandler(&Config{
+			R:            router,
+			MediaService: mockMediaService,
+			MaxBodyBytes: 4 * 1024 * 1024,
+		})
+
+		request, _ := http.NewRequest(http.MethodPut, "/v1/media/uploads/10/chunks/0", bytes.NewReader(chunk))
+		request.Header.Set("Content-Type", "application/octet-stream")
+		router.ServeHTTP(rr, request)
+
+		respBody, err := json.Marshal(upload.NewChunkedUploadResponse())
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+		mockMediaService.AssertExpectations(t)
+	})
+
+	t.Run("Invalid index", func(t *testing.T) {
+		mockMediaService := new(mocks.MediaService)
+
+		rr := httptest.NewRecorder()
+		router := gin.Default()
+		store := cookie.NewStore([]byte("secret"))
+		router.Use(sessions.Sessions("mqk", store))
+
+		router.Use(func(c *gin.Context) {
+			session := sessions.Default(c)
+			session.Set("userId", uid)
+		})
+
+		NewHandler(&Config{
+			R:            router,
+			MediaService: mockMediaService,
+			MaxBodyBytes: 4 * 1024 * 1024,
+		})
+
+		request, _ := http.NewRequest(http.MethodPut, "/v1/media/uploads/10/chunks/first", bytes.NewReader([]byte("chunk")))
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		mockMediaService.AssertNotCalled(t, "AppendChunk", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Chunk too large", func(t *testing.T) {
+		mockMediaService := new(mocks.MediaService)
+
+		rr := httptest.NewRecorder()
+		router := gin.Default()
+		store := cookie.NewStore([]byte("secret"))
+		router.Use(sessions.Sessions("mqk", store))
+
+		router.Use(func(c *gin.Context) {
+			session := sessions.Default(c)
+			session.Set("userId", uid)
+		})
+
+		NewHandler(&Config{
+			R:            router,
+			MediaService: mockMediaService,
+			MaxBodyBytes: 4 * 1024 * 1024,
+		})
+
+		request, _ := http.NewRequest(http.MethodPut, "/v1/media/uploads/10/chunks/0", bytes.NewReader(make([]byte, model.ChunkSize+1)))
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+		mockMediaService.AssertNotCalled(t, "AppendChunk", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Unreadable body", func(t *testing.T) {
+		mockMediaService := new(mocks.MediaService)
+
+		rr := httptest.NewRecorder()
+		router := gin.Default()
+		store := cookie.NewStore([]byte("secret"))
+		router.Use(sessions.Sessions("mqk", store))
+
+		router.Use(func(c *gin.Context) {
+			session := sessions.Default(c)
+			session.Set("userId", uid)
+		})
+
+		NewHandler(&Config{
+			R:            router,
+			MediaService: mockMediaService,
+			MaxBodyBytes: 4 * 1024 * 1024,
+		})
+
+		request, _ := http.NewRequest(http.MethodPut, "/v1/media/uploads/10/chunks/0", iotest.ErrReader(errors.New("connection reset")))
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		mockMediaService.AssertNotCalled(t, "AppendChunk", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Unknown upload", func(t *testing.T) {
+		mockMediaService := new(mocks.MediaService)
+		mockMediaService.
+			On("AppendChunk", uid, "11", 0, mock.Anything).
+			Return(nil, apperrors.NewNotFound("media", "11"))
+
+		rr := httptest.NewRecorder()
+		router := gin.Default()
+		store := cookie.NewStore([]byte("secret"))
+		router.Use(sessions.Sessions("mqk", store))
+
+		router.Use(func(c *gin.Context) {
+			session := sessions.Default(c)
+			session.Set("userId", uid)
+		})
+
+		NewHandler(&Config{
+			R:            router,
+			MediaService: mockMediaService,
+			MaxBodyBytes: 4 * 1024 * 1024,
+		})
+
+		request, _ := http.NewRequest(http.MethodPut, "/v1/media/uploads/11/chunks/0", bytes.NewReader([]byte("chunk")))
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
@@ -0,0 +1,23 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"log"
+	"net/http"
+)
+
+// ClearSearchHistory handler removes all of the current user's recent searches
+func (h *Handler) ClearSearchHistory(c *gin.Context) {
+	userId := c.MustGet("userId").(string)
+
+	if err := h.SearchService.ClearHistory(userId); err != nil {
+		log.Printf("Unable to clear search history of user: %v\n%v", userId, err)
+		c.JSON(apperrors.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, true)
+}
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-contrib/sessions/cookie"
+	"github.com/gin-gonic/gin"
+	"github.com/sentrionic/mirage/mocks"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"github.com/sentrionic/mirage/service"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandler_ClearSearchHistory(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	uid, _ := service.GenerateId()
+
+	setupRouter := func(mockSearchService *mocks.SearchService) *gin.Engine {
+		router := gin.Default()
+		store := cookie.NewStore([]byte("secret"))
+		router.Use(sessions.Sessions("mqk", store))
+
+		router.Use(func(c *gin.Context) {
+			session := sessions.Default(c)
+			session.Set("userId", uid)
+			c.Set("userId", uid)
+		})
+
+		NewHandler(&Config{
+			R:             router,
+			SearchService: mockSearchService,
+		})
+
+		return router
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		mockSearchService := new(mocks.SearchService)
+		mockSearchService.On("ClearHistory", uid).Return(nil)
+
+		rr := httptest.NewRecorder()
+		router := setupRouter(mockSearchService)
+
+		request, err := http.NewRequest(http.MethodDelete, "/v1/accounts/searches/history", nil)
+		assert.NoError(t, err)
+
+		router.ServeHTTP(rr, request)
+
+		respBody, err := json.Marshal(true)
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+		mockSearchService.AssertExpectations(t)
+		mockSearchService.AssertNotCalled(t, "DeleteSavedSearch", uid, "history")
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		mockSearchService := new(mocks.SearchService)
+		mockSearchService.On("ClearHistory", uid).Return(apperrors.NewInternal())
+
+		rr := httptest.NewRecorder()
+		router := setupRouter(mockSearchService)
+
+		request, err := http.NewRequest(http.MethodDelete, "/v1/accounts/searches/history", nil)
+		assert.NoError(t, err)
+
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+		mockSearchService.AssertExpectations(t)
+	})
+}
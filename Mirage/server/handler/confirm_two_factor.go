@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// twoFactorCodeReq is used by every endpoint that
+// requires the current two-factor or a recovery code
+type twoFactorCodeReq struct {
+	Code string `json:"code"`
+}
+
+func (r twoFactorCodeReq) Validate() error {
+	return validation.ValidateStruct(&r,
+		validation.Field(&r.Code, validation.Required, validation.Length(6, 20)),
+	)
+}
+
+func (r *twoFactorCodeReq) Sanitize() {
+	r.Code = strings.TrimSpace(r.Code)
+}
+
+// ConfirmTwoFactor handler enables two-factor authentication
+// once the user proves their authenticator app works
+func (h *Handler) ConfirmTwoFactor(c *gin.Context) {
+	userId := c.MustGet("userId").(string)
+
+	var req twoFactorCodeReq
+
+	if ok := bindData(c, &req); !ok {
+		return
+	}
+
+	req.Sanitize()
+
+	authUser, err := h.UserService.Get(userId)
+
+	if err != nil {
+		err := errors.New("provided session is invalid")
+		c.JSON(401, gin.H{
+			"error": err,
+		})
+		c.Abort()
+		return
+	}
+
+	if err := h.UserService.ConfirmTwoFactor(authUser, req.Code); err != nil {
+		log.Printf("Failed to confirm two-factor authentication: %v\n", err)
+		c.JSON(apperrors.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, authUser.NewAccountResponse())
+}
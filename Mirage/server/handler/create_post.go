@@ -13,17 +13,29 @@ import (
 )
 
 type createPostReq struct {
-	Text *string               `form:"text"`
-	File *multipart.FileHeader `form:"file"`
+	Text    *string               `form:"text"`
+	File    *multipart.FileHeader `form:"file"`
+	AltText *string               `form:"altText"`
+	// MediaID attaches a file uploaded with CreateUpload instead of File
+	MediaID *string `form:"mediaId"`
 }
 
 func (r createPostReq) Validate() error {
 	return validation.ValidateStruct(&r,
 		validation.Field(&r.Text,
-			validation.Required.When(r.File == nil).
+			validation.Required.When(r.File == nil && r.MediaID == nil).
 				Error("text is required if no files are provided"),
 			validation.Length(1, 280),
 		),
+		validation.Field(&r.AltText,
+			validation.Nil.When(r.File == nil && r.MediaID == nil).
+				Error("alt text requires a file"),
+			validation.Length(0, maxAltTextLength),
+		),
+		validation.Field(&r.MediaID,
+			validation.Nil.When(r.File != nil).
+				Error("either upload a file or attach a media ID"),
+		),
 	)
 }
 
@@ -32,6 +44,11 @@ func (r *createPostReq) Sanitize() {
 		text := strings.TrimSpace(*r.Text)
 		r.Text = &text
 	}
+
+	if r.AltText != nil {
+		altText := strings.TrimSpace(*r.AltText)
+		r.AltText = &altText
+	}
 }
 
 // CreatePost handler
@@ -80,12 +97,35 @@ func (h *Handler) CreatePost(c *gin.Context) {
 		file, err := h.PostService.UploadFile(req.File)
 
 		if err != nil {
-			c.JSON(500, gin.H{
+			c.JSON(apperrors.Status(err), gin.H{
+				"error": err,
+			})
+			return
+		}
+
+		if req.AltText != nil {
+			file.AltText = *req.AltText
+		}
+
+		initial.File = file
+	}
+
+	if req.MediaID != nil {
+		file, err := h.MediaService.AttachUpload(authUser.ID, *req.MediaID)
+
+		if err != nil {
+			log.Printf("Failed to attach media: %v\n", err)
+
+			c.JSON(apperrors.Status(err), gin.H{
 				"error": err,
 			})
 			return
 		}
 
+		if req.AltText != nil {
+			file.AltText = *req.AltText
+		}
+
 		initial.File = file
 	}
 
@@ -100,5 +140,11 @@ func (h *Handler) CreatePost(c *gin.Context) {
 		return
 	}
 
+	if req.MediaID != nil {
+		if err := h.MediaService.ReleaseUpload(authUser.ID, *req.MediaID); err != nil {
+			log.Printf("Failed to release attached media: %v\n", err)
+		}
+	}
+
 	c.JSON(http.StatusCreated, post.NewPostResponse(""))
 }
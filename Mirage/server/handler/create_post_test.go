@@ -43,11 +43,13 @@ func TestHandler_CreatePost(t *testing.T) {
 	mockUserService.On("Get", uid).Return(mockUser, nil)
 
 	mockPostService := new(mocks.PostService)
+	mockMediaService := new(mocks.MediaService)
 
 	NewHandler(&Config{
 		R:            router,
 		UserService:  mockUserService,
 		PostService:  mockPostService,
+		MediaService: mockMediaService,
 		MaxBodyBytes: 4 * 1024 * 1024,
 	})
 
@@ -211,6 +213,66 @@ func TestHandler_CreatePost(t *testing.T) {
 		mockPostService.AssertCalled(t, "CreatePost", initial)
 		mockPostService.AssertCalled(t, "UploadFile", formFile)
 	})
+
+	t.Run("Media ID Post Creation Success", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+
+		mockPost := fixture.GetMockPost()
+		mockPost.User = *mockUser
+		mockPost.UserID = mockUser.ID
+
+		attachedFile := &model.File{
+			Url:      fixture.RandStringRunes(8),
+			FileType: "video/mp4",
+			Filename: "20.mp4",
+		}
+
+		form := url.Values{}
+		form.Add("mediaId", "10")
+		form.Add("altText", "A cat chasing a laser pointer")
+
+		request, _ := http.NewRequest(http.MethodPost, "/v1/posts", strings.NewReader(form.Encode()))
+		request.Form = form
+
+		mockMediaService.On("AttachUpload", uid, "10").Return(attachedFile, nil)
+
+		initial := &model.Post{
+			UserID: mockUser.ID,
+			User:   *mockUser,
+			File: &model.File{
+				Url:      attachedFile.Url,
+				FileType: "video/mp4",
+				Filename: "20.mp4",
+				AltText:  "A cat chasing a laser pointer",
+			},
+		}
+
+		mockPostService.On("CreatePost", initial).Return(mockPost, nil)
+		mockMediaService.On("ReleaseUpload", uid, "10").Return(nil)
+
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		mockMediaService.AssertExpectations(t)
+		mockPostService.AssertCalled(t, "CreatePost", initial)
+	})
+
+	t.Run("Unknown media ID", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+
+		form := url.Values{}
+		form.Add("text", "Hello")
+		form.Add("mediaId", "11")
+
+		request, _ := http.NewRequest(http.MethodPost, "/v1/posts", strings.NewReader(form.Encode()))
+		request.Form = form
+
+		mockMediaService.On("AttachUpload", uid, "11").Return(nil, apperrors.NewNotFound("media", "11"))
+
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
 }
 
 func TestHandler_CreatePost_BadRequests(t *testing.T) {
@@ -265,6 +327,13 @@ func TestHandler_CreatePost_BadRequests(t *testing.T) {
 				"text": {fixture.RandStringRunes(300)},
 			},
 		},
+		{
+			name: "Alt text without file",
+			body: map[string][]string{
+				"text":    {"Hello"},
+				"altText": {"A cat"},
+			},
+		},
 	}
 
 	for i := range testCases {
@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"log"
+	"net/http"
+	"strings"
+)
+
+type createSavedSearchReq struct {
+	Name  string           `json:"name"`
+	Query string           `json:"query"`
+	Kind  model.SearchKind `json:"kind"`
+}
+
+func (r createSavedSearchReq) Validate() error {
+	return validation.ValidateStruct(&r,
+		validation.Field(&r.Name, validation.Required, validation.Length(1, 50)),
+		validation.Field(&r.Query, validation.Required, validation.Length(1, 100)),
+		validation.Field(&r.Kind, validation.Required, validation.In(model.ValidSearchKinds...)),
+	)
+}
+
+func (r *createSavedSearchReq) Sanitize() {
+	r.Name = strings.TrimSpace(r.Name)
+	r.Query = strings.TrimSpace(r.Query)
+}
+
+// CreateSavedSearch handler saves a post or profile search of the current user
+func (h *Handler) CreateSavedSearch(c *gin.Context) {
+	userId := c.MustGet("userId").(string)
+
+	var req createSavedSearchReq
+
+	if ok := bindData(c, &req); !ok {
+		return
+	}
+
+	req.Sanitize()
+
+	search, err := h.SearchService.SaveSearch(userId, req.Name, req.Query, req.Kind)
+
+	if err != nil {
+		log.Printf("Failed to save search: %v\n", err)
+		c.JSON(apperrors.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, search.NewSavedSearchResponse())
+}
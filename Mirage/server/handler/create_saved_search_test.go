@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-contrib/sessions/cookie"
+	"github.com/gin-gonic/gin"
+	"github.com/sentrionic/mirage/mocks"
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"github.com/sentrionic/mirage/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHandler_CreateSavedSearch(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	uid, _ := service.GenerateId()
+
+	setupRouter := func(mockSearchService *mocks.SearchService) *gin.Engine {
+		router := gin.Default()
+		store := cookie.NewStore([]byte("secret"))
+		router.Use(sessions.Sessions("mqk", store))
+
+		router.Use(func(c *gin.Context) {
+			session := sessions.Default(c)
+			session.Set("userId", uid)
+			c.Set("userId", uid)
+		})
+
+		NewHandler(&Config{
+			R:             router,
+			SearchService: mockSearchService,
+		})
+
+		return router
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		search := &model.SavedSearch{
+			ID:            "1",
+			UserID:        uid,
+			Name:          "Go",
+			Query:         "#go OR #rust",
+			Kind:          model.SearchPosts,
+			LastVisitedAt: time.Now(),
+		}
+
+		mockSearchService := new(mocks.SearchService)
+		mockSearchService.On("SaveSearch", uid, "Go", "#go OR #rust", model.SearchPosts).Return(search, nil)
+
+		rr := httptest.NewRecorder()
+		router := setupRouter(mockSearchService)
+
+		reqBody, err := json.Marshal(gin.H{
+			"name":  " Go ",
+			"query": "#go OR #rust",
+			"kind":  "posts",
+		})
+		assert.NoError(t, err)
+
+		request, err := http.NewRequest(http.MethodPost, "/v1/accounts/searches", bytes.NewBuffer(reqBody))
+		assert.NoError(t, err)
+		request.Header.Set("Content-Type", "application/json")
+
+		router.ServeHTTP(rr, request)
+
+		respBody, err := json.Marshal(search.NewSavedSearchResponse())
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+		mockSearchService.AssertExpectations(t)
+	})
+
+	t.Run("Invalid kind", func(t *testing.T) {
+		mockSearchService := new(mocks.SearchService)
+
+		rr := httptest.NewRecorder()
+		router := setupRouter(mockSearchService)
+
+		reqBody, err := json.Marshal(gin.H{
+			"name":  "Go",
+			"query": "#go",
+			"kind":  "hashtags",
+		})
+		assert.NoError(t, err)
+
+		request, err := http.NewRequest(http.MethodPost, "/v1/accounts/searches", bytes.NewBuffer(reqBody))
+		assert.NoError(t, err)
+		request.Header.Set("Content-Type", "application/json")
+
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		mockSearchService.AssertNotCalled(t, "SaveSearch", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Too many searches", func(t *testing.T) {
+		respErr := apperrors.NewBadRequest("you can save at most 25 searches")
+
+		mockSearchService := new(mocks.SearchService)
+		mockSearchService.On("SaveSearch", uid, "Tong", "tong", model.SearchProfiles).Return(nil, respErr)
+
+		rr := httptest.NewRecorder()
+		router := setupRouter(mockSearchService)
+
+		reqBody, err := json.Marshal(gin.H{
+			"name":  "Tong",
+			"query": "tong",
+			"kind":  "profiles",
+		})
+		assert.NoError(t, err)
+
+		request, err := http.NewRequest(http.MethodPost, "/v1/accounts/searches", bytes.NewBuffer(reqBody))
+		assert.NoError(t, err)
+		request.Header.Set("Content-Type", "application/json")
+
+		router.ServeHTTP(rr, request)
+
+		respBody, err := json.Marshal(gin.H{
+			"error": respErr,
+		})
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+		mockSearchService.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"log"
+	"net/http"
+	"strings"
+)
+
+type createTokenReq struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+}
+
+// Validate checks the sanitized request, so that names
+// consisting only of whitespace are rejected
+func (r createTokenReq) Validate() error {
+	r.Sanitize()
+	return validation.ValidateStruct(&r,
+		validation.Field(&r.Name, validation.Required, validation.Length(1, 50)),
+		validation.Field(&r.Scopes, validation.Required, validation.Each(validation.In(model.ValidScopes...))),
+	)
+}
+
+func (r *createTokenReq) Sanitize() {
+	r.Name = strings.TrimSpace(r.Name)
+
+	// remove duplicate scopes
+	seen := make(map[string]bool)
+	scopes := make([]string, 0)
+	for _, s := range r.Scopes {
+		s = strings.ToLower(strings.TrimSpace(s))
+		if !seen[s] {
+			seen[s] = true
+			scopes = append(scopes, s)
+		}
+	}
+	r.Scopes = scopes
+}
+
+// CreateToken handler creates a personal access token.
+// The token is only returned in this response.
+func (h *Handler) CreateToken(c *gin.Context) {
+	userId := c.MustGet("userId").(string)
+
+	var req createTokenReq
+
+	if ok := bindData(c, &req); !ok {
+		return
+	}
+
+	req.Sanitize()
+
+	token, raw, err := h.TokenService.Create(userId, req.Name, req.Scopes)
+
+	if err != nil {
+		log.Printf("Failed to create token: %v\n", err)
+		c.JSON(apperrors.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, token.NewTokenResponse(raw))
+}
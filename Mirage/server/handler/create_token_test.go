@@ -0,0 +1,185 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-contrib/sessions/cookie"
+	"github.com/gin-gonic/gin"
+	"github.com/sentrionic/mirage/mocks"
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"github.com/sentrionic/mirage/model/fixture"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHandler_CreateToken(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	authUser := fixture.GetMockUser()
+
+	setupRouter := func(mockTokenService *mocks.TokenService) *gin.Engine {
+		router := gin.Default()
+		store := cookie.NewStore([]byte("secret"))
+		router.Use(sessions.Sessions("mqk", store))
+
+		router.Use(func(c *gin.Context) {
+			session := sessions.Default(c)
+			session.Set("userId", authUser.ID)
+		})
+
+		NewHandler(&Config{
+			R:            router,
+			TokenService: mockTokenService,
+		})
+
+		return router
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		raw := "mrg_" + fixture.RandStringRunes(43)
+		token := &model.AccessToken{
+			ID:        fixture.RandID(),
+			UserID:    authUser.ID,
+			Name:      "archive import",
+			Scopes:    []string{model.ScopeRead, model.ScopeImport},
+			CreatedAt: time.Now(),
+		}
+
+		mockTokenService := new(mocks.TokenService)
+		mockTokenService.
+			On("Create", authUser.ID, "archive import", []string{model.ScopeRead, model.ScopeImport}).
+			Return(token, raw, nil)
+
+		rr := httptest.NewRecorder()
+		router := setupRouter(mockTokenService)
+
+		reqBody, err := json.Marshal(gin.H{
+			"name":   " archive import ",
+			"scopes": []string{"read", "import", "read"},
+		})
+		assert.NoError(t, err)
+
+		request, err := http.NewRequest(http.MethodPost, "/v1/accounts/tokens", bytes.NewBuffer(reqBody))
+		assert.NoError(t, err)
+		request.Header.Set("Content-Type", "application/json")
+
+		router.ServeHTTP(rr, request)
+
+		respBody, err := json.Marshal(token.NewTokenResponse(raw))
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+		mockTokenService.AssertExpectations(t)
+	})
+
+	t.Run("Invalid scope", func(t *testing.T) {
+		mockTokenService := new(mocks.TokenService)
+
+		rr := httptest.NewRecorder()
+		router := setupRouter(mockTokenService)
+
+		reqBody, err := json.Marshal(gin.H{
+			"name":   "admin",
+			"scopes": []string{"admin"},
+		})
+		assert.NoError(t, err)
+
+		request, err := http.NewRequest(http.MethodPost, "/v1/accounts/tokens", bytes.NewBuffer(reqBody))
+		assert.NoError(t, err)
+		request.Header.Set("Content-Type", "application/json")
+
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		mockTokenService.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Blank name", func(t *testing.T) {
+		mockTokenService := new(mocks.TokenService)
+
+		rr := httptest.NewRecorder()
+		router := setupRouter(mockTokenService)
+
+		reqBody, err := json.Marshal(gin.H{
+			"name":   "    ",
+			"scopes": []string{"read"},
+		})
+		assert.NoError(t, err)
+
+		request, err := http.NewRequest(http.MethodPost, "/v1/accounts/tokens", bytes.NewBuffer(reqBody))
+		assert.NoError(t, err)
+		request.Header.Set("Content-Type", "application/json")
+
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		mockTokenService.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Tokens cannot create tokens", func(t *testing.T) {
+		raw := "mrg_" + fixture.RandStringRunes(43)
+		token := &model.AccessToken{ID: fixture.RandID(), UserID: authUser.ID, Scopes: []string{model.ScopeWrite}}
+
+		mockTokenService := new(mocks.TokenService)
+		mockTokenService.On("Authenticate", raw).Return(token, nil)
+
+		rr := httptest.NewRecorder()
+
+		router := gin.Default()
+		store := cookie.NewStore([]byte("secret"))
+		router.Use(sessions.Sessions("mqk", store))
+
+		NewHandler(&Config{
+			R:            router,
+			TokenService: mockTokenService,
+		})
+
+		reqBody, err := json.Marshal(gin.H{
+			"name":   "copy",
+			"scopes": []string{"write"},
+		})
+		assert.NoError(t, err)
+
+		request, err := http.NewRequest(http.MethodPost, "/v1/accounts/tokens", bytes.NewBuffer(reqBody))
+		assert.NoError(t, err)
+		request.Header.Set("Content-Type", "application/json")
+		request.Header.Set("Authorization", "Bearer "+raw)
+
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+		mockTokenService.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		mockTokenService := new(mocks.TokenService)
+		mockTokenService.
+			On("Create", authUser.ID, "script", []string{model.ScopeRead}).
+			Return(nil, "", apperrors.NewInternal())
+
+		rr := httptest.NewRecorder()
+		router := setupRouter(mockTokenService)
+
+		reqBody, err := json.Marshal(gin.H{
+			"name":   "script",
+			"scopes": []string{"read"},
+		})
+		assert.NoError(t, err)
+
+		request, err := http.NewRequest(http.MethodPost, "/v1/accounts/tokens", bytes.NewBuffer(reqBody))
+		assert.NoError(t, err)
+		request.Header.Set("Content-Type", "application/json")
+
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+		mockTokenService.AssertExpectations(t)
+	})
+}
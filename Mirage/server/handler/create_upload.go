@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"log"
+	"net/http"
+)
+
+type createUploadReq struct {
+	FileType string `json:"filetype"`
+	Size     int64  `json:"size"`
+}
+
+func (r createUploadReq) Validate() error {
+	return validation.ValidateStruct(&r,
+		validation.Field(&r.FileType, validation.Required),
+		validation.Field(&r.Size, validation.Required, validation.Min(int64(1))),
+	)
+}
+
+// CreateUpload handler returns a presigned request to upload
+// a media file directly to the storage. The returned media ID
+// gets attached to a post in CreatePost.
+func (h *Handler) CreateUpload(c *gin.Context) {
+	userId := c.MustGet("userId").(string)
+
+	var req createUploadReq
+
+	if ok := bindData(c, &req); !ok {
+		return
+	}
+
+	upload, target, err := h.MediaService.CreateUpload(userId, req.FileType, req.Size)
+
+	if err != nil {
+		log.Printf("Unable to create upload: %v\n", err)
+
+		c.JSON(apperrors.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, upload.NewUploadResponse(target))
+}
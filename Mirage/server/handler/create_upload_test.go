@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-contrib/sessions/cookie"
+	"github.com/gin-gonic/gin"
+	"github.com/sentrionic/mirage/mocks"
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"github.com/sentrionic/mirage/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHandler_CreateUpload(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	uid, _ := service.GenerateId()
+
+	newRequest := func(body gin.H) *http.Request {
+		reqBody, _ := json.Marshal(body)
+		request, _ := http.NewRequest(http.MethodPost, "/v1/media/uploads", bytes.NewBuffer(reqBody))
+		request.Header.Set("Content-Type", "application/json")
+		return request
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		upload := &model.Upload{
+			ID:        "10",
+			UserID:    uid,
+			Key:       "files/uploads/10.mp4",
+			FileType:  "video/mp4",
+			Size:      1 << 20,
+			ExpiresAt: time.Now().Add(time.Hour),
+		}
+		target := &model.UploadTarget{
+			URL:     "https://bucket.s3.amazonaws.com/files/uploads/10.mp4?X-Amz-Signature=abc",
+			Method:  http.MethodPut,
+			Headers: map[string]string{"Content-Type": "video/mp4"},
+		}
+
+		mockMediaService := new(mocks.MediaService)
+		mockMediaService.On("CreateUpload", uid, "video/mp4", int64(1<<20)).Return(upload, target, nil)
+
+		rr := httptest.NewRecorder()
+		router := gin.Default()
+		store := cookie.NewStore([]byte("secret"))
+		router.Use(sessions.Sessions("mqk", store))
+
+		router.Use(func(c *gin.Context) {
+			session := sessions.Default(c)
+			session.Set("userId", uid)
+		})
+
+		NewHandler(&Config{
+			R:            router,
+			MediaService: mockMediaService,
+			MaxBodyBytes: 4 * 1024 * 1024,
+		})
+
+		router.ServeHTTP(rr, newRequest(gin.H{"filetype": "video/mp4", "size": 1 << 20}))
+
+		respBody, err := json.Marshal(upload.NewUploadResponse(target))
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+		mockMediaService.AssertExpectations(t)
+	})
+
+	t.Run("Missing size", func(t *testing.T) {
+		mockMediaService := new(mocks.MediaService)
+
+		rr := httptest.NewRecorder()
+		router := gin.Default()
+		store := cookie.NewStore([]byte("secret"))
+		router.Use(sessions.Sessions("mqk", store))
+
+		router.Use(func(c *gin.Context) {
+			session := sessions.Default(c)
+			session.Set("userId", uid)
+		})
+
+		NewHandler(&Config{
+			R:            router,
+			MediaService: mockMediaService,
+			MaxBodyBytes: 4 * 1024 * 1024,
+		})
+
+		router.ServeHTTP(rr, newRequest(gin.H{"filetype": "video/mp4"}))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		mockMediaService.AssertNotCalled(t, "CreateUpload", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("File too large", func(t *testing.T) {
+		mockMediaService := new(mocks.MediaService)
+		mockMediaService.
+			On("CreateUpload", uid, "video/mp4", int64(1<<30)).
+			Return(nil, nil, apperrors.NewPayloadTooLarge(512<<20, 1<<30))
+
+		rr := httptest.NewRecorder()
+		router := gin.Default()
+		store := cookie.NewStore([]byte("secret"))
+		router.Use(sessions.Sessions("mqk", store))
+
+		router.Use(func(c *gin.Context) {
+			session := sessions.Default(c)
+			session.Set("userId", uid)
+		})
+
+		NewHandler(&Config{
+			R:            router,
+			MediaService: mockMediaService,
+			MaxBodyBytes: 4 * 1024 * 1024,
+		})
+
+		router.ServeHTTP(rr, newRequest(gin.H{"filetype": "video/mp4", "size": 1 << 30}))
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+	})
+}
@@ -0,0 +1,26 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"log"
+	"net/http"
+)
+
+// DeleteSavedSearch handler removes the saved search with the given ID
+func (h *Handler) DeleteSavedSearch(c *gin.Context) {
+	userId := c.MustGet("userId").(string)
+	searchId := c.Param("id")
+
+	err := h.SearchService.DeleteSavedSearch(userId, searchId)
+
+	if err != nil {
+		log.Printf("Unable to delete saved search: %v\n%v", searchId, err)
+		c.JSON(apperrors.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, true)
+}
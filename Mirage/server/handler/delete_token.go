@@ -0,0 +1,26 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"log"
+	"net/http"
+)
+
+// DeleteToken handler revokes the access token with the given ID
+func (h *Handler) DeleteToken(c *gin.Context) {
+	userId := c.MustGet("userId").(string)
+	tokenId := c.Param("id")
+
+	err := h.TokenService.Revoke(userId, tokenId)
+
+	if err != nil {
+		log.Printf("Unable to revoke token: %v\n%v", tokenId, err)
+		c.JSON(apperrors.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, true)
+}
@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-contrib/sessions/cookie"
+	"github.com/gin-gonic/gin"
+	"github.com/sentrionic/mirage/mocks"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"github.com/sentrionic/mirage/model/fixture"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandler_DeleteToken(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	authUser := fixture.GetMockUser()
+	tokenId := fixture.RandID()
+
+	setupRouter := func(mockTokenService *mocks.TokenService) *gin.Engine {
+		router := gin.Default()
+		store := cookie.NewStore([]byte("secret"))
+		router.Use(sessions.Sessions("mqk", store))
+
+		router.Use(func(c *gin.Context) {
+			session := sessions.Default(c)
+			session.Set("userId", authUser.ID)
+		})
+
+		NewHandler(&Config{
+			R:            router,
+			TokenService: mockTokenService,
+		})
+
+		return router
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		mockTokenService := new(mocks.TokenService)
+		mockTokenService.On("Revoke", authUser.ID, tokenId).Return(nil)
+
+		rr := httptest.NewRecorder()
+		router := setupRouter(mockTokenService)
+
+		request, err := http.NewRequest(http.MethodDelete, "/v1/accounts/tokens/"+tokenId, nil)
+		assert.NoError(t, err)
+
+		router.ServeHTTP(rr, request)
+
+		respBody, _ := json.Marshal(true)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+		mockTokenService.AssertExpectations(t)
+	})
+
+	t.Run("Not Found", func(t *testing.T) {
+		mockTokenService := new(mocks.TokenService)
+		mockTokenService.On("Revoke", authUser.ID, tokenId).Return(apperrors.NewNotFound("token", tokenId))
+
+		rr := httptest.NewRecorder()
+		router := setupRouter(mockTokenService)
+
+		request, err := http.NewRequest(http.MethodDelete, "/v1/accounts/tokens/"+tokenId, nil)
+		assert.NoError(t, err)
+
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		mockTokenService.AssertExpectations(t)
+	})
+}
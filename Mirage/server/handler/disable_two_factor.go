@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"log"
+	"net/http"
+	"strings"
+)
+
+type disableTwoFactorReq struct {
+	Password string `json:"password"`
+	Code     string `json:"code"`
+}
+
+func (r disableTwoFactorReq) Validate() error {
+	return validation.ValidateStruct(&r,
+		validation.Field(&r.Password, validation.Required, validation.Length(6, 150)),
+		validation.Field(&r.Code, validation.Required, validation.Length(6, 20)),
+	)
+}
+
+func (r *disableTwoFactorReq) Sanitize() {
+	r.Password = strings.TrimSpace(r.Password)
+	r.Code = strings.TrimSpace(r.Code)
+}
+
+// DisableTwoFactor handler turns off two-factor authentication.
+// It requires the current password and a two-factor or recovery code.
+func (h *Handler) DisableTwoFactor(c *gin.Context) {
+	userId := c.MustGet("userId").(string)
+
+	var req disableTwoFactorReq
+
+	if ok := bindData(c, &req); !ok {
+		return
+	}
+
+	req.Sanitize()
+
+	authUser, err := h.UserService.Get(userId)
+
+	if err != nil {
+		err := errors.New("provided session is invalid")
+		c.JSON(401, gin.H{
+			"error": err,
+		})
+		c.Abort()
+		return
+	}
+
+	if err := h.UserService.DisableTwoFactor(authUser, req.Password, req.Code); err != nil {
+		log.Printf("Failed to disable two-factor authentication: %v\n", err)
+		c.JSON(apperrors.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, authUser.NewAccountResponse())
+}
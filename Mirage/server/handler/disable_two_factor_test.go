@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-contrib/sessions/cookie"
+	"github.com/gin-gonic/gin"
+	"github.com/sentrionic/mirage/mocks"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"github.com/sentrionic/mirage/model/fixture"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandler_DisableTwoFactor(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	authUser := fixture.GetMockUser()
+
+	setupRouter := func(mockUserService *mocks.UserService) *gin.Engine {
+		router := gin.Default()
+		store := cookie.NewStore([]byte("secret"))
+		router.Use(sessions.Sessions("mqk", store))
+
+		router.Use(func(c *gin.Context) {
+			session := sessions.Default(c)
+			session.Set("userId", authUser.ID)
+		})
+
+		NewHandler(&Config{
+			R:           router,
+			UserService: mockUserService,
+		})
+
+		return router
+	}
+
+	sendRequest := func(router *gin.Engine, password, code string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+
+		reqBody, err := json.Marshal(gin.H{"password": password, "code": code})
+		assert.NoError(t, err)
+
+		request, err := http.NewRequest(http.MethodDelete, "/v1/accounts/2fa", bytes.NewBuffer(reqBody))
+		assert.NoError(t, err)
+
+		request.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(rr, request)
+
+		return rr
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		mockUserService := new(mocks.UserService)
+		mockUserService.On("Get", authUser.ID).Return(authUser, nil)
+		mockUserService.On("DisableTwoFactor", authUser, "password", "123456").Return(nil)
+
+		router := setupRouter(mockUserService)
+		rr := sendRequest(router, "password", " 123456 ")
+
+		respBody, err := json.Marshal(authUser.NewAccountResponse())
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+		mockUserService.AssertExpectations(t)
+	})
+
+	t.Run("Invalid code", func(t *testing.T) {
+		mockUserService := new(mocks.UserService)
+		mockUserService.On("Get", authUser.ID).Return(authUser, nil)
+		mockUserService.
+			On("DisableTwoFactor", authUser, "password", "000000").
+			Return(apperrors.NewBadRequest("invalid two-factor code"))
+
+		router := setupRouter(mockUserService)
+		rr := sendRequest(router, "password", "000000")
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		mockUserService.AssertExpectations(t)
+	})
+
+	t.Run("Missing code", func(t *testing.T) {
+		mockUserService := new(mocks.UserService)
+
+		router := setupRouter(mockUserService)
+		rr := sendRequest(router, "password", "")
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		mockUserService.AssertNotCalled(t, "DisableTwoFactor", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Missing password", func(t *testing.T) {
+		mockUserService := new(mocks.UserService)
+
+		router := setupRouter(mockUserService)
+		rr := sendRequest(router, "", "123456")
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		mockUserService.AssertNotCalled(t, "DisableTwoFactor", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Wrong password", func(t *testing.T) {
+		mockUserService := new(mocks.UserService)
+		mockUserService.On("Get", authUser.ID).Return(authUser, nil)
+		mockUserService.
+			On("DisableTwoFactor", authUser, "wrong password", "123456").
+			Return(apperrors.NewBadRequest("invalid password"))
+
+		router := setupRouter(mockUserService)
+		rr := sendRequest(router, "wrong password", "123456")
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		mockUserService.AssertExpectations(t)
+	})
+}
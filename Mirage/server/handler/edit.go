@@ -71,10 +71,13 @@ func (h *Handler) EditAccount(c *gin.Context) {
 	authUser.DisplayName = req.DisplayName
 	authUser.Bio = req.Bio
 
-	if req.Image != nil {
+	// Validate image mime-types are allowable
+	for _, image := range []*multipart.FileHeader{req.Image, req.Banner} {
+		if image == nil {
+			continue
+		}
 
-		// Validate image mime-type is allowable
-		mimeType := req.Image.Header.Get("Content-Type")
+		mimeType := image.Header.Get("Content-Type")
 
 		if valid := isAllowedImageType(mimeType); !valid {
 			e := apperrors.NewBadRequest("image must be 'image/jpeg' or 'image/png'")
@@ -83,60 +86,41 @@ func (h *Handler) EditAccount(c *gin.Context) {
 			})
 			return
 		}
+	}
+
+	err = h.UserService.Update(authUser)
+
+	if err != nil {
+		log.Printf("Failed to update user: %v\n", err)
+
+		c.JSON(apperrors.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
 
+	// images are changed after the update, so saving the
+	// profile can't overwrite an image set by a job
+	if req.Image != nil {
 		directory := fmt.Sprintf("profile_images/%s", authUser.ID)
-		url, err := h.UserService.ChangeAvatar(req.Image, directory)
 
-		if err != nil {
+		if err := h.UserService.ChangeAvatar(authUser, req.Image, directory); err != nil {
 			c.JSON(500, gin.H{
 				"error": err,
 			})
 			return
 		}
-
-		_ = h.UserService.DeleteImage(authUser.Image)
-
-		authUser.Image = url
 	}
 
 	if req.Banner != nil {
-		// Validate image mime-type is allowable
-		mimeType := req.Banner.Header.Get("Content-Type")
-
-		if valid := isAllowedImageType(mimeType); !valid {
-			e := apperrors.NewBadRequest("image must be 'image/jpeg' or 'image/png'")
-			c.JSON(e.Status(), gin.H{
-				"error": e,
-			})
-			return
-		}
-
 		directory := fmt.Sprintf("header_photo/%s", authUser.ID)
-		url, err := h.UserService.ChangeBanner(req.Banner, directory)
 
-		if err != nil {
+		if err := h.UserService.ChangeBanner(authUser, req.Banner, directory); err != nil {
 			c.JSON(500, gin.H{
 				"error": err,
 			})
 			return
 		}
-
-		if authUser.Banner != nil {
-			_ = h.UserService.DeleteImage(authUser.Image)
-		}
-
-		authUser.Banner = &url
-	}
-
-	err = h.UserService.Update(authUser)
-
-	if err != nil {
-		log.Printf("Failed to update user: %v\n", err)
-
-		c.JSON(apperrors.Status(err), gin.H{
-			"error": err,
-		})
-		return
 	}
 
 	c.JSON(http.StatusOK, authUser.NewAccountResponse())
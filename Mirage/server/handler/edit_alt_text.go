@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// maxAltTextLength is the longest description an attachment can have
+const maxAltTextLength = 1000
+
+type editAltTextReq struct {
+	AltText string `json:"altText"`
+}
+
+func (r editAltTextReq) Validate() error {
+	return validation.ValidateStruct(&r,
+		validation.Field(&r.AltText, validation.Length(0, maxAltTextLength)),
+	)
+}
+
+func (r *editAltTextReq) Sanitize() {
+	r.AltText = strings.TrimSpace(r.AltText)
+}
+
+// EditAltText handler changes the description of the post's attachment.
+// An empty alt text removes it.
+func (h *Handler) EditAltText(c *gin.Context) {
+	postId := c.Param("id")
+
+	userId := c.MustGet("userId").(string)
+
+	var req editAltTextReq
+
+	if ok := bindData(c, &req); !ok {
+		return
+	}
+
+	req.Sanitize()
+
+	post, err := h.PostService.FindPostByID(postId)
+
+	if err != nil || post.File == nil {
+		log.Printf("Unable to find post attachment: %v\n%v", postId, err)
+		e := apperrors.NewNotFound("post", postId)
+
+		c.JSON(e.Status(), gin.H{
+			"error": e,
+		})
+		return
+	}
+
+	if post.UserID != userId {
+		e := apperrors.NewAuthorization("you are not the owner")
+
+		c.JSON(e.Status(), gin.H{
+			"error": e,
+		})
+		return
+	}
+
+	err = h.PostService.UpdateAltText(post, req.AltText)
+
+	if err != nil {
+		log.Printf("Unable to update alt text: %v\n%v", postId, err)
+
+		c.JSON(apperrors.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, post.NewPostResponse(userId))
+}
@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-contrib/sessions/cookie"
+	"github.com/gin-gonic/gin"
+	"github.com/sentrionic/mirage/mocks"
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/fixture"
+	"github.com/sentrionic/mirage/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandler_EditAltText(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	uid, _ := service.GenerateId()
+
+	setupRouter := func(mockPostService *mocks.PostService) *gin.Engine {
+		router := gin.Default()
+		store := cookie.NewStore([]byte("secret"))
+		router.Use(sessions.Sessions("mqk", store))
+
+		router.Use(func(c *gin.Context) {
+			session := sessions.Default(c)
+			session.Set("userId", uid)
+			c.Set("userId", uid)
+		})
+
+		NewHandler(&Config{
+			R:            router,
+			PostService:  mockPostService,
+			MaxBodyBytes: 4 * 1024 * 1024,
+		})
+
+		return router
+	}
+
+	newRequest := func(postId, altText string) *http.Request {
+		reqBody, _ := json.Marshal(gin.H{"altText": altText})
+		request, _ := http.NewRequest(http.MethodPut, "/v1/posts/"+postId+"/alt", bytes.NewBuffer(reqBody))
+		request.Header.Set("Content-Type", "application/json")
+		return request
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		mockPost := fixture.GetMockPost()
+		mockPost.UserID = uid
+		mockPost.File = &model.File{PostId: mockPost.ID, FileType: "image/png"}
+
+		mockPostService := new(mocks.PostService)
+		mockPostService.On("FindPostByID", mockPost.ID).Return(mockPost, nil)
+		mockPostService.
+			On("UpdateAltText", mockPost, "A cat sleeping on a keyboard").
+			Run(func(args mock.Arguments) {
+				mockPost.File.AltText = args.String(1)
+			}).
+			Return(nil)
+
+		rr := httptest.NewRecorder()
+		router := setupRouter(mockPostService)
+
+		router.ServeHTTP(rr, newRequest(mockPost.ID, "  A cat sleeping on a keyboard "))
+
+		respBody, err := json.Marshal(mockPost.NewPostResponse(uid))
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+		mockPostService.AssertExpectations(t)
+	})
+
+	t.Run("Not the owner of the post", func(t *testing.T) {
+		mockPost := fixture.GetMockPost()
+		mockPost.File = &model.File{PostId: mockPost.ID, FileType: "image/png"}
+
+		mockPostService := new(mocks.PostService)
+		mockPostService.On("FindPostByID", mockPost.ID).Return(mockPost, nil)
+
+		rr := httptest.NewRecorder()
+		router := setupRouter(mockPostService)
+
+		router.ServeHTTP(rr, newRequest(mockPost.ID, "A cat"))
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		mockPostService.AssertNotCalled(t, "UpdateAltText", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Post without file", func(t *testing.T) {
+		mockPost := fixture.GetMockPost()
+		mockPost.UserID = uid
+
+		mockPostService := new(mocks.PostService)
+		mockPostService.On("FindPostByID", mockPost.ID).Return(mockPost, nil)
+
+		rr := httptest.NewRecorder()
+		router := setupRouter(mockPostService)
+
+		router.ServeHTTP(rr, newRequest(mockPost.ID, "A cat"))
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		mockPostService.AssertNotCalled(t, "UpdateAltText", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Alt text too long", func(t *testing.T) {
+		mockPost := fixture.GetMockPost()
+
+		mockPostService := new(mocks.PostService)
+
+		rr := httptest.NewRecorder()
+		router := setupRouter(mockPostService)
+
+		router.ServeHTTP(rr, newRequest(mockPost.ID, fixture.RandStringRunes(1001)))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		mockPostService.AssertNotCalled(t, "FindPostByID", mock.Anything)
+	})
+}
@@ -13,6 +13,7 @@ import (
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"net/textproto"
 	"net/url"
 	"strings"
 	"testing"
@@ -130,6 +131,72 @@ func TestHandler_EditAccount(t *testing.T) {
 		mockUserService.AssertCalled(t, "Update", updateArgs...)
 	})
 
+	t.Run("Avatar changed after update", func(t *testing.T) {
+		router := gin.Default()
+		store := cookie.NewStore([]byte("secret"))
+		router.Use(sessions.Sessions("mqk", store))
+
+		router.Use(func(c *gin.Context) {
+			c.Set("userId", uid)
+			session := sessions.Default(c)
+			session.Set("userId", uid)
+		})
+
+		mockUserService := new(mocks.UserService)
+		mockUserService.On("Get", uid).Return(mockUser, nil)
+
+		NewHandler(&Config{
+			R:            router,
+			UserService:  mockUserService,
+			MaxBodyBytes: 4 * 1024 * 1024,
+		})
+
+		rr := httptest.NewRecorder()
+
+		body := &bytes.Buffer{}
+		writer := multipart.NewWriter(body)
+		_ = writer.WriteField("username", mockUser.Username)
+		_ = writer.WriteField("email", mockUser.Email)
+		_ = writer.WriteField("displayName", mockUser.DisplayName)
+
+		h := make(textproto.MIMEHeader)
+		h.Set("Content-Disposition", `form-data; name="image"; filename="image.png"`)
+		h.Set("Content-Type", "image/png")
+		part, _ := writer.CreatePart(h)
+		_, _ = part.Write([]byte("image"))
+
+		_ = writer.Close()
+
+		request, _ := http.NewRequest(http.MethodPut, "/v1/accounts", body)
+		request.Header.Set("Content-Type", writer.FormDataContentType())
+
+		var calls []string
+		mockUserService.
+			On("Update", mockUser).
+			Run(func(args mock.Arguments) {
+				calls = append(calls, "Update")
+			}).
+			Return(nil)
+		mockUserService.
+			On("ChangeAvatar", mockUser, mock.AnythingOfType("*multipart.FileHeader"), "profile_images/"+uid).
+			Run(func(args mock.Arguments) {
+				calls = append(calls, "ChangeAvatar")
+				args.Get(0).(*model.User).ImageJobID = "10"
+			}).
+			Return(nil)
+
+		router.ServeHTTP(rr, request)
+
+		var response model.AccountResponse
+		_ = json.Unmarshal(rr.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "10", response.ImageJobID)
+		// the update must not overwrite the image set by the job
+		assert.Equal(t, []string{"Update", "ChangeAvatar"}, calls)
+		mockUserService.AssertNotCalled(t, "ChangeBanner", mock.Anything, mock.Anything, mock.Anything)
+	})
+
 	t.Run("Update Failure", func(t *testing.T) {
 		router := gin.Default()
 		store := cookie.NewStore([]byte("secret"))
@@ -211,7 +278,8 @@ func TestHandler_EditAccount(t *testing.T) {
 
 		assert.Equal(t, http.StatusBadRequest, rr.Code)
 
-		mockUserService.AssertNotCalled(t, "ChangeAvatar")
+		mockUserService.AssertNotCalled(t, "ChangeAvatar", mock.Anything, mock.Anything, mock.Anything)
+		mockUserService.AssertNotCalled(t, "Update", mock.Anything)
 	})
 }
 
@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// passwordReq is used by endpoints that require the current password
+type passwordReq struct {
+	Password string `json:"password"`
+}
+
+func (r passwordReq) Validate() error {
+	return validation.ValidateStruct(&r,
+		validation.Field(&r.Password, validation.Required, validation.Length(6, 150)),
+	)
+}
+
+func (r *passwordReq) Sanitize() {
+	r.Password = strings.TrimSpace(r.Password)
+}
+
+// EnrollTwoFactor handler starts setting up two-factor authentication.
+// It requires the current password and returns the secret, the
+// provisioning URI for authenticator apps and the recovery codes.
+func (h *Handler) EnrollTwoFactor(c *gin.Context) {
+	userId := c.MustGet("userId").(string)
+
+	var req passwordReq
+
+	if ok := bindData(c, &req); !ok {
+		return
+	}
+
+	req.Sanitize()
+
+	authUser, err := h.UserService.Get(userId)
+
+	if err != nil {
+		err := errors.New("provided session is invalid")
+		c.JSON(401, gin.H{
+			"error": err,
+		})
+		c.Abort()
+		return
+	}
+
+	setup, err := h.UserService.EnrollTwoFactor(authUser, req.Password)
+
+	if err != nil {
+		log.Printf("Failed to enroll two-factor authentication: %v\n", err)
+		c.JSON(apperrors.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, setup)
+}
@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-contrib/sessions/cookie"
+	"github.com/gin-gonic/gin"
+	"github.com/sentrionic/mirage/mocks"
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"github.com/sentrionic/mirage/model/fixture"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandler_EnrollTwoFactor(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	authUser := fixture.GetMockUser()
+
+	setupRouter := func(mockUserService *mocks.UserService) *gin.Engine {
+		router := gin.Default()
+		store := cookie.NewStore([]byte("secret"))
+		router.Use(sessions.Sessions("mqk", store))
+
+		router.Use(func(c *gin.Context) {
+			session := sessions.Default(c)
+			session.Set("userId", authUser.ID)
+		})
+
+		NewHandler(&Config{
+			R:           router,
+			UserService: mockUserService,
+		})
+
+		return router
+	}
+
+	sendRequest := func(router *gin.Engine, password string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+
+		reqBody, err := json.Marshal(gin.H{"password": password})
+		assert.NoError(t, err)
+
+		request, err := http.NewRequest(http.MethodPost, "/v1/accounts/2fa", bytes.NewBuffer(reqBody))
+		assert.NoError(t, err)
+
+		request.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(rr, request)
+
+		return rr
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		setup := &model.TwoFactorSetup{
+			Secret:        "JBSWY3DPEHPK3PXP",
+			URI:           "otpauth://totp/Mirage:bob?secret=JBSWY3DPEHPK3PXP",
+			RecoveryCodes: []string{"abcde-fghij"},
+		}
+
+		mockUserService := new(mocks.UserService)
+		mockUserService.On("Get", authUser.ID).Return(authUser, nil)
+		mockUserService.On("EnrollTwoFactor", authUser, "password").Return(setup, nil)
+
+		router := setupRouter(mockUserService)
+		rr := sendRequest(router, " password ")
+
+		respBody, err := json.Marshal(setup)
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+		mockUserService.AssertExpectations(t)
+	})
+
+	t.Run("Already enabled", func(t *testing.T) {
+		mockUserService := new(mocks.UserService)
+		mockUserService.On("Get", authUser.ID).Return(authUser, nil)
+		mockUserService.
+			On("EnrollTwoFactor", authUser, "password").
+			Return(nil, apperrors.NewBadRequest("two-factor authentication is already enabled"))
+
+		router := setupRouter(mockUserService)
+		rr := sendRequest(router, " password ")
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		mockUserService.AssertExpectations(t)
+	})
+
+	t.Run("Missing password", func(t *testing.T) {
+		mockUserService := new(mocks.UserService)
+
+		router := setupRouter(mockUserService)
+		rr := sendRequest(router, "")
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		mockUserService.AssertNotCalled(t, "EnrollTwoFactor", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Wrong password", func(t *testing.T) {
+		mockUserService := new(mocks.UserService)
+		mockUserService.On("Get", authUser.ID).Return(authUser, nil)
+		mockUserService.
+			On("EnrollTwoFactor", authUser, "wrong password").
+			Return(nil, apperrors.NewBadRequest("invalid password"))
+
+		router := setupRouter(mockUserService)
+		rr := sendRequest(router, "wrong password")
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		mockUserService.AssertExpectations(t)
+	})
+}
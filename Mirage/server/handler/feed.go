@@ -7,13 +7,30 @@ import (
 	"net/http"
 )
 
+// Feed handler returns the current user's home timeline. The "mode" query
+// selects the ranking, either "latest" (the default) or "top". The latest
+// feed can be limited to a time range with the "since" and "until" queries.
 func (h *Handler) Feed(c *gin.Context) {
 	authUser := c.MustGet("userId").(string)
 	cursor := c.Query("cursor")
+	mode := model.FeedMode(c.Query("mode"))
 
-	posts, err := h.PostService.GetUserFeed(authUser, cursor)
+	timeRange, ok := bindTimeRange(c)
+
+	if !ok {
+		return
+	}
+
+	posts, next, err := h.PostService.GetUserFeed(authUser, mode, cursor, timeRange)
 
 	if err != nil {
+		if apperrors.Status(err) == http.StatusBadRequest {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err,
+			})
+			return
+		}
+
 		e := apperrors.NewNotFound("feed", authUser)
 
 		c.JSON(e.Status(), gin.H{
@@ -22,19 +39,26 @@ func (h *Handler) Feed(c *gin.Context) {
 		return
 	}
 
-	response := make([]model.PostResponse, 0)
+	response := make([]model.TimelineItemResponse, 0)
 
 	if len(*posts) > 0 {
 		for i, p := range *posts {
 			if i != model.LIMIT {
-				post := p.NewFeedResponse(authUser)
+				post := p.NewTimelineItemResponse(authUser)
 				response = append(response, post)
 			}
 		}
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	body := gin.H{
 		"posts":   response,
-		"hasMore": len(*posts) == model.LIMIT+1,
-	})
+		"hasMore": len(*posts) == model.LIMIT+1 || next != "",
+	}
+
+	// the top feed can't be paged by the time of the last post
+	if next != "" {
+		body["nextCursor"] = next
+	}
+
+	c.JSON(http.StatusOK, body)
 }
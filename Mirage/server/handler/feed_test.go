@@ -31,7 +31,7 @@ func TestHandler_Feed(t *testing.T) {
 
 	t.Run("Success", func(t *testing.T) {
 		mockPostService := new(mocks.PostService)
-		mockPostService.On("GetUserFeed", authUser.ID, "").Return(&profile.Posts, nil)
+		mockPostService.On("GetUserFeed", authUser.ID, model.FeedMode(""), "", model.TimeRange{}).Return(&profile.Posts, "", nil)
 
 		// a response recorder for getting written http response
 		rr := httptest.NewRecorder()
@@ -56,10 +56,10 @@ func TestHandler_Feed(t *testing.T) {
 
 		router.ServeHTTP(rr, request)
 
-		rsp := make([]model.PostResponse, 0)
+		rsp := make([]model.TimelineItemResponse, 0)
 
 		for _, p := range profile.Posts {
-			post := p.NewFeedResponse(authUser.ID)
+			post := p.NewTimelineItemResponse(authUser.ID)
 			rsp = append(rsp, post)
 		}
 
@@ -76,7 +76,7 @@ func TestHandler_Feed(t *testing.T) {
 
 	t.Run("Unauthorized", func(t *testing.T) {
 		mockPostService := new(mocks.PostService)
-		mockPostService.On("GetUserFeed", authUser.ID, "").Return(&profile.Posts, nil)
+		mockPostService.On("GetUserFeed", authUser.ID, model.FeedMode(""), "", model.TimeRange{}).Return(&profile.Posts, "", nil)
 
 		// a response recorder for getting written http response
 		rr := httptest.NewRecorder()
@@ -99,12 +99,12 @@ func TestHandler_Feed(t *testing.T) {
 
 		assert.Equal(t, http.StatusUnauthorized, rr.Code)
 
-		mockPostService.AssertNotCalled(t, "GetUserFeed", authUser.ID, "")
+		mockPostService.AssertNotCalled(t, "GetUserFeed", authUser.ID, model.FeedMode(""), "", model.TimeRange{})
 	})
 
 	t.Run("Error", func(t *testing.T) {
 		mockPostService := new(mocks.PostService)
-		mockPostService.On("GetUserFeed", authUser.ID, "").Return(nil, fmt.Errorf("some error down call chain"))
+		mockPostService.On("GetUserFeed", authUser.ID, model.FeedMode(""), "", model.TimeRange{}).Return(nil, "", fmt.Errorf("some error down call chain"))
 
 		// a response recorder for getting written http response
 		rr := httptest.NewRecorder()
@@ -140,4 +140,79 @@ func TestHandler_Feed(t *testing.T) {
 		assert.Equal(t, respBody, rr.Body.Bytes())
 		mockPostService.AssertExpectations(t)
 	})
+
+	t.Run("Top mode", func(t *testing.T) {
+		posts := make([]model.Post, 0)
+		for i := 0; i < model.LIMIT+1; i++ {
+			posts = append(posts, *fixture.GetMockPost())
+		}
+
+		mockPostService := new(mocks.PostService)
+		mockPostService.On("GetUserFeed", authUser.ID, model.FeedTop, "20", model.TimeRange{}).Return(&posts, "40", nil)
+
+		rr := httptest.NewRecorder()
+
+		router := gin.Default()
+		store := cookie.NewStore([]byte("secret"))
+		router.Use(sessions.Sessions("mqk", store))
+
+		router.Use(func(c *gin.Context) {
+			session := sessions.Default(c)
+			session.Set("userId", authUser.ID)
+			c.Set("userId", authUser.ID)
+		})
+
+		NewHandler(&Config{
+			R:           router,
+			PostService: mockPostService,
+		})
+
+		request, err := http.NewRequest(http.MethodGet, "/v1/posts/feed?mode=top&cursor=20", nil)
+		assert.NoError(t, err)
+
+		router.ServeHTTP(rr, request)
+
+		var body struct {
+			Posts      []model.PostResponse `json:"posts"`
+			HasMore    bool                 `json:"hasMore"`
+			NextCursor string               `json:"nextCursor"`
+		}
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Len(t, body.Posts, model.LIMIT)
+		assert.True(t, body.HasMore)
+		assert.Equal(t, "40", body.NextCursor)
+		mockPostService.AssertExpectations(t)
+	})
+
+	t.Run("Invalid mode", func(t *testing.T) {
+		mockPostService := new(mocks.PostService)
+		mockPostService.On("GetUserFeed", authUser.ID, model.FeedMode("random"), "", model.TimeRange{}).Return(nil, "", apperrors.NewBadRequest("invalid feed mode"))
+
+		rr := httptest.NewRecorder()
+
+		router := gin.Default()
+		store := cookie.NewStore([]byte("secret"))
+		router.Use(sessions.Sessions("mqk", store))
+
+		router.Use(func(c *gin.Context) {
+			session := sessions.Default(c)
+			session.Set("userId", authUser.ID)
+			c.Set("userId", authUser.ID)
+		})
+
+		NewHandler(&Config{
+			R:           router,
+			PostService: mockPostService,
+		})
+
+		request, err := http.NewRequest(http.MethodGet, "/v1/posts/feed?mode=random", nil)
+		assert.NoError(t, err)
+
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		mockPostService.AssertExpectations(t)
+	})
 }
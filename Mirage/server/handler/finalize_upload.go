@@ -0,0 +1,28 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"log"
+	"net/http"
+)
+
+// FinalizeUpload handler completes a chunked upload. The
+// returned media ID gets attached to a post in CreatePost.
+func (h *Handler) FinalizeUpload(c *gin.Context) {
+	userId := c.MustGet("userId").(string)
+	mediaId := c.Param("id")
+
+	upload, err := h.MediaService.FinalizeChunkedUpload(userId, mediaId)
+
+	if err != nil {
+		log.Printf("Unable to finalize upload: %v\n%v", mediaId, err)
+
+		c.JSON(apperrors.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, upload.NewChunkedUploadResponse())
+}
@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-contrib/sessions/cookie"
+	"github.com/gin-gonic/gin"
+	"github.com/sentrionic/mirage/mocks"
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"github.com/sentrionic/mirage/service"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandler_FinalizeUpload(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	uid, _ := service.GenerateId()
+
+	t.Run("Success", func(t *testing.T) {
+		upload := &model.ChunkedUpload{ID: "10", UserID: uid, Size: 100, NextChunk: 1, Received: 100}
+
+		mockMediaService := new(mocks.MediaService)
+		mockMediaService.On("FinalizeChunkedUpload", uid, "10").Return(upload, nil)
+
+		rr := httptest.NewRecorder()
+		router := gin.Default()
+		store := cookie.NewStore([]byte("secret"))
+		router.Use(sessions.Sessions("mqk", store))
+
+		router.Use(func(c *gin.Context) {
+			session := sessions.Default(c)
+			session.Set("userId", uid)
+		})
+
+		NewHandler(&Config{
+			R:            router,
+			MediaService: mockMediaService,
+			MaxBodyBytes: 4 * 1024 * 1024,
+		})
+
+		request, _ := http.NewRequest(http.MethodPost, "/v1/media/uploads/10/finalize", nil)
+		router.ServeHTTP(rr, request)
+
+		respBody, err := json.Marshal(upload.NewChunkedUploadResponse())
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+	})
+
+	t.Run("Checksum mismatch", func(t *testing.T) {
+		mockMediaService := new(mocks.MediaService)
+		mockMediaService.
+			On("FinalizeChunkedUpload", uid, "10").
+			Return(nil, apperrors.NewBadRequest("checksum does not match the uploaded file"))
+
+		rr := httptest.NewRecorder()
+		router := gin.Default()
+		store := cookie.NewStore([]byte("secret"))
+		router.Use(sessions.Sessions("mqk", store))
+
+		router.Use(func(c *gin.Context) {
+			session := sessions.Default(c)
+			session.Set("userId", uid)
+		})
+
+		NewHandler(&Config{
+			R:            router,
+			MediaService: mockMediaService,
+			MaxBodyBytes: 4 * 1024 * 1024,
+		})
+
+		request, _ := http.NewRequest(http.MethodPost, "/v1/media/uploads/10/finalize", nil)
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
@@ -0,0 +1,25 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"net/http"
+)
+
+// GetJob handler returns the status of one of the current user's
+// background jobs, e.g. the one creating the variants of a post image
+func (h *Handler) GetJob(c *gin.Context) {
+	userId := c.MustGet("userId").(string)
+	jobId := c.Param("id")
+
+	job, err := h.JobService.GetJob(userId, jobId)
+
+	if err != nil {
+		c.JSON(apperrors.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-contrib/sessions/cookie"
+	"github.com/gin-gonic/gin"
+	"github.com/sentrionic/mirage/mocks"
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"github.com/sentrionic/mirage/service"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHandler_GetJob(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	uid, _ := service.GenerateId()
+
+	setupRouter := func(mockJobService *mocks.JobService) *gin.Engine {
+		router := gin.Default()
+		store := cookie.NewStore([]byte("secret"))
+		router.Use(sessions.Sessions("mqk", store))
+
+		router.Use(func(c *gin.Context) {
+			session := sessions.Default(c)
+			session.Set("userId", uid)
+			c.Set("userId", uid)
+		})
+
+		NewHandler(&Config{
+			R:            router,
+			JobService:   mockJobService,
+			MaxBodyBytes: 4 * 1024 * 1024,
+		})
+
+		return router
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		job := &model.Job{
+			ID:          "10",
+			Type:        model.JobMediaVariants,
+			UserID:      uid,
+			Payload:     json.RawMessage(`{"hash":"abc"}`),
+			Status:      model.JobRetrying,
+			Attempts:    1,
+			MaxAttempts: 5,
+			LastError:   "timeout",
+			RunAt:       time.Now(),
+		}
+
+		mockJobService := new(mocks.JobService)
+		mockJobService.On("GetJob", uid, "10").Return(job, nil)
+
+		rr := httptest.NewRecorder()
+		router := setupRouter(mockJobService)
+
+		request, _ := http.NewRequest(http.MethodGet, "/v1/jobs/10", nil)
+		router.ServeHTTP(rr, request)
+
+		respBody, err := json.Marshal(job)
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+		// the payload and owner stay internal
+		assert.NotContains(t, rr.Body.String(), "abc")
+		assert.NotContains(t, rr.Body.String(), uid)
+		mockJobService.AssertExpectations(t)
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		mockJobService := new(mocks.JobService)
+		mockJobService.On("GetJob", uid, "11").Return(nil, apperrors.NewNotFound("job", "11"))
+
+		rr := httptest.NewRecorder()
+		router := setupRouter(mockJobService)
+
+		request, _ := http.NewRequest(http.MethodGet, "/v1/jobs/11", nil)
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		mockJobService.AssertExpectations(t)
+	})
+}
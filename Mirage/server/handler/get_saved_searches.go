@@ -0,0 +1,34 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"log"
+	"net/http"
+)
+
+// GetSavedSearches handler returns the current user's saved searches.
+// With counts=true, saved post searches include their new results since the last visit.
+func (h *Handler) GetSavedSearches(c *gin.Context) {
+	userId := c.MustGet("userId").(string)
+	withCounts := c.Query("counts") == "true"
+
+	searches, err := h.SearchService.SavedSearches(userId, withCounts)
+
+	if err != nil {
+		log.Printf("Unable to get saved searches of user: %v\n%v", userId, err)
+		c.JSON(apperrors.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	response := make([]model.SavedSearchResponse, 0)
+
+	for _, s := range *searches {
+		response = append(response, s.NewSavedSearchResponse())
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-contrib/sessions/cookie"
+	"github.com/gin-gonic/gin"
+	"github.com/sentrionic/mirage/mocks"
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"github.com/sentrionic/mirage/service"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHandler_GetSavedSearches(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	uid, _ := service.GenerateId()
+
+	newResults := int64(3)
+	searches := &[]model.SavedSearch{
+		{ID: "1", UserID: uid, Name: "Go", Query: "#go", Kind: model.SearchPosts, LastVisitedAt: time.Now(), NewResults: &newResults},
+		{ID: "2", UserID: uid, Name: "Tong", Query: "tong", Kind: model.SearchProfiles, LastVisitedAt: time.Now()},
+	}
+
+	setupRouter := func(mockSearchService *mocks.SearchService, authenticated bool) *gin.Engine {
+		router := gin.Default()
+		store := cookie.NewStore([]byte("secret"))
+		router.Use(sessions.Sessions("mqk", store))
+
+		if authenticated {
+			router.Use(func(c *gin.Context) {
+				session := sessions.Default(c)
+				session.Set("userId", uid)
+				c.Set("userId", uid)
+			})
+		}
+
+		NewHandler(&Config{
+			R:             router,
+			SearchService: mockSearchService,
+		})
+
+		return router
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		mockSearchService := new(mocks.SearchService)
+		mockSearchService.On("SavedSearches", uid, false).Return(searches, nil)
+
+		rr := httptest.NewRecorder()
+		router := setupRouter(mockSearchService, true)
+
+		request, err := http.NewRequest(http.MethodGet, "/v1/accounts/searches", nil)
+		assert.NoError(t, err)
+
+		router.ServeHTTP(rr, request)
+
+		rsp := make([]model.SavedSearchResponse, 0)
+		for _, s := range *searches {
+			rsp = append(rsp, s.NewSavedSearchResponse())
+		}
+
+		respBody, err := json.Marshal(rsp)
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+		mockSearchService.AssertExpectations(t)
+	})
+
+	t.Run("With counts", func(t *testing.T) {
+		mockSearchService := new(mocks.SearchService)
+		mockSearchService.On("SavedSearches", uid, true).Return(searches, nil)
+
+		rr := httptest.NewRecorder()
+		router := setupRouter(mockSearchService, true)
+
+		request, err := http.NewRequest(http.MethodGet, "/v1/accounts/searches?counts=true", nil)
+		assert.NoError(t, err)
+
+		router.ServeHTTP(rr, request)
+
+		var body []map[string]interface{}
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, float64(3), body[0]["newResults"])
+		assert.NotContains(t, body[1], "newResults")
+		mockSearchService.AssertExpectations(t)
+	})
+
+	t.Run("Unauthorized", func(t *testing.T) {
+		mockSearchService := new(mocks.SearchService)
+
+		rr := httptest.NewRecorder()
+		router := setupRouter(mockSearchService, false)
+
+		request, err := http.NewRequest(http.MethodGet, "/v1/accounts/searches", nil)
+		assert.NoError(t, err)
+
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		mockSearchService.AssertNotCalled(t, "SavedSearches")
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		mockSearchService := new(mocks.SearchService)
+		mockSearchService.On("SavedSearches", uid, false).Return(nil, fmt.Errorf("some error down call chain"))
+
+		rr := httptest.NewRecorder()
+		router := setupRouter(mockSearchService, true)
+
+		request, err := http.NewRequest(http.MethodGet, "/v1/accounts/searches", nil)
+		assert.NoError(t, err)
+
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, apperrors.NewInternal().Status(), rr.Code)
+		mockSearchService.AssertExpectations(t)
+	})
+}
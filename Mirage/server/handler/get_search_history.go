@@ -0,0 +1,25 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"log"
+	"net/http"
+)
+
+// GetSearchHistory handler returns the current user's recent searches, newest first
+func (h *Handler) GetSearchHistory(c *gin.Context) {
+	userId := c.MustGet("userId").(string)
+
+	history, err := h.SearchService.History(userId)
+
+	if err != nil {
+		log.Printf("Unable to get search history of user: %v\n%v", userId, err)
+		c.JSON(apperrors.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-contrib/sessions/cookie"
+	"github.com/gin-gonic/gin"
+	"github.com/sentrionic/mirage/mocks"
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"github.com/sentrionic/mirage/service"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHandler_GetSearchHistory(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	uid, _ := service.GenerateId()
+
+	setupRouter := func(mockSearchService *mocks.SearchService) *gin.Engine {
+		router := gin.Default()
+		store := cookie.NewStore([]byte("secret"))
+		router.Use(sessions.Sessions("mqk", store))
+
+		router.Use(func(c *gin.Context) {
+			session := sessions.Default(c)
+			session.Set("userId", uid)
+			c.Set("userId", uid)
+		})
+
+		NewHandler(&Config{
+			R:             router,
+			SearchService: mockSearchService,
+		})
+
+		return router
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		history := []model.SearchHistoryEntry{
+			{Query: "#go", Kind: model.SearchPosts, SearchedAt: time.Date(2021, 5, 1, 12, 0, 0, 0, time.UTC)},
+			{Query: "tong", Kind: model.SearchProfiles, SearchedAt: time.Date(2021, 5, 1, 11, 0, 0, 0, time.UTC)},
+		}
+
+		mockSearchService := new(mocks.SearchService)
+		mockSearchService.On("History", uid).Return(history, nil)
+
+		rr := httptest.NewRecorder()
+		router := setupRouter(mockSearchService)
+
+		request, err := http.NewRequest(http.MethodGet, "/v1/accounts/searches/history", nil)
+		assert.NoError(t, err)
+
+		router.ServeHTTP(rr, request)
+
+		respBody, err := json.Marshal(history)
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+		mockSearchService.AssertExpectations(t)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		mockSearchService := new(mocks.SearchService)
+		mockSearchService.On("History", uid).Return(nil, fmt.Errorf("some error down call chain"))
+
+		rr := httptest.NewRecorder()
+		router := setupRouter(mockSearchService)
+
+		request, err := http.NewRequest(http.MethodGet, "/v1/accounts/searches/history", nil)
+		assert.NoError(t, err)
+
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, apperrors.NewInternal().Status(), rr.Code)
+		mockSearchService.AssertExpectations(t)
+	})
+}
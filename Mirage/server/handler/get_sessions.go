@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"log"
+	"net/http"
+	"sort"
+)
+
+// GetSessions handler returns all active sessions
+// of the current user, most recently used first
+func (h *Handler) GetSessions(c *gin.Context) {
+	userId := c.MustGet("userId").(string)
+
+	list, err := h.SessionService.List(userId)
+
+	if err != nil {
+		log.Printf("Unable to find sessions for user: %v\n%v", userId, err)
+		c.JSON(apperrors.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	current := currentSessionId(c)
+	response := make([]model.SessionResponse, 0)
+
+	for _, s := range *list {
+		response = append(response, s.NewSessionResponse(current))
+	}
+
+	sort.SliceStable(response, func(i, j int) bool {
+		return response[i].LastSeen.After(response[j].LastSeen)
+	})
+
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,164 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-contrib/sessions/cookie"
+	"github.com/gin-gonic/gin"
+	"github.com/sentrionic/mirage/mocks"
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"github.com/sentrionic/mirage/model/fixture"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHandler_GetSessions(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	authUser := fixture.GetMockUser()
+
+	current := model.Session{
+		ID:        fixture.RandID(),
+		UserID:    authUser.ID,
+		Device:    "Mirage/1.0 (Android)",
+		IP:        "10.0.0.1",
+		CreatedAt: time.Now().Add(-time.Hour),
+		LastSeen:  time.Now(),
+	}
+
+	other := model.Session{
+		ID:        fixture.RandID(),
+		UserID:    authUser.ID,
+		Device:    "curl/7.68.0",
+		IP:        "10.0.0.2",
+		CreatedAt: time.Now().Add(-48 * time.Hour),
+		LastSeen:  time.Now().Add(-24 * time.Hour),
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		mockSessionService := new(mocks.SessionService)
+		mockSessionService.On("Verify", authUser.ID, current.ID, mock.AnythingOfType("string")).Return(true, nil)
+		mockSessionService.On("List", authUser.ID).Return(&[]model.Session{other, current}, nil)
+
+		rr := httptest.NewRecorder()
+
+		router := gin.Default()
+		store := cookie.NewStore([]byte("secret"))
+		router.Use(sessions.Sessions("mqk", store))
+
+		router.Use(func(c *gin.Context) {
+			session := sessions.Default(c)
+			session.Set("userId", authUser.ID)
+			session.Set("sessionId", current.ID)
+		})
+
+		NewHandler(&Config{
+			R:              router,
+			SessionService: mockSessionService,
+		})
+
+		request, err := http.NewRequest(http.MethodGet, "/v1/accounts/sessions", nil)
+		assert.NoError(t, err)
+
+		router.ServeHTTP(rr, request)
+
+		respBody, err := json.Marshal([]model.SessionResponse{
+			current.NewSessionResponse(current.ID),
+			other.NewSessionResponse(current.ID),
+		})
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+		mockSessionService.AssertExpectations(t)
+	})
+
+	t.Run("Unauthorized", func(t *testing.T) {
+		mockSessionService := new(mocks.SessionService)
+
+		rr := httptest.NewRecorder()
+
+		router := gin.Default()
+		store := cookie.NewStore([]byte("secret"))
+		router.Use(sessions.Sessions("mqk", store))
+
+		NewHandler(&Config{
+			R:              router,
+			SessionService: mockSessionService,
+		})
+
+		request, err := http.NewRequest(http.MethodGet, "/v1/accounts/sessions", nil)
+		assert.NoError(t, err)
+
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		mockSessionService.AssertNotCalled(t, "List", authUser.ID)
+	})
+
+	t.Run("Tokens cannot list sessions", func(t *testing.T) {
+		raw := "mrg_" + fixture.RandStringRunes(43)
+		token := &model.AccessToken{ID: fixture.RandID(), UserID: authUser.ID, Scopes: []string{model.ScopeRead}}
+
+		mockTokenService := new(mocks.TokenService)
+		mockTokenService.On("Authenticate", raw).Return(token, nil)
+		mockSessionService := new(mocks.SessionService)
+
+		rr := httptest.NewRecorder()
+
+		router := gin.Default()
+		store := cookie.NewStore([]byte("secret"))
+		router.Use(sessions.Sessions("mqk", store))
+
+		NewHandler(&Config{
+			R:              router,
+			SessionService: mockSessionService,
+			TokenService:   mockTokenService,
+		})
+
+		request, err := http.NewRequest(http.MethodGet, "/v1/accounts/sessions", nil)
+		assert.NoError(t, err)
+		request.Header.Set("Authorization", "Bearer "+raw)
+
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+		mockSessionService.AssertNotCalled(t, "List", authUser.ID)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		mockSessionService := new(mocks.SessionService)
+		mockSessionService.On("Verify", authUser.ID, current.ID, mock.AnythingOfType("string")).Return(true, nil)
+		mockSessionService.On("List", authUser.ID).Return(nil, apperrors.NewInternal())
+
+		rr := httptest.NewRecorder()
+
+		router := gin.Default()
+		store := cookie.NewStore([]byte("secret"))
+		router.Use(sessions.Sessions("mqk", store))
+
+		router.Use(func(c *gin.Context) {
+			session := sessions.Default(c)
+			session.Set("userId", authUser.ID)
+			session.Set("sessionId", current.ID)
+		})
+
+		NewHandler(&Config{
+			R:              router,
+			SessionService: mockSessionService,
+		})
+
+		request, err := http.NewRequest(http.MethodGet, "/v1/accounts/sessions", nil)
+		assert.NoError(t, err)
+
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+		mockSessionService.AssertExpectations(t)
+	})
+}
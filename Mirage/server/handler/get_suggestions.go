@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"log"
+	"net/http"
+)
+
+// GetSuggestions handler returns the accounts the current user might want to follow, best first
+func (h *Handler) GetSuggestions(c *gin.Context) {
+	userId := c.MustGet("userId").(string)
+
+	suggestions, err := h.SuggestionService.Suggestions(userId)
+
+	if err != nil {
+		log.Printf("Unable to get suggestions for user: %v\n%v", userId, err)
+		c.JSON(apperrors.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, newSuggestionsResponse(suggestions, userId))
+}
+
+func newSuggestionsResponse(suggestions *[]model.Suggestion, userId string) []model.SuggestionResponse {
+	response := make([]model.SuggestionResponse, 0)
+
+	for _, s := range *suggestions {
+		response = append(response, s.NewSuggestionResponse(userId))
+	}
+
+	return response
+}
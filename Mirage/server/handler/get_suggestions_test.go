@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-contrib/sessions/cookie"
+	"github.com/gin-gonic/gin"
+	"github.com/sentrionic/mirage/mocks"
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"github.com/sentrionic/mirage/model/fixture"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandler_GetSuggestions(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	current := fixture.GetMockUser()
+
+	t.Run("Success", func(t *testing.T) {
+		user := fixture.GetMockUser()
+		suggestions := []model.Suggestion{{UserID: user.ID, Score: 2.5, MutualFollowers: 3, User: user}}
+
+		mockSuggestionService := new(mocks.SuggestionService)
+		mockSuggestionService.On("Suggestions", current.ID).Return(&suggestions, nil)
+
+		rr := httptest.NewRecorder()
+		router := gin.Default()
+		store := cookie.NewStore([]byte("secret"))
+		router.Use(sessions.Sessions("mqk", store))
+
+		router.Use(func(c *gin.Context) {
+			session := sessions.Default(c)
+			session.Set("userId", current.ID)
+		})
+
+		NewHandler(&Config{
+			R:                 router,
+			SuggestionService: mockSuggestionService,
+		})
+
+		request, err := http.NewRequest(http.MethodGet, "/v1/suggestions", nil)
+		assert.NoError(t, err)
+
+		router.ServeHTTP(rr, request)
+
+		respBody, err := json.Marshal([]model.SuggestionResponse{suggestions[0].NewSuggestionResponse(current.ID)})
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+		mockSuggestionService.AssertExpectations(t)
+	})
+
+	t.Run("Unauthorized", func(t *testing.T) {
+		mockSuggestionService := new(mocks.SuggestionService)
+
+		rr := httptest.NewRecorder()
+		router := gin.Default()
+		store := cookie.NewStore([]byte("secret"))
+		router.Use(sessions.Sessions("mqk", store))
+
+		NewHandler(&Config{
+			R:                 router,
+			SuggestionService: mockSuggestionService,
+		})
+
+		request, err := http.NewRequest(http.MethodGet, "/v1/suggestions", nil)
+		assert.NoError(t, err)
+
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		mockSuggestionService.AssertNotCalled(t, "Suggestions", mock.Anything)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		mockSuggestionService := new(mocks.SuggestionService)
+		mockSuggestionService.On("Suggestions", current.ID).Return(nil, apperrors.NewInternal())
+
+		rr := httptest.NewRecorder()
+		router := gin.Default()
+		store := cookie.NewStore([]byte("secret"))
+		router.Use(sessions.Sessions("mqk", store))
+
+		router.Use(func(c *gin.Context) {
+			session := sessions.Default(c)
+			session.Set("userId", current.ID)
+		})
+
+		NewHandler(&Config{
+			R:                 router,
+			SuggestionService: mockSuggestionService,
+		})
+
+		request, err := http.NewRequest(http.MethodGet, "/v1/suggestions", nil)
+		assert.NoError(t, err)
+
+		router.ServeHTTP(rr, request)
+
+		respBody, err := json.Marshal(gin.H{
+			"error": apperrors.NewInternal(),
+		})
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+	})
+}
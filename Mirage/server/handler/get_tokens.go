@@ -0,0 +1,32 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"log"
+	"net/http"
+)
+
+// GetTokens handler returns the current user's access tokens
+func (h *Handler) GetTokens(c *gin.Context) {
+	userId := c.MustGet("userId").(string)
+
+	tokens, err := h.TokenService.List(userId)
+
+	if err != nil {
+		log.Printf("Unable to find tokens for user: %v\n%v", userId, err)
+		c.JSON(apperrors.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	response := make([]model.TokenResponse, 0)
+
+	for _, t := range *tokens {
+		response = append(response, t.NewTokenResponse(""))
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-contrib/sessions/cookie"
+	"github.com/gin-gonic/gin"
+	"github.com/sentrionic/mirage/mocks"
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"github.com/sentrionic/mirage/model/fixture"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHandler_GetTokens(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	authUser := fixture.GetMockUser()
+
+	tokens := []model.AccessToken{
+		{
+			ID:        fixture.RandID(),
+			UserID:    authUser.ID,
+			Name:      "android",
+			Hash:      fixture.RandStringRunes(64),
+			Scopes:    []string{model.ScopeRead, model.ScopeWrite},
+			CreatedAt: time.Now(),
+		},
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		mockTokenService := new(mocks.TokenService)
+		mockTokenService.On("List", authUser.ID).Return(&tokens, nil)
+
+		rr := httptest.NewRecorder()
+
+		router := gin.Default()
+		store := cookie.NewStore([]byte("secret"))
+		router.Use(sessions.Sessions("mqk", store))
+
+		router.Use(func(c *gin.Context) {
+			session := sessions.Default(c)
+			session.Set("userId", authUser.ID)
+		})
+
+		NewHandler(&Config{
+			R:            router,
+			TokenService: mockTokenService,
+		})
+
+		request, err := http.NewRequest(http.MethodGet, "/v1/accounts/tokens", nil)
+		assert.NoError(t, err)
+
+		router.ServeHTTP(rr, request)
+
+		respBody, err := json.Marshal([]model.TokenResponse{tokens[0].NewTokenResponse("")})
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+		assert.NotContains(t, rr.Body.String(), tokens[0].Hash)
+		mockTokenService.AssertExpectations(t)
+	})
+
+	t.Run("Unauthorized", func(t *testing.T) {
+		mockTokenService := new(mocks.TokenService)
+
+		rr := httptest.NewRecorder()
+
+		router := gin.Default()
+		store := cookie.NewStore([]byte("secret"))
+		router.Use(sessions.Sessions("mqk", store))
+
+		NewHandler(&Config{
+			R:            router,
+			TokenService: mockTokenService,
+		})
+
+		request, err := http.NewRequest(http.MethodGet, "/v1/accounts/tokens", nil)
+		assert.NoError(t, err)
+
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		mockTokenService.AssertNotCalled(t, "List", authUser.ID)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		mockError := apperrors.NewInternal()
+		mockTokenService := new(mocks.TokenService)
+		mockTokenService.On("List", authUser.ID).Return(nil, mockError)
+
+		rr := httptest.NewRecorder()
+
+		router := gin.Default()
+		store := cookie.NewStore([]byte("secret"))
+		router.Use(sessions.Sessions("mqk", store))
+
+		router.Use(func(c *gin.Context) {
+			session := sessions.Default(c)
+			session.Set("userId", authUser.ID)
+		})
+
+		NewHandler(&Config{
+			R:            router,
+			TokenService: mockTokenService,
+		})
+
+		request, err := http.NewRequest(http.MethodGet, "/v1/accounts/tokens", nil)
+		assert.NoError(t, err)
+
+		router.ServeHTTP(rr, request)
+
+		respBody, err := json.Marshal(gin.H{
+			"error": mockError,
+		})
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+		mockTokenService.AssertExpectations(t)
+	})
+}
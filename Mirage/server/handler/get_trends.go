@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"log"
+	"net/http"
+)
+
+// defaultTrendWindow is used if no window is given
+const defaultTrendWindow = "24h"
+
+// GetTrends handler returns the trending hashtags of the
+// given window. Supported windows are 1h, 24h and 7d.
+func (h *Handler) GetTrends(c *gin.Context) {
+	name := c.DefaultQuery("window", defaultTrendWindow)
+	window, ok := model.TrendWindows[name]
+
+	if !ok {
+		e := apperrors.NewBadRequest("window must be one of 1h, 24h or 7d")
+		c.JSON(e.Status(), gin.H{
+			"error": e,
+		})
+		return
+	}
+
+	trends, err := h.TrendService.GetTrends(window, model.LIMIT)
+
+	if err != nil {
+		log.Printf("Unable to get trends for window: %v\n%v", name, err)
+		c.JSON(apperrors.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, trends)
+}
@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-contrib/sessions/cookie"
+	"github.com/gin-gonic/gin"
+	"github.com/sentrionic/mirage/mocks"
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHandler_GetTrends(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+
+	setupRouter := func(mockTrendService *mocks.TrendService) *gin.Engine {
+		router := gin.Default()
+		store := cookie.NewStore([]byte("secret"))
+		router.Use(sessions.Sessions("mqk", store))
+
+		NewHandler(&Config{
+			R:            router,
+			TrendService: mockTrendService,
+		})
+
+		return router
+	}
+
+	trends := &[]model.Trend{
+		{Hashtag: "#go", Score: 4.2, Count: 7},
+		{Hashtag: "#mirage", Score: 1.5, Count: 2},
+	}
+
+	t.Run("Default window", func(t *testing.T) {
+		mockTrendService := new(mocks.TrendService)
+		mockTrendService.On("GetTrends", 24*time.Hour, model.LIMIT).Return(trends, nil)
+
+		rr := httptest.NewRecorder()
+		router := setupRouter(mockTrendService)
+
+		request, err := http.NewRequest(http.MethodGet, "/v1/trends", nil)
+		assert.NoError(t, err)
+
+		router.ServeHTTP(rr, request)
+
+		respBody, err := json.Marshal(trends)
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+		mockTrendService.AssertExpectations(t)
+	})
+
+	t.Run("Hour window", func(t *testing.T) {
+		mockTrendService := new(mocks.TrendService)
+		mockTrendService.On("GetTrends", time.Hour, model.LIMIT).Return(trends, nil)
+
+		rr := httptest.NewRecorder()
+		router := setupRouter(mockTrendService)
+
+		request, err := http.NewRequest(http.MethodGet, "/v1/trends?window=1h", nil)
+		assert.NoError(t, err)
+
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		mockTrendService.AssertExpectations(t)
+	})
+
+	t.Run("Invalid window", func(t *testing.T) {
+		mockTrendService := new(mocks.TrendService)
+
+		rr := httptest.NewRecorder()
+		router := setupRouter(mockTrendService)
+
+		request, err := http.NewRequest(http.MethodGet, "/v1/trends?window=30d", nil)
+		assert.NoError(t, err)
+
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		mockTrendService.AssertNotCalled(t, "GetTrends", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		mockTrendService := new(mocks.TrendService)
+		mockTrendService.On("GetTrends", 7*24*time.Hour, model.LIMIT).Return(nil, apperrors.NewInternal())
+
+		rr := httptest.NewRecorder()
+		router := setupRouter(mockTrendService)
+
+		request, err := http.NewRequest(http.MethodGet, "/v1/trends?window=7d", nil)
+		assert.NoError(t, err)
+
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+		mockTrendService.AssertExpectations(t)
+	})
+}
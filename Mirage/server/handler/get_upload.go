@@ -0,0 +1,25 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"net/http"
+)
+
+// GetUpload handler returns the state of a chunked upload,
+// so that clients know where to resume the upload
+func (h *Handler) GetUpload(c *gin.Context) {
+	userId := c.MustGet("userId").(string)
+	mediaId := c.Param("id")
+
+	upload, err := h.MediaService.GetChunkedUpload(userId, mediaId)
+
+	if err != nil {
+		c.JSON(apperrors.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, upload.NewChunkedUploadResponse())
+}
@@ -8,30 +8,56 @@ import (
 	"github.com/sentrionic/mirage/handler/middleware"
 	"github.com/sentrionic/mirage/model"
 	"github.com/sentrionic/mirage/model/apperrors"
+	"log"
+	"net"
 	"net/http"
 	"os"
+	"strings"
 	"time"
 )
 
 type Handler struct {
-	UserService  model.UserService
-	PostService  model.PostService
-	MaxBodyBytes int64
+	UserService       model.UserService
+	PostService       model.PostService
+	SessionService    model.SessionService
+	TokenService      model.TokenService
+	TrendService      model.TrendService
+	MediaService      model.MediaService
+	JobService        model.JobService
+	SuggestionService model.SuggestionService
+	SearchService     model.SearchService
+	MaxBodyBytes      int64
 }
 
 type Config struct {
-	R               *gin.Engine
-	UserService     model.UserService
-	PostService     model.PostService
-	TimeoutDuration time.Duration
-	MaxBodyBytes    int64
+	R                 *gin.Engine
+	UserService       model.UserService
+	PostService       model.PostService
+	SessionService    model.SessionService
+	TokenService      model.TokenService
+	TrendService      model.TrendService
+	MediaService      model.MediaService
+	JobService        model.JobService
+	SuggestionService model.SuggestionService
+	SearchService     model.SearchService
+	RateLimiter       model.RateLimiter
+	TrustedProxies    []*net.IPNet
+	TimeoutDuration   time.Duration
+	MaxBodyBytes      int64
 }
 
 func NewHandler(c *Config) {
 	h := &Handler{
-		UserService:  c.UserService,
-		PostService:  c.PostService,
-		MaxBodyBytes: c.MaxBodyBytes,
+		UserService:       c.UserService,
+		PostService:       c.PostService,
+		SessionService:    c.SessionService,
+		TokenService:      c.TokenService,
+		TrendService:      c.TrendService,
+		MediaService:      c.MediaService,
+		JobService:        c.JobService,
+		SuggestionService: c.SuggestionService,
+		SearchService:     c.SearchService,
+		MaxBodyBytes:      c.MaxBodyBytes,
 	}
 
 	// set cors settings
@@ -43,7 +69,8 @@ func NewHandler(c *Config) {
 	})
 	c.R.Use(options)
 
-	c.R.Use(middleware.ContextUser())
+	c.R.Use(middleware.RealIP(c.TrustedProxies))
+	c.R.Use(middleware.ContextUser(c.SessionService, c.TokenService))
 	c.R.NoRoute(func(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{
 			"error": "No route with for the given path found",
@@ -57,46 +84,163 @@ func NewHandler(c *Config) {
 	// Account group
 	ag := c.R.Group("v1/accounts")
 
-	ag.POST("/register", h.Register)
-	ag.POST("/login", h.Login)
+	registerLimit := middleware.RateLimit(c.RateLimiter,
+		middleware.RateLimitRule{Name: "register_ip", Limit: 5, Window: time.Hour, Key: middleware.ByIP},
+	)
+	loginLimit := middleware.RateLimit(c.RateLimiter,
+		middleware.RateLimitRule{Name: "login_ip", Limit: 20, Window: time.Minute, Key: middleware.ByIP},
+		middleware.RateLimitRule{Name: "login_email", Limit: 10, Window: 15 * time.Minute, Key: middleware.ByEmail},
+	)
+	twoFactorLimit := middleware.RateLimit(c.RateLimiter,
+		middleware.RateLimitRule{Name: "login_2fa_ip", Limit: 20, Window: time.Minute, Key: middleware.ByIP},
+	)
 
-	ag.Use(middleware.AuthUser())
+	ag.POST("/register", registerLimit, h.Register)
+	ag.POST("/login", loginLimit, h.Login)
+	ag.POST("/login/2fa", twoFactorLimit, h.LoginTwoFactor)
+
+	ag.Use(middleware.AuthUser(c.SessionService, c.TokenService))
 
 	ag.GET("", h.Current)
-	ag.PUT("", h.EditAccount)
+	ag.PUT("", middleware.RequireSession(), h.EditAccount)
 	ag.POST("/logout", h.Logout)
 
+	asg := ag.Group("/sessions", middleware.RequireSession())
+	asg.GET("", h.GetSessions)
+	asg.DELETE("", h.RevokeAllSessions)
+	asg.DELETE("/:id", h.RevokeSession)
+
+	tg := ag.Group("/tokens", middleware.RequireSession())
+	tg.GET("", h.GetTokens)
+	tg.POST("", h.CreateToken)
+	tg.DELETE("/:id", h.DeleteToken)
+
+	fg := ag.Group("/2fa", middleware.RequireSession())
+	fg.POST("", h.EnrollTwoFactor)
+	fg.POST("/confirm", h.ConfirmTwoFactor)
+	fg.DELETE("", h.DisableTwoFactor)
+	fg.POST("/recovery-codes", h.RegenerateRecoveryCodes)
+
+	shg := ag.Group("/searches")
+	shg.GET("", h.GetSavedSearches)
+	shg.POST("", h.CreateSavedSearch)
+	shg.GET("/history", middleware.RequireSession(), h.GetSearchHistory)
+	shg.DELETE("/history", middleware.RequireSession(), h.ClearSearchHistory)
+	shg.PUT("/:id", h.UpdateSavedSearch)
+	shg.DELETE("/:id", h.DeleteSavedSearch)
+	shg.POST("/:id/visit", h.VisitSavedSearch)
+
 	// User group
 	ug := c.R.Group("v1/profiles")
 	ug.GET("/:username", h.GetProfile)
 	ug.GET("/:username/posts", h.GetProfilePosts)
 	ug.GET("/:username/likes", h.GetProfileLikes)
 	ug.GET("/:username/media", h.GetProfileMedia)
+	ug.GET("/:username/followers", h.GetProfileFollowers)
+	ug.GET("/:username/following", h.GetProfileFollowing)
+	ug.GET("/:username/calendar", h.GetProfileCalendar)
+	ug.GET("/:username/on-this-day", h.GetProfileOnThisDay)
 
-	ug.Use(middleware.AuthUser())
+	ug.Use(middleware.AuthUser(c.SessionService, c.TokenService))
 	ug.GET("", h.SearchProfiles)
+	ug.GET("/typeahead", h.TypeaheadProfiles)
 	ug.POST("/:username/follow", h.ToggleFollow)
+	ug.GET("/:username/followers/mutual", h.GetMutualFollowers)
 
 	// Post group
 	pg := c.R.Group("v1/posts")
 	pg.GET("/:id", h.GetPost)
 
-	pg.Use(middleware.AuthUser())
+	pg.Use(middleware.AuthUser(c.SessionService, c.TokenService))
 	pg.POST("", h.CreatePost)
 	pg.GET("", h.SearchPosts)
 	pg.GET("/feed", h.Feed)
 	pg.POST("/:id/like", h.LikePost)
 	pg.DELETE("/:id", h.DeletePost)
+	pg.PUT("/:id/alt", h.EditAltText)
 	pg.POST("/:id/retweet", h.Retweet)
+
+	// Media group
+	mg := c.R.Group("v1/media")
+	mg.Use(middleware.AuthUser(c.SessionService, c.TokenService))
+	mg.POST("/uploads", h.CreateUpload)
+	mg.POST("/uploads/chunked", h.InitChunkedUpload)
+	mg.GET("/uploads/:id", h.GetUpload)
+	mg.PUT("/uploads/:id/chunks/:index", h.AppendChunk)
+	mg.POST("/uploads/:id/finalize", h.FinalizeUpload)
+
+	// Job group, only registered when jobs get queued
+	if c.JobService != nil {
+		jg := c.R.Group("v1/jobs")
+		jg.Use(middleware.AuthUser(c.SessionService, c.TokenService))
+		jg.GET("/:id", h.GetJob)
+	}
+
+	// Trend group
+	trg := c.R.Group("v1/trends")
+	trg.GET("", h.GetTrends)
+
+	// Hashtag group
+	hg := c.R.Group("v1/hashtags")
+	hg.GET("/:tag/related", h.GetRelatedHashtags)
+
+	// Suggestion group
+	sg := c.R.Group("v1/suggestions")
+	sg.Use(middleware.AuthUser(c.SessionService, c.TokenService))
+	sg.GET("", h.GetSuggestions)
+	sg.POST("/refresh", h.RefreshSuggestions)
 }
 
-// setUserSession saves the users ID in the session
-func setUserSession(c *gin.Context, id string) {
+// setUserSession records a new session for the user
+// and saves the user's and the session's ID in the cookie
+func (h *Handler) setUserSession(c *gin.Context, id string) error {
+	record, err := h.SessionService.Create(id, c.Request.UserAgent(), middleware.ClientIP(c))
+
+	if err != nil {
+		return err
+	}
+
 	session := sessions.Default(c)
 	session.Set("userId", id)
+	session.Set("sessionId", record.ID)
 	if err := session.Save(); err != nil {
 		fmt.Println(err)
 	}
+
+	return nil
+}
+
+// clearUserSession removes the user from the cookie session
+func clearUserSession(c *gin.Context) {
+	c.Set("userId", nil)
+
+	session := sessions.Default(c)
+	session.Set("userId", "")
+	session.Clear()
+	session.Options(sessions.Options{Path: "/", MaxAge: -1})
+
+	if err := session.Save(); err != nil {
+		fmt.Printf("error clearing session: %v", err)
+	}
+}
+
+// currentSessionId returns the ID of the session record
+// referenced by the cookie, if there is one
+func currentSessionId(c *gin.Context) string {
+	id, _ := sessions.Default(c).Get("sessionId").(string)
+	return id
+}
+
+// recordSearch adds the first page of a non-empty search to the user's
+// history. A failure is only logged since the search itself succeeded.
+func (h *Handler) recordSearch(userId, query string, kind model.SearchKind) {
+	if strings.TrimSpace(query) == "" {
+		return
+	}
+
+	if err := h.SearchService.RecordSearch(userId, query, kind); err != nil {
+		log.Printf("Unable to record search of user: %v\n%v", userId, err)
+	}
 }
 
 var validImageTypes = map[string]bool{
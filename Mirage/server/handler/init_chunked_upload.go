@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"log"
+	"net/http"
+	"strings"
+)
+
+type initChunkedUploadReq struct {
+	FileType string `json:"filetype"`
+	Size     int64  `json:"size"`
+	// Checksum is the hex encoded SHA-256 of the whole file
+	Checksum string `json:"checksum"`
+}
+
+func (r initChunkedUploadReq) Validate() error {
+	return validation.ValidateStruct(&r,
+		validation.Field(&r.FileType, validation.Required),
+		validation.Field(&r.Size, validation.Required, validation.Min(int64(1))),
+		validation.Field(&r.Checksum, validation.Required, validation.Length(64, 64)),
+	)
+}
+
+func (r *initChunkedUploadReq) Sanitize() {
+	r.Checksum = strings.ToLower(strings.TrimSpace(r.Checksum))
+}
+
+// InitChunkedUpload handler starts a resumable upload. The file gets
+// uploaded in chunks with AppendChunk and completed with FinalizeUpload.
+func (h *Handler) InitChunkedUpload(c *gin.Context) {
+	userId := c.MustGet("userId").(string)
+
+	var req initChunkedUploadReq
+
+	if ok := bindData(c, &req); !ok {
+		return
+	}
+
+	req.Sanitize()
+
+	upload, err := h.MediaService.InitChunkedUpload(userId, req.FileType, req.Size, req.Checksum)
+
+	if err != nil {
+		log.Printf("Unable to init chunked upload: %v\n", err)
+
+		c.JSON(apperrors.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, upload.NewChunkedUploadResponse())
+}
@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-contrib/sessions/cookie"
+	"github.com/gin-gonic/gin"
+	"github.com/sentrionic/mirage/mocks"
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandler_InitChunkedUpload(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	uid, _ := service.GenerateId()
+	checksum := strings.Repeat("ab", 32)
+
+	newRequest := func(body gin.H) *http.Request {
+		reqBody, _ := json.Marshal(body)
+		request, _ := http.NewRequest(http.MethodPost, "/v1/media/uploads/chunked", bytes.NewBuffer(reqBody))
+		request.Header.Set("Content-Type", "application/json")
+		return request
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		upload := &model.ChunkedUpload{
+			ID:        "10",
+			UserID:    uid,
+			FileType:  "video/mp4",
+			Size:      12 << 20,
+			Checksum:  checksum,
+			ExpiresAt: time.Now().Add(time.Hour),
+		}
+
+		mockMediaService := new(mocks.MediaService)
+		mockMediaService.On("InitChunkedUpload", uid, "video/mp4", int64(12<<20), checksum).Return(upload, nil)
+
+		rr := httptest.NewRecorder()
+		router := gin.Default()
+		store := cookie.NewStore([]byte("secret"))
+		router.Use(sessions.Sessions("mqk", store))
+
+		router.Use(func(c *gin.Context) {
+			session := sessions.Default(c)
+			session.Set("userId", uid)
+		})
+
+		NewHandler(&Config{
+			R:            router,
+			MediaService: mockMediaService,
+			MaxBodyBytes: 4 * 1024 * 1024,
+		})
+
+		router.ServeHTTP(rr, newRequest(gin.H{
+			"filetype": "video/mp4",
+			"size":     12 << 20,
+			"checksum": strings.ToUpper(checksum),
+		}))
+
+		respBody, err := json.Marshal(upload.NewChunkedUploadResponse())
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+		mockMediaService.AssertExpectations(t)
+	})
+
+	t.Run("Missing checksum", func(t *testing.T) {
+		mockMediaService := new(mocks.MediaService)
+
+		rr := httptest.NewRecorder()
+		router := gin.Default()
+		store := cookie.NewStore([]byte("secret"))
+		router.Use(sessions.Sessions("mqk", store))
+
+		router.Use(func(c *gin.Context) {
+			session := sessions.Default(c)
+			session.Set("userId", uid)
+		})
+
+		NewHandler(&Config{
+			R:            router,
+			MediaService: mockMediaService,
+			MaxBodyBytes: 4 * 1024 * 1024,
+		})
+
+		router.ServeHTTP(rr, newRequest(gin.H{"filetype": "video/mp4", "size": 12 << 20}))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		mockMediaService.AssertNotCalled(t, "InitChunkedUpload", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
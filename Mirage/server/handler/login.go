@@ -4,9 +4,11 @@ import (
 	"github.com/gin-gonic/gin"
 	validation "github.com/go-ozzo/ozzo-validation/v4"
 	"github.com/go-ozzo/ozzo-validation/v4/is"
+	"github.com/sentrionic/mirage/handler/middleware"
 	"github.com/sentrionic/mirage/model/apperrors"
 	"log"
 	"net/http"
+	"strconv"
 	"strings"
 )
 
@@ -38,17 +40,44 @@ func (h *Handler) Login(c *gin.Context) {
 
 	req.Sanitize()
 
-	user, err := h.UserService.Login(req.Email, req.Password)
+	user, err := h.UserService.Login(req.Email, req.Password, middleware.ClientIP(c))
 
 	if err != nil {
 		log.Printf("Failed to sign in user: %v\n", err.Error())
+		if retryAfter := apperrors.RetryAfter(err); retryAfter > 0 {
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+		}
 		c.JSON(apperrors.Status(err), gin.H{
 			"error": err,
 		})
 		return
 	}
 
-	setUserSession(c, user.ID)
+	if user.TwoFactorEnabled {
+		token, err := h.SessionService.CreatePending(user.ID)
+
+		if err != nil {
+			log.Printf("Failed to create pending login: %v\n", err.Error())
+			c.JSON(apperrors.Status(err), gin.H{
+				"error": err,
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"twoFactorRequired": true,
+			"token":             token,
+		})
+		return
+	}
+
+	if err := h.setUserSession(c, user.ID); err != nil {
+		log.Printf("Failed to create session: %v\n", err.Error())
+		c.JSON(apperrors.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
 
 	c.JSON(http.StatusOK, user.NewAccountResponse())
 }
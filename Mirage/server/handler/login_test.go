@@ -6,11 +6,13 @@ import (
 	"github.com/gin-contrib/sessions"
 	"github.com/gin-contrib/sessions/cookie"
 	"github.com/sentrionic/mirage/mocks"
+	"github.com/sentrionic/mirage/model"
 	"github.com/sentrionic/mirage/model/apperrors"
 	"github.com/sentrionic/mirage/model/fixture"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
@@ -23,14 +25,16 @@ func TestLogin(t *testing.T) {
 
 	// setup mock services, gin engine/router, handler layer
 	mockUserService := new(mocks.UserService)
+	mockSessionService := new(mocks.SessionService)
 
 	router := gin.Default()
 	store := cookie.NewStore([]byte("secret"))
 	router.Use(sessions.Sessions("mqk", store))
 
 	NewHandler(&Config{
-		R:           router,
-		UserService: mockUserService,
+		R:              router,
+		UserService:    mockUserService,
+		SessionService: mockSessionService,
 	})
 
 	t.Run("Bad request data", func(t *testing.T) {
@@ -61,6 +65,7 @@ func TestLogin(t *testing.T) {
 		mockUSArgs := mock.Arguments{
 			email,
 			password,
+			mock.AnythingOfType("string"),
 		}
 
 		mockError := apperrors.NewAuthorization("invalid email/password combo")
@@ -92,9 +97,13 @@ func TestLogin(t *testing.T) {
 		mockUSArgs := mock.Arguments{
 			mockUser.Email,
 			mockUser.Password,
+			mock.AnythingOfType("string"),
 		}
 
 		mockUserService.On("Login", mockUSArgs...).Return(mockUser, nil)
+		mockSessionService.
+			On("Create", mockUser.ID, mock.AnythingOfType("string"), mock.AnythingOfType("string")).
+			Return(&model.Session{ID: fixture.RandID(), UserID: mockUser.ID}, nil)
 
 		rr := httptest.NewRecorder()
 
@@ -119,6 +128,71 @@ func TestLogin(t *testing.T) {
 		assert.Contains(t, rr.Header(), "Set-Cookie")
 
 		mockUserService.AssertCalled(t, "Login", mockUSArgs...)
+		mockSessionService.AssertExpectations(t)
+	})
+
+	t.Run("Two-factor required", func(t *testing.T) {
+		mockUser := fixture.GetMockUser()
+		mockUser.TwoFactorEnabled = true
+
+		mockUSArgs := mock.Arguments{
+			mockUser.Email,
+			mockUser.Password,
+			mock.AnythingOfType("string"),
+		}
+
+		mockUserService.On("Login", mockUSArgs...).Return(mockUser, nil)
+		mockSessionService.On("CreatePending", mockUser.ID).Return("pending-token", nil)
+
+		rr := httptest.NewRecorder()
+
+		reqBody, err := json.Marshal(gin.H{
+			"email":    mockUser.Email,
+			"password": mockUser.Password,
+		})
+		assert.NoError(t, err)
+
+		request, err := http.NewRequest(http.MethodPost, "/v1/accounts/login", bytes.NewBuffer(reqBody))
+		assert.NoError(t, err)
+
+		request.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(rr, request)
+
+		respBody, err := json.Marshal(gin.H{
+			"twoFactorRequired": true,
+			"token":             "pending-token",
+		})
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+		assert.NotContains(t, rr.Header(), "Set-Cookie")
+		mockSessionService.AssertNotCalled(t, "Create", mockUser.ID, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Locked out", func(t *testing.T) {
+		mockUser := fixture.GetMockUser()
+
+		mockUserService.
+			On("Login", mockUser.Email, mockUser.Password, mock.AnythingOfType("string")).
+			Return(nil, apperrors.NewTooManyRequests(2*time.Minute))
+
+		rr := httptest.NewRecorder()
+
+		reqBody, err := json.Marshal(gin.H{
+			"email":    mockUser.Email,
+			"password": mockUser.Password,
+		})
+		assert.NoError(t, err)
+
+		request, err := http.NewRequest(http.MethodPost, "/v1/accounts/login", bytes.NewBuffer(reqBody))
+		assert.NoError(t, err)
+
+		request.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+		assert.Equal(t, "120", rr.Header().Get("Retry-After"))
 	})
 
 }
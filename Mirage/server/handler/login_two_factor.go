@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/sentrionic/mirage/handler/middleware"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type loginTwoFactorReq struct {
+	Token string `json:"token"`
+	Code  string `json:"code"`
+}
+
+func (r loginTwoFactorReq) Validate() error {
+	return validation.ValidateStruct(&r,
+		validation.Field(&r.Token, validation.Required),
+		validation.Field(&r.Code, validation.Required, validation.Length(6, 20)),
+	)
+}
+
+func (r *loginTwoFactorReq) Sanitize() {
+	r.Token = strings.TrimSpace(r.Token)
+	r.Code = strings.TrimSpace(r.Code)
+}
+
+// LoginTwoFactor handler completes a login of a user
+// with two-factor authentication enabled
+func (h *Handler) LoginTwoFactor(c *gin.Context) {
+	var req loginTwoFactorReq
+
+	if ok := bindData(c, &req); !ok {
+		return
+	}
+
+	req.Sanitize()
+
+	userId, err := h.SessionService.FindPending(req.Token)
+
+	if err != nil {
+		c.JSON(apperrors.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	user, err := h.UserService.Get(userId)
+
+	if err != nil {
+		log.Printf("Unable to find user: %v\n%v", userId, err)
+		e := apperrors.NewAuthorization("Login expired, please sign in again")
+		c.JSON(e.Status(), gin.H{
+			"error": e,
+		})
+		return
+	}
+
+	valid, err := h.UserService.LoginTwoFactor(user, req.Code, middleware.ClientIP(c))
+
+	if err != nil {
+		log.Printf("Failed to verify two-factor code: %v\n", err.Error())
+		if retryAfter := apperrors.RetryAfter(err); retryAfter > 0 {
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+		}
+		c.JSON(apperrors.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	if !valid {
+		if err := h.SessionService.FailPending(req.Token); err != nil {
+			log.Printf("Failed to update pending login: %v\n", err.Error())
+		}
+
+		e := apperrors.NewAuthorization("Invalid two-factor code")
+		c.JSON(e.Status(), gin.H{
+			"error": e,
+		})
+		return
+	}
+
+	if err := h.SessionService.DeletePending(req.Token); err != nil {
+		log.Printf("Failed to delete pending login: %v\n", err.Error())
+	}
+
+	if err := h.setUserSession(c, user.ID); err != nil {
+		log.Printf("Failed to create session: %v\n", err.Error())
+		c.JSON(apperrors.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, user.NewAccountResponse())
+}
@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-contrib/sessions/cookie"
+	"github.com/gin-gonic/gin"
+	"github.com/sentrionic/mirage/mocks"
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"github.com/sentrionic/mirage/model/fixture"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHandler_LoginTwoFactor(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	mockUser := fixture.GetMockUser()
+	mockUser.TwoFactorEnabled = true
+	token := "pending-token"
+
+	setupRouter := func(mockUserService *mocks.UserService, mockSessionService *mocks.SessionService) *gin.Engine {
+		router := gin.Default()
+		store := cookie.NewStore([]byte("secret"))
+		router.Use(sessions.Sessions("mqk", store))
+
+		NewHandler(&Config{
+			R:              router,
+			UserService:    mockUserService,
+			SessionService: mockSessionService,
+		})
+
+		return router
+	}
+
+	sendRequest := func(router *gin.Engine, body gin.H) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+
+		reqBody, err := json.Marshal(body)
+		assert.NoError(t, err)
+
+		request, err := http.NewRequest(http.MethodPost, "/v1/accounts/login/2fa", bytes.NewBuffer(reqBody))
+		assert.NoError(t, err)
+
+		request.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(rr, request)
+
+		return rr
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		mockUserService := new(mocks.UserService)
+		mockSessionService := new(mocks.SessionService)
+
+		mockSessionService.On("FindPending", token).Return(mockUser.ID, nil)
+		mockUserService.On("Get", mockUser.ID).Return(mockUser, nil)
+		mockUserService.On("LoginTwoFactor", mockUser, "123456", mock.AnythingOfType("string")).Return(true, nil)
+		mockSessionService.On("DeletePending", token).Return(nil)
+		mockSessionService.
+			On("Create", mockUser.ID, mock.AnythingOfType("string"), mock.AnythingOfType("string")).
+			Return(&model.Session{ID: fixture.RandID(), UserID: mockUser.ID}, nil)
+
+		router := setupRouter(mockUserService, mockSessionService)
+		rr := sendRequest(router, gin.H{"token": token, "code": "123456"})
+
+		respBody, err := json.Marshal(mockUser.NewAccountResponse())
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+		assert.Contains(t, rr.Header(), "Set-Cookie")
+		mockUserService.AssertExpectations(t)
+		mockSessionService.AssertExpectations(t)
+	})
+
+	t.Run("Invalid code", func(t *testing.T) {
+		mockUserService := new(mocks.UserService)
+		mockSessionService := new(mocks.SessionService)
+
+		mockSessionService.On("FindPending", token).Return(mockUser.ID, nil)
+		mockUserService.On("Get", mockUser.ID).Return(mockUser, nil)
+		mockUserService.On("LoginTwoFactor", mockUser, "654321", mock.AnythingOfType("string")).Return(false, nil)
+		mockSessionService.On("FailPending", token).Return(nil)
+
+		router := setupRouter(mockUserService, mockSessionService)
+		rr := sendRequest(router, gin.H{"token": token, "code": "654321"})
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		assert.NotContains(t, rr.Header(), "Set-Cookie")
+		mockSessionService.AssertExpectations(t)
+		mockSessionService.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Locked out", func(t *testing.T) {
+		mockUserService := new(mocks.UserService)
+		mockSessionService := new(mocks.SessionService)
+
+		mockSessionService.On("FindPending", token).Return(mockUser.ID, nil)
+		mockUserService.On("Get", mockUser.ID).Return(mockUser, nil)
+		mockUserService.
+			On("LoginTwoFactor", mockUser, "123456", mock.AnythingOfType("string")).
+			Return(false, apperrors.NewTooManyRequests(2*time.Minute))
+
+		router := setupRouter(mockUserService, mockSessionService)
+		rr := sendRequest(router, gin.H{"token": token, "code": "123456"})
+
+		assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+		assert.Equal(t, "120", rr.Header().Get("Retry-After"))
+		mockSessionService.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Expired login", func(t *testing.T) {
+		mockUserService := new(mocks.UserService)
+		mockSessionService := new(mocks.SessionService)
+
+		mockSessionService.
+			On("FindPending", token).
+			Return("", apperrors.NewAuthorization("Login expired, please sign in again"))
+
+		router := setupRouter(mockUserService, mockSessionService)
+		rr := sendRequest(router, gin.H{"token": token, "code": "123456"})
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		mockUserService.AssertNotCalled(t, "LoginTwoFactor", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Bad request data", func(t *testing.T) {
+		mockUserService := new(mocks.UserService)
+		mockSessionService := new(mocks.SessionService)
+
+		router := setupRouter(mockUserService, mockSessionService)
+		rr := sendRequest(router, gin.H{"token": token, "code": "1"})
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		mockSessionService.AssertNotCalled(t, "FindPending", mock.Anything)
+	})
+}
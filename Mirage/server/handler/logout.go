@@ -1,8 +1,7 @@
 package handler
 
 import (
-	"fmt"
-	"github.com/gin-contrib/sessions"
+	"log"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -10,17 +9,15 @@ import (
 
 // Logout handler
 func (h *Handler) Logout(c *gin.Context) {
-	c.Set("userId", nil)
+	userId := c.MustGet("userId").(string)
 
-	session := sessions.Default(c)
-	session.Set("userId", "")
-	session.Clear()
-	session.Options(sessions.Options{Path: "/", MaxAge: -1})
-	err := session.Save()
-
-	if err != nil {
-		fmt.Printf("error clearing session: %v", err)
+	if sid := currentSessionId(c); sid != "" {
+		if err := h.SessionService.Revoke(userId, sid); err != nil {
+			log.Printf("Unable to revoke session: %v\n%v", sid, err)
+		}
 	}
 
+	clearUserSession(c)
+
 	c.JSON(http.StatusOK, true)
 }
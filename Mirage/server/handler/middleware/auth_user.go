@@ -4,18 +4,46 @@ import (
 	"errors"
 	"fmt"
 	"github.com/gin-contrib/sessions"
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
 
 	"github.com/gin-gonic/gin"
 )
 
 // AuthUser checks if the request contains a valid session
-// and saves the session's userId in the context
-func AuthUser() gin.HandlerFunc {
+// or bearer token and saves the userId in the context.
+// Sessions that have been revoked through the given
+// SessionService are rejected. If s is nil only the cookie is checked.
+// Tokens must have a scope matching the request's method.
+func AuthUser(s model.SessionService, t model.TokenService) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if raw, ok := bearerToken(c); ok {
+			if !authenticateToken(c, t, raw) {
+				e := apperrors.NewAuthorization("provided token is invalid")
+				c.JSON(e.Status(), gin.H{
+					"error": e,
+				})
+				c.Abort()
+				return
+			}
+
+			if !hasRequiredScope(c) {
+				e := apperrors.NewForbidden("provided token is missing the required scope")
+				c.JSON(e.Status(), gin.H{
+					"error": e,
+				})
+				c.Abort()
+				return
+			}
+
+			c.Next()
+			return
+		}
+
 		session := sessions.Default(c)
 		id := session.Get("userId")
 
-		if id == nil {
+		if id == nil || !isActiveSession(c, s, session, id.(string)) {
 			err := errors.New("provided session is invalid")
 			c.JSON(401, gin.H{
 				"error": err,
@@ -3,6 +3,10 @@ package middleware
 import (
 	"github.com/gin-contrib/sessions"
 	"github.com/gin-contrib/sessions/cookie"
+	"github.com/sentrionic/mirage/mocks"
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"github.com/sentrionic/mirage/model/fixture"
 	"github.com/sentrionic/mirage/service"
 	"net/http"
 	"net/http/httptest"
@@ -10,6 +14,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 func TestAuthUser(t *testing.T) {
@@ -31,7 +36,7 @@ func TestAuthUser(t *testing.T) {
 
 		var contextUserId string
 
-		r.GET("/v1/accounts", AuthUser(), func(c *gin.Context) {
+		r.GET("/v1/accounts", AuthUser(nil, nil), func(c *gin.Context) {
 			contextKeyVal, _ := c.Get("userId")
 			contextUserId = contextKeyVal.(string)
 		})
@@ -51,7 +56,7 @@ func TestAuthUser(t *testing.T) {
 		store := cookie.NewStore([]byte("secret"))
 		r.Use(sessions.Sessions("mqk", store))
 
-		r.GET("/v1/accounts", AuthUser())
+		r.GET("/v1/accounts", AuthUser(nil, nil))
 
 		request, _ := http.NewRequest(http.MethodGet, "/v1/accounts", http.NoBody)
 
@@ -59,4 +64,256 @@ func TestAuthUser(t *testing.T) {
 
 		assert.Equal(t, http.StatusUnauthorized, rr.Code)
 	})
+
+	t.Run("Accepts an active session", func(t *testing.T) {
+		sid, _ := service.GenerateId()
+		mockSessionService := new(mocks.SessionService)
+		mockSessionService.On("Verify", uid, sid, mock.AnythingOfType("string")).Return(true, nil)
+
+		rr := httptest.NewRecorder()
+
+		_, r := gin.CreateTestContext(rr)
+		store := cookie.NewStore([]byte("secret"))
+		r.Use(sessions.Sessions("mqk", store))
+
+		r.Use(func(c *gin.Context) {
+			session := sessions.Default(c)
+			session.Set("userId", uid)
+			session.Set("sessionId", sid)
+		})
+
+		r.GET("/v1/accounts", AuthUser(mockSessionService, nil))
+
+		request, _ := http.NewRequest(http.MethodGet, "/v1/accounts", http.NoBody)
+		r.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		mockSessionService.AssertExpectations(t)
+	})
+
+	t.Run("Rejects a revoked session", func(t *testing.T) {
+		sid, _ := service.GenerateId()
+		mockSessionService := new(mocks.SessionService)
+		mockSessionService.On("Verify", uid, sid, mock.AnythingOfType("string")).Return(false, nil)
+
+		rr := httptest.NewRecorder()
+
+		_, r := gin.CreateTestContext(rr)
+		store := cookie.NewStore([]byte("secret"))
+		r.Use(sessions.Sessions("mqk", store))
+
+		r.Use(func(c *gin.Context) {
+			session := sessions.Default(c)
+			session.Set("userId", uid)
+			session.Set("sessionId", sid)
+		})
+
+		r.GET("/v1/accounts", AuthUser(mockSessionService, nil))
+
+		request, _ := http.NewRequest(http.MethodGet, "/v1/accounts", http.NoBody)
+		r.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		mockSessionService.AssertExpectations(t)
+	})
+
+	t.Run("Upgrades a cookie without a session record", func(t *testing.T) {
+		record := &model.Session{ID: fixture.RandID(), UserID: uid}
+		mockSessionService := new(mocks.SessionService)
+		mockSessionService.On("UpgradeLegacy", uid, mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(record, nil)
+
+		rr := httptest.NewRecorder()
+
+		_, r := gin.CreateTestContext(rr)
+		store := cookie.NewStore([]byte("secret"))
+		r.Use(sessions.Sessions("mqk", store))
+
+		r.Use(func(c *gin.Context) {
+			session := sessions.Default(c)
+			session.Set("userId", uid)
+		})
+
+		var sessionId interface{}
+
+		r.GET("/v1/accounts", AuthUser(mockSessionService, nil), func(c *gin.Context) {
+			sessionId = sessions.Default(c).Get("sessionId")
+		})
+
+		request, _ := http.NewRequest(http.MethodGet, "/v1/accounts", http.NoBody)
+		r.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, record.ID, sessionId)
+		mockSessionService.AssertNotCalled(t, "Verify")
+		mockSessionService.AssertExpectations(t)
+	})
+
+	t.Run("Rejects a cookie that cannot be upgraded", func(t *testing.T) {
+		mockSessionService := new(mocks.SessionService)
+		mockSessionService.On("UpgradeLegacy", uid, mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(nil, apperrors.NewAuthorization("Session expired, please sign in again"))
+
+		rr := httptest.NewRecorder()
+
+		_, r := gin.CreateTestContext(rr)
+		store := cookie.NewStore([]byte("secret"))
+		r.Use(sessions.Sessions("mqk", store))
+
+		r.Use(func(c *gin.Context) {
+			session := sessions.Default(c)
+			session.Set("userId", uid)
+		})
+
+		r.GET("/v1/accounts", AuthUser(mockSessionService, nil))
+
+		request, _ := http.NewRequest(http.MethodGet, "/v1/accounts", http.NoBody)
+		r.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+
+	t.Run("Accepts a bearer token", func(t *testing.T) {
+		raw := "mrg_" + fixture.RandStringRunes(43)
+		token := &model.AccessToken{ID: fixture.RandID(), UserID: uid, Scopes: []string{model.ScopeRead}}
+
+		mockTokenService := new(mocks.TokenService)
+		mockTokenService.On("Authenticate", raw).Return(token, nil)
+
+		rr := httptest.NewRecorder()
+
+		_, r := gin.CreateTestContext(rr)
+		store := cookie.NewStore([]byte("secret"))
+		r.Use(sessions.Sessions("mqk", store))
+
+		var contextUserId string
+
+		r.GET("/v1/accounts", AuthUser(nil, mockTokenService), func(c *gin.Context) {
+			contextKeyVal, _ := c.Get("userId")
+			contextUserId = contextKeyVal.(string)
+		})
+
+		request, _ := http.NewRequest(http.MethodGet, "/v1/accounts", http.NoBody)
+		request.Header.Set("Authorization", "Bearer "+raw)
+		r.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, uid, contextUserId)
+		assert.NotContains(t, rr.Header(), "Set-Cookie")
+		mockTokenService.AssertExpectations(t)
+	})
+
+	t.Run("Rejects an invalid bearer token", func(t *testing.T) {
+		raw := "mrg_" + fixture.RandStringRunes(43)
+
+		mockTokenService := new(mocks.TokenService)
+		mockTokenService.On("Authenticate", raw).Return(nil, apperrors.NewAuthorization("provided token is invalid"))
+
+		rr := httptest.NewRecorder()
+
+		_, r := gin.CreateTestContext(rr)
+		store := cookie.NewStore([]byte("secret"))
+		r.Use(sessions.Sessions("mqk", store))
+
+		r.GET("/v1/accounts", AuthUser(nil, mockTokenService))
+
+		request, _ := http.NewRequest(http.MethodGet, "/v1/accounts", http.NoBody)
+		request.Header.Set("Authorization", "Bearer "+raw)
+		r.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		mockTokenService.AssertExpectations(t)
+	})
+
+	t.Run("Rejects a token without the required scope", func(t *testing.T) {
+		raw := "mrg_" + fixture.RandStringRunes(43)
+		token := &model.AccessToken{ID: fixture.RandID(), UserID: uid, Scopes: []string{model.ScopeRead}}
+
+		mockTokenService := new(mocks.TokenService)
+		mockTokenService.On("Authenticate", raw).Return(token, nil)
+
+		rr := httptest.NewRecorder()
+
+		_, r := gin.CreateTestContext(rr)
+		store := cookie.NewStore([]byte("secret"))
+		r.Use(sessions.Sessions("mqk", store))
+
+		r.DELETE("/v1/posts/1", AuthUser(nil, mockTokenService))
+
+		request, _ := http.NewRequest(http.MethodDelete, "/v1/posts/1", http.NoBody)
+		request.Header.Set("Authorization", "Bearer "+raw)
+		r.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+		mockTokenService.AssertExpectations(t)
+	})
+
+	testCases := []struct {
+		name   string
+		method string
+		route  string
+		path   string
+		status int
+	}{
+		{name: "Import scope allows creating posts", method: http.MethodPost, route: "/v1/posts", path: "/v1/posts", status: http.StatusOK},
+		{name: "Import scope allows uploading chunks", method: http.MethodPut, route: "/v1/media/uploads/:id/chunks/:index", path: "/v1/media/uploads/1/chunks/0", status: http.StatusOK},
+		{name: "Import scope rejects following", method: http.MethodPost, route: "/v1/profiles/:username/follow", path: "/v1/profiles/someone/follow", status: http.StatusForbidden},
+		{name: "Import scope rejects enrolling 2FA", method: http.MethodPost, route: "/v1/accounts/2fa", path: "/v1/accounts/2fa", status: http.StatusForbidden},
+		{name: "Import scope rejects deleting posts", method: http.MethodDelete, route: "/v1/posts/:id", path: "/v1/posts/1", status: http.StatusForbidden},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			raw := "mrg_" + fixture.RandStringRunes(43)
+			token := &model.AccessToken{ID: fixture.RandID(), UserID: uid, Scopes: []string{model.ScopeImport}}
+
+			mockTokenService := new(mocks.TokenService)
+			mockTokenService.On("Authenticate", raw).Return(token, nil)
+
+			rr := httptest.NewRecorder()
+
+			_, r := gin.CreateTestContext(rr)
+			store := cookie.NewStore([]byte("secret"))
+			r.Use(sessions.Sessions("mqk", store))
+
+			r.Handle(tc.method, tc.route, AuthUser(nil, mockTokenService))
+
+			request, _ := http.NewRequest(tc.method, tc.path, http.NoBody)
+			request.Header.Set("Authorization", "Bearer "+raw)
+			r.ServeHTTP(rr, request)
+
+			assert.Equal(t, tc.status, rr.Code)
+			mockTokenService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRequireSession(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Rejects token authenticated requests", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+
+		_, r := gin.CreateTestContext(rr)
+		r.Use(func(c *gin.Context) {
+			c.Set("tokenId", fixture.RandID())
+		})
+
+		r.GET("/v1/accounts/tokens", RequireSession())
+
+		request, _ := http.NewRequest(http.MethodGet, "/v1/accounts/tokens", http.NoBody)
+		r.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+	})
+
+	t.Run("Allows session authenticated requests", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+
+		_, r := gin.CreateTestContext(rr)
+		r.GET("/v1/accounts/tokens", RequireSession())
+
+		request, _ := http.NewRequest(http.MethodGet, "/v1/accounts/tokens", http.NoBody)
+		r.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
 }
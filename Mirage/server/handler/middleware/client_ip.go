@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net"
+	"strings"
+)
+
+// ParseTrustedProxies parses a comma separated list of IPs and CIDRs
+// of the reverse proxies in front of the server
+func ParseTrustedProxies(list string) ([]*net.IPNet, error) {
+	proxies := make([]*net.IPNet, 0)
+
+	for _, p := range strings.Split(list, ",") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+
+		if !strings.Contains(p, "/") {
+			ip := net.ParseIP(p)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy: %s", p)
+			}
+
+			if ip.To4() != nil {
+				p += "/32"
+			} else {
+				p += "/128"
+			}
+		}
+
+		_, network, err := net.ParseCIDR(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy: %s", p)
+		}
+
+		proxies = append(proxies, network)
+	}
+
+	return proxies, nil
+}
+
+// RealIP saves the IP of the client in the context.
+// The X-Forwarded-For header can be set by anyone, so it is only
+// used for requests coming from one of the trusted proxies.
+func RealIP(proxies []*net.IPNet) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("clientIP", resolveClientIP(c, proxies))
+		c.Next()
+	}
+}
+
+// ClientIP returns the IP saved by RealIP
+// or the remote address of the request
+func ClientIP(c *gin.Context) string {
+	if ip := c.GetString("clientIP"); ip != "" {
+		return ip
+	}
+
+	return resolveClientIP(c, nil)
+}
+
+// resolveClientIP walks the X-Forwarded-For header from the right and returns
+// the first address that is not a trusted proxy, since only the entries
+// added by the trusted proxies can be relied on
+func resolveClientIP(c *gin.Context, proxies []*net.IPNet) string {
+	remote, _ := c.RemoteIP()
+
+	if remote == nil {
+		return ""
+	}
+
+	if !isTrustedProxy(remote, proxies) {
+		return remote.String()
+	}
+
+	forwarded := strings.Split(c.GetHeader("X-Forwarded-For"), ",")
+
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		ip := net.ParseIP(strings.TrimSpace(forwarded[i]))
+
+		if ip == nil {
+			break
+		}
+
+		if !isTrustedProxy(ip, proxies) {
+			return ip.String()
+		}
+	}
+
+	return remote.String()
+}
+
+func isTrustedProxy(ip net.IP, proxies []*net.IPNet) bool {
+	for _, p := range proxies {
+		if p.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRealIP(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	proxies, err := ParseTrustedProxies("10.0.0.1, 192.168.0.0/16")
+	assert.NoError(t, err)
+
+	clientIP := func(remote, forwarded string) string {
+		var ip string
+
+		_, r := gin.CreateTestContext(httptest.NewRecorder())
+		r.GET("/", RealIP(proxies), func(c *gin.Context) {
+			ip = ClientIP(c)
+		})
+
+		request, _ := http.NewRequest(http.MethodGet, "/", http.NoBody)
+		request.RemoteAddr = remote
+		if forwarded != "" {
+			request.Header.Set("X-Forwarded-For", forwarded)
+		}
+		r.ServeHTTP(httptest.NewRecorder(), request)
+
+		return ip
+	}
+
+	t.Run("Ignores X-Forwarded-For from untrusted clients", func(t *testing.T) {
+		assert.Equal(t, "203.0.113.7", clientIP("203.0.113.7:1234", "198.51.100.1"))
+	})
+
+	t.Run("Uses X-Forwarded-For from trusted proxies", func(t *testing.T) {
+		assert.Equal(t, "198.51.100.1", clientIP("10.0.0.1:1234", "198.51.100.1"))
+	})
+
+	t.Run("Skips entries added by the client", func(t *testing.T) {
+		assert.Equal(t, "198.51.100.1", clientIP("10.0.0.1:1234", "1.2.3.4, 198.51.100.1, 192.168.1.5"))
+	})
+
+	t.Run("Uses the remote address without a header", func(t *testing.T) {
+		assert.Equal(t, "10.0.0.1", clientIP("10.0.0.1:1234", ""))
+	})
+
+	t.Run("Rejects invalid proxies", func(t *testing.T) {
+		_, err := ParseTrustedProxies("10.0.0.1, proxy")
+		assert.Error(t, err)
+	})
+
+	t.Run("Trusts no proxies by default", func(t *testing.T) {
+		proxies, err := ParseTrustedProxies("")
+		assert.NoError(t, err)
+		assert.Empty(t, proxies)
+	})
+}
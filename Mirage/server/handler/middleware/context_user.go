@@ -4,14 +4,27 @@ import (
 	"fmt"
 	"github.com/gin-contrib/sessions"
 	"github.com/gin-gonic/gin"
+	"github.com/sentrionic/mirage/model"
 )
 
-func ContextUser() gin.HandlerFunc {
+// ContextUser saves the userId in the context if the request
+// contains a valid session or bearer token, but does not
+// reject requests without one.
+// Tokens without the scope for the request are ignored.
+func ContextUser(s model.SessionService, t model.TokenService) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if raw, ok := bearerToken(c); ok {
+			if authenticateToken(c, t, raw) && !hasRequiredScope(c) {
+				forgetToken(c)
+			}
+			c.Next()
+			return
+		}
+
 		session := sessions.Default(c)
 		id := session.Get("userId")
 
-		if id == nil {
+		if id == nil || !isActiveSession(c, s, session, id.(string)) {
 			c.Next()
 			return
 		}
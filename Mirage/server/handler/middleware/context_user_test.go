@@ -3,6 +3,8 @@ package middleware
 import (
 	"github.com/gin-contrib/sessions"
 	"github.com/gin-contrib/sessions/cookie"
+	"github.com/sentrionic/mirage/mocks"
+	"github.com/sentrionic/mirage/model"
 	"github.com/sentrionic/mirage/model/fixture"
 	"github.com/sentrionic/mirage/service"
 	"net/http"
@@ -33,7 +35,7 @@ func TestOptionalAuth(t *testing.T) {
 
 		var contextUserId string
 
-		r.GET("/v1/profiles/"+mockProfile.Username, ContextUser(), func(c *gin.Context) {
+		r.GET("/v1/profiles/"+mockProfile.Username, ContextUser(nil, nil), func(c *gin.Context) {
 			contextKeyVal, _ := c.Get("userId")
 			contextUserId = contextKeyVal.(string)
 		})
@@ -56,7 +58,7 @@ func TestOptionalAuth(t *testing.T) {
 
 		var contextUserId string
 
-		r.GET("/v1/profiles/"+mockProfile.Username, ContextUser(), func(c *gin.Context) {
+		r.GET("/v1/profiles/"+mockProfile.Username, ContextUser(nil, nil), func(c *gin.Context) {
 			contextKeyVal, exists := c.Get("userId")
 			if exists {
 				contextUserId = contextKeyVal.(string)
@@ -70,4 +72,65 @@ func TestOptionalAuth(t *testing.T) {
 		assert.Equal(t, http.StatusOK, rr.Code)
 		assert.Equal(t, "", contextUserId)
 	})
+
+	t.Run("Adds the token's userId to context", func(t *testing.T) {
+		mockProfile := fixture.GetMockUser()
+		raw := "mrg_" + fixture.RandStringRunes(43)
+		token := &model.AccessToken{ID: fixture.RandID(), UserID: uid, Scopes: []string{model.ScopeRead}}
+
+		mockTokenService := new(mocks.TokenService)
+		mockTokenService.On("Authenticate", raw).Return(token, nil)
+
+		rr := httptest.NewRecorder()
+
+		_, r := gin.CreateTestContext(rr)
+		store := cookie.NewStore([]byte("secret"))
+		r.Use(sessions.Sessions("mqk", store))
+
+		var contextUserId string
+
+		r.GET("/v1/profiles/"+mockProfile.Username, ContextUser(nil, mockTokenService), func(c *gin.Context) {
+			contextKeyVal, _ := c.Get("userId")
+			contextUserId = contextKeyVal.(string)
+		})
+
+		request, _ := http.NewRequest(http.MethodGet, "/v1/profiles/"+mockProfile.Username, http.NoBody)
+		request.Header.Set("Authorization", "Bearer "+raw)
+		r.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, uid, contextUserId)
+		mockTokenService.AssertExpectations(t)
+	})
+
+	t.Run("Ignores a token without the required scope", func(t *testing.T) {
+		mockProfile := fixture.GetMockUser()
+		raw := "mrg_" + fixture.RandStringRunes(43)
+		token := &model.AccessToken{ID: fixture.RandID(), UserID: uid, Scopes: []string{model.ScopeImport}}
+
+		mockTokenService := new(mocks.TokenService)
+		mockTokenService.On("Authenticate", raw).Return(token, nil)
+
+		rr := httptest.NewRecorder()
+
+		_, r := gin.CreateTestContext(rr)
+		store := cookie.NewStore([]byte("secret"))
+		r.Use(sessions.Sessions("mqk", store))
+
+		var userExists, tokenExists bool
+
+		r.GET("/v1/profiles/"+mockProfile.Username, ContextUser(nil, mockTokenService), func(c *gin.Context) {
+			_, userExists = c.Get("userId")
+			_, tokenExists = c.Get("tokenId")
+		})
+
+		request, _ := http.NewRequest(http.MethodGet, "/v1/profiles/"+mockProfile.Username, http.NoBody)
+		request.Header.Set("Authorization", "Bearer "+raw)
+		r.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.False(t, userExists)
+		assert.False(t, tokenExists)
+		mockTokenService.AssertExpectations(t)
+	})
 }
@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxKeyBodyBytes limits how much of the body gets read to find a key
+const maxKeyBodyBytes = 1 << 16
+
+// RateLimitKey returns the key a request gets counted under.
+// Requests with an empty key are not counted by the rule.
+type RateLimitKey func(c *gin.Context) string
+
+// RateLimitRule allows Limit requests per Window for every key
+type RateLimitRule struct {
+	Name   string
+	Limit  int64
+	Window time.Duration
+	Key    RateLimitKey
+}
+
+// ByIP counts requests per client IP
+func ByIP(c *gin.Context) string {
+	return ClientIP(c)
+}
+
+// ByUser counts requests per authenticated user
+func ByUser(c *gin.Context) string {
+	id, _ := c.Get("userId")
+	uid, _ := id.(string)
+	return uid
+}
+
+// ByEmail counts requests per email in the JSON body
+func ByEmail(c *gin.Context) string {
+	return strings.ToLower(strings.TrimSpace(jsonField(c, "email")))
+}
+
+// RateLimit rejects requests with 429 once a key exceeds the limit of one of the rules.
+// Requests are let through if no limiter is given or the limiter fails.
+func RateLimit(limiter model.RateLimiter, rules ...RateLimitRule) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if limiter == nil {
+			c.Next()
+			return
+		}
+
+		for _, rule := range rules {
+			key := rule.Key(c)
+
+			if key == "" {
+				continue
+			}
+
+			count, retryAfter, err := limiter.Hit(fmt.Sprintf("%s:%s", rule.Name, key), rule.Window)
+
+			if err != nil {
+				log.Printf("Failed to check rate limit %v: %v\n", rule.Name, err)
+				continue
+			}
+
+			if count > rule.Limit {
+				e := apperrors.NewTooManyRequests(retryAfter)
+				c.Header("Retry-After", strconv.Itoa(e.RetryAfter))
+				c.AbortWithStatusJSON(e.Status(), gin.H{
+					"error": e,
+				})
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// jsonField reads a string field from the JSON body
+// and restores the body for the handler
+func jsonField(c *gin.Context, field string) string {
+	if c.Request.Body == nil {
+		return ""
+	}
+
+	data, err := io.ReadAll(io.LimitReader(c.Request.Body, maxKeyBodyBytes))
+	c.Request.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(data), c.Request.Body), c.Request.Body}
+
+	if err != nil {
+		return ""
+	}
+
+	var body map[string]interface{}
+	if err := json.Unmarshal(data, &body); err != nil {
+		return ""
+	}
+
+	value, _ := body[field].(string)
+	return value
+}
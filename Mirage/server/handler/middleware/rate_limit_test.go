@@ -0,0 +1,151 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/sentrionic/mirage/mocks"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"github.com/sentrionic/mirage/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	now := time.Unix(1600000000, 0)
+	clock := func() time.Time { return now }
+
+	send := func(r *gin.Engine, body gin.H) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		reqBody, _ := json.Marshal(body)
+		request, _ := http.NewRequest(http.MethodPost, "/login", bytes.NewBuffer(reqBody))
+		request.Header.Set("Content-Type", "application/json")
+		request.RemoteAddr = "10.0.0.1:1234"
+		r.ServeHTTP(rr, request)
+		return rr
+	}
+
+	t.Run("Limits by IP", func(t *testing.T) {
+		limiter := repository.NewMemoryRateLimiter(clock)
+
+		_, r := gin.CreateTestContext(httptest.NewRecorder())
+		r.POST("/login", RateLimit(limiter, RateLimitRule{
+			Name: "ip", Limit: 2, Window: time.Minute, Key: ByIP,
+		}), func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+
+		assert.Equal(t, http.StatusOK, send(r, gin.H{}).Code)
+		assert.Equal(t, http.StatusOK, send(r, gin.H{}).Code)
+
+		rr := send(r, gin.H{})
+		assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+		assert.Equal(t, "60", rr.Header().Get("Retry-After"))
+
+		var body struct {
+			Error apperrors.Error `json:"error"`
+		}
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+		assert.Equal(t, apperrors.TooManyRequests, body.Error.Type)
+		assert.Equal(t, 60, body.Error.RetryAfter)
+	})
+
+	t.Run("Ignores spoofed X-Forwarded-For headers", func(t *testing.T) {
+		limiter := repository.NewMemoryRateLimiter(clock)
+
+		_, r := gin.CreateTestContext(httptest.NewRecorder())
+		r.POST("/login", RealIP(nil), RateLimit(limiter, RateLimitRule{
+			Name: "ip", Limit: 1, Window: time.Minute, Key: ByIP,
+		}), func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+
+		sendFrom := func(forwarded string) int {
+			rr := httptest.NewRecorder()
+			request, _ := http.NewRequest(http.MethodPost, "/login", http.NoBody)
+			request.RemoteAddr = "10.0.0.1:1234"
+			request.Header.Set("X-Forwarded-For", forwarded)
+			r.ServeHTTP(rr, request)
+			return rr.Code
+		}
+
+		assert.Equal(t, http.StatusOK, sendFrom("198.51.100.1"))
+		assert.Equal(t, http.StatusTooManyRequests, sendFrom("198.51.100.2"))
+	})
+
+	t.Run("Limits by email and keeps the body", func(t *testing.T) {
+		limiter := repository.NewMemoryRateLimiter(clock)
+		var emails []string
+
+		_, r := gin.CreateTestContext(httptest.NewRecorder())
+		r.POST("/login", RateLimit(limiter, RateLimitRule{
+			Name: "email", Limit: 1, Window: time.Minute, Key: ByEmail,
+		}), func(c *gin.Context) {
+			var req struct {
+				Email string `json:"email"`
+			}
+			assert.NoError(t, c.ShouldBindJSON(&req))
+			emails = append(emails, req.Email)
+			c.Status(http.StatusOK)
+		})
+
+		assert.Equal(t, http.StatusOK, send(r, gin.H{"email": "bob@bob.com"}).Code)
+		assert.Equal(t, http.StatusTooManyRequests, send(r, gin.H{"email": " BOB@bob.com"}).Code)
+		assert.Equal(t, http.StatusOK, send(r, gin.H{"email": "alice@bob.com"}).Code)
+		assert.Equal(t, []string{"bob@bob.com", "alice@bob.com"}, emails)
+	})
+
+	t.Run("Skips requests without a key", func(t *testing.T) {
+		limiter := new(mocks.RateLimiter)
+
+		_, r := gin.CreateTestContext(httptest.NewRecorder())
+		r.POST("/login", RateLimit(limiter, RateLimitRule{
+			Name: "user", Limit: 1, Window: time.Minute, Key: ByUser,
+		}), func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+
+		assert.Equal(t, http.StatusOK, send(r, gin.H{}).Code)
+		limiter.AssertNotCalled(t, "Hit", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Lets requests through when the limiter fails", func(t *testing.T) {
+		limiter := new(mocks.RateLimiter)
+		limiter.On("Hit", mock.AnythingOfType("string"), time.Minute).
+			Return(int64(0), time.Duration(0), apperrors.NewInternal())
+
+		_, r := gin.CreateTestContext(httptest.NewRecorder())
+		r.POST("/login", RateLimit(limiter, RateLimitRule{
+			Name: "ip", Limit: 1, Window: time.Minute, Key: ByIP,
+		}), func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+
+		assert.Equal(t, http.StatusOK, send(r, gin.H{}).Code)
+		limiter.AssertExpectations(t)
+	})
+
+	t.Run("Window resets", func(t *testing.T) {
+		current := now
+		limiter := repository.NewMemoryRateLimiter(func() time.Time { return current })
+
+		_, r := gin.CreateTestContext(httptest.NewRecorder())
+		r.POST("/login", RateLimit(limiter, RateLimitRule{
+			Name: "ip", Limit: 1, Window: time.Minute, Key: ByIP,
+		}), func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+
+		assert.Equal(t, http.StatusOK, send(r, gin.H{}).Code)
+		assert.Equal(t, http.StatusTooManyRequests, send(r, gin.H{}).Code)
+
+		current = current.Add(time.Minute)
+		assert.Equal(t, http.StatusOK, send(r, gin.H{}).Code)
+	})
+}
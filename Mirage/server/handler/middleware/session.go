@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"github.com/sentrionic/mirage/model"
+	"log"
+)
+
+// isActiveSession checks that the session record referenced
+// by the cookie has not been revoked. Cookies from before session
+// records existed get a new record, which the caller saves in the cookie,
+// unless the user revoked all of their sessions since.
+func isActiveSession(c *gin.Context, s model.SessionService, session sessions.Session, uid string) bool {
+	if s == nil {
+		return true
+	}
+
+	sid, ok := session.Get("sessionId").(string)
+
+	if !ok || sid == "" {
+		return upgradeLegacySession(c, s, session, uid)
+	}
+
+	valid, err := s.Verify(uid, sid, ClientIP(c))
+
+	if err != nil {
+		log.Printf("Unable to verify session: %v\n%v", sid, err)
+		return false
+	}
+
+	return valid
+}
+
+// upgradeLegacySession records a session for a cookie that only
+// holds the user's ID, so that existing logins keep working
+func upgradeLegacySession(c *gin.Context, s model.SessionService, session sessions.Session, uid string) bool {
+	record, err := s.UpgradeLegacy(uid, c.Request.UserAgent(), ClientIP(c))
+
+	if err != nil {
+		log.Printf("Unable to upgrade session of user: %v\n%v", uid, err)
+		return false
+	}
+
+	session.Set("sessionId", record.ID)
+	return true
+}
@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"net/http"
+	"strings"
+)
+
+// bearerToken returns the token of the Authorization header, if there is one
+func bearerToken(c *gin.Context) (string, bool) {
+	header := c.GetHeader("Authorization")
+
+	if !strings.HasPrefix(header, "Bearer ") {
+		return "", false
+	}
+
+	return strings.TrimSpace(strings.TrimPrefix(header, "Bearer ")), true
+}
+
+// authenticateToken saves the token's user and scopes in the context.
+// Tokens already authenticated by ContextUser are not looked up again.
+func authenticateToken(c *gin.Context, t model.TokenService, raw string) bool {
+	if _, exists := c.Get("tokenId"); exists {
+		return true
+	}
+
+	if t == nil {
+		return false
+	}
+
+	token, err := t.Authenticate(raw)
+
+	if err != nil {
+		return false
+	}
+
+	c.Set("userId", token.UserID)
+	c.Set("tokenId", token.ID)
+	c.Set("scopes", []string(token.Scopes))
+
+	return true
+}
+
+// forgetToken removes the token's user and scopes from the context
+func forgetToken(c *gin.Context) {
+	delete(c.Keys, "userId")
+	delete(c.Keys, "tokenId")
+	delete(c.Keys, "scopes")
+}
+
+// importRoutes are the routes an import token may call to bulk import
+// an archive: creating posts and uploading their media
+var importRoutes = map[string]bool{
+	http.MethodPost + " /v1/posts":                          true,
+	http.MethodPost + " /v1/media/uploads":                  true,
+	http.MethodPost + " /v1/media/uploads/chunked":          true,
+	http.MethodPut + " /v1/media/uploads/:id/chunks/:index": true,
+	http.MethodPost + " /v1/media/uploads/:id/finalize":     true,
+}
+
+// hasRequiredScope checks if the token's scopes allow the request's method.
+// The import scope only allows the import routes.
+// Requests authenticated with a cookie session are allowed everything.
+func hasRequiredScope(c *gin.Context) bool {
+	value, exists := c.Get("scopes")
+
+	if !exists {
+		return true
+	}
+
+	token := &model.AccessToken{Scopes: value.([]string)}
+
+	switch c.Request.Method {
+	case http.MethodGet, http.MethodHead:
+		return token.HasScope(model.ScopeRead)
+	default:
+		if token.HasScope(model.ScopeImport) && importRoutes[c.Request.Method+" "+c.FullPath()] {
+			return true
+		}
+		return token.HasScope(model.ScopeWrite)
+	}
+}
+
+// RequireSession rejects requests that are authenticated
+// with an access token instead of a cookie session
+func RequireSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, exists := c.Get("tokenId"); exists {
+			e := apperrors.NewForbidden("access tokens cannot be used for this endpoint")
+			c.JSON(e.Status(), gin.H{
+				"error": e,
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
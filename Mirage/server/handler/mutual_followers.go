@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"log"
+	"net/http"
+)
+
+// GetMutualFollowers handler returns a page of the users following
+// the profile that the current user follows, newest follow first
+func (h *Handler) GetMutualFollowers(c *gin.Context) {
+	username := c.Param("username")
+	cursor := c.Query("cursor")
+
+	userId := c.MustGet("userId").(string)
+
+	user, err := h.UserService.FindByUsername(username)
+
+	if err != nil {
+		log.Printf("Unable to find user: %v\n%v", username, err)
+		e := apperrors.NewNotFound("profile", username)
+
+		c.JSON(e.Status(), gin.H{
+			"error": e,
+		})
+		return
+	}
+
+	users, next, err := h.UserService.MutualFollowers(user.ID, userId, cursor)
+
+	if err != nil {
+		log.Printf("Unable to find mutual followers of user: %v\n%v", username, err)
+		c.JSON(apperrors.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	response := make([]model.FollowProfile, 0)
+
+	for i, u := range *users {
+		if i != model.LIMIT {
+			response = append(response, u.NewFollowProfileResponse(userId))
+		}
+	}
+
+	body := gin.H{
+		"profiles": response,
+		"hasMore":  next != "",
+	}
+
+	if next != "" {
+		body["nextCursor"] = next
+	}
+
+	c.JSON(http.StatusOK, body)
+}
@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-contrib/sessions/cookie"
+	"github.com/gin-gonic/gin"
+	"github.com/sentrionic/mirage/mocks"
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/fixture"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandler_GetMutualFollowers(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	current := fixture.GetMockUser()
+
+	t.Run("Success", func(t *testing.T) {
+		mockUser := fixture.GetMockUser()
+
+		// the first mutual follower follows the current user back
+		first := fixture.GetMockUser()
+		first.Followers = []*model.User{current}
+		first.Followee = []*model.User{current}
+		second := fixture.GetMockUser()
+		second.Followers = []*model.User{current}
+		users := []model.User{*first, *second}
+
+		mockUserService := new(mocks.UserService)
+		mockUserService.On("FindByUsername", mockUser.Username).Return(mockUser, nil)
+		mockUserService.On("MutualFollowers", mockUser.ID, current.ID, "").Return(&users, "", nil)
+
+		// a response recorder for getting written http response
+		rr := httptest.NewRecorder()
+
+		router := gin.Default()
+		store := cookie.NewStore([]byte("secret"))
+		router.Use(sessions.Sessions("mqk", store))
+
+		router.Use(func(c *gin.Context) {
+			session := sessions.Default(c)
+			session.Set("userId", current.ID)
+			c.Set("userId", current.ID)
+		})
+
+		NewHandler(&Config{
+			R:           router,
+			UserService: mockUserService,
+		})
+
+		url := fmt.Sprintf("/v1/profiles/%s/followers/mutual", mockUser.Username)
+		request, err := http.NewRequest(http.MethodGet, url, nil)
+		assert.NoError(t, err)
+
+		router.ServeHTTP(rr, request)
+
+		var body struct {
+			Profiles []model.FollowProfile `json:"profiles"`
+			HasMore  bool                  `json:"hasMore"`
+		}
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.False(t, body.HasMore)
+		assert.Len(t, body.Profiles, 2)
+		assert.True(t, body.Profiles[0].Following)
+		assert.True(t, body.Profiles[0].FollowsYou)
+		assert.True(t, body.Profiles[1].Following)
+		assert.False(t, body.Profiles[1].FollowsYou)
+		mockUserService.AssertExpectations(t)
+	})
+
+	t.Run("Unauthorized", func(t *testing.T) {
+		mockUserService := new(mocks.UserService)
+
+		rr := httptest.NewRecorder()
+
+		router := gin.Default()
+		store := cookie.NewStore([]byte("secret"))
+		router.Use(sessions.Sessions("mqk", store))
+
+		NewHandler(&Config{
+			R:           router,
+			UserService: mockUserService,
+		})
+
+		request, err := http.NewRequest(http.MethodGet, "/v1/profiles/someone/followers/mutual", nil)
+		assert.NoError(t, err)
+
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		mockUserService.AssertNotCalled(t, "MutualFollowers", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// GetProfileCalendar handler returns the number of the profile's posts per month,
+// or per day of the year given by the "year" query
+func (h *Handler) GetProfileCalendar(c *gin.Context) {
+	username := c.Param("username")
+
+	year := 0
+	if value := c.Query("year"); value != "" {
+		y, err := strconv.Atoi(value)
+
+		if err != nil {
+			e := apperrors.NewBadRequest("invalid year")
+			c.JSON(e.Status(), gin.H{
+				"error": e,
+			})
+			return
+		}
+		year = y
+	}
+
+	user, err := h.UserService.FindByUsername(username)
+
+	if err != nil {
+		log.Printf("Unable to find user: %v\n%v", username, err)
+		e := apperrors.NewNotFound("profile", username)
+
+		c.JSON(e.Status(), gin.H{
+			"error": e,
+		})
+		return
+	}
+
+	buckets, err := h.PostService.Calendar(user.ID, year)
+
+	if err != nil {
+		log.Printf("Unable to get the calendar of user: %v\n%v", username, err)
+		c.JSON(apperrors.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, buckets)
+}
@@ -0,0 +1,214 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-contrib/sessions/cookie"
+	"github.com/gin-gonic/gin"
+	"github.com/sentrionic/mirage/mocks"
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"github.com/sentrionic/mirage/model/fixture"
+	"github.com/sentrionic/mirage/service"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandler_GetProfileCalendar(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Months", func(t *testing.T) {
+		mockUserResp := fixture.GetMockUser()
+
+		mockUserService := new(mocks.UserService)
+		mockUserService.On("FindByUsername", mockUserResp.Username).Return(mockUserResp, nil)
+
+		buckets := []model.CalendarBucket{{Period: "2022-02", Count: 4}, {Period: "2021-12", Count: 1}}
+
+		mockPostService := new(mocks.PostService)
+		mockPostService.On("Calendar", mockUserResp.ID, 0).Return(buckets, nil)
+
+		// a response recorder for getting written http response
+		rr := httptest.NewRecorder()
+
+		router := gin.Default()
+		store := cookie.NewStore([]byte("secret"))
+		router.Use(sessions.Sessions("mqk", store))
+
+		NewHandler(&Config{
+			R:           router,
+			UserService: mockUserService,
+			PostService: mockPostService,
+		})
+
+		url := fmt.Sprintf("/v1/profiles/%s/calendar", mockUserResp.Username)
+		request, err := http.NewRequest(http.MethodGet, url, nil)
+		assert.NoError(t, err)
+
+		router.ServeHTTP(rr, request)
+
+		respBody, err := json.Marshal(buckets)
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+		mockUserService.AssertExpectations(t)
+		mockPostService.AssertExpectations(t)
+	})
+
+	t.Run("Days of a year", func(t *testing.T) {
+		mockUserResp := fixture.GetMockUser()
+
+		mockUserService := new(mocks.UserService)
+		mockUserService.On("FindByUsername", mockUserResp.Username).Return(mockUserResp, nil)
+
+		buckets := []model.CalendarBucket{{Period: "2021-12-24", Count: 2}}
+
+		mockPostService := new(mocks.PostService)
+		mockPostService.On("Calendar", mockUserResp.ID, 2021).Return(buckets, nil)
+
+		// a response recorder for getting written http response
+		rr := httptest.NewRecorder()
+
+		router := gin.Default()
+		store := cookie.NewStore([]byte("secret"))
+		router.Use(sessions.Sessions("mqk", store))
+
+		NewHandler(&Config{
+			R:           router,
+			UserService: mockUserService,
+			PostService: mockPostService,
+		})
+
+		url := fmt.Sprintf("/v1/profiles/%s/calendar?year=2021", mockUserResp.Username)
+		request, err := http.NewRequest(http.MethodGet, url, nil)
+		assert.NoError(t, err)
+
+		router.ServeHTTP(rr, request)
+
+		respBody, err := json.Marshal(buckets)
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+		mockPostService.AssertExpectations(t)
+	})
+
+	t.Run("Invalid year", func(t *testing.T) {
+		mockUserService := new(mocks.UserService)
+		mockPostService := new(mocks.PostService)
+
+		// a response recorder for getting written http response
+		rr := httptest.NewRecorder()
+
+		router := gin.Default()
+		store := cookie.NewStore([]byte("secret"))
+		router.Use(sessions.Sessions("mqk", store))
+
+		NewHandler(&Config{
+			R:           router,
+			UserService: mockUserService,
+			PostService: mockPostService,
+		})
+
+		request, err := http.NewRequest(http.MethodGet, "/v1/profiles/someone/calendar?year=last", nil)
+		assert.NoError(t, err)
+
+		router.ServeHTTP(rr, request)
+
+		respErr := apperrors.NewBadRequest("invalid year")
+
+		respBody, err := json.Marshal(gin.H{
+			"error": respErr,
+		})
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+		mockUserService.AssertNotCalled(t, "FindByUsername")
+		mockPostService.AssertNotCalled(t, "Calendar")
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		username, _ := service.GenerateId()
+
+		mockUserService := new(mocks.UserService)
+		mockUserService.On("FindByUsername", username).Return(nil, fmt.Errorf("some error down call chain"))
+
+		mockPostService := new(mocks.PostService)
+
+		// a response recorder for getting written http response
+		rr := httptest.NewRecorder()
+
+		router := gin.Default()
+		store := cookie.NewStore([]byte("secret"))
+		router.Use(sessions.Sessions("mqk", store))
+
+		NewHandler(&Config{
+			R:           router,
+			UserService: mockUserService,
+			PostService: mockPostService,
+		})
+
+		url := fmt.Sprintf("/v1/profiles/%s/calendar", username)
+		request, err := http.NewRequest(http.MethodGet, url, nil)
+		assert.NoError(t, err)
+
+		router.ServeHTTP(rr, request)
+
+		respErr := apperrors.NewNotFound("profile", username)
+
+		respBody, err := json.Marshal(gin.H{
+			"error": respErr,
+		})
+		assert.NoError(t, err)
+
+		assert.Equal(t, respErr.Status(), rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+		mockPostService.AssertNotCalled(t, "Calendar")
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		mockUserResp := fixture.GetMockUser()
+
+		mockUserService := new(mocks.UserService)
+		mockUserService.On("FindByUsername", mockUserResp.Username).Return(mockUserResp, nil)
+
+		mockError := apperrors.NewBadRequest("invalid year")
+
+		mockPostService := new(mocks.PostService)
+		mockPostService.On("Calendar", mockUserResp.ID, 10000).Return(nil, mockError)
+
+		// a response recorder for getting written http response
+		rr := httptest.NewRecorder()
+
+		router := gin.Default()
+		store := cookie.NewStore([]byte("secret"))
+		router.Use(sessions.Sessions("mqk", store))
+
+		NewHandler(&Config{
+			R:           router,
+			UserService: mockUserService,
+			PostService: mockPostService,
+		})
+
+		url := fmt.Sprintf("/v1/profiles/%s/calendar?year=10000", mockUserResp.Username)
+		request, err := http.NewRequest(http.MethodGet, url, nil)
+		assert.NoError(t, err)
+
+		router.ServeHTTP(rr, request)
+
+		respBody, err := json.Marshal(gin.H{
+			"error": mockError,
+		})
+		assert.NoError(t, err)
+
+		assert.Equal(t, mockError.Status(), rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+		mockPostService.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"log"
+	"net/http"
+)
+
+// followList returns a page of the follows of the user and the cursor of the next page
+type followList func(userId, cursor string) (*[]model.User, string, error)
+
+// GetProfileFollowers handler returns a page of the users following the profile,
+// newest follow first
+func (h *Handler) GetProfileFollowers(c *gin.Context) {
+	h.profileFollows(c, "followers", h.UserService.Followers)
+}
+
+// GetProfileFollowing handler returns a page of the users the profile follows,
+// newest follow first
+func (h *Handler) GetProfileFollowing(c *gin.Context) {
+	h.profileFollows(c, "following", h.UserService.Following)
+}
+
+// profileFollows responds with the page of the profile's follows the list returns
+func (h *Handler) profileFollows(c *gin.Context, name string, list followList) {
+	username := c.Param("username")
+	cursor := c.Query("cursor")
+
+	var userId string
+	value, exists := c.Get("userId")
+
+	if exists {
+		userId = value.(string)
+	}
+
+	user, err := h.UserService.FindByUsername(username)
+
+	if err != nil {
+		log.Printf("Unable to find user: %v\n%v", username, err)
+		e := apperrors.NewNotFound("profile", username)
+
+		c.JSON(e.Status(), gin.H{
+			"error": e,
+		})
+		return
+	}
+
+	users, next, err := list(user.ID, cursor)
+
+	if err != nil {
+		log.Printf("Unable to find %s of user: %v\n%v", name, username, err)
+		c.JSON(apperrors.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	response := make([]model.FollowProfile, 0)
+
+	for i, u := range *users {
+		if i != model.LIMIT {
+			response = append(response, u.NewFollowProfileResponse(userId))
+		}
+	}
+
+	body := gin.H{
+		"profiles": response,
+		"hasMore":  next != "",
+	}
+
+	if next != "" {
+		body["nextCursor"] = next
+	}
+
+	c.JSON(http.StatusOK, body)
+}
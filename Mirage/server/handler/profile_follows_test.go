@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-contrib/sessions/cookie"
+	"github.com/gin-gonic/gin"
+	"github.com/sentrionic/mirage/mocks"
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"github.com/sentrionic/mirage/model/fixture"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandler_GetProfileFollows(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	current := fixture.GetMockUser()
+
+	lists := []struct {
+		Path   string
+		Method string
+	}{
+		{Path: "followers", Method: "Followers"},
+		{Path: "following", Method: "Following"},
+	}
+
+	for _, list := range lists {
+		t.Run(list.Path, func(t *testing.T) {
+			t.Run("Success", func(t *testing.T) {
+				mockUser := fixture.GetMockUser()
+
+				users := make([]model.User, 0)
+				for i := 0; i < 3; i++ {
+					users = append(users, *fixture.GetMockUser())
+				}
+
+				mockUserService := new(mocks.UserService)
+				mockUserService.On("FindByUsername", mockUser.Username).Return(mockUser, nil)
+				mockUserService.On(list.Method, mockUser.ID, "cursor").Return(&users, "next", nil)
+
+				// a response recorder for getting written http response
+				rr := httptest.NewRecorder()
+
+				router := gin.Default()
+				store := cookie.NewStore([]byte("secret"))
+				router.Use(sessions.Sessions("mqk", store))
+
+				router.Use(func(c *gin.Context) {
+					c.Set("userId", current.ID)
+				})
+
+				NewHandler(&Config{
+					R:           router,
+					UserService: mockUserService,
+				})
+
+				url := fmt.Sprintf("/v1/profiles/%s/%s?cursor=cursor", mockUser.Username, list.Path)
+				request, err := http.NewRequest(http.MethodGet, url, nil)
+				assert.NoError(t, err)
+
+				router.ServeHTTP(rr, request)
+
+				profiles := make([]model.FollowProfile, 0)
+				for _, u := range users {
+					profiles = append(profiles, u.NewFollowProfileResponse(current.ID))
+				}
+
+				respBody, err := json.Marshal(gin.H{
+					"profiles":   profiles,
+					"hasMore":    true,
+					"nextCursor": "next",
+				})
+				assert.NoError(t, err)
+
+				assert.Equal(t, http.StatusOK, rr.Code)
+				assert.Equal(t, respBody, rr.Body.Bytes())
+				mockUserService.AssertExpectations(t)
+			})
+
+			t.Run("Invalid cursor", func(t *testing.T) {
+				mockUser := fixture.GetMockUser()
+
+				mockUserService := new(mocks.UserService)
+				mockUserService.On("FindByUsername", mockUser.Username).Return(mockUser, nil)
+				mockUserService.On(list.Method, mockUser.ID, "20").Return(nil, "", apperrors.NewBadRequest("invalid cursor"))
+
+				rr := httptest.NewRecorder()
+
+				router := gin.Default()
+				store := cookie.NewStore([]byte("secret"))
+				router.Use(sessions.Sessions("mqk", store))
+
+				NewHandler(&Config{
+					R:           router,
+					UserService: mockUserService,
+				})
+
+				url := fmt.Sprintf("/v1/profiles/%s/%s?cursor=20", mockUser.Username, list.Path)
+				request, err := http.NewRequest(http.MethodGet, url, nil)
+				assert.NoError(t, err)
+
+				router.ServeHTTP(rr, request)
+
+				assert.Equal(t, http.StatusBadRequest, rr.Code)
+			})
+
+			t.Run("NotFound", func(t *testing.T) {
+				mockUserService := new(mocks.UserService)
+				mockUserService.On("FindByUsername", "unknown").Return(nil, fmt.Errorf("some error down call chain"))
+
+				rr := httptest.NewRecorder()
+
+				router := gin.Default()
+				store := cookie.NewStore([]byte("secret"))
+				router.Use(sessions.Sessions("mqk", store))
+
+				NewHandler(&Config{
+					R:           router,
+					UserService: mockUserService,
+				})
+
+				request, err := http.NewRequest(http.MethodGet, "/v1/profiles/unknown/"+list.Path, nil)
+				assert.NoError(t, err)
+
+				router.ServeHTTP(rr, request)
+
+				respBody, err := json.Marshal(gin.H{
+					"error": apperrors.NewNotFound("profile", "unknown"),
+				})
+				assert.NoError(t, err)
+
+				assert.Equal(t, http.StatusNotFound, rr.Code)
+				assert.Equal(t, respBody, rr.Body.Bytes())
+				mockUserService.AssertNotCalled(t, list.Method)
+			})
+		})
+	}
+}
@@ -16,6 +16,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 )
 
 func TestHandler_GetProfileLikes(t *testing.T) {
@@ -33,11 +34,13 @@ func TestHandler_GetProfileLikes(t *testing.T) {
 
 		for i := 0; i < 5; i++ {
 			mockPost := fixture.GetMockPost()
+			mockPost.Likes = []model.User{*mockUserResp}
+			mockPost.Activity = &model.TimelineActivity{Reason: model.ReasonLikedBy, Actor: *mockUserResp, At: time.Now()}
 			posts = append(posts, *mockPost)
 		}
 
 		mockPostService := new(mocks.PostService)
-		mockPostService.On("ProfileLikes", mockUserResp.ID, "").Return(&posts, nil)
+		mockPostService.On("ProfileLikes", mockUserResp.ID, "", model.TimeRange{}).Return(&posts, "", nil)
 
 		// a response recorder for getting written http response
 		rr := httptest.NewRecorder()
@@ -64,10 +67,10 @@ func TestHandler_GetProfileLikes(t *testing.T) {
 
 		router.ServeHTTP(rr, request)
 
-		rsp := make([]model.PostResponse, 0)
+		rsp := make([]model.TimelineItemResponse, 0)
 
 		for _, p := range posts {
-			post := p.NewPostResponse(uid)
+			post := p.NewTimelineItemResponse(uid)
 			rsp = append(rsp, post)
 		}
 
@@ -96,7 +99,7 @@ func TestHandler_GetProfileLikes(t *testing.T) {
 		}
 
 		mockPostService := new(mocks.PostService)
-		mockPostService.On("ProfileLikes", mockUserResp.ID, "").Return(&posts, nil)
+		mockPostService.On("ProfileLikes", mockUserResp.ID, "", model.TimeRange{}).Return(&posts, "", nil)
 
 		// a response recorder for getting written http response
 		rr := httptest.NewRecorder()
@@ -117,10 +120,10 @@ func TestHandler_GetProfileLikes(t *testing.T) {
 
 		router.ServeHTTP(rr, request)
 
-		rsp := make([]model.PostResponse, 0)
+		rsp := make([]model.TimelineItemResponse, 0)
 
 		for _, p := range posts {
-			post := p.NewPostResponse(uid)
+			post := p.NewTimelineItemResponse(uid)
 			rsp = append(rsp, post)
 		}
 
@@ -142,7 +145,7 @@ func TestHandler_GetProfileLikes(t *testing.T) {
 		mockUserService.On("FindByUsername", username).Return(nil, fmt.Errorf("some error down call chain"))
 
 		mockPostService := new(mocks.PostService)
-		mockPostService.On("ProfileLikes", mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(nil, nil)
+		mockPostService.On("ProfileLikes", mock.AnythingOfType("string"), mock.AnythingOfType("string"), model.TimeRange{}).Return(nil, "", nil)
 
 		// a response recorder for getting written http response
 		rr := httptest.NewRecorder()
@@ -181,4 +184,42 @@ func TestHandler_GetProfileLikes(t *testing.T) {
 		mockPostService.AssertNotCalled(t, "ProfileLikes")
 	})
 
+	t.Run("Error", func(t *testing.T) {
+		mockUserResp := fixture.GetMockUser()
+
+		mockUserService := new(mocks.UserService)
+		mockUserService.On("FindByUsername", mockUserResp.Username).Return(mockUserResp, nil)
+
+		mockError := apperrors.NewBadRequest("invalid cursor")
+		mockPostService := new(mocks.PostService)
+		mockPostService.On("ProfileLikes", mockUserResp.ID, "invalid", model.TimeRange{}).Return(nil, "", mockError)
+
+		rr := httptest.NewRecorder()
+
+		router := gin.Default()
+		store := cookie.NewStore([]byte("secret"))
+		router.Use(sessions.Sessions("mqk", store))
+
+		NewHandler(&Config{
+			R:           router,
+			UserService: mockUserService,
+			PostService: mockPostService,
+		})
+
+		url := fmt.Sprintf("/v1/profiles/%s/likes?cursor=invalid", mockUserResp.Username)
+		request, err := http.NewRequest(http.MethodGet, url, nil)
+		assert.NoError(t, err)
+
+		router.ServeHTTP(rr, request)
+
+		respBody, err := json.Marshal(gin.H{
+			"error": mockError,
+		})
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+		mockPostService.AssertExpectations(t)
+	})
+
 }
@@ -12,6 +12,12 @@ func (h *Handler) GetProfileMedia(c *gin.Context) {
 	username := c.Param("username")
 	cursor := c.Query("cursor")
 
+	timeRange, ok := bindTimeRange(c)
+
+	if !ok {
+		return
+	}
+
 	var userId string
 	value, exists := c.Get("userId")
 
@@ -31,14 +37,12 @@ func (h *Handler) GetProfileMedia(c *gin.Context) {
 		return
 	}
 
-	posts, err := h.PostService.ProfileMedia(user.ID, cursor)
+	posts, err := h.PostService.ProfileMedia(user.ID, cursor, timeRange)
 
 	if err != nil {
 		log.Printf("Unable to find media posts for user: %v\n%v", username, err)
-		e := apperrors.NewNotFound("posts", username)
-
-		c.JSON(e.Status(), gin.H{
-			"error": e,
+		c.JSON(apperrors.Status(err), gin.H{
+			"error": err,
 		})
 		return
 	}
@@ -38,7 +38,7 @@ func TestHandler_GetProfileMedia(t *testing.T) {
 		}
 
 		mockPostService := new(mocks.PostService)
-		mockPostService.On("ProfileMedia", mockUserResp.ID, "").Return(&posts, nil)
+		mockPostService.On("ProfileMedia", mockUserResp.ID, "", model.TimeRange{}).Return(&posts, nil)
 
 		// a response recorder for getting written http response
 		rr := httptest.NewRecorder()
@@ -98,7 +98,7 @@ func TestHandler_GetProfileMedia(t *testing.T) {
 		}
 
 		mockPostService := new(mocks.PostService)
-		mockPostService.On("ProfileMedia", mockUserResp.ID, "").Return(&posts, nil)
+		mockPostService.On("ProfileMedia", mockUserResp.ID, "", model.TimeRange{}).Return(&posts, nil)
 
 		// a response recorder for getting written http response
 		rr := httptest.NewRecorder()
@@ -144,7 +144,7 @@ func TestHandler_GetProfileMedia(t *testing.T) {
 		mockUserService.On("FindByUsername", username).Return(nil, fmt.Errorf("some error down call chain"))
 
 		mockPostService := new(mocks.PostService)
-		mockPostService.On("ProfileMedia", mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(nil, nil)
+		mockPostService.On("ProfileMedia", mock.AnythingOfType("string"), mock.AnythingOfType("string"), model.TimeRange{}).Return(nil, nil)
 
 		// a response recorder for getting written http response
 		rr := httptest.NewRecorder()
@@ -183,4 +183,42 @@ func TestHandler_GetProfileMedia(t *testing.T) {
 		mockPostService.AssertNotCalled(t, "ProfileMedia")
 	})
 
+	t.Run("Error", func(t *testing.T) {
+		mockUserResp := fixture.GetMockUser()
+
+		mockUserService := new(mocks.UserService)
+		mockUserService.On("FindByUsername", mockUserResp.Username).Return(mockUserResp, nil)
+
+		mockError := apperrors.NewBadRequest("invalid cursor")
+		mockPostService := new(mocks.PostService)
+		mockPostService.On("ProfileMedia", mockUserResp.ID, "invalid", model.TimeRange{}).Return(nil, mockError)
+
+		rr := httptest.NewRecorder()
+
+		router := gin.Default()
+		store := cookie.NewStore([]byte("secret"))
+		router.Use(sessions.Sessions("mqk", store))
+
+		NewHandler(&Config{
+			R:           router,
+			UserService: mockUserService,
+			PostService: mockPostService,
+		})
+
+		url := fmt.Sprintf("/v1/profiles/%s/media?cursor=invalid", mockUserResp.Username)
+		request, err := http.NewRequest(http.MethodGet, url, nil)
+		assert.NoError(t, err)
+
+		router.ServeHTTP(rr, request)
+
+		respBody, err := json.Marshal(gin.H{
+			"error": mockError,
+		})
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+		mockPostService.AssertExpectations(t)
+	})
+
 }
@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"log"
+	"net/http"
+	"time"
+)
+
+// GetProfileOnThisDay handler returns the profile's posts from the same day
+// in previous years. The "date" query takes a date like 2022-02-14 and
+// defaults to today.
+func (h *Handler) GetProfileOnThisDay(c *gin.Context) {
+	username := c.Param("username")
+	cursor := c.Query("cursor")
+
+	var date time.Time
+	if value := c.Query("date"); value != "" {
+		d, err := time.Parse(dateLayout, value)
+
+		if err != nil {
+			e := apperrors.NewBadRequest("date must be a date like 2022-02-14")
+			c.JSON(e.Status(), gin.H{
+				"error": e,
+			})
+			return
+		}
+		date = d
+	}
+
+	var userId string
+	value, exists := c.Get("userId")
+
+	if exists {
+		userId = value.(string)
+	}
+
+	user, err := h.UserService.FindByUsername(username)
+
+	if err != nil {
+		log.Printf("Unable to find user: %v\n%v", username, err)
+		e := apperrors.NewNotFound("profile", username)
+
+		c.JSON(e.Status(), gin.H{
+			"error": e,
+		})
+		return
+	}
+
+	posts, err := h.PostService.OnThisDay(user.ID, date, cursor)
+
+	if err != nil {
+		log.Printf("Unable to find the posts on this day for user: %v\n%v", username, err)
+		c.JSON(apperrors.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	response := make([]model.PostResponse, 0)
+
+	for i, p := range *posts {
+		if i != model.LIMIT {
+			response = append(response, p.NewPostResponse(userId))
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"posts":   response,
+		"hasMore": len(*posts) == model.LIMIT+1,
+	})
+}
@@ -0,0 +1,246 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-contrib/sessions/cookie"
+	"github.com/gin-gonic/gin"
+	"github.com/sentrionic/mirage/mocks"
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"github.com/sentrionic/mirage/model/fixture"
+	"github.com/sentrionic/mirage/service"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHandler_GetProfileOnThisDay(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	uid, _ := service.GenerateId()
+
+	t.Run("Today", func(t *testing.T) {
+		mockUserResp := fixture.GetMockUser()
+
+		mockUserService := new(mocks.UserService)
+		mockUserService.On("FindByUsername", mockUserResp.Username).Return(mockUserResp, nil)
+
+		posts := make([]model.Post, 0)
+
+		for i := 0; i < 3; i++ {
+			posts = append(posts, *fixture.GetMockPost())
+		}
+
+		mockPostService := new(mocks.PostService)
+		mockPostService.On("OnThisDay", mockUserResp.ID, time.Time{}, "").Return(&posts, nil)
+
+		// a response recorder for getting written http response
+		rr := httptest.NewRecorder()
+
+		router := gin.Default()
+		store := cookie.NewStore([]byte("secret"))
+		router.Use(sessions.Sessions("mqk", store))
+
+		router.Use(func(c *gin.Context) {
+			session := sessions.Default(c)
+			session.Set("userId", uid)
+			c.Set("userId", uid)
+		})
+
+		NewHandler(&Config{
+			R:           router,
+			UserService: mockUserService,
+			PostService: mockPostService,
+		})
+
+		url := fmt.Sprintf("/v1/profiles/%s/on-this-day", mockUserResp.Username)
+		request, err := http.NewRequest(http.MethodGet, url, nil)
+		assert.NoError(t, err)
+
+		router.ServeHTTP(rr, request)
+
+		rsp := make([]model.PostResponse, 0)
+
+		for _, p := range posts {
+			rsp = append(rsp, p.NewPostResponse(uid))
+		}
+
+		respBody, err := json.Marshal(gin.H{
+			"posts":   rsp,
+			"hasMore": false,
+		})
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+		mockUserService.AssertExpectations(t)
+		mockPostService.AssertExpectations(t)
+	})
+
+	t.Run("Given date and cursor", func(t *testing.T) {
+		mockUserResp := fixture.GetMockUser()
+
+		mockUserService := new(mocks.UserService)
+		mockUserService.On("FindByUsername", mockUserResp.Username).Return(mockUserResp, nil)
+
+		posts := make([]model.Post, 0)
+
+		for i := 0; i < model.LIMIT+1; i++ {
+			posts = append(posts, *fixture.GetMockPost())
+		}
+
+		date := time.Date(2022, 2, 14, 0, 0, 0, 0, time.UTC)
+		cursor := "2020-02-14T09:00:00Z"
+
+		mockPostService := new(mocks.PostService)
+		mockPostService.On("OnThisDay", mockUserResp.ID, date, cursor).Return(&posts, nil)
+
+		// a response recorder for getting written http response
+		rr := httptest.NewRecorder()
+
+		router := gin.Default()
+		store := cookie.NewStore([]byte("secret"))
+		router.Use(sessions.Sessions("mqk", store))
+
+		NewHandler(&Config{
+			R:           router,
+			UserService: mockUserService,
+			PostService: mockPostService,
+		})
+
+		url := fmt.Sprintf("/v1/profiles/%s/on-this-day?date=2022-02-14&cursor=%s", mockUserResp.Username, cursor)
+		request, err := http.NewRequest(http.MethodGet, url, nil)
+		assert.NoError(t, err)
+
+		router.ServeHTTP(rr, request)
+
+		var body struct {
+			Posts   []model.PostResponse `json:"posts"`
+			HasMore bool                 `json:"hasMore"`
+		}
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Len(t, body.Posts, model.LIMIT)
+		assert.True(t, body.HasMore)
+		mockPostService.AssertExpectations(t)
+	})
+
+	t.Run("Invalid date", func(t *testing.T) {
+		mockUserService := new(mocks.UserService)
+		mockPostService := new(mocks.PostService)
+
+		// a response recorder for getting written http response
+		rr := httptest.NewRecorder()
+
+		router := gin.Default()
+		store := cookie.NewStore([]byte("secret"))
+		router.Use(sessions.Sessions("mqk", store))
+
+		NewHandler(&Config{
+			R:           router,
+			UserService: mockUserService,
+			PostService: mockPostService,
+		})
+
+		request, err := http.NewRequest(http.MethodGet, "/v1/profiles/someone/on-this-day?date=14.02.2022", nil)
+		assert.NoError(t, err)
+
+		router.ServeHTTP(rr, request)
+
+		respErr := apperrors.NewBadRequest("date must be a date like 2022-02-14")
+
+		respBody, err := json.Marshal(gin.H{
+			"error": respErr,
+		})
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+		mockUserService.AssertNotCalled(t, "FindByUsername")
+		mockPostService.AssertNotCalled(t, "OnThisDay")
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		username, _ := service.GenerateId()
+
+		mockUserService := new(mocks.UserService)
+		mockUserService.On("FindByUsername", username).Return(nil, fmt.Errorf("some error down call chain"))
+
+		mockPostService := new(mocks.PostService)
+
+		// a response recorder for getting written http response
+		rr := httptest.NewRecorder()
+
+		router := gin.Default()
+		store := cookie.NewStore([]byte("secret"))
+		router.Use(sessions.Sessions("mqk", store))
+
+		NewHandler(&Config{
+			R:           router,
+			UserService: mockUserService,
+			PostService: mockPostService,
+		})
+
+		url := fmt.Sprintf("/v1/profiles/%s/on-this-day", username)
+		request, err := http.NewRequest(http.MethodGet, url, nil)
+		assert.NoError(t, err)
+
+		router.ServeHTTP(rr, request)
+
+		respErr := apperrors.NewNotFound("profile", username)
+
+		respBody, err := json.Marshal(gin.H{
+			"error": respErr,
+		})
+		assert.NoError(t, err)
+
+		assert.Equal(t, respErr.Status(), rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+		mockPostService.AssertNotCalled(t, "OnThisDay")
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		mockUserResp := fixture.GetMockUser()
+
+		mockUserService := new(mocks.UserService)
+		mockUserService.On("FindByUsername", mockUserResp.Username).Return(mockUserResp, nil)
+
+		mockError := apperrors.NewInternal()
+
+		mockPostService := new(mocks.PostService)
+		mockPostService.On("OnThisDay", mockUserResp.ID, time.Time{}, "").Return(nil, mockError)
+
+		// a response recorder for getting written http response
+		rr := httptest.NewRecorder()
+
+		router := gin.Default()
+		store := cookie.NewStore([]byte("secret"))
+		router.Use(sessions.Sessions("mqk", store))
+
+		NewHandler(&Config{
+			R:           router,
+			UserService: mockUserService,
+			PostService: mockPostService,
+		})
+
+		url := fmt.Sprintf("/v1/profiles/%s/on-this-day", mockUserResp.Username)
+		request, err := http.NewRequest(http.MethodGet, url, nil)
+		assert.NoError(t, err)
+
+		router.ServeHTTP(rr, request)
+
+		respBody, err := json.Marshal(gin.H{
+			"error": mockError,
+		})
+		assert.NoError(t, err)
+
+		assert.Equal(t, mockError.Status(), rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+		mockPostService.AssertExpectations(t)
+	})
+}
@@ -12,6 +12,12 @@ func (h *Handler) GetProfilePosts(c *gin.Context) {
 	username := c.Param("username")
 	cursor := c.Query("cursor")
 
+	timeRange, ok := bindTimeRange(c)
+
+	if !ok {
+		return
+	}
+
 	var userId string
 	value, exists := c.Get("userId")
 
@@ -31,42 +37,36 @@ func (h *Handler) GetProfilePosts(c *gin.Context) {
 		return
 	}
 
-	posts, err := h.PostService.ProfilePosts(user.ID, cursor)
+	posts, next, err := h.PostService.ProfilePosts(user.ID, cursor, timeRange)
 
 	if err != nil {
 		log.Printf("Unable to find posts for user: %v\n%v", username, err)
-		e := apperrors.NewNotFound("posts", username)
-
-		c.JSON(e.Status(), gin.H{
-			"error": e,
+		c.JSON(apperrors.Status(err), gin.H{
+			"error": err,
 		})
 		return
 	}
 
-	response := make([]model.PostResponse, 0)
+	response := make([]model.TimelineItemResponse, 0)
 
 	if len(*posts) > 0 {
 		for i, p := range *posts {
 			if i != model.LIMIT {
-				post := model.PostResponse{
-					ID:        p.ID,
-					Text:      p.Text,
-					Likes:     uint(len(p.Likes)),
-					Liked:     p.IsLiked(userId),
-					Retweets:  uint(len(p.Retweets)),
-					Retweeted: p.IsRetweeted(userId),
-					IsRetweet: p.UserID != user.ID,
-					File:      p.File,
-					Author:    p.User.NewProfileResponse(userId),
-					CreatedAt: p.CreatedAt,
-				}
+				post := p.NewTimelineItemResponse(userId)
 				response = append(response, post)
 			}
 		}
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	body := gin.H{
 		"posts":   response,
-		"hasMore": len(*posts) == model.LIMIT+1,
-	})
+		"hasMore": len(*posts) == model.LIMIT+1 || next != "",
+	}
+
+	// retweets are paged by the time of the retweet
+	if next != "" {
+		body["nextCursor"] = next
+	}
+
+	c.JSON(http.StatusOK, body)
 }
@@ -16,6 +16,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 )
 
 func getPostResponse(posts *[]model.Post) []model.PostResponse {
@@ -27,6 +28,8 @@ func getPostResponse(posts *[]model.Post) []model.PostResponse {
 			Text:      p.Text,
 			Likes:     uint(len(p.Likes)),
 			Retweets:  uint(len(p.Retweets)),
+			Entities:  p.GetEntities(),
+			Card:      p.Card,
 			File:      p.File,
 			Author:    p.User.NewProfileResponse(""),
 			CreatedAt: p.CreatedAt,
@@ -37,6 +40,16 @@ func getPostResponse(posts *[]model.Post) []model.PostResponse {
 	return response
 }
 
+func getTimelineItemResponse(posts *[]model.Post, id string) []model.TimelineItemResponse {
+	response := make([]model.TimelineItemResponse, 0)
+
+	for _, p := range *posts {
+		response = append(response, p.NewTimelineItemResponse(id))
+	}
+
+	return response
+}
+
 func TestHandler_GetProfilePosts(t *testing.T) {
 	// Setup
 	gin.SetMode(gin.TestMode)
@@ -57,7 +70,7 @@ func TestHandler_GetProfilePosts(t *testing.T) {
 		}
 
 		mockPostService := new(mocks.PostService)
-		mockPostService.On("ProfilePosts", mockUserResp.ID, "").Return(&posts, nil)
+		mockPostService.On("ProfilePosts", mockUserResp.ID, "", model.TimeRange{}).Return(&posts, "", nil)
 
 		// a response recorder for getting written http response
 		rr := httptest.NewRecorder()
@@ -85,7 +98,7 @@ func TestHandler_GetProfilePosts(t *testing.T) {
 		router.ServeHTTP(rr, request)
 
 		respBody, err := json.Marshal(gin.H{
-			"posts":   getPostResponse(&posts),
+			"posts":   getTimelineItemResponse(&posts, uid),
 			"hasMore": false,
 		})
 		assert.NoError(t, err)
@@ -110,7 +123,7 @@ func TestHandler_GetProfilePosts(t *testing.T) {
 		}
 
 		mockPostService := new(mocks.PostService)
-		mockPostService.On("ProfilePosts", mockUserResp.ID, "").Return(&posts, nil)
+		mockPostService.On("ProfilePosts", mockUserResp.ID, "", model.TimeRange{}).Return(&posts, "", nil)
 
 		// a response recorder for getting written http response
 		rr := httptest.NewRecorder()
@@ -132,7 +145,7 @@ func TestHandler_GetProfilePosts(t *testing.T) {
 		router.ServeHTTP(rr, request)
 
 		respBody, err := json.Marshal(gin.H{
-			"posts":   getPostResponse(&posts),
+			"posts":   getTimelineItemResponse(&posts, ""),
 			"hasMore": false,
 		})
 		assert.NoError(t, err)
@@ -142,6 +155,57 @@ func TestHandler_GetProfilePosts(t *testing.T) {
 		mockUserService.AssertExpectations(t)
 	})
 
+	t.Run("Retweets and next cursor", func(t *testing.T) {
+		mockUserResp := fixture.GetMockUser()
+
+		mockUserService := new(mocks.UserService)
+		mockUserService.On("FindByUsername", mockUserResp.Username).Return(mockUserResp, nil)
+
+		mockPost := fixture.GetMockPost()
+		mockPost.Retweets = []model.User{*mockUserResp}
+		mockPost.RetweetedBy = []model.User{*mockUserResp}
+		mockPost.Activity = &model.TimelineActivity{Reason: model.ReasonRetweetedBy, Actor: *mockUserResp, At: time.Now()}
+		posts := []model.Post{*mockPost}
+
+		mockPostService := new(mocks.PostService)
+		mockPostService.On("ProfilePosts", mockUserResp.ID, "", model.TimeRange{}).Return(&posts, "2021-05-01T12:00:00Z", nil)
+
+		// a response recorder for getting written http response
+		rr := httptest.NewRecorder()
+
+		router := gin.Default()
+		store := cookie.NewStore([]byte("secret"))
+		router.Use(sessions.Sessions("mqk", store))
+
+		NewHandler(&Config{
+			R:           router,
+			UserService: mockUserService,
+			PostService: mockPostService,
+		})
+
+		url := fmt.Sprintf("/v1/profiles/%s/posts", mockUserResp.Username)
+		request, err := http.NewRequest(http.MethodGet, url, nil)
+		assert.NoError(t, err)
+
+		router.ServeHTTP(rr, request)
+
+		var body struct {
+			Posts      []model.TimelineItemResponse `json:"posts"`
+			HasMore    bool                         `json:"hasMore"`
+			NextCursor string                       `json:"nextCursor"`
+		}
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.True(t, body.HasMore)
+		assert.Equal(t, "2021-05-01T12:00:00Z", body.NextCursor)
+		assert.True(t, body.Posts[0].IsRetweet)
+		assert.Equal(t, mockUserResp.ID, body.Posts[0].RetweetedBy.User.ID)
+		assert.Equal(t, 0, body.Posts[0].RetweetedBy.Others)
+		assert.Equal(t, model.ReasonRetweetedBy, body.Posts[0].Reason)
+		assert.Equal(t, mockUserResp.ID, body.Posts[0].Actor.ID)
+	})
+
 	t.Run("NotFound", func(t *testing.T) {
 		username, _ := service.GenerateId()
 
@@ -149,7 +213,7 @@ func TestHandler_GetProfilePosts(t *testing.T) {
 		mockUserService.On("FindByUsername", username).Return(nil, fmt.Errorf("some error down call chain"))
 
 		mockPostService := new(mocks.PostService)
-		mockPostService.On("ProfilePosts", mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(nil, nil)
+		mockPostService.On("ProfilePosts", mock.AnythingOfType("string"), mock.AnythingOfType("string"), model.TimeRange{}).Return(nil, "", nil)
 
 		// a response recorder for getting written http response
 		rr := httptest.NewRecorder()
@@ -188,4 +252,172 @@ func TestHandler_GetProfilePosts(t *testing.T) {
 		mockPostService.AssertNotCalled(t, "ProfilePosts")
 	})
 
+	t.Run("Error", func(t *testing.T) {
+		mockUserResp := fixture.GetMockUser()
+
+		mockUserService := new(mocks.UserService)
+		mockUserService.On("FindByUsername", mockUserResp.Username).Return(mockUserResp, nil)
+
+		mockError := apperrors.NewBadRequest("invalid cursor")
+		mockPostService := new(mocks.PostService)
+		mockPostService.On("ProfilePosts", mockUserResp.ID, "invalid", model.TimeRange{}).Return(nil, "", mockError)
+
+		rr := httptest.NewRecorder()
+
+		router := gin.Default()
+		store := cookie.NewStore([]byte("secret"))
+		router.Use(sessions.Sessions("mqk", store))
+
+		NewHandler(&Config{
+			R:           router,
+			UserService: mockUserService,
+			PostService: mockPostService,
+		})
+
+		url := fmt.Sprintf("/v1/profiles/%s/posts?cursor=invalid", mockUserResp.Username)
+		request, err := http.NewRequest(http.MethodGet, url, nil)
+		assert.NoError(t, err)
+
+		router.ServeHTTP(rr, request)
+
+		respBody, err := json.Marshal(gin.H{
+			"error": mockError,
+		})
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+		mockPostService.AssertExpectations(t)
+	})
+
+}
+
+func TestHandler_GetProfilePosts_TimeRange(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Dates", func(t *testing.T) {
+		mockUserResp := fixture.GetMockUser()
+
+		mockUserService := new(mocks.UserService)
+		mockUserService.On("FindByUsername", mockUserResp.Username).Return(mockUserResp, nil)
+
+		timeRange := model.TimeRange{
+			Since: time.Date(2022, 2, 1, 0, 0, 0, 0, time.UTC),
+			Until: time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC),
+		}
+		posts := make([]model.Post, 0)
+
+		mockPostService := new(mocks.PostService)
+		mockPostService.On("ProfilePosts", mockUserResp.ID, "", timeRange).Return(&posts, "", nil)
+
+		// a response recorder for getting written http response
+		rr := httptest.NewRecorder()
+
+		router := gin.Default()
+		store := cookie.NewStore([]byte("secret"))
+		router.Use(sessions.Sessions("mqk", store))
+
+		NewHandler(&Config{
+			R:           router,
+			UserService: mockUserService,
+			PostService: mockPostService,
+		})
+
+		// until is a whole day
+		url := fmt.Sprintf("/v1/profiles/%s/posts?since=2022-02-01&until=2022-02-28", mockUserResp.Username)
+		request, err := http.NewRequest(http.MethodGet, url, nil)
+		assert.NoError(t, err)
+
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		mockPostService.AssertExpectations(t)
+	})
+
+	t.Run("Timestamps", func(t *testing.T) {
+		mockUserResp := fixture.GetMockUser()
+
+		mockUserService := new(mocks.UserService)
+		mockUserService.On("FindByUsername", mockUserResp.Username).Return(mockUserResp, nil)
+
+		timeRange := model.TimeRange{
+			Until: time.Date(2022, 2, 1, 10, 30, 0, 0, time.UTC),
+		}
+		posts := make([]model.Post, 0)
+
+		mockPostService := new(mocks.PostService)
+		mockPostService.On("ProfilePosts", mockUserResp.ID, "", mock.MatchedBy(func(r model.TimeRange) bool {
+			return r.Since.IsZero() && r.Until.Equal(timeRange.Until)
+		})).Return(&posts, "", nil)
+
+		// a response recorder for getting written http response
+		rr := httptest.NewRecorder()
+
+		router := gin.Default()
+		store := cookie.NewStore([]byte("secret"))
+		router.Use(sessions.Sessions("mqk", store))
+
+		NewHandler(&Config{
+			R:           router,
+			UserService: mockUserService,
+			PostService: mockPostService,
+		})
+
+		url := fmt.Sprintf("/v1/profiles/%s/posts?until=2022-02-01T12:30:00%%2B02:00", mockUserResp.Username)
+		request, err := http.NewRequest(http.MethodGet, url, nil)
+		assert.NoError(t, err)
+
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		mockPostService.AssertExpectations(t)
+	})
+
+	testCases := []struct {
+		name   string
+		query  string
+		reason string
+	}{
+		{name: "Invalid since", query: "since=yesterday", reason: "since must be a date like 2022-02-01 or an RFC 3339 time"},
+		{name: "Invalid until", query: "until=2022-13-01", reason: "until must be a date like 2022-02-01 or an RFC 3339 time"},
+		{name: "Since after until", query: "since=2022-03-01&until=2022-02-01", reason: "since must be before until"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockUserService := new(mocks.UserService)
+			mockPostService := new(mocks.PostService)
+
+			// a response recorder for getting written http response
+			rr := httptest.NewRecorder()
+
+			router := gin.Default()
+			store := cookie.NewStore([]byte("secret"))
+			router.Use(sessions.Sessions("mqk", store))
+
+			NewHandler(&Config{
+				R:           router,
+				UserService: mockUserService,
+				PostService: mockPostService,
+			})
+
+			request, err := http.NewRequest(http.MethodGet, "/v1/profiles/someone/posts?"+tc.query, nil)
+			assert.NoError(t, err)
+
+			router.ServeHTTP(rr, request)
+
+			respErr := apperrors.NewBadRequest(tc.reason)
+
+			respBody, err := json.Marshal(gin.H{
+				"error": respErr,
+			})
+			assert.NoError(t, err)
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+			assert.Equal(t, respBody, rr.Body.Bytes())
+			mockUserService.AssertNotCalled(t, "FindByUsername")
+			mockPostService.AssertNotCalled(t, "ProfilePosts")
+		})
+	}
 }
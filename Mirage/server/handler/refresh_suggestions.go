@@ -0,0 +1,25 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"log"
+	"net/http"
+)
+
+// RefreshSuggestions handler ranks the suggestions of the current user again and returns them
+func (h *Handler) RefreshSuggestions(c *gin.Context) {
+	userId := c.MustGet("userId").(string)
+
+	suggestions, err := h.SuggestionService.Refresh(userId)
+
+	if err != nil {
+		log.Printf("Unable to refresh suggestions for user: %v\n%v", userId, err)
+		c.JSON(apperrors.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, newSuggestionsResponse(suggestions, userId))
+}
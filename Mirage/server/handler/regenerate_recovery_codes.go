@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"log"
+	"net/http"
+)
+
+// RegenerateRecoveryCodes handler replaces the user's recovery codes
+func (h *Handler) RegenerateRecoveryCodes(c *gin.Context) {
+	userId := c.MustGet("userId").(string)
+
+	var req twoFactorCodeReq
+
+	if ok := bindData(c, &req); !ok {
+		return
+	}
+
+	req.Sanitize()
+
+	authUser, err := h.UserService.Get(userId)
+
+	if err != nil {
+		err := errors.New("provided session is invalid")
+		c.JSON(401, gin.H{
+			"error": err,
+		})
+		c.Abort()
+		return
+	}
+
+	codes, err := h.UserService.RegenerateRecoveryCodes(authUser, req.Code)
+
+	if err != nil {
+		log.Printf("Failed to regenerate recovery codes: %v\n", err)
+		c.JSON(apperrors.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"recoveryCodes": codes,
+	})
+}
@@ -63,7 +63,13 @@ func (h *Handler) Register(c *gin.Context) {
 		return
 	}
 
-	setUserSession(c, user.ID)
+	if err := h.setUserSession(c, user.ID); err != nil {
+		log.Printf("Failed to create session: %v\n", err.Error())
+		c.JSON(apperrors.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
 
 	c.JSON(http.StatusCreated, user.NewAccountResponse())
 }
@@ -310,6 +310,11 @@ func TestHandler_Register(t *testing.T) {
 			mockUserService := new(mocks.UserService)
 			tc.buildStubs(mockUserService)
 
+			mockSessionService := new(mocks.SessionService)
+			mockSessionService.
+				On("Create", mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.AnythingOfType("string")).
+				Return(&model.Session{ID: fixture.RandID()}, nil)
+
 			// a response recorder for getting written http response
 			rr := httptest.NewRecorder()
 
@@ -318,8 +323,9 @@ func TestHandler_Register(t *testing.T) {
 			router.Use(sessions.Sessions("mqk", store))
 
 			NewHandler(&Config{
-				R:           router,
-				UserService: mockUserService,
+				R:              router,
+				UserService:    mockUserService,
+				SessionService: mockSessionService,
 			})
 
 			// create a request body with empty email and password
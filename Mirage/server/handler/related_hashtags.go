@@ -0,0 +1,26 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"log"
+	"net/http"
+)
+
+// GetRelatedHashtags handler returns the hashtags most often
+// used together with the given one, with or without the #
+func (h *Handler) GetRelatedHashtags(c *gin.Context) {
+	tag := c.Param("tag")
+
+	related, err := h.PostService.RelatedHashtags(tag)
+
+	if err != nil {
+		log.Printf("Unable to get related hashtags of: %v\n%v", tag, err)
+		c.JSON(apperrors.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, related)
+}
@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-contrib/sessions/cookie"
+	"github.com/gin-gonic/gin"
+	"github.com/sentrionic/mirage/mocks"
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandler_GetRelatedHashtags(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+
+	setupRouter := func(mockPostService *mocks.PostService) *gin.Engine {
+		router := gin.Default()
+		store := cookie.NewStore([]byte("secret"))
+		router.Use(sessions.Sessions("mqk", store))
+
+		NewHandler(&Config{
+			R:           router,
+			PostService: mockPostService,
+		})
+
+		return router
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		related := &[]model.RelatedHashtag{
+			{Hashtag: "#rust", Count: 7},
+			{Hashtag: "#mirage", Count: 2},
+		}
+
+		mockPostService := new(mocks.PostService)
+		mockPostService.On("RelatedHashtags", "#go").Return(related, nil)
+
+		rr := httptest.NewRecorder()
+		router := setupRouter(mockPostService)
+
+		request, err := http.NewRequest(http.MethodGet, "/v1/hashtags/%23go/related", nil)
+		assert.NoError(t, err)
+
+		router.ServeHTTP(rr, request)
+
+		respBody, err := json.Marshal(related)
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+		mockPostService.AssertExpectations(t)
+	})
+
+	t.Run("Invalid hashtag", func(t *testing.T) {
+		respErr := apperrors.NewBadRequest("invalid hashtag: go%")
+
+		mockPostService := new(mocks.PostService)
+		mockPostService.On("RelatedHashtags", "go%").Return(nil, respErr)
+
+		rr := httptest.NewRecorder()
+		router := setupRouter(mockPostService)
+
+		request, err := http.NewRequest(http.MethodGet, "/v1/hashtags/go%25/related", nil)
+		assert.NoError(t, err)
+
+		router.ServeHTTP(rr, request)
+
+		respBody, err := json.Marshal(gin.H{
+			"error": respErr,
+		})
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+		mockPostService.AssertExpectations(t)
+	})
+}
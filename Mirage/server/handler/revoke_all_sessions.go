@@ -0,0 +1,27 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"log"
+	"net/http"
+)
+
+// RevokeAllSessions handler logs the current user out everywhere
+func (h *Handler) RevokeAllSessions(c *gin.Context) {
+	userId := c.MustGet("userId").(string)
+
+	err := h.SessionService.RevokeAll(userId)
+
+	if err != nil {
+		log.Printf("Unable to revoke sessions for user: %v\n%v", userId, err)
+		c.JSON(apperrors.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	clearUserSession(c)
+
+	c.JSON(http.StatusOK, true)
+}
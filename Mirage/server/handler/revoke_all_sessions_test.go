@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-contrib/sessions/cookie"
+	"github.com/gin-gonic/gin"
+	"github.com/sentrionic/mirage/mocks"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"github.com/sentrionic/mirage/model/fixture"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandler_RevokeAllSessions(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	authUser := fixture.GetMockUser()
+	current := fixture.RandID()
+
+	setupRouter := func(mockSessionService *mocks.SessionService) *gin.Engine {
+		router := gin.Default()
+		store := cookie.NewStore([]byte("secret"))
+		router.Use(sessions.Sessions("mqk", store))
+
+		router.Use(func(c *gin.Context) {
+			session := sessions.Default(c)
+			session.Set("userId", authUser.ID)
+			session.Set("sessionId", current)
+		})
+
+		NewHandler(&Config{
+			R:              router,
+			SessionService: mockSessionService,
+		})
+
+		return router
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		mockSessionService := new(mocks.SessionService)
+		mockSessionService.On("Verify", authUser.ID, current, mock.AnythingOfType("string")).Return(true, nil)
+		mockSessionService.On("RevokeAll", authUser.ID).Return(nil)
+
+		rr := httptest.NewRecorder()
+		router := setupRouter(mockSessionService)
+
+		request, err := http.NewRequest(http.MethodDelete, "/v1/accounts/sessions", nil)
+		assert.NoError(t, err)
+
+		router.ServeHTTP(rr, request)
+
+		respBody, _ := json.Marshal(true)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+		assert.Contains(t, lastSetCookie(rr), "Max-Age=0")
+		mockSessionService.AssertExpectations(t)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		mockSessionService := new(mocks.SessionService)
+		mockSessionService.On("Verify", authUser.ID, current, mock.AnythingOfType("string")).Return(true, nil)
+		mockSessionService.On("RevokeAll", authUser.ID).Return(apperrors.NewInternal())
+
+		rr := httptest.NewRecorder()
+		router := setupRouter(mockSessionService)
+
+		request, err := http.NewRequest(http.MethodDelete, "/v1/accounts/sessions", nil)
+		assert.NoError(t, err)
+
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+		mockSessionService.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,31 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"log"
+	"net/http"
+)
+
+// RevokeSession handler logs the current user out
+// of the session with the given ID
+func (h *Handler) RevokeSession(c *gin.Context) {
+	userId := c.MustGet("userId").(string)
+	sessionId := c.Param("id")
+
+	err := h.SessionService.Revoke(userId, sessionId)
+
+	if err != nil {
+		log.Printf("Unable to revoke session: %v\n%v", sessionId, err)
+		c.JSON(apperrors.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	if sessionId == currentSessionId(c) {
+		clearUserSession(c)
+	}
+
+	c.JSON(http.StatusOK, true)
+}
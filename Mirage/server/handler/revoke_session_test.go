@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-contrib/sessions/cookie"
+	"github.com/gin-gonic/gin"
+	"github.com/sentrionic/mirage/mocks"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"github.com/sentrionic/mirage/model/fixture"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandler_RevokeSession(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	authUser := fixture.GetMockUser()
+	current := fixture.RandID()
+	other := fixture.RandID()
+
+	setupRouter := func(mockSessionService *mocks.SessionService) *gin.Engine {
+		router := gin.Default()
+		store := cookie.NewStore([]byte("secret"))
+		router.Use(sessions.Sessions("mqk", store))
+
+		router.Use(func(c *gin.Context) {
+			session := sessions.Default(c)
+			session.Set("userId", authUser.ID)
+			session.Set("sessionId", current)
+		})
+
+		NewHandler(&Config{
+			R:              router,
+			SessionService: mockSessionService,
+		})
+
+		return router
+	}
+
+	t.Run("Revokes another session", func(t *testing.T) {
+		mockSessionService := new(mocks.SessionService)
+		mockSessionService.On("Verify", authUser.ID, current, mock.AnythingOfType("string")).Return(true, nil)
+		mockSessionService.On("Revoke", authUser.ID, other).Return(nil)
+
+		rr := httptest.NewRecorder()
+		router := setupRouter(mockSessionService)
+
+		request, err := http.NewRequest(http.MethodDelete, "/v1/accounts/sessions/"+other, nil)
+		assert.NoError(t, err)
+
+		router.ServeHTTP(rr, request)
+
+		respBody, _ := json.Marshal(true)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+		assert.NotContains(t, lastSetCookie(rr), "Max-Age=0")
+		mockSessionService.AssertExpectations(t)
+	})
+
+	t.Run("Revoking the current session clears the cookie", func(t *testing.T) {
+		mockSessionService := new(mocks.SessionService)
+		mockSessionService.On("Verify", authUser.ID, current, mock.AnythingOfType("string")).Return(true, nil)
+		mockSessionService.On("Revoke", authUser.ID, current).Return(nil)
+
+		rr := httptest.NewRecorder()
+		router := setupRouter(mockSessionService)
+
+		request, err := http.NewRequest(http.MethodDelete, "/v1/accounts/sessions/"+current, nil)
+		assert.NoError(t, err)
+
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, lastSetCookie(rr), "Max-Age=0")
+		mockSessionService.AssertExpectations(t)
+	})
+
+	t.Run("Unknown session", func(t *testing.T) {
+		mockSessionService := new(mocks.SessionService)
+		mockSessionService.On("Verify", authUser.ID, current, mock.AnythingOfType("string")).Return(true, nil)
+		mockSessionService.On("Revoke", authUser.ID, other).Return(apperrors.NewNotFound("session", other))
+
+		rr := httptest.NewRecorder()
+		router := setupRouter(mockSessionService)
+
+		request, err := http.NewRequest(http.MethodDelete, "/v1/accounts/sessions/"+other, nil)
+		assert.NoError(t, err)
+
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		mockSessionService.AssertExpectations(t)
+	})
+}
+
+// lastSetCookie returns the cookie written last, as the auth
+// middleware already refreshes the session before the handler runs
+func lastSetCookie(rr *httptest.ResponseRecorder) string {
+	cookies := rr.Header().Values("Set-Cookie")
+	if len(cookies) == 0 {
+		return ""
+	}
+	return cookies[len(cookies)-1]
+}
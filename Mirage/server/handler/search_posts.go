@@ -8,24 +8,29 @@ import (
 	"net/http"
 )
 
+// SearchPosts handler returns a page of the newest posts with the searched
+// hashtags and, on the first page, the number of all posts that have them.
+// "#a #b" finds posts with both tags, "#a OR #b" posts with either.
 func (h *Handler) SearchPosts(c *gin.Context) {
 	search := c.Query("search")
 	cursor := c.Query("cursor")
 
 	userId := c.MustGet("userId").(string)
 
-	posts, err := h.PostService.SearchPosts(search, cursor)
+	posts, total, err := h.PostService.SearchPosts(search, cursor)
 
 	if err != nil {
 		log.Printf("Unable to find posts for term: %v\n%v", search, err)
-		e := apperrors.NewNotFound("posts", search)
-
-		c.JSON(e.Status(), gin.H{
-			"error": e,
+		c.JSON(apperrors.Status(err), gin.H{
+			"error": err,
 		})
 		return
 	}
 
+	if cursor == "" {
+		h.recordSearch(userId, search, model.SearchPosts)
+	}
+
 	response := make([]model.PostResponse, 0)
 
 	if len(*posts) > 0 {
@@ -37,8 +42,14 @@ func (h *Handler) SearchPosts(c *gin.Context) {
 		}
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	body := gin.H{
 		"posts":   response,
 		"hasMore": len(*posts) == model.LIMIT+1,
-	})
+	}
+
+	if cursor == "" {
+		body["total"] = total
+	}
+
+	c.JSON(http.StatusOK, body)
 }
@@ -7,6 +7,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/sentrionic/mirage/mocks"
 	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
 	"github.com/sentrionic/mirage/model/fixture"
 	"github.com/sentrionic/mirage/service"
 	"github.com/stretchr/testify/assert"
@@ -29,7 +30,7 @@ func TestHandler_SearchPosts(t *testing.T) {
 		}
 
 		mockPostService := new(mocks.PostService)
-		mockPostService.On("SearchPosts", "", "").Return(&posts, nil)
+		mockPostService.On("SearchPosts", "", "").Return(&posts, int64(10), nil)
 
 		// a response recorder for getting written http response
 		rr := httptest.NewRecorder()
@@ -57,6 +58,7 @@ func TestHandler_SearchPosts(t *testing.T) {
 		respBody, err := json.Marshal(gin.H{
 			"posts":   getPostResponse(&posts),
 			"hasMore": false,
+			"total":   10,
 		})
 		assert.NoError(t, err)
 
@@ -67,7 +69,7 @@ func TestHandler_SearchPosts(t *testing.T) {
 
 	t.Run("Unauthorized", func(t *testing.T) {
 		mockPostService := new(mocks.PostService)
-		mockPostService.On("SearchPosts", "", "").Return(nil, nil)
+		mockPostService.On("SearchPosts", "", "").Return(nil, int64(0), nil)
 
 		// a response recorder for getting written http response
 		rr := httptest.NewRecorder()
@@ -94,7 +96,7 @@ func TestHandler_SearchPosts(t *testing.T) {
 		posts := make([]model.Post, 0)
 
 		mockPostService := new(mocks.PostService)
-		mockPostService.On("SearchPosts", "", "").Return(&posts, nil)
+		mockPostService.On("SearchPosts", "", "").Return(&posts, int64(0), nil)
 
 		// a response recorder for getting written http response
 		rr := httptest.NewRecorder()
@@ -122,6 +124,7 @@ func TestHandler_SearchPosts(t *testing.T) {
 		respBody, err := json.Marshal(gin.H{
 			"posts":   &posts,
 			"hasMore": false,
+			"total":   0,
 		})
 		assert.NoError(t, err)
 
@@ -129,4 +132,119 @@ func TestHandler_SearchPosts(t *testing.T) {
 		assert.Equal(t, respBody, rr.Body.Bytes())
 		mockPostService.AssertExpectations(t)
 	})
+
+	t.Run("Later page leaves out the total", func(t *testing.T) {
+		posts := make([]model.Post, 0)
+
+		mockPostService := new(mocks.PostService)
+		mockPostService.On("SearchPosts", "#tes", "cursor").Return(&posts, int64(0), nil)
+
+		rr := httptest.NewRecorder()
+
+		router := gin.Default()
+		store := cookie.NewStore([]byte("secret"))
+		router.Use(sessions.Sessions("mqk", store))
+
+		router.Use(func(c *gin.Context) {
+			session := sessions.Default(c)
+			session.Set("userId", uid)
+			c.Set("userId", uid)
+		})
+
+		NewHandler(&Config{
+			R:           router,
+			PostService: mockPostService,
+		})
+
+		request, err := http.NewRequest(http.MethodGet, "/v1/posts?search=%23tes&cursor=cursor", nil)
+		assert.NoError(t, err)
+
+		router.ServeHTTP(rr, request)
+
+		respBody, err := json.Marshal(gin.H{
+			"posts":   &posts,
+			"hasMore": false,
+		})
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+		mockPostService.AssertExpectations(t)
+	})
+
+	t.Run("Invalid search", func(t *testing.T) {
+		respErr := apperrors.NewBadRequest("invalid hashtag: #te%")
+
+		mockPostService := new(mocks.PostService)
+		mockPostService.On("SearchPosts", "#te%", "").Return(nil, int64(0), respErr)
+
+		// a response recorder for getting written http response
+		rr := httptest.NewRecorder()
+
+		router := gin.Default()
+		store := cookie.NewStore([]byte("secret"))
+		router.Use(sessions.Sessions("mqk", store))
+
+		router.Use(func(c *gin.Context) {
+			session := sessions.Default(c)
+			session.Set("userId", uid)
+			c.Set("userId", uid)
+		})
+
+		NewHandler(&Config{
+			R:           router,
+			PostService: mockPostService,
+		})
+
+		request, err := http.NewRequest(http.MethodGet, "/v1/posts?search=%23te%25", nil)
+		assert.NoError(t, err)
+
+		router.ServeHTTP(rr, request)
+
+		respBody, err := json.Marshal(gin.H{
+			"error": respErr,
+		})
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+		mockPostService.AssertExpectations(t)
+	})
+
+	t.Run("Records the first page", func(t *testing.T) {
+		posts := make([]model.Post, 0)
+
+		mockPostService := new(mocks.PostService)
+		mockPostService.On("SearchPosts", "#go", "").Return(&posts, int64(0), nil)
+
+		mockSearchService := new(mocks.SearchService)
+		mockSearchService.On("RecordSearch", uid, "#go", model.SearchPosts).Return(nil)
+
+		// a response recorder for getting written http response
+		rr := httptest.NewRecorder()
+
+		router := gin.Default()
+		store := cookie.NewStore([]byte("secret"))
+		router.Use(sessions.Sessions("mqk", store))
+
+		router.Use(func(c *gin.Context) {
+			session := sessions.Default(c)
+			session.Set("userId", uid)
+			c.Set("userId", uid)
+		})
+
+		NewHandler(&Config{
+			R:             router,
+			PostService:   mockPostService,
+			SearchService: mockSearchService,
+		})
+
+		request, err := http.NewRequest(http.MethodGet, "/v1/posts?search=%23go", nil)
+		assert.NoError(t, err)
+
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		mockSearchService.AssertExpectations(t)
+	})
 }
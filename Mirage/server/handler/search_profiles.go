@@ -8,31 +8,44 @@ import (
 	"net/http"
 )
 
+// SearchProfiles handler returns a page of the profiles whose username,
+// display name or bio match the search term, best match first
 func (h *Handler) SearchProfiles(c *gin.Context) {
 	search := c.Query("search")
+	cursor := c.Query("cursor")
 
 	userId := c.MustGet("userId").(string)
 
-	users, err := h.UserService.Search(search)
+	users, next, err := h.UserService.Search(search, cursor)
 
 	if err != nil {
 		log.Printf("Unable to find profiles for term: %v\n%v", search, err)
-		e := apperrors.NewNotFound("profiles", search)
-
-		c.JSON(e.Status(), gin.H{
-			"error": e,
+		c.JSON(apperrors.Status(err), gin.H{
+			"error": err,
 		})
 		return
 	}
 
+	if cursor == "" {
+		h.recordSearch(userId, search, model.SearchProfiles)
+	}
+
 	response := make([]model.Profile, 0)
 
-	if len(*users) > 0 {
-		for _, p := range *users {
-			profile := p.NewProfileResponse(userId)
-			response = append(response, profile)
+	for i, u := range *users {
+		if i != model.LIMIT {
+			response = append(response, u.NewProfileResponse(userId))
 		}
 	}
 
-	c.JSON(http.StatusOK, response)
+	body := gin.H{
+		"profiles": response,
+		"hasMore":  next != "",
+	}
+
+	if next != "" {
+		body["nextCursor"] = next
+	}
+
+	c.JSON(http.StatusOK, body)
 }
@@ -7,6 +7,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/sentrionic/mirage/mocks"
 	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
 	"github.com/sentrionic/mirage/model/fixture"
 	"github.com/sentrionic/mirage/service"
 	"github.com/stretchr/testify/assert"
@@ -29,7 +30,7 @@ func TestHandler_SearchProfiles(t *testing.T) {
 		}
 
 		mockUserService := new(mocks.UserService)
-		mockUserService.On("Search", "").Return(&users, nil)
+		mockUserService.On("Search", "", "").Return(&users, "", nil)
 
 		// a response recorder for getting written http response
 		rr := httptest.NewRecorder()
@@ -61,7 +62,10 @@ func TestHandler_SearchProfiles(t *testing.T) {
 			rsp = append(rsp, profile)
 		}
 
-		respBody, err := json.Marshal(rsp)
+		respBody, err := json.Marshal(gin.H{
+			"profiles": rsp,
+			"hasMore":  false,
+		})
 		assert.NoError(t, err)
 
 		assert.Equal(t, http.StatusOK, rr.Code)
@@ -71,7 +75,7 @@ func TestHandler_SearchProfiles(t *testing.T) {
 
 	t.Run("Unauthorized", func(t *testing.T) {
 		mockUserService := new(mocks.UserService)
-		mockUserService.On("Search", "").Return(nil, nil)
+		mockUserService.On("Search", "", "").Return(nil, "", nil)
 
 		// a response recorder for getting written http response
 		rr := httptest.NewRecorder()
@@ -98,7 +102,7 @@ func TestHandler_SearchProfiles(t *testing.T) {
 		users := make([]model.User, 0)
 
 		mockUserService := new(mocks.UserService)
-		mockUserService.On("Search", "").Return(&users, nil)
+		mockUserService.On("Search", "", "").Return(&users, "", nil)
 
 		// a response recorder for getting written http response
 		rr := httptest.NewRecorder()
@@ -125,11 +129,137 @@ func TestHandler_SearchProfiles(t *testing.T) {
 
 		rsp := make([]model.Profile, 0)
 
-		respBody, err := json.Marshal(rsp)
+		respBody, err := json.Marshal(gin.H{
+			"profiles": rsp,
+			"hasMore":  false,
+		})
 		assert.NoError(t, err)
 
 		assert.Equal(t, http.StatusOK, rr.Code)
 		assert.Equal(t, respBody, rr.Body.Bytes())
 		mockUserService.AssertExpectations(t)
 	})
+
+	t.Run("Term and next cursor", func(t *testing.T) {
+		users := make([]model.User, 0)
+
+		for i := 0; i < model.LIMIT+1; i++ {
+			mockUser := fixture.GetMockUser()
+			users = append(users, *mockUser)
+		}
+
+		mockUserService := new(mocks.UserService)
+		mockUserService.On("Search", "tong", "20").Return(&users, "40", nil)
+
+		// a response recorder for getting written http response
+		rr := httptest.NewRecorder()
+
+		router := gin.Default()
+		store := cookie.NewStore([]byte("secret"))
+		router.Use(sessions.Sessions("mqk", store))
+
+		router.Use(func(c *gin.Context) {
+			session := sessions.Default(c)
+			session.Set("userId", uid)
+			c.Set("userId", uid)
+		})
+
+		NewHandler(&Config{
+			R:           router,
+			UserService: mockUserService,
+		})
+
+		request, err := http.NewRequest(http.MethodGet, "/v1/profiles?search=tong&cursor=20", nil)
+		assert.NoError(t, err)
+
+		router.ServeHTTP(rr, request)
+
+		var body struct {
+			Profiles   []model.Profile `json:"profiles"`
+			HasMore    bool            `json:"hasMore"`
+			NextCursor string          `json:"nextCursor"`
+		}
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Len(t, body.Profiles, model.LIMIT)
+		assert.True(t, body.HasMore)
+		assert.Equal(t, "40", body.NextCursor)
+		mockUserService.AssertExpectations(t)
+	})
+
+	t.Run("Invalid cursor", func(t *testing.T) {
+		mockUserService := new(mocks.UserService)
+		respErr := apperrors.NewBadRequest("invalid cursor")
+		mockUserService.On("Search", "tong", "abc").Return(nil, "", respErr)
+
+		// a response recorder for getting written http response
+		rr := httptest.NewRecorder()
+
+		router := gin.Default()
+		store := cookie.NewStore([]byte("secret"))
+		router.Use(sessions.Sessions("mqk", store))
+
+		router.Use(func(c *gin.Context) {
+			session := sessions.Default(c)
+			session.Set("userId", uid)
+			c.Set("userId", uid)
+		})
+
+		NewHandler(&Config{
+			R:           router,
+			UserService: mockUserService,
+		})
+
+		request, err := http.NewRequest(http.MethodGet, "/v1/profiles?search=tong&cursor=abc", nil)
+		assert.NoError(t, err)
+
+		router.ServeHTTP(rr, request)
+
+		respBody, err := json.Marshal(gin.H{
+			"error": respErr,
+		})
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+		mockUserService.AssertExpectations(t)
+	})
+
+	t.Run("Records the first page", func(t *testing.T) {
+		users := make([]model.User, 0)
+
+		mockUserService := new(mocks.UserService)
+		mockUserService.On("Search", "tong", "").Return(&users, "", nil)
+
+		mockSearchService := new(mocks.SearchService)
+		mockSearchService.On("RecordSearch", uid, "tong", model.SearchProfiles).Return(nil)
+
+		// a response recorder for getting written http response
+		rr := httptest.NewRecorder()
+
+		router := gin.Default()
+		store := cookie.NewStore([]byte("secret"))
+		router.Use(sessions.Sessions("mqk", store))
+
+		router.Use(func(c *gin.Context) {
+			session := sessions.Default(c)
+			session.Set("userId", uid)
+			c.Set("userId", uid)
+		})
+
+		NewHandler(&Config{
+			R:             router,
+			UserService:   mockUserService,
+			SearchService: mockSearchService,
+		})
+
+		request, err := http.NewRequest(http.MethodGet, "/v1/profiles?search=tong", nil)
+		assert.NoError(t, err)
+
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		mockSearchService.AssertExpectations(t)
+	})
 }
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"strings"
+	"time"
+)
+
+// dateLayout is the layout of dates in query parameters
+const dateLayout = "2006-01-02"
+
+// bindTimeRange reads the optional "since" and "until" query parameters.
+// Both take a date like 2022-02-01, which is midnight UTC, or an RFC 3339 time.
+// A date as until includes that whole day. It returns false if it sent an error.
+func bindTimeRange(c *gin.Context) (model.TimeRange, bool) {
+	var r model.TimeRange
+	var err error
+
+	if value := c.Query("since"); value != "" {
+		if r.Since, err = parseRangeBound(value, false); err != nil {
+			return sendRangeError(c, "since must be a date like 2022-02-01 or an RFC 3339 time")
+		}
+	}
+
+	if value := c.Query("until"); value != "" {
+		if r.Until, err = parseRangeBound(value, true); err != nil {
+			return sendRangeError(c, "until must be a date like 2022-02-01 or an RFC 3339 time")
+		}
+	}
+
+	if !r.Since.IsZero() && !r.Until.IsZero() && !r.Since.Before(r.Until) {
+		return sendRangeError(c, "since must be before until")
+	}
+
+	return r, true
+}
+
+// parseRangeBound parses a date or an RFC 3339 time. With endOfDay,
+// a date is turned into the start of the following day.
+func parseRangeBound(value string, endOfDay bool) (time.Time, error) {
+	if t, err := time.Parse(dateLayout, value); err == nil {
+		if endOfDay {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+
+	// a "+" in the query string turns into a space
+	return time.Parse(time.RFC3339Nano, strings.Replace(value, " ", "+", 1))
+}
+
+func sendRangeError(c *gin.Context, reason string) (model.TimeRange, bool) {
+	e := apperrors.NewBadRequest(reason)
+	c.JSON(e.Status(), gin.H{
+		"error": e,
+	})
+	return model.TimeRange{}, false
+}
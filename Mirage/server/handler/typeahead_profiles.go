@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"log"
+	"net/http"
+)
+
+// TypeaheadProfiles handler returns the few profiles whose username or
+// display name start with the query, for autocompleting a mention
+func (h *Handler) TypeaheadProfiles(c *gin.Context) {
+	query := c.Query("q")
+
+	users, err := h.UserService.Typeahead(query)
+
+	if err != nil {
+		log.Printf("Unable to find typeahead profiles for query: %v\n%v", query, err)
+		c.JSON(apperrors.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	response := make([]model.MentionProfile, 0)
+
+	for _, u := range *users {
+		response = append(response, u.NewMentionProfileResponse())
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-contrib/sessions/cookie"
+	"github.com/gin-gonic/gin"
+	"github.com/sentrionic/mirage/mocks"
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"github.com/sentrionic/mirage/model/fixture"
+	"github.com/sentrionic/mirage/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandler_TypeaheadProfiles(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	uid, _ := service.GenerateId()
+
+	t.Run("Success", func(t *testing.T) {
+		users := make([]model.User, 0)
+
+		for i := 0; i < 3; i++ {
+			mockUser := fixture.GetMockUser()
+			users = append(users, *mockUser)
+		}
+
+		mockUserService := new(mocks.UserService)
+		mockUserService.On("Typeahead", "@to").Return(&users, nil)
+
+		// a response recorder for getting written http response
+		rr := httptest.NewRecorder()
+
+		router := gin.Default()
+		store := cookie.NewStore([]byte("secret"))
+		router.Use(sessions.Sessions("mqk", store))
+
+		router.Use(func(c *gin.Context) {
+			session := sessions.Default(c)
+			session.Set("userId", uid)
+			c.Set("userId", uid)
+		})
+
+		NewHandler(&Config{
+			R:           router,
+			UserService: mockUserService,
+		})
+
+		request, err := http.NewRequest(http.MethodGet, "/v1/profiles/typeahead?q=%40to", nil)
+		assert.NoError(t, err)
+
+		router.ServeHTTP(rr, request)
+
+		rsp := make([]model.MentionProfile, 0)
+
+		for _, u := range users {
+			rsp = append(rsp, u.NewMentionProfileResponse())
+		}
+
+		respBody, err := json.Marshal(rsp)
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+		mockUserService.AssertExpectations(t)
+	})
+
+	t.Run("Unauthorized", func(t *testing.T) {
+		mockUserService := new(mocks.UserService)
+		mockUserService.On("Typeahead", mock.AnythingOfType("string")).Return(nil, nil)
+
+		// a response recorder for getting written http response
+		rr := httptest.NewRecorder()
+
+		router := gin.Default()
+		store := cookie.NewStore([]byte("secret"))
+		router.Use(sessions.Sessions("mqk", store))
+
+		NewHandler(&Config{
+			R:           router,
+			UserService: mockUserService,
+		})
+
+		request, err := http.NewRequest(http.MethodGet, "/v1/profiles/typeahead?q=to", nil)
+		assert.NoError(t, err)
+
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		mockUserService.AssertNotCalled(t, "Typeahead")
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		mockUserService := new(mocks.UserService)
+		mockUserService.On("Typeahead", "to").Return(nil, fmt.Errorf("some error down call chain"))
+
+		// a response recorder for getting written http response
+		rr := httptest.NewRecorder()
+
+		router := gin.Default()
+		store := cookie.NewStore([]byte("secret"))
+		router.Use(sessions.Sessions("mqk", store))
+
+		router.Use(func(c *gin.Context) {
+			session := sessions.Default(c)
+			session.Set("userId", uid)
+			c.Set("userId", uid)
+		})
+
+		NewHandler(&Config{
+			R:           router,
+			UserService: mockUserService,
+		})
+
+		request, err := http.NewRequest(http.MethodGet, "/v1/profiles/typeahead?q=to", nil)
+		assert.NoError(t, err)
+
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, apperrors.NewInternal().Status(), rr.Code)
+		mockUserService.AssertExpectations(t)
+	})
+}
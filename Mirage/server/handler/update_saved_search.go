@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"log"
+	"net/http"
+	"strings"
+)
+
+type updateSavedSearchReq struct {
+	Name string `json:"name"`
+}
+
+func (r updateSavedSearchReq) Validate() error {
+	return validation.ValidateStruct(&r,
+		validation.Field(&r.Name, validation.Required, validation.Length(1, 50)),
+	)
+}
+
+func (r *updateSavedSearchReq) Sanitize() {
+	r.Name = strings.TrimSpace(r.Name)
+}
+
+// UpdateSavedSearch handler renames the saved search with the given ID
+func (h *Handler) UpdateSavedSearch(c *gin.Context) {
+	userId := c.MustGet("userId").(string)
+	searchId := c.Param("id")
+
+	var req updateSavedSearchReq
+
+	if ok := bindData(c, &req); !ok {
+		return
+	}
+
+	req.Sanitize()
+
+	search, err := h.SearchService.RenameSavedSearch(userId, searchId, req.Name)
+
+	if err != nil {
+		log.Printf("Unable to rename saved search: %v\n%v", searchId, err)
+		c.JSON(apperrors.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, search.NewSavedSearchResponse())
+}
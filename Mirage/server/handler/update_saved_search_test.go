@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-contrib/sessions/cookie"
+	"github.com/gin-gonic/gin"
+	"github.com/sentrionic/mirage/mocks"
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"github.com/sentrionic/mirage/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHandler_UpdateSavedSearch(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	uid, _ := service.GenerateId()
+
+	setupRouter := func(mockSearchService *mocks.SearchService) *gin.Engine {
+		router := gin.Default()
+		store := cookie.NewStore([]byte("secret"))
+		router.Use(sessions.Sessions("mqk", store))
+
+		router.Use(func(c *gin.Context) {
+			session := sessions.Default(c)
+			session.Set("userId", uid)
+			c.Set("userId", uid)
+		})
+
+		NewHandler(&Config{
+			R:             router,
+			SearchService: mockSearchService,
+		})
+
+		return router
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		search := &model.SavedSearch{
+			ID:            "1",
+			UserID:        uid,
+			Name:          "Golang",
+			Query:         "#go",
+			Kind:          model.SearchPosts,
+			LastVisitedAt: time.Now(),
+		}
+
+		mockSearchService := new(mocks.SearchService)
+		mockSearchService.On("RenameSavedSearch", uid, "1", "Golang").Return(search, nil)
+
+		rr := httptest.NewRecorder()
+		router := setupRouter(mockSearchService)
+
+		reqBody, err := json.Marshal(gin.H{"name": "Golang"})
+		assert.NoError(t, err)
+
+		request, err := http.NewRequest(http.MethodPut, "/v1/accounts/searches/1", bytes.NewBuffer(reqBody))
+		assert.NoError(t, err)
+		request.Header.Set("Content-Type", "application/json")
+
+		router.ServeHTTP(rr, request)
+
+		respBody, err := json.Marshal(search.NewSavedSearchResponse())
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+		mockSearchService.AssertExpectations(t)
+	})
+
+	t.Run("Missing name", func(t *testing.T) {
+		mockSearchService := new(mocks.SearchService)
+
+		rr := httptest.NewRecorder()
+		router := setupRouter(mockSearchService)
+
+		reqBody, err := json.Marshal(gin.H{"name": ""})
+		assert.NoError(t, err)
+
+		request, err := http.NewRequest(http.MethodPut, "/v1/accounts/searches/1", bytes.NewBuffer(reqBody))
+		assert.NoError(t, err)
+		request.Header.Set("Content-Type", "application/json")
+
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		mockSearchService.AssertNotCalled(t, "RenameSavedSearch", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		respErr := apperrors.NewNotFound("saved search", "2")
+
+		mockSearchService := new(mocks.SearchService)
+		mockSearchService.On("RenameSavedSearch", uid, "2", "Golang").Return(nil, respErr)
+
+		rr := httptest.NewRecorder()
+		router := setupRouter(mockSearchService)
+
+		reqBody, err := json.Marshal(gin.H{"name": "Golang"})
+		assert.NoError(t, err)
+
+		request, err := http.NewRequest(http.MethodPut, "/v1/accounts/searches/2", bytes.NewBuffer(reqBody))
+		assert.NoError(t, err)
+		request.Header.Set("Content-Type", "application/json")
+
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		mockSearchService.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,27 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"log"
+	"net/http"
+)
+
+// VisitSavedSearch handler marks the saved search with the given ID as visited,
+// so its new results are counted from now on
+func (h *Handler) VisitSavedSearch(c *gin.Context) {
+	userId := c.MustGet("userId").(string)
+	searchId := c.Param("id")
+
+	search, err := h.SearchService.VisitSavedSearch(userId, searchId)
+
+	if err != nil {
+		log.Printf("Unable to visit saved search: %v\n%v", searchId, err)
+		c.JSON(apperrors.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, search.NewSavedSearchResponse())
+}
@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-contrib/sessions/cookie"
+	"github.com/gin-gonic/gin"
+	"github.com/sentrionic/mirage/mocks"
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"github.com/sentrionic/mirage/service"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHandler_VisitSavedSearch(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	uid, _ := service.GenerateId()
+
+	setupRouter := func(mockSearchService *mocks.SearchService) *gin.Engine {
+		router := gin.Default()
+		store := cookie.NewStore([]byte("secret"))
+		router.Use(sessions.Sessions("mqk", store))
+
+		router.Use(func(c *gin.Context) {
+			session := sessions.Default(c)
+			session.Set("userId", uid)
+			c.Set("userId", uid)
+		})
+
+		NewHandler(&Config{
+			R:             router,
+			SearchService: mockSearchService,
+		})
+
+		return router
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		search := &model.SavedSearch{
+			ID:            "1",
+			UserID:        uid,
+			Name:          "Go",
+			Query:         "#go",
+			Kind:          model.SearchPosts,
+			LastVisitedAt: time.Now(),
+		}
+
+		mockSearchService := new(mocks.SearchService)
+		mockSearchService.On("VisitSavedSearch", uid, "1").Return(search, nil)
+
+		rr := httptest.NewRecorder()
+		router := setupRouter(mockSearchService)
+
+		request, err := http.NewRequest(http.MethodPost, "/v1/accounts/searches/1/visit", nil)
+		assert.NoError(t, err)
+
+		router.ServeHTTP(rr, request)
+
+		respBody, err := json.Marshal(search.NewSavedSearchResponse())
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+		mockSearchService.AssertExpectations(t)
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		mockSearchService := new(mocks.SearchService)
+		mockSearchService.On("VisitSavedSearch", uid, "2").Return(nil, apperrors.NewNotFound("saved search", "2"))
+
+		rr := httptest.NewRecorder()
+		router := setupRouter(mockSearchService)
+
+		request, err := http.NewRequest(http.MethodPost, "/v1/accounts/searches/2/visit", nil)
+		assert.NoError(t, err)
+
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		mockSearchService.AssertExpectations(t)
+	})
+}
@@ -5,6 +5,8 @@ import (
 	"github.com/gin-contrib/sessions"
 	"github.com/gin-contrib/sessions/redis"
 	"github.com/sentrionic/mirage/handler"
+	"github.com/sentrionic/mirage/handler/middleware"
+	"github.com/sentrionic/mirage/model"
 	"github.com/sentrionic/mirage/repository"
 	"github.com/sentrionic/mirage/service"
 	"log"
@@ -26,6 +28,18 @@ func inject(d *dataSources) (*gin.Engine, error) {
 	 */
 	userRepository := repository.NewUserRepository(d.DB)
 	postRepository := repository.NewPostRepository(d.DB)
+	sessionRepository := repository.NewSessionRepository(d.RedisClient)
+	tokenRepository := repository.NewTokenRepository(d.DB)
+	rateLimiter := repository.NewRateLimiter(d.RedisClient)
+	trendRepository := repository.NewTrendRepository(d.RedisClient)
+	linkRepository := repository.NewLinkRepository(d.RedisClient)
+	uploadRepository := repository.NewUploadRepository(d.DB)
+	chunkedUploadRepository := repository.NewChunkedUploadRepository(d.RedisClient)
+	timelineRepository := repository.NewTimelineRepository(d.RedisClient)
+	feedSnapshotRepository := repository.NewFeedSnapshotRepository(d.RedisClient)
+	suggestionCache := repository.NewSuggestionCache(d.RedisClient)
+	savedSearchRepository := repository.NewSavedSearchRepository(d.DB)
+	searchHistoryRepository := repository.NewSearchHistoryRepository(d.RedisClient)
 
 	bucketName := os.Getenv("AWS_STORAGE_BUCKET_NAME")
 	fileRepository := repository.NewFileRepository(d.S3Session, bucketName)
@@ -33,14 +47,77 @@ func inject(d *dataSources) (*gin.Engine, error) {
 	/*
 	 * service layer
 	 */
+	// jobs are processed by the worker command, so they are only queued
+	// when it runs. Otherwise the work is done during the request.
+	var jobService model.JobService
+	if os.Getenv("BACKGROUND_JOBS") == "true" {
+		jobService = service.NewJobService(&service.JSConfig{
+			JobQueue: repository.NewJobQueue(d.RedisClient),
+		})
+	}
+
+	timelineService := service.NewTimelineService(&service.TlSConfig{
+		TimelineRepository: timelineRepository,
+		PostRepository:     postRepository,
+		UserRepository:     userRepository,
+		JobService:         jobService,
+	})
+
 	userService := service.NewUserService(&service.USConfig{
-		UserRepository: userRepository,
+		UserRepository:   userRepository,
+		FileRepository:   fileRepository,
+		UploadRepository: uploadRepository,
+		RateLimiter:      rateLimiter,
+		JobService:       jobService,
+		TimelineService:  timelineService,
+	})
+
+	trendService := service.NewTrendService(&service.TrSConfig{
+		TrendRepository: trendRepository,
+		PostRepository:  postRepository,
+	})
+
+	linkService := service.NewLinkService(&service.LSConfig{
+		Fetcher:        service.NewLinkFetcher(5 * time.Second),
+		LinkRepository: linkRepository,
 		FileRepository: fileRepository,
 	})
 
 	postService := service.NewPostService(&service.PSConfig{
-		PostRepository: postRepository,
-		FileRepository: fileRepository,
+		PostRepository:         postRepository,
+		FileRepository:         fileRepository,
+		TrendService:           trendService,
+		LinkService:            linkService,
+		JobService:             jobService,
+		TimelineService:        timelineService,
+		FeedSnapshotRepository: feedSnapshotRepository,
+	})
+
+	mediaService := service.NewMediaService(&service.MSConfig{
+		UploadRepository:        uploadRepository,
+		ChunkedUploadRepository: chunkedUploadRepository,
+		FileRepository:          fileRepository,
+		PostRepository:          postRepository,
+		JobService:              jobService,
+	})
+
+	suggestionService := service.NewSuggestionService(&service.SgSConfig{
+		UserRepository:  userRepository,
+		SuggestionCache: suggestionCache,
+	})
+
+	searchService := service.NewSearchService(&service.SeSConfig{
+		SavedSearchRepository:   savedSearchRepository,
+		SearchHistoryRepository: searchHistoryRepository,
+		PostRepository:          postRepository,
+	})
+
+	sessionService := service.NewSessionService(&service.SSConfig{
+		SessionRepository: sessionRepository,
+	})
+
+	tokenService := service.NewTokenService(&service.TSConfig{
+		TokenRepository: tokenRepository,
 	})
 
 	// initialize gin.Engine
@@ -62,7 +139,7 @@ func inject(d *dataSources) (*gin.Engine, error) {
 
 	store.Options(sessions.Options{
 		Domain:   domain,
-		MaxAge:   60 * 60 * 24 * 7, // 7 days
+		MaxAge:   int(model.SessionMaxAge.Seconds()), // 7 days
 		Secure:   gin.Mode() == gin.ReleaseMode,
 		HttpOnly: true,
 		Path:     "/",
@@ -79,19 +156,64 @@ func inject(d *dataSources) (*gin.Engine, error) {
 		return nil, fmt.Errorf("could not parse HANDLER_TIMEOUT as int: %w", err)
 	}
 
+	if err := configurePasswordHashing(); err != nil {
+		return nil, err
+	}
+
 	maxBodyBytes := os.Getenv("MAX_BODY_BYTES")
 	mbb, err := strconv.ParseInt(maxBodyBytes, 0, 64)
 	if err != nil {
 		return nil, fmt.Errorf("could not parse MAX_BODY_BYTES as int: %w", err)
 	}
 
+	// only requests from the trusted proxies may set the client IP
+	trustedProxies, err := middleware.ParseTrustedProxies(os.Getenv("TRUSTED_PROXIES"))
+	if err != nil {
+		return nil, fmt.Errorf("could not parse TRUSTED_PROXIES: %w", err)
+	}
+
 	handler.NewHandler(&handler.Config{
-		R:               router,
-		UserService:     userService,
-		PostService:     postService,
-		TimeoutDuration: time.Duration(ht) * time.Second,
-		MaxBodyBytes:    mbb,
+		R:                 router,
+		UserService:       userService,
+		PostService:       postService,
+		SessionService:    sessionService,
+		TokenService:      tokenService,
+		TrendService:      trendService,
+		MediaService:      mediaService,
+		JobService:        jobService,
+		SuggestionService: suggestionService,
+		SearchService:     searchService,
+		RateLimiter:       rateLimiter,
+		TrustedProxies:    trustedProxies,
+		TimeoutDuration:   time.Duration(ht) * time.Second,
+		MaxBodyBytes:      mbb,
 	})
 
 	return router, nil
 }
+
+// configurePasswordHashing reads the optional argon2id cost of new password
+// hashes from ARGON2_MEMORY (KiB), ARGON2_TIME and ARGON2_THREADS
+func configurePasswordHashing() error {
+	memory, time, threads := uint64(64*1024), uint64(3), uint64(4)
+
+	for name, value := range map[string]*uint64{
+		"ARGON2_MEMORY":  &memory,
+		"ARGON2_TIME":    &time,
+		"ARGON2_THREADS": &threads,
+	} {
+		if env := os.Getenv(name); env != "" {
+			v, err := strconv.ParseUint(env, 10, 32)
+			if err != nil {
+				return fmt.Errorf("could not parse %s as positive int: %w", name, err)
+			}
+			*value = v
+		}
+	}
+
+	if threads > 255 {
+		return fmt.Errorf("ARGON2_THREADS must be at most 255")
+	}
+
+	return service.SetArgon2idCost(uint32(memory), uint32(time), uint8(threads))
+}
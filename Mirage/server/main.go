@@ -30,6 +30,22 @@ func main() {
 		log.Fatalf("Unable to initialize data sources: %v\n", err)
 	}
 
+	// run a maintenance command like "rebuild-trends" if one is given
+	if len(os.Args) > 1 {
+		err := runCommand(os.Args[1], ds)
+
+		if closeErr := ds.close(); closeErr != nil {
+			log.Printf("A problem occured closing data sources: %v\n", closeErr)
+		}
+
+		if err != nil {
+			log.Fatalf("Command %v failed: %v\n", os.Args[1], err)
+		}
+
+		log.Printf("Command %v finished\n", os.Args[1])
+		return
+	}
+
 	router, err := inject(ds)
 
 	if err != nil {
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"fmt"
+	"github.com/lib/pq"
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/repository"
+	"github.com/sentrionic/mirage/service"
+	"gorm.io/gorm"
+	"log"
+	"time"
+)
+
+// dataMigrationBatchSize is the number of rows a data migration loads at once
+const dataMigrationBatchSize = 500
+
+// dataMigrationLock is the key of the advisory lock that keeps
+// several instances from running the data migrations at once
+const dataMigrationLock = 7041843
+
+// dataMigration changes existing rows after a change to how they are
+// written. Every data migration runs once in its own transaction.
+type dataMigration struct {
+	Name string
+	Run  func(tx *gorm.DB) error
+}
+
+// dataMigrations are run in order. Never rename or remove an entry,
+// the names of applied migrations are stored in the database.
+var dataMigrations = []dataMigration{
+	{Name: "normalize-post-hashtags", Run: normalizePostHashtags},
+	{Name: "copy-legacy-follows", Run: copyLegacyFollows},
+	{Name: "index-profile-search-text", Run: indexProfileSearchText},
+	{Name: "count-followers", Run: countFollowers},
+}
+
+// appliedMigration records a data migration that has been run
+type appliedMigration struct {
+	Name      string `gorm:"primaryKey"`
+	AppliedAt time.Time
+}
+
+func (appliedMigration) TableName() string {
+	return "data_migrations"
+}
+
+// runDataMigrations runs the data migrations that have not been applied yet
+func runDataMigrations(db *gorm.DB) error {
+	if err := db.AutoMigrate(&appliedMigration{}); err != nil {
+		return err
+	}
+
+	for _, migration := range dataMigrations {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", dataMigrationLock).Error; err != nil {
+				return err
+			}
+
+			var applied int64
+			if err := tx.Model(&appliedMigration{}).Where("name = ?", migration.Name).Count(&applied).Error; err != nil {
+				return err
+			}
+
+			if applied > 0 {
+				return nil
+			}
+
+			log.Printf("Running data migration %s\n", migration.Name)
+
+			if err := migration.Run(tx); err != nil {
+				return err
+			}
+
+			return tx.Create(&appliedMigration{Name: migration.Name, AppliedAt: time.Now()}).Error
+		})
+
+		if err != nil {
+			return fmt.Errorf("data migration %s: %w", migration.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// normalizePostHashtags extracts the hashtags of every post again, so that
+// posts from before hashtags were lower cased and stripped of punctuation
+// match the exact hashtag search
+func normalizePostHashtags(tx *gorm.DB) error {
+	var posts []model.Post
+
+	return tx.
+		Model(&model.Post{}).
+		Select("id", "text", "hash_tags").
+		FindInBatches(&posts, dataMigrationBatchSize, func(batch *gorm.DB, _ int) error {
+			for _, post := range posts {
+				tags := make([]string, 0)
+				if post.Text != nil {
+					tags = service.GetHashtags(*post.Text)
+				}
+
+				if equalTags(tags, post.HashTags) {
+					continue
+				}
+
+				if err := tx.
+					Model(&model.Post{}).
+					Where("id = ?", post.ID).
+					UpdateColumn("hash_tags", pq.StringArray(tags)).
+					Error; err != nil {
+					return err
+				}
+			}
+
+			return nil
+		}).
+		Error
+}
+
+// indexProfileSearchText sets the normalized display names and bios
+// of the users created before profile search matched against them
+func indexProfileSearchText(tx *gorm.DB) error {
+	userService := service.NewUserService(&service.USConfig{
+		UserRepository: repository.NewUserRepository(tx),
+	})
+	_, err := userService.ReindexProfiles()
+	return err
+}
+
+// countFollowers sets the stored follower counts from the follows made before they were kept
+func countFollowers(tx *gorm.DB) error {
+	return repository.NewUserRepository(tx).RecountFollowers()
+}
+
+// legacyFollowTables are the tables that stored every follow twice
+// before the follows table. They are only dropped by the drop-legacy-follows command.
+var legacyFollowTables = []string{"followers", "followee"}
+
+// copyLegacyFollows copies the edges of the old followers and followee tables
+// into the follows table. The old tables had no timestamps, so the copied
+// follows get the current time.
+func copyLegacyFollows(tx *gorm.DB) error {
+	if tx.Migrator().HasTable("followers") {
+		if err := tx.Exec(`
+			INSERT INTO follows (follower_id, followee_id)
+			SELECT follower_id, user_id FROM followers
+			ON CONFLICT DO NOTHING
+		`).Error; err != nil {
+			return err
+		}
+	}
+
+	if tx.Migrator().HasTable("followee") {
+		if err := tx.Exec(`
+			INSERT INTO follows (follower_id, followee_id)
+			SELECT user_id, followee_id FROM followee
+			ON CONFLICT DO NOTHING
+		`).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// dropLegacyFollows drops the old follow tables once their edges have been
+// copied into the follows table. It returns the number of dropped tables.
+func dropLegacyFollows(db *gorm.DB) (int, error) {
+	var copied int64
+	if err := db.Model(&appliedMigration{}).Where("name = ?", "copy-legacy-follows").Count(&copied).Error; err != nil {
+		return 0, err
+	}
+
+	if copied == 0 {
+		return 0, fmt.Errorf("the follows have not been copied yet")
+	}
+
+	dropped := 0
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for _, table := range legacyFollowTables {
+			if !tx.Migrator().HasTable(table) {
+				continue
+			}
+
+			if err := tx.Migrator().DropTable(table); err != nil {
+				return err
+			}
+			dropped++
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return dropped, nil
+}
+
+func equalTags(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
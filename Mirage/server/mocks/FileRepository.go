@@ -3,9 +3,12 @@
 package mocks
 
 import (
+	model "github.com/sentrionic/mirage/model"
 	mock "github.com/stretchr/testify/mock"
 
+	io "io"
 	multipart "mime/multipart"
+	time "time"
 )
 
 // FileRepository is an autogenerated mock type for the FileRepository type
@@ -13,6 +16,76 @@ type FileRepository struct {
 	mock.Mock
 }
 
+// AbortMultipartUpload provides a mock function with given fields: key, multipartId
+func (_m *FileRepository) AbortMultipartUpload(key string, multipartId string) error {
+	ret := _m.Called(key, multipartId)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string) error); ok {
+		r0 = rf(key, multipartId)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CompleteMultipartUpload provides a mock function with given fields: key, multipartId, parts
+func (_m *FileRepository) CompleteMultipartUpload(key string, multipartId string, parts []model.UploadPart) error {
+	ret := _m.Called(key, multipartId, parts)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, []model.UploadPart) error); ok {
+		r0 = rf(key, multipartId, parts)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CopyFile provides a mock function with given fields: key, directory, filename
+func (_m *FileRepository) CopyFile(key string, directory string, filename string) (string, error) {
+	ret := _m.Called(key, directory, filename)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(string, string, string) string); ok {
+		r0 = rf(key, directory, filename)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string, string) error); ok {
+		r1 = rf(key, directory, filename)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateMultipartUpload provides a mock function with given fields: key, mimetype
+func (_m *FileRepository) CreateMultipartUpload(key string, mimetype string) (string, error) {
+	ret := _m.Called(key, mimetype)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(string, string) string); ok {
+		r0 = rf(key, mimetype)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(key, mimetype)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // DeleteImage provides a mock function with given fields: key
 func (_m *FileRepository) DeleteImage(key string) error {
 	ret := _m.Called(key)
@@ -27,6 +100,96 @@ func (_m *FileRepository) DeleteImage(key string) error {
 	return r0
 }
 
+// FileURL provides a mock function with given fields: directory, filename
+func (_m *FileRepository) FileURL(directory string, filename string) (string, error) {
+	ret := _m.Called(directory, filename)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(string, string) string); ok {
+		r0 = rf(directory, filename)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(directory, filename)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// OpenFile provides a mock function with given fields: key
+func (_m *FileRepository) OpenFile(key string) (io.ReadCloser, error) {
+	ret := _m.Called(key)
+
+	var r0 io.ReadCloser
+	if rf, ok := ret.Get(0).(func(string) io.ReadCloser); ok {
+		r0 = rf(key)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(io.ReadCloser)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(key)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PresignUpload provides a mock function with given fields: key, mimetype, size, expires
+func (_m *FileRepository) PresignUpload(key string, mimetype string, size int64, expires time.Duration) (*model.UploadTarget, error) {
+	ret := _m.Called(key, mimetype, size, expires)
+
+	var r0 *model.UploadTarget
+	if rf, ok := ret.Get(0).(func(string, string, int64, time.Duration) *model.UploadTarget); ok {
+		r0 = rf(key, mimetype, size, expires)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.UploadTarget)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string, int64, time.Duration) error); ok {
+		r1 = rf(key, mimetype, size, expires)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// StatFile provides a mock function with given fields: key
+func (_m *FileRepository) StatFile(key string) (*model.StoredObject, error) {
+	ret := _m.Called(key)
+
+	var r0 *model.StoredObject
+	if rf, ok := ret.Get(0).(func(string) *model.StoredObject); ok {
+		r0 = rf(key)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.StoredObject)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(key)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // UploadAvatar provides a mock function with given fields: header, directory
 func (_m *FileRepository) UploadAvatar(header *multipart.FileHeader, directory string) (string, error) {
 	ret := _m.Called(header, directory)
@@ -69,20 +232,62 @@ func (_m *FileRepository) UploadBanner(header *multipart.FileHeader, directory s
 	return r0, r1
 }
 
-// UploadFile provides a mock function with given fields: header, directory, filename, mimetype
-func (_m *FileRepository) UploadFile(header *multipart.FileHeader, directory string, filename string, mimetype string) (string, error) {
-	ret := _m.Called(header, directory, filename, mimetype)
+// UploadFile provides a mock function with given fields: body, directory, filename, mimetype
+func (_m *FileRepository) UploadFile(body io.Reader, directory string, filename string, mimetype string) (string, error) {
+	ret := _m.Called(body, directory, filename, mimetype)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(io.Reader, string, string, string) string); ok {
+		r0 = rf(body, directory, filename, mimetype)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(io.Reader, string, string, string) error); ok {
+		r1 = rf(body, directory, filename, mimetype)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UploadPart provides a mock function with given fields: key, multipartId, number, body
+func (_m *FileRepository) UploadPart(key string, multipartId string, number int64, body io.ReadSeeker) (string, error) {
+	ret := _m.Called(key, multipartId, number, body)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(string, string, int64, io.ReadSeeker) string); ok {
+		r0 = rf(key, multipartId, number, body)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string, int64, io.ReadSeeker) error); ok {
+		r1 = rf(key, multipartId, number, body)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UploadResizedImage provides a mock function with given fields: body, directory, filename, width
+func (_m *FileRepository) UploadResizedImage(body io.Reader, directory string, filename string, width int) (string, error) {
+	ret := _m.Called(body, directory, filename, width)
 
 	var r0 string
-	if rf, ok := ret.Get(0).(func(*multipart.FileHeader, string, string, string) string); ok {
-		r0 = rf(header, directory, filename, mimetype)
+	if rf, ok := ret.Get(0).(func(io.Reader, string, string, int) string); ok {
+		r0 = rf(body, directory, filename, width)
 	} else {
 		r0 = ret.Get(0).(string)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(*multipart.FileHeader, string, string, string) error); ok {
-		r1 = rf(header, directory, filename, mimetype)
+	if rf, ok := ret.Get(1).(func(io.Reader, string, string, int) error); ok {
+		r1 = rf(body, directory, filename, width)
 	} else {
 		r1 = ret.Error(1)
 	}
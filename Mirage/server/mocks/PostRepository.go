@@ -5,6 +5,8 @@ package mocks
 import (
 	model "github.com/sentrionic/mirage/model"
 	mock "github.com/stretchr/testify/mock"
+
+	time "time"
 )
 
 // PostRepository is an autogenerated mock type for the PostRepository type
@@ -12,6 +14,29 @@ type PostRepository struct {
 	mock.Mock
 }
 
+// ActivityEntries provides a mock function with given fields: actorIds, before, limit
+func (_m *PostRepository) ActivityEntries(actorIds []string, before *model.TimelineEntry, limit int) ([]model.TimelineEntry, error) {
+	ret := _m.Called(actorIds, before, limit)
+
+	var r0 []model.TimelineEntry
+	if rf, ok := ret.Get(0).(func([]string, *model.TimelineEntry, int) []model.TimelineEntry); ok {
+		r0 = rf(actorIds, before, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.TimelineEntry)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func([]string, *model.TimelineEntry, int) error); ok {
+		r1 = rf(actorIds, before, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // AddLike provides a mock function with given fields: post, uid
 func (_m *PostRepository) AddLike(post *model.Post, uid string) error {
 	ret := _m.Called(post, uid)
@@ -40,6 +65,87 @@ func (_m *PostRepository) AddRetweet(post *model.Post, uid string) error {
 	return r0
 }
 
+// AuthorAffinity provides a mock function with given fields: userId, authorIds
+func (_m *PostRepository) AuthorAffinity(userId string, authorIds []string) (map[string]model.AuthorAffinity, error) {
+	ret := _m.Called(userId, authorIds)
+
+	var r0 map[string]model.AuthorAffinity
+	if rf, ok := ret.Get(0).(func(string, []string) map[string]model.AuthorAffinity); ok {
+		r0 = rf(userId, authorIds)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[string]model.AuthorAffinity)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, []string) error); ok {
+		r1 = rf(userId, authorIds)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ClaimFileHash provides a mock function with given fields: hash
+func (_m *PostRepository) ClaimFileHash(hash string) error {
+	ret := _m.Called(hash)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(hash)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CountHashtagPosts provides a mock function with given fields: query
+func (_m *PostRepository) CountHashtagPosts(query model.HashtagQuery) (int64, error) {
+	ret := _m.Called(query)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(model.HashtagQuery) int64); ok {
+		r0 = rf(query)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(model.HashtagQuery) error); ok {
+		r1 = rf(query)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CountHashtagPostsBatch provides a mock function with given fields: queries
+func (_m *PostRepository) CountHashtagPostsBatch(queries []model.HashtagQuery) ([]int64, error) {
+	ret := _m.Called(queries)
+
+	var r0 []int64
+	if rf, ok := ret.Get(0).(func([]model.HashtagQuery) []int64); ok {
+		r0 = rf(queries)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]int64)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func([]model.HashtagQuery) error); ok {
+		r1 = rf(queries)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Create provides a mock function with given fields: post
 func (_m *PostRepository) Create(post *model.Post) (*model.Post, error) {
 	ret := _m.Called(post)
@@ -77,13 +183,57 @@ func (_m *PostRepository) Delete(post *model.Post) error {
 	return r0
 }
 
-// Feed provides a mock function with given fields: userId, cursor
-func (_m *PostRepository) Feed(userId string, cursor string) (*[]model.Post, error) {
-	ret := _m.Called(userId, cursor)
+// DeleteOrphanedMedia provides a mock function with given fields: hash, deleteObjects
+func (_m *PostRepository) DeleteOrphanedMedia(hash string, deleteObjects model.ObjectDeleter) (bool, error) {
+	ret := _m.Called(hash, deleteObjects)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(string, model.ObjectDeleter) bool); ok {
+		r0 = rf(hash, deleteObjects)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, model.ObjectDeleter) error); ok {
+		r1 = rf(hash, deleteObjects)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FeedActivity provides a mock function with given fields: userId, postIds
+func (_m *PostRepository) FeedActivity(userId string, postIds []string) ([]model.TimelineEntry, error) {
+	ret := _m.Called(userId, postIds)
+
+	var r0 []model.TimelineEntry
+	if rf, ok := ret.Get(0).(func(string, []string) []model.TimelineEntry); ok {
+		r0 = rf(userId, postIds)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.TimelineEntry)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, []string) error); ok {
+		r1 = rf(userId, postIds)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FeedCandidates provides a mock function with given fields: userId, since, limit
+func (_m *PostRepository) FeedCandidates(userId string, since time.Time, limit int) (*[]model.Post, error) {
+	ret := _m.Called(userId, since, limit)
 
 	var r0 *[]model.Post
-	if rf, ok := ret.Get(0).(func(string, string) *[]model.Post); ok {
-		r0 = rf(userId, cursor)
+	if rf, ok := ret.Get(0).(func(string, time.Time, int) *[]model.Post); ok {
+		r0 = rf(userId, since, limit)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*[]model.Post)
@@ -91,8 +241,31 @@ func (_m *PostRepository) Feed(userId string, cursor string) (*[]model.Post, err
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string, string) error); ok {
-		r1 = rf(userId, cursor)
+	if rf, ok := ret.Get(1).(func(string, time.Time, int) error); ok {
+		r1 = rf(userId, since, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FeedEntries provides a mock function with given fields: userId, before, limit
+func (_m *PostRepository) FeedEntries(userId string, before *model.TimelineEntry, limit int) ([]model.TimelineEntry, error) {
+	ret := _m.Called(userId, before, limit)
+
+	var r0 []model.TimelineEntry
+	if rf, ok := ret.Get(0).(func(string, *model.TimelineEntry, int) []model.TimelineEntry); ok {
+		r0 = rf(userId, before, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.TimelineEntry)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, *model.TimelineEntry, int) error); ok {
+		r1 = rf(userId, before, limit)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -123,13 +296,59 @@ func (_m *PostRepository) FindByID(id string) (*model.Post, error) {
 	return r0, r1
 }
 
-// GetPostsForHashtag provides a mock function with given fields: tag, cursor
-func (_m *PostRepository) GetPostsForHashtag(tag string, cursor string) (*[]model.Post, error) {
-	ret := _m.Called(tag, cursor)
+// FindByIDs provides a mock function with given fields: ids
+func (_m *PostRepository) FindByIDs(ids []string) (*[]model.Post, error) {
+	ret := _m.Called(ids)
+
+	var r0 *[]model.Post
+	if rf, ok := ret.Get(0).(func([]string) *[]model.Post); ok {
+		r0 = rf(ids)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*[]model.Post)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func([]string) error); ok {
+		r1 = rf(ids)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindFileByHash provides a mock function with given fields: hash
+func (_m *PostRepository) FindFileByHash(hash string) (*model.File, error) {
+	ret := _m.Called(hash)
+
+	var r0 *model.File
+	if rf, ok := ret.Get(0).(func(string) *model.File); ok {
+		r0 = rf(hash)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.File)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(hash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPostsForHashtags provides a mock function with given fields: query, cursor
+func (_m *PostRepository) GetPostsForHashtags(query model.HashtagQuery, cursor string) (*[]model.Post, error) {
+	ret := _m.Called(query, cursor)
 
 	var r0 *[]model.Post
-	if rf, ok := ret.Get(0).(func(string, string) *[]model.Post); ok {
-		r0 = rf(tag, cursor)
+	if rf, ok := ret.Get(0).(func(model.HashtagQuery, string) *[]model.Post); ok {
+		r0 = rf(query, cursor)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*[]model.Post)
@@ -137,8 +356,8 @@ func (_m *PostRepository) GetPostsForHashtag(tag string, cursor string) (*[]mode
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string, string) error); ok {
-		r1 = rf(tag, cursor)
+	if rf, ok := ret.Get(1).(func(model.HashtagQuery, string) error); ok {
+		r1 = rf(query, cursor)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -146,13 +365,13 @@ func (_m *PostRepository) GetPostsForHashtag(tag string, cursor string) (*[]mode
 	return r0, r1
 }
 
-// Likes provides a mock function with given fields: id, cursor
-func (_m *PostRepository) Likes(id string, cursor string) (*[]model.Post, error) {
-	ret := _m.Called(id, cursor)
+// HashtagsSince provides a mock function with given fields: since
+func (_m *PostRepository) HashtagsSince(since time.Time) (*[]model.Post, error) {
+	ret := _m.Called(since)
 
 	var r0 *[]model.Post
-	if rf, ok := ret.Get(0).(func(string, string) *[]model.Post); ok {
-		r0 = rf(id, cursor)
+	if rf, ok := ret.Get(0).(func(time.Time) *[]model.Post); ok {
+		r0 = rf(since)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*[]model.Post)
@@ -160,8 +379,8 @@ func (_m *PostRepository) Likes(id string, cursor string) (*[]model.Post, error)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string, string) error); ok {
-		r1 = rf(id, cursor)
+	if rf, ok := ret.Get(1).(func(time.Time) error); ok {
+		r1 = rf(since)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -169,13 +388,36 @@ func (_m *PostRepository) Likes(id string, cursor string) (*[]model.Post, error)
 	return r0, r1
 }
 
-// List provides a mock function with given fields: id, cursor
-func (_m *PostRepository) List(id string, cursor string) (*[]model.Post, error) {
-	ret := _m.Called(id, cursor)
+// LikeEntries provides a mock function with given fields: userId, before, limit
+func (_m *PostRepository) LikeEntries(userId string, before *model.TimelineEntry, limit int) ([]model.TimelineEntry, error) {
+	ret := _m.Called(userId, before, limit)
+
+	var r0 []model.TimelineEntry
+	if rf, ok := ret.Get(0).(func(string, *model.TimelineEntry, int) []model.TimelineEntry); ok {
+		r0 = rf(userId, before, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.TimelineEntry)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, *model.TimelineEntry, int) error); ok {
+		r1 = rf(userId, before, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Media provides a mock function with given fields: id, cursor, since
+func (_m *PostRepository) Media(id string, cursor string, since time.Time) (*[]model.Post, error) {
+	ret := _m.Called(id, cursor, since)
 
 	var r0 *[]model.Post
-	if rf, ok := ret.Get(0).(func(string, string) *[]model.Post); ok {
-		r0 = rf(id, cursor)
+	if rf, ok := ret.Get(0).(func(string, string, time.Time) *[]model.Post); ok {
+		r0 = rf(id, cursor, since)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*[]model.Post)
@@ -183,8 +425,8 @@ func (_m *PostRepository) List(id string, cursor string) (*[]model.Post, error)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string, string) error); ok {
-		r1 = rf(id, cursor)
+	if rf, ok := ret.Get(1).(func(string, string, time.Time) error); ok {
+		r1 = rf(id, cursor, since)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -192,13 +434,13 @@ func (_m *PostRepository) List(id string, cursor string) (*[]model.Post, error)
 	return r0, r1
 }
 
-// Media provides a mock function with given fields: id, cursor
-func (_m *PostRepository) Media(id string, cursor string) (*[]model.Post, error) {
-	ret := _m.Called(id, cursor)
+// OnThisDay provides a mock function with given fields: userId, month, days, before, limit
+func (_m *PostRepository) OnThisDay(userId string, month time.Month, days []int, before time.Time, limit int) (*[]model.Post, error) {
+	ret := _m.Called(userId, month, days, before, limit)
 
 	var r0 *[]model.Post
-	if rf, ok := ret.Get(0).(func(string, string) *[]model.Post); ok {
-		r0 = rf(id, cursor)
+	if rf, ok := ret.Get(0).(func(string, time.Month, []int, time.Time, int) *[]model.Post); ok {
+		r0 = rf(userId, month, days, before, limit)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*[]model.Post)
@@ -206,8 +448,77 @@ func (_m *PostRepository) Media(id string, cursor string) (*[]model.Post, error)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string, string) error); ok {
-		r1 = rf(id, cursor)
+	if rf, ok := ret.Get(1).(func(string, time.Month, []int, time.Time, int) error); ok {
+		r1 = rf(userId, month, days, before, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// OrphanedMedia provides a mock function with given fields: before
+func (_m *PostRepository) OrphanedMedia(before time.Time) (*[]model.OrphanedMedia, error) {
+	ret := _m.Called(before)
+
+	var r0 *[]model.OrphanedMedia
+	if rf, ok := ret.Get(0).(func(time.Time) *[]model.OrphanedMedia); ok {
+		r0 = rf(before)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*[]model.OrphanedMedia)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(time.Time) error); ok {
+		r1 = rf(before)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PostCalendar provides a mock function with given fields: userId, unit, from, to
+func (_m *PostRepository) PostCalendar(userId string, unit model.CalendarUnit, from time.Time, to time.Time) ([]model.CalendarBucket, error) {
+	ret := _m.Called(userId, unit, from, to)
+
+	var r0 []model.CalendarBucket
+	if rf, ok := ret.Get(0).(func(string, model.CalendarUnit, time.Time, time.Time) []model.CalendarBucket); ok {
+		r0 = rf(userId, unit, from, to)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.CalendarBucket)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, model.CalendarUnit, time.Time, time.Time) error); ok {
+		r1 = rf(userId, unit, from, to)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RelatedHashtags provides a mock function with given fields: tag, since, limit
+func (_m *PostRepository) RelatedHashtags(tag string, since time.Time, limit int) (*[]model.RelatedHashtag, error) {
+	ret := _m.Called(tag, since, limit)
+
+	var r0 *[]model.RelatedHashtag
+	if rf, ok := ret.Get(0).(func(string, time.Time, int) *[]model.RelatedHashtag); ok {
+		r0 = rf(tag, since, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*[]model.RelatedHashtag)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, time.Time, int) error); ok {
+		r1 = rf(tag, since, limit)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -242,3 +553,45 @@ func (_m *PostRepository) RemoveRetweet(post *model.Post, uid string) error {
 
 	return r0
 }
+
+// SetCard provides a mock function with given fields: id, card
+func (_m *PostRepository) SetCard(id string, card *model.Card) error {
+	ret := _m.Called(id, card)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, *model.Card) error); ok {
+		r0 = rf(id, card)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetFileVariants provides a mock function with given fields: hash, variants
+func (_m *PostRepository) SetFileVariants(hash string, variants model.ImageVariants) error {
+	ret := _m.Called(hash, variants)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, model.ImageVariants) error); ok {
+		r0 = rf(hash, variants)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateFile provides a mock function with given fields: file
+func (_m *PostRepository) UpdateFile(file *model.File) error {
+	ret := _m.Called(file)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*model.File) error); ok {
+		r0 = rf(file)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
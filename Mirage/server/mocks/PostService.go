@@ -7,6 +7,7 @@ import (
 	mock "github.com/stretchr/testify/mock"
 
 	multipart "mime/multipart"
+	time "time"
 )
 
 // PostService is an autogenerated mock type for the PostService type
@@ -14,6 +15,29 @@ type PostService struct {
 	mock.Mock
 }
 
+// Calendar provides a mock function with given fields: id, year
+func (_m *PostService) Calendar(id string, year int) ([]model.CalendarBucket, error) {
+	ret := _m.Called(id, year)
+
+	var r0 []model.CalendarBucket
+	if rf, ok := ret.Get(0).(func(string, int) []model.CalendarBucket); ok {
+		r0 = rf(id, year)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.CalendarBucket)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, int) error); ok {
+		r1 = rf(id, year)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // CreatePost provides a mock function with given fields: post
 func (_m *PostService) CreatePost(post *model.Post) (*model.Post, error) {
 	ret := _m.Called(post)
@@ -74,36 +98,43 @@ func (_m *PostService) FindPostByID(id string) (*model.Post, error) {
 	return r0, r1
 }
 
-// GetUserFeed provides a mock function with given fields: userId, cursor
-func (_m *PostService) GetUserFeed(userId string, cursor string) (*[]model.Post, error) {
-	ret := _m.Called(userId, cursor)
+// GetUserFeed provides a mock function with given fields: userId, mode, cursor, r
+func (_m *PostService) GetUserFeed(userId string, mode model.FeedMode, cursor string, r model.TimeRange) (*[]model.Post, string, error) {
+	ret := _m.Called(userId, mode, cursor, r)
 
 	var r0 *[]model.Post
-	if rf, ok := ret.Get(0).(func(string, string) *[]model.Post); ok {
-		r0 = rf(userId, cursor)
+	if rf, ok := ret.Get(0).(func(string, model.FeedMode, string, model.TimeRange) *[]model.Post); ok {
+		r0 = rf(userId, mode, cursor, r)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*[]model.Post)
 		}
 	}
 
-	var r1 error
-	if rf, ok := ret.Get(1).(func(string, string) error); ok {
-		r1 = rf(userId, cursor)
+	var r1 string
+	if rf, ok := ret.Get(1).(func(string, model.FeedMode, string, model.TimeRange) string); ok {
+		r1 = rf(userId, mode, cursor, r)
 	} else {
-		r1 = ret.Error(1)
+		r1 = ret.Get(1).(string)
 	}
 
-	return r0, r1
+	var r2 error
+	if rf, ok := ret.Get(2).(func(string, model.FeedMode, string, model.TimeRange) error); ok {
+		r2 = rf(userId, mode, cursor, r)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
 }
 
-// ProfileLikes provides a mock function with given fields: id, cursor
-func (_m *PostService) ProfileLikes(id string, cursor string) (*[]model.Post, error) {
-	ret := _m.Called(id, cursor)
+// OnThisDay provides a mock function with given fields: id, date, cursor
+func (_m *PostService) OnThisDay(id string, date time.Time, cursor string) (*[]model.Post, error) {
+	ret := _m.Called(id, date, cursor)
 
 	var r0 *[]model.Post
-	if rf, ok := ret.Get(0).(func(string, string) *[]model.Post); ok {
-		r0 = rf(id, cursor)
+	if rf, ok := ret.Get(0).(func(string, time.Time, string) *[]model.Post); ok {
+		r0 = rf(id, date, cursor)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*[]model.Post)
@@ -111,8 +142,8 @@ func (_m *PostService) ProfileLikes(id string, cursor string) (*[]model.Post, er
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string, string) error); ok {
-		r1 = rf(id, cursor)
+	if rf, ok := ret.Get(1).(func(string, time.Time, string) error); ok {
+		r1 = rf(id, date, cursor)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -120,13 +151,43 @@ func (_m *PostService) ProfileLikes(id string, cursor string) (*[]model.Post, er
 	return r0, r1
 }
 
-// ProfileMedia provides a mock function with given fields: id, cursor
-func (_m *PostService) ProfileMedia(id string, cursor string) (*[]model.Post, error) {
-	ret := _m.Called(id, cursor)
+// ProfileLikes provides a mock function with given fields: id, cursor, r
+func (_m *PostService) ProfileLikes(id string, cursor string, r model.TimeRange) (*[]model.Post, string, error) {
+	ret := _m.Called(id, cursor, r)
 
 	var r0 *[]model.Post
-	if rf, ok := ret.Get(0).(func(string, string) *[]model.Post); ok {
-		r0 = rf(id, cursor)
+	if rf, ok := ret.Get(0).(func(string, string, model.TimeRange) *[]model.Post); ok {
+		r0 = rf(id, cursor, r)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*[]model.Post)
+		}
+	}
+
+	var r1 string
+	if rf, ok := ret.Get(1).(func(string, string, model.TimeRange) string); ok {
+		r1 = rf(id, cursor, r)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(string, string, model.TimeRange) error); ok {
+		r2 = rf(id, cursor, r)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// ProfileMedia provides a mock function with given fields: id, cursor, r
+func (_m *PostService) ProfileMedia(id string, cursor string, r model.TimeRange) (*[]model.Post, error) {
+	ret := _m.Called(id, cursor, r)
+
+	var r0 *[]model.Post
+	if rf, ok := ret.Get(0).(func(string, string, model.TimeRange) *[]model.Post); ok {
+		r0 = rf(id, cursor, r)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*[]model.Post)
@@ -134,8 +195,8 @@ func (_m *PostService) ProfileMedia(id string, cursor string) (*[]model.Post, er
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string, string) error); ok {
-		r1 = rf(id, cursor)
+	if rf, ok := ret.Get(1).(func(string, string, model.TimeRange) error); ok {
+		r1 = rf(id, cursor, r)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -143,22 +204,52 @@ func (_m *PostService) ProfileMedia(id string, cursor string) (*[]model.Post, er
 	return r0, r1
 }
 
-// ProfilePosts provides a mock function with given fields: id, cursor
-func (_m *PostService) ProfilePosts(id string, cursor string) (*[]model.Post, error) {
-	ret := _m.Called(id, cursor)
+// ProfilePosts provides a mock function with given fields: id, cursor, r
+func (_m *PostService) ProfilePosts(id string, cursor string, r model.TimeRange) (*[]model.Post, string, error) {
+	ret := _m.Called(id, cursor, r)
 
 	var r0 *[]model.Post
-	if rf, ok := ret.Get(0).(func(string, string) *[]model.Post); ok {
-		r0 = rf(id, cursor)
+	if rf, ok := ret.Get(0).(func(string, string, model.TimeRange) *[]model.Post); ok {
+		r0 = rf(id, cursor, r)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*[]model.Post)
 		}
 	}
 
+	var r1 string
+	if rf, ok := ret.Get(1).(func(string, string, model.TimeRange) string); ok {
+		r1 = rf(id, cursor, r)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(string, string, model.TimeRange) error); ok {
+		r2 = rf(id, cursor, r)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// RelatedHashtags provides a mock function with given fields: tag
+func (_m *PostService) RelatedHashtags(tag string) (*[]model.RelatedHashtag, error) {
+	ret := _m.Called(tag)
+
+	var r0 *[]model.RelatedHashtag
+	if rf, ok := ret.Get(0).(func(string) *[]model.RelatedHashtag); ok {
+		r0 = rf(tag)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*[]model.RelatedHashtag)
+		}
+	}
+
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string, string) error); ok {
-		r1 = rf(id, cursor)
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(tag)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -166,27 +257,34 @@ func (_m *PostService) ProfilePosts(id string, cursor string) (*[]model.Post, er
 	return r0, r1
 }
 
-// SearchPosts provides a mock function with given fields: tag, cursor
-func (_m *PostService) SearchPosts(tag string, cursor string) (*[]model.Post, error) {
-	ret := _m.Called(tag, cursor)
+// SearchPosts provides a mock function with given fields: search, cursor
+func (_m *PostService) SearchPosts(search string, cursor string) (*[]model.Post, int64, error) {
+	ret := _m.Called(search, cursor)
 
 	var r0 *[]model.Post
 	if rf, ok := ret.Get(0).(func(string, string) *[]model.Post); ok {
-		r0 = rf(tag, cursor)
+		r0 = rf(search, cursor)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*[]model.Post)
 		}
 	}
 
-	var r1 error
-	if rf, ok := ret.Get(1).(func(string, string) error); ok {
-		r1 = rf(tag, cursor)
+	var r1 int64
+	if rf, ok := ret.Get(1).(func(string, string) int64); ok {
+		r1 = rf(search, cursor)
 	} else {
-		r1 = ret.Error(1)
+		r1 = ret.Get(1).(int64)
 	}
 
-	return r0, r1
+	var r2 error
+	if rf, ok := ret.Get(2).(func(string, string) error); ok {
+		r2 = rf(search, cursor)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
 }
 
 // ToggleLike provides a mock function with given fields: post, uid
@@ -217,6 +315,20 @@ func (_m *PostService) ToggleRetweet(post *model.Post, uid string) error {
 	return r0
 }
 
+// UpdateAltText provides a mock function with given fields: post, altText
+func (_m *PostService) UpdateAltText(post *model.Post, altText string) error {
+	ret := _m.Called(post, altText)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*model.Post, string) error); ok {
+		r0 = rf(post, altText)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // UploadFile provides a mock function with given fields: header
 func (_m *PostService) UploadFile(header *multipart.FileHeader) (*model.File, error) {
 	ret := _m.Called(header)
@@ -5,6 +5,8 @@ package mocks
 import (
 	model "github.com/sentrionic/mirage/model"
 	mock "github.com/stretchr/testify/mock"
+
+	time "time"
 )
 
 // UserRepository is an autogenerated mock type for the UserRepository type
@@ -26,6 +28,71 @@ func (_m *UserRepository) AddFollow(userId string, currentId string) error {
 	return r0
 }
 
+// BlockedIDs provides a mock function with given fields: userId
+func (_m *UserRepository) BlockedIDs(userId string) ([]string, error) {
+	ret := _m.Called(userId)
+
+	var r0 []string
+	if rf, ok := ret.Get(0).(func(string) []string); ok {
+		r0 = rf(userId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(userId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ConsumeRecoveryCode provides a mock function with given fields: userId, hash
+func (_m *UserRepository) ConsumeRecoveryCode(userId string, hash string) (bool, error) {
+	ret := _m.Called(userId, hash)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(string, string) bool); ok {
+		r0 = rf(userId, hash)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(userId, hash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ConsumeTwoFactorStep provides a mock function with given fields: userId, step
+func (_m *UserRepository) ConsumeTwoFactorStep(userId string, step int64) (bool, error) {
+	ret := _m.Called(userId, step)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(string, int64) bool); ok {
+		r0 = rf(userId, step)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, int64) error); ok {
+		r1 = rf(userId, step)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Create provides a mock function with given fields: user
 func (_m *UserRepository) Create(user *model.User) (*model.User, error) {
 	ret := _m.Called(user)
@@ -49,6 +116,29 @@ func (_m *UserRepository) Create(user *model.User) (*model.User, error) {
 	return r0, r1
 }
 
+// FindAfter provides a mock function with given fields: id, limit
+func (_m *UserRepository) FindAfter(id string, limit int) (*[]model.User, error) {
+	ret := _m.Called(id, limit)
+
+	var r0 *[]model.User
+	if rf, ok := ret.Get(0).(func(string, int) *[]model.User); ok {
+		r0 = rf(id, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*[]model.User)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, int) error); ok {
+		r1 = rf(id, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // FindByEmail provides a mock function with given fields: email
 func (_m *UserRepository) FindByEmail(email string) (*model.User, error) {
 	ret := _m.Called(email)
@@ -95,6 +185,29 @@ func (_m *UserRepository) FindByID(uid string) (*model.User, error) {
 	return r0, r1
 }
 
+// FindByIDs provides a mock function with given fields: ids
+func (_m *UserRepository) FindByIDs(ids []string) (*[]model.User, error) {
+	ret := _m.Called(ids)
+
+	var r0 *[]model.User
+	if rf, ok := ret.Get(0).(func([]string) *[]model.User); ok {
+		r0 = rf(ids)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*[]model.User)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func([]string) error); ok {
+		r1 = rf(ids)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // FindByUsername provides a mock function with given fields: username
 func (_m *UserRepository) FindByUsername(username string) (*model.User, error) {
 	ret := _m.Called(username)
@@ -118,6 +231,179 @@ func (_m *UserRepository) FindByUsername(username string) (*model.User, error) {
 	return r0, r1
 }
 
+// FolloweeIDs provides a mock function with given fields: userId
+func (_m *UserRepository) FolloweeIDs(userId string) ([]string, error) {
+	ret := _m.Called(userId)
+
+	var r0 []string
+	if rf, ok := ret.Get(0).(func(string) []string); ok {
+		r0 = rf(userId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(userId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FollowerCount provides a mock function with given fields: userId
+func (_m *UserRepository) FollowerCount(userId string) (int64, error) {
+	ret := _m.Called(userId)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(string) int64); ok {
+		r0 = rf(userId)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(userId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FollowerEdges provides a mock function with given fields: userId, before, limit
+func (_m *UserRepository) FollowerEdges(userId string, before *model.Follow, limit int) ([]model.Follow, error) {
+	ret := _m.Called(userId, before, limit)
+
+	var r0 []model.Follow
+	if rf, ok := ret.Get(0).(func(string, *model.Follow, int) []model.Follow); ok {
+		r0 = rf(userId, before, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Follow)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, *model.Follow, int) error); ok {
+		r1 = rf(userId, before, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FollowerIDs provides a mock function with given fields: userId
+func (_m *UserRepository) FollowerIDs(userId string) ([]string, error) {
+	ret := _m.Called(userId)
+
+	var r0 []string
+	if rf, ok := ret.Get(0).(func(string) []string); ok {
+		r0 = rf(userId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(userId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FollowingEdges provides a mock function with given fields: userId, before, limit
+func (_m *UserRepository) FollowingEdges(userId string, before *model.Follow, limit int) ([]model.Follow, error) {
+	ret := _m.Called(userId, before, limit)
+
+	var r0 []model.Follow
+	if rf, ok := ret.Get(0).(func(string, *model.Follow, int) []model.Follow); ok {
+		r0 = rf(userId, before, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Follow)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, *model.Follow, int) error); ok {
+		r1 = rf(userId, before, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MutualFollowerEdges provides a mock function with given fields: userId, viewerId, before, limit
+func (_m *UserRepository) MutualFollowerEdges(userId string, viewerId string, before *model.Follow, limit int) ([]model.Follow, error) {
+	ret := _m.Called(userId, viewerId, before, limit)
+
+	var r0 []model.Follow
+	if rf, ok := ret.Get(0).(func(string, string, *model.Follow, int) []model.Follow); ok {
+		r0 = rf(userId, viewerId, before, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Follow)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string, *model.Follow, int) error); ok {
+		r1 = rf(userId, viewerId, before, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PopularUsers provides a mock function with given fields: minFollowers
+func (_m *UserRepository) PopularUsers(minFollowers int) ([]string, error) {
+	ret := _m.Called(minFollowers)
+
+	var r0 []string
+	if rf, ok := ret.Get(0).(func(int) []string); ok {
+		r0 = rf(minFollowers)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(int) error); ok {
+		r1 = rf(minFollowers)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RecountFollowers provides a mock function with given fields:
+func (_m *UserRepository) RecountFollowers() error {
+	ret := _m.Called()
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // RemoveFollow provides a mock function with given fields: userId, currentId
 func (_m *UserRepository) RemoveFollow(userId string, currentId string) error {
 	ret := _m.Called(userId, currentId)
@@ -132,13 +418,82 @@ func (_m *UserRepository) RemoveFollow(userId string, currentId string) error {
 	return r0
 }
 
-// SearchProfiles provides a mock function with given fields: term
-func (_m *UserRepository) SearchProfiles(term string) (*[]model.User, error) {
-	ret := _m.Called(term)
+// SearchProfiles provides a mock function with given fields: term, after, limit
+func (_m *UserRepository) SearchProfiles(term string, after *model.ProfileRank, limit int) (*[]model.User, []model.ProfileRank, error) {
+	ret := _m.Called(term, after, limit)
+
+	var r0 *[]model.User
+	if rf, ok := ret.Get(0).(func(string, *model.ProfileRank, int) *[]model.User); ok {
+		r0 = rf(term, after, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*[]model.User)
+		}
+	}
+
+	var r1 []model.ProfileRank
+	if rf, ok := ret.Get(1).(func(string, *model.ProfileRank, int) []model.ProfileRank); ok {
+		r1 = rf(term, after, limit)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).([]model.ProfileRank)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(string, *model.ProfileRank, int) error); ok {
+		r2 = rf(term, after, limit)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// SetProfileImage provides a mock function with given fields: userId, kind, url
+func (_m *UserRepository) SetProfileImage(userId string, kind model.ProfileImage, url string) error {
+	ret := _m.Called(userId, kind, url)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, model.ProfileImage, string) error); ok {
+		r0 = rf(userId, kind, url)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SuggestionCandidates provides a mock function with given fields: userId, since, limit
+func (_m *UserRepository) SuggestionCandidates(userId string, since time.Time, limit int) ([]model.SuggestionCandidate, error) {
+	ret := _m.Called(userId, since, limit)
+
+	var r0 []model.SuggestionCandidate
+	if rf, ok := ret.Get(0).(func(string, time.Time, int) []model.SuggestionCandidate); ok {
+		r0 = rf(userId, since, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.SuggestionCandidate)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, time.Time, int) error); ok {
+		r1 = rf(userId, since, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// TypeaheadProfiles provides a mock function with given fields: term, limit
+func (_m *UserRepository) TypeaheadProfiles(term string, limit int) (*[]model.User, error) {
+	ret := _m.Called(term, limit)
 
 	var r0 *[]model.User
-	if rf, ok := ret.Get(0).(func(string) *[]model.User); ok {
-		r0 = rf(term)
+	if rf, ok := ret.Get(0).(func(string, int) *[]model.User); ok {
+		r0 = rf(term, limit)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*[]model.User)
@@ -146,8 +501,8 @@ func (_m *UserRepository) SearchProfiles(term string) (*[]model.User, error) {
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(term)
+	if rf, ok := ret.Get(1).(func(string, int) error); ok {
+		r1 = rf(term, limit)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -168,3 +523,17 @@ func (_m *UserRepository) Update(user *model.User) error {
 
 	return r0
 }
+
+// UpdateSearchText provides a mock function with given fields: user
+func (_m *UserRepository) UpdateSearchText(user *model.User) error {
+	ret := _m.Called(user)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*model.User) error); ok {
+		r0 = rf(user)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
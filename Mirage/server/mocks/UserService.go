@@ -14,46 +14,32 @@ type UserService struct {
 	mock.Mock
 }
 
-// ChangeAvatar provides a mock function with given fields: header, directory
-func (_m *UserService) ChangeAvatar(header *multipart.FileHeader, directory string) (string, error) {
-	ret := _m.Called(header, directory)
+// ChangeAvatar provides a mock function with given fields: user, header, directory
+func (_m *UserService) ChangeAvatar(user *model.User, header *multipart.FileHeader, directory string) error {
+	ret := _m.Called(user, header, directory)
 
-	var r0 string
-	if rf, ok := ret.Get(0).(func(*multipart.FileHeader, string) string); ok {
-		r0 = rf(header, directory)
-	} else {
-		r0 = ret.Get(0).(string)
-	}
-
-	var r1 error
-	if rf, ok := ret.Get(1).(func(*multipart.FileHeader, string) error); ok {
-		r1 = rf(header, directory)
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*model.User, *multipart.FileHeader, string) error); ok {
+		r0 = rf(user, header, directory)
 	} else {
-		r1 = ret.Error(1)
+		r0 = ret.Error(0)
 	}
 
-	return r0, r1
+	return r0
 }
 
-// ChangeBanner provides a mock function with given fields: header, directory
-func (_m *UserService) ChangeBanner(header *multipart.FileHeader, directory string) (string, error) {
-	ret := _m.Called(header, directory)
+// ChangeBanner provides a mock function with given fields: user, header, directory
+func (_m *UserService) ChangeBanner(user *model.User, header *multipart.FileHeader, directory string) error {
+	ret := _m.Called(user, header, directory)
 
-	var r0 string
-	if rf, ok := ret.Get(0).(func(*multipart.FileHeader, string) string); ok {
-		r0 = rf(header, directory)
-	} else {
-		r0 = ret.Get(0).(string)
-	}
-
-	var r1 error
-	if rf, ok := ret.Get(1).(func(*multipart.FileHeader, string) error); ok {
-		r1 = rf(header, directory)
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*model.User, *multipart.FileHeader, string) error); ok {
+		r0 = rf(user, header, directory)
 	} else {
-		r1 = ret.Error(1)
+		r0 = ret.Error(0)
 	}
 
-	return r0, r1
+	return r0
 }
 
 // ChangeFollow provides a mock function with given fields: user, current
@@ -70,6 +56,20 @@ func (_m *UserService) ChangeFollow(user *model.User, current string) error {
 	return r0
 }
 
+// ConfirmTwoFactor provides a mock function with given fields: user, code
+func (_m *UserService) ConfirmTwoFactor(user *model.User, code string) error {
+	ret := _m.Called(user, code)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*model.User, string) error); ok {
+		r0 = rf(user, code)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // DeleteImage provides a mock function with given fields: key
 func (_m *UserService) DeleteImage(key string) error {
 	ret := _m.Called(key)
@@ -84,6 +84,43 @@ func (_m *UserService) DeleteImage(key string) error {
 	return r0
 }
 
+// DisableTwoFactor provides a mock function with given fields: user, password, code
+func (_m *UserService) DisableTwoFactor(user *model.User, password string, code string) error {
+	ret := _m.Called(user, password, code)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*model.User, string, string) error); ok {
+		r0 = rf(user, password, code)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// EnrollTwoFactor provides a mock function with given fields: user, password
+func (_m *UserService) EnrollTwoFactor(user *model.User, password string) (*model.TwoFactorSetup, error) {
+	ret := _m.Called(user, password)
+
+	var r0 *model.TwoFactorSetup
+	if rf, ok := ret.Get(0).(func(*model.User, string) *model.TwoFactorSetup); ok {
+		r0 = rf(user, password)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.TwoFactorSetup)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*model.User, string) error); ok {
+		r1 = rf(user, password)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // FindByUsername provides a mock function with given fields: username
 func (_m *UserService) FindByUsername(username string) (*model.User, error) {
 	ret := _m.Called(username)
@@ -107,6 +144,66 @@ func (_m *UserService) FindByUsername(username string) (*model.User, error) {
 	return r0, r1
 }
 
+// Followers provides a mock function with given fields: userId, cursor
+func (_m *UserService) Followers(userId string, cursor string) (*[]model.User, string, error) {
+	ret := _m.Called(userId, cursor)
+
+	var r0 *[]model.User
+	if rf, ok := ret.Get(0).(func(string, string) *[]model.User); ok {
+		r0 = rf(userId, cursor)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*[]model.User)
+		}
+	}
+
+	var r1 string
+	if rf, ok := ret.Get(1).(func(string, string) string); ok {
+		r1 = rf(userId, cursor)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(string, string) error); ok {
+		r2 = rf(userId, cursor)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// Following provides a mock function with given fields: userId, cursor
+func (_m *UserService) Following(userId string, cursor string) (*[]model.User, string, error) {
+	ret := _m.Called(userId, cursor)
+
+	var r0 *[]model.User
+	if rf, ok := ret.Get(0).(func(string, string) *[]model.User); ok {
+		r0 = rf(userId, cursor)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*[]model.User)
+		}
+	}
+
+	var r1 string
+	if rf, ok := ret.Get(1).(func(string, string) string); ok {
+		r1 = rf(userId, cursor)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(string, string) error); ok {
+		r2 = rf(userId, cursor)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // Get provides a mock function with given fields: uid
 func (_m *UserService) Get(uid string) (*model.User, error) {
 	ret := _m.Called(uid)
@@ -130,13 +227,13 @@ func (_m *UserService) Get(uid string) (*model.User, error) {
 	return r0, r1
 }
 
-// Login provides a mock function with given fields: email, password
-func (_m *UserService) Login(email string, password string) (*model.User, error) {
-	ret := _m.Called(email, password)
+// Login provides a mock function with given fields: email, password, ip
+func (_m *UserService) Login(email string, password string, ip string) (*model.User, error) {
+	ret := _m.Called(email, password, ip)
 
 	var r0 *model.User
-	if rf, ok := ret.Get(0).(func(string, string) *model.User); ok {
-		r0 = rf(email, password)
+	if rf, ok := ret.Get(0).(func(string, string, string) *model.User); ok {
+		r0 = rf(email, password, ip)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*model.User)
@@ -144,8 +241,82 @@ func (_m *UserService) Login(email string, password string) (*model.User, error)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string, string) error); ok {
-		r1 = rf(email, password)
+	if rf, ok := ret.Get(1).(func(string, string, string) error); ok {
+		r1 = rf(email, password, ip)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LoginTwoFactor provides a mock function with given fields: user, code, ip
+func (_m *UserService) LoginTwoFactor(user *model.User, code string, ip string) (bool, error) {
+	ret := _m.Called(user, code, ip)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(*model.User, string, string) bool); ok {
+		r0 = rf(user, code, ip)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*model.User, string, string) error); ok {
+		r1 = rf(user, code, ip)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MutualFollowers provides a mock function with given fields: userId, viewerId, cursor
+func (_m *UserService) MutualFollowers(userId string, viewerId string, cursor string) (*[]model.User, string, error) {
+	ret := _m.Called(userId, viewerId, cursor)
+
+	var r0 *[]model.User
+	if rf, ok := ret.Get(0).(func(string, string, string) *[]model.User); ok {
+		r0 = rf(userId, viewerId, cursor)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*[]model.User)
+		}
+	}
+
+	var r1 string
+	if rf, ok := ret.Get(1).(func(string, string, string) string); ok {
+		r1 = rf(userId, viewerId, cursor)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(string, string, string) error); ok {
+		r2 = rf(userId, viewerId, cursor)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// RegenerateRecoveryCodes provides a mock function with given fields: user, code
+func (_m *UserService) RegenerateRecoveryCodes(user *model.User, code string) ([]string, error) {
+	ret := _m.Called(user, code)
+
+	var r0 []string
+	if rf, ok := ret.Get(0).(func(*model.User, string) []string); ok {
+		r0 = rf(user, code)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*model.User, string) error); ok {
+		r1 = rf(user, code)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -176,8 +347,59 @@ func (_m *UserService) Register(user *model.User) (*model.User, error) {
 	return r0, r1
 }
 
-// Search provides a mock function with given fields: term
-func (_m *UserService) Search(term string) (*[]model.User, error) {
+// ReindexProfiles provides a mock function with given fields:
+func (_m *UserService) ReindexProfiles() (int, error) {
+	ret := _m.Called()
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func() int); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Search provides a mock function with given fields: term, cursor
+func (_m *UserService) Search(term string, cursor string) (*[]model.User, string, error) {
+	ret := _m.Called(term, cursor)
+
+	var r0 *[]model.User
+	if rf, ok := ret.Get(0).(func(string, string) *[]model.User); ok {
+		r0 = rf(term, cursor)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*[]model.User)
+		}
+	}
+
+	var r1 string
+	if rf, ok := ret.Get(1).(func(string, string) string); ok {
+		r1 = rf(term, cursor)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(string, string) error); ok {
+		r2 = rf(term, cursor)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// Typeahead provides a mock function with given fields: term
+func (_m *UserService) Typeahead(term string) (*[]model.User, error) {
 	ret := _m.Called(term)
 
 	var r0 *[]model.User
@@ -212,3 +434,24 @@ func (_m *UserService) Update(user *model.User) error {
 
 	return r0
 }
+
+// VerifyTwoFactor provides a mock function with given fields: user, code
+func (_m *UserService) VerifyTwoFactor(user *model.User, code string) (bool, error) {
+	ret := _m.Called(user, code)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(*model.User, string) bool); ok {
+		r0 = rf(user, code)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*model.User, string) error); ok {
+		r1 = rf(user, code)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
@@ -3,7 +3,9 @@ package apperrors
 import (
 	"errors"
 	"fmt"
+	"math"
 	"net/http"
+	"time"
 )
 
 // Type holds a type string and integer code for the error
@@ -14,10 +16,12 @@ const (
 	Authorization        Type = "AUTHORIZATION"          // Authentication Failures -
 	BadRequest           Type = "BAD_REQUEST"            // Validation errors / BadInput
 	Conflict             Type = "CONFLICT"               // Already exists (eg, create account with existent email) - 409
+	Forbidden            Type = "FORBIDDEN"              // Authenticated, but not allowed to access the resource - 403
 	Internal             Type = "INTERNAL"               // Server (500) and fallback errors
 	NotFound             Type = "NOT_FOUND"              // For not finding resource
 	PayloadTooLarge      Type = "PAYLOAD_TOO_LARGE"      // for uploading tons of JSON, or an image over the limit - 413
 	ServiceUnavailable   Type = "SERVICE_UNAVAILABLE"    // For long running handlers
+	TooManyRequests      Type = "TOO_MANY_REQUESTS"      // Rate limited or locked out - 429
 	UnsupportedMediaType Type = "UNSUPPORTED_MEDIA_TYPE" // for http 415
 )
 
@@ -27,6 +31,9 @@ const (
 type Error struct {
 	Type    Type   `json:"type"`
 	Message string `json:"message"`
+	// RetryAfter is the number of seconds the client
+	// should wait before retrying the request
+	RetryAfter int `json:"retryAfter,omitempty"`
 }
 
 // Error satisfies standard error interface
@@ -47,6 +54,8 @@ func (e *Error) Status() int {
 		return http.StatusBadRequest
 	case Conflict:
 		return http.StatusConflict
+	case Forbidden:
+		return http.StatusForbidden
 	case Internal:
 		return http.StatusInternalServerError
 	case NotFound:
@@ -55,6 +64,8 @@ func (e *Error) Status() int {
 		return http.StatusRequestEntityTooLarge
 	case ServiceUnavailable:
 		return http.StatusServiceUnavailable
+	case TooManyRequests:
+		return http.StatusTooManyRequests
 	case UnsupportedMediaType:
 		return http.StatusUnsupportedMediaType
 	default:
@@ -73,6 +84,16 @@ func Status(err error) int {
 	return http.StatusInternalServerError
 }
 
+// RetryAfter returns the number of seconds the client
+// should wait before retrying or 0 if the error has none
+func RetryAfter(err error) int {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.RetryAfter
+	}
+	return 0
+}
+
 /*
 * Error "Factories"
  */
@@ -101,6 +122,14 @@ func NewConflict(name string) *Error {
 	}
 }
 
+// NewForbidden to create an error for 403
+func NewForbidden(reason string) *Error {
+	return &Error{
+		Type:    Forbidden,
+		Message: reason,
+	}
+}
+
 // NewInternal for 500 errors and unknown errors
 func NewInternal() *Error {
 	return &Error{
@@ -133,6 +162,21 @@ func NewServiceUnavailable() *Error {
 	}
 }
 
+// NewTooManyRequests to create an error for 429.
+// The wait time gets rounded up to full seconds.
+func NewTooManyRequests(retryAfter time.Duration) *Error {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+
+	return &Error{
+		Type:       TooManyRequests,
+		Message:    fmt.Sprintf("Too many requests, please try again in %v seconds", seconds),
+		RetryAfter: seconds,
+	}
+}
+
 // NewUnsupportedMediaType to create an error for 415
 func NewUnsupportedMediaType(reason string) *Error {
 	return &Error{
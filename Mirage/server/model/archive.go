@@ -0,0 +1,35 @@
+package model
+
+import "time"
+
+// TimeRange limits a listing to what happened at or after Since and
+// before Until. A zero bound leaves that side of the range open.
+type TimeRange struct {
+	Since time.Time
+	Until time.Time
+}
+
+// IsZero reports whether the range has no bounds
+func (r TimeRange) IsZero() bool {
+	return r.Since.IsZero() && r.Until.IsZero()
+}
+
+// Contains reports whether the time lies within the range
+func (r TimeRange) Contains(t time.Time) bool {
+	return (r.Since.IsZero() || !t.Before(r.Since)) && (r.Until.IsZero() || t.Before(r.Until))
+}
+
+// CalendarUnit is the size of the periods of a calendar
+type CalendarUnit string
+
+const (
+	CalendarMonth CalendarUnit = "month"
+	CalendarDay   CalendarUnit = "day"
+)
+
+// CalendarBucket is the number of posts of a user in one period. Period is
+// formatted as "2006-01" for months and "2006-01-02" for days, in UTC.
+type CalendarBucket struct {
+	Period string `json:"period"`
+	Count  int64  `json:"count"`
+}
@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+// Block is an edge of the block graph. The blocker blocked the blocked user,
+// so neither of them gets the other one suggested.
+type Block struct {
+	BlockerID string    `gorm:"primaryKey"`
+	BlockedID string    `gorm:"primaryKey;index"`
+	Blocker   User      `gorm:"constraint:OnDelete:CASCADE;" json:"-"`
+	Blocked   User      `gorm:"constraint:OnDelete:CASCADE;" json:"-"`
+	CreatedAt time.Time `gorm:"default:now()"`
+}
@@ -0,0 +1,56 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// Card is the preview of the first link in a post built
+// from the page's OpenGraph and Twitter card metadata
+type Card struct {
+	URL         string  `json:"url"`
+	Type        string  `json:"type"`
+	Title       string  `json:"title"`
+	Description string  `json:"description"`
+	SiteName    string  `json:"siteName"`
+	Image       *string `json:"image"`
+}
+
+// Value implements the driver.Valuer interface
+func (c Card) Value() (driver.Value, error) {
+	data, err := json.Marshal(c)
+	return string(data), err
+}
+
+// Scan implements the sql.Scanner interface
+func (c *Card) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(v, c)
+	case string:
+		return json.Unmarshal([]byte(v), c)
+	default:
+		return errors.New("unsupported type for card")
+	}
+}
+
+// HTTPFetcher sends HTTP requests. *http.Client satisfies it.
+type HTTPFetcher interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+type LinkService interface {
+	Unfurl(url string) (*Card, error)
+}
+
+// LinkRepository caches the cards of unfurled links. A nil card
+// without an error marks a link that could not be unfurled.
+type LinkRepository interface {
+	Get(url string) (*Card, error)
+	Set(url string, card *Card, ttl time.Duration) error
+}
@@ -1,3 +1,9 @@
 package model
 
+import "time"
+
 const LIMIT = 20
+
+// SessionMaxAge is the lifetime of a login session.
+// It is used for both the cookie and the stored session record.
+const SessionMaxAge = 7 * 24 * time.Hour
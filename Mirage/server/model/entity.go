@@ -0,0 +1,58 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+)
+
+// EntityType is the kind of a post entity
+type EntityType string
+
+const (
+	EntityHashtag EntityType = "hashtag"
+	EntityMention EntityType = "mention"
+	EntityCashtag EntityType = "cashtag"
+	EntityURL     EntityType = "url"
+)
+
+// Entity is a hashtag, mention, cashtag or URL inside a post's text.
+// Start and End are character (code point) offsets, End is exclusive.
+// Text is the entity as written, Value its normalized form:
+// lowercase hashtags and mentions without the sign, uppercase cashtags
+// without the sign and URLs with lowercase scheme and host.
+type Entity struct {
+	Type  EntityType `json:"type"`
+	Text  string     `json:"text"`
+	Value string     `json:"value"`
+	Start int        `json:"start"`
+	End   int        `json:"end"`
+}
+
+// Entities are stored as jsonb
+type Entities []Entity
+
+// Value implements the driver.Valuer interface
+func (e Entities) Value() (driver.Value, error) {
+	if e == nil {
+		return "[]", nil
+	}
+
+	data, err := json.Marshal(e)
+	return string(data), err
+}
+
+// Scan implements the sql.Scanner interface
+func (e *Entities) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*e = Entities{}
+		return nil
+	case []byte:
+		return json.Unmarshal(v, e)
+	case string:
+		return json.Unmarshal([]byte(v), e)
+	default:
+		return errors.New("unsupported type for entities")
+	}
+}
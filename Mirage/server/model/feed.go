@@ -0,0 +1,30 @@
+package model
+
+import "time"
+
+// FeedMode selects how the home timeline is ordered
+type FeedMode string
+
+const (
+	// FeedLatest orders the timeline by post or retweet time
+	FeedLatest FeedMode = "latest"
+	// FeedTop orders recent posts by their score
+	FeedTop FeedMode = "top"
+)
+
+// AuthorAffinity describes how close a user is to an author
+type AuthorAffinity struct {
+	AuthorID string
+	// Interactions counts the author's posts the user liked or retweeted
+	Interactions int64
+	// Following is set if the user follows the author
+	Following bool
+}
+
+// FeedSnapshotRepository stores the scores of a user's top feed at the
+// time of its first page, so that the following pages keep that ranking
+type FeedSnapshotRepository interface {
+	// Get returns nil if the snapshot expired
+	Get(userId string, at time.Time) (map[string]float64, error)
+	Save(userId string, at time.Time, scores map[string]float64, ttl time.Duration) error
+}
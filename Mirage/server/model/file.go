@@ -1,22 +1,122 @@
 package model
 
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"github.com/lib/pq"
+	"io"
 	"mime/multipart"
 	"time"
 )
 
 type File struct {
-	ID        string    `gorm:"primaryKey" json:"-"`
-	PostId    string    `gorm:"not null;constraint:OnDelete:CASCADE;" json:"-"`
-	Url       string    `json:"url"`
-	FileType  string    `json:"filetype"`
-	Filename  string    `json:"filename"`
-	CreatedAt time.Time `json:"-"`
+	ID       string `gorm:"primaryKey" json:"-"`
+	PostId   string `gorm:"not null;constraint:OnDelete:CASCADE;" json:"-"`
+	Url      string `json:"url"`
+	FileType string `json:"filetype"`
+	Filename string `json:"filename"`
+	// Hash is the SHA-256 of the stored image. Files with the same hash share their objects
+	Hash      string        `gorm:"index" json:"-"`
+	AltText   string        `json:"altText"`
+	Width     int           `json:"width"`
+	Height    int           `json:"height"`
+	Blurhash  string        `json:"blurhash"`
+	Variants  ImageVariants `gorm:"type:jsonb" json:"variants"`
+	CreatedAt time.Time     `json:"-"`
+	// VariantsPending is set when the variants still have to be created
+	VariantsPending bool `gorm:"-" json:"-"`
+	// JobID is the job that creates the variants
+	JobID string `gorm:"-" json:"jobId,omitempty"`
+}
+
+// ObjectNames returns the distinct filenames of the file and its variants
+func (f *File) ObjectNames() []string {
+	names := []string{f.Filename}
+
+	for _, variant := range f.Variants {
+		// variants of small images use the original
+		if variant.Filename == "" || contains(names, variant.Filename) {
+			continue
+		}
+		names = append(names, variant.Filename)
+	}
+
+	return names
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// OrphanedMedia are the objects of a content hash whose last file got deleted.
+// They are kept for a grace period, as a new upload of the same image might
+// already reuse them, and are removed by the media cleanup afterwards.
+type OrphanedMedia struct {
+	Hash      string         `gorm:"primaryKey"`
+	Filenames pq.StringArray `gorm:"type:text[]"`
+	CreatedAt time.Time      `gorm:"index"`
+}
+
+// ObjectDeleter removes the objects with the given filenames from the storage
+type ObjectDeleter func(filenames []string) error
+
+// ImageVariant is a resized version of an uploaded image
+type ImageVariant struct {
+	Url      string `json:"url"`
+	FileType string `json:"filetype"`
+	Filename string `json:"filename"`
+	Width    int    `json:"width"`
+	Height   int    `json:"height"`
+}
+
+// ImageVariants are keyed by their name: "thumb", "small", "large" and "original".
+// They are stored as jsonb
+type ImageVariants map[string]ImageVariant
+
+// Value implements the driver.Valuer interface
+func (v ImageVariants) Value() (driver.Value, error) {
+	if v == nil {
+		return "{}", nil
+	}
+
+	data, err := json.Marshal(v)
+	return string(data), err
+}
+
+// Scan implements the sql.Scanner interface
+func (v *ImageVariants) Scan(value interface{}) error {
+	switch data := value.(type) {
+	case nil:
+		*v = ImageVariants{}
+		return nil
+	case []byte:
+		return json.Unmarshal(data, v)
+	case string:
+		return json.Unmarshal([]byte(data), v)
+	default:
+		return errors.New("unsupported type for image variants")
+	}
 }
 
 type FileRepository interface {
 	UploadAvatar(header *multipart.FileHeader, directory string) (string, error)
 	UploadBanner(header *multipart.FileHeader, directory string) (string, error)
-	UploadFile(header *multipart.FileHeader, directory, filename, mimetype string) (string, error)
+	UploadFile(body io.Reader, directory, filename, mimetype string) (string, error)
+	UploadResizedImage(body io.Reader, directory, filename string, width int) (string, error)
+	FileURL(directory, filename string) (string, error)
+	PresignUpload(key, mimetype string, size int64, expires time.Duration) (*UploadTarget, error)
+	StatFile(key string) (*StoredObject, error)
+	OpenFile(key string) (io.ReadCloser, error)
+	CopyFile(key, directory, filename string) (string, error)
+	CreateMultipartUpload(key, mimetype string) (string, error)
+	UploadPart(key, multipartId string, number int64, body io.ReadSeeker) (string, error)
+	CompleteMultipartUpload(key, multipartId string, parts []UploadPart) error
+	AbortMultipartUpload(key, multipartId string) error
 	DeleteImage(key string) error
 }
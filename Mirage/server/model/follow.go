@@ -0,0 +1,10 @@
+package model
+
+import "time"
+
+// Follow is an edge of the follow graph. The follower follows the followee.
+type Follow struct {
+	FollowerID string    `gorm:"primaryKey;constraint:OnDelete:CASCADE;"`
+	FolloweeID string    `gorm:"primaryKey;index;constraint:OnDelete:CASCADE;"`
+	CreatedAt  time.Time `gorm:"index;default:now()"`
+}
@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// HashtagQuery searches posts with all or, if MatchAll is false,
+// any of the tags. The tags are normalized and start with #.
+// If Since is set, only posts created after it are counted.
+type HashtagQuery struct {
+	Tags     []string
+	MatchAll bool
+	Since    time.Time
+}
+
+// RelatedHashtag is a hashtag used together with another one.
+// Count is the number of posts that have both.
+type RelatedHashtag struct {
+	Hashtag string `json:"hashtag"`
+	Count   int64  `json:"count"`
+}
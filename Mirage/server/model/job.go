@@ -0,0 +1,71 @@
+package model
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+type JobStatus string
+
+const (
+	JobQueued    JobStatus = "queued"
+	JobRunning   JobStatus = "running"
+	JobRetrying  JobStatus = "retrying"
+	JobSucceeded JobStatus = "succeeded"
+	// JobDead jobs failed too often and got moved to the dead letter queue
+	JobDead JobStatus = "dead"
+)
+
+// Job types
+const (
+	// JobMediaVariants creates the resized variants of a post image
+	JobMediaVariants = "media.variants"
+	// JobProfileImage resizes an uploaded avatar or banner
+	JobProfileImage = "user.profile_image"
+	// JobTimelineFanout adds a post or retweet to the followers' timelines
+	JobTimelineFanout = "timeline.fanout"
+	// JobTimelineRetract removes a post or retweet from the followers' timelines
+	JobTimelineRetract = "timeline.retract"
+	// JobLinkCard unfurls the first link of a post and attaches its card
+	JobLinkCard = "post.link_card"
+)
+
+// Job is a unit of background work
+type Job struct {
+	ID          string          `json:"id"`
+	Type        string          `json:"type"`
+	UserID      string          `json:"-"`
+	Payload     json.RawMessage `json:"-"`
+	Status      JobStatus       `json:"status"`
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"maxAttempts"`
+	LastError   string          `json:"error,omitempty"`
+	RunAt       time.Time       `json:"runAt"`
+	CreatedAt   time.Time       `json:"createdAt"`
+	UpdatedAt   time.Time       `json:"updatedAt"`
+}
+
+// JobHandler processes the payload of a job
+type JobHandler func(payload json.RawMessage) error
+
+type JobService interface {
+	Enqueue(jobType, userId string, payload interface{}) (*Job, error)
+	Handle(jobType string, handler JobHandler)
+	GetJob(userId, id string) (*Job, error)
+	ProcessNext(timeout time.Duration) (bool, error)
+	Work(ctx context.Context, workers int)
+	RetryDead() (int, error)
+}
+
+type JobQueue interface {
+	Save(job *Job) error
+	Get(id string) (*Job, error)
+	Push(job *Job) error
+	Schedule(job *Job) error
+	Pop(timeout, visibility time.Duration) (*Job, error)
+	Extend(id string, visibility time.Duration) error
+	Ack(id string) error
+	DeadLetter(job *Job) error
+	PopDead() (*Job, error)
+}
@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+type Like struct {
+	UserID    string    `gorm:"primaryKey;constraint:OnDelete:CASCADE;"`
+	PostId    string    `gorm:"primaryKey;constraint:OnDelete:CASCADE;"`
+	CreatedAt time.Time `gorm:"index;default:now()"`
+}
+
+func (Like) TableName() string {
+	return "post_likes"
+}
@@ -7,16 +7,26 @@ import (
 )
 
 type PostResponse struct {
-	ID        string    `json:"id"`
-	Text      *string   `json:"text"`
-	Likes     uint      `json:"likes"`
-	Liked     bool      `json:"liked"`
-	Retweets  uint      `json:"retweets"`
-	Retweeted bool      `json:"retweeted"`
-	IsRetweet bool      `json:"isRetweet"`
-	File      *File     `json:"file"`
-	Author    Profile   `json:"author"`
-	CreatedAt time.Time `json:"createdAt"`
+	ID          string       `json:"id"`
+	Text        *string      `json:"text"`
+	Likes       uint         `json:"likes"`
+	Liked       bool         `json:"liked"`
+	Retweets    uint         `json:"retweets"`
+	Retweeted   bool         `json:"retweeted"`
+	IsRetweet   bool         `json:"isRetweet"`
+	RetweetedBy *RetweetedBy `json:"retweetedBy,omitempty"`
+	Entities    Entities     `json:"entities"`
+	Card        *Card        `json:"card"`
+	File        *File        `json:"file"`
+	Author      Profile      `json:"author"`
+	CreatedAt   time.Time    `json:"createdAt"`
+}
+
+// RetweetedBy is the latest user that retweeted a timeline
+// post and how many others did, as in "X and 2 others"
+type RetweetedBy struct {
+	User   Profile `json:"user"`
+	Others int     `json:"others"`
 }
 
 func (post *Post) NewPostResponse(id string) PostResponse {
@@ -27,6 +37,8 @@ func (post *Post) NewPostResponse(id string) PostResponse {
 		Liked:     post.IsLiked(id),
 		Retweets:  uint(len(post.Retweets)),
 		Retweeted: post.IsRetweeted(id),
+		Entities:  post.GetEntities(),
+		Card:      post.Card,
 		File:      post.File,
 		Author:    post.User.NewProfileResponse(id),
 		CreatedAt: post.CreatedAt,
@@ -35,17 +47,59 @@ func (post *Post) NewPostResponse(id string) PostResponse {
 
 func (post *Post) NewFeedResponse(id string) PostResponse {
 	return PostResponse{
-		ID:        post.ID,
-		Text:      post.Text,
-		Likes:     uint(len(post.Likes)),
-		Liked:     post.IsLiked(id),
-		Retweets:  uint(len(post.Retweets)),
-		Retweeted: post.IsRetweeted(id),
-		IsRetweet: post.UserID != id && !post.User.IsFollowing(id),
-		File:      post.File,
-		Author:    post.User.NewProfileResponse(id),
-		CreatedAt: post.CreatedAt,
+		ID:          post.ID,
+		Text:        post.Text,
+		Likes:       uint(len(post.Likes)),
+		Liked:       post.IsLiked(id),
+		Retweets:    uint(len(post.Retweets)),
+		Retweeted:   post.IsRetweeted(id),
+		IsRetweet:   len(post.RetweetedBy) > 0,
+		RetweetedBy: post.NewRetweetedByResponse(id),
+		Entities:    post.GetEntities(),
+		Card:        post.Card,
+		File:        post.File,
+		Author:      post.User.NewProfileResponse(id),
+		CreatedAt:   post.CreatedAt,
+	}
+}
+
+// NewRetweetedByResponse returns who retweeted the post into the
+// timeline or nil if it is in the timeline as a post
+func (post *Post) NewRetweetedByResponse(id string) *RetweetedBy {
+	if len(post.RetweetedBy) == 0 {
+		return nil
+	}
+
+	return &RetweetedBy{
+		User:   post.RetweetedBy[0].NewProfileResponse(id),
+		Others: len(post.RetweetedBy) - 1,
+	}
+}
+
+// NewTimelineItemResponse returns the feed response of the post with the action
+// that put it into the timeline. Posts without one count as authored.
+func (post *Post) NewTimelineItemResponse(id string) TimelineItemResponse {
+	activity := post.Activity
+
+	if activity == nil {
+		activity = &TimelineActivity{Reason: ReasonAuthored, Actor: post.User, At: post.CreatedAt}
+	}
+
+	return TimelineItemResponse{
+		PostResponse: post.NewFeedResponse(id),
+		Reason:       activity.Reason,
+		Actor:        activity.Actor.NewProfileResponse(id),
+		ActedAt:      activity.At,
+	}
+}
+
+// GetEntities returns the post's entities or an
+// empty list for posts created before entities were stored
+func (post *Post) GetEntities() Entities {
+	if post.Entities == nil {
+		return Entities{}
 	}
+	return post.Entities
 }
 
 func (post *Post) IsLiked(id string) bool {
@@ -75,15 +129,19 @@ func (post *Post) IsRetweeted(id string) bool {
 }
 
 type Post struct {
-	ID        string `gorm:"primaryKey"`
-	Text      *string
-	File      *File          `gorm:"constraint:OnDelete:CASCADE;"`
-	HashTags  pq.StringArray `gorm:"type:text[]"`
-	UserID    string         `gorm:"not null;constraint:OnDelete:CASCADE;"`
-	User      User           `gorm:"not null;constraint:OnDelete:CASCADE;"`
-	Likes     []User         `gorm:"many2many:post_likes;constraint:OnDelete:CASCADE;"`
-	Retweets  []User         `gorm:"many2many:retweets;constraint:OnDelete:CASCADE;"`
-	CreatedAt time.Time      `gorm:"index"`
+	ID          string `gorm:"primaryKey"`
+	Text        *string
+	File        *File             `gorm:"constraint:OnDelete:CASCADE;"`
+	HashTags    pq.StringArray    `gorm:"type:text[];index:idx_posts_hash_tags,type:gin"`
+	Entities    Entities          `gorm:"type:jsonb"`
+	Card        *Card             `gorm:"type:jsonb"`
+	UserID      string            `gorm:"not null;constraint:OnDelete:CASCADE;"`
+	User        User              `gorm:"not null;constraint:OnDelete:CASCADE;"`
+	Likes       []User            `gorm:"many2many:post_likes;constraint:OnDelete:CASCADE;"`
+	Retweets    []User            `gorm:"many2many:retweets;constraint:OnDelete:CASCADE;"`
+	CreatedAt   time.Time         `gorm:"index"`
+	RetweetedBy []User            `gorm:"-"`
+	Activity    *TimelineActivity `gorm:"-"`
 }
 
 type PostService interface {
@@ -91,26 +149,47 @@ type PostService interface {
 	CreatePost(post *Post) (*Post, error)
 	DeletePost(post *Post) error
 	UploadFile(header *multipart.FileHeader) (*File, error)
+	UpdateAltText(post *Post, altText string) error
 	ToggleLike(post *Post, uid string) error
 	ToggleRetweet(post *Post, uid string) error
-	GetUserFeed(userId, cursor string) (*[]Post, error)
-	ProfilePosts(id, cursor string) (*[]Post, error)
-	ProfileLikes(id, cursor string) (*[]Post, error)
-	ProfileMedia(id, cursor string) (*[]Post, error)
-	SearchPosts(tag, cursor string) (*[]Post, error)
+	GetUserFeed(userId string, mode FeedMode, cursor string, r TimeRange) (*[]Post, string, error)
+	ProfilePosts(id, cursor string, r TimeRange) (*[]Post, string, error)
+	ProfileLikes(id, cursor string, r TimeRange) (*[]Post, string, error)
+	ProfileMedia(id, cursor string, r TimeRange) (*[]Post, error)
+	Calendar(id string, year int) ([]CalendarBucket, error)
+	OnThisDay(id string, date time.Time, cursor string) (*[]Post, error)
+	SearchPosts(search, cursor string) (*[]Post, int64, error)
+	RelatedHashtags(tag string) (*[]RelatedHashtag, error)
 }
 
 type PostRepository interface {
 	FindByID(id string) (*Post, error)
+	FindByIDs(ids []string) (*[]Post, error)
+	ActivityEntries(actorIds []string, before *TimelineEntry, limit int) ([]TimelineEntry, error)
 	Create(post *Post) (*Post, error)
 	Delete(post *Post) error
+	UpdateFile(file *File) error
+	SetCard(id string, card *Card) error
+	FindFileByHash(hash string) (*File, error)
+	SetFileVariants(hash string, variants ImageVariants) error
+	ClaimFileHash(hash string) error
+	OrphanedMedia(before time.Time) (*[]OrphanedMedia, error)
+	DeleteOrphanedMedia(hash string, deleteObjects ObjectDeleter) (bool, error)
 	AddLike(post *Post, uid string) error
 	RemoveLike(post *Post, uid string) error
 	AddRetweet(post *Post, uid string) error
 	RemoveRetweet(post *Post, uid string) error
-	Feed(userId, cursor string) (*[]Post, error)
-	List(id, cursor string) (*[]Post, error)
-	Likes(id, cursor string) (*[]Post, error)
-	GetPostsForHashtag(tag, cursor string) (*[]Post, error)
-	Media(id, cursor string) (*[]Post, error)
+	FeedEntries(userId string, before *TimelineEntry, limit int) ([]TimelineEntry, error)
+	FeedActivity(userId string, postIds []string) ([]TimelineEntry, error)
+	FeedCandidates(userId string, since time.Time, limit int) (*[]Post, error)
+	AuthorAffinity(userId string, authorIds []string) (map[string]AuthorAffinity, error)
+	LikeEntries(userId string, before *TimelineEntry, limit int) ([]TimelineEntry, error)
+	GetPostsForHashtags(query HashtagQuery, cursor string) (*[]Post, error)
+	CountHashtagPosts(query HashtagQuery) (int64, error)
+	CountHashtagPostsBatch(queries []HashtagQuery) ([]int64, error)
+	RelatedHashtags(tag string, since time.Time, limit int) (*[]RelatedHashtag, error)
+	Media(id, cursor string, since time.Time) (*[]Post, error)
+	HashtagsSince(since time.Time) (*[]Post, error)
+	PostCalendar(userId string, unit CalendarUnit, from, to time.Time) ([]CalendarBucket, error)
+	OnThisDay(userId string, month time.Month, days []int, before time.Time, limit int) (*[]Post, error)
 }
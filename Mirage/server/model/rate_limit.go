@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// RateLimiter counts hits per key in fixed windows.
+// It backs the rate limit middleware and the login lockout.
+type RateLimiter interface {
+	// Hit counts a hit for the key and returns the number of hits
+	// in the current window and the time until the window ends
+	Hit(key string, window time.Duration) (int64, time.Duration, error)
+	// Lock blocks the key for the given duration
+	Lock(key string, d time.Duration) error
+	// LockedFor returns how long the key is still blocked
+	LockedFor(key string) (time.Duration, error)
+	// Reset removes the hits and lock of the key
+	Reset(key string) error
+}
@@ -0,0 +1,89 @@
+package model
+
+import "time"
+
+// SearchKind is what a search looks for
+type SearchKind string
+
+const (
+	SearchPosts    SearchKind = "posts"
+	SearchProfiles SearchKind = "profiles"
+)
+
+// ValidSearchKinds contains every kind a search can be saved with
+var ValidSearchKinds = []interface{}{SearchPosts, SearchProfiles}
+
+// MaxSavedSearches is the number of searches a user can save
+const MaxSavedSearches = 25
+
+// MaxSearchHistory is the number of recent searches kept for every user
+const MaxSearchHistory = 20
+
+// SavedSearch is a named search query of a user. LastVisitedAt
+// is used to count the posts that are new since the last visit.
+type SavedSearch struct {
+	ID            string     `gorm:"primaryKey"`
+	UserID        string     `gorm:"not null;index;constraint:OnDelete:CASCADE;"`
+	User          User       `gorm:"constraint:OnDelete:CASCADE;" json:"-"`
+	Name          string     `gorm:"not null"`
+	Query         string     `gorm:"not null"`
+	Kind          SearchKind `gorm:"not null"`
+	LastVisitedAt time.Time  `gorm:"not null"`
+	CreatedAt     time.Time
+	NewResults    *int64 `gorm:"-"`
+}
+
+type SavedSearchResponse struct {
+	ID            string     `json:"id"`
+	Name          string     `json:"name"`
+	Query         string     `json:"query"`
+	Kind          SearchKind `json:"kind"`
+	NewResults    *int64     `json:"newResults,omitempty"`
+	LastVisitedAt time.Time  `json:"lastVisitedAt"`
+	CreatedAt     time.Time  `json:"createdAt"`
+}
+
+func (search *SavedSearch) NewSavedSearchResponse() SavedSearchResponse {
+	return SavedSearchResponse{
+		ID:            search.ID,
+		Name:          search.Name,
+		Query:         search.Query,
+		Kind:          search.Kind,
+		NewResults:    search.NewResults,
+		LastVisitedAt: search.LastVisitedAt,
+		CreatedAt:     search.CreatedAt,
+	}
+}
+
+// SearchHistoryEntry is a recent search of a user
+type SearchHistoryEntry struct {
+	Query      string     `json:"query"`
+	Kind       SearchKind `json:"kind"`
+	SearchedAt time.Time  `json:"searchedAt"`
+}
+
+type SearchService interface {
+	SavedSearches(uid string, withCounts bool) (*[]SavedSearch, error)
+	SaveSearch(uid, name, query string, kind SearchKind) (*SavedSearch, error)
+	RenameSavedSearch(uid, id, name string) (*SavedSearch, error)
+	VisitSavedSearch(uid, id string) (*SavedSearch, error)
+	DeleteSavedSearch(uid, id string) error
+	RecordSearch(uid, query string, kind SearchKind) error
+	History(uid string) ([]SearchHistoryEntry, error)
+	ClearHistory(uid string) error
+}
+
+type SavedSearchRepository interface {
+	FindByID(uid, id string) (*SavedSearch, error)
+	FindAllForUser(uid string) (*[]SavedSearch, error)
+	Create(search *SavedSearch, max int) error
+	Update(search *SavedSearch) error
+	Delete(uid, id string) error
+}
+
+// SearchHistoryRepository keeps the most recent distinct searches of every user
+type SearchHistoryRepository interface {
+	Add(uid string, entry SearchHistoryEntry, max int) error
+	List(uid string) ([]SearchHistoryEntry, error)
+	Clear(uid string) error
+}
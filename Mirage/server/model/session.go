@@ -0,0 +1,62 @@
+package model
+
+import "time"
+
+// Session is a single login of a user on a device.
+// Sessions are stored in Redis next to the cookie session
+// so that they can be listed and revoked.
+type Session struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"-"`
+	Device    string    `json:"device"`
+	IP        string    `json:"ip"`
+	CreatedAt time.Time `json:"createdAt"`
+	LastSeen  time.Time `json:"lastSeen"`
+}
+
+type SessionResponse struct {
+	ID        string    `json:"id"`
+	Device    string    `json:"device"`
+	IP        string    `json:"ip"`
+	Current   bool      `json:"current"`
+	CreatedAt time.Time `json:"createdAt"`
+	LastSeen  time.Time `json:"lastSeen"`
+}
+
+func (session *Session) NewSessionResponse(current string) SessionResponse {
+	return SessionResponse{
+		ID:        session.ID,
+		Device:    session.Device,
+		IP:        session.IP,
+		Current:   session.ID == current,
+		CreatedAt: session.CreatedAt,
+		LastSeen:  session.LastSeen,
+	}
+}
+
+type SessionService interface {
+	Create(uid, device, ip string) (*Session, error)
+	UpgradeLegacy(uid, device, ip string) (*Session, error)
+	Verify(uid, id, ip string) (bool, error)
+	List(uid string) (*[]Session, error)
+	Revoke(uid, id string) error
+	RevokeAll(uid string) error
+	CreatePending(uid string) (string, error)
+	FindPending(token string) (string, error)
+	FailPending(token string) error
+	DeletePending(token string) error
+}
+
+type SessionRepository interface {
+	FindByID(id string) (*Session, error)
+	Create(session *Session) error
+	Update(session *Session) (bool, error)
+	Delete(uid, id string) error
+	FindAllForUser(uid string) (*[]Session, error)
+	DeleteAllForUser(uid string) error
+	RevokedAll(uid string) (bool, error)
+	CreatePending(token, uid string, ttl time.Duration) error
+	FindPending(token string) (string, error)
+	IncrementPendingAttempts(token string) (int64, error)
+	DeletePending(token string) error
+}
@@ -0,0 +1,52 @@
+package model
+
+import "time"
+
+// SuggestionCandidate is an account the user doesn't follow
+// yet with the signals it gets ranked by
+type SuggestionCandidate struct {
+	UserID string
+	// MutualFollowers is how many of the user's followees follow the account
+	MutualFollowers int64
+	// SharedHashtags is how many hashtags both recently used
+	SharedHashtags int64
+	// RecentPosts is how many posts the account recently created
+	RecentPosts int64
+}
+
+// Suggestion is a ranked account to follow
+type Suggestion struct {
+	UserID          string  `json:"userId"`
+	Score           float64 `json:"score"`
+	MutualFollowers int64   `json:"mutualFollowers"`
+	SharedHashtags  int64   `json:"sharedHashtags"`
+	User            *User   `json:"-"`
+}
+
+// SuggestionResponse is a suggested profile with the reasons it was suggested
+type SuggestionResponse struct {
+	Profile
+	MutualFollowers int64 `json:"mutualFollowers"`
+	SharedHashtags  int64 `json:"sharedHashtags"`
+}
+
+func (s *Suggestion) NewSuggestionResponse(id string) SuggestionResponse {
+	return SuggestionResponse{
+		Profile:         s.User.NewProfileResponse(id),
+		MutualFollowers: s.MutualFollowers,
+		SharedHashtags:  s.SharedHashtags,
+	}
+}
+
+type SuggestionService interface {
+	Suggestions(userId string) (*[]Suggestion, error)
+	Refresh(userId string) (*[]Suggestion, error)
+}
+
+// SuggestionCache stores the ranked suggestions of every user
+type SuggestionCache interface {
+	// Get returns nil if the user has no cached suggestions
+	Get(userId string) ([]Suggestion, error)
+	Set(userId string, suggestions []Suggestion, ttl time.Duration) error
+	Delete(userId string) error
+}
@@ -0,0 +1,65 @@
+package model
+
+import "time"
+
+// TimelineReason tells why a post is in a timeline
+type TimelineReason string
+
+const (
+	// ReasonAuthored is a post of the timeline's owner or a followee
+	ReasonAuthored TimelineReason = "authored"
+	// ReasonRetweetedBy is a post that the actor retweeted
+	ReasonRetweetedBy TimelineReason = "retweeted_by"
+	// ReasonLikedBy is a post that the actor liked
+	ReasonLikedBy TimelineReason = "liked_by"
+)
+
+// TimelineEntry is a post or a retweet in a home timeline. Entries are ordered
+// newest first by CreatedAt, then by PostID and ActorID. Pages of entries start
+// after a before entry, the last one of the previous page, or nil for the first.
+type TimelineEntry struct {
+	PostID string `json:"postId"`
+	// ActorID is the author of the post or the user who retweeted it
+	ActorID   string    `json:"actorId"`
+	CreatedAt time.Time `json:"createdAt"`
+	// Reason is derived from the actor if empty
+	Reason TimelineReason `json:"reason,omitempty"`
+}
+
+// TimelineActivity is the action that put a post into a timeline
+type TimelineActivity struct {
+	Reason TimelineReason
+	Actor  User
+	At     time.Time
+}
+
+// TimelineItemResponse is a post with the reason it is in the timeline.
+// The post's fields stay at the top level for older clients.
+type TimelineItemResponse struct {
+	PostResponse
+	Reason  TimelineReason `json:"reason"`
+	Actor   Profile        `json:"actor"`
+	ActedAt time.Time      `json:"actedAt"`
+}
+
+type TimelineService interface {
+	Timeline(userId, cursor string) (*[]Post, string, error)
+	Publish(entry TimelineEntry) error
+	Retract(entry TimelineEntry) error
+	Follow(userId, followeeId string) error
+	Unfollow(userId, followeeId string) error
+	Rebuild(userId string) error
+	RebuildAll() (int, error)
+}
+
+type TimelineRepository interface {
+	Add(userIds []string, entries []TimelineEntry) error
+	Remove(userIds []string, entry TimelineEntry) error
+	RemoveActor(userId, actorId string) error
+	Range(userId string, before *TimelineEntry, limit int) ([]TimelineEntry, error)
+	Replace(userId string, entries []TimelineEntry) error
+	Exists(userId string) (bool, error)
+	PopularActors() ([]string, error)
+	SetPopular(actorId string, popular bool) (bool, error)
+	ReplacePopular(actorIds []string) error
+}
@@ -0,0 +1,75 @@
+package model
+
+import (
+	"github.com/lib/pq"
+	"time"
+)
+
+// Scopes an access token can be granted
+const (
+	ScopeRead   = "read"   // GET requests
+	ScopeWrite  = "write"  // all requests that change data
+	ScopeImport = "import" // creating posts and uploading media for bulk importing archives
+)
+
+// ValidScopes contains every scope a token can be created with
+var ValidScopes = []interface{}{ScopeRead, ScopeWrite, ScopeImport}
+
+type TokenResponse struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	Token      string     `json:"token,omitempty"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+// NewTokenResponse returns the public fields of the token.
+// The raw token is only included right after its creation.
+func (token *AccessToken) NewTokenResponse(raw string) TokenResponse {
+	return TokenResponse{
+		ID:         token.ID,
+		Name:       token.Name,
+		Scopes:     token.Scopes,
+		Token:      raw,
+		LastUsedAt: token.LastUsedAt,
+		CreatedAt:  token.CreatedAt,
+	}
+}
+
+// HasScope checks if the token was granted the given scope
+func (token *AccessToken) HasScope(scope string) bool {
+	for _, s := range token.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// AccessToken is a personal access token for non-browser clients.
+// Only the SHA-256 hash of the token is stored.
+type AccessToken struct {
+	ID         string         `gorm:"primaryKey"`
+	UserID     string         `gorm:"not null;index"`
+	Name       string         `gorm:"not null"`
+	Hash       string         `gorm:"not null;uniqueIndex" json:"-"`
+	Scopes     pq.StringArray `gorm:"type:text[]"`
+	LastUsedAt *time.Time
+	CreatedAt  time.Time
+}
+
+type TokenService interface {
+	Create(uid, name string, scopes []string) (*AccessToken, string, error)
+	Authenticate(raw string) (*AccessToken, error)
+	List(uid string) (*[]AccessToken, error)
+	Revoke(uid, id string) error
+}
+
+type TokenRepository interface {
+	FindByHash(hash string) (*AccessToken, error)
+	FindAllForUser(uid string) (*[]AccessToken, error)
+	Create(token *AccessToken) error
+	UpdateLastUsed(id string, lastUsed time.Time) error
+	Delete(uid, id string) error
+}
@@ -0,0 +1,45 @@
+package model
+
+import "time"
+
+// TrendWindows are the supported windows of the trends endpoint
+var TrendWindows = map[string]time.Duration{
+	"1h":  time.Hour,
+	"24h": 24 * time.Hour,
+	"7d":  7 * 24 * time.Hour,
+}
+
+// Trend is a hashtag ranked by how much faster it is used now than before.
+// Count is the number of uses in the window, Previous the number of uses
+// in the window before it and Score the velocity between the two.
+type Trend struct {
+	Hashtag  string  `json:"hashtag"`
+	Score    float64 `json:"score"`
+	Count    int64   `json:"count"`
+	Previous int64   `json:"previous"`
+}
+
+// TrendCount is the number of uses of a hashtag in the
+// current window and in the baseline window before it
+type TrendCount struct {
+	Hashtag  string
+	Current  int64
+	Baseline int64
+}
+
+type TrendService interface {
+	Record(tags []string, at time.Time) error
+	Remove(tags []string, at time.Time) error
+	GetTrends(window time.Duration, limit int) (*[]Trend, error)
+	Rebuild() error
+}
+
+// TrendRepository stores hashtag counts in time buckets of the given resolution
+type TrendRepository interface {
+	Increment(resolution time.Duration, bucket time.Time, tags []string, ttl time.Duration) error
+	Decrement(resolution time.Duration, bucket time.Time, tags []string) error
+	// Counts returns the counts of the tags whose use grew the most
+	// from the baseline to the current buckets
+	Counts(resolution time.Duration, current, baseline []time.Time, limit int) ([]TrendCount, error)
+	Clear() error
+}
@@ -0,0 +1,9 @@
+package model
+
+// TwoFactorSetup is returned when a user enrolls in two-factor authentication.
+// The secret and recovery codes are only shown this once.
+type TwoFactorSetup struct {
+	Secret        string   `json:"secret"`
+	URI           string   `json:"uri"`
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
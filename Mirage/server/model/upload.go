@@ -0,0 +1,126 @@
+package model
+
+import "time"
+
+// Upload is a media file the client uploads directly to the file storage.
+// It gets attached to a post by its ID and removed once it is attached
+// or expired.
+type Upload struct {
+	ID        string    `gorm:"primaryKey" json:"id"`
+	UserID    string    `gorm:"not null;index" json:"-"`
+	Key       string    `gorm:"not null" json:"-"`
+	FileType  string    `gorm:"not null" json:"filetype"`
+	Size      int64     `gorm:"not null" json:"size"`
+	ExpiresAt time.Time `gorm:"index" json:"expiresAt"`
+	// MultipartID is the storage's multipart upload of chunked uploads
+	MultipartID string    `json:"-"`
+	CreatedAt   time.Time `json:"-"`
+}
+
+// UploadTarget describes the request the client has to send to upload the file
+type UploadTarget struct {
+	URL     string            `json:"url"`
+	Method  string            `json:"method"`
+	Headers map[string]string `json:"headers"`
+}
+
+// UploadResponse is returned when requesting an upload
+type UploadResponse struct {
+	MediaID   string       `json:"mediaId"`
+	FileType  string       `json:"filetype"`
+	Size      int64        `json:"size"`
+	Upload    UploadTarget `json:"upload"`
+	ExpiresAt time.Time    `json:"expiresAt"`
+}
+
+func (u *Upload) NewUploadResponse(target *UploadTarget) UploadResponse {
+	return UploadResponse{
+		MediaID:   u.ID,
+		FileType:  u.FileType,
+		Size:      u.Size,
+		Upload:    *target,
+		ExpiresAt: u.ExpiresAt,
+	}
+}
+
+// StoredObject is the metadata of an object in the file storage
+type StoredObject struct {
+	Url      string
+	Size     int64
+	FileType string
+}
+
+// ChunkSize is the size of every chunk of a chunked upload except the last one.
+// It is the smallest part size S3 accepts for multipart uploads.
+const ChunkSize = 5 << 20
+
+// UploadPart is an uploaded part of a multipart upload
+type UploadPart struct {
+	Number int64  `json:"number"`
+	ETag   string `json:"etag"`
+}
+
+// ChunkedUpload is the state of a resumable upload. Chunks have to
+// be appended in order, so the checksum can be calculated on the fly.
+type ChunkedUpload struct {
+	ID          string       `json:"id"`
+	UserID      string       `json:"userId"`
+	Key         string       `json:"key"`
+	FileType    string       `json:"filetype"`
+	Size        int64        `json:"size"`
+	Checksum    string       `json:"checksum"`
+	MultipartID string       `json:"multipartId"`
+	NextChunk   int          `json:"nextChunk"`
+	Received    int64        `json:"received"`
+	Parts       []UploadPart `json:"parts"`
+	HashState   []byte       `json:"hashState"`
+	ExpiresAt   time.Time    `json:"expiresAt"`
+}
+
+// ChunkedUploadResponse tells the client where to continue the upload
+type ChunkedUploadResponse struct {
+	MediaID   string    `json:"mediaId"`
+	FileType  string    `json:"filetype"`
+	Size      int64     `json:"size"`
+	ChunkSize int64     `json:"chunkSize"`
+	NextChunk int       `json:"nextChunk"`
+	Received  int64     `json:"received"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+func (u *ChunkedUpload) NewChunkedUploadResponse() ChunkedUploadResponse {
+	return ChunkedUploadResponse{
+		MediaID:   u.ID,
+		FileType:  u.FileType,
+		Size:      u.Size,
+		ChunkSize: ChunkSize,
+		NextChunk: u.NextChunk,
+		Received:  u.Received,
+		ExpiresAt: u.ExpiresAt,
+	}
+}
+
+type MediaService interface {
+	CreateUpload(userId, filetype string, size int64) (*Upload, *UploadTarget, error)
+	AttachUpload(userId, mediaId string) (*File, error)
+	ReleaseUpload(userId, mediaId string) error
+	CleanupUploads() (int, error)
+	CleanupOrphanedMedia() (int, error)
+	InitChunkedUpload(userId, filetype string, size int64, checksum string) (*ChunkedUpload, error)
+	AppendChunk(userId, mediaId string, index int, chunk []byte) (*ChunkedUpload, error)
+	GetChunkedUpload(userId, mediaId string) (*ChunkedUpload, error)
+	FinalizeChunkedUpload(userId, mediaId string) (*ChunkedUpload, error)
+}
+
+type UploadRepository interface {
+	Create(upload *Upload) error
+	FindByID(id string) (*Upload, error)
+	Delete(upload *Upload) error
+	Expired(before time.Time) (*[]Upload, error)
+}
+
+type ChunkedUploadRepository interface {
+	Save(upload *ChunkedUpload) error
+	Get(id string) (*ChunkedUpload, error)
+	Delete(id string) error
+}
@@ -1,31 +1,38 @@
 package model
 
 import (
+	"github.com/lib/pq"
 	"mime/multipart"
 	"time"
 )
 
 type AccountResponse struct {
-	ID          string    `json:"id"`
-	Email       string    `json:"email"`
-	Username    string    `json:"username"`
-	DisplayName string    `json:"displayName"`
-	Image       string    `json:"image"`
-	Banner      *string   `json:"banner"`
-	Bio         *string   `json:"bio"`
-	CreatedAt   time.Time `json:"createdAt"`
+	ID               string    `json:"id"`
+	Email            string    `json:"email"`
+	Username         string    `json:"username"`
+	DisplayName      string    `json:"displayName"`
+	Image            string    `json:"image"`
+	Banner           *string   `json:"banner"`
+	Bio              *string   `json:"bio"`
+	TwoFactorEnabled bool      `json:"twoFactorEnabled"`
+	CreatedAt        time.Time `json:"createdAt"`
+	ImageJobID       string    `json:"imageJobId,omitempty"`
+	BannerJobID      string    `json:"bannerJobId,omitempty"`
 }
 
 func (user *User) NewAccountResponse() AccountResponse {
 	return AccountResponse{
-		ID:          user.ID,
-		Username:    user.Username,
-		DisplayName: user.DisplayName,
-		Email:       user.Email,
-		Image:       user.Image,
-		Banner:      user.Banner,
-		Bio:         user.Bio,
-		CreatedAt:   user.CreatedAt,
+		ID:               user.ID,
+		Username:         user.Username,
+		DisplayName:      user.DisplayName,
+		Email:            user.Email,
+		Image:            user.Image,
+		Banner:           user.Banner,
+		Bio:              user.Bio,
+		TwoFactorEnabled: user.TwoFactorEnabled,
+		CreatedAt:        user.CreatedAt,
+		ImageJobID:       user.ImageJobID,
+		BannerJobID:      user.BannerJobID,
 	}
 }
 
@@ -57,6 +64,35 @@ func (user *User) NewProfileResponse(id string) Profile {
 	}
 }
 
+// FollowProfile is a profile in a follower or following list
+type FollowProfile struct {
+	Profile
+	FollowsYou bool `json:"followsYou"`
+}
+
+// NewFollowProfileResponse returns the profile with whether
+// the user follows the viewer with the given ID
+func (user *User) NewFollowProfileResponse(id string) FollowProfile {
+	return FollowProfile{
+		Profile:    user.NewProfileResponse(id),
+		FollowsYou: user.Follows(id),
+	}
+}
+
+// Follows checks if the user follows the user with the given ID
+func (user *User) Follows(id string) bool {
+	if id == "" {
+		return false
+	}
+
+	for _, v := range user.Followee {
+		if v.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
 func (user *User) IsFollowing(id string) bool {
 	if id == "" {
 		return false
@@ -71,32 +107,61 @@ func (user *User) IsFollowing(id string) bool {
 }
 
 type User struct {
-	ID          string `gorm:"primaryKey"`
-	Username    string `gorm:"not null;index;uniqueIndex"`
-	DisplayName string `gorm:"not null;index"`
-	Email       string `gorm:"not null;uniqueIndex"`
-	Password    string `gorm:"not null" json:"-"`
-	Image       string `gorm:"not null"`
-	Banner      *string
-	Bio         *string
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	Posts       []Post
-	Followers   []*User `gorm:"many2many:followers" json:"-"`
-	Followee    []*User `gorm:"many2many:followee" json:"-"`
+	ID                string `gorm:"primaryKey"`
+	Username          string `gorm:"not null;index;uniqueIndex"`
+	DisplayName       string `gorm:"not null;index"`
+	Email             string `gorm:"not null;uniqueIndex"`
+	Password          string `gorm:"not null" json:"-"`
+	Image             string `gorm:"not null"`
+	Banner            *string
+	Bio               *string
+	SearchName        string         `gorm:"not null;default:'';index" json:"-"`
+	SearchBio         string         `gorm:"not null;default:''" json:"-"`
+	FollowerCount     int64          `gorm:"<-:create;not null;default:0;index" json:"-"`
+	TwoFactorEnabled  bool           `gorm:"not null;default:false"`
+	TwoFactorSecret   *string        `json:"-"`
+	LastTwoFactorStep int64          `gorm:"not null;default:0" json:"-"`
+	RecoveryCodes     pq.StringArray `gorm:"type:text[]" json:"-"`
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+	Posts             []Post
+	Followers         []*User `gorm:"many2many:follows;joinForeignKey:FolloweeID;joinReferences:FollowerID" json:"-"`
+	Followee          []*User `gorm:"many2many:follows;joinForeignKey:FollowerID;joinReferences:FolloweeID" json:"-"`
+	// ImageJobID and BannerJobID are the jobs that resize a changed avatar or banner
+	ImageJobID  string `gorm:"-" json:"-"`
+	BannerJobID string `gorm:"-" json:"-"`
 }
 
+// ProfileImage is the column a profile image is stored in
+type ProfileImage string
+
+const (
+	AvatarImage ProfileImage = "image"
+	BannerImage ProfileImage = "banner"
+)
+
 type UserService interface {
 	Get(uid string) (*User, error)
 	FindByUsername(username string) (*User, error)
 	Register(user *User) (*User, error)
-	Login(email, password string) (*User, error)
+	Login(email, password, ip string) (*User, error)
+	LoginTwoFactor(user *User, code, ip string) (bool, error)
 	Update(user *User) error
-	ChangeAvatar(header *multipart.FileHeader, directory string) (string, error)
-	ChangeBanner(header *multipart.FileHeader, directory string) (string, error)
+	ChangeAvatar(user *User, header *multipart.FileHeader, directory string) error
+	ChangeBanner(user *User, header *multipart.FileHeader, directory string) error
 	DeleteImage(key string) error
 	ChangeFollow(user *User, current string) error
-	Search(term string) (*[]User, error)
+	Search(term, cursor string) (*[]User, string, error)
+	Typeahead(term string) (*[]User, error)
+	ReindexProfiles() (int, error)
+	Followers(userId, cursor string) (*[]User, string, error)
+	Following(userId, cursor string) (*[]User, string, error)
+	MutualFollowers(userId, viewerId, cursor string) (*[]User, string, error)
+	EnrollTwoFactor(user *User, password string) (*TwoFactorSetup, error)
+	ConfirmTwoFactor(user *User, code string) error
+	VerifyTwoFactor(user *User, code string) (bool, error)
+	DisableTwoFactor(user *User, password, code string) error
+	RegenerateRecoveryCodes(user *User, code string) ([]string, error)
 }
 
 type UserRepository interface {
@@ -107,5 +172,47 @@ type UserRepository interface {
 	Update(user *User) error
 	AddFollow(userId, currentId string) error
 	RemoveFollow(userId, currentId string) error
-	SearchProfiles(term string) (*[]User, error)
+	SearchProfiles(term string, after *ProfileRank, limit int) (*[]User, []ProfileRank, error)
+	TypeaheadProfiles(term string, limit int) (*[]User, error)
+	FindAfter(id string, limit int) (*[]User, error)
+	UpdateSearchText(user *User) error
+	SetProfileImage(userId string, kind ProfileImage, url string) error
+	ConsumeTwoFactorStep(userId string, step int64) (bool, error)
+	ConsumeRecoveryCode(userId, hash string) (bool, error)
+	FindByIDs(ids []string) (*[]User, error)
+	FollowerEdges(userId string, before *Follow, limit int) ([]Follow, error)
+	FollowingEdges(userId string, before *Follow, limit int) ([]Follow, error)
+	MutualFollowerEdges(userId, viewerId string, before *Follow, limit int) ([]Follow, error)
+	FollowerIDs(userId string) ([]string, error)
+	FollowerCount(userId string) (int64, error)
+	RecountFollowers() error
+	FolloweeIDs(userId string) ([]string, error)
+	BlockedIDs(userId string) ([]string, error)
+	PopularUsers(minFollowers int) ([]string, error)
+	SuggestionCandidates(userId string, since time.Time, limit int) ([]SuggestionCandidate, error)
+}
+
+// ProfileRank is the position of a user in the results of a profile search:
+// by the kind of match, then most followers first, then by ID
+type ProfileRank struct {
+	Match     int
+	Followers int64
+	ID        string
+}
+
+// MentionProfile is the short profile shown when autocompleting a mention
+type MentionProfile struct {
+	ID          string `json:"id"`
+	Username    string `json:"username"`
+	DisplayName string `json:"displayName"`
+	Image       string `json:"image"`
+}
+
+func (user *User) NewMentionProfileResponse() MentionProfile {
+	return MentionProfile{
+		ID:          user.ID,
+		Username:    user.Username,
+		DisplayName: user.DisplayName,
+		Image:       user.Image,
+	}
 }
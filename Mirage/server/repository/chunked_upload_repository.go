@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"log"
+	"time"
+)
+
+// redisChunkedUploadRepository keeps the state of chunked uploads in Redis.
+// Sessions expire at the upload's expiration.
+type redisChunkedUploadRepository struct {
+	Redis *redis.Client
+}
+
+// NewChunkedUploadRepository is a factory for initializing ChunkedUpload Repositories
+func NewChunkedUploadRepository(rds *redis.Client) model.ChunkedUploadRepository {
+	return &redisChunkedUploadRepository{
+		Redis: rds,
+	}
+}
+
+func chunkedUploadKey(id string) string {
+	return fmt.Sprintf("chunked_upload:%s", id)
+}
+
+// Save stores the upload's state until it expires
+func (r *redisChunkedUploadRepository) Save(upload *model.ChunkedUpload) error {
+	ctx := context.Background()
+
+	ttl := time.Until(upload.ExpiresAt)
+	if ttl <= 0 {
+		return apperrors.NewNotFound("media", upload.ID)
+	}
+
+	data, err := json.Marshal(upload)
+
+	if err != nil {
+		return apperrors.NewInternal()
+	}
+
+	if err := r.Redis.Set(ctx, chunkedUploadKey(upload.ID), data, ttl).Err(); err != nil {
+		log.Printf("Could not save chunked upload: %v. Reason: %v\n", upload.ID, err)
+		return apperrors.NewInternal()
+	}
+
+	return nil
+}
+
+// Get returns the state of the upload
+func (r *redisChunkedUploadRepository) Get(id string) (*model.ChunkedUpload, error) {
+	ctx := context.Background()
+
+	data, err := r.Redis.Get(ctx, chunkedUploadKey(id)).Bytes()
+
+	if err == redis.Nil {
+		return nil, apperrors.NewNotFound("media", id)
+	}
+
+	if err != nil {
+		log.Printf("Could not get chunked upload: %v. Reason: %v\n", id, err)
+		return nil, apperrors.NewInternal()
+	}
+
+	upload := &model.ChunkedUpload{}
+	if err := json.Unmarshal(data, upload); err != nil {
+		return nil, apperrors.NewNotFound("media", id)
+	}
+
+	return upload, nil
+}
+
+func (r *redisChunkedUploadRepository) Delete(id string) error {
+	ctx := context.Background()
+
+	if err := r.Redis.Del(ctx, chunkedUploadKey(id)).Err(); err != nil {
+		log.Printf("Could not delete chunked upload: %v. Reason: %v\n", id, err)
+		return apperrors.NewInternal()
+	}
+
+	return nil
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"log"
+	"strconv"
+	"time"
+)
+
+// redisFeedSnapshotRepository stores the scores of a top feed
+// as a hash of post IDs to scores
+type redisFeedSnapshotRepository struct {
+	Redis *redis.Client
+}
+
+// NewFeedSnapshotRepository is a factory for initializing Feed Snapshot Repositories
+func NewFeedSnapshotRepository(rds *redis.Client) model.FeedSnapshotRepository {
+	return &redisFeedSnapshotRepository{
+		Redis: rds,
+	}
+}
+
+func feedSnapshotKey(userId string, at time.Time) string {
+	return fmt.Sprintf("top_feed:%s:%d", userId, at.UnixNano())
+}
+
+// Get returns the scores of the snapshot or nil if it expired
+func (r *redisFeedSnapshotRepository) Get(userId string, at time.Time) (map[string]float64, error) {
+	ctx := context.Background()
+	key := feedSnapshotKey(userId, at)
+
+	values, err := r.Redis.HGetAll(ctx, key).Result()
+
+	if err != nil {
+		log.Printf("Could not get feed snapshot: %v. Reason: %v\n", key, err)
+		return nil, apperrors.NewInternal()
+	}
+
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	scores := make(map[string]float64, len(values))
+	for id, value := range values {
+		score, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			log.Printf("Could not decode feed snapshot: %v. Reason: %v\n", key, err)
+			return nil, apperrors.NewInternal()
+		}
+		scores[id] = score
+	}
+
+	return scores, nil
+}
+
+// Save stores the scores for the given duration
+func (r *redisFeedSnapshotRepository) Save(userId string, at time.Time, scores map[string]float64, ttl time.Duration) error {
+	ctx := context.Background()
+	key := feedSnapshotKey(userId, at)
+
+	values := make(map[string]interface{}, len(scores))
+	for id, score := range scores {
+		values[id] = strconv.FormatFloat(score, 'g', -1, 64)
+	}
+
+	_, err := r.Redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, key)
+		pipe.HSet(ctx, key, values)
+		pipe.Expire(ctx, key, ttl)
+		return nil
+	})
+
+	if err != nil {
+		log.Printf("Could not save feed snapshot: %v. Reason: %v\n", key, err)
+		return apperrors.NewInternal()
+	}
+
+	return nil
+}
@@ -4,18 +4,24 @@ import (
 	"bytes"
 	"fmt"
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/s3/s3manager"
 	"github.com/disintegration/imaging"
 	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
 	"github.com/sentrionic/mirage/service"
 	"image"
 	_ "image/gif"
 	"image/jpeg"
 	_ "image/jpeg"
 	_ "image/png"
+	"io"
 	"mime/multipart"
+	"net/http"
+	"net/url"
+	"time"
 )
 
 // s3FileRepository includes the S3 session and the BucketName
@@ -37,10 +43,11 @@ func NewFileRepository(session *session.Session, bucketName string) model.FileRe
 // All images turn into jpeg images.
 // It returns the url of the uploaded file.
 func (s *s3FileRepository) UploadAvatar(header *multipart.FileHeader, directory string) (string, error) {
-	uploader := s3manager.NewUploader(s.S3Session)
+	return s.uploadResizedHeader(header, directory, 400)
+}
 
+func (s *s3FileRepository) uploadResizedHeader(header *multipart.FileHeader, directory string, width int) (string, error) {
 	id, _ := service.GenerateId()
-	key := fmt.Sprintf("files/%s/%s.jpeg", directory, id)
 
 	file, err := header.Open()
 
@@ -48,54 +55,69 @@ func (s *s3FileRepository) UploadAvatar(header *multipart.FileHeader, directory
 		return "", err
 	}
 
-	src, _, err := image.Decode(file)
+	url, err := s.UploadResizedImage(file, directory, id+".jpeg", width)
 
 	if err != nil {
 		return "", err
 	}
 
-	img := imaging.Resize(src, 400, 0, imaging.Lanczos)
+	if err := file.Close(); err != nil {
+		return "", err
+	}
 
-	buf := new(bytes.Buffer)
-	err = jpeg.Encode(buf, img, &jpeg.Options{Quality: 75})
+	return url, nil
+}
+
+// UploadResizedImage resizes the image to the given width and uploads
+// it as a jpeg image. It returns the url of the uploaded file.
+func (s *s3FileRepository) UploadResizedImage(body io.Reader, directory, filename string, width int) (string, error) {
+	src, _, err := image.Decode(body)
 
 	if err != nil {
 		return "", err
 	}
 
-	up, err := uploader.Upload(&s3manager.UploadInput{
-		Body:        buf,
-		Bucket:      aws.String(s.BucketName),
-		ContentType: aws.String("image/jpeg"),
-		Key:         aws.String(key),
-	})
+	img := imaging.Resize(src, width, 0, imaging.Lanczos)
+
+	buf := new(bytes.Buffer)
+	err = jpeg.Encode(buf, img, &jpeg.Options{Quality: 75})
 
 	if err != nil {
 		return "", err
 	}
 
-	if err := file.Close(); err != nil {
+	return s.UploadFile(buf, directory, filename, "image/jpeg")
+}
+
+// FileURL returns the url a file in the directory has once it is uploaded
+func (s *s3FileRepository) FileURL(directory, filename string) (string, error) {
+	return s.objectURL(fmt.Sprintf("files/%s/%s", directory, filename))
+}
+
+// objectURL builds the object's url without sending a request
+func (s *s3FileRepository) objectURL(key string) (string, error) {
+	srv := s3.New(s.S3Session)
+	req, _ := srv.GetObjectRequest(&s3.GetObjectInput{
+		Bucket: aws.String(s.BucketName),
+		Key:    aws.String(key),
+	})
+
+	if err := req.Build(); err != nil {
 		return "", err
 	}
 
-	return up.Location, nil
+	return req.HTTPRequest.URL.String(), nil
 }
 
-// UploadFile uploads the given file to the initialized Bucket.
+// UploadFile uploads the content of the reader to the initialized Bucket.
 // It returns the url of the uploaded file.
-func (s *s3FileRepository) UploadFile(header *multipart.FileHeader, directory, filename, mimetype string) (string, error) {
+func (s *s3FileRepository) UploadFile(body io.Reader, directory, filename, mimetype string) (string, error) {
 	uploader := s3manager.NewUploader(s.S3Session)
 
 	key := fmt.Sprintf("files/%s/%s", directory, filename)
 
-	file, err := header.Open()
-
-	if err != nil {
-		return "", err
-	}
-
 	up, err := uploader.Upload(&s3manager.UploadInput{
-		Body:        file,
+		Body:        body,
 		Bucket:      aws.String(s.BucketName),
 		ContentType: aws.String(mimetype),
 		Key:         aws.String(key),
@@ -105,65 +127,178 @@ func (s *s3FileRepository) UploadFile(header *multipart.FileHeader, directory, f
 		return "", err
 	}
 
-	if err := file.Close(); err != nil {
-		return "", err
-	}
-
 	return up.Location, nil
 }
 
-// DeleteImage deletes the file from the Bucket.
-func (s *s3FileRepository) DeleteImage(key string) error {
+// PresignUpload returns a presigned PUT request for the key. The content type
+// and length are part of the signature, so the client can't upload anything else.
+func (s *s3FileRepository) PresignUpload(key, mimetype string, size int64, expires time.Duration) (*model.UploadTarget, error) {
 	srv := s3.New(s.S3Session)
-	_, err := srv.DeleteObject(&s3.DeleteObjectInput{
-		Bucket: aws.String(s.BucketName),
-		Key:    aws.String(key),
+	req, _ := srv.PutObjectRequest(&s3.PutObjectInput{
+		Bucket:        aws.String(s.BucketName),
+		Key:           aws.String(key),
+		ContentType:   aws.String(mimetype),
+		ContentLength: aws.Int64(size),
 	})
 
-	return err
+	url, header, err := req.PresignRequest(expires)
+
+	if err != nil {
+		return nil, err
+	}
+
+	headers := make(map[string]string)
+	for name := range header {
+		headers[name] = header.Get(name)
+	}
+
+	return &model.UploadTarget{
+		URL:     url,
+		Method:  http.MethodPut,
+		Headers: headers,
+	}, nil
 }
 
-func (s *s3FileRepository) UploadBanner(header *multipart.FileHeader, directory string) (string, error) {
-	uploader := s3manager.NewUploader(s.S3Session)
+// StatFile returns the size, type and url of the object with the given key
+func (s *s3FileRepository) StatFile(key string) (*model.StoredObject, error) {
+	srv := s3.New(s.S3Session)
+	head, err := srv.HeadObject(&s3.HeadObjectInput{
+		Bucket: aws.String(s.BucketName),
+		Key:    aws.String(key),
+	})
 
-	id, _ := service.GenerateId()
-	key := fmt.Sprintf("files/%s/%s.jpeg", directory, id)
+	if err != nil {
+		if reqErr, ok := err.(awserr.RequestFailure); ok && reqErr.StatusCode() == http.StatusNotFound {
+			return nil, apperrors.NewNotFound("file", key)
+		}
+		return nil, err
+	}
 
-	file, err := header.Open()
+	url, err := s.objectURL(key)
 
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	src, _, err := image.Decode(file)
+	return &model.StoredObject{
+		Url:      url,
+		Size:     aws.Int64Value(head.ContentLength),
+		FileType: aws.StringValue(head.ContentType),
+	}, nil
+}
+
+// CopyFile copies the object with the given key into the directory.
+// It returns the url of the copy.
+func (s *s3FileRepository) CopyFile(key, directory, filename string) (string, error) {
+	srv := s3.New(s.S3Session)
+	dst := fmt.Sprintf("files/%s/%s", directory, filename)
+
+	_, err := srv.CopyObject(&s3.CopyObjectInput{
+		Bucket:     aws.String(s.BucketName),
+		CopySource: aws.String(url.PathEscape(s.BucketName + "/" + key)),
+		Key:        aws.String(dst),
+	})
 
 	if err != nil {
 		return "", err
 	}
 
-	img := imaging.Resize(src, 1500, 0, imaging.Lanczos)
+	return s.objectURL(dst)
+}
 
-	buf := new(bytes.Buffer)
-	err = jpeg.Encode(buf, img, &jpeg.Options{Quality: 75})
+// OpenFile returns the content of the object with the given key
+func (s *s3FileRepository) OpenFile(key string) (io.ReadCloser, error) {
+	srv := s3.New(s.S3Session)
+	object, err := srv.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(s.BucketName),
+		Key:    aws.String(key),
+	})
 
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	up, err := uploader.Upload(&s3manager.UploadInput{
-		Body:        buf,
+	return object.Body, nil
+}
+
+// CreateMultipartUpload starts a multipart upload for the key and returns its ID
+func (s *s3FileRepository) CreateMultipartUpload(key, mimetype string) (string, error) {
+	srv := s3.New(s.S3Session)
+	out, err := srv.CreateMultipartUpload(&s3.CreateMultipartUploadInput{
 		Bucket:      aws.String(s.BucketName),
-		ContentType: aws.String("image/jpeg"),
 		Key:         aws.String(key),
+		ContentType: aws.String(mimetype),
 	})
 
 	if err != nil {
 		return "", err
 	}
 
-	if err := file.Close(); err != nil {
+	return aws.StringValue(out.UploadId), nil
+}
+
+// UploadPart uploads a part of the multipart upload and returns its ETag
+func (s *s3FileRepository) UploadPart(key, multipartId string, number int64, body io.ReadSeeker) (string, error) {
+	srv := s3.New(s.S3Session)
+	out, err := srv.UploadPart(&s3.UploadPartInput{
+		Bucket:     aws.String(s.BucketName),
+		Key:        aws.String(key),
+		UploadId:   aws.String(multipartId),
+		PartNumber: aws.Int64(number),
+		Body:       body,
+	})
+
+	if err != nil {
 		return "", err
 	}
 
-	return up.Location, nil
+	return aws.StringValue(out.ETag), nil
+}
+
+// CompleteMultipartUpload assembles the parts into the object
+func (s *s3FileRepository) CompleteMultipartUpload(key, multipartId string, parts []model.UploadPart) error {
+	completed := make([]*s3.CompletedPart, len(parts))
+	for i, part := range parts {
+		completed[i] = &s3.CompletedPart{
+			ETag:       aws.String(part.ETag),
+			PartNumber: aws.Int64(part.Number),
+		}
+	}
+
+	srv := s3.New(s.S3Session)
+	_, err := srv.CompleteMultipartUpload(&s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(s.BucketName),
+		Key:             aws.String(key),
+		UploadId:        aws.String(multipartId),
+		MultipartUpload: &s3.CompletedMultipartUpload{Parts: completed},
+	})
+
+	return err
+}
+
+// AbortMultipartUpload discards the multipart upload and its parts
+func (s *s3FileRepository) AbortMultipartUpload(key, multipartId string) error {
+	srv := s3.New(s.S3Session)
+	_, err := srv.AbortMultipartUpload(&s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(s.BucketName),
+		Key:      aws.String(key),
+		UploadId: aws.String(multipartId),
+	})
+
+	return err
+}
+
+// DeleteImage deletes the file from the Bucket.
+func (s *s3FileRepository) DeleteImage(key string) error {
+	srv := s3.New(s.S3Session)
+	_, err := srv.DeleteObject(&s3.DeleteObjectInput{
+		Bucket: aws.String(s.BucketName),
+		Key:    aws.String(key),
+	})
+
+	return err
+}
+
+func (s *s3FileRepository) UploadBanner(header *multipart.FileHeader, directory string) (string, error) {
+	return s.uploadResizedHeader(header, directory, 1500)
 }
@@ -0,0 +1,267 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	readyJobsKey      = "jobs:ready"
+	processingJobsKey = "jobs:processing"
+	leasedJobsKey     = "jobs:leases"
+	delayedJobsKey    = "jobs:delayed"
+	deadJobsKey       = "jobs:dead"
+	// jobTTL is how long finished jobs can be looked up
+	jobTTL = 7 * 24 * time.Hour
+)
+
+// redisJobQueue keeps jobs in Redis. Job IDs wait in a list until a
+// worker pops them, retries wait in a sorted set scored by their run time.
+// Popped jobs stay in a processing list with a lease until they are acked,
+// so the jobs of a crashed worker are queued again once their lease expired.
+type redisJobQueue struct {
+	Redis *redis.Client
+}
+
+// NewJobQueue is a factory for initializing Job Queues
+func NewJobQueue(rds *redis.Client) model.JobQueue {
+	return &redisJobQueue{
+		Redis: rds,
+	}
+}
+
+// storedJob includes the fields of the job that are hidden from the API
+type storedJob struct {
+	model.Job
+	UserID  string          `json:"userId"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+func jobKey(id string) string {
+	return fmt.Sprintf("job:%s", id)
+}
+
+// Save stores the job's state
+func (r *redisJobQueue) Save(job *model.Job) error {
+	ctx := context.Background()
+
+	data, err := json.Marshal(&storedJob{Job: *job, UserID: job.UserID, Payload: job.Payload})
+
+	if err != nil {
+		return apperrors.NewInternal()
+	}
+
+	if err := r.Redis.Set(ctx, jobKey(job.ID), data, jobTTL).Err(); err != nil {
+		log.Printf("Could not save job: %v. Reason: %v\n", job.ID, err)
+		return apperrors.NewInternal()
+	}
+
+	return nil
+}
+
+// Get returns the job with the given ID
+func (r *redisJobQueue) Get(id string) (*model.Job, error) {
+	ctx := context.Background()
+
+	data, err := r.Redis.Get(ctx, jobKey(id)).Bytes()
+
+	if err == redis.Nil {
+		return nil, apperrors.NewNotFound("job", id)
+	}
+
+	if err != nil {
+		log.Printf("Could not get job: %v. Reason: %v\n", id, err)
+		return nil, apperrors.NewInternal()
+	}
+
+	stored := &storedJob{}
+	if err := json.Unmarshal(data, stored); err != nil {
+		return nil, apperrors.NewNotFound("job", id)
+	}
+
+	job := stored.Job
+	job.UserID = stored.UserID
+	job.Payload = stored.Payload
+
+	return &job, nil
+}
+
+// Push adds the job to the ready queue
+func (r *redisJobQueue) Push(job *model.Job) error {
+	ctx := context.Background()
+
+	if err := r.Redis.LPush(ctx, readyJobsKey, job.ID).Err(); err != nil {
+		log.Printf("Could not push job: %v. Reason: %v\n", job.ID, err)
+		return apperrors.NewInternal()
+	}
+
+	return nil
+}
+
+// Schedule delays the job until its RunAt time
+func (r *redisJobQueue) Schedule(job *model.Job) error {
+	ctx := context.Background()
+
+	err := r.Redis.ZAdd(ctx, delayedJobsKey, &redis.Z{
+		Score:  float64(job.RunAt.Unix()),
+		Member: job.ID,
+	}).Err()
+
+	if err != nil {
+		log.Printf("Could not schedule job: %v. Reason: %v\n", job.ID, err)
+		return apperrors.NewInternal()
+	}
+
+	return nil
+}
+
+// requeueExpiredScript gives processing jobs without a lease one, as the
+// worker might have crashed right after popping them, and moves the jobs
+// whose lease expired back to the ready queue
+var requeueExpiredScript = redis.NewScript(`
+for _, id in ipairs(redis.call("LRANGE", KEYS[1], 0, -1)) do
+	redis.call("ZADD", KEYS[2], "NX", ARGV[2], id)
+end
+local expired = redis.call("ZRANGEBYSCORE", KEYS[2], "-inf", ARGV[1])
+for _, id in ipairs(expired) do
+	if redis.call("LREM", KEYS[1], 0, id) > 0 then
+		redis.call("LPUSH", KEYS[3], id)
+	end
+	redis.call("ZREM", KEYS[2], id)
+end
+return #expired
+`)
+
+// Pop moves the due delayed jobs and the jobs with an expired lease to the
+// ready queue and waits up to the timeout for the next ready job. The job
+// is leased for the visibility timeout. It returns nil if no job is ready.
+func (r *redisJobQueue) Pop(timeout, visibility time.Duration) (*model.Job, error) {
+	ctx := context.Background()
+	now := time.Now()
+
+	due, err := r.Redis.ZRangeByScore(ctx, delayedJobsKey, &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   strconv.FormatInt(now.Unix(), 10),
+		Count: 100,
+	}).Result()
+
+	if err != nil {
+		log.Printf("Could not get delayed jobs. Reason: %v\n", err)
+		return nil, apperrors.NewInternal()
+	}
+
+	for _, id := range due {
+		// only the worker that removes the job from the set moves it
+		if removed, err := r.Redis.ZRem(ctx, delayedJobsKey, id).Result(); err == nil && removed == 1 {
+			r.Redis.LPush(ctx, readyJobsKey, id)
+		}
+	}
+
+	err = requeueExpiredScript.Run(ctx, r.Redis,
+		[]string{processingJobsKey, leasedJobsKey, readyJobsKey},
+		now.Unix(), now.Add(visibility).Unix(),
+	).Err()
+
+	if err != nil {
+		log.Printf("Could not requeue expired jobs. Reason: %v\n", err)
+		return nil, apperrors.NewInternal()
+	}
+
+	id, err := r.Redis.BRPopLPush(ctx, readyJobsKey, processingJobsKey, timeout).Result()
+
+	if err == redis.Nil {
+		return nil, nil
+	}
+
+	if err != nil {
+		log.Printf("Could not pop job. Reason: %v\n", err)
+		return nil, apperrors.NewInternal()
+	}
+
+	if err := r.Extend(id, visibility); err != nil {
+		return nil, err
+	}
+
+	job, err := r.Get(id)
+
+	// the job expired while it was queued
+	if apperrors.Status(err) == http.StatusNotFound {
+		return nil, r.Ack(id)
+	}
+
+	return job, err
+}
+
+// Extend renews the lease of the processing job
+func (r *redisJobQueue) Extend(id string, visibility time.Duration) error {
+	ctx := context.Background()
+
+	err := r.Redis.ZAdd(ctx, leasedJobsKey, &redis.Z{
+		Score:  float64(time.Now().Add(visibility).Unix()),
+		Member: id,
+	}).Err()
+
+	if err != nil {
+		log.Printf("Could not lease job: %v. Reason: %v\n", id, err)
+		return apperrors.NewInternal()
+	}
+
+	return nil
+}
+
+// Ack removes the job from the processing jobs once it got handled
+func (r *redisJobQueue) Ack(id string) error {
+	ctx := context.Background()
+
+	_, err := r.Redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.LRem(ctx, processingJobsKey, 0, id)
+		pipe.ZRem(ctx, leasedJobsKey, id)
+		return nil
+	})
+
+	if err != nil {
+		log.Printf("Could not ack job: %v. Reason: %v\n", id, err)
+		return apperrors.NewInternal()
+	}
+
+	return nil
+}
+
+// DeadLetter moves the job to the dead letter queue
+func (r *redisJobQueue) DeadLetter(job *model.Job) error {
+	ctx := context.Background()
+
+	if err := r.Redis.LPush(ctx, deadJobsKey, job.ID).Err(); err != nil {
+		log.Printf("Could not dead letter job: %v. Reason: %v\n", job.ID, err)
+		return apperrors.NewInternal()
+	}
+
+	return nil
+}
+
+// PopDead removes the oldest job from the dead letter queue.
+// It returns nil if the queue is empty.
+func (r *redisJobQueue) PopDead() (*model.Job, error) {
+	ctx := context.Background()
+
+	id, err := r.Redis.RPop(ctx, deadJobsKey).Result()
+
+	if err == redis.Nil {
+		return nil, nil
+	}
+
+	if err != nil {
+		log.Printf("Could not pop dead job. Reason: %v\n", err)
+		return nil, apperrors.NewInternal()
+	}
+
+	return r.Get(id)
+}
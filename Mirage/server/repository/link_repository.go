@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"log"
+	"time"
+)
+
+// redisLinkRepository caches link cards in Redis
+type redisLinkRepository struct {
+	Redis *redis.Client
+}
+
+// NewLinkRepository is a factory for initializing Link Repositories
+func NewLinkRepository(rds *redis.Client) model.LinkRepository {
+	return &redisLinkRepository{
+		Redis: rds,
+	}
+}
+
+func linkKey(url string) string {
+	hash := sha256.Sum256([]byte(url))
+	return fmt.Sprintf("link_card:%s", hex.EncodeToString(hash[:]))
+}
+
+// Get returns the cached card of the url
+func (r *redisLinkRepository) Get(url string) (*model.Card, error) {
+	ctx := context.Background()
+
+	data, err := r.Redis.Get(ctx, linkKey(url)).Bytes()
+
+	if err == redis.Nil {
+		return nil, apperrors.NewNotFound("card", url)
+	}
+
+	if err != nil {
+		log.Printf("Could not get card for url: %v. Reason: %v\n", url, err)
+		return nil, apperrors.NewInternal()
+	}
+
+	// the link could not be unfurled
+	if string(data) == "null" {
+		return nil, nil
+	}
+
+	card := &model.Card{}
+	if err := json.Unmarshal(data, card); err != nil {
+		return nil, apperrors.NewNotFound("card", url)
+	}
+
+	return card, nil
+}
+
+// Set caches the card of the url. A nil card caches that the url has no card.
+func (r *redisLinkRepository) Set(url string, card *model.Card, ttl time.Duration) error {
+	ctx := context.Background()
+
+	data, err := json.Marshal(card)
+
+	if err != nil {
+		return apperrors.NewInternal()
+	}
+
+	if err := r.Redis.Set(ctx, linkKey(url), data, ttl).Err(); err != nil {
+		log.Printf("Could not cache card for url: %v. Reason: %v\n", url, err)
+		return apperrors.NewInternal()
+	}
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"github.com/sentrionic/mirage/model"
+	"sync"
+	"time"
+)
+
+// memoryRateLimiter is an in-memory implementation of the
+// service layer RateLimiter. It is meant for tests and
+// single instance development setups.
+type memoryRateLimiter struct {
+	mu     sync.Mutex
+	clock  func() time.Time
+	hits   map[string]memoryWindow
+	locked map[string]time.Time
+}
+
+type memoryWindow struct {
+	count int64
+	end   time.Time
+}
+
+// NewMemoryRateLimiter is a factory for initializing in-memory Rate Limiters.
+// The clock defaults to time.Now.
+func NewMemoryRateLimiter(clock func() time.Time) model.RateLimiter {
+	if clock == nil {
+		clock = time.Now
+	}
+
+	return &memoryRateLimiter{
+		clock:  clock,
+		hits:   make(map[string]memoryWindow),
+		locked: make(map[string]time.Time),
+	}
+}
+
+func (r *memoryRateLimiter) Hit(key string, window time.Duration) (int64, time.Duration, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.clock()
+	w, ok := r.hits[key]
+
+	if !ok || !now.Before(w.end) {
+		w = memoryWindow{end: now.Add(window)}
+	}
+
+	w.count++
+	r.hits[key] = w
+
+	return w.count, w.end.Sub(now), nil
+}
+
+func (r *memoryRateLimiter) Lock(key string, d time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.locked[key] = r.clock().Add(d)
+
+	return nil
+}
+
+func (r *memoryRateLimiter) LockedFor(key string) (time.Duration, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	until, ok := r.locked[key]
+
+	if !ok {
+		return 0, nil
+	}
+
+	remaining := until.Sub(r.clock())
+
+	if remaining <= 0 {
+		delete(r.locked, key)
+		return 0, nil
+	}
+
+	return remaining, nil
+}
+
+func (r *memoryRateLimiter) Reset(key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.hits, key)
+	delete(r.locked, key)
+
+	return nil
+}
@@ -3,11 +3,14 @@ package repository
 import (
 	"database/sql"
 	"errors"
+	"github.com/lib/pq"
 	"github.com/sentrionic/mirage/model"
 	"github.com/sentrionic/mirage/model/apperrors"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"log"
 	"strings"
+	"time"
 )
 
 // postRepository is data/repository implementation
@@ -45,6 +48,69 @@ func (r *postRepository) FindByID(id string) (*model.Post, error) {
 	return post, nil
 }
 
+// FindByIDs returns the posts with the given IDs in no particular order
+func (r *postRepository) FindByIDs(ids []string) (*[]model.Post, error) {
+	var posts []model.Post
+
+	if len(ids) == 0 {
+		return &posts, nil
+	}
+
+	err := r.DB.
+		Preload("Likes").
+		Preload("Retweets").
+		Preload("File").
+		Preload("User.Followers").
+		Where("id IN ?", ids).
+		Find(&posts).
+		Error
+
+	return &posts, err
+}
+
+// ActivityEntries returns the posts and retweets of the users
+// after the given entry, newest first
+func (r *postRepository) ActivityEntries(actorIds []string, before *model.TimelineEntry, limit int) ([]model.TimelineEntry, error) {
+	var entries []model.TimelineEntry
+
+	if len(actorIds) == 0 {
+		return entries, nil
+	}
+
+	err := r.DB.Raw(`
+		SELECT * FROM (
+			SELECT id AS post_id, user_id AS actor_id, created_at
+			FROM posts
+			WHERE user_id IN @actors AND created_at <= @at
+			UNION ALL
+			SELECT post_id, user_id AS actor_id, created_at
+			FROM retweets
+			WHERE user_id IN @actors AND created_at <= @at
+		) e
+		WHERE (created_at, post_id, actor_id) < (@at, @post, @actor)
+		ORDER BY created_at DESC, post_id DESC, actor_id DESC
+		LIMIT @limit
+	`, append(entryKeyset(before), sql.Named("actors", actorIds), sql.Named("limit", limit))...).
+		Scan(&entries).
+		Error
+
+	return entries, err
+}
+
+// entryKeyset returns the position a page of timeline entries starts after
+// as the named arguments at, post and actor. The first page starts now.
+func entryKeyset(before *model.TimelineEntry) []interface{} {
+	if before == nil {
+		before = &model.TimelineEntry{CreatedAt: time.Now()}
+	}
+
+	return []interface{}{
+		sql.Named("at", before.CreatedAt),
+		sql.Named("post", before.PostID),
+		sql.Named("actor", before.ActorID),
+	}
+}
+
 // Create inserts the post in the DB
 func (r *postRepository) Create(post *model.Post) (*model.Post, error) {
 	if result := r.DB.Create(&post); result.Error != nil {
@@ -55,8 +121,137 @@ func (r *postRepository) Create(post *model.Post) (*model.Post, error) {
 	return post, nil
 }
 
+// fileHashLock is the advisory lock class that serializes deleting,
+// reusing and cleaning up the objects of a content hash
+const fileHashLock = 1
+
+func lockFileHash(tx *gorm.DB, hash string) error {
+	return tx.Exec("SELECT pg_advisory_xact_lock(?, hashtext(?))", fileHashLock, hash).Error
+}
+
+// Delete removes the post. If its file was the last one using its content
+// hash, the objects are recorded as orphaned for the media cleanup.
 func (r *postRepository) Delete(post *model.Post) error {
-	return r.DB.Delete(&post).Error
+	file := post.File
+
+	if file == nil || file.Hash == "" {
+		return r.DB.Delete(&post).Error
+	}
+
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockFileHash(tx, file.Hash); err != nil {
+			return err
+		}
+
+		if err := tx.Delete(&post).Error; err != nil {
+			return err
+		}
+
+		var references int64
+		err := tx.Model(&model.File{}).
+			Where("hash = ? AND post_id <> ?", file.Hash, post.ID).
+			Count(&references).Error
+
+		if err != nil || references > 0 {
+			return err
+		}
+
+		return tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(&model.OrphanedMedia{
+			Hash:      file.Hash,
+			Filenames: file.ObjectNames(),
+		}).Error
+	})
+}
+
+// UpdateFile only saves the alt text of the file, so that it doesn't
+// overwrite the variants added by a resize job in the meantime
+func (r *postRepository) UpdateFile(file *model.File) error {
+	return r.DB.
+		Model(&model.File{}).
+		Where("id = ?", file.ID).
+		Update("alt_text", file.AltText).
+		Error
+}
+
+// SetCard sets the link card of the post
+func (r *postRepository) SetCard(id string, card *model.Card) error {
+	return r.DB.Model(&model.Post{}).Where("id = ?", id).Update("card", card).Error
+}
+
+// FindFileByHash returns a file with the given content hash
+func (r *postRepository) FindFileByHash(hash string) (*model.File, error) {
+	file := &model.File{}
+
+	if err := r.DB.Where("hash = ?", hash).First(file).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperrors.NewNotFound("file", hash)
+		}
+		return nil, apperrors.NewInternal()
+	}
+
+	return file, nil
+}
+
+// SetFileVariants sets the variants of all files with the given content hash
+func (r *postRepository) SetFileVariants(hash string, variants model.ImageVariants) error {
+	return r.DB.Model(&model.File{}).Where("hash = ?", hash).Update("variants", variants).Error
+}
+
+// ClaimFileHash keeps the orphaned objects of the content hash from being
+// removed, as a new file is about to use them
+func (r *postRepository) ClaimFileHash(hash string) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockFileHash(tx, hash); err != nil {
+			return err
+		}
+
+		return tx.Where("hash = ?", hash).Delete(&model.OrphanedMedia{}).Error
+	})
+}
+
+// OrphanedMedia returns the objects that got orphaned before the given time
+func (r *postRepository) OrphanedMedia(before time.Time) (*[]model.OrphanedMedia, error) {
+	var media []model.OrphanedMedia
+
+	err := r.DB.
+		Where("created_at < ?", before).
+		Find(&media).Error
+
+	return &media, err
+}
+
+// DeleteOrphanedMedia calls deleteObjects with the filenames of the orphaned
+// objects while holding the lock of their hash and forgets them afterwards.
+// Objects that got claimed again or are used by a file are not deleted.
+func (r *postRepository) DeleteOrphanedMedia(hash string, deleteObjects model.ObjectDeleter) (bool, error) {
+	deleted := false
+
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockFileHash(tx, hash); err != nil {
+			return err
+		}
+
+		var media []model.OrphanedMedia
+		if err := tx.Where("hash = ?", hash).Limit(1).Find(&media).Error; err != nil || len(media) == 0 {
+			return err
+		}
+
+		var references int64
+		if err := tx.Model(&model.File{}).Where("hash = ?", hash).Count(&references).Error; err != nil {
+			return err
+		}
+
+		if references == 0 {
+			if err := deleteObjects(media[0].Filenames); err != nil {
+				return err
+			}
+			deleted = true
+		}
+
+		return tx.Where("hash = ?", hash).Delete(&model.OrphanedMedia{}).Error
+	})
+
+	return deleted && err == nil, err
 }
 
 func (r *postRepository) AddLike(post *model.Post, uid string) error {
@@ -91,124 +286,289 @@ func (r *postRepository) RemoveRetweet(post *model.Post, uid string) error {
 	return err
 }
 
-func (r *postRepository) Feed(userId, cursor string) (*[]model.Post, error) {
+// FeedEntries returns the posts and retweets of the user and
+// their followees after the given entry, newest first
+func (r *postRepository) FeedEntries(userId string, before *model.TimelineEntry, limit int) ([]model.TimelineEntry, error) {
+	var entries []model.TimelineEntry
+
+	err := r.DB.Raw(`
+		WITH actors AS (
+			SELECT @id AS id
+			UNION
+			SELECT followee_id FROM follows WHERE follower_id = @id
+		)
+		SELECT * FROM (
+			SELECT id AS post_id, user_id AS actor_id, created_at
+			FROM posts
+			WHERE user_id IN (SELECT id FROM actors) AND created_at <= @at
+			UNION ALL
+			SELECT post_id, user_id AS actor_id, created_at
+			FROM retweets
+			WHERE user_id IN (SELECT id FROM actors) AND created_at <= @at
+		) e
+		WHERE (created_at, post_id, actor_id) < (@at, @post, @actor)
+		ORDER BY created_at DESC, post_id DESC, actor_id DESC
+		LIMIT @limit
+	`, append(entryKeyset(before), sql.Named("id", userId), sql.Named("limit", limit))...).
+		Scan(&entries).
+		Error
+
+	return entries, err
+}
+
+// FeedActivity returns all entries of the posts in the home timeline
+// of the user: the posts and retweets of the user and their followees
+func (r *postRepository) FeedActivity(userId string, postIds []string) ([]model.TimelineEntry, error) {
+	var entries []model.TimelineEntry
+
+	if len(postIds) == 0 {
+		return entries, nil
+	}
+
+	err := r.DB.Raw(`
+		WITH actors AS (
+			SELECT @id AS id
+			UNION
+			SELECT followee_id FROM follows WHERE follower_id = @id
+		)
+		SELECT id AS post_id, user_id AS actor_id, created_at
+		FROM posts
+		WHERE id IN @posts AND user_id IN (SELECT id FROM actors)
+		UNION ALL
+		SELECT post_id, user_id AS actor_id, created_at
+		FROM retweets
+		WHERE post_id IN @posts AND user_id IN (SELECT id FROM actors)
+		ORDER BY created_at DESC, post_id DESC, actor_id DESC
+	`, sql.Named("id", userId), sql.Named("posts", postIds)).
+		Scan(&entries).
+		Error
+
+	return entries, err
+}
+
+// FeedCandidates returns the posts of the user's home timeline that
+// were posted or retweeted since the given time, newest first
+func (r *postRepository) FeedCandidates(userId string, since time.Time, limit int) (*[]model.Post, error) {
 	var posts []model.Post
-	query := r.DB.
+	err := r.DB.
 		Preload("Likes").
 		Preload("Retweets").
 		Preload("File").
 		Preload("User.Followers").
-		Preload("User.Followers").
-		Joins("LEFT JOIN followers on \"posts\".user_id = followers.user_id").
-		Joins("LEFT JOIN retweets r on \"posts\".id = r.post_id").
-		Where(`
-			("posts".user_id = @id
-			OR followers.follower_id = @id
-			OR r.user_id = @id
-			OR r.user_id IN (
-				SELECT id
-				from "users"
-				join followee f on "users".id = f.followee_id
-				WHERE f.user_id = @id
-			))
-		`, sql.Named("id", userId))
+		Where(`"posts".id IN (
+			SELECT p.id
+			FROM posts p
+			LEFT JOIN follows f on p.user_id = f.followee_id
+			LEFT JOIN retweets r on p.id = r.post_id
+			WHERE (p.user_id = @id
+				OR f.follower_id = @id
+				OR r.user_id = @id
+				OR r.user_id IN (SELECT followee_id FROM follows WHERE follower_id = @id))
+			AND (p.created_at >= @since OR r.created_at >= @since)
+		)`, sql.Named("id", userId), sql.Named("since", since)).
+		Order(`"posts".created_at DESC`).
+		Limit(limit).
+		Find(&posts).
+		Error
 
-	if cursor != "" {
-		cursor = strings.Replace(cursor, " ", "+", 1)
-		query.
-			Where("\"posts\".created_at::timestamptz < ?", cursor)
+	return &posts, err
+}
+
+// AuthorAffinity counts how often the user liked or retweeted
+// posts of each author and whether they follow the author
+func (r *postRepository) AuthorAffinity(userId string, authorIds []string) (map[string]model.AuthorAffinity, error) {
+	affinity := make(map[string]model.AuthorAffinity)
+
+	if len(authorIds) == 0 {
+		return affinity, nil
 	}
 
-	query.
-		Order("(CASE when r.created_at notnull THEN r.created_at ELSE \"posts\".created_at end) DESC").
-		Limit(model.LIMIT + 1).
-		Find(&posts)
+	var interactions []struct {
+		AuthorID     string
+		Interactions int64
+	}
 
-	return &posts, query.Error
+	err := r.DB.Raw(`
+		SELECT p.user_id AS author_id, COUNT(*) AS interactions
+		FROM (
+			SELECT post_id FROM post_likes WHERE user_id = @id
+			UNION ALL
+			SELECT post_id FROM retweets WHERE user_id = @id
+		) i
+		JOIN posts p on p.id = i.post_id
+		WHERE p.user_id IN @authors
+		GROUP BY p.user_id
+	`, sql.Named("id", userId), sql.Named("authors", authorIds)).
+		Scan(&interactions).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	var following []string
+	err = r.DB.Model(&model.Follow{}).
+		Where("follower_id = ? AND followee_id IN ?", userId, authorIds).
+		Pluck("followee_id", &following).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, i := range interactions {
+		affinity[i.AuthorID] = model.AuthorAffinity{AuthorID: i.AuthorID, Interactions: i.Interactions}
+	}
+
+	for _, id := range following {
+		a := affinity[id]
+		a.AuthorID = id
+		a.Following = true
+		affinity[id] = a
+	}
+
+	return affinity, nil
 }
 
-func (r *postRepository) List(id, cursor string) (*[]model.Post, error) {
-	var posts []model.Post
+// LikeEntries returns the posts the user liked after the given entry, newest like first
+func (r *postRepository) LikeEntries(userId string, before *model.TimelineEntry, limit int) ([]model.TimelineEntry, error) {
+	var entries []model.TimelineEntry
+
+	err := r.DB.Model(&model.Like{}).
+		Select("post_id, user_id AS actor_id, created_at, ?::text AS reason", model.ReasonLikedBy).
+		Where("user_id = @id AND created_at <= @at AND (created_at, post_id, user_id) < (@at, @post, @actor)",
+			append(entryKeyset(before), sql.Named("id", userId))...).
+		Order("created_at DESC, post_id DESC").
+		Limit(limit).
+		Scan(&entries).
+		Error
 
-	query := r.DB.
+	return entries, err
+}
+
+// GetPostsForHashtags returns the newest posts created before the cursor
+// that have all or any of the query's tags
+func (r *postRepository) GetPostsForHashtags(query model.HashtagQuery, cursor string) (*[]model.Post, error) {
+	posts := &[]model.Post{}
+
+	tx := r.DB.
 		Preload("Likes").
 		Preload("Retweets").
 		Preload("File").
 		Preload("User.Followers").
-		Preload("User.Followers").
-		Joins("LEFT JOIN retweets r on \"posts\".id = r.post_id").
-		Where("(\"posts\".user_id = @id OR r.user_id IN (SELECT id from \"users\" join followee f on \"users\".id = f.followee_id WHERE f.user_id = @id))", sql.Named("id", id))
+		Where(hashtagCondition(query), pq.StringArray(query.Tags))
 
 	if cursor != "" {
 		cursor = strings.Replace(cursor, " ", "+", 1)
-		query.
-			Where("\"posts\".created_at::timestamptz < ?", cursor)
+		tx.Where("\"posts\".created_at::timestamptz < ?", cursor)
 	}
 
-	query.
-		Order("(CASE when r.created_at notnull THEN r.created_at ELSE \"posts\".created_at end) DESC").
+	tx.
+		Order("\"posts\".created_at DESC").
 		Limit(model.LIMIT + 1).
 		Find(&posts)
 
-	return &posts, query.Error
+	return posts, tx.Error
 }
 
-func (r *postRepository) Likes(id, cursor string) (*[]model.Post, error) {
-	var posts []model.Post
+// CountHashtagPosts returns the number of posts that have all or any of the query's tags,
+// created after the query's Since time if it is set
+func (r *postRepository) CountHashtagPosts(query model.HashtagQuery) (int64, error) {
+	var count int64
 
-	query := r.DB.
-		Preload("Likes").
-		Preload("Retweets").
-		Preload("File").
-		Preload("User.Followers").
-		Preload("User.Followers").
-		Joins("LEFT JOIN post_likes pl on \"posts\".id = pl.post_id").
-		Where("pl.user_id = ?", id)
+	tx := r.DB.
+		Model(&model.Post{}).
+		Where(hashtagCondition(query), pq.StringArray(query.Tags))
 
-	if cursor != "" {
-		cursor = strings.Replace(cursor, " ", "+", 1)
-		query.
-			Where("created_at::timestamptz < ?", cursor)
+	if !query.Since.IsZero() {
+		tx = tx.Where("created_at > ?", query.Since)
 	}
 
-	query.
-		Order("created_at DESC").
-		Limit(model.LIMIT + 1).
-		Find(&posts)
+	err := tx.Count(&count).Error
 
-	return &posts, query.Error
+	return count, err
 }
 
-func (r *postRepository) GetPostsForHashtag(term, cursor string) (*[]model.Post, error) {
-	posts := &[]model.Post{}
+// CountHashtagPostsBatch counts the posts of every query like CountHashtagPosts
+// in a single scan of the posts that have any of the queries' tags
+func (r *postRepository) CountHashtagPostsBatch(queries []model.HashtagQuery) ([]int64, error) {
+	counts := make([]int64, len(queries))
 
-	if !strings.HasPrefix(term, "#") {
-		term = "#" + term
+	if len(queries) == 0 {
+		return counts, nil
 	}
 
-	query := r.DB.
-		Preload("Likes").
-		Preload("Retweets").
-		Preload("File").
-		Preload("User.Followers").
-		Preload("User.Followers").
-		Joins("LEFT JOIN users u ON u.id = \"posts\".user_id").
-		Where("@term ILIKE ANY (\"posts\".hash_tags)", sql.Named("term", strings.ToLower(term)))
+	columns := make([]string, len(queries))
+	args := make([]interface{}, 0, 2*len(queries)+2)
+	tags := make([]string, 0)
+	since := queries[0].Since
 
-	if cursor != "" {
-		cursor = strings.Replace(cursor, " ", "+", 1)
-		query.Where("created_at::timestamptz < ?", cursor)
+	for i, query := range queries {
+		columns[i] = "COUNT(*) FILTER (WHERE " + hashtagCondition(query) + " AND \"posts\".created_at > ?)"
+		args = append(args, pq.StringArray(query.Tags), query.Since)
+		tags = append(tags, query.Tags...)
+
+		if query.Since.Before(since) {
+			since = query.Since
+		}
 	}
 
-	query.
-		Order("created_at DESC").
-		Limit(model.LIMIT + 1).
-		Find(&posts)
+	args = append(args, pq.StringArray(tags), since)
 
-	return posts, query.Error
+	row := r.DB.Raw(
+		"SELECT "+strings.Join(columns, ", ")+" FROM posts WHERE \"posts\".hash_tags && ?::text[] AND \"posts\".created_at > ?",
+		args...,
+	).Row()
+
+	dest := make([]interface{}, len(counts))
+	for i := range counts {
+		dest[i] = &counts[i]
+	}
+
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+
+	return counts, nil
 }
 
-func (r *postRepository) Media(id, cursor string) (*[]model.Post, error) {
+// RelatedHashtags returns up to limit hashtags that were used together with the tag
+// since the given time, the ones used together the most first
+func (r *postRepository) RelatedHashtags(tag string, since time.Time, limit int) (*[]model.RelatedHashtag, error) {
+	var related []model.RelatedHashtag
+
+	err := r.DB.Raw(`
+		SELECT t.tag AS hashtag, COUNT(*) AS count
+		FROM posts p, unnest(p.hash_tags) AS t(tag)
+		WHERE p.hash_tags @> @tags::text[] AND p.created_at >= @since AND t.tag <> @tag
+		GROUP BY t.tag
+		ORDER BY count DESC, hashtag
+		LIMIT @limit
+	`,
+		sql.Named("tags", pq.StringArray{tag}),
+		sql.Named("tag", tag),
+		sql.Named("since", since),
+		sql.Named("limit", limit),
+	).
+		Scan(&related).
+		Error
+
+	return &related, err
+}
+
+// hashtagCondition returns the condition matching the posts
+// that contain all or overlap with the given array of tags.
+// Both operators compare whole tags and use the GIN index.
+func hashtagCondition(query model.HashtagQuery) string {
+	if query.MatchAll {
+		return "\"posts\".hash_tags @> ?::text[]"
+	}
+	return "\"posts\".hash_tags && ?::text[]"
+}
+
+// Media returns the newest posts with a file of the user created before the
+// cursor and, if it is set, not before the given time
+func (r *postRepository) Media(id, cursor string, since time.Time) (*[]model.Post, error) {
 	var posts []model.Post
 
 	query := r.DB.
@@ -216,20 +576,93 @@ func (r *postRepository) Media(id, cursor string) (*[]model.Post, error) {
 		Preload("Retweets").
 		Preload("File").
 		Preload("User.Followers").
-		Preload("User.Followers").
 		Joins("LEFT JOIN files f on \"posts\".id = f.post_id").
 		Where("\"posts\".user_id = ? AND f IS NOT NULL", id)
 
 	if cursor != "" {
 		cursor = strings.Replace(cursor, " ", "+", 1)
 		query.
-			Where("created_at::timestamptz < ?", cursor)
+			Where("\"posts\".created_at::timestamptz < ?", cursor)
+	}
+
+	if !since.IsZero() {
+		query.
+			Where("\"posts\".created_at >= ?", since)
 	}
 
 	query.
-		Order("created_at DESC").
+		Order("\"posts\".created_at DESC").
 		Limit(model.LIMIT + 1).
 		Find(&posts)
 
 	return &posts, query.Error
 }
+
+// HashtagsSince returns the hashtags and creation time
+// of all posts with hashtags created after the given time
+func (r *postRepository) HashtagsSince(since time.Time) (*[]model.Post, error) {
+	var posts []model.Post
+
+	err := r.DB.
+		Select("id", "hash_tags", "created_at").
+		Where("created_at >= ?", since).
+		Where("cardinality(hash_tags) > 0").
+		Order("created_at ASC").
+		Find(&posts).Error
+
+	return &posts, err
+}
+
+// calendarFormats are the to_char formats of the calendar periods
+var calendarFormats = map[model.CalendarUnit]string{
+	model.CalendarMonth: "YYYY-MM",
+	model.CalendarDay:   "YYYY-MM-DD",
+}
+
+// PostCalendar returns the number of posts the user created in every month or day
+// from the given time until the other, in UTC. Periods without posts are left out.
+func (r *postRepository) PostCalendar(userId string, unit model.CalendarUnit, from, to time.Time) ([]model.CalendarBucket, error) {
+	var buckets []model.CalendarBucket
+
+	err := r.DB.Raw(`
+		SELECT to_char(date_trunc(@unit, created_at AT TIME ZONE 'UTC'), @format) AS period, COUNT(*) AS count
+		FROM posts
+		WHERE user_id = @id AND created_at >= @from AND created_at < @to
+		GROUP BY period
+		ORDER BY period
+	`,
+		sql.Named("unit", string(unit)),
+		sql.Named("format", calendarFormats[unit]),
+		sql.Named("id", userId),
+		sql.Named("from", from),
+		sql.Named("to", to),
+	).
+		Scan(&buckets).
+		Error
+
+	return buckets, err
+}
+
+// OnThisDay returns up to limit of the user's posts created before the given time
+// on the given month and days of any year in UTC, newest first. The month and day
+// filters can't use the index on created_at. They match the expressions of the
+// idx_posts_user_month_day index instead, which has to be kept in step with them.
+func (r *postRepository) OnThisDay(userId string, month time.Month, days []int, before time.Time, limit int) (*[]model.Post, error) {
+	var posts []model.Post
+
+	err := r.DB.
+		Preload("Likes").
+		Preload("Retweets").
+		Preload("File").
+		Preload("User.Followers").
+		Preload("User.Followee").
+		Where("user_id = ? AND created_at < ?", userId, before).
+		Where("EXTRACT(MONTH FROM created_at AT TIME ZONE 'UTC') = ?", int(month)).
+		Where("EXTRACT(DAY FROM created_at AT TIME ZONE 'UTC') IN ?", days).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&posts).
+		Error
+
+	return &posts, err
+}
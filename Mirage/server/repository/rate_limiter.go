@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"log"
+	"time"
+)
+
+// redisRateLimiter is a Redis implementation
+// of the service layer RateLimiter
+type redisRateLimiter struct {
+	Redis *redis.Client
+}
+
+// NewRateLimiter is a factory for initializing Redis Rate Limiters
+func NewRateLimiter(rds *redis.Client) model.RateLimiter {
+	return &redisRateLimiter{
+		Redis: rds,
+	}
+}
+
+func hitsKey(key string) string {
+	return fmt.Sprintf("rate_limit:%s", key)
+}
+
+func lockKey(key string) string {
+	return fmt.Sprintf("lock:%s", key)
+}
+
+// Hit increments the counter of the key. The window starts with the first hit.
+func (r *redisRateLimiter) Hit(key string, window time.Duration) (int64, time.Duration, error) {
+	ctx := context.Background()
+
+	var incr *redis.IntCmd
+	var ttl *redis.DurationCmd
+
+	_, err := r.Redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		incr = pipe.Incr(ctx, hitsKey(key))
+		ttl = pipe.PTTL(ctx, hitsKey(key))
+		return nil
+	})
+
+	if err != nil {
+		log.Printf("Could not count hit for key: %v. Reason: %v\n", key, err)
+		return 0, 0, apperrors.NewInternal()
+	}
+
+	remaining := ttl.Val()
+
+	// A negative TTL means the key has just been created
+	// or its expiry was never set
+	if remaining < 0 {
+		if err := r.Redis.PExpire(ctx, hitsKey(key), window).Err(); err != nil {
+			log.Printf("Could not set window for key: %v. Reason: %v\n", key, err)
+			return 0, 0, apperrors.NewInternal()
+		}
+		remaining = window
+	}
+
+	return incr.Val(), remaining, nil
+}
+
+// Lock blocks the key until the given duration passed
+func (r *redisRateLimiter) Lock(key string, d time.Duration) error {
+	ctx := context.Background()
+
+	if err := r.Redis.Set(ctx, lockKey(key), 1, d).Err(); err != nil {
+		log.Printf("Could not lock key: %v. Reason: %v\n", key, err)
+		return apperrors.NewInternal()
+	}
+
+	return nil
+}
+
+// LockedFor returns the remaining lock time of the key
+func (r *redisRateLimiter) LockedFor(key string) (time.Duration, error) {
+	ctx := context.Background()
+
+	ttl, err := r.Redis.PTTL(ctx, lockKey(key)).Result()
+
+	if err != nil {
+		log.Printf("Could not get lock for key: %v. Reason: %v\n", key, err)
+		return 0, apperrors.NewInternal()
+	}
+
+	if ttl < 0 {
+		return 0, nil
+	}
+
+	return ttl, nil
+}
+
+// Reset removes the counter and the lock of the key
+func (r *redisRateLimiter) Reset(key string) error {
+	ctx := context.Background()
+
+	if err := r.Redis.Del(ctx, hitsKey(key), lockKey(key)).Err(); err != nil {
+		log.Printf("Could not reset key: %v. Reason: %v\n", key, err)
+		return apperrors.NewInternal()
+	}
+
+	return nil
+}
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"gorm.io/gorm"
+	"log"
+)
+
+// savedSearchRepository is data/repository implementation
+// of service layer SavedSearchRepository
+type savedSearchRepository struct {
+	DB *gorm.DB
+}
+
+// NewSavedSearchRepository is a factory for initializing Saved Search Repositories
+func NewSavedSearchRepository(db *gorm.DB) model.SavedSearchRepository {
+	return &savedSearchRepository{
+		DB: db,
+	}
+}
+
+// FindByID returns the saved search if it belongs to the given user
+func (r *savedSearchRepository) FindByID(uid, id string) (*model.SavedSearch, error) {
+	search := &model.SavedSearch{}
+
+	if err := r.DB.Where("id = ? AND user_id = ?", id, uid).First(&search).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return search, apperrors.NewNotFound("saved search", id)
+		}
+		return search, apperrors.NewInternal()
+	}
+
+	return search, nil
+}
+
+// FindAllForUser returns all saved searches of the given user, oldest first
+func (r *savedSearchRepository) FindAllForUser(uid string) (*[]model.SavedSearch, error) {
+	var searches []model.SavedSearch
+
+	err := r.DB.
+		Where("user_id = ?", uid).
+		Order("created_at ASC").
+		Find(&searches).Error
+
+	return &searches, err
+}
+
+// Create inserts the saved search in the DB unless the user already saved max searches.
+// The user's row stays locked until the search is inserted, so that
+// concurrent requests can't save more than max searches.
+func (r *savedSearchRepository) Create(search *model.SavedSearch, max int) error {
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT id FROM users WHERE id = ? FOR UPDATE", search.UserID).Error; err != nil {
+			return err
+		}
+
+		var count int64
+		if err := tx.Model(&model.SavedSearch{}).Where("user_id = ?", search.UserID).Count(&count).Error; err != nil {
+			return err
+		}
+
+		if count >= int64(max) {
+			return apperrors.NewBadRequest(fmt.Sprintf("you can save at most %d searches", max))
+		}
+
+		return tx.Create(&search).Error
+	})
+
+	if err != nil {
+		var e *apperrors.Error
+		if errors.As(err, &e) {
+			return err
+		}
+
+		log.Printf("Could not create a saved search for user: %v. Reason: %v\n", search.UserID, err)
+		return apperrors.NewInternal()
+	}
+
+	return nil
+}
+
+// Update saves the name and the last visit of the saved search
+func (r *savedSearchRepository) Update(search *model.SavedSearch) error {
+	err := r.DB.
+		Model(&model.SavedSearch{}).
+		Where("id = ? AND user_id = ?", search.ID, search.UserID).
+		Updates(map[string]interface{}{
+			"name":            search.Name,
+			"last_visited_at": search.LastVisitedAt,
+		}).Error
+
+	if err != nil {
+		log.Printf("Could not update saved search: %v. Reason: %v\n", search.ID, err)
+		return apperrors.NewInternal()
+	}
+
+	return nil
+}
+
+// Delete removes the saved search if it belongs to the given user
+func (r *savedSearchRepository) Delete(uid, id string) error {
+	result := r.DB.
+		Where("id = ? AND user_id = ?", id, uid).
+		Delete(&model.SavedSearch{})
+
+	if result.Error != nil {
+		log.Printf("Could not delete saved search: %v. Reason: %v\n", id, result.Error)
+		return apperrors.NewInternal()
+	}
+
+	if result.RowsAffected == 0 {
+		return apperrors.NewNotFound("saved search", id)
+	}
+
+	return nil
+}
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"log"
+	"time"
+)
+
+// redisSearchHistoryRepository stores the recent searches of every user in a sorted set.
+// The members are the query and kind, so searching again only moves the entry to the top.
+// The score is the time of the last search in milliseconds.
+type redisSearchHistoryRepository struct {
+	Redis *redis.Client
+}
+
+// NewSearchHistoryRepository is a factory for initializing Search History Repositories
+func NewSearchHistoryRepository(rds *redis.Client) model.SearchHistoryRepository {
+	return &redisSearchHistoryRepository{
+		Redis: rds,
+	}
+}
+
+func searchHistoryKey(uid string) string {
+	return fmt.Sprintf("search_history:%s", uid)
+}
+
+// searchHistoryMember is the part of an entry stored as the member
+type searchHistoryMember struct {
+	Query string           `json:"q"`
+	Kind  model.SearchKind `json:"k"`
+}
+
+// Add records the search and removes the oldest ones beyond max
+func (r *redisSearchHistoryRepository) Add(uid string, entry model.SearchHistoryEntry, max int) error {
+	ctx := context.Background()
+	key := searchHistoryKey(uid)
+
+	member, err := json.Marshal(searchHistoryMember{Query: entry.Query, Kind: entry.Kind})
+
+	if err != nil {
+		log.Printf("Could not encode search: %v. Reason: %v\n", key, err)
+		return apperrors.NewInternal()
+	}
+
+	_, err = r.Redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZAdd(ctx, key, &redis.Z{
+			Score:  float64(entry.SearchedAt.UnixNano() / int64(time.Millisecond)),
+			Member: string(member),
+		})
+		pipe.ZRemRangeByRank(ctx, key, 0, int64(-max-1))
+		return nil
+	})
+
+	if err != nil {
+		log.Printf("Could not add search: %v. Reason: %v\n", key, err)
+		return apperrors.NewInternal()
+	}
+
+	return nil
+}
+
+// List returns the recent searches of the user, newest first
+func (r *redisSearchHistoryRepository) List(uid string) ([]model.SearchHistoryEntry, error) {
+	ctx := context.Background()
+	key := searchHistoryKey(uid)
+
+	values, err := r.Redis.ZRevRangeWithScores(ctx, key, 0, -1).Result()
+
+	if err != nil {
+		log.Printf("Could not get search history: %v. Reason: %v\n", key, err)
+		return nil, apperrors.NewInternal()
+	}
+
+	entries := make([]model.SearchHistoryEntry, 0, len(values))
+
+	for _, z := range values {
+		var member searchHistoryMember
+
+		if err := json.Unmarshal([]byte(z.Member.(string)), &member); err != nil {
+			log.Printf("Could not decode search: %v. Reason: %v\n", key, err)
+			continue
+		}
+
+		entries = append(entries, model.SearchHistoryEntry{
+			Query:      member.Query,
+			Kind:       member.Kind,
+			SearchedAt: time.Unix(0, int64(z.Score)*int64(time.Millisecond)).UTC(),
+		})
+	}
+
+	return entries, nil
+}
+
+// Clear removes all recent searches of the user
+func (r *redisSearchHistoryRepository) Clear(uid string) error {
+	ctx := context.Background()
+	key := searchHistoryKey(uid)
+
+	if err := r.Redis.Del(ctx, key).Err(); err != nil {
+		log.Printf("Could not clear search history: %v. Reason: %v\n", key, err)
+		return apperrors.NewInternal()
+	}
+
+	return nil
+}
@@ -0,0 +1,288 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"log"
+	"strconv"
+	"time"
+)
+
+// redisSessionRepository is data/repository implementation
+// of service layer SessionRepository
+type redisSessionRepository struct {
+	Redis *redis.Client
+}
+
+// NewSessionRepository is a factory for initializing Session Repositories
+func NewSessionRepository(rds *redis.Client) model.SessionRepository {
+	return &redisSessionRepository{
+		Redis: rds,
+	}
+}
+
+func sessionKey(id string) string {
+	return fmt.Sprintf("session:%s", id)
+}
+
+func userSessionsKey(uid string) string {
+	return fmt.Sprintf("user_sessions:%s", uid)
+}
+
+func sessionsRevokedKey(uid string) string {
+	return fmt.Sprintf("sessions_revoked:%s", uid)
+}
+
+// FindByID returns the session for the given ID
+func (r *redisSessionRepository) FindByID(id string) (*model.Session, error) {
+	ctx := context.Background()
+
+	values, err := r.Redis.HGetAll(ctx, sessionKey(id)).Result()
+
+	if err != nil {
+		log.Printf("Could not get session: %v. Reason: %v\n", id, err)
+		return nil, apperrors.NewInternal()
+	}
+
+	if len(values) == 0 {
+		return nil, apperrors.NewNotFound("session", id)
+	}
+
+	createdAt, _ := strconv.ParseInt(values["createdAt"], 10, 64)
+	lastSeen, _ := strconv.ParseInt(values["lastSeen"], 10, 64)
+
+	return &model.Session{
+		ID:        id,
+		UserID:    values["userId"],
+		Device:    values["device"],
+		IP:        values["ip"],
+		CreatedAt: time.Unix(createdAt, 0),
+		LastSeen:  time.Unix(lastSeen, 0),
+	}, nil
+}
+
+// Create stores the session and adds it to the user's set of sessions
+func (r *redisSessionRepository) Create(session *model.Session) error {
+	ctx := context.Background()
+
+	_, err := r.Redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, sessionKey(session.ID), sessionValues(session))
+		pipe.Expire(ctx, sessionKey(session.ID), model.SessionMaxAge)
+		pipe.SAdd(ctx, userSessionsKey(session.UserID), session.ID)
+		pipe.Expire(ctx, userSessionsKey(session.UserID), model.SessionMaxAge)
+		return nil
+	})
+
+	if err != nil {
+		log.Printf("Could not create session for user: %v. Reason: %v\n", session.UserID, err)
+		return apperrors.NewInternal()
+	}
+
+	return nil
+}
+
+// touchSessionScript only refreshes a session that still exists,
+// so that a concurrent revoke cannot be undone by a request in flight
+var touchSessionScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 0 then
+	return 0
+end
+redis.call("HSET", KEYS[1], "lastSeen", ARGV[1], "ip", ARGV[2])
+redis.call("EXPIRE", KEYS[1], ARGV[3])
+redis.call("EXPIRE", KEYS[2], ARGV[3])
+return 1
+`)
+
+// Update saves the session's last seen time and IP and extends its lifetime.
+// It returns false without writing anything if the session no longer exists.
+func (r *redisSessionRepository) Update(session *model.Session) (bool, error) {
+	ctx := context.Background()
+
+	updated, err := touchSessionScript.Run(
+		ctx,
+		r.Redis,
+		[]string{sessionKey(session.ID), userSessionsKey(session.UserID)},
+		session.LastSeen.Unix(),
+		session.IP,
+		int(model.SessionMaxAge.Seconds()),
+	).Int()
+
+	if err != nil {
+		log.Printf("Could not update session: %v. Reason: %v\n", session.ID, err)
+		return false, apperrors.NewInternal()
+	}
+
+	return updated == 1, nil
+}
+
+// Delete removes the session of the given user
+func (r *redisSessionRepository) Delete(uid, id string) error {
+	ctx := context.Background()
+
+	_, err := r.Redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, sessionKey(id))
+		pipe.SRem(ctx, userSessionsKey(uid), id)
+		return nil
+	})
+
+	if err != nil {
+		log.Printf("Could not delete session: %v. Reason: %v\n", id, err)
+		return apperrors.NewInternal()
+	}
+
+	return nil
+}
+
+// FindAllForUser returns all sessions of the given user.
+// Expired sessions get removed from the user's set.
+func (r *redisSessionRepository) FindAllForUser(uid string) (*[]model.Session, error) {
+	ctx := context.Background()
+	sessions := make([]model.Session, 0)
+
+	ids, err := r.Redis.SMembers(ctx, userSessionsKey(uid)).Result()
+
+	if err != nil {
+		log.Printf("Could not get sessions for user: %v. Reason: %v\n", uid, err)
+		return nil, apperrors.NewInternal()
+	}
+
+	for _, id := range ids {
+		session, err := r.FindByID(id)
+
+		if err != nil {
+			r.Redis.SRem(ctx, userSessionsKey(uid), id)
+			continue
+		}
+
+		sessions = append(sessions, *session)
+	}
+
+	return &sessions, nil
+}
+
+// DeleteAllForUser removes every session of the given user
+// and marks that all of their sessions got revoked
+func (r *redisSessionRepository) DeleteAllForUser(uid string) error {
+	ctx := context.Background()
+
+	ids, err := r.Redis.SMembers(ctx, userSessionsKey(uid)).Result()
+
+	if err != nil {
+		log.Printf("Could not get sessions for user: %v. Reason: %v\n", uid, err)
+		return apperrors.NewInternal()
+	}
+
+	keys := []string{userSessionsKey(uid)}
+	for _, id := range ids {
+		keys = append(keys, sessionKey(id))
+	}
+
+	// cookies without a session record are at most SessionMaxAge old,
+	// so the marker only has to outlive the ones issued before it
+	_, err = r.Redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, keys...)
+		pipe.Set(ctx, sessionsRevokedKey(uid), time.Now().Unix(), model.SessionMaxAge)
+		return nil
+	})
+
+	if err != nil {
+		log.Printf("Could not delete sessions for user: %v. Reason: %v\n", uid, err)
+		return apperrors.NewInternal()
+	}
+
+	return nil
+}
+
+// RevokedAll reports whether the user revoked all of their sessions
+// within the lifetime of a session
+func (r *redisSessionRepository) RevokedAll(uid string) (bool, error) {
+	ctx := context.Background()
+
+	count, err := r.Redis.Exists(ctx, sessionsRevokedKey(uid)).Result()
+
+	if err != nil {
+		log.Printf("Could not get revoked sessions for user: %v. Reason: %v\n", uid, err)
+		return false, apperrors.NewInternal()
+	}
+
+	return count > 0, nil
+}
+
+func pendingKey(token string) string {
+	return fmt.Sprintf("pending_login:%s", token)
+}
+
+// CreatePending stores a login that still has to pass two-factor authentication
+func (r *redisSessionRepository) CreatePending(token, uid string, ttl time.Duration) error {
+	ctx := context.Background()
+
+	_, err := r.Redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, pendingKey(token), "userId", uid, "attempts", 0)
+		pipe.Expire(ctx, pendingKey(token), ttl)
+		return nil
+	})
+
+	if err != nil {
+		log.Printf("Could not create pending login for user: %v. Reason: %v\n", uid, err)
+		return apperrors.NewInternal()
+	}
+
+	return nil
+}
+
+// FindPending returns the user ID of the pending login
+func (r *redisSessionRepository) FindPending(token string) (string, error) {
+	ctx := context.Background()
+
+	uid, err := r.Redis.HGet(ctx, pendingKey(token), "userId").Result()
+
+	if err == redis.Nil {
+		return "", apperrors.NewNotFound("pending login", token)
+	}
+
+	if err != nil {
+		log.Printf("Could not get pending login. Reason: %v\n", err)
+		return "", apperrors.NewInternal()
+	}
+
+	return uid, nil
+}
+
+// IncrementPendingAttempts increments the number of failed
+// verification attempts and returns the new count
+func (r *redisSessionRepository) IncrementPendingAttempts(token string) (int64, error) {
+	ctx := context.Background()
+
+	attempts, err := r.Redis.HIncrBy(ctx, pendingKey(token), "attempts", 1).Result()
+
+	if err != nil {
+		log.Printf("Could not update pending login. Reason: %v\n", err)
+		return 0, apperrors.NewInternal()
+	}
+
+	return attempts, nil
+}
+
+func (r *redisSessionRepository) DeletePending(token string) error {
+	ctx := context.Background()
+
+	if err := r.Redis.Del(ctx, pendingKey(token)).Err(); err != nil {
+		log.Printf("Could not delete pending login. Reason: %v\n", err)
+		return apperrors.NewInternal()
+	}
+
+	return nil
+}
+
+func sessionValues(session *model.Session) map[string]interface{} {
+	return map[string]interface{}{
+		"userId":    session.UserID,
+		"device":    session.Device,
+		"ip":        session.IP,
+		"createdAt": session.CreatedAt.Unix(),
+		"lastSeen":  session.LastSeen.Unix(),
+	}
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"log"
+	"time"
+)
+
+// redisSuggestionCache stores the suggestions of every user as a JSON list
+type redisSuggestionCache struct {
+	Redis *redis.Client
+}
+
+// NewSuggestionCache is a factory for initializing Suggestion Caches
+func NewSuggestionCache(rds *redis.Client) model.SuggestionCache {
+	return &redisSuggestionCache{
+		Redis: rds,
+	}
+}
+
+func suggestionKey(userId string) string {
+	return fmt.Sprintf("suggestions:%s", userId)
+}
+
+// Get returns the cached suggestions of the user or nil if there are none
+func (r *redisSuggestionCache) Get(userId string) ([]model.Suggestion, error) {
+	ctx := context.Background()
+	key := suggestionKey(userId)
+
+	value, err := r.Redis.Get(ctx, key).Result()
+
+	if err == redis.Nil {
+		return nil, nil
+	}
+
+	if err != nil {
+		log.Printf("Could not get suggestions: %v. Reason: %v\n", key, err)
+		return nil, apperrors.NewInternal()
+	}
+
+	suggestions := make([]model.Suggestion, 0)
+
+	if err := json.Unmarshal([]byte(value), &suggestions); err != nil {
+		log.Printf("Could not decode suggestions: %v. Reason: %v\n", key, err)
+		return nil, apperrors.NewInternal()
+	}
+
+	return suggestions, nil
+}
+
+// Set caches the suggestions of the user for the given duration
+func (r *redisSuggestionCache) Set(userId string, suggestions []model.Suggestion, ttl time.Duration) error {
+	ctx := context.Background()
+	key := suggestionKey(userId)
+
+	value, err := json.Marshal(suggestions)
+
+	if err != nil {
+		log.Printf("Could not encode suggestions: %v. Reason: %v\n", key, err)
+		return apperrors.NewInternal()
+	}
+
+	if err := r.Redis.Set(ctx, key, value, ttl).Err(); err != nil {
+		log.Printf("Could not set suggestions: %v. Reason: %v\n", key, err)
+		return apperrors.NewInternal()
+	}
+
+	return nil
+}
+
+// Delete removes the cached suggestions of the user
+func (r *redisSuggestionCache) Delete(userId string) error {
+	ctx := context.Background()
+	key := suggestionKey(userId)
+
+	if err := r.Redis.Del(ctx, key).Err(); err != nil {
+		log.Printf("Could not delete suggestions: %v. Reason: %v\n", key, err)
+		return apperrors.NewInternal()
+	}
+
+	return nil
+}
@@ -0,0 +1,348 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// timelineSize is the number of entries kept per timeline
+	timelineSize = 800
+	// timelineTTL removes the timelines of inactive users. They get rebuilt on their next read
+	timelineTTL = 14 * 24 * time.Hour
+	// timelineMarker keeps empty timelines from being rebuilt on every read.
+	// Its score is below every entry's.
+	timelineMarker = "-"
+	// popularActorsKey is the set of the users whose posts are read by their
+	// followers instead of being written to their timelines. It also holds
+	// the timelineMarker, so a built but empty set is kept.
+	popularActorsKey = "timelines:popular"
+)
+
+// redisTimelineRepository stores every home timeline as a sorted set
+// of "postId:actorId" members scored by their time in microseconds
+type redisTimelineRepository struct {
+	Redis *redis.Client
+}
+
+// NewTimelineRepository is a factory for initializing Timeline Repositories
+func NewTimelineRepository(rds *redis.Client) model.TimelineRepository {
+	return &redisTimelineRepository{
+		Redis: rds,
+	}
+}
+
+// timelineKey is versioned, as the timelines scored in milliseconds are
+// left to expire and get rebuilt on their next read
+func timelineKey(userId string) string {
+	return fmt.Sprintf("timeline:v2:%s", userId)
+}
+
+func timelineMember(entry model.TimelineEntry) string {
+	return entry.PostID + ":" + entry.ActorID
+}
+
+func timelineScore(t time.Time) float64 {
+	return float64(t.UnixNano() / int64(time.Microsecond))
+}
+
+func timelineScoreString(t time.Time) string {
+	return strconv.FormatFloat(timelineScore(t), 'f', 0, 64)
+}
+
+// parseTimelineMember returns the entry of the member and its score
+func parseTimelineMember(z redis.Z) (model.TimelineEntry, bool) {
+	parts := strings.SplitN(z.Member.(string), ":", 2)
+	if len(parts) != 2 {
+		return model.TimelineEntry{}, false
+	}
+
+	return model.TimelineEntry{
+		PostID:    parts[0],
+		ActorID:   parts[1],
+		CreatedAt: time.Unix(0, int64(z.Score)*int64(time.Microsecond)).UTC(),
+	}, true
+}
+
+// Add inserts the entries into the existing timelines of the users.
+// Timelines that don't exist are left for the rebuild on their next read.
+func (r *redisTimelineRepository) Add(userIds []string, entries []model.TimelineEntry) error {
+	ctx := context.Background()
+
+	exists := make([]*redis.IntCmd, len(userIds))
+	_, err := r.Redis.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, id := range userIds {
+			exists[i] = pipe.Exists(ctx, timelineKey(id))
+		}
+		return nil
+	})
+
+	if err != nil {
+		log.Printf("Could not check timelines. Reason: %v\n", err)
+		return apperrors.NewInternal()
+	}
+
+	members := make([]*redis.Z, len(entries))
+	for i, entry := range entries {
+		members[i] = &redis.Z{Score: timelineScore(entry.CreatedAt), Member: timelineMember(entry)}
+	}
+
+	_, err = r.Redis.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, id := range userIds {
+			if exists[i].Val() == 0 || len(members) == 0 {
+				continue
+			}
+			key := timelineKey(id)
+			pipe.ZAdd(ctx, key, members...)
+			pipe.ZRemRangeByRank(ctx, key, 0, -timelineSize-1)
+		}
+		return nil
+	})
+
+	if err != nil {
+		log.Printf("Could not add timeline entries. Reason: %v\n", err)
+		return apperrors.NewInternal()
+	}
+
+	return nil
+}
+
+// Remove deletes the entry from the timelines of the users
+func (r *redisTimelineRepository) Remove(userIds []string, entry model.TimelineEntry) error {
+	ctx := context.Background()
+	member := timelineMember(entry)
+
+	_, err := r.Redis.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, id := range userIds {
+			pipe.ZRem(ctx, timelineKey(id), member)
+		}
+		return nil
+	})
+
+	if err != nil {
+		log.Printf("Could not remove timeline entry: %v. Reason: %v\n", member, err)
+		return apperrors.NewInternal()
+	}
+
+	return nil
+}
+
+// RemoveActor deletes all posts and retweets of the actor from the user's timeline
+func (r *redisTimelineRepository) RemoveActor(userId, actorId string) error {
+	ctx := context.Background()
+	key := timelineKey(userId)
+
+	members, err := r.Redis.ZRange(ctx, key, 0, -1).Result()
+
+	if err != nil {
+		log.Printf("Could not get timeline: %v. Reason: %v\n", key, err)
+		return apperrors.NewInternal()
+	}
+
+	remove := make([]interface{}, 0)
+	for _, member := range members {
+		if strings.HasSuffix(member, ":"+actorId) {
+			remove = append(remove, member)
+		}
+	}
+
+	if len(remove) == 0 {
+		return nil
+	}
+
+	if err := r.Redis.ZRem(ctx, key, remove...).Err(); err != nil {
+		log.Printf("Could not remove entries from timeline: %v. Reason: %v\n", key, err)
+		return apperrors.NewInternal()
+	}
+
+	return nil
+}
+
+// Range returns up to limit entries after the given entry, newest first
+func (r *redisTimelineRepository) Range(userId string, before *model.TimelineEntry, limit int) ([]model.TimelineEntry, error) {
+	ctx := context.Background()
+	key := timelineKey(userId)
+
+	max := "+inf"
+	if before != nil {
+		max = "(" + timelineScoreString(before.CreatedAt)
+	}
+
+	// entries at the time of the before entry are ordered by their members
+	var ties *redis.ZSliceCmd
+	var result *redis.ZSliceCmd
+	_, err := r.Redis.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		if before != nil {
+			score := timelineScoreString(before.CreatedAt)
+			ties = pipe.ZRangeByScoreWithScores(ctx, key, &redis.ZRangeBy{Min: score, Max: score})
+		}
+		result = pipe.ZRevRangeByScoreWithScores(ctx, key, &redis.ZRangeBy{
+			Min:   "(0",
+			Max:   max,
+			Count: int64(limit),
+		})
+		pipe.Expire(ctx, key, timelineTTL)
+		return nil
+	})
+
+	if err != nil {
+		log.Printf("Could not get timeline: %v. Reason: %v\n", key, err)
+		return nil, apperrors.NewInternal()
+	}
+
+	entries := make([]model.TimelineEntry, 0, len(result.Val()))
+
+	if ties != nil {
+		for _, z := range ties.Val() {
+			entry, ok := parseTimelineMember(z)
+			if ok && (entry.PostID < before.PostID || entry.PostID == before.PostID && entry.ActorID < before.ActorID) {
+				entries = append(entries, entry)
+			}
+		}
+		// ties come in ascending order
+		for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+			entries[i], entries[j] = entries[j], entries[i]
+		}
+	}
+
+	for _, z := range result.Val() {
+		if entry, ok := parseTimelineMember(z); ok {
+			entries = append(entries, entry)
+		}
+	}
+
+	if len(entries) > limit {
+		entries = entries[:limit]
+	}
+
+	return entries, nil
+}
+
+// Replace sets the user's timeline to the given entries
+func (r *redisTimelineRepository) Replace(userId string, entries []model.TimelineEntry) error {
+	ctx := context.Background()
+	key := timelineKey(userId)
+
+	members := []*redis.Z{{Score: 0, Member: timelineMarker}}
+	for _, entry := range entries {
+		members = append(members, &redis.Z{Score: timelineScore(entry.CreatedAt), Member: timelineMember(entry)})
+	}
+
+	_, err := r.Redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, key)
+		pipe.ZAdd(ctx, key, members...)
+		pipe.ZRemRangeByRank(ctx, key, 0, -timelineSize-1)
+		pipe.Expire(ctx, key, timelineTTL)
+		return nil
+	})
+
+	if err != nil {
+		log.Printf("Could not replace timeline: %v. Reason: %v\n", key, err)
+		return apperrors.NewInternal()
+	}
+
+	return nil
+}
+
+// Exists checks if the user's timeline has been built
+func (r *redisTimelineRepository) Exists(userId string) (bool, error) {
+	ctx := context.Background()
+
+	count, err := r.Redis.Exists(ctx, timelineKey(userId)).Result()
+
+	if err != nil {
+		log.Printf("Could not check timeline of user: %v. Reason: %v\n", userId, err)
+		return false, apperrors.NewInternal()
+	}
+
+	return count > 0, nil
+}
+
+// setPopularScript changes whether the actor is popular, but only
+// once the set has been built. It returns -1 if it hasn't been built,
+// otherwise whether the membership changed.
+var setPopularScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 0 then
+	return -1
+end
+if ARGV[2] == "1" then
+	return redis.call("SADD", KEYS[1], ARGV[1])
+end
+return redis.call("SREM", KEYS[1], ARGV[1])
+`)
+
+// PopularActors returns the users whose posts get merged in when reading.
+// It returns a NotFound error if the set hasn't been built yet.
+func (r *redisTimelineRepository) PopularActors() ([]string, error) {
+	ctx := context.Background()
+
+	members, err := r.Redis.SMembers(ctx, popularActorsKey).Result()
+
+	if err != nil {
+		log.Printf("Could not get popular actors. Reason: %v\n", err)
+		return nil, apperrors.NewInternal()
+	}
+
+	if len(members) == 0 {
+		return nil, apperrors.NewNotFound("popular actors", popularActorsKey)
+	}
+
+	ids := make([]string, 0, len(members)-1)
+	for _, member := range members {
+		if member != timelineMarker {
+			ids = append(ids, member)
+		}
+	}
+
+	return ids, nil
+}
+
+// SetPopular adds the actor to or removes it from the popular actors.
+// It returns whether that changed the set.
+func (r *redisTimelineRepository) SetPopular(actorId string, popular bool) (bool, error) {
+	ctx := context.Background()
+
+	flag := "0"
+	if popular {
+		flag = "1"
+	}
+
+	changed, err := setPopularScript.Run(ctx, r.Redis, []string{popularActorsKey}, actorId, flag).Int()
+
+	if err != nil {
+		log.Printf("Could not set popular actor: %v. Reason: %v\n", actorId, err)
+		return false, apperrors.NewInternal()
+	}
+
+	return changed == 1, nil
+}
+
+// ReplacePopular sets the popular actors to the given users
+func (r *redisTimelineRepository) ReplacePopular(actorIds []string) error {
+	ctx := context.Background()
+
+	members := []interface{}{timelineMarker}
+	for _, id := range actorIds {
+		members = append(members, id)
+	}
+
+	_, err := r.Redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, popularActorsKey)
+		pipe.SAdd(ctx, popularActorsKey, members...)
+		return nil
+	})
+
+	if err != nil {
+		log.Printf("Could not replace popular actors. Reason: %v\n", err)
+		return apperrors.NewInternal()
+	}
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"errors"
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"gorm.io/gorm"
+	"log"
+	"time"
+)
+
+// tokenRepository is data/repository implementation
+// of service layer TokenRepository
+type tokenRepository struct {
+	DB *gorm.DB
+}
+
+// NewTokenRepository is a factory for initializing Token Repositories
+func NewTokenRepository(db *gorm.DB) model.TokenRepository {
+	return &tokenRepository{
+		DB: db,
+	}
+}
+
+// FindByHash returns the token with the given hash
+func (r *tokenRepository) FindByHash(hash string) (*model.AccessToken, error) {
+	token := &model.AccessToken{}
+
+	if err := r.DB.Where("hash = ?", hash).First(&token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return token, apperrors.NewNotFound("token", "hash")
+		}
+		return token, apperrors.NewInternal()
+	}
+
+	return token, nil
+}
+
+// FindAllForUser returns all tokens of the given user, newest first
+func (r *tokenRepository) FindAllForUser(uid string) (*[]model.AccessToken, error) {
+	var tokens []model.AccessToken
+
+	err := r.DB.
+		Where("user_id = ?", uid).
+		Order("created_at DESC").
+		Find(&tokens).Error
+
+	return &tokens, err
+}
+
+// Create inserts the token in the DB
+func (r *tokenRepository) Create(token *model.AccessToken) error {
+	if err := r.DB.Create(&token).Error; err != nil {
+		log.Printf("Could not create a token for user: %v. Reason: %v\n", token.UserID, err)
+		return apperrors.NewInternal()
+	}
+
+	return nil
+}
+
+func (r *tokenRepository) UpdateLastUsed(id string, lastUsed time.Time) error {
+	return r.DB.
+		Model(&model.AccessToken{}).
+		Where("id = ?", id).
+		Update("last_used_at", lastUsed).
+		Error
+}
+
+// Delete removes the token if it belongs to the given user
+func (r *tokenRepository) Delete(uid, id string) error {
+	result := r.DB.
+		Where("id = ? AND user_id = ?", id, uid).
+		Delete(&model.AccessToken{})
+
+	if result.Error != nil {
+		log.Printf("Could not delete token: %v. Reason: %v\n", id, result.Error)
+		return apperrors.NewInternal()
+	}
+
+	if result.RowsAffected == 0 {
+		return apperrors.NewNotFound("token", id)
+	}
+
+	return nil
+}
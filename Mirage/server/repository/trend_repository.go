@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"log"
+	"time"
+)
+
+// redisTrendRepository stores hashtag counts in one
+// sorted set per time bucket
+type redisTrendRepository struct {
+	Redis *redis.Client
+}
+
+// NewTrendRepository is a factory for initializing Trend Repositories
+func NewTrendRepository(rds *redis.Client) model.TrendRepository {
+	return &redisTrendRepository{
+		Redis: rds,
+	}
+}
+
+func trendKey(resolution time.Duration, bucket time.Time) string {
+	return fmt.Sprintf("trends:%d:%d", int64(resolution.Seconds()), bucket.Unix())
+}
+
+// Increment adds one use of every tag to the bucket
+func (r *redisTrendRepository) Increment(resolution time.Duration, bucket time.Time, tags []string, ttl time.Duration) error {
+	ctx := context.Background()
+	key := trendKey(resolution, bucket)
+
+	_, err := r.Redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, tag := range tags {
+			pipe.ZIncrBy(ctx, key, 1, tag)
+		}
+		pipe.Expire(ctx, key, ttl)
+		return nil
+	})
+
+	if err != nil {
+		log.Printf("Could not increment trends in bucket: %v. Reason: %v\n", key, err)
+		return apperrors.NewInternal()
+	}
+
+	return nil
+}
+
+// decrementTrendScript removes one use of every tag from an existing bucket.
+// Buckets that expired are not created again and tags without uses are removed.
+var decrementTrendScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 0 then
+	return 0
+end
+for _, tag in ipairs(ARGV) do
+	redis.call("ZINCRBY", KEYS[1], -1, tag)
+end
+redis.call("ZREMRANGEBYSCORE", KEYS[1], "-inf", 0)
+return 1
+`)
+
+// Decrement removes one use of every tag from the bucket
+func (r *redisTrendRepository) Decrement(resolution time.Duration, bucket time.Time, tags []string) error {
+	ctx := context.Background()
+	key := trendKey(resolution, bucket)
+
+	args := make([]interface{}, len(tags))
+	for i, tag := range tags {
+		args[i] = tag
+	}
+
+	if err := decrementTrendScript.Run(ctx, r.Redis, []string{key}, args...).Err(); err != nil {
+		log.Printf("Could not decrement trends in bucket: %v. Reason: %v\n", key, err)
+		return apperrors.NewInternal()
+	}
+
+	return nil
+}
+
+// Counts subtracts the baseline from the current buckets and returns
+// the tags with the largest growth with both of their counts
+func (r *redisTrendRepository) Counts(resolution time.Duration, current, baseline []time.Time, limit int) ([]model.TrendCount, error) {
+	ctx := context.Background()
+	counts := make([]model.TrendCount, 0)
+
+	if len(current) == 0 {
+		return counts, nil
+	}
+
+	keys := make([]string, 0, len(current)+len(baseline))
+	weights := make([]float64, 0, len(current)+len(baseline))
+
+	for _, bucket := range current {
+		keys = append(keys, trendKey(resolution, bucket))
+		weights = append(weights, 1)
+	}
+
+	for _, bucket := range baseline {
+		keys = append(keys, trendKey(resolution, bucket))
+		weights = append(weights, -1)
+	}
+
+	id := fmt.Sprintf("%d", time.Now().UnixNano())
+	growthKey := "trends:tmp:growth:" + id
+	countKey := "trends:tmp:count:" + id
+
+	var top *redis.ZSliceCmd
+
+	_, err := r.Redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZUnionStore(ctx, growthKey, &redis.ZStore{Keys: keys, Weights: weights})
+		pipe.ZUnionStore(ctx, countKey, &redis.ZStore{Keys: keys[:len(current)]})
+		top = pipe.ZRevRangeByScoreWithScores(ctx, growthKey, &redis.ZRangeBy{Min: "(0", Max: "+inf", Count: int64(limit)})
+		pipe.Expire(ctx, countKey, time.Minute)
+		pipe.Del(ctx, growthKey)
+		return nil
+	})
+
+	if err != nil {
+		log.Printf("Could not get trends. Reason: %v\n", err)
+		return nil, apperrors.NewInternal()
+	}
+
+	scores := make([]*redis.FloatCmd, len(top.Val()))
+
+	_, err = r.Redis.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, z := range top.Val() {
+			scores[i] = pipe.ZScore(ctx, countKey, z.Member.(string))
+		}
+		pipe.Del(ctx, countKey)
+		return nil
+	})
+
+	if err != nil {
+		log.Printf("Could not get trend counts. Reason: %v\n", err)
+		return nil, apperrors.NewInternal()
+	}
+
+	for i, z := range top.Val() {
+		count := int64(scores[i].Val())
+		counts = append(counts, model.TrendCount{
+			Hashtag:  z.Member.(string),
+			Current:  count,
+			Baseline: count - int64(z.Score),
+		})
+	}
+
+	return counts, nil
+}
+
+// Clear removes all trend buckets
+func (r *redisTrendRepository) Clear() error {
+	ctx := context.Background()
+	iter := r.Redis.Scan(ctx, 0, "trends:*", 100).Iterator()
+
+	for iter.Next(ctx) {
+		if err := r.Redis.Del(ctx, iter.Val()).Err(); err != nil {
+			log.Printf("Could not delete trend bucket: %v. Reason: %v\n", iter.Val(), err)
+			return apperrors.NewInternal()
+		}
+	}
+
+	if err := iter.Err(); err != nil {
+		log.Printf("Could not list trend buckets. Reason: %v\n", err)
+		return apperrors.NewInternal()
+	}
+
+	return nil
+}
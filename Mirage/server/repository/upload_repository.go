@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"errors"
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"gorm.io/gorm"
+	"log"
+	"time"
+)
+
+// uploadRepository is data/repository implementation
+// of service layer UploadRepository
+type uploadRepository struct {
+	DB *gorm.DB
+}
+
+// NewUploadRepository is a factory for initializing Upload Repositories
+func NewUploadRepository(db *gorm.DB) model.UploadRepository {
+	return &uploadRepository{
+		DB: db,
+	}
+}
+
+// Create inserts the upload in the DB
+func (r *uploadRepository) Create(upload *model.Upload) error {
+	if err := r.DB.Create(upload).Error; err != nil {
+		log.Printf("Could not create an upload for user: %v. Reason: %v\n", upload.UserID, err)
+		return apperrors.NewInternal()
+	}
+
+	return nil
+}
+
+// FindByID returns the upload for the given ID
+func (r *uploadRepository) FindByID(id string) (*model.Upload, error) {
+	upload := &model.Upload{}
+
+	if err := r.DB.Where("id = ?", id).First(upload).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperrors.NewNotFound("media", id)
+		}
+		return nil, apperrors.NewInternal()
+	}
+
+	return upload, nil
+}
+
+func (r *uploadRepository) Delete(upload *model.Upload) error {
+	return r.DB.Delete(upload).Error
+}
+
+// Expired returns the uploads that expired before the given time
+func (r *uploadRepository) Expired(before time.Time) (*[]model.Upload, error) {
+	var uploads []model.Upload
+
+	err := r.DB.
+		Where("expires_at < ?", before).
+		Find(&uploads).Error
+
+	return &uploads, err
+}
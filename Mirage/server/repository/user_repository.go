@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"database/sql"
 	"errors"
 	"github.com/sentrionic/mirage/model"
 	"github.com/sentrionic/mirage/model/apperrors"
@@ -8,6 +9,7 @@ import (
 	"log"
 	"regexp"
 	"strings"
+	"time"
 )
 
 // userRepository is data/repository implementation
@@ -112,35 +114,430 @@ func (r *userRepository) Update(user *model.User) error {
 	return nil
 }
 
+// AddFollow makes the current user follow the user
+// and counts the new follower of the user
 func (r *userRepository) AddFollow(userId, currentId string) error {
-	err := r.DB.Table("followers").Create(map[string]interface{}{
-		"user_id":     userId,
-		"follower_id": currentId,
-	}).Table("followee").Create(map[string]interface{}{
-		"followee_id": userId,
-		"user_id":     currentId,
-	}).Error
-	return err
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&model.Follow{FollowerID: currentId, FolloweeID: userId}).Error; err != nil {
+			return err
+		}
+
+		return tx.Exec("UPDATE users SET follower_count = follower_count + 1 WHERE id = ?", userId).Error
+	})
 }
 
+// RemoveFollow makes the current user unfollow the user
+// and no longer counts them as a follower of the user
 func (r *userRepository) RemoveFollow(userId, currentId string) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.
+			Where("follower_id = ? AND followee_id = ?", currentId, userId).
+			Delete(&model.Follow{})
+
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+
+		return tx.Exec("UPDATE users SET follower_count = follower_count - 1 WHERE id = ?", userId).Error
+	})
+}
+
+// SearchProfiles returns up to limit users after the given rank whose username, display
+// name or bio contains the normalized term, and the ranks of the matches. Exact and prefix
+// matches of the username come first, then prefix matches of a word of the display name,
+// then the other matches of the username or the display name and last the matches of the
+// bio. Users with more followers come first within each of those groups. A nil rank
+// starts at the best match.
+func (r *userRepository) SearchProfiles(term string, after *model.ProfileRank, limit int) (*[]model.User, []model.ProfileRank, error) {
+	var ranks []model.ProfileRank
+
+	if after == nil {
+		after = &model.ProfileRank{Match: -1}
+	}
+
+	pattern := escapeLike(term)
+
+	err := r.DB.Raw(`
+		SELECT m.id, m.match, m.followers
+		FROM (
+			SELECT
+				u.id,
+				CASE
+					WHEN lower(u.username) = @term THEN 0
+					WHEN lower(u.username) LIKE @prefix THEN 1
+					WHEN u.search_name LIKE @prefix OR u.search_name LIKE @word THEN 2
+					WHEN lower(u.username) LIKE @contains OR u.search_name LIKE @contains THEN 3
+					ELSE 4
+				END AS match,
+				u.follower_count AS followers
+			FROM users u
+			WHERE lower(u.username) LIKE @contains
+				OR u.search_name LIKE @contains
+				OR u.search_bio LIKE @contains
+		) m
+		WHERE (m.match, -m.followers, m.id) > (@match, -@followers::bigint, @id)
+		ORDER BY m.match, m.followers DESC, m.id
+		LIMIT @limit
+	`,
+		sql.Named("term", term),
+		sql.Named("prefix", pattern+"%"),
+		sql.Named("word", "% "+pattern+"%"),
+		sql.Named("contains", "%"+pattern+"%"),
+		sql.Named("match", after.Match),
+		sql.Named("followers", after.Followers),
+		sql.Named("id", after.ID),
+		sql.Named("limit", limit),
+	).
+		Scan(&ranks).
+		Error
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ids := make([]string, len(ranks))
+	for i, rank := range ranks {
+		ids[i] = rank.ID
+	}
+
+	users, err := r.findOrdered(ids)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return users, ranks, nil
+}
+
+// TypeaheadProfiles returns up to limit users whose username or a word of
+// whose display name starts with the normalized term, most followed first
+func (r *userRepository) TypeaheadProfiles(term string, limit int) (*[]model.User, error) {
+	var users []model.User
+
+	pattern := escapeLike(term)
+
+	err := r.DB.Raw(`
+		SELECT u.id, u.username, u.display_name, u.image
+		FROM users u
+		WHERE lower(u.username) LIKE @prefix
+			OR u.search_name LIKE @prefix
+			OR u.search_name LIKE @word
+		ORDER BY
+			lower(u.username) LIKE @prefix DESC,
+			u.follower_count DESC,
+			u.id
+		LIMIT @limit
+	`,
+		sql.Named("prefix", pattern+"%"),
+		sql.Named("word", "% "+pattern+"%"),
+		sql.Named("limit", limit),
+	).
+		Scan(&users).
+		Error
+
+	return &users, err
+}
+
+// FindAfter returns up to limit users with an ID greater than the given one, ordered by ID
+func (r *userRepository) FindAfter(id string, limit int) (*[]model.User, error) {
+	var users []model.User
+
 	err := r.DB.
-		Exec("DELETE FROM followers WHERE user_id = ? AND follower_id = ?", userId, currentId).
-		Exec("DELETE FROM followee WHERE followee_id = ? AND user_id = ?", userId, currentId).
+		Where("id > ?", id).
+		Order("id").
+		Limit(limit).
+		Find(&users).
+		Error
+
+	return &users, err
+}
+
+// UpdateSearchText saves the normalized display name and bio of the user
+func (r *userRepository) UpdateSearchText(user *model.User) error {
+	return r.DB.
+		Model(&model.User{}).
+		Where("id = ?", user.ID).
+		UpdateColumns(map[string]interface{}{
+			"search_name": user.SearchName,
+			"search_bio":  user.SearchBio,
+		}).
 		Error
-	return err
 }
 
-func (r *userRepository) SearchProfiles(term string) (*[]model.User, error) {
-	users := &[]model.User{}
+// SetProfileImage only saves the avatar or banner, so it doesn't
+// overwrite changes made to the profile in the meantime
+func (r *userRepository) SetProfileImage(userId string, kind model.ProfileImage, url string) error {
+	return r.DB.
+		Model(&model.User{}).
+		Where("id = ?", userId).
+		UpdateColumn(string(kind), url).
+		Error
+}
+
+// ConsumeTwoFactorStep records the step of a used two-factor code.
+// It returns false if the step or a later one was already used,
+// so that concurrent requests cannot use the same code twice.
+func (r *userRepository) ConsumeTwoFactorStep(userId string, step int64) (bool, error) {
+	result := r.DB.
+		Model(&model.User{}).
+		Where("id = ? AND last_two_factor_step < ?", userId, step).
+		UpdateColumn("last_two_factor_step", step)
+
+	if result.Error != nil {
+		log.Printf("Could not consume two-factor step of user: %v. Reason: %v\n", userId, result.Error)
+		return false, apperrors.NewInternal()
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+// ConsumeRecoveryCode removes the hashed recovery code of the user.
+// It returns false if the code was already used.
+func (r *userRepository) ConsumeRecoveryCode(userId, hash string) (bool, error) {
+	result := r.DB.
+		Model(&model.User{}).
+		Where("id = ? AND ? = ANY(recovery_codes)", userId, hash).
+		UpdateColumn("recovery_codes", gorm.Expr("array_remove(recovery_codes, ?)", hash))
+
+	if result.Error != nil {
+		log.Printf("Could not consume recovery code of user: %v. Reason: %v\n", userId, result.Error)
+		return false, apperrors.NewInternal()
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+// findOrdered returns the users with the given IDs in the order of the IDs
+func (r *userRepository) findOrdered(ids []string) (*[]model.User, error) {
+	found, err := r.FindByIDs(ids)
+
+	if err != nil {
+		return nil, err
+	}
+
+	byId := make(map[string]model.User, len(*found))
+	for _, user := range *found {
+		byId[user.ID] = user
+	}
+
+	users := make([]model.User, 0, len(ids))
+	for _, id := range ids {
+		if user, ok := byId[id]; ok {
+			users = append(users, user)
+		}
+	}
+
+	return &users, nil
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern
+func escapeLike(term string) string {
+	return likeEscaper.Replace(term)
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// FindByIDs returns the users with the given IDs in no particular order
+func (r *userRepository) FindByIDs(ids []string) (*[]model.User, error) {
+	var users []model.User
+
+	if len(ids) == 0 {
+		return &users, nil
+	}
 
 	err := r.DB.
 		Preload("Followers").
 		Preload("Followee").
-		Where("username ILIKE ?", "%"+strings.ToLower(term)+"%").
-		Find(&users).Error
+		Where("id IN ?", ids).
+		Find(&users).
+		Error
+
+	return &users, err
+}
+
+// FollowerEdges returns the follows of the user's followers after the
+// given edge of the previous page, newest first. A nil edge starts at the newest.
+func (r *userRepository) FollowerEdges(userId string, before *model.Follow, limit int) ([]model.Follow, error) {
+	return r.followerEdges(r.DB.Where("followee_id = ?", userId), before, limit)
+}
+
+// MutualFollowerEdges returns the follows of the user's followers that the viewer follows
+// after the given edge of the previous page, newest first. A nil edge starts at the newest.
+func (r *userRepository) MutualFollowerEdges(userId, viewerId string, before *model.Follow, limit int) ([]model.Follow, error) {
+	query := r.DB.
+		Where("followee_id = ?", userId).
+		Where("follower_id IN (?)", r.DB.Model(&model.Follow{}).Select("followee_id").Where("follower_id = ?", viewerId))
+
+	return r.followerEdges(query, before, limit)
+}
+
+func (r *userRepository) followerEdges(query *gorm.DB, before *model.Follow, limit int) ([]model.Follow, error) {
+	var follows []model.Follow
+
+	if before != nil {
+		query = query.Where("(created_at, follower_id) < (?, ?)", before.CreatedAt, before.FollowerID)
+	}
+
+	err := query.
+		Order("created_at DESC, follower_id DESC").
+		Limit(limit).
+		Find(&follows).
+		Error
+
+	return follows, err
+}
+
+// FollowingEdges returns the follows of the user after the given edge of
+// the previous page, newest first. A nil edge starts at the newest.
+func (r *userRepository) FollowingEdges(userId string, before *model.Follow, limit int) ([]model.Follow, error) {
+	var follows []model.Follow
+
+	query := r.DB.Where("follower_id = ?", userId)
+
+	if before != nil {
+		query = query.Where("(created_at, followee_id) < (?, ?)", before.CreatedAt, before.FolloweeID)
+	}
+
+	err := query.
+		Order("created_at DESC, followee_id DESC").
+		Limit(limit).
+		Find(&follows).
+		Error
+
+	return follows, err
+}
+
+// FollowerIDs returns the IDs of the users following the user
+func (r *userRepository) FollowerIDs(userId string) ([]string, error) {
+	var ids []string
+	err := r.DB.Model(&model.Follow{}).
+		Where("followee_id = ?", userId).
+		Pluck("follower_id", &ids).
+		Error
+	return ids, err
+}
+
+// FollowerCount returns how many users follow the user
+func (r *userRepository) FollowerCount(userId string) (int64, error) {
+	var count int64
+	err := r.DB.Model(&model.User{}).
+		Select("follower_count").
+		Where("id = ?", userId).
+		Scan(&count).
+		Error
+	return count, err
+}
+
+// RecountFollowers sets the follower count of every user from the follows table
+func (r *userRepository) RecountFollowers() error {
+	return r.DB.Exec(`
+		UPDATE users u
+		SET follower_count = COALESCE(f.followers, 0)
+		FROM users v
+		LEFT JOIN (
+			SELECT followee_id, COUNT(*) AS followers FROM follows GROUP BY followee_id
+		) f ON f.followee_id = v.id
+		WHERE u.id = v.id AND u.follower_count <> COALESCE(f.followers, 0)
+	`).Error
+}
+
+// FolloweeIDs returns the IDs of the users the user follows
+func (r *userRepository) FolloweeIDs(userId string) ([]string, error) {
+	var ids []string
+	err := r.DB.Model(&model.Follow{}).
+		Where("follower_id = ?", userId).
+		Pluck("followee_id", &ids).
+		Error
+	return ids, err
+}
+
+// BlockedIDs returns the IDs of the users the user blocked or got blocked by
+func (r *userRepository) BlockedIDs(userId string) ([]string, error) {
+	var ids []string
+	err := r.DB.Raw(`
+		SELECT blocked_id FROM blocks WHERE blocker_id = @id
+		UNION
+		SELECT blocker_id FROM blocks WHERE blocked_id = @id
+	`, sql.Named("id", userId)).
+		Scan(&ids).
+		Error
+	return ids, err
+}
+
+// PopularUsers returns the IDs of the users
+// that have at least the given number of followers
+func (r *userRepository) PopularUsers(minFollowers int) ([]string, error) {
+	var ids []string
+	err := r.DB.Model(&model.User{}).
+		Where("follower_count >= ?", minFollowers).
+		Pluck("id", &ids).
+		Error
+	return ids, err
+}
+
+// SuggestionCandidates returns accounts that the user doesn't follow and hasn't blocked or
+// got blocked by: the limit accounts followed by the most of the user's followees, sharing
+// the most hashtags the user used since the given time and posting the most since then.
+// The candidates are not ordered, every signal is weighed when ranking them.
+func (r *userRepository) SuggestionCandidates(userId string, since time.Time, limit int) ([]model.SuggestionCandidate, error) {
+	var candidates []model.SuggestionCandidate
+
+	err := r.DB.Raw(`
+		WITH following AS (
+			SELECT followee_id AS id FROM follows WHERE follower_id = @id
+		), excluded AS (
+			SELECT @id AS id
+			UNION
+			SELECT id FROM following
+			UNION
+			SELECT blocked_id FROM blocks WHERE blocker_id = @id
+			UNION
+			SELECT blocker_id FROM blocks WHERE blocked_id = @id
+		), mutual AS (
+			SELECT followee_id AS user_id, COUNT(*) AS mutual_followers
+			FROM follows
+			WHERE follower_id IN (SELECT id FROM following)
+			GROUP BY followee_id
+		), tags AS (
+			SELECT DISTINCT unnest(hash_tags) AS tag
+			FROM posts
+			WHERE user_id = @id AND created_at >= @since
+		), shared AS (
+			SELECT p.user_id, COUNT(DISTINCT t.tag) AS shared_hashtags
+			FROM posts p, unnest(p.hash_tags) AS t(tag)
+			WHERE p.created_at >= @since AND t.tag IN (SELECT tag FROM tags)
+			GROUP BY p.user_id
+		), activity AS (
+			SELECT user_id, COUNT(*) AS recent_posts
+			FROM posts
+			WHERE created_at >= @since
+			GROUP BY user_id
+		), candidates AS (
+			(SELECT user_id FROM mutual
+			WHERE user_id NOT IN (SELECT id FROM excluded)
+			ORDER BY mutual_followers DESC, user_id DESC LIMIT @limit)
+			UNION
+			(SELECT user_id FROM shared
+			WHERE user_id NOT IN (SELECT id FROM excluded)
+			ORDER BY shared_hashtags DESC, user_id DESC LIMIT @limit)
+			UNION
+			(SELECT user_id FROM activity
+			WHERE user_id NOT IN (SELECT id FROM excluded)
+			ORDER BY recent_posts DESC, user_id DESC LIMIT @limit)
+		)
+		SELECT c.user_id,
+			COALESCE(m.mutual_followers, 0) AS mutual_followers,
+			COALESCE(s.shared_hashtags, 0) AS shared_hashtags,
+			COALESCE(a.recent_posts, 0) AS recent_posts
+		FROM candidates c
+		LEFT JOIN mutual m ON m.user_id = c.user_id
+		LEFT JOIN shared s ON s.user_id = c.user_id
+		LEFT JOIN activity a ON a.user_id = c.user_id
+	`, sql.Named("id", userId), sql.Named("since", since), sql.Named("limit", limit)).
+		Scan(&candidates).
+		Error
 
-	return users, err
+	return candidates, err
 }
 
 // isDuplicateKeyError checks if the provided error is a PostgreSQL duplicate key error
@@ -0,0 +1,203 @@
+package service
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding"
+	"encoding/hex"
+	"fmt"
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"hash"
+	"log"
+	"regexp"
+	"time"
+)
+
+// chunkedUploadExpiration is how long a chunked upload can take until it gets abandoned
+const chunkedUploadExpiration = 24 * time.Hour
+
+var checksumPattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// InitChunkedUpload starts a resumable upload of a file with the given type, size and
+// SHA-256 checksum. The chunks are stored as the parts of a multipart upload.
+func (s *mediaService) InitChunkedUpload(userId, filetype string, size int64, checksum string) (*model.ChunkedUpload, error) {
+	if !checksumPattern.MatchString(checksum) {
+		return nil, apperrors.NewBadRequest("checksum must be a hex encoded SHA-256 hash")
+	}
+
+	upload, err := newUpload(userId, filetype, size, s.Clock().Add(chunkedUploadExpiration))
+
+	if err != nil {
+		return nil, err
+	}
+
+	multipartId, err := s.FileRepository.CreateMultipartUpload(upload.Key, filetype)
+
+	if err != nil {
+		log.Printf("Unable to create multipart upload: %v\n%v", upload.Key, err)
+		return nil, apperrors.NewInternal()
+	}
+
+	upload.MultipartID = multipartId
+
+	state, err := marshalHash(sha256.New())
+	if err != nil {
+		return nil, err
+	}
+
+	// the upload gets recorded so that abandoned uploads get aborted by CleanupUploads
+	if err := s.UploadRepository.Create(upload); err != nil {
+		return nil, err
+	}
+
+	chunked := &model.ChunkedUpload{
+		ID:          upload.ID,
+		UserID:      userId,
+		Key:         upload.Key,
+		FileType:    filetype,
+		Size:        size,
+		Checksum:    checksum,
+		MultipartID: multipartId,
+		Parts:       []model.UploadPart{},
+		HashState:   state,
+		ExpiresAt:   upload.ExpiresAt,
+	}
+
+	if err := s.ChunkedUploadRepository.Save(chunked); err != nil {
+		return nil, err
+	}
+
+	return chunked, nil
+}
+
+// AppendChunk stores the chunk with the given index. Every chunk has to be
+// model.ChunkSize bytes except for the last one. Appending an already
+// received chunk again does nothing, so clients can safely retry.
+func (s *mediaService) AppendChunk(userId, mediaId string, index int, chunk []byte) (*model.ChunkedUpload, error) {
+	upload, err := s.GetChunkedUpload(userId, mediaId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if index < upload.NextChunk {
+		return upload, nil
+	}
+
+	if index > upload.NextChunk {
+		return nil, apperrors.NewBadRequest(fmt.Sprintf("expected chunk %d", upload.NextChunk))
+	}
+
+	expected := upload.Size - upload.Received
+	if expected > model.ChunkSize {
+		expected = model.ChunkSize
+	}
+
+	if int64(len(chunk)) != expected {
+		return nil, apperrors.NewBadRequest(fmt.Sprintf("chunk %d must be %d bytes", index, expected))
+	}
+
+	h, err := unmarshalHash(upload.HashState)
+	if err != nil {
+		return nil, err
+	}
+
+	number := int64(index + 1)
+	etag, err := s.FileRepository.UploadPart(upload.Key, upload.MultipartID, number, bytes.NewReader(chunk))
+
+	if err != nil {
+		log.Printf("Unable to upload part %d of: %v\n%v", number, upload.Key, err)
+		return nil, apperrors.NewInternal()
+	}
+
+	h.Write(chunk)
+
+	if upload.HashState, err = marshalHash(h); err != nil {
+		return nil, err
+	}
+
+	upload.NextChunk++
+	upload.Received += int64(len(chunk))
+	upload.Parts = append(upload.Parts, model.UploadPart{Number: number, ETag: etag})
+
+	if err := s.ChunkedUploadRepository.Save(upload); err != nil {
+		return nil, err
+	}
+
+	return upload, nil
+}
+
+// GetChunkedUpload returns the state of the user's chunked upload
+func (s *mediaService) GetChunkedUpload(userId, mediaId string) (*model.ChunkedUpload, error) {
+	upload, err := s.ChunkedUploadRepository.Get(mediaId)
+
+	if err != nil || upload.UserID != userId {
+		return nil, apperrors.NewNotFound("media", mediaId)
+	}
+
+	return upload, nil
+}
+
+// FinalizeChunkedUpload verifies the checksum and assembles the chunks. Afterwards the
+// upload gets attached to a post like direct uploads. Uploads with a wrong checksum
+// get discarded.
+func (s *mediaService) FinalizeChunkedUpload(userId, mediaId string) (*model.ChunkedUpload, error) {
+	chunked, err := s.GetChunkedUpload(userId, mediaId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if chunked.Received != chunked.Size {
+		return nil, apperrors.NewBadRequest(fmt.Sprintf("upload is incomplete, expected chunk %d", chunked.NextChunk))
+	}
+
+	h, err := unmarshalHash(chunked.HashState)
+	if err != nil {
+		return nil, err
+	}
+
+	if hex.EncodeToString(h.Sum(nil)) != chunked.Checksum {
+		if upload, err := s.UploadRepository.FindByID(mediaId); err == nil {
+			s.discard(upload)
+		}
+		_ = s.ChunkedUploadRepository.Delete(mediaId)
+		return nil, apperrors.NewBadRequest("checksum does not match the uploaded file")
+	}
+
+	if err := s.FileRepository.CompleteMultipartUpload(chunked.Key, chunked.MultipartID, chunked.Parts); err != nil {
+		log.Printf("Unable to complete multipart upload: %v\n%v", chunked.Key, err)
+		return nil, apperrors.NewInternal()
+	}
+
+	if err := s.ChunkedUploadRepository.Delete(mediaId); err != nil {
+		log.Printf("Unable to delete chunked upload: %v\n%v", mediaId, err)
+	}
+
+	return chunked, nil
+}
+
+// marshalHash returns the internal state of the hash so
+// that it can continue with the next chunk later on
+func marshalHash(h hash.Hash) ([]byte, error) {
+	state, err := h.(encoding.BinaryMarshaler).MarshalBinary()
+
+	if err != nil {
+		log.Printf("Unable to marshal hash: %v\n", err)
+		return nil, apperrors.NewInternal()
+	}
+
+	return state, nil
+}
+
+func unmarshalHash(state []byte) (hash.Hash, error) {
+	h := sha256.New()
+
+	if err := h.(encoding.BinaryUnmarshaler).UnmarshalBinary(state); err != nil {
+		log.Printf("Unable to unmarshal hash: %v\n", err)
+		return nil, apperrors.NewInternal()
+	}
+
+	return h, nil
+}
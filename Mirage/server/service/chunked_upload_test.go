@@ -0,0 +1,303 @@
+package service
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/sentrionic/mirage/mocks"
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestMediaService_ChunkedUpload(t *testing.T) {
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+
+	data := bytes.Repeat([]byte("mirage"), (model.ChunkSize+10)/6+1)[:model.ChunkSize+10]
+	sum := sha256.Sum256(data)
+	checksum := hex.EncodeToString(sum[:])
+
+	t.Run("Uploads in chunks", func(t *testing.T) {
+		mockFileRepository := new(mocks.FileRepository)
+		mockUploadRepository := new(mocks.UploadRepository)
+		mockChunkedUploadRepository := new(mocks.ChunkedUploadRepository)
+
+		ms := NewMediaService(&MSConfig{
+			UploadRepository:        mockUploadRepository,
+			ChunkedUploadRepository: mockChunkedUploadRepository,
+			FileRepository:          mockFileRepository,
+			Clock:                   clock,
+		})
+
+		// keep the saved state like Redis would
+		var stored *model.ChunkedUpload
+		mockChunkedUploadRepository.
+			On("Save", mock.AnythingOfType("*model.ChunkedUpload")).
+			Run(func(args mock.Arguments) {
+				saved := *args.Get(0).(*model.ChunkedUpload)
+				stored = &saved
+			}).
+			Return(nil)
+		mockChunkedUploadRepository.
+			On("Get", mock.AnythingOfType("string")).
+			Return(func(id string) *model.ChunkedUpload {
+				if stored == nil {
+					return nil
+				}
+				copied := *stored
+				return &copied
+			}, func(id string) error {
+				if stored == nil {
+					return apperrors.NewNotFound("media", id)
+				}
+				return nil
+			})
+
+		mockFileRepository.On("CreateMultipartUpload", mock.AnythingOfType("string"), "video/mp4").Return("multipart", nil)
+		mockUploadRepository.On("Create", mock.AnythingOfType("*model.Upload")).Return(nil)
+
+		upload, err := ms.InitChunkedUpload("1", "video/mp4", int64(len(data)), checksum)
+		require.NoError(t, err)
+		assert.Equal(t, now.Add(chunkedUploadExpiration), upload.ExpiresAt)
+
+		mockFileRepository.On("UploadPart", upload.Key, "multipart", int64(1), mock.Anything).Return("etag-1", nil).Once()
+		mockFileRepository.On("UploadPart", upload.Key, "multipart", int64(2), mock.Anything).Return("etag-2", nil).Once()
+
+		upload, err = ms.AppendChunk("1", upload.ID, 0, data[:model.ChunkSize])
+		require.NoError(t, err)
+		assert.Equal(t, 1, upload.NextChunk)
+
+		// retrying a received chunk does not upload it again
+		upload, err = ms.AppendChunk("1", upload.ID, 0, data[:model.ChunkSize])
+		require.NoError(t, err)
+		assert.Equal(t, 1, upload.NextChunk)
+
+		_, err = ms.AppendChunk("1", upload.ID, 2, data[model.ChunkSize:])
+		assert.Equal(t, http.StatusBadRequest, apperrors.Status(err))
+
+		_, err = ms.AppendChunk("1", upload.ID, 1, data[model.ChunkSize:model.ChunkSize+5])
+		assert.Equal(t, http.StatusBadRequest, apperrors.Status(err))
+
+		upload, err = ms.AppendChunk("1", upload.ID, 1, data[model.ChunkSize:])
+		require.NoError(t, err)
+		assert.Equal(t, int64(len(data)), upload.Received)
+
+		parts := []model.UploadPart{{Number: 1, ETag: "etag-1"}, {Number: 2, ETag: "etag-2"}}
+		mockFileRepository.On("CompleteMultipartUpload", upload.Key, "multipart", parts).Return(nil)
+		mockChunkedUploadRepository.On("Delete", upload.ID).Return(nil)
+
+		finalized, err := ms.FinalizeChunkedUpload("1", upload.ID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, upload.ID, finalized.ID)
+		mockFileRepository.AssertExpectations(t)
+		mockChunkedUploadRepository.AssertExpectations(t)
+	})
+
+	t.Run("Other user's upload", func(t *testing.T) {
+		mockFileRepository := new(mocks.FileRepository)
+		mockUploadRepository := new(mocks.UploadRepository)
+		mockChunkedUploadRepository := new(mocks.ChunkedUploadRepository)
+
+		ms := NewMediaService(&MSConfig{
+			UploadRepository:        mockUploadRepository,
+			ChunkedUploadRepository: mockChunkedUploadRepository,
+			FileRepository:          mockFileRepository,
+			Clock:                   clock,
+		})
+
+		// keep the saved state like Redis would
+		var stored *model.ChunkedUpload
+		mockChunkedUploadRepository.
+			On("Save", mock.AnythingOfType("*model.ChunkedUpload")).
+			Run(func(args mock.Arguments) {
+				saved := *args.Get(0).(*model.ChunkedUpload)
+				stored = &saved
+			}).
+			Return(nil)
+		mockChunkedUploadRepository.
+			On("Get", mock.AnythingOfType("string")).
+			Return(func(id string) *model.ChunkedUpload {
+				if stored == nil {
+					return nil
+				}
+				copied := *stored
+				return &copied
+			}, func(id string) error {
+				if stored == nil {
+					return apperrors.NewNotFound("media", id)
+				}
+				return nil
+			})
+
+		mockFileRepository.On("CreateMultipartUpload", mock.AnythingOfType("string"), "video/mp4").Return("multipart", nil)
+		mockUploadRepository.On("Create", mock.AnythingOfType("*model.Upload")).Return(nil)
+
+		upload, err := ms.InitChunkedUpload("1", "video/mp4", int64(len(data)), checksum)
+		require.NoError(t, err)
+
+		_, err = ms.AppendChunk("2", upload.ID, 0, data[:model.ChunkSize])
+		assert.Equal(t, http.StatusNotFound, apperrors.Status(err))
+	})
+
+	t.Run("Incomplete upload", func(t *testing.T) {
+		mockFileRepository := new(mocks.FileRepository)
+		mockUploadRepository := new(mocks.UploadRepository)
+		mockChunkedUploadRepository := new(mocks.ChunkedUploadRepository)
+
+		ms := NewMediaService(&MSConfig{
+			UploadRepository:        mockUploadRepository,
+			ChunkedUploadRepository: mockChunkedUploadRepository,
+			FileRepository:          mockFileRepository,
+			Clock:                   clock,
+		})
+
+		// keep the saved state like Redis would
+		var stored *model.ChunkedUpload
+		mockChunkedUploadRepository.
+			On("Save", mock.AnythingOfType("*model.ChunkedUpload")).
+			Run(func(args mock.Arguments) {
+				saved := *args.Get(0).(*model.ChunkedUpload)
+				stored = &saved
+			}).
+			Return(nil)
+		mockChunkedUploadRepository.
+			On("Get", mock.AnythingOfType("string")).
+			Return(func(id string) *model.ChunkedUpload {
+				if stored == nil {
+					return nil
+				}
+				copied := *stored
+				return &copied
+			}, func(id string) error {
+				if stored == nil {
+					return apperrors.NewNotFound("media", id)
+				}
+				return nil
+			})
+
+		mockFileRepository.On("CreateMultipartUpload", mock.AnythingOfType("string"), "video/mp4").Return("multipart", nil)
+		mockUploadRepository.On("Create", mock.AnythingOfType("*model.Upload")).Return(nil)
+
+		upload, err := ms.InitChunkedUpload("1", "video/mp4", int64(len(data)), checksum)
+		require.NoError(t, err)
+
+		_, err = ms.FinalizeChunkedUpload("1", upload.ID)
+
+		assert.Equal(t, http.StatusBadRequest, apperrors.Status(err))
+		mockFileRepository.AssertNotCalled(t, "CompleteMultipartUpload", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Checksum mismatch discards the upload", func(t *testing.T) {
+		mockFileRepository := new(mocks.FileRepository)
+		mockUploadRepository := new(mocks.UploadRepository)
+		mockChunkedUploadRepository := new(mocks.ChunkedUploadRepository)
+
+		ms := NewMediaService(&MSConfig{
+			UploadRepository:        mockUploadRepository,
+			ChunkedUploadRepository: mockChunkedUploadRepository,
+			FileRepository:          mockFileRepository,
+			Clock:                   clock,
+		})
+
+		// keep the saved state like Redis would
+		var stored *model.ChunkedUpload
+		mockChunkedUploadRepository.
+			On("Save", mock.AnythingOfType("*model.ChunkedUpload")).
+			Run(func(args mock.Arguments) {
+				saved := *args.Get(0).(*model.ChunkedUpload)
+				stored = &saved
+			}).
+			Return(nil)
+		mockChunkedUploadRepository.
+			On("Get", mock.AnythingOfType("string")).
+			Return(func(id string) *model.ChunkedUpload {
+				if stored == nil {
+					return nil
+				}
+				copied := *stored
+				return &copied
+			}, func(id string) error {
+				if stored == nil {
+					return apperrors.NewNotFound("media", id)
+				}
+				return nil
+			})
+
+		mockFileRepository.On("CreateMultipartUpload", mock.AnythingOfType("string"), "video/mp4").Return("multipart", nil)
+		mockUploadRepository.On("Create", mock.AnythingOfType("*model.Upload")).Return(nil)
+
+		small := []byte("tiny video")
+		upload, err := ms.InitChunkedUpload("1", "video/mp4", int64(len(small)), checksum)
+		require.NoError(t, err)
+
+		mockFileRepository.On("UploadPart", upload.Key, "multipart", int64(1), mock.Anything).Return("etag-1", nil)
+		_, err = ms.AppendChunk("1", upload.ID, 0, small)
+		require.NoError(t, err)
+
+		record := &model.Upload{ID: upload.ID, Key: upload.Key, MultipartID: "multipart"}
+		mockUploadRepository.On("FindByID", upload.ID).Return(record, nil)
+		mockFileRepository.On("AbortMultipartUpload", upload.Key, "multipart").Return(nil)
+		mockFileRepository.On("DeleteImage", upload.Key).Return(apperrors.NewNotFound("file", upload.Key))
+		mockUploadRepository.On("Delete", record).Return(nil)
+		mockChunkedUploadRepository.On("Delete", upload.ID).Return(nil)
+
+		_, err = ms.FinalizeChunkedUpload("1", upload.ID)
+
+		assert.Equal(t, http.StatusBadRequest, apperrors.Status(err))
+		mockFileRepository.AssertCalled(t, "AbortMultipartUpload", upload.Key, "multipart")
+		mockFileRepository.AssertNotCalled(t, "CompleteMultipartUpload", mock.Anything, mock.Anything, mock.Anything)
+		mockUploadRepository.AssertExpectations(t)
+	})
+
+	t.Run("Invalid checksum", func(t *testing.T) {
+		mockFileRepository := new(mocks.FileRepository)
+		mockUploadRepository := new(mocks.UploadRepository)
+		mockChunkedUploadRepository := new(mocks.ChunkedUploadRepository)
+
+		ms := NewMediaService(&MSConfig{
+			UploadRepository:        mockUploadRepository,
+			ChunkedUploadRepository: mockChunkedUploadRepository,
+			FileRepository:          mockFileRepository,
+			Clock:                   clock,
+		})
+
+		// keep the saved state like Redis would
+		var stored *model.ChunkedUpload
+		mockChunkedUploadRepository.
+			On("Save", mock.AnythingOfType("*model.ChunkedUpload")).
+			Run(func(args mock.Arguments) {
+				saved := *args.Get(0).(*model.ChunkedUpload)
+				stored = &saved
+			}).
+			Return(nil)
+		mockChunkedUploadRepository.
+			On("Get", mock.AnythingOfType("string")).
+			Return(func(id string) *model.ChunkedUpload {
+				if stored == nil {
+					return nil
+				}
+				copied := *stored
+				return &copied
+			}, func(id string) error {
+				if stored == nil {
+					return apperrors.NewNotFound("media", id)
+				}
+				return nil
+			})
+
+		mockFileRepository.On("CreateMultipartUpload", mock.AnythingOfType("string"), "video/mp4").Return("multipart", nil)
+		mockUploadRepository.On("Create", mock.AnythingOfType("*model.Upload")).Return(nil)
+
+		_, err := ms.InitChunkedUpload("1", "video/mp4", int64(len(data)), "abc")
+
+		assert.Equal(t, http.StatusBadRequest, apperrors.Status(err))
+		mockFileRepository.AssertNotCalled(t, "CreateMultipartUpload", mock.Anything, mock.Anything)
+	})
+}
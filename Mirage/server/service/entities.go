@@ -0,0 +1,260 @@
+package service
+
+import (
+	"github.com/sentrionic/mirage/model"
+	"net/url"
+	"strings"
+	"unicode"
+)
+
+// maxMentionLength matches the longest allowed username
+const maxMentionLength = 15
+
+// maxCashtagLength is the longest ticker symbol
+const maxCashtagLength = 6
+
+// ExtractEntities finds the hashtags, mentions, cashtags and URLs in the text.
+//
+// An entity has to start at the beginning of the text or after a character
+// that can't be part of a word. Chinese, Japanese and Korean characters
+// count as separators since those languages don't use spaces,
+// so "今天#学习" contains the hashtag "学习". Entities end at the first
+// character that can't be part of them, e.g. punctuation like "," or "，".
+func ExtractEntities(text string) model.Entities {
+	runes := []rune(text)
+	entities := make(model.Entities, 0)
+
+	for i := 0; i < len(runes); {
+		var entity *model.Entity
+
+		if atBoundary(runes, i) {
+			switch {
+			case hasURLScheme(runes, i):
+				entity = extractURL(runes, i)
+			case runes[i] == '#' || runes[i] == '＃':
+				entity = extractHashtag(runes, i)
+			case runes[i] == '@' || runes[i] == '＠':
+				entity = extractMention(runes, i)
+			case runes[i] == '$':
+				entity = extractCashtag(runes, i)
+			}
+		}
+
+		if entity == nil {
+			i++
+			continue
+		}
+
+		entities = append(entities, *entity)
+		i = entity.End
+	}
+
+	return entities
+}
+
+// GetHashtags returns the distinct normalized hashtags of the text
+// in the "#tag" form stored in the posts table
+func GetHashtags(text string) []string {
+	list := make([]string, 0)
+	seen := make(map[string]bool)
+
+	for _, entity := range ExtractEntities(text) {
+		if entity.Type != model.EntityHashtag || seen[entity.Value] {
+			continue
+		}
+		seen[entity.Value] = true
+		list = append(list, "#"+entity.Value)
+	}
+
+	return list
+}
+
+func extractHashtag(runes []rune, start int) *model.Entity {
+	end := start + 1
+	hasLetter := false
+
+	for end < len(runes) && isHashtagRune(runes[end]) {
+		if unicode.IsLetter(runes[end]) || unicode.IsMark(runes[end]) {
+			hasLetter = true
+		}
+		end++
+	}
+
+	// tags consisting only of digits like #1 are not hashtags
+	if !hasLetter {
+		return nil
+	}
+
+	body := string(runes[start+1 : end])
+
+	return &model.Entity{
+		Type:  model.EntityHashtag,
+		Text:  string(runes[start:end]),
+		Value: strings.ToLower(body),
+		Start: start,
+		End:   end,
+	}
+}
+
+func extractMention(runes []rune, start int) *model.Entity {
+	end := start + 1
+
+	for end < len(runes) && isUsernameRune(runes[end]) {
+		end++
+	}
+
+	length := end - start - 1
+
+	// too long usernames or email addresses like @bob@bob.com
+	if length == 0 || length > maxMentionLength ||
+		(end < len(runes) && (runes[end] == '@' || runes[end] == '＠' || isWordRune(runes[end]))) {
+		return nil
+	}
+
+	return &model.Entity{
+		Type:  model.EntityMention,
+		Text:  string(runes[start:end]),
+		Value: strings.ToLower(string(runes[start+1 : end])),
+		Start: start,
+		End:   end,
+	}
+}
+
+func extractCashtag(runes []rune, start int) *model.Entity {
+	end := start + 1
+
+	for end < len(runes) && end-start-1 < maxCashtagLength && isASCIILetter(runes[end]) {
+		end++
+	}
+
+	if end == start+1 {
+		return nil
+	}
+
+	// share classes like $BRK.A
+	if end+1 < len(runes) && (runes[end] == '.' || runes[end] == '_') && isASCIILetter(runes[end+1]) {
+		end += 2
+		if end < len(runes) && isASCIILetter(runes[end]) {
+			end++
+		}
+	}
+
+	if end < len(runes) && (isWordRune(runes[end]) || unicode.IsDigit(runes[end])) {
+		return nil
+	}
+
+	return &model.Entity{
+		Type:  model.EntityCashtag,
+		Text:  string(runes[start:end]),
+		Value: strings.ToUpper(string(runes[start+1 : end])),
+		Start: start,
+		End:   end,
+	}
+}
+
+func extractURL(runes []rune, start int) *model.Entity {
+	end := start
+
+	for end < len(runes) && isURLRune(runes[end]) {
+		end++
+	}
+
+	// trailing punctuation usually ends the sentence, not the URL
+	for end > start {
+		r := runes[end-1]
+		if strings.ContainsRune(".,;:!?'\"*", r) ||
+			(r == ')' && unbalanced(runes[start:end], '(', ')')) ||
+			(r == ']' && unbalanced(runes[start:end], '[', ']')) {
+			end--
+			continue
+		}
+		break
+	}
+
+	raw := string(runes[start:end])
+	u, err := url.Parse(raw)
+
+	if err != nil || u.Host == "" {
+		return nil
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+
+	return &model.Entity{
+		Type:  model.EntityURL,
+		Text:  raw,
+		Value: u.String(),
+		Start: start,
+		End:   end,
+	}
+}
+
+// atBoundary reports whether an entity may start at position i
+func atBoundary(runes []rune, i int) bool {
+	if i == 0 {
+		return true
+	}
+
+	prev := runes[i-1]
+
+	// HTML entities like &#39;
+	if prev == '&' {
+		return false
+	}
+
+	return !isWordRune(prev)
+}
+
+// isWordRune reports whether the rune continues a word.
+// CJK characters are not counted since those languages don't use spaces.
+func isWordRune(r rune) bool {
+	if isCJK(r) {
+		return false
+	}
+
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r)
+}
+
+func isHashtagRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r)
+}
+
+func isUsernameRune(r rune) bool {
+	return r == '_' || isASCIILetter(r) || (r >= '0' && r <= '9')
+}
+
+func isASCIILetter(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
+
+func isURLRune(r rune) bool {
+	return r > ' ' && r < 0x7f && !strings.ContainsRune("<>\"{}|\\^`", r)
+}
+
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+func hasURLScheme(runes []rune, i int) bool {
+	for _, scheme := range []string{"https://", "http://"} {
+		if i+len(scheme) <= len(runes) && strings.EqualFold(string(runes[i:i+len(scheme)]), scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+// unbalanced reports whether the text contains more closing than opening brackets
+func unbalanced(runes []rune, open, close rune) bool {
+	depth := 0
+	for _, r := range runes {
+		switch r {
+		case open:
+			depth++
+		case close:
+			depth--
+		}
+	}
+	return depth < 0
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/fixture"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestGetHashtags(t *testing.T) {
+	t.Run("Returns an empty array if no hashtags", func(t *testing.T) {
+		text := fixture.RandStr(120)
+		list := GetHashtags(text)
+		assert.Empty(t, list)
+	})
+
+	t.Run("Returns an empty array if hashtags are at the wrong position", func(t *testing.T) {
+		text := "This is a test# pos#t"
+		list := GetHashtags(text)
+		assert.Empty(t, list)
+	})
+
+	t.Run("Returns a list of hashtags for a given text", func(t *testing.T) {
+		text := "This is a #test #post"
+		list := GetHashtags(text)
+		assert.Equal(t, len(list), 2)
+		assert.Equal(t, list[0], "#test")
+		assert.Equal(t, list[1], "#post")
+	})
+
+	t.Run("Finds hashtags after newlines and punctuation", func(t *testing.T) {
+		text := "First line\n#go,#Rust.(#zig) done"
+		list := GetHashtags(text)
+		assert.Equal(t, []string{"#go", "#rust", "#zig"}, list)
+	})
+
+	t.Run("Lowercases and removes duplicates", func(t *testing.T) {
+		text := "#GoLang #golang #Ünïcode"
+		list := GetHashtags(text)
+		assert.Equal(t, []string{"#golang", "#ünïcode"}, list)
+	})
+
+	t.Run("Chinese text", func(t *testing.T) {
+		text := "北京市平面图#北京，1722年。＃历史"
+		list := GetHashtags(text)
+		assert.Equal(t, []string{"#北京", "#历史"}, list)
+	})
+}
+
+func TestExtractEntities(t *testing.T) {
+	t.Run("All entity types with character offsets", func(t *testing.T) {
+		text := "Hi @Bob, 看 #Go! $aapl https://Example.com/Path?q=1."
+		entities := ExtractEntities(text)
+
+		assert.Equal(t, model.Entities{
+			{Type: model.EntityMention, Text: "@Bob", Value: "bob", Start: 3, End: 7},
+			{Type: model.EntityHashtag, Text: "#Go", Value: "go", Start: 11, End: 14},
+			{Type: model.EntityCashtag, Text: "$aapl", Value: "AAPL", Start: 16, End: 21},
+			{Type: model.EntityURL, Text: "https://Example.com/Path?q=1", Value: "https://example.com/Path?q=1", Start: 22, End: 50},
+		}, entities)
+	})
+
+	t.Run("Ignores emails, HTML entities and numbers", func(t *testing.T) {
+		text := "mail bob@bob.com &#39; #123 $100 @averyveryverylongname"
+		assert.Empty(t, ExtractEntities(text))
+	})
+
+	t.Run("Hashtags in URLs are not extracted", func(t *testing.T) {
+		entities := ExtractEntities("see http://mirage.xyz/#anchor")
+		assert.Len(t, entities, 1)
+		assert.Equal(t, model.EntityURL, entities[0].Type)
+		assert.Equal(t, "http://mirage.xyz/#anchor", entities[0].Value)
+	})
+
+	t.Run("Keeps balanced parentheses in URLs", func(t *testing.T) {
+		entities := ExtractEntities("(https://en.wikipedia.org/wiki/Go_(language))")
+		assert.Len(t, entities, 1)
+		assert.Equal(t, "https://en.wikipedia.org/wiki/Go_(language)", entities[0].Text)
+	})
+
+	t.Run("Full width signs", func(t *testing.T) {
+		entities := ExtractEntities("你好＠bob ＃話題")
+		assert.Equal(t, model.Entities{
+			{Type: model.EntityMention, Text: "＠bob", Value: "bob", Start: 2, End: 6},
+			{Type: model.EntityHashtag, Text: "＃話題", Value: "話題", Start: 7, End: 10},
+		}, entities)
+	})
+
+	t.Run("Share classes", func(t *testing.T) {
+		entities := ExtractEntities("$BRK.A")
+		assert.Len(t, entities, 1)
+		assert.Equal(t, "BRK.A", entities[0].Value)
+	})
+}
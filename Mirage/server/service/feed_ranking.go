@@ -0,0 +1,383 @@
+package service
+
+import (
+	"fmt"
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// topFeedWindow is how far back the top feed looks for posts
+	topFeedWindow = 3 * 24 * time.Hour
+	// topFeedCandidates is the number of posts the top feed ranks
+	topFeedCandidates = 500
+	// topFeedSnapshotExpiration is how long the following pages of
+	// the top feed keep the ranking of the first page
+	topFeedSnapshotExpiration = time.Hour
+)
+
+// feedRanker builds a page of the home timeline for one FeedMode.
+// It returns the posts and the cursor of the next page.
+type feedRanker interface {
+	Feed(userId, cursor string) (*[]model.Post, string, error)
+}
+
+// rangeFeedRanker is a feedRanker that can also build a page of the posts
+// that got into the home timeline within a time range
+type rangeFeedRanker interface {
+	FeedInRange(userId, cursor string, r model.TimeRange) (*[]model.Post, string, error)
+}
+
+// feedWeights are how much each signal adds to the score of a post
+type feedWeights struct {
+	Likes    float64
+	Retweets float64
+	// Affinity weighs the user's likes and retweets of the author's posts
+	Affinity  float64
+	Following float64
+	// HalfLife is the age at which a post's score is halved
+	HalfLife time.Duration
+}
+
+var topFeedWeights = feedWeights{
+	Likes:     1,
+	Retweets:  2,
+	Affinity:  0.5,
+	Following: 1,
+	HalfLife:  6 * time.Hour,
+}
+
+// chronologicalRanker orders by post or retweet time. It reads the
+// precomputed timeline if there is one.
+type chronologicalRanker struct {
+	PostRepository  model.PostRepository
+	TimelineService model.TimelineService
+}
+
+func (r *chronologicalRanker) Feed(userId, cursor string) (*[]model.Post, string, error) {
+	if r.TimelineService != nil {
+		return r.TimelineService.Timeline(userId, cursor)
+	}
+
+	return r.FeedInRange(userId, cursor, model.TimeRange{})
+}
+
+// FeedInRange always queries the database, since the precomputed
+// timeline only holds the most recent entries
+func (r *chronologicalRanker) FeedInRange(userId, cursor string, tr model.TimeRange) (*[]model.Post, string, error) {
+	after, err := parseEntryCursor(cursor)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	entries, err := r.PostRepository.FeedEntries(userId, entriesBefore(after, tr), timelinePageEntries)
+
+	if err != nil {
+		log.Printf("Unable to get feed entries of user: %v\n%v", userId, err)
+		return nil, "", apperrors.NewInternal()
+	}
+
+	entries, cut := entriesSince(entries, tr.Since)
+
+	shown, err := shownBefore(after, tr.Until, entries, func(postIds []string) ([]model.TimelineEntry, error) {
+		return r.PostRepository.FeedActivity(userId, postIds)
+	})
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	return buildTimelinePage(r.PostRepository, entries, !cut && len(entries) == timelinePageEntries, shown)
+}
+
+// topRanker orders the recent posts by their score. The score depends on
+// the time, so the cursor fixes the time the following pages are scored at
+// and the position in the ranking they start after. Likes and retweets
+// change the scores as well, so the scores of the first page are kept
+// in a snapshot for the following pages.
+type topRanker struct {
+	PostRepository model.PostRepository
+	// Snapshots keeps the ranking of the first page. Optional, without
+	// it or once it expired the following pages are scored again
+	Snapshots model.FeedSnapshotRepository
+	Clock     func() time.Time
+	Weights   feedWeights
+}
+
+func (r *topRanker) Feed(userId, cursor string) (*[]model.Post, string, error) {
+	after, err := parseTopCursor(cursor)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	now := r.Clock()
+	if after != nil {
+		now = after.Now
+	}
+
+	candidates, err := r.PostRepository.FeedCandidates(userId, now.Add(-topFeedWindow), topFeedCandidates)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	posts := make([]model.Post, 0)
+	for _, post := range uniquePosts(*candidates) {
+		// posts created after the first page would shift the ranking
+		if !post.CreatedAt.After(now) {
+			posts = append(posts, post)
+		}
+	}
+
+	var scores map[string]float64
+	if after != nil && r.Snapshots != nil {
+		scores, err = r.Snapshots.Get(userId, now)
+
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	if scores != nil {
+		posts = snapshotPosts(posts, scores)
+	} else {
+		scores, err = r.score(userId, posts, now)
+
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	rankPosts(posts, scores)
+
+	page := make([]model.Post, 0)
+	for _, post := range posts {
+		if after != nil && !after.precedes(&post, scores[post.ID]) {
+			continue
+		}
+		page = append(page, post)
+		if len(page) > model.LIMIT {
+			break
+		}
+	}
+
+	if err := r.attribute(userId, page); err != nil {
+		return nil, "", err
+	}
+
+	next := ""
+	if len(page) > model.LIMIT {
+		last := page[model.LIMIT-1]
+		next = (&topCursor{
+			Now:       now,
+			Score:     scores[last.ID],
+			CreatedAt: last.CreatedAt,
+			ID:        last.ID,
+		}).String()
+
+		// a failed snapshot only means the following pages are scored again
+		if after == nil && r.Snapshots != nil {
+			_ = r.Snapshots.Save(userId, now, scores, topFeedSnapshotExpiration)
+		}
+	}
+
+	return &page, next, nil
+}
+
+// score returns the scores of the posts at the given time by post ID
+func (r *topRanker) score(userId string, posts []model.Post, now time.Time) (map[string]float64, error) {
+	authors := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, post := range posts {
+		if !seen[post.UserID] {
+			seen[post.UserID] = true
+			authors = append(authors, post.UserID)
+		}
+	}
+
+	affinity, err := r.PostRepository.AuthorAffinity(userId, authors)
+
+	if err != nil {
+		log.Printf("Unable to get author affinity for user: %v\n%v", userId, err)
+		return nil, apperrors.NewInternal()
+	}
+
+	scores := make(map[string]float64, len(posts))
+	for i := range posts {
+		scores[posts[i].ID] = scorePost(&posts[i], affinity[posts[i].UserID], now, r.Weights)
+	}
+
+	return scores, nil
+}
+
+// snapshotPosts keeps the posts that were ranked on the first page
+func snapshotPosts(posts []model.Post, scores map[string]float64) []model.Post {
+	kept := make([]model.Post, 0, len(posts))
+
+	for _, post := range posts {
+		if _, ok := scores[post.ID]; ok {
+			kept = append(kept, post)
+		}
+	}
+
+	return kept
+}
+
+// attribute records which followees retweeted the posts and why
+// each post is in the feed, like the latest feed does
+func (r *topRanker) attribute(userId string, posts []model.Post) error {
+	ids := make([]string, len(posts))
+	for i, post := range posts {
+		ids[i] = post.ID
+	}
+
+	entries, err := r.PostRepository.FeedActivity(userId, ids)
+
+	if err != nil {
+		log.Printf("Unable to get activity of feed posts of user: %v\n%v", userId, err)
+		return apperrors.NewInternal()
+	}
+
+	groups := make(map[string]*timelineGroup)
+	for _, entry := range entries {
+		group, ok := groups[entry.PostID]
+		if !ok {
+			group = &timelineGroup{Newest: entry}
+			groups[entry.PostID] = group
+		}
+		group.Actors = append(group.Actors, entry.ActorID)
+	}
+
+	for i := range posts {
+		if group, ok := groups[posts[i].ID]; ok {
+			posts[i].RetweetedBy = retweeters(&posts[i], group.Actors)
+			posts[i].Activity = activity(&posts[i], group.Newest)
+		}
+	}
+
+	return nil
+}
+
+// topCursor is the time the top feed is scored at
+// and the last post of the previous page
+type topCursor struct {
+	Now       time.Time
+	Score     float64
+	CreatedAt time.Time
+	ID        string
+}
+
+// parseTopCursor returns nil for the first page
+func parseTopCursor(cursor string) (*topCursor, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+
+	parts := strings.SplitN(cursor, "_", 4)
+
+	if len(parts) != 4 || parts[3] == "" {
+		return nil, apperrors.NewBadRequest("invalid cursor")
+	}
+
+	now, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return nil, apperrors.NewBadRequest("invalid cursor")
+	}
+
+	score, err := strconv.ParseFloat(parts[1], 64)
+	if err != nil || math.IsNaN(score) {
+		return nil, apperrors.NewBadRequest("invalid cursor")
+	}
+
+	createdAt, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return nil, apperrors.NewBadRequest("invalid cursor")
+	}
+
+	return &topCursor{
+		Now:       time.Unix(0, now).UTC(),
+		Score:     score,
+		CreatedAt: time.Unix(0, createdAt).UTC(),
+		ID:        parts[3],
+	}, nil
+}
+
+func (c *topCursor) String() string {
+	return fmt.Sprintf("%d_%s_%d_%s",
+		c.Now.UnixNano(),
+		strconv.FormatFloat(c.Score, 'g', -1, 64),
+		c.CreatedAt.UnixNano(),
+		c.ID,
+	)
+}
+
+// precedes reports whether the cursor's post ranks before the given one
+func (c *topCursor) precedes(post *model.Post, score float64) bool {
+	if score != c.Score {
+		return score < c.Score
+	}
+	if !post.CreatedAt.Equal(c.CreatedAt) {
+		return post.CreatedAt.Before(c.CreatedAt)
+	}
+	return post.ID < c.ID
+}
+
+// rankPosts sorts the posts by score, newest and then highest ID first on ties
+func rankPosts(posts []model.Post, scores map[string]float64) {
+	sort.SliceStable(posts, func(i, j int) bool {
+		a, b := posts[i], posts[j]
+		if scores[a.ID] != scores[b.ID] {
+			return scores[a.ID] > scores[b.ID]
+		}
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.After(b.CreatedAt)
+		}
+		return a.ID > b.ID
+	})
+}
+
+// scorePost multiplies the post's engagement and the affinity to its
+// author and lets the result decay with the post's age
+func scorePost(post *model.Post, affinity model.AuthorAffinity, now time.Time, w feedWeights) float64 {
+	age := now.Sub(post.CreatedAt)
+	if age < 0 {
+		age = 0
+	}
+
+	recency := math.Exp2(-age.Hours() / w.HalfLife.Hours())
+
+	engagement := 1 +
+		w.Likes*math.Log1p(float64(len(post.Likes))) +
+		w.Retweets*math.Log1p(float64(len(post.Retweets)))
+
+	closeness := 1 + w.Affinity*math.Log1p(float64(affinity.Interactions))
+	if affinity.Following {
+		closeness += w.Following
+	}
+
+	return recency * engagement * closeness
+}
+
+// uniquePosts removes repeated posts, keeping the first one
+func uniquePosts(posts []model.Post) []model.Post {
+	seen := make(map[string]bool, len(posts))
+	unique := make([]model.Post, 0, len(posts))
+
+	for _, post := range posts {
+		if seen[post.ID] {
+			continue
+		}
+		seen[post.ID] = true
+		unique = append(unique, post)
+	}
+
+	return unique
+}
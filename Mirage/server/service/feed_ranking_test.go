@@ -0,0 +1,282 @@
+package service
+
+import (
+	"github.com/sentrionic/mirage/mocks"
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestPostService_GetUserFeed_Top(t *testing.T) {
+	now := time.Date(2021, 5, 1, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	users := func(n int) []model.User {
+		return make([]model.User, n)
+	}
+
+	t.Run("Ranks by engagement, affinity and recency", func(t *testing.T) {
+		candidates := []model.Post{
+			{ID: "1", UserID: "a", CreatedAt: now.Add(-time.Hour)},
+			{ID: "2", UserID: "b", CreatedAt: now.Add(-2 * time.Hour), Likes: users(20), Retweets: users(5)},
+			{ID: "3", UserID: "c", CreatedAt: now.Add(-time.Hour)},
+			{ID: "4", UserID: "a", CreatedAt: now.Add(-48 * time.Hour), Likes: users(20), Retweets: users(5)},
+			// joins can return a post more than once
+			{ID: "3", UserID: "c", CreatedAt: now.Add(-time.Hour)},
+		}
+
+		mockPostRepository := new(mocks.PostRepository)
+		ps := NewPostService(&PSConfig{
+			PostRepository: mockPostRepository,
+			Clock:          clock,
+		})
+
+		mockPostRepository.On("FeedCandidates", "u", now.Add(-topFeedWindow), topFeedCandidates).Return(&candidates, nil)
+		mockPostRepository.On("AuthorAffinity", "u", []string{"a", "b", "c"}).Return(map[string]model.AuthorAffinity{
+			"c": {AuthorID: "c", Interactions: 10, Following: true},
+		}, nil)
+		mockPostRepository.On("FeedActivity", "u", []string{"2", "3", "1", "4"}).Return([]model.TimelineEntry{}, nil)
+
+		posts, next, err := ps.GetUserFeed("u", model.FeedTop, "", model.TimeRange{})
+
+		assert.NoError(t, err)
+		assert.Empty(t, next)
+
+		ids := make([]string, 0)
+		for _, p := range *posts {
+			ids = append(ids, p.ID)
+		}
+		assert.Equal(t, []string{"2", "3", "1", "4"}, ids)
+	})
+
+	t.Run("Ties keep newest first", func(t *testing.T) {
+		candidates := []model.Post{
+			{ID: "1", UserID: "a", CreatedAt: now.Add(-time.Hour)},
+			{ID: "2", UserID: "a", CreatedAt: now.Add(-time.Hour)},
+		}
+
+		mockPostRepository := new(mocks.PostRepository)
+		ps := NewPostService(&PSConfig{
+			PostRepository: mockPostRepository,
+			Clock:          clock,
+		})
+
+		mockPostRepository.On("FeedCandidates", "u", mock.Anything, mock.Anything).Return(&candidates, nil)
+		mockPostRepository.On("AuthorAffinity", "u", mock.Anything).Return(map[string]model.AuthorAffinity{}, nil)
+		mockPostRepository.On("FeedActivity", "u", mock.Anything).Return([]model.TimelineEntry{}, nil)
+
+		posts, _, err := ps.GetUserFeed("u", model.FeedTop, "", model.TimeRange{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "2", (*posts)[0].ID)
+		assert.Equal(t, "1", (*posts)[1].ID)
+	})
+
+	t.Run("Pages by score at the time of the first page", func(t *testing.T) {
+		candidates := make([]model.Post, 0)
+		for i := 0; i < 50; i++ {
+			candidates = append(candidates, model.Post{
+				ID:        string(rune('A' + i)),
+				UserID:    "a",
+				CreatedAt: now.Add(-time.Duration(i) * time.Minute),
+			})
+		}
+
+		current := now
+		mockPostRepository := new(mocks.PostRepository)
+		ps := NewPostService(&PSConfig{
+			PostRepository: mockPostRepository,
+			Clock:          func() time.Time { return current },
+		})
+
+		mockPostRepository.On("FeedCandidates", "u", now.Add(-topFeedWindow), topFeedCandidates).Return(&candidates, nil)
+		mockPostRepository.On("AuthorAffinity", "u", mock.Anything).Return(map[string]model.AuthorAffinity{}, nil)
+		mockPostRepository.On("FeedActivity", "u", mock.Anything).Return([]model.TimelineEntry{}, nil)
+
+		first, next, err := ps.GetUserFeed("u", model.FeedTop, "", model.TimeRange{})
+		assert.NoError(t, err)
+		assert.Len(t, *first, model.LIMIT+1)
+		assert.NotEmpty(t, next)
+
+		// a post created after the first page doesn't shift the following pages
+		current = now.Add(time.Hour)
+		candidates = append([]model.Post{{ID: "new", UserID: "a", CreatedAt: now.Add(time.Minute)}}, candidates...)
+
+		second, next, err := ps.GetUserFeed("u", model.FeedTop, next, model.TimeRange{})
+		assert.NoError(t, err)
+		assert.Len(t, *second, model.LIMIT+1)
+		assert.Equal(t, (*first)[model.LIMIT].ID, (*second)[0].ID)
+		assert.NotEmpty(t, next)
+
+		last, next, err := ps.GetUserFeed("u", model.FeedTop, next, model.TimeRange{})
+		assert.NoError(t, err)
+		assert.Len(t, *last, 10)
+		assert.Equal(t, candidates[len(candidates)-1].ID, (*last)[9].ID)
+		assert.Empty(t, next)
+	})
+
+	t.Run("Keeps the ranking of the first page", func(t *testing.T) {
+		candidates := make([]model.Post, 0)
+		for i := 0; i < 30; i++ {
+			candidates = append(candidates, model.Post{
+				ID:        string(rune('A' + i)),
+				UserID:    "a",
+				CreatedAt: now.Add(-time.Duration(i) * time.Minute),
+			})
+		}
+
+		mockPostRepository := new(mocks.PostRepository)
+		mockSnapshotRepository := new(mocks.FeedSnapshotRepository)
+		ps := NewPostService(&PSConfig{
+			PostRepository:         mockPostRepository,
+			FeedSnapshotRepository: mockSnapshotRepository,
+			Clock:                  clock,
+		})
+
+		var snapshot map[string]float64
+		mockSnapshotRepository.
+			On("Save", "u", now, mock.AnythingOfType("map[string]float64"), topFeedSnapshotExpiration).
+			Run(func(args mock.Arguments) {
+				snapshot = args.Get(2).(map[string]float64)
+			}).
+			Return(nil)
+		mockSnapshotRepository.
+			On("Get", "u", now).
+			Return(func(string, time.Time) map[string]float64 { return snapshot }, nil)
+
+		mockPostRepository.On("FeedCandidates", "u", now.Add(-topFeedWindow), topFeedCandidates).Return(&candidates, nil)
+		mockPostRepository.On("AuthorAffinity", "u", mock.Anything).Return(map[string]model.AuthorAffinity{}, nil).Once()
+		mockPostRepository.On("FeedActivity", "u", mock.Anything).Return([]model.TimelineEntry{}, nil)
+
+		first, next, err := ps.GetUserFeed("u", model.FeedTop, "", model.TimeRange{})
+		assert.NoError(t, err)
+		assert.Len(t, *first, model.LIMIT+1)
+		assert.Len(t, snapshot, len(candidates))
+
+		// a post on the second page that gets popular doesn't move up
+		candidates[len(candidates)-1].Likes = users(100)
+
+		second, next, err := ps.GetUserFeed("u", model.FeedTop, next, model.TimeRange{})
+		assert.NoError(t, err)
+		assert.Len(t, *second, 10)
+		assert.Equal(t, (*first)[model.LIMIT].ID, (*second)[0].ID)
+		assert.Equal(t, candidates[len(candidates)-1].ID, (*second)[9].ID)
+		assert.Empty(t, next)
+		mockPostRepository.AssertExpectations(t)
+		mockSnapshotRepository.AssertNumberOfCalls(t, "Save", 1)
+	})
+
+	t.Run("Keeps who retweeted a post", func(t *testing.T) {
+		b, c := model.User{ID: "b"}, model.User{ID: "c"}
+		candidates := []model.Post{
+			{ID: "1", UserID: "a", CreatedAt: now.Add(-2 * time.Hour), Retweets: []model.User{b, c}},
+			{ID: "2", UserID: "b", CreatedAt: now.Add(-time.Hour), User: b},
+		}
+
+		mockPostRepository := new(mocks.PostRepository)
+		ps := NewPostService(&PSConfig{
+			PostRepository: mockPostRepository,
+			Clock:          clock,
+		})
+
+		mockPostRepository.On("FeedCandidates", "u", mock.Anything, mock.Anything).Return(&candidates, nil)
+		mockPostRepository.On("AuthorAffinity", "u", mock.Anything).Return(map[string]model.AuthorAffinity{}, nil)
+		// the user follows b and c, but not the author of post 1
+		mockPostRepository.On("FeedActivity", "u", mock.Anything).Return([]model.TimelineEntry{
+			{PostID: "1", ActorID: "c", CreatedAt: now.Add(-10 * time.Minute)},
+			{PostID: "2", ActorID: "b", CreatedAt: now.Add(-time.Hour)},
+			{PostID: "1", ActorID: "b", CreatedAt: now.Add(-90 * time.Minute)},
+		}, nil)
+
+		posts, _, err := ps.GetUserFeed("u", model.FeedTop, "", model.TimeRange{})
+
+		assert.NoError(t, err)
+		for _, post := range *posts {
+			switch post.ID {
+			case "1":
+				assert.Equal(t, []model.User{c, b}, post.RetweetedBy)
+				assert.Equal(t, &model.TimelineActivity{Reason: model.ReasonRetweetedBy, Actor: c, At: now.Add(-10 * time.Minute)}, post.Activity)
+			case "2":
+				assert.Empty(t, post.RetweetedBy)
+				assert.Equal(t, model.ReasonAuthored, post.Activity.Reason)
+			}
+		}
+	})
+
+	t.Run("Invalid cursor", func(t *testing.T) {
+		ps := NewPostService(&PSConfig{PostRepository: new(mocks.PostRepository)})
+
+		_, _, err := ps.GetUserFeed("u", model.FeedTop, "2021-05-01T12:00:00Z", model.TimeRange{})
+
+		assert.Equal(t, http.StatusBadRequest, apperrors.Status(err))
+	})
+
+	t.Run("Invalid mode", func(t *testing.T) {
+		ps := NewPostService(&PSConfig{PostRepository: new(mocks.PostRepository)})
+
+		_, _, err := ps.GetUserFeed("u", "random", "", model.TimeRange{})
+
+		assert.Equal(t, http.StatusBadRequest, apperrors.Status(err))
+	})
+}
+
+func TestPostService_GetUserFeed_LatestCursor(t *testing.T) {
+	now := time.Date(2021, 5, 1, 12, 0, 0, 0, time.UTC)
+	entries := make([]model.TimelineEntry, 0)
+	posts := make([]model.Post, 0)
+	for i := 0; i <= model.LIMIT; i++ {
+		id := string(rune('A' + i))
+		entries = append(entries, model.TimelineEntry{PostID: id, ActorID: "b", CreatedAt: now.Add(-time.Duration(i) * time.Minute)})
+		posts = append(posts, model.Post{ID: id, UserID: "b"})
+	}
+
+	mockPostRepository := new(mocks.PostRepository)
+	ps := NewPostService(&PSConfig{PostRepository: mockPostRepository})
+	mockPostRepository.On("FeedEntries", "u", (*model.TimelineEntry)(nil), timelinePageEntries).Return(entries, nil)
+	mockPostRepository.On("FindByIDs", mock.Anything).Return(&posts, nil)
+
+	feed, next, err := ps.GetUserFeed("u", model.FeedLatest, "", model.TimeRange{})
+
+	assert.NoError(t, err)
+	assert.Len(t, *feed, model.LIMIT+1)
+	assert.Equal(t, formatEntryCursor(entries[model.LIMIT-1]), next)
+}
+
+func TestPostService_GetUserFeed_TimeRange(t *testing.T) {
+	now := time.Date(2021, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Latest bypasses the timeline", func(t *testing.T) {
+		until := now.Add(-time.Hour)
+		entries := []model.TimelineEntry{
+			{PostID: "A", ActorID: "b", CreatedAt: now.Add(-2 * time.Hour)},
+			{PostID: "B", ActorID: "b", CreatedAt: now.Add(-48 * time.Hour)},
+		}
+
+		mockPostRepository := new(mocks.PostRepository)
+		mockTimelineService := new(mocks.TimelineService)
+		ps := NewPostService(&PSConfig{PostRepository: mockPostRepository, TimelineService: mockTimelineService})
+		mockPostRepository.On("FeedEntries", "u", &model.TimelineEntry{CreatedAt: until}, timelinePageEntries).Return(entries, nil)
+		mockPostRepository.On("FindByIDs", []string{"A"}).Return(&[]model.Post{{ID: "A", UserID: "b"}}, nil)
+
+		feed, next, err := ps.GetUserFeed("u", model.FeedLatest, "", model.TimeRange{Since: now.Add(-24 * time.Hour), Until: until})
+
+		assert.NoError(t, err)
+		assert.Len(t, *feed, 1)
+		assert.Empty(t, next)
+		mockPostRepository.AssertExpectations(t)
+		mockTimelineService.AssertNotCalled(t, "Timeline")
+	})
+
+	t.Run("Top rejects a range", func(t *testing.T) {
+		mockPostRepository := new(mocks.PostRepository)
+		ps := NewPostService(&PSConfig{PostRepository: mockPostRepository})
+
+		_, _, err := ps.GetUserFeed("u", model.FeedTop, "", model.TimeRange{Since: now})
+
+		assert.Equal(t, http.StatusBadRequest, apperrors.Status(err))
+		mockPostRepository.AssertNotCalled(t, "FeedCandidates")
+	})
+}
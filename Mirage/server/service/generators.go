@@ -5,7 +5,6 @@ import (
 	"encoding/hex"
 	"fmt"
 	"github.com/bwmarrin/snowflake"
-	"strings"
 )
 
 // GenerateId generates a snowflake id
@@ -29,16 +28,3 @@ func GetGravatar(email string) string {
 	value := hex.EncodeToString(hash[:])
 	return fmt.Sprintf("https://gravatar.com/avatar/%s?d=identicon", value)
 }
-
-func GetHashtags(text string) []string {
-	list := make([]string, 0)
-	words := strings.Split(text, " ")
-	for _, word := range words {
-		word = strings.TrimSpace(word)
-		if strings.HasPrefix(word, "#") {
-			list = append(list, word)
-		}
-	}
-
-	return list
-}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"strings"
+	"unicode"
+)
+
+// maxQueryHashtags is the most tags a hashtag search may combine
+const maxQueryHashtags = 5
+
+// parseHashtagQuery parses a hashtag search like "#go #rust", which finds the posts
+// with both tags, or "#go OR #rust", which finds the posts with either tag.
+// AND may be written out. Mixing AND and OR is not supported.
+func parseHashtagQuery(search string) (model.HashtagQuery, error) {
+	query := model.HashtagQuery{MatchAll: true}
+	seen := make(map[string]bool)
+	hasOr, hasAnd := false, false
+
+	fields := strings.FieldsFunc(search, func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	})
+
+	for _, field := range fields {
+		switch field {
+		case "OR":
+			hasOr = true
+			continue
+		case "AND":
+			hasAnd = true
+			continue
+		}
+
+		tag, ok := normalizeHashtag(field)
+
+		if !ok {
+			return query, apperrors.NewBadRequest("invalid hashtag: " + field)
+		}
+
+		if !seen[tag] {
+			seen[tag] = true
+			query.Tags = append(query.Tags, tag)
+		}
+	}
+
+	if hasOr && hasAnd {
+		return query, apperrors.NewBadRequest("a search can't combine AND and OR")
+	}
+
+	if len(query.Tags) > maxQueryHashtags {
+		return query, apperrors.NewBadRequest("a search can have at most 5 hashtags")
+	}
+
+	query.MatchAll = !hasOr
+
+	return query, nil
+}
+
+// normalizeHashtag returns the tag in the "#tag" form stored in the posts
+// table. The # is optional. It reports false if the text is not one hashtag.
+func normalizeHashtag(text string) (string, bool) {
+	text = strings.TrimPrefix(strings.TrimPrefix(text, "#"), "＃")
+
+	tags := GetHashtags("#" + text)
+
+	if len(tags) != 1 || tags[0] != "#"+strings.ToLower(text) {
+		return "", false
+	}
+
+	return tags[0], true
+}
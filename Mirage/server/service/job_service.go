@@ -0,0 +1,313 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// jobMaxAttempts is how often a job runs before it gets dead lettered
+	jobMaxAttempts = 5
+	// jobBackoffBase is the delay before the first retry. Every
+	// further retry doubles it up to jobBackoffMax.
+	jobBackoffBase = 10 * time.Second
+	jobBackoffMax  = 10 * time.Minute
+	// jobPollTimeout is how long a worker waits for a job before
+	// checking if it should stop
+	jobPollTimeout = 5 * time.Second
+	// jobVisibilityTimeout is how long a popped job is leased to its worker.
+	// The lease is renewed while the job runs, jobs of a worker that stopped
+	// renewing it are queued again.
+	jobVisibilityTimeout = 2 * time.Minute
+)
+
+type jobService struct {
+	JobQueue model.JobQueue
+	Clock    func() time.Time
+	mu       sync.RWMutex
+	handlers map[string]model.JobHandler
+}
+
+// JSConfig will hold repositories that will eventually be injected into this
+// this service layer
+type JSConfig struct {
+	JobQueue model.JobQueue
+	// Clock returns the current time. Defaults to time.Now
+	Clock func() time.Time
+}
+
+// NewJobService is a factory function for
+// initializing a JobService with its repository layer dependencies
+func NewJobService(c *JSConfig) model.JobService {
+	clock := c.Clock
+	if clock == nil {
+		clock = time.Now
+	}
+
+	return &jobService{
+		JobQueue: c.JobQueue,
+		Clock:    clock,
+		handlers: make(map[string]model.JobHandler),
+	}
+}
+
+// Enqueue adds a job with the given payload to the queue
+func (s *jobService) Enqueue(jobType, userId string, payload interface{}) (*model.Job, error) {
+	data, err := json.Marshal(payload)
+
+	if err != nil {
+		log.Printf("Unable to encode payload of job: %v\n%v", jobType, err)
+		return nil, apperrors.NewInternal()
+	}
+
+	id, err := GenerateId()
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.Clock()
+	job := &model.Job{
+		ID:          id,
+		Type:        jobType,
+		UserID:      userId,
+		Payload:     data,
+		Status:      model.JobQueued,
+		MaxAttempts: jobMaxAttempts,
+		RunAt:       now,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	if err := s.JobQueue.Save(job); err != nil {
+		return nil, err
+	}
+
+	if err := s.JobQueue.Push(job); err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// Handle registers the handler that processes jobs of the given type
+func (s *jobService) Handle(jobType string, handler model.JobHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[jobType] = handler
+}
+
+// GetJob returns the job if it belongs to the user
+func (s *jobService) GetJob(userId, id string) (*model.Job, error) {
+	job, err := s.JobQueue.Get(id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if job.UserID != userId {
+		return nil, apperrors.NewNotFound("job", id)
+	}
+
+	return job, nil
+}
+
+// ProcessNext runs the next ready job. It returns false
+// if no job became ready within the timeout.
+func (s *jobService) ProcessNext(timeout time.Duration) (bool, error) {
+	job, err := s.JobQueue.Pop(timeout, jobVisibilityTimeout)
+
+	if err != nil {
+		return false, err
+	}
+
+	if job == nil {
+		return false, nil
+	}
+
+	stop := s.renewLease(job.ID)
+	err = s.run(job)
+	stop()
+
+	// jobs that could not be saved are run again once their lease expired
+	if err != nil {
+		return true, err
+	}
+
+	return true, s.JobQueue.Ack(job.ID)
+}
+
+// renewLease extends the lease of the job until the returned function gets called
+func (s *jobService) renewLease(id string) func() {
+	done := make(chan struct{})
+
+	go func() {
+		ticker := time.NewTicker(jobVisibilityTimeout / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := s.JobQueue.Extend(id, jobVisibilityTimeout); err != nil {
+					log.Printf("Unable to renew lease of job: %v\n%v", id, err)
+				}
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
+
+func (s *jobService) run(job *model.Job) error {
+	s.mu.RLock()
+	handler, ok := s.handlers[job.Type]
+	s.mu.RUnlock()
+
+	job.Attempts++
+
+	if !ok {
+		// retrying doesn't help until a worker knows the type
+		job.LastError = fmt.Sprintf("no handler for job type: %v", job.Type)
+		return s.bury(job)
+	}
+
+	job.Status = model.JobRunning
+	job.UpdatedAt = s.Clock()
+
+	if err := s.JobQueue.Save(job); err != nil {
+		return err
+	}
+
+	if err := callJobHandler(handler, job.Payload); err != nil {
+		log.Printf("Job %v of type %v failed: %v\n", job.ID, job.Type, err)
+		job.LastError = err.Error()
+
+		if job.Attempts >= job.MaxAttempts {
+			return s.bury(job)
+		}
+
+		job.Status = model.JobRetrying
+		job.UpdatedAt = s.Clock()
+		job.RunAt = job.UpdatedAt.Add(jobBackoff(job.Attempts))
+
+		if err := s.JobQueue.Save(job); err != nil {
+			return err
+		}
+
+		return s.JobQueue.Schedule(job)
+	}
+
+	job.Status = model.JobSucceeded
+	job.LastError = ""
+	job.UpdatedAt = s.Clock()
+
+	return s.JobQueue.Save(job)
+}
+
+// bury moves the job to the dead letter queue
+func (s *jobService) bury(job *model.Job) error {
+	job.Status = model.JobDead
+	job.UpdatedAt = s.Clock()
+
+	if err := s.JobQueue.Save(job); err != nil {
+		return err
+	}
+
+	return s.JobQueue.DeadLetter(job)
+}
+
+// callJobHandler turns a panicking handler into a failed attempt
+func callJobHandler(handler model.JobHandler, payload json.RawMessage) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+
+	return handler(payload)
+}
+
+// jobBackoff returns the delay before the given attempt gets retried
+func jobBackoff(attempts int) time.Duration {
+	backoff := jobBackoffBase
+
+	for i := 1; i < attempts && backoff < jobBackoffMax; i++ {
+		backoff *= 2
+	}
+
+	if backoff > jobBackoffMax {
+		return jobBackoffMax
+	}
+
+	return backoff
+}
+
+// Work processes jobs with the given number of workers until the context is done
+func (s *jobService) Work(ctx context.Context, workers int) {
+	var wg sync.WaitGroup
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for ctx.Err() == nil {
+				if _, err := s.ProcessNext(jobPollTimeout); err != nil {
+					log.Printf("Unable to process job: %v\n", err)
+					// don't spin while the queue is unavailable
+					select {
+					case <-ctx.Done():
+					case <-time.After(time.Second):
+					}
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+// RetryDead moves all dead lettered jobs back to the
+// ready queue and returns how many got moved
+func (s *jobService) RetryDead() (int, error) {
+	retried := 0
+
+	for {
+		job, err := s.JobQueue.PopDead()
+
+		if err != nil {
+			// the job expired while it was dead lettered
+			if apperrors.Status(err) == http.StatusNotFound {
+				continue
+			}
+			return retried, err
+		}
+
+		if job == nil {
+			return retried, nil
+		}
+
+		job.Status = model.JobQueued
+		job.Attempts = 0
+		job.RunAt = s.Clock()
+		job.UpdatedAt = job.RunAt
+
+		if err := s.JobQueue.Save(job); err != nil {
+			return retried, err
+		}
+
+		if err := s.JobQueue.Push(job); err != nil {
+			return retried, err
+		}
+
+		retried++
+	}
+}
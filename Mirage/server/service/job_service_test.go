@@ -0,0 +1,248 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/sentrionic/mirage/mocks"
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestJobService_Enqueue(t *testing.T) {
+	now := time.Date(2021, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	mockJobQueue := new(mocks.JobQueue)
+	js := NewJobService(&JSConfig{
+		JobQueue: mockJobQueue,
+		Clock:    func() time.Time { return now },
+	})
+
+	mockJobQueue.On("Save", mock.AnythingOfType("*model.Job")).Return(nil)
+	mockJobQueue.On("Push", mock.AnythingOfType("*model.Job")).Return(nil)
+
+	job, err := js.Enqueue(model.JobMediaVariants, "1", &variantsJob{Hash: "abc"})
+
+	assert.NoError(t, err)
+	assert.NotEmpty(t, job.ID)
+	assert.Equal(t, model.JobMediaVariants, job.Type)
+	assert.Equal(t, "1", job.UserID)
+	assert.JSONEq(t, `{"hash":"abc"}`, string(job.Payload))
+	assert.Equal(t, model.JobQueued, job.Status)
+	assert.Equal(t, jobMaxAttempts, job.MaxAttempts)
+	assert.Equal(t, now, job.RunAt)
+	mockJobQueue.AssertExpectations(t)
+}
+
+func TestJobService_GetJob(t *testing.T) {
+	job := &model.Job{ID: "10", UserID: "1", Status: model.JobSucceeded}
+
+	mockJobQueue := new(mocks.JobQueue)
+	js := NewJobService(&JSConfig{JobQueue: mockJobQueue})
+	mockJobQueue.On("Get", "10").Return(job, nil)
+
+	t.Run("Owner", func(t *testing.T) {
+		found, err := js.GetJob("1", "10")
+
+		assert.NoError(t, err)
+		assert.Equal(t, job, found)
+	})
+
+	t.Run("Someone else's job", func(t *testing.T) {
+		found, err := js.GetJob("2", "10")
+
+		assert.Nil(t, found)
+		assert.Equal(t, http.StatusNotFound, apperrors.Status(err))
+	})
+}
+
+func TestJobService_ProcessNext(t *testing.T) {
+	now := time.Date(2021, 5, 1, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+
+	newJob := func(attempts int) *model.Job {
+		return &model.Job{
+			ID:          "10",
+			Type:        model.JobMediaVariants,
+			Payload:     json.RawMessage(`{"hash":"abc"}`),
+			Status:      model.JobQueued,
+			Attempts:    attempts,
+			MaxAttempts: 3,
+		}
+	}
+
+	t.Run("Empty queue", func(t *testing.T) {
+		mockJobQueue := new(mocks.JobQueue)
+		js := NewJobService(&JSConfig{JobQueue: mockJobQueue, Clock: clock})
+		mockJobQueue.On("Pop", time.Second, jobVisibilityTimeout).Return(nil, nil)
+
+		processed, err := js.ProcessNext(time.Second)
+
+		assert.NoError(t, err)
+		assert.False(t, processed)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		job := newJob(0)
+		mockJobQueue := new(mocks.JobQueue)
+		js := NewJobService(&JSConfig{JobQueue: mockJobQueue, Clock: clock})
+
+		var payload json.RawMessage
+		js.Handle(model.JobMediaVariants, func(p json.RawMessage) error {
+			payload = p
+			return nil
+		})
+
+		mockJobQueue.On("Pop", time.Second, jobVisibilityTimeout).Return(job, nil)
+		mockJobQueue.On("Ack", job.ID).Return(nil)
+		mockJobQueue.On("Save", job).Return(nil)
+
+		processed, err := js.ProcessNext(time.Second)
+
+		assert.NoError(t, err)
+		assert.True(t, processed)
+		assert.JSONEq(t, `{"hash":"abc"}`, string(payload))
+		assert.Equal(t, model.JobSucceeded, job.Status)
+		assert.Equal(t, 1, job.Attempts)
+		mockJobQueue.AssertNotCalled(t, "Schedule", mock.Anything)
+		mockJobQueue.AssertNotCalled(t, "DeadLetter", mock.Anything)
+		mockJobQueue.AssertCalled(t, "Ack", job.ID)
+	})
+
+	t.Run("Job stays leased if its state can't be saved", func(t *testing.T) {
+		job := newJob(0)
+		mockJobQueue := new(mocks.JobQueue)
+		js := NewJobService(&JSConfig{JobQueue: mockJobQueue, Clock: clock})
+		js.Handle(model.JobMediaVariants, func(json.RawMessage) error {
+			return nil
+		})
+
+		mockJobQueue.On("Pop", time.Second, jobVisibilityTimeout).Return(job, nil)
+		mockJobQueue.On("Save", job).Return(apperrors.NewInternal())
+
+		processed, err := js.ProcessNext(time.Second)
+
+		assert.Error(t, err)
+		assert.True(t, processed)
+		mockJobQueue.AssertNotCalled(t, "Ack", mock.Anything)
+	})
+
+	t.Run("Failure gets retried with backoff", func(t *testing.T) {
+		job := newJob(1)
+		mockJobQueue := new(mocks.JobQueue)
+		js := NewJobService(&JSConfig{JobQueue: mockJobQueue, Clock: clock})
+		js.Handle(model.JobMediaVariants, func(json.RawMessage) error {
+			return errors.New("storage unavailable")
+		})
+
+		mockJobQueue.On("Pop", time.Second, jobVisibilityTimeout).Return(job, nil)
+		mockJobQueue.On("Ack", job.ID).Return(nil)
+		mockJobQueue.On("Save", job).Return(nil)
+		mockJobQueue.On("Schedule", job).Return(nil)
+
+		processed, err := js.ProcessNext(time.Second)
+
+		assert.NoError(t, err)
+		assert.True(t, processed)
+		assert.Equal(t, model.JobRetrying, job.Status)
+		assert.Equal(t, 2, job.Attempts)
+		assert.Equal(t, "storage unavailable", job.LastError)
+		// the second attempt waits twice as long as the first
+		assert.Equal(t, now.Add(20*time.Second), job.RunAt)
+		mockJobQueue.AssertCalled(t, "Schedule", job)
+		mockJobQueue.AssertNotCalled(t, "DeadLetter", mock.Anything)
+	})
+
+	t.Run("Panic counts as failure", func(t *testing.T) {
+		job := newJob(0)
+		mockJobQueue := new(mocks.JobQueue)
+		js := NewJobService(&JSConfig{JobQueue: mockJobQueue, Clock: clock})
+		js.Handle(model.JobMediaVariants, func(json.RawMessage) error {
+			panic("nil map")
+		})
+
+		mockJobQueue.On("Pop", time.Second, jobVisibilityTimeout).Return(job, nil)
+		mockJobQueue.On("Ack", job.ID).Return(nil)
+		mockJobQueue.On("Save", job).Return(nil)
+		mockJobQueue.On("Schedule", job).Return(nil)
+
+		_, err := js.ProcessNext(time.Second)
+
+		assert.NoError(t, err)
+		assert.Equal(t, model.JobRetrying, job.Status)
+		assert.Contains(t, job.LastError, "nil map")
+	})
+
+	t.Run("Last attempt gets dead lettered", func(t *testing.T) {
+		job := newJob(2)
+		mockJobQueue := new(mocks.JobQueue)
+		js := NewJobService(&JSConfig{JobQueue: mockJobQueue, Clock: clock})
+		js.Handle(model.JobMediaVariants, func(json.RawMessage) error {
+			return errors.New("invalid image")
+		})
+
+		mockJobQueue.On("Pop", time.Second, jobVisibilityTimeout).Return(job, nil)
+		mockJobQueue.On("Ack", job.ID).Return(nil)
+		mockJobQueue.On("Save", job).Return(nil)
+		mockJobQueue.On("DeadLetter", job).Return(nil)
+
+		_, err := js.ProcessNext(time.Second)
+
+		assert.NoError(t, err)
+		assert.Equal(t, model.JobDead, job.Status)
+		assert.Equal(t, 3, job.Attempts)
+		mockJobQueue.AssertCalled(t, "DeadLetter", job)
+		mockJobQueue.AssertNotCalled(t, "Schedule", mock.Anything)
+	})
+
+	t.Run("Unknown type gets dead lettered", func(t *testing.T) {
+		job := newJob(0)
+		job.Type = "unknown"
+		mockJobQueue := new(mocks.JobQueue)
+		js := NewJobService(&JSConfig{JobQueue: mockJobQueue, Clock: clock})
+
+		mockJobQueue.On("Pop", time.Second, jobVisibilityTimeout).Return(job, nil)
+		mockJobQueue.On("Ack", job.ID).Return(nil)
+		mockJobQueue.On("Save", job).Return(nil)
+		mockJobQueue.On("DeadLetter", job).Return(nil)
+
+		_, err := js.ProcessNext(time.Second)
+
+		assert.NoError(t, err)
+		assert.Equal(t, model.JobDead, job.Status)
+		assert.Contains(t, job.LastError, "unknown")
+	})
+}
+
+func TestJobBackoff(t *testing.T) {
+	assert.Equal(t, 10*time.Second, jobBackoff(1))
+	assert.Equal(t, 20*time.Second, jobBackoff(2))
+	assert.Equal(t, 80*time.Second, jobBackoff(4))
+	assert.Equal(t, jobBackoffMax, jobBackoff(20))
+}
+
+func TestJobService_RetryDead(t *testing.T) {
+	dead := &model.Job{ID: "10", Status: model.JobDead, Attempts: 5, MaxAttempts: 5}
+
+	mockJobQueue := new(mocks.JobQueue)
+	js := NewJobService(&JSConfig{JobQueue: mockJobQueue})
+
+	mockJobQueue.On("PopDead").Return(dead, nil).Once()
+	// the second dead job expired in the meantime
+	mockJobQueue.On("PopDead").Return(nil, apperrors.NewNotFound("job", "11")).Once()
+	mockJobQueue.On("PopDead").Return(nil, nil).Once()
+	mockJobQueue.On("Save", dead).Return(nil)
+	mockJobQueue.On("Push", dead).Return(nil)
+
+	retried, err := js.RetryDead()
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, retried)
+	assert.Equal(t, model.JobQueued, dead.Status)
+	assert.Equal(t, 0, dead.Attempts)
+	mockJobQueue.AssertExpectations(t)
+}
@@ -0,0 +1,261 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"io"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+const (
+	// cardCacheTTL is how long unfurled cards are cached
+	cardCacheTTL = 24 * time.Hour
+	// cardFailureTTL is how long links without a card are not fetched again
+	cardFailureTTL = time.Hour
+	// maxPageBytes limits how much of a page gets read to find its metadata
+	maxPageBytes = 1 << 20
+	// maxCardImageBytes is the largest preview image that gets stored
+	maxCardImageBytes = 5 << 20
+	linkUserAgent     = "MirageBot/1.0 (+https://github.com/sentrionic/mirage)"
+)
+
+type linkService struct {
+	Fetcher        model.HTTPFetcher
+	LinkRepository model.LinkRepository
+	FileRepository model.FileRepository
+}
+
+// LSConfig will hold repositories that will eventually be injected into this
+// this service layer
+type LSConfig struct {
+	Fetcher        model.HTTPFetcher
+	LinkRepository model.LinkRepository
+	FileRepository model.FileRepository
+}
+
+// NewLinkService is a factory function for
+// initializing a LinkService with its repository layer dependencies
+func NewLinkService(c *LSConfig) model.LinkService {
+	return &linkService{
+		Fetcher:        c.Fetcher,
+		LinkRepository: c.LinkRepository,
+		FileRepository: c.FileRepository,
+	}
+}
+
+// Unfurl follows the url's redirects and builds a card from the page's
+// OpenGraph and Twitter card metadata. The preview image gets copied
+// to the file storage. Pages without a title return no card. Links that
+// can't be fetched or have no card are remembered for cardFailureTTL.
+func (s *linkService) Unfurl(rawURL string) (*model.Card, error) {
+	if card, err := s.LinkRepository.Get(rawURL); err == nil {
+		return card, nil
+	}
+
+	res, err := s.fetch(rawURL, "text/html,application/xhtml+xml")
+
+	if err != nil {
+		log.Printf("Unable to fetch link: %v\n%v", rawURL, err)
+		s.cacheFailure(rawURL)
+		return nil, apperrors.NewBadRequest("unable to fetch link")
+	}
+
+	defer res.Body.Close()
+
+	mediaType, _, _ := mime.ParseMediaType(res.Header.Get("Content-Type"))
+
+	if res.StatusCode != http.StatusOK || (mediaType != "text/html" && mediaType != "application/xhtml+xml") {
+		s.cacheFailure(rawURL)
+		return nil, nil
+	}
+
+	page, err := io.ReadAll(io.LimitReader(res.Body, maxPageBytes))
+
+	if err != nil {
+		log.Printf("Unable to read link: %v\n%v", rawURL, err)
+		s.cacheFailure(rawURL)
+		return nil, apperrors.NewBadRequest("unable to fetch link")
+	}
+
+	finalURL := res.Request.URL
+	meta := parseMetadata(string(page))
+
+	card := &model.Card{
+		URL:         finalURL.String(),
+		Type:        meta.first("twitter:card"),
+		Title:       meta.first("og:title", "twitter:title"),
+		Description: meta.first("og:description", "twitter:description", "description"),
+		SiteName:    meta.first("og:site_name"),
+	}
+
+	if card.Title == "" {
+		card.Title = meta.Title
+	}
+
+	if card.Title == "" {
+		s.cacheFailure(rawURL)
+		return nil, nil
+	}
+
+	if card.SiteName == "" {
+		card.SiteName = finalURL.Hostname()
+	}
+
+	if image := meta.first("og:image:secure_url", "og:image", "twitter:image", "twitter:image:src"); image != "" {
+		if ref, err := finalURL.Parse(image); err == nil {
+			if location, err := s.storeImage(ref.String()); err == nil {
+				card.Image = &location
+			} else {
+				log.Printf("Unable to store card image: %v\n%v", ref, err)
+			}
+		}
+	}
+
+	if card.Type == "" {
+		card.Type = "summary"
+		if card.Image != nil {
+			card.Type = "summary_large_image"
+		}
+	}
+
+	if err := s.LinkRepository.Set(rawURL, card, cardCacheTTL); err != nil {
+		log.Printf("Unable to cache card for: %v\n", rawURL)
+	}
+
+	return card, nil
+}
+
+// cacheFailure remembers that the url has no card
+func (s *linkService) cacheFailure(rawURL string) {
+	if err := s.LinkRepository.Set(rawURL, nil, cardFailureTTL); err != nil {
+		log.Printf("Unable to cache failed link: %v\n", rawURL)
+	}
+}
+
+// storeImage copies the image to the file storage and returns its location
+func (s *linkService) storeImage(imageURL string) (string, error) {
+	res, err := s.fetch(imageURL, "image/*")
+
+	if err != nil {
+		return "", err
+	}
+
+	defer res.Body.Close()
+
+	mediaType, _, _ := mime.ParseMediaType(res.Header.Get("Content-Type"))
+	ext, ok := imageExtensions[mediaType]
+
+	if res.StatusCode != http.StatusOK || !ok {
+		return "", fmt.Errorf("unsupported image: %v %v", res.StatusCode, mediaType)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(res.Body, maxCardImageBytes+1))
+
+	if err != nil {
+		return "", err
+	}
+
+	if len(data) > maxCardImageBytes {
+		return "", errors.New("image too large")
+	}
+
+	id, err := GenerateId()
+
+	if err != nil {
+		return "", err
+	}
+
+	return s.FileRepository.UploadFile(bytes.NewReader(data), "cards", id+ext, mediaType)
+}
+
+func (s *linkService) fetch(rawURL, accept string) (*http.Response, error) {
+	u, err := url.Parse(rawURL)
+
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid url: %v", rawURL)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("User-Agent", linkUserAgent)
+	req.Header.Set("Accept", accept)
+
+	return s.Fetcher.Do(req)
+}
+
+// NewLinkFetcher returns a HTTP client for unfurling links. It only connects
+// to public addresses so that posts can't be used to probe internal services.
+func NewLinkFetcher(timeout time.Duration) model.HTTPFetcher {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+
+			ip := net.ParseIP(host)
+			if ip == nil || !isPublicIP(ip) {
+				return fmt.Errorf("refusing to connect to %v", address)
+			}
+
+			return nil
+		},
+	}
+
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				return dialer.DialContext(ctx, network, addr)
+			},
+			TLSHandshakeTimeout: timeout,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 5 {
+				return errors.New("too many redirects")
+			}
+			return nil
+		},
+	}
+}
+
+// nonPublicNetworks are the private, shared and reserved ranges not covered by net.IP's methods
+var nonPublicNetworks = []string{
+	"0.0.0.0/8",
+	"10.0.0.0/8",
+	"100.64.0.0/10",
+	"172.16.0.0/12",
+	"192.168.0.0/16",
+	"fc00::/7",
+}
+
+func isPublicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsMulticast() || ip.IsInterfaceLocalMulticast() {
+		return false
+	}
+
+	for _, cidr := range nonPublicNetworks {
+		_, network, _ := net.ParseCIDR(cidr)
+		if network.Contains(ip) {
+			return false
+		}
+	}
+
+	return true
+}
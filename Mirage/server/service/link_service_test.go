@@ -0,0 +1,194 @@
+package service
+
+import (
+	"fmt"
+	"github.com/sentrionic/mirage/mocks"
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const articlePage = `<!DOCTYPE html>
+<html>
+<head>
+	<title>Fallback title</title>
+	<meta property="og:title" content="Plan of the City of Peking, 1722" />
+	<meta property='og:description' content="A map &amp; its history">
+	<meta name="twitter:card" content="summary_large_image">
+	<meta property="og:image" content="/images/peking.png">
+</head>
+<body><meta property="og:title" content="Not in head"></body>
+</html>`
+
+func TestLinkService_Unfurl(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/short", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/article", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/article", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, articlePage)
+	})
+	mux.HandleFunc("/images/peking.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		fmt.Fprint(w, "png")
+	})
+	mux.HandleFunc("/plain", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, "<html><head><title> Just a   title </title></head></html>")
+	})
+	mux.HandleFunc("/file.zip", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/zip")
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	t.Run("Follows redirects and reads OpenGraph metadata", func(t *testing.T) {
+		mockLinkRepository := new(mocks.LinkRepository)
+		mockFileRepository := new(mocks.FileRepository)
+		ls := NewLinkService(&LSConfig{
+			Fetcher:        server.Client(),
+			LinkRepository: mockLinkRepository,
+			FileRepository: mockFileRepository,
+		})
+
+		link := server.URL + "/short"
+		imageURL := "https://bucket.s3.amazonaws.com/files/cards/1.png"
+
+		mockLinkRepository.On("Get", link).Return(nil, apperrors.NewNotFound("card", link))
+		mockLinkRepository.On("Set", link, mock.AnythingOfType("*model.Card"), cardCacheTTL).Return(nil)
+		mockFileRepository.
+			On("UploadFile", mock.Anything, "cards", mock.MatchedBy(func(name string) bool {
+				return len(name) > 4 && name[len(name)-4:] == ".png"
+			}), "image/png").
+			Return(imageURL, nil)
+
+		card, err := ls.Unfurl(link)
+
+		assert.NoError(t, err)
+		assert.Equal(t, &model.Card{
+			URL:         server.URL + "/article",
+			Type:        "summary_large_image",
+			Title:       "Plan of the City of Peking, 1722",
+			Description: "A map & its history",
+			SiteName:    "127.0.0.1",
+			Image:       &imageURL,
+		}, card)
+		mockLinkRepository.AssertExpectations(t)
+		mockFileRepository.AssertExpectations(t)
+	})
+
+	t.Run("Falls back to the title", func(t *testing.T) {
+		mockLinkRepository := new(mocks.LinkRepository)
+		ls := NewLinkService(&LSConfig{
+			Fetcher:        server.Client(),
+			LinkRepository: mockLinkRepository,
+		})
+
+		link := server.URL + "/plain"
+
+		mockLinkRepository.On("Get", link).Return(nil, apperrors.NewNotFound("card", link))
+		mockLinkRepository.On("Set", link, mock.AnythingOfType("*model.Card"), cardCacheTTL).Return(nil)
+
+		card, err := ls.Unfurl(link)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Just a title", card.Title)
+		assert.Equal(t, "summary", card.Type)
+		assert.Nil(t, card.Image)
+	})
+
+	t.Run("Returns cached cards", func(t *testing.T) {
+		mockLinkRepository := new(mocks.LinkRepository)
+		mockFetcher := new(mocks.HTTPFetcher)
+		ls := NewLinkService(&LSConfig{
+			Fetcher:        mockFetcher,
+			LinkRepository: mockLinkRepository,
+		})
+
+		cached := &model.Card{URL: "https://mirage.xyz", Title: "Mirage"}
+		mockLinkRepository.On("Get", "https://t.co/abc").Return(cached, nil)
+
+		card, err := ls.Unfurl("https://t.co/abc")
+
+		assert.NoError(t, err)
+		assert.Equal(t, cached, card)
+		mockFetcher.AssertNotCalled(t, "Do", mock.Anything)
+	})
+
+	t.Run("Returns cached failures", func(t *testing.T) {
+		mockLinkRepository := new(mocks.LinkRepository)
+		mockFetcher := new(mocks.HTTPFetcher)
+		ls := NewLinkService(&LSConfig{
+			Fetcher:        mockFetcher,
+			LinkRepository: mockLinkRepository,
+		})
+
+		mockLinkRepository.On("Get", "https://t.co/abc").Return(nil, nil)
+
+		card, err := ls.Unfurl("https://t.co/abc")
+
+		assert.NoError(t, err)
+		assert.Nil(t, card)
+		mockFetcher.AssertNotCalled(t, "Do", mock.Anything)
+	})
+
+	t.Run("No card for other content", func(t *testing.T) {
+		mockLinkRepository := new(mocks.LinkRepository)
+		ls := NewLinkService(&LSConfig{
+			Fetcher:        server.Client(),
+			LinkRepository: mockLinkRepository,
+		})
+
+		link := server.URL + "/file.zip"
+		mockLinkRepository.On("Get", link).Return(nil, apperrors.NewNotFound("card", link))
+		mockLinkRepository.On("Set", link, (*model.Card)(nil), cardFailureTTL).Return(nil)
+
+		card, err := ls.Unfurl(link)
+
+		assert.NoError(t, err)
+		assert.Nil(t, card)
+		mockLinkRepository.AssertExpectations(t)
+	})
+
+	t.Run("Unreachable link", func(t *testing.T) {
+		mockLinkRepository := new(mocks.LinkRepository)
+		ls := NewLinkService(&LSConfig{
+			Fetcher:        server.Client(),
+			LinkRepository: mockLinkRepository,
+		})
+
+		link := "ftp://mirage.xyz/file"
+		mockLinkRepository.On("Get", link).Return(nil, apperrors.NewNotFound("card", link))
+		mockLinkRepository.On("Set", link, (*model.Card)(nil), cardFailureTTL).Return(nil)
+
+		card, err := ls.Unfurl(link)
+
+		assert.Error(t, err)
+		assert.Nil(t, card)
+		mockLinkRepository.AssertExpectations(t)
+	})
+}
+
+func TestIsPublicIP(t *testing.T) {
+	for ip, public := range map[string]bool{
+		"8.8.8.8":         true,
+		"2606:4700::1111": true,
+		"127.0.0.1":       false,
+		"10.1.2.3":        false,
+		"172.20.0.1":      false,
+		"192.168.1.1":     false,
+		"169.254.169.254": false,
+		"100.64.0.1":      false,
+		"::1":             false,
+		"fd00::1":         false,
+	} {
+		assert.Equal(t, public, isPublicIP(net.ParseIP(ip)), ip)
+	}
+}
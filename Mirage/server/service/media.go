@@ -0,0 +1,409 @@
+package service
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/disintegration/imaging"
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"log"
+	"path"
+)
+
+const (
+	// blurhashSize is the largest side the image gets scaled down to before calculating its blurhash
+	blurhashSize        = 32
+	blurhashXComponents = 4
+	blurhashYComponents = 3
+	// originalVariant is the name of the full size image
+	originalVariant = "original"
+	// mediaDirectory is where post images are stored
+	mediaDirectory = "media/"
+	// maxImagePixels limits the size of decoded images. Small files can
+	// declare huge dimensions and would otherwise exhaust the memory.
+	maxImagePixels = 50 * 1000 * 1000
+)
+
+// imageVariant describes a resized version of uploaded images.
+// Images get scaled to fit into the bounds, or cropped to
+// fill them for square variants.
+//
+// Variants keep the format of the original. WebP variants are not created,
+// as neither the standard library nor imaging are able to encode them.
+type imageVariant struct {
+	Name   string
+	Width  int
+	Height int
+	Crop   bool
+}
+
+// imageExtensions are the file extensions of the supported image types
+var imageExtensions = map[string]string{
+	"image/jpeg": ".jpeg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+var imageVariants = []imageVariant{
+	{Name: "thumb", Width: 150, Height: 150, Crop: true},
+	{Name: "small", Width: 680, Height: 680},
+	{Name: "large", Width: 1200, Height: 1200},
+}
+
+// processedImage is an uploaded image without its metadata
+type processedImage struct {
+	Data     []byte
+	Width    int
+	Height   int
+	Blurhash string
+	// Image is the decoded image. It is nil for GIFs, which don't get resized
+	Image image.Image
+}
+
+type processedVariant struct {
+	Name   string
+	Data   []byte
+	Width  int
+	Height int
+}
+
+// processImage decodes the image and encodes it again, which drops all of its
+// metadata like EXIF and GPS tags. JPEGs get rotated according to their
+// EXIF orientation first, as that information is lost afterwards.
+func processImage(src io.Reader, mimetype string) (*processedImage, error) {
+	var processed *processedImage
+	var img image.Image
+
+	data, err := io.ReadAll(src)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkImageSize(data); err != nil {
+		return nil, err
+	}
+
+	r := bytes.NewReader(data)
+
+	switch mimetype {
+	case "image/jpeg", "image/png":
+		src, err := imaging.Decode(r, imaging.AutoOrientation(true))
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := encodeImage(src, mimetype)
+		if err != nil {
+			return nil, err
+		}
+
+		processed = &processedImage{
+			Data:   data,
+			Width:  src.Bounds().Dx(),
+			Height: src.Bounds().Dy(),
+			Image:  src,
+		}
+
+		img = src
+	case "image/gif":
+		// keep all frames of animated GIFs. They don't get
+		// resized, as that would lose the animation
+		src, err := gif.DecodeAll(r)
+		if err != nil {
+			return nil, err
+		}
+
+		buf := new(bytes.Buffer)
+		if err := gif.EncodeAll(buf, src); err != nil {
+			return nil, err
+		}
+
+		processed = &processedImage{
+			Data:   buf.Bytes(),
+			Width:  src.Config.Width,
+			Height: src.Config.Height,
+		}
+
+		img = src.Image[0]
+	default:
+		return nil, fmt.Errorf("unsupported image type: %v", mimetype)
+	}
+
+	processed.Blurhash = encodeBlurhash(
+		imaging.Fit(img, blurhashSize, blurhashSize, imaging.Box),
+		blurhashXComponents,
+		blurhashYComponents,
+	)
+
+	return processed, nil
+}
+
+// checkImageSize reads the dimensions from the image's header and
+// rejects images that would be larger than maxImagePixels once decoded
+func checkImageSize(data []byte) error {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > maxImagePixels {
+		return fmt.Errorf("image too large: %dx%d", config.Width, config.Height)
+	}
+
+	return nil
+}
+
+// resizeImage creates the variants of the image. Variants that would be as
+// large as the original are left out and use the original instead.
+func resizeImage(img image.Image, mimetype string) ([]processedVariant, error) {
+	var variants []processedVariant
+
+	for _, v := range imageVariants {
+		resized := v.resize(img)
+		if resized == nil {
+			continue
+		}
+
+		data, err := encodeImage(resized, mimetype)
+		if err != nil {
+			return nil, err
+		}
+
+		variants = append(variants, processedVariant{
+			Name:   v.Name,
+			Data:   data,
+			Width:  resized.Bounds().Dx(),
+			Height: resized.Bounds().Dy(),
+		})
+	}
+
+	return variants, nil
+}
+
+// hasVariants reports whether the image is larger than any of the variants
+func hasVariants(img image.Image) bool {
+	for _, v := range imageVariants {
+		if v.fits(img.Bounds()) {
+			return true
+		}
+	}
+	return false
+}
+
+// mediaKey returns the storage key of a post image
+func mediaKey(filename string) string {
+	return fmt.Sprintf("files/%s/%s", mediaDirectory, filename)
+}
+
+// mediaStore uploads processed images. Images are stored by the SHA-256
+// of their content, so uploading the same image again reuses the stored objects.
+type mediaStore struct {
+	FileRepository model.FileRepository
+	PostRepository model.PostRepository
+}
+
+// storeImage processes the image and uploads it. The variants get uploaded
+// as well, unless withVariants is false. The file is then marked with
+// VariantsPending and its variants use the original until storeVariants runs.
+func (m *mediaStore) storeImage(src io.Reader, mimetype, ext string, withVariants bool) (*model.File, error) {
+	img, err := processImage(src, mimetype)
+
+	if err != nil {
+		log.Printf("Unable to process image: %v\n", err)
+		return nil, apperrors.NewBadRequest("invalid image")
+	}
+
+	id, err := GenerateId()
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(img.Data)
+	hash := hex.EncodeToString(sum[:])
+
+	// keep the media cleanup from removing the objects this file is going to use
+	if err := m.PostRepository.ClaimFileHash(hash); err != nil {
+		log.Printf("Unable to claim file hash: %v\n%v", hash, err)
+		return nil, apperrors.NewInternal()
+	}
+
+	if existing, err := m.PostRepository.FindFileByHash(hash); err == nil {
+		return &model.File{
+			ID:       id,
+			Url:      existing.Url,
+			FileType: existing.FileType,
+			Filename: existing.Filename,
+			Width:    existing.Width,
+			Height:   existing.Height,
+			Blurhash: existing.Blurhash,
+			Variants: existing.Variants,
+			Hash:     hash,
+		}, nil
+	}
+
+	filename := hash + ext
+
+	file := model.File{
+		ID:       id,
+		FileType: mimetype,
+		Filename: filename,
+		Width:    img.Width,
+		Height:   img.Height,
+		Blurhash: img.Blurhash,
+		Hash:     hash,
+	}
+
+	url, err := m.FileRepository.UploadFile(bytes.NewReader(img.Data), mediaDirectory, filename, mimetype)
+
+	if err != nil {
+		return nil, err
+	}
+
+	file.Url = url
+	file.Variants = model.ImageVariants{
+		originalVariant: {
+			Url:      url,
+			FileType: mimetype,
+			Filename: filename,
+			Width:    img.Width,
+			Height:   img.Height,
+		},
+	}
+
+	if img.Image != nil {
+		if withVariants {
+			variants, err := resizeImage(img.Image, mimetype)
+			if err != nil {
+				log.Printf("Unable to resize image: %v\n", err)
+				return nil, apperrors.NewBadRequest("invalid image")
+			}
+
+			if err := m.uploadVariants(&file, variants); err != nil {
+				return nil, err
+			}
+		} else {
+			file.VariantsPending = hasVariants(img.Image)
+		}
+	}
+
+	fillVariants(file.Variants)
+
+	return &file, nil
+}
+
+// storeVariants creates and uploads the variants of the image with the
+// given hash and sets them on all files that share its objects
+func (m *mediaStore) storeVariants(hash string) error {
+	file, err := m.PostRepository.FindFileByHash(hash)
+
+	if err != nil {
+		return err
+	}
+
+	src, err := m.FileRepository.OpenFile(mediaKey(file.Filename))
+
+	if err != nil {
+		return err
+	}
+
+	defer src.Close()
+
+	// the stored image is already oriented and stripped
+	img, err := imaging.Decode(src)
+
+	if err != nil {
+		return err
+	}
+
+	variants, err := resizeImage(img, file.FileType)
+
+	if err != nil {
+		return err
+	}
+
+	file.Variants = model.ImageVariants{originalVariant: file.Variants[originalVariant]}
+
+	if err := m.uploadVariants(file, variants); err != nil {
+		return err
+	}
+
+	fillVariants(file.Variants)
+
+	return m.PostRepository.SetFileVariants(hash, file.Variants)
+}
+
+// uploadVariants uploads the resized images next to the file's original
+func (m *mediaStore) uploadVariants(file *model.File, variants []processedVariant) error {
+	ext := path.Ext(file.Filename)
+
+	for _, v := range variants {
+		variantName := file.Hash + "_" + v.Name + ext
+		variantUrl, err := m.FileRepository.UploadFile(bytes.NewReader(v.Data), mediaDirectory, variantName, file.FileType)
+
+		if err != nil {
+			return err
+		}
+
+		file.Variants[v.Name] = model.ImageVariant{
+			Url:      variantUrl,
+			FileType: file.FileType,
+			Filename: variantName,
+			Width:    v.Width,
+			Height:   v.Height,
+		}
+	}
+
+	return nil
+}
+
+// fillVariants makes missing variants use the original
+func fillVariants(variants model.ImageVariants) {
+	for _, v := range imageVariants {
+		if _, ok := variants[v.Name]; !ok {
+			variants[v.Name] = variants[originalVariant]
+		}
+	}
+}
+
+// resize returns the variant of the image or nil
+// if the image is not larger than the variant
+func (v imageVariant) resize(img image.Image) image.Image {
+	if !v.fits(img.Bounds()) {
+		return nil
+	}
+
+	if v.Crop {
+		return imaging.Fill(img, v.Width, v.Height, imaging.Center, imaging.Lanczos)
+	}
+
+	return imaging.Fit(img, v.Width, v.Height, imaging.Lanczos)
+}
+
+// fits reports whether an image of the given size is large enough for the variant
+func (v imageVariant) fits(bounds image.Rectangle) bool {
+	if v.Crop {
+		return bounds.Dx() >= v.Width && bounds.Dy() >= v.Height
+	}
+
+	return bounds.Dx() > v.Width || bounds.Dy() > v.Height
+}
+
+func encodeImage(img image.Image, mimetype string) ([]byte, error) {
+	buf := new(bytes.Buffer)
+
+	var err error
+	if mimetype == "image/jpeg" {
+		err = jpeg.Encode(buf, img, &jpeg.Options{Quality: 90})
+	} else {
+		err = png.Encode(buf, img)
+	}
+
+	return buf.Bytes(), err
+}
@@ -0,0 +1,303 @@
+package service
+
+import (
+	"fmt"
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// uploadExpiration is how long a requested upload can be attached to a post
+	uploadExpiration = time.Hour
+	// uploadURLExpiration is how long the presigned upload url is valid
+	uploadURLExpiration = 15 * time.Minute
+	// uploadDirectory is where direct uploads are stored until they get attached
+	uploadDirectory = "files/uploads"
+	// orphanedMediaGrace is how long orphaned objects are kept. Uploads
+	// claim the objects of their hash while the post gets created.
+	orphanedMediaGrace = time.Hour
+)
+
+// uploadType is a file type that can be uploaded directly to the storage
+type uploadType struct {
+	Extension string
+	MaxSize   int64
+}
+
+var uploadTypes = map[string]uploadType{
+	"image/jpeg":      {Extension: ".jpeg", MaxSize: 15 << 20},
+	"image/png":       {Extension: ".png", MaxSize: 15 << 20},
+	"image/gif":       {Extension: ".gif", MaxSize: 15 << 20},
+	"video/mp4":       {Extension: ".mp4", MaxSize: 512 << 20},
+	"video/quicktime": {Extension: ".mov", MaxSize: 512 << 20},
+}
+
+type mediaService struct {
+	UploadRepository        model.UploadRepository
+	ChunkedUploadRepository model.ChunkedUploadRepository
+	FileRepository          model.FileRepository
+	PostRepository          model.PostRepository
+	JobService              model.JobService
+	Clock                   func() time.Time
+}
+
+// MSConfig will hold repositories that will eventually be injected into this
+// this service layer
+type MSConfig struct {
+	UploadRepository        model.UploadRepository
+	ChunkedUploadRepository model.ChunkedUploadRepository
+	FileRepository          model.FileRepository
+	PostRepository          model.PostRepository
+	// JobService creates image variants in the background. Optional
+	JobService model.JobService
+	// Clock returns the current time. Defaults to time.Now
+	Clock func() time.Time
+}
+
+// NewMediaService is a factory function for
+// initializing a MediaService with its repository layer dependencies
+func NewMediaService(c *MSConfig) model.MediaService {
+	clock := c.Clock
+	if clock == nil {
+		clock = time.Now
+	}
+
+	return &mediaService{
+		UploadRepository:        c.UploadRepository,
+		ChunkedUploadRepository: c.ChunkedUploadRepository,
+		FileRepository:          c.FileRepository,
+		PostRepository:          c.PostRepository,
+		JobService:              c.JobService,
+		Clock:                   clock,
+	}
+}
+
+// CreateUpload records an upload of the given type and size and returns
+// the request the client uses to upload the file directly to the storage
+func (s *mediaService) CreateUpload(userId, filetype string, size int64) (*model.Upload, *model.UploadTarget, error) {
+	upload, err := newUpload(userId, filetype, size, s.Clock().Add(uploadExpiration))
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	target, err := s.FileRepository.PresignUpload(upload.Key, filetype, size, uploadURLExpiration)
+
+	if err != nil {
+		log.Printf("Unable to presign upload: %v\n%v", upload.Key, err)
+		return nil, nil, apperrors.NewInternal()
+	}
+
+	if err := s.UploadRepository.Create(upload); err != nil {
+		return nil, nil, err
+	}
+
+	return upload, target, nil
+}
+
+// newUpload validates the file type and size and returns a new upload
+func newUpload(userId, filetype string, size int64, expiresAt time.Time) (*model.Upload, error) {
+	t, ok := uploadTypes[filetype]
+
+	if !ok {
+		return nil, apperrors.NewUnsupportedMediaType(fmt.Sprintf("unsupported file type: %v", filetype))
+	}
+
+	if size <= 0 {
+		return nil, apperrors.NewBadRequest("size must be positive")
+	}
+
+	if size > t.MaxSize {
+		return nil, apperrors.NewPayloadTooLarge(t.MaxSize, size)
+	}
+
+	id, err := GenerateId()
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.Upload{
+		ID:        id,
+		UserID:    userId,
+		Key:       fmt.Sprintf("%s/%s%s", uploadDirectory, id, t.Extension),
+		FileType:  filetype,
+		Size:      size,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// AttachUpload verifies the uploaded file and returns it as a post's file.
+// Images go through the same processing as multipart uploads, videos are
+// copied to the media directory. The upload is kept until ReleaseUpload,
+// so it can be attached again if creating the post fails.
+func (s *mediaService) AttachUpload(userId, mediaId string) (*model.File, error) {
+	upload, err := s.UploadRepository.FindByID(mediaId)
+
+	if err != nil || upload.UserID != userId || !upload.ExpiresAt.After(s.Clock()) {
+		return nil, apperrors.NewNotFound("media", mediaId)
+	}
+
+	object, err := s.FileRepository.StatFile(upload.Key)
+
+	if err != nil {
+		if apperrors.Status(err) == http.StatusNotFound {
+			return nil, apperrors.NewBadRequest("upload has not been completed")
+		}
+		log.Printf("Unable to get uploaded file: %v\n%v", upload.Key, err)
+		return nil, apperrors.NewInternal()
+	}
+
+	if object.Size != upload.Size || object.FileType != upload.FileType {
+		s.discard(upload)
+		return nil, apperrors.NewBadRequest("uploaded file does not match the requested upload")
+	}
+
+	var file *model.File
+
+	if strings.HasPrefix(upload.FileType, "image/") {
+		file, err = s.storeUploadedImage(upload)
+
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		id, err := GenerateId()
+		if err != nil {
+			return nil, err
+		}
+
+		filename := id + uploadTypes[upload.FileType].Extension
+		url, err := s.FileRepository.CopyFile(upload.Key, mediaDirectory, filename)
+
+		if err != nil {
+			log.Printf("Unable to copy uploaded file: %v\n%v", upload.Key, err)
+			return nil, apperrors.NewInternal()
+		}
+
+		file = &model.File{
+			ID:       id,
+			Url:      url,
+			FileType: upload.FileType,
+			Filename: filename,
+		}
+	}
+
+	return file, nil
+}
+
+// ReleaseUpload removes the upload and its object once
+// its file got attached to a created post
+func (s *mediaService) ReleaseUpload(userId, mediaId string) error {
+	upload, err := s.UploadRepository.FindByID(mediaId)
+
+	if err != nil || upload.UserID != userId {
+		return apperrors.NewNotFound("media", mediaId)
+	}
+
+	if err := s.FileRepository.DeleteImage(upload.Key); err != nil {
+		log.Printf("Unable to delete attached upload: %v\n%v", upload.Key, err)
+	}
+
+	return s.UploadRepository.Delete(upload)
+}
+
+// storeUploadedImage processes the uploaded image
+func (s *mediaService) storeUploadedImage(upload *model.Upload) (*model.File, error) {
+	src, err := s.FileRepository.OpenFile(upload.Key)
+
+	if err != nil {
+		log.Printf("Unable to open uploaded file: %v\n%v", upload.Key, err)
+		return nil, apperrors.NewInternal()
+	}
+
+	defer src.Close()
+
+	store := &mediaStore{
+		FileRepository: s.FileRepository,
+		PostRepository: s.PostRepository,
+	}
+
+	return store.storeImage(src, upload.FileType, uploadTypes[upload.FileType].Extension, s.JobService == nil)
+}
+
+// CleanupUploads deletes the uploads that expired without getting
+// attached to a post and returns how many got deleted
+func (s *mediaService) CleanupUploads() (int, error) {
+	uploads, err := s.UploadRepository.Expired(s.Clock())
+
+	if err != nil {
+		log.Printf("Unable to get expired uploads: %v\n", err)
+		return 0, apperrors.NewInternal()
+	}
+
+	deleted := 0
+	for i := range *uploads {
+		if s.discard(&(*uploads)[i]) {
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
+
+// CleanupOrphanedMedia deletes the objects that no file used for
+// orphanedMediaGrace and returns for how many hashes they got deleted
+func (s *mediaService) CleanupOrphanedMedia() (int, error) {
+	media, err := s.PostRepository.OrphanedMedia(s.Clock().Add(-orphanedMediaGrace))
+
+	if err != nil {
+		log.Printf("Unable to get orphaned media: %v\n", err)
+		return 0, apperrors.NewInternal()
+	}
+
+	deleteObjects := func(filenames []string) error {
+		for _, filename := range filenames {
+			if err := s.FileRepository.DeleteImage(mediaKey(filename)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	deleted := 0
+	for _, m := range *media {
+		ok, err := s.PostRepository.DeleteOrphanedMedia(m.Hash, deleteObjects)
+
+		if err != nil {
+			log.Printf("Unable to delete orphaned media: %v\n%v", m.Hash, err)
+			continue
+		}
+
+		if ok {
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
+
+// discard removes the upload and its object. The object might have
+// never been uploaded, so failing to delete it is not an error.
+func (s *mediaService) discard(upload *model.Upload) bool {
+	// the parts of unfinished chunked uploads are only removed by aborting
+	if upload.MultipartID != "" {
+		if err := s.FileRepository.AbortMultipartUpload(upload.Key, upload.MultipartID); err != nil {
+			log.Printf("Unable to abort multipart upload: %v\n%v", upload.Key, err)
+		}
+	}
+
+	if err := s.FileRepository.DeleteImage(upload.Key); err != nil {
+		log.Printf("Unable to delete uploaded file: %v\n%v", upload.Key, err)
+	}
+
+	if err := s.UploadRepository.Delete(upload); err != nil {
+		log.Printf("Unable to delete upload: %v\n%v", upload.ID, err)
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,317 @@
+package service
+
+import (
+	"bytes"
+	"github.com/sentrionic/mirage/mocks"
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"image"
+	"image/png"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMediaService_CreateUpload(t *testing.T) {
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+
+	t.Run("Success", func(t *testing.T) {
+		mockUploadRepository := new(mocks.UploadRepository)
+		mockFileRepository := new(mocks.FileRepository)
+		ms := NewMediaService(&MSConfig{
+			UploadRepository: mockUploadRepository,
+			FileRepository:   mockFileRepository,
+			Clock:            clock,
+		})
+
+		target := &model.UploadTarget{URL: "https://bucket.s3.amazonaws.com/files/uploads/1.mp4", Method: http.MethodPut}
+
+		mockFileRepository.
+			On("PresignUpload", mock.AnythingOfType("string"), "video/mp4", int64(1<<20), uploadURLExpiration).
+			Return(target, nil)
+		mockUploadRepository.On("Create", mock.AnythingOfType("*model.Upload")).Return(nil)
+
+		upload, uploadTarget, err := ms.CreateUpload("1", "video/mp4", 1<<20)
+
+		assert.NoError(t, err)
+		assert.Equal(t, target, uploadTarget)
+		assert.Equal(t, "1", upload.UserID)
+		assert.Equal(t, "files/uploads/"+upload.ID+".mp4", upload.Key)
+		assert.Equal(t, now.Add(uploadExpiration), upload.ExpiresAt)
+		mockFileRepository.AssertExpectations(t)
+		mockUploadRepository.AssertExpectations(t)
+	})
+
+	t.Run("Unsupported type", func(t *testing.T) {
+		mockUploadRepository := new(mocks.UploadRepository)
+		ms := NewMediaService(&MSConfig{UploadRepository: mockUploadRepository, Clock: clock})
+
+		upload, _, err := ms.CreateUpload("1", "application/zip", 100)
+
+		assert.Nil(t, upload)
+		assert.Equal(t, http.StatusUnsupportedMediaType, apperrors.Status(err))
+		mockUploadRepository.AssertNotCalled(t, "Create", mock.Anything)
+	})
+
+	t.Run("Too large", func(t *testing.T) {
+		mockUploadRepository := new(mocks.UploadRepository)
+		ms := NewMediaService(&MSConfig{UploadRepository: mockUploadRepository, Clock: clock})
+
+		upload, _, err := ms.CreateUpload("1", "image/png", 16<<20)
+
+		assert.Nil(t, upload)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, apperrors.Status(err))
+		mockUploadRepository.AssertNotCalled(t, "Create", mock.Anything)
+	})
+}
+
+func TestMediaService_AttachUpload(t *testing.T) {
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+
+	newUpload := func(filetype string, size int64) *model.Upload {
+		return &model.Upload{
+			ID:        "10",
+			UserID:    "1",
+			Key:       "files/uploads/10" + uploadTypes[filetype].Extension,
+			FileType:  filetype,
+			Size:      size,
+			ExpiresAt: now.Add(time.Minute),
+		}
+	}
+
+	t.Run("Attaches videos", func(t *testing.T) {
+		mockUploadRepository := new(mocks.UploadRepository)
+		mockFileRepository := new(mocks.FileRepository)
+		ms := NewMediaService(&MSConfig{
+			UploadRepository: mockUploadRepository,
+			FileRepository:   mockFileRepository,
+			Clock:            clock,
+		})
+
+		upload := newUpload("video/mp4", 2048)
+		url := "https://bucket.s3.amazonaws.com/files/media/20.mp4"
+
+		mockUploadRepository.On("FindByID", upload.ID).Return(upload, nil)
+		mockFileRepository.On("StatFile", upload.Key).Return(&model.StoredObject{Size: 2048, FileType: "video/mp4"}, nil)
+		mockFileRepository.
+			On("CopyFile", upload.Key, mediaDirectory, mock.MatchedBy(func(name string) bool {
+				return strings.HasSuffix(name, ".mp4") && !strings.Contains(name, "/")
+			})).
+			Return(url, nil)
+
+		file, err := ms.AttachUpload("1", upload.ID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, url, file.Url)
+		assert.Equal(t, "video/mp4", file.FileType)
+		assert.Equal(t, file.ID+".mp4", file.Filename)
+		mockFileRepository.AssertExpectations(t)
+		mockUploadRepository.AssertNotCalled(t, "Delete", mock.Anything)
+		mockFileRepository.AssertNotCalled(t, "DeleteImage", mock.Anything)
+	})
+
+	t.Run("Processes images", func(t *testing.T) {
+		mockUploadRepository := new(mocks.UploadRepository)
+		mockFileRepository := new(mocks.FileRepository)
+		mockPostRepository := new(mocks.PostRepository)
+		ms := NewMediaService(&MSConfig{
+			UploadRepository: mockUploadRepository,
+			FileRepository:   mockFileRepository,
+			PostRepository:   mockPostRepository,
+			Clock:            clock,
+		})
+
+		buf := new(bytes.Buffer)
+		require.NoError(t, png.Encode(buf, image.NewRGBA(image.Rect(0, 0, 2, 2))))
+		upload := newUpload("image/png", int64(buf.Len()))
+		url := "https://bucket.s3.amazonaws.com/files/media/image.png"
+
+		mockUploadRepository.On("FindByID", upload.ID).Return(upload, nil)
+		mockFileRepository.On("StatFile", upload.Key).Return(&model.StoredObject{Size: upload.Size, FileType: "image/png"}, nil)
+		mockFileRepository.On("OpenFile", upload.Key).Return(ioutil.NopCloser(buf), nil)
+		mockPostRepository.On("ClaimFileHash", mock.AnythingOfType("string")).Return(nil)
+		mockPostRepository.On("FindFileByHash", mock.AnythingOfType("string")).Return(nil, apperrors.NewNotFound("file", "hash"))
+		mockFileRepository.On("UploadFile", mock.Anything, "media/", mock.AnythingOfType("string"), "image/png").Return(url, nil)
+
+		file, err := ms.AttachUpload("1", upload.ID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, url, file.Url)
+		assert.Equal(t, 2, file.Width)
+		assert.Len(t, file.Hash, 64)
+		mockFileRepository.AssertExpectations(t)
+		mockUploadRepository.AssertNotCalled(t, "Delete", mock.Anything)
+		mockFileRepository.AssertNotCalled(t, "DeleteImage", mock.Anything)
+	})
+
+	t.Run("Other user's upload", func(t *testing.T) {
+		mockUploadRepository := new(mocks.UploadRepository)
+		mockFileRepository := new(mocks.FileRepository)
+		ms := NewMediaService(&MSConfig{
+			UploadRepository: mockUploadRepository,
+			FileRepository:   mockFileRepository,
+			Clock:            clock,
+		})
+
+		upload := newUpload("video/mp4", 2048)
+		mockUploadRepository.On("FindByID", upload.ID).Return(upload, nil)
+
+		file, err := ms.AttachUpload("2", upload.ID)
+
+		assert.Nil(t, file)
+		assert.Equal(t, http.StatusNotFound, apperrors.Status(err))
+		mockFileRepository.AssertNotCalled(t, "StatFile", mock.Anything)
+	})
+
+	t.Run("Upload not completed", func(t *testing.T) {
+		mockUploadRepository := new(mocks.UploadRepository)
+		mockFileRepository := new(mocks.FileRepository)
+		ms := NewMediaService(&MSConfig{
+			UploadRepository: mockUploadRepository,
+			FileRepository:   mockFileRepository,
+			Clock:            clock,
+		})
+
+		upload := newUpload("video/mp4", 2048)
+		mockUploadRepository.On("FindByID", upload.ID).Return(upload, nil)
+		mockFileRepository.On("StatFile", upload.Key).Return(nil, apperrors.NewNotFound("file", upload.Key))
+
+		file, err := ms.AttachUpload("1", upload.ID)
+
+		assert.Nil(t, file)
+		assert.Equal(t, http.StatusBadRequest, apperrors.Status(err))
+		mockUploadRepository.AssertNotCalled(t, "Delete", mock.Anything)
+	})
+
+	t.Run("Uploaded file does not match", func(t *testing.T) {
+		mockUploadRepository := new(mocks.UploadRepository)
+		mockFileRepository := new(mocks.FileRepository)
+		ms := NewMediaService(&MSConfig{
+			UploadRepository: mockUploadRepository,
+			FileRepository:   mockFileRepository,
+			Clock:            clock,
+		})
+
+		upload := newUpload("video/mp4", 2048)
+		mockUploadRepository.On("FindByID", upload.ID).Return(upload, nil)
+		mockFileRepository.On("StatFile", upload.Key).Return(&model.StoredObject{Size: 4096, FileType: "video/mp4"}, nil)
+		mockFileRepository.On("DeleteImage", upload.Key).Return(nil)
+		mockUploadRepository.On("Delete", upload).Return(nil)
+
+		file, err := ms.AttachUpload("1", upload.ID)
+
+		assert.Nil(t, file)
+		assert.Equal(t, http.StatusBadRequest, apperrors.Status(err))
+		mockFileRepository.AssertExpectations(t)
+		mockUploadRepository.AssertExpectations(t)
+	})
+}
+
+func TestMediaService_ReleaseUpload(t *testing.T) {
+	upload := &model.Upload{ID: "10", UserID: "1", Key: "files/uploads/10.png"}
+
+	t.Run("Success", func(t *testing.T) {
+		mockUploadRepository := new(mocks.UploadRepository)
+		mockFileRepository := new(mocks.FileRepository)
+		ms := NewMediaService(&MSConfig{
+			UploadRepository: mockUploadRepository,
+			FileRepository:   mockFileRepository,
+		})
+
+		mockUploadRepository.On("FindByID", upload.ID).Return(upload, nil)
+		mockFileRepository.On("DeleteImage", upload.Key).Return(nil)
+		mockUploadRepository.On("Delete", upload).Return(nil)
+
+		err := ms.ReleaseUpload("1", upload.ID)
+
+		assert.NoError(t, err)
+		mockUploadRepository.AssertExpectations(t)
+		mockFileRepository.AssertExpectations(t)
+	})
+
+	t.Run("Other user's upload", func(t *testing.T) {
+		mockUploadRepository := new(mocks.UploadRepository)
+		ms := NewMediaService(&MSConfig{UploadRepository: mockUploadRepository})
+
+		mockUploadRepository.On("FindByID", upload.ID).Return(upload, nil)
+
+		err := ms.ReleaseUpload("2", upload.ID)
+
+		assert.Equal(t, http.StatusNotFound, apperrors.Status(err))
+		mockUploadRepository.AssertNotCalled(t, "Delete", mock.Anything)
+	})
+}
+
+func TestMediaService_CleanupUploads(t *testing.T) {
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	mockUploadRepository := new(mocks.UploadRepository)
+	mockFileRepository := new(mocks.FileRepository)
+	ms := NewMediaService(&MSConfig{
+		UploadRepository: mockUploadRepository,
+		FileRepository:   mockFileRepository,
+		Clock:            func() time.Time { return now },
+	})
+
+	expired := []model.Upload{
+		{ID: "1", Key: "files/uploads/1.mp4"},
+		{ID: "2", Key: "files/uploads/2.png"},
+	}
+
+	mockUploadRepository.On("Expired", now).Return(&expired, nil)
+	mockFileRepository.On("DeleteImage", "files/uploads/1.mp4").Return(nil)
+	// the second file was never uploaded
+	mockFileRepository.On("DeleteImage", "files/uploads/2.png").Return(apperrors.NewNotFound("file", "2"))
+	mockUploadRepository.On("Delete", mock.AnythingOfType("*model.Upload")).Return(nil)
+
+	deleted, err := ms.CleanupUploads()
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, deleted)
+	mockUploadRepository.AssertNumberOfCalls(t, "Delete", 2)
+	mockFileRepository.AssertExpectations(t)
+}
+
+func TestMediaService_CleanupOrphanedMedia(t *testing.T) {
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	mockPostRepository := new(mocks.PostRepository)
+	mockFileRepository := new(mocks.FileRepository)
+	ms := NewMediaService(&MSConfig{
+		PostRepository: mockPostRepository,
+		FileRepository: mockFileRepository,
+		Clock:          func() time.Time { return now },
+	})
+
+	orphaned := []model.OrphanedMedia{
+		{Hash: "abc", Filenames: []string{"abc.png", "abc_thumb.png"}},
+		// claimed by a new upload in the meantime
+		{Hash: "def", Filenames: []string{"def.png"}},
+	}
+
+	mockPostRepository.On("OrphanedMedia", now.Add(-orphanedMediaGrace)).Return(&orphaned, nil)
+	mockPostRepository.
+		On("DeleteOrphanedMedia", "abc", mock.AnythingOfType("model.ObjectDeleter")).
+		Return(func(_ string, deleteObjects model.ObjectDeleter) bool {
+			return deleteObjects(orphaned[0].Filenames) == nil
+		}, nil)
+	mockPostRepository.
+		On("DeleteOrphanedMedia", "def", mock.AnythingOfType("model.ObjectDeleter")).
+		Return(false, nil)
+	mockFileRepository.On("DeleteImage", "files/media//abc.png").Return(nil)
+	mockFileRepository.On("DeleteImage", "files/media//abc_thumb.png").Return(nil)
+
+	deleted, err := ms.CleanupOrphanedMedia()
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+	mockFileRepository.AssertExpectations(t)
+	mockFileRepository.AssertNumberOfCalls(t, "DeleteImage", 2)
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func TestProcessImage(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 40, 20))
+	for x := 0; x < 40; x++ {
+		for y := 0; y < 20; y++ {
+			img.Set(x, y, color.RGBA{R: uint8(x * 6), G: 100, B: uint8(y * 12), A: 255})
+		}
+	}
+
+	t.Run("Strips EXIF from JPEGs", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		require.NoError(t, jpeg.Encode(buf, img, nil))
+
+		// insert an APP1 segment with GPS data right after the SOI marker
+		exif := []byte("Exif\x00\x00GPSLatitude=47.3769")
+		segment := append([]byte{0xFF, 0xE1, 0, byte(len(exif) + 2)}, exif...)
+		data := append(append([]byte{}, buf.Bytes()[:2]...), append(segment, buf.Bytes()[2:]...)...)
+
+		processed, err := processImage(bytes.NewReader(data), "image/jpeg")
+
+		require.NoError(t, err)
+		assert.NotContains(t, string(processed.Data), "GPSLatitude")
+		assert.Equal(t, 40, processed.Width)
+		assert.Equal(t, 20, processed.Height)
+		assert.Len(t, processed.Blurhash, 28)
+
+		_, format, err := image.Decode(bytes.NewReader(processed.Data))
+		assert.NoError(t, err)
+		assert.Equal(t, "jpeg", format)
+	})
+
+	t.Run("Keeps PNGs", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		require.NoError(t, png.Encode(buf, img))
+
+		processed, err := processImage(buf, "image/png")
+
+		require.NoError(t, err)
+		_, format, err := image.Decode(bytes.NewReader(processed.Data))
+		assert.NoError(t, err)
+		assert.Equal(t, "png", format)
+	})
+
+	t.Run("Keeps all frames of GIFs", func(t *testing.T) {
+		palette := color.Palette{color.Black, color.White}
+		anim := &gif.GIF{
+			Image: []*image.Paletted{
+				image.NewPaletted(image.Rect(0, 0, 10, 8), palette),
+				image.NewPaletted(image.Rect(0, 0, 10, 8), palette),
+			},
+			Delay: []int{10, 10},
+		}
+		buf := new(bytes.Buffer)
+		require.NoError(t, gif.EncodeAll(buf, anim))
+
+		processed, err := processImage(buf, "image/gif")
+
+		require.NoError(t, err)
+		decoded, err := gif.DecodeAll(bytes.NewReader(processed.Data))
+		assert.NoError(t, err)
+		assert.Len(t, decoded.Image, 2)
+		assert.Equal(t, 10, processed.Width)
+		assert.Equal(t, 8, processed.Height)
+	})
+
+	t.Run("Creates smaller variants", func(t *testing.T) {
+		large := image.NewRGBA(image.Rect(0, 0, 1600, 900))
+		buf := new(bytes.Buffer)
+		require.NoError(t, jpeg.Encode(buf, large, nil))
+
+		processed, err := processImage(buf, "image/jpeg")
+		require.NoError(t, err)
+		assert.True(t, hasVariants(processed.Image))
+
+		variants, err := resizeImage(processed.Image, "image/jpeg")
+
+		require.NoError(t, err)
+		require.Len(t, variants, 3)
+
+		sizes := make(map[string][2]int)
+		for _, v := range variants {
+			decoded, format, err := image.Decode(bytes.NewReader(v.Data))
+			require.NoError(t, err)
+			assert.Equal(t, "jpeg", format)
+			assert.Equal(t, v.Width, decoded.Bounds().Dx())
+			assert.Equal(t, v.Height, decoded.Bounds().Dy())
+			sizes[v.Name] = [2]int{v.Width, v.Height}
+		}
+
+		assert.Equal(t, map[string][2]int{
+			"thumb": {150, 150},
+			"small": {680, 382},
+			"large": {1200, 675},
+		}, sizes)
+	})
+
+	t.Run("No variants larger than the original", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		require.NoError(t, png.Encode(buf, img))
+
+		processed, err := processImage(buf, "image/png")
+		require.NoError(t, err)
+		assert.False(t, hasVariants(processed.Image))
+
+		variants, err := resizeImage(processed.Image, "image/png")
+
+		require.NoError(t, err)
+		assert.Empty(t, variants)
+	})
+
+	t.Run("Rejects images with too many pixels", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		require.NoError(t, gif.Encode(buf, image.NewPaletted(image.Rect(0, 0, 1, 1), palette.Plan9), nil))
+
+		// declare a 65535x65535 logical screen in the header
+		data := buf.Bytes()
+		copy(data[6:10], []byte{0xff, 0xff, 0xff, 0xff})
+
+		_, err := processImage(bytes.NewReader(data), "image/gif")
+		assert.EqualError(t, err, "image too large: 65535x65535")
+	})
+
+	t.Run("Invalid image", func(t *testing.T) {
+		_, err := processImage(bytes.NewReader([]byte("not an image")), "image/png")
+		assert.Error(t, err)
+	})
+}
+
+func TestEncodeBlurhash(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	for x := 0; x < 4; x++ {
+		for y := 0; y < 4; y++ {
+			img.Set(x, y, color.RGBA{R: 255, A: 255})
+		}
+	}
+
+	hash := encodeBlurhash(img, 4, 3)
+
+	// 1 char size flag, 1 char maximum AC value, 4 chars DC and 2 chars for each of the 11 AC components
+	assert.Len(t, hash, 28)
+	assert.Equal(t, "L", hash[:1])
+	// the DC component is the average color
+	assert.Equal(t, "TI:j", hash[2:6])
+}
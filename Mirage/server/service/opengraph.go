@@ -0,0 +1,76 @@
+package service
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+var (
+	metaTagPattern   = regexp.MustCompile(`(?is)<meta\s+([^>]*)>`)
+	attributePattern = regexp.MustCompile(`(?is)([a-z:_-]+)\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>]+))`)
+	titlePattern     = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+	headEndPattern   = regexp.MustCompile(`(?i)</head>`)
+)
+
+// pageMetadata holds the OpenGraph and Twitter card
+// properties and the title of a HTML page
+type pageMetadata struct {
+	Properties map[string]string
+	Title      string
+}
+
+// parseMetadata reads the meta tags of the page's head. Properties are keyed
+// by their lowercase property or name attribute, e.g. "og:title".
+// The first occurrence of a property wins.
+func parseMetadata(page string) pageMetadata {
+	if loc := headEndPattern.FindStringIndex(page); loc != nil {
+		page = page[:loc[0]]
+	}
+
+	meta := pageMetadata{Properties: make(map[string]string)}
+
+	for _, tag := range metaTagPattern.FindAllStringSubmatch(page, -1) {
+		attributes := make(map[string]string)
+
+		for _, attr := range attributePattern.FindAllStringSubmatch(tag[1], -1) {
+			attributes[strings.ToLower(attr[1])] = attr[2] + attr[3] + attr[4]
+		}
+
+		key := attributes["property"]
+		if key == "" {
+			key = attributes["name"]
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+
+		content, ok := attributes["content"]
+		if key == "" || !ok {
+			continue
+		}
+
+		if _, exists := meta.Properties[key]; !exists {
+			meta.Properties[key] = cleanText(content)
+		}
+	}
+
+	if title := titlePattern.FindStringSubmatch(page); title != nil {
+		meta.Title = cleanText(title[1])
+	}
+
+	return meta
+}
+
+// first returns the first non-empty property of the given keys
+func (m pageMetadata) first(keys ...string) string {
+	for _, key := range keys {
+		if value := m.Properties[key]; value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// cleanText unescapes HTML entities and collapses whitespace
+func cleanText(text string) string {
+	return strings.Join(strings.Fields(html.UnescapeString(text)), " ")
+}
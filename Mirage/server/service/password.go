@@ -2,51 +2,251 @@ package service
 
 import (
 	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
 	"encoding/hex"
 	"fmt"
 	"strings"
 
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/scrypt"
 )
 
+// Password hashes are stored in the PHC string format, which records the
+// algorithm and cost next to the salt and hash:
+//
+//	$scrypt$ln=15,r=8,p=1$<salt>$<hash>
+//	$argon2id$v=19$m=65536,t=3,p=4$<salt>$<hash>
+//
+// Salt and hash are unpadded base64. Hashes created before this format
+// are stored as hex(hash).hex(salt) and use scrypt with N=32768, r=8, p=1.
+
+const (
+	algorithmScrypt   = "scrypt"
+	algorithmArgon2id = "argon2id"
+)
+
+// passwordParams describes the algorithm and cost of a password hash
+type passwordParams struct {
+	Algorithm string
+	// scrypt cost, N = 2^LogN
+	LogN int
+	R    int
+	P    int
+	// argon2id cost, Memory in KiB
+	Memory  uint32
+	Time    uint32
+	Threads uint8
+	SaltLen int
+	KeyLen  int
+}
+
+// scryptParams are the recommended parameters from https://godoc.org/golang.org/x/crypto/scrypt
+var scryptParams = passwordParams{
+	Algorithm: algorithmScrypt,
+	LogN:      15,
+	R:         8,
+	P:         1,
+	SaltLen:   32,
+	KeyLen:    32,
+}
+
+// argon2idParams are the recommended parameters from RFC 9106
+// for memory constrained environments
+var argon2idParams = passwordParams{
+	Algorithm: algorithmArgon2id,
+	Memory:    64 * 1024,
+	Time:      3,
+	Threads:   4,
+	SaltLen:   16,
+	KeyLen:    32,
+}
+
+// Limits of the cost parameters accepted from stored hashes,
+// so that a tampered hash cannot exhaust memory or CPU
+const (
+	maxScryptLogN   = 20
+	maxArgon2Memory = 1024 * 1024 // 1 GiB in KiB
+	maxArgon2Time   = 16
+)
+
+// defaultPasswordParams are used for new hashes. Stored hashes with
+// other parameters are upgraded on the user's next login.
+var defaultPasswordParams = argon2idParams
+
+// SetArgon2idCost changes the cost of new password hashes.
+// Memory is in KiB. Existing hashes get upgraded on the next login.
+func SetArgon2idCost(memory, time uint32, threads uint8) error {
+	params := argon2idParams
+	params.Memory = memory
+	params.Time = time
+	params.Threads = threads
+
+	if !params.valid() {
+		return fmt.Errorf("invalid argon2id parameters: m=%d,t=%d,p=%d", memory, time, threads)
+	}
+
+	defaultPasswordParams = params
+	return nil
+}
+
 func hashPassword(password string) (string, error) {
-	salt := make([]byte, 32)
+	return hashPasswordWith(password, defaultPasswordParams)
+}
+
+func hashPasswordWith(password string, params passwordParams) (string, error) {
+	salt := make([]byte, params.SaltLen)
 	_, err := rand.Read(salt)
 	if err != nil {
 		return "", err
 	}
 
-	// using recommended cost parameters from - https://godoc.org/golang.org/x/crypto/scrypt
-	shash, err := scrypt.Key([]byte(password), salt, 32768, 8, 1, 32)
+	key, err := deriveKey([]byte(password), salt, params)
 	if err != nil {
 		return "", err
 	}
 
-	// return hex-encoded string with salt appended to password
-	hashedPW := fmt.Sprintf("%s.%s", hex.EncodeToString(shash), hex.EncodeToString(salt))
-
-	return hashedPW, nil
+	return encodeHash(params, salt, key), nil
 }
 
 func comparePasswords(storedPassword string, suppliedPassword string) (bool, error) {
+	params, salt, key, err := decodeHash(storedPassword)
+
+	if err != nil {
+		return false, err
+	}
+
+	supplied, err := deriveKey([]byte(suppliedPassword), salt, params)
+
+	if err != nil {
+		return false, fmt.Errorf("unable to verify user password")
+	}
+
+	return subtle.ConstantTimeCompare(supplied, key) == 1, nil
+}
+
+// passwordNeedsRehash reports whether the stored hash uses the
+// legacy format or parameters other than the current defaults
+func passwordNeedsRehash(storedPassword string) bool {
+	if !strings.HasPrefix(storedPassword, "$") {
+		return true
+	}
+
+	params, _, _, err := decodeHash(storedPassword)
+
+	return err != nil || params != defaultPasswordParams
+}
+
+// valid checks that the cost parameters are within the supported limits.
+// argon2.IDKey panics on a time or thread count of zero.
+func (params passwordParams) valid() bool {
+	switch params.Algorithm {
+	case algorithmScrypt:
+		return params.LogN > 0 && params.LogN <= maxScryptLogN && params.R > 0 && params.P > 0 && params.R*params.P < 1<<30
+	case algorithmArgon2id:
+		return params.Time > 0 && params.Time <= maxArgon2Time &&
+			params.Threads > 0 &&
+			params.Memory >= 8*uint32(params.Threads) && params.Memory <= maxArgon2Memory
+	default:
+		return false
+	}
+}
+
+func deriveKey(password, salt []byte, params passwordParams) ([]byte, error) {
+	switch params.Algorithm {
+	case algorithmScrypt:
+		return scrypt.Key(password, salt, 1<<params.LogN, params.R, params.P, params.KeyLen)
+	case algorithmArgon2id:
+		return argon2.IDKey(password, salt, params.Time, params.Memory, params.Threads, uint32(params.KeyLen)), nil
+	default:
+		return nil, fmt.Errorf("unsupported password algorithm: %v", params.Algorithm)
+	}
+}
+
+func encodeHash(params passwordParams, salt, key []byte) string {
+	b64Salt := base64.RawStdEncoding.EncodeToString(salt)
+	b64Key := base64.RawStdEncoding.EncodeToString(key)
+
+	if params.Algorithm == algorithmArgon2id {
+		return fmt.Sprintf("$%s$v=%d$m=%d,t=%d,p=%d$%s$%s",
+			params.Algorithm, argon2.Version, params.Memory, params.Time, params.Threads, b64Salt, b64Key)
+	}
+
+	return fmt.Sprintf("$%s$ln=%d,r=%d,p=%d$%s$%s",
+		params.Algorithm, params.LogN, params.R, params.P, b64Salt, b64Key)
+}
+
+func decodeHash(storedPassword string) (passwordParams, []byte, []byte, error) {
+	if !strings.HasPrefix(storedPassword, "$") {
+		return decodeLegacyHash(storedPassword)
+	}
+
+	invalid := fmt.Errorf("did not provide a valid hash")
+	parts := strings.Split(storedPassword, "$")
+	params := passwordParams{}
+
+	var encodedSalt, encodedKey string
+
+	switch {
+	case len(parts) == 5 && parts[1] == algorithmScrypt:
+		params.Algorithm = algorithmScrypt
+		if _, err := fmt.Sscanf(parts[2], "ln=%d,r=%d,p=%d", &params.LogN, &params.R, &params.P); err != nil {
+			return params, nil, nil, invalid
+		}
+		encodedSalt, encodedKey = parts[3], parts[4]
+	case len(parts) == 6 && parts[1] == algorithmArgon2id:
+		var version int
+		if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+			return params, nil, nil, invalid
+		}
+		params.Algorithm = algorithmArgon2id
+		if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Time, &params.Threads); err != nil {
+			return params, nil, nil, invalid
+		}
+		encodedSalt, encodedKey = parts[4], parts[5]
+	default:
+		return params, nil, nil, invalid
+	}
+
+	if !params.valid() {
+		return params, nil, nil, invalid
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(encodedSalt)
+	if err != nil {
+		return params, nil, nil, invalid
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(encodedKey)
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, invalid
+	}
+
+	params.SaltLen = len(salt)
+	params.KeyLen = len(key)
+
+	return params, salt, key, nil
+}
+
+// decodeLegacyHash reads the hex(hash).hex(salt) format
+func decodeLegacyHash(storedPassword string) (passwordParams, []byte, []byte, error) {
 	pwsalt := strings.Split(storedPassword, ".")
 
 	if len(pwsalt) < 2 {
-		return false, fmt.Errorf("did not provide a valid hash")
+		return passwordParams{}, nil, nil, fmt.Errorf("did not provide a valid hash")
 	}
 
-	// check supplied password salted with hash
 	salt, err := hex.DecodeString(pwsalt[1])
 
 	if err != nil {
-		return false, fmt.Errorf("unable to verify user password")
+		return passwordParams{}, nil, nil, fmt.Errorf("unable to verify user password")
 	}
 
-	shash, err := scrypt.Key([]byte(suppliedPassword), salt, 32768, 8, 1, 32)
+	key, err := hex.DecodeString(pwsalt[0])
 
 	if err != nil {
-		return false, fmt.Errorf("unable to verify user password")
+		return passwordParams{}, nil, nil, fmt.Errorf("unable to verify user password")
 	}
 
-	return hex.EncodeToString(shash) == pwsalt[0], nil
+	return scryptParams, salt, key, nil
 }
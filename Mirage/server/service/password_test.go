@@ -1,10 +1,15 @@
 package service
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
 	"github.com/sentrionic/mirage/model/fixture"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/scrypt"
 )
 
 func TestPassword(t *testing.T) {
@@ -33,3 +38,110 @@ func TestPassword(t *testing.T) {
 	require.EqualError(t, err, "did not provide a valid hash")
 	require.False(t, valid)
 }
+
+func TestPassword_Formats(t *testing.T) {
+	password := fixture.RandStringRunes(10)
+
+	t.Run("scrypt", func(t *testing.T) {
+		hashed, err := hashPasswordWith(password, scryptParams)
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(hashed, "$scrypt$ln=15,r=8,p=1$"))
+
+		valid, err := comparePasswords(hashed, password)
+		require.NoError(t, err)
+		require.True(t, valid)
+	})
+
+	t.Run("argon2id", func(t *testing.T) {
+		params := argon2idParams
+		params.Memory = 1024
+		params.Time = 1
+
+		hashed, err := hashPasswordWith(password, params)
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(hashed, "$argon2id$v=19$m=1024,t=1,p=4$"))
+
+		valid, err := comparePasswords(hashed, password)
+		require.NoError(t, err)
+		require.True(t, valid)
+
+		valid, err = comparePasswords(hashed, "not"+password)
+		require.NoError(t, err)
+		require.False(t, valid)
+
+		require.True(t, passwordNeedsRehash(hashed))
+	})
+
+	t.Run("legacy", func(t *testing.T) {
+		hashed := legacyHash(t, password)
+
+		valid, err := comparePasswords(hashed, password)
+		require.NoError(t, err)
+		require.True(t, valid)
+
+		valid, err = comparePasswords(hashed, "not"+password)
+		require.NoError(t, err)
+		require.False(t, valid)
+
+		require.True(t, passwordNeedsRehash(hashed))
+	})
+
+	t.Run("Current hashes do not need a rehash", func(t *testing.T) {
+		hashed, err := hashPassword(password)
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(hashed, "$argon2id$"))
+		require.False(t, passwordNeedsRehash(hashed))
+	})
+
+	t.Run("scrypt hashes get upgraded", func(t *testing.T) {
+		hashed, err := hashPasswordWith(password, scryptParams)
+		require.NoError(t, err)
+		require.True(t, passwordNeedsRehash(hashed))
+	})
+
+	t.Run("Malformed hashes", func(t *testing.T) {
+		for _, hashed := range []string{
+			"$scrypt$ln=15$abc$def",
+			"$bcrypt$ln=15,r=8,p=1$abc$def",
+			"$argon2id$v=16$m=1024,t=1,p=4$abc$def",
+			"$scrypt$ln=15,r=8,p=1$!!!$def",
+			"$scrypt$ln=40,r=8,p=1$abc$def",
+			"$argon2id$v=19$m=1024,t=0,p=4$abc$def",
+			"$argon2id$v=19$m=1024,t=1,p=0$abc$def",
+			"$argon2id$v=19$m=4194304,t=1,p=4$abc$def",
+		} {
+			valid, err := comparePasswords(hashed, password)
+			require.EqualError(t, err, "did not provide a valid hash")
+			require.False(t, valid)
+		}
+	})
+}
+
+// legacyHash creates a hash in the hex(hash).hex(salt) format
+// used before hashes recorded their parameters
+func legacyHash(t *testing.T, password string) string {
+	salt := make([]byte, 32)
+	_, err := rand.Read(salt)
+	require.NoError(t, err)
+
+	key, err := scrypt.Key([]byte(password), salt, 32768, 8, 1, 32)
+	require.NoError(t, err)
+
+	return fmt.Sprintf("%s.%s", hex.EncodeToString(key), hex.EncodeToString(salt))
+}
+
+func TestSetArgon2idCost(t *testing.T) {
+	defaults := defaultPasswordParams
+	defer func() { defaultPasswordParams = defaults }()
+
+	require.Error(t, SetArgon2idCost(64*1024, 0, 4))
+	require.Error(t, SetArgon2idCost(64*1024, 3, 0))
+	require.Error(t, SetArgon2idCost(maxArgon2Memory+1, 3, 4))
+	require.Equal(t, defaults, defaultPasswordParams)
+
+	require.NoError(t, SetArgon2idCost(1024, 1, 1))
+
+	hashed, err := hashPassword("password")
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(hashed, "$argon2id$v=19$m=1024,t=1,p=1$"))
+}
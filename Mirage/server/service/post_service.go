@@ -1,17 +1,29 @@
 package service
 
 import (
-	"github.com/lucsky/cuid"
+	"encoding/json"
 	"github.com/sentrionic/mirage/model"
 	"github.com/sentrionic/mirage/model/apperrors"
 	"log"
 	"mime/multipart"
-	"path"
+	"time"
 )
 
+// relatedHashtagWindow is how far back related hashtags are counted
+const relatedHashtagWindow = 30 * 24 * time.Hour
+
+// relatedHashtagLimit is the number of related hashtags returned
+const relatedHashtagLimit = 10
+
 type postService struct {
-	PostRepository model.PostRepository
-	FileRepository model.FileRepository
+	PostRepository  model.PostRepository
+	FileRepository  model.FileRepository
+	TrendService    model.TrendService
+	LinkService     model.LinkService
+	JobService      model.JobService
+	TimelineService model.TimelineService
+	Clock           func() time.Time
+	rankers         map[model.FeedMode]feedRanker
 }
 
 // PSConfig will hold repositories that will eventually be injected into this
@@ -19,15 +31,107 @@ type postService struct {
 type PSConfig struct {
 	PostRepository model.PostRepository
 	FileRepository model.FileRepository
+	// TrendService counts the hashtags of new and deleted posts. Optional
+	TrendService model.TrendService
+	// LinkService creates the preview card of the first link. Optional
+	LinkService model.LinkService
+	// JobService creates image variants and link cards in the background.
+	// Optional, without it they are created during the request
+	JobService model.JobService
+	// TimelineService keeps the precomputed home timelines. Optional,
+	// without it the latest feed is queried from the database
+	TimelineService model.TimelineService
+	// FeedSnapshotRepository keeps the ranking of the top feed between
+	// its pages. Optional, without it every page is scored again
+	FeedSnapshotRepository model.FeedSnapshotRepository
+	// Clock returns the current time for ranking the feed. Defaults to time.Now
+	Clock func() time.Time
 }
 
 // NewPostService is a factory function for
 // initializing a PostService with its repository layer dependencies
 func NewPostService(c *PSConfig) model.PostService {
-	return &postService{
-		PostRepository: c.PostRepository,
-		FileRepository: c.FileRepository,
+	clock := c.Clock
+	if clock == nil {
+		clock = time.Now
+	}
+
+	ps := &postService{
+		PostRepository:  c.PostRepository,
+		FileRepository:  c.FileRepository,
+		TrendService:    c.TrendService,
+		LinkService:     c.LinkService,
+		JobService:      c.JobService,
+		TimelineService: c.TimelineService,
+		Clock:           clock,
+		rankers: map[model.FeedMode]feedRanker{
+			model.FeedLatest: &chronologicalRanker{
+				PostRepository:  c.PostRepository,
+				TimelineService: c.TimelineService,
+			},
+			model.FeedTop: &topRanker{
+				PostRepository: c.PostRepository,
+				Snapshots:      c.FeedSnapshotRepository,
+				Clock:          clock,
+				Weights:        topFeedWeights,
+			},
+		},
+	}
+
+	if ps.JobService != nil {
+		ps.JobService.Handle(model.JobMediaVariants, ps.createVariants)
+
+		if ps.LinkService != nil {
+			ps.JobService.Handle(model.JobLinkCard, ps.attachCard)
+		}
+	}
+
+	return ps
+}
+
+// variantsJob is the payload of JobMediaVariants
+type variantsJob struct {
+	Hash string `json:"hash"`
+}
+
+// createVariants processes a JobMediaVariants job
+func (p *postService) createVariants(payload json.RawMessage) error {
+	var job variantsJob
+
+	if err := json.Unmarshal(payload, &job); err != nil {
+		return err
 	}
+
+	return p.mediaStore().storeVariants(job.Hash)
+}
+
+// linkCardJob is the payload of JobLinkCard
+type linkCardJob struct {
+	PostID string `json:"postId"`
+	URL    string `json:"url"`
+}
+
+// attachCard processes a JobLinkCard job. Links that can't be unfurled
+// are cached by the LinkService, so the job is not retried for them.
+func (p *postService) attachCard(payload json.RawMessage) error {
+	var job linkCardJob
+
+	if err := json.Unmarshal(payload, &job); err != nil {
+		return err
+	}
+
+	card, err := p.LinkService.Unfurl(job.URL)
+
+	if err != nil {
+		log.Printf("Unable to unfurl link: %v\n%v", job.URL, err)
+		return nil
+	}
+
+	if card == nil {
+		return nil
+	}
+
+	return p.PostRepository.SetCard(job.PostID, card)
 }
 
 func (p *postService) FindPostByID(id string) (*model.Post, error) {
@@ -44,51 +148,164 @@ func (p *postService) CreatePost(post *model.Post) (*model.Post, error) {
 	post.ID = id
 
 	if post.Text != nil {
+		post.Entities = ExtractEntities(*post.Text)
 		post.HashTags = GetHashtags(*post.Text)
+
+		if p.JobService == nil {
+			post.Card = p.unfurlFirstLink(post.Entities)
+		}
 	}
 
-	return p.PostRepository.Create(post)
+	created, err := p.PostRepository.Create(post)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if p.TrendService != nil && len(created.HashTags) > 0 {
+		if err := p.TrendService.Record(created.HashTags, created.CreatedAt); err != nil {
+			log.Printf("Unable to record trends for post: %v\n", created.ID)
+		}
+	}
+
+	if p.TimelineService != nil {
+		entry := model.TimelineEntry{PostID: created.ID, ActorID: created.UserID, CreatedAt: created.CreatedAt}
+		if err := p.TimelineService.Publish(entry); err != nil {
+			log.Printf("Unable to publish post to timelines: %v\n%v", created.ID, err)
+		}
+	}
+
+	if p.JobService != nil && created.File != nil && created.File.VariantsPending {
+		job, err := p.JobService.Enqueue(model.JobMediaVariants, created.UserID, &variantsJob{Hash: created.File.Hash})
+
+		if err != nil {
+			log.Printf("Unable to queue variants of file: %v\n%v", created.File.Hash, err)
+		} else {
+			created.File.JobID = job.ID
+		}
+	}
+
+	if p.JobService != nil && p.LinkService != nil {
+		if link := firstLink(created.Entities); link != "" {
+			if _, err := p.JobService.Enqueue(model.JobLinkCard, created.UserID, &linkCardJob{PostID: created.ID, URL: link}); err != nil {
+				log.Printf("Unable to queue card of post: %v\n%v", created.ID, err)
+			}
+		}
+	}
+
+	return created, nil
+}
+
+// unfurlFirstLink returns the preview card of the first URL in the
+// entities. Posts are still created if the link can't be unfurled.
+func (p *postService) unfurlFirstLink(entities model.Entities) *model.Card {
+	link := firstLink(entities)
+
+	if p.LinkService == nil || link == "" {
+		return nil
+	}
+
+	card, err := p.LinkService.Unfurl(link)
+
+	if err != nil {
+		log.Printf("Unable to unfurl link: %v\n%v", link, err)
+		return nil
+	}
+
+	return card
+}
+
+// firstLink returns the first URL in the entities
+func firstLink(entities model.Entities) string {
+	for _, entity := range entities {
+		if entity.Type == model.EntityURL {
+			return entity.Value
+		}
+	}
+
+	return ""
 }
 
 func (p *postService) DeletePost(post *model.Post) error {
-	if post.File != nil {
-		err := p.FileRepository.DeleteImage(post.File.Filename)
-		if err != nil {
+	// files uploaded before content hashing have their own objects. Objects
+	// shared by hash are removed by the media cleanup once they are orphaned
+	if post.File != nil && post.File.Hash == "" {
+		if err := p.deleteFileObjects(post.File.ObjectNames()); err != nil {
+			return err
+		}
+	}
+
+	if err := p.PostRepository.Delete(post); err != nil {
+		return err
+	}
+
+	if p.TrendService != nil && len(post.HashTags) > 0 {
+		if err := p.TrendService.Remove(post.HashTags, post.CreatedAt); err != nil {
+			log.Printf("Unable to remove trends of post: %v\n", post.ID)
+		}
+	}
+
+	// retweets of the post are skipped when the timelines are read
+	if p.TimelineService != nil {
+		entry := model.TimelineEntry{PostID: post.ID, ActorID: post.UserID, CreatedAt: post.CreatedAt}
+		if err := p.TimelineService.Retract(entry); err != nil {
+			log.Printf("Unable to retract post from timelines: %v\n%v", post.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// deleteFileObjects removes the media objects from the storage
+func (p *postService) deleteFileObjects(filenames []string) error {
+	for _, filename := range filenames {
+		if err := p.FileRepository.DeleteImage(mediaKey(filename)); err != nil {
 			return err
 		}
 	}
 
-	return p.PostRepository.Delete(post)
+	return nil
 }
 
+// UploadFile strips the image's metadata and uploads it together with its resized
+// variants. With a JobService the variants are created after the post got created.
 func (p *postService) UploadFile(header *multipart.FileHeader) (*model.File, error) {
-	slug := cuid.New()
-	ext := path.Ext(header.Filename)
-	filename := slug + ext
 	mimetype := header.Header.Get("Content-Type")
+	ext, ok := imageExtensions[mimetype]
 
-	file := model.File{
-		FileType: mimetype,
-		Filename: filename,
+	if !ok {
+		return nil, apperrors.NewBadRequest("unsupported image type")
 	}
 
-	id, err := GenerateId()
+	src, err := header.Open()
+
 	if err != nil {
-		return nil, err
+		log.Printf("Unable to open file: %v\n", err)
+		return nil, apperrors.NewInternal()
 	}
 
-	file.ID = id
+	defer src.Close()
 
-	directory := "media/"
-	url, err := p.FileRepository.UploadFile(header, directory, filename, mimetype)
+	return p.mediaStore().storeImage(src, mimetype, ext, p.JobService == nil)
+}
 
-	if err != nil {
-		return nil, err
+// mediaStore returns the image store using the service's repositories
+func (p *postService) mediaStore() *mediaStore {
+	return &mediaStore{
+		FileRepository: p.FileRepository,
+		PostRepository: p.PostRepository,
 	}
+}
 
-	file.Url = url
+// UpdateAltText sets the description of the post's attachment
+func (p *postService) UpdateAltText(post *model.Post, altText string) error {
+	if post.File == nil {
+		return apperrors.NewNotFound("file", post.ID)
+	}
+
+	post.File.AltText = altText
 
-	return &file, nil
+	return p.PostRepository.UpdateFile(post.File)
 }
 
 func (p *postService) ToggleLike(post *model.Post, uid string) error {
@@ -100,29 +317,242 @@ func (p *postService) ToggleLike(post *model.Post, uid string) error {
 }
 
 func (p *postService) ToggleRetweet(post *model.Post, uid string) error {
+	entry := model.TimelineEntry{PostID: post.ID, ActorID: uid, CreatedAt: p.Clock()}
+
 	if post.IsRetweeted(uid) {
-		return p.PostRepository.RemoveRetweet(post, uid)
-	} else {
-		return p.PostRepository.AddRetweet(post, uid)
+		if err := p.PostRepository.RemoveRetweet(post, uid); err != nil {
+			return err
+		}
+
+		if p.TimelineService != nil {
+			if err := p.TimelineService.Retract(entry); err != nil {
+				log.Printf("Unable to retract retweet from timelines: %v\n%v", post.ID, err)
+			}
+		}
+
+		return nil
+	}
+
+	if err := p.PostRepository.AddRetweet(post, uid); err != nil {
+		return err
+	}
+
+	if p.TimelineService != nil {
+		if err := p.TimelineService.Publish(entry); err != nil {
+			log.Printf("Unable to publish retweet to timelines: %v\n%v", post.ID, err)
+		}
 	}
+
+	return nil
 }
 
-func (p *postService) GetUserFeed(userId, cursor string) (*[]model.Post, error) {
-	return p.PostRepository.Feed(userId, cursor)
+// GetUserFeed returns a page of the home timeline ordered by the given mode
+// and the cursor of the next page. It defaults to the latest posts.
+func (p *postService) GetUserFeed(userId string, mode model.FeedMode, cursor string, r model.TimeRange) (*[]model.Post, string, error) {
+	if mode == "" {
+		mode = model.FeedLatest
+	}
+
+	ranker, ok := p.rankers[mode]
+
+	if !ok {
+		return nil, "", apperrors.NewBadRequest("invalid feed mode")
+	}
+
+	if r.IsZero() {
+		return ranker.Feed(userId, cursor)
+	}
+
+	rangeRanker, ok := ranker.(rangeFeedRanker)
+
+	if !ok {
+		return nil, "", apperrors.NewBadRequest("since and until are only supported by the latest feed")
+	}
+
+	return rangeRanker.FeedInRange(userId, cursor, r)
 }
 
-func (p *postService) ProfilePosts(id, cursor string) (*[]model.Post, error) {
-	return p.PostRepository.List(id, cursor)
+// ProfilePosts returns a page of the user's posts and retweets within the range
+// and the cursor of the next page
+func (p *postService) ProfilePosts(id, cursor string, r model.TimeRange) (*[]model.Post, string, error) {
+	after, err := parseEntryCursor(cursor)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	entries, err := p.PostRepository.ActivityEntries([]string{id}, entriesBefore(after, r), timelinePageEntries)
+
+	if err != nil {
+		log.Printf("Unable to get posts of user: %v\n%v", id, err)
+		return nil, "", apperrors.NewInternal()
+	}
+
+	entries, cut := entriesSince(entries, r.Since)
+
+	shown, err := shownBefore(after, r.Until, entries, nil)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	return buildTimelinePage(p.PostRepository, entries, !cut && len(entries) == timelinePageEntries, shown)
 }
 
-func (p *postService) ProfileLikes(id, cursor string) (*[]model.Post, error) {
-	return p.PostRepository.Likes(id, cursor)
+// ProfileLikes returns a page of the posts the user liked within the range,
+// newest like first, and the cursor of the next page
+func (p *postService) ProfileLikes(id, cursor string, r model.TimeRange) (*[]model.Post, string, error) {
+	after, err := parseEntryCursor(cursor)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	entries, err := p.PostRepository.LikeEntries(id, entriesBefore(after, r), model.LIMIT+1)
+
+	if err != nil {
+		log.Printf("Unable to get likes of user: %v\n%v", id, err)
+		return nil, "", apperrors.NewInternal()
+	}
+
+	entries, cut := entriesSince(entries, r.Since)
+
+	// a post is liked only once, so it can't be on an earlier page
+	return buildTimelinePage(p.PostRepository, entries, !cut && len(entries) == model.LIMIT+1, nil)
 }
 
-func (p *postService) SearchPosts(tag, cursor string) (*[]model.Post, error) {
-	return p.PostRepository.GetPostsForHashtag(tag, cursor)
+// SearchPosts returns a page of the newest posts matching the hashtag search
+// and, on the first page, the number of all matching posts
+func (p *postService) SearchPosts(search, cursor string) (*[]model.Post, int64, error) {
+	query, err := parseHashtagQuery(search)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if len(query.Tags) == 0 {
+		return &[]model.Post{}, 0, nil
+	}
+
+	posts, err := p.PostRepository.GetPostsForHashtags(query, cursor)
+
+	if err != nil {
+		log.Printf("Unable to find posts for hashtags: %v\n%v", query.Tags, err)
+		return nil, 0, apperrors.NewInternal()
+	}
+
+	if cursor != "" {
+		return posts, 0, nil
+	}
+
+	total, err := p.PostRepository.CountHashtagPosts(query)
+
+	if err != nil {
+		log.Printf("Unable to count posts for hashtags: %v\n%v", query.Tags, err)
+		return nil, 0, apperrors.NewInternal()
+	}
+
+	return posts, total, nil
+}
+
+// RelatedHashtags returns the hashtags most often used
+// together with the tag in the last relatedHashtagWindow
+func (p *postService) RelatedHashtags(tag string) (*[]model.RelatedHashtag, error) {
+	normalized, ok := normalizeHashtag(tag)
+
+	if !ok {
+		return nil, apperrors.NewBadRequest("invalid hashtag: " + tag)
+	}
+
+	related, err := p.PostRepository.RelatedHashtags(normalized, p.Clock().Add(-relatedHashtagWindow), relatedHashtagLimit)
+
+	if err != nil {
+		log.Printf("Unable to get related hashtags of: %v\n%v", normalized, err)
+		return nil, apperrors.NewInternal()
+	}
+
+	return related, nil
+}
+
+// ProfileMedia returns a page of the user's posts with media within the range, newest first
+func (p *postService) ProfileMedia(id, cursor string, r model.TimeRange) (*[]model.Post, error) {
+	if !r.Until.IsZero() {
+		before, err := pageBefore(cursor, r)
+
+		if err != nil {
+			return nil, err
+		}
+
+		cursor = formatTimelineCursor(before)
+	}
+
+	return p.PostRepository.Media(id, cursor, r.Since)
+}
+
+// Calendar returns the number of the user's posts per month or, for a
+// given year, per day of that year. Periods without posts are left out.
+func (p *postService) Calendar(id string, year int) ([]model.CalendarBucket, error) {
+	unit := model.CalendarMonth
+	from := time.Time{}
+	to := p.Clock().Add(24 * time.Hour)
+
+	if year != 0 {
+		if year < 1 || year > 9999 {
+			return nil, apperrors.NewBadRequest("invalid year")
+		}
+
+		unit = model.CalendarDay
+		from = time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+		to = from.AddDate(1, 0, 0)
+	}
+
+	buckets, err := p.PostRepository.PostCalendar(id, unit, from, to)
+
+	if err != nil {
+		log.Printf("Unable to get the calendar of user: %v\n%v", id, err)
+		return nil, apperrors.NewInternal()
+	}
+
+	if buckets == nil {
+		buckets = make([]model.CalendarBucket, 0)
+	}
+
+	return buckets, nil
+}
+
+// OnThisDay returns a page of the user's posts created on the same month and day as the
+// date in previous years, newest first. The date defaults to today. Both use UTC.
+func (p *postService) OnThisDay(id string, date time.Time, cursor string) (*[]model.Post, error) {
+	if date.IsZero() {
+		date = p.Clock()
+	}
+
+	date = date.UTC()
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+
+	before, err := pageBefore(cursor, model.TimeRange{Until: day})
+
+	if err != nil {
+		return nil, err
+	}
+
+	days := []int{day.Day()}
+
+	// posts from February 29 are shown on February 28 in the years without it
+	if day.Month() == time.February && day.Day() == 28 && !isLeapYear(day.Year()) {
+		days = append(days, 29)
+	}
+
+	posts, err := p.PostRepository.OnThisDay(id, day.Month(), days, before, model.LIMIT+1)
+
+	if err != nil {
+		log.Printf("Unable to get the posts on this day of user: %v\n%v", id, err)
+		return nil, apperrors.NewInternal()
+	}
+
+	return posts, nil
 }
 
-func (p *postService) ProfileMedia(id, cursor string) (*[]model.Post, error) {
-	return p.PostRepository.Media(id, cursor)
+func isLeapYear(year int) bool {
+	return year%4 == 0 && (year%100 != 0 || year%400 == 0)
 }
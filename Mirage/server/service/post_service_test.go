@@ -1,6 +1,8 @@
 package service
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"github.com/sentrionic/mirage/mocks"
 	"github.com/sentrionic/mirage/model"
@@ -8,7 +10,14 @@ import (
 	"github.com/sentrionic/mirage/model/fixture"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"image"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"net/http"
 	"testing"
+	"time"
 )
 
 func TestPostService_FindPostByID(t *testing.T) {
@@ -79,6 +88,110 @@ func TestPostService_CreatePost(t *testing.T) {
 		mockPostRepository.AssertExpectations(t)
 	})
 
+	t.Run("Stores normalized entities", func(t *testing.T) {
+		text := "Hello @Bob #Mirage https://mirage.xyz"
+		initial := &model.Post{
+			UserID: fixture.RandID(),
+			Text:   &text,
+		}
+
+		mockPostRepository := new(mocks.PostRepository)
+		ps := NewPostService(&PSConfig{
+			PostRepository: mockPostRepository,
+		})
+
+		mockPostRepository.
+			On("Create", initial).
+			Return(initial, nil)
+
+		post, err := ps.CreatePost(initial)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"#mirage"}, []string(post.HashTags))
+		assert.Len(t, post.Entities, 3)
+		assert.Equal(t, "bob", post.Entities[0].Value)
+		assert.Equal(t, model.EntityHashtag, post.Entities[1].Type)
+		assert.Equal(t, model.EntityURL, post.Entities[2].Type)
+	})
+
+	t.Run("Attaches the card of the first link", func(t *testing.T) {
+		text := "Read https://t.co/abc and https://t.co/def"
+		initial := &model.Post{
+			UserID: fixture.RandID(),
+			Text:   &text,
+		}
+		card := &model.Card{URL: "https://mirage.xyz/article", Title: "Article"}
+
+		mockPostRepository := new(mocks.PostRepository)
+		mockLinkService := new(mocks.LinkService)
+		ps := NewPostService(&PSConfig{
+			PostRepository: mockPostRepository,
+			LinkService:    mockLinkService,
+		})
+
+		mockLinkService.On("Unfurl", "https://t.co/abc").Return(card, nil)
+		mockPostRepository.On("Create", initial).Return(initial, nil)
+
+		post, err := ps.CreatePost(initial)
+
+		assert.NoError(t, err)
+		assert.Equal(t, card, post.Card)
+		mockLinkService.AssertNumberOfCalls(t, "Unfurl", 1)
+	})
+
+	t.Run("Creates the post if the link can't be unfurled", func(t *testing.T) {
+		text := "Read https://t.co/abc"
+		initial := &model.Post{
+			UserID: fixture.RandID(),
+			Text:   &text,
+		}
+
+		mockPostRepository := new(mocks.PostRepository)
+		mockLinkService := new(mocks.LinkService)
+		ps := NewPostService(&PSConfig{
+			PostRepository: mockPostRepository,
+			LinkService:    mockLinkService,
+		})
+
+		mockLinkService.On("Unfurl", "https://t.co/abc").Return(nil, apperrors.NewBadRequest("unable to fetch link"))
+		mockPostRepository.On("Create", initial).Return(initial, nil)
+
+		post, err := ps.CreatePost(initial)
+
+		assert.NoError(t, err)
+		assert.Nil(t, post.Card)
+	})
+
+	t.Run("Records hashtags for trends", func(t *testing.T) {
+		text := "#Go is fun"
+		initial := &model.Post{
+			UserID: fixture.RandID(),
+			Text:   &text,
+		}
+
+		mockPostRepository := new(mocks.PostRepository)
+		mockTrendService := new(mocks.TrendService)
+		ps := NewPostService(&PSConfig{
+			PostRepository: mockPostRepository,
+			TrendService:   mockTrendService,
+		})
+
+		mockPostRepository.
+			On("Create", initial).
+			Run(func(args mock.Arguments) {
+				initial.CreatedAt = time.Now()
+			}).
+			Return(initial, nil)
+		mockTrendService.
+			On("Record", []string{"#go"}, mock.AnythingOfType("time.Time")).
+			Return(nil)
+
+		_, err := ps.CreatePost(initial)
+
+		assert.NoError(t, err)
+		mockTrendService.AssertExpectations(t)
+	})
+
 	t.Run("Error", func(t *testing.T) {
 		mockPost := fixture.GetMockPost()
 		initial := &model.Post{
@@ -132,7 +245,7 @@ func TestPostService_UploadFile(t *testing.T) {
 		directory := "media/"
 
 		uploadFileArgs := mock.Arguments{
-			imageFileHeader,
+			mock.AnythingOfType("*bytes.Reader"),
 			directory,
 			mock.AnythingOfType("string"),
 			file.FileType,
@@ -140,6 +253,10 @@ func TestPostService_UploadFile(t *testing.T) {
 
 		imageURL := "https://imageurl.com/jdfkj34kljl"
 
+		mockPostRepository.On("ClaimFileHash", mock.AnythingOfType("string")).Return(nil)
+		mockPostRepository.
+			On("FindFileByHash", mock.AnythingOfType("string")).
+			Return(nil, apperrors.NewNotFound("file", "hash"))
 		mockFileRepository.
 			On("UploadFile", uploadFileArgs...).
 			Return(imageURL, nil)
@@ -158,6 +275,15 @@ func TestPostService_UploadFile(t *testing.T) {
 		uploadedFile, err := ps.UploadFile(imageFileHeader)
 		assert.NoError(t, err)
 		assert.NotNil(t, uploadedFile)
+		assert.Len(t, uploadedFile.Hash, 64)
+		assert.Equal(t, uploadedFile.Hash+".png", uploadedFile.Filename)
+		assert.Equal(t, 1, uploadedFile.Width)
+		assert.Equal(t, 1, uploadedFile.Height)
+		assert.NotEmpty(t, uploadedFile.Blurhash)
+		// the image is smaller than every variant
+		for _, name := range []string{"thumb", "small", "large", "original"} {
+			assert.Equal(t, imageURL, uploadedFile.Variants[name].Url)
+		}
 
 		newPost, err := ps.CreatePost(initial)
 
@@ -182,13 +308,17 @@ func TestPostService_UploadFile(t *testing.T) {
 		directory := "media/"
 
 		uploadFileArgs := mock.Arguments{
-			imageFileHeader,
+			mock.AnythingOfType("*bytes.Reader"),
 			directory,
 			mock.AnythingOfType("string"),
 			mock.AnythingOfType("string"),
 		}
 
 		mockError := apperrors.NewInternal()
+		mockPostRepository.On("ClaimFileHash", mock.AnythingOfType("string")).Return(nil)
+		mockPostRepository.
+			On("FindFileByHash", mock.AnythingOfType("string")).
+			Return(nil, apperrors.NewNotFound("file", "hash"))
 		mockFileRepository.
 			On("UploadFile", uploadFileArgs...).
 			Return("", mockError)
@@ -211,12 +341,16 @@ func TestPostService_UploadFile(t *testing.T) {
 		directory := "media/"
 
 		uploadFileArgs := mock.Arguments{
-			imageFileHeader,
+			mock.AnythingOfType("*bytes.Reader"),
 			directory,
 			mock.AnythingOfType("string"),
 			mock.AnythingOfType("string"),
 		}
 
+		mockPostRepository.On("ClaimFileHash", mock.AnythingOfType("string")).Return(nil)
+		mockPostRepository.
+			On("FindFileByHash", mock.AnythingOfType("string")).
+			Return(nil, apperrors.NewNotFound("file", "hash"))
 		mockFileRepository.
 			On("UploadFile", uploadFileArgs...).
 			Return(imageURL, nil)
@@ -250,6 +384,334 @@ func TestPostService_UploadFile(t *testing.T) {
 	})
 }
 
+func TestPostService_UploadFile_Deduplication(t *testing.T) {
+	mockPostRepository := new(mocks.PostRepository)
+	mockFileRepository := new(mocks.FileRepository)
+
+	ps := NewPostService(&PSConfig{
+		PostRepository: mockPostRepository,
+		FileRepository: mockFileRepository,
+	})
+
+	multipartImageFixture := fixture.NewMultipartImage("image.png", "image/png")
+	defer multipartImageFixture.Close()
+	imageFileHeader := multipartImageFixture.GetFormFile()
+
+	existing := &model.File{
+		ID:       "1",
+		Url:      "https://imageurl.com/abc.png",
+		FileType: "image/png",
+		Filename: "abc.png",
+		Width:    1,
+		Height:   1,
+		Hash:     "abc",
+		Variants: model.ImageVariants{
+			"original": {Url: "https://imageurl.com/abc.png", Filename: "abc.png"},
+		},
+	}
+
+	mockPostRepository.On("ClaimFileHash", mock.AnythingOfType("string")).Return(nil)
+	mockPostRepository.On("FindFileByHash", mock.AnythingOfType("string")).Return(existing, nil)
+
+	uploadedFile, err := ps.UploadFile(imageFileHeader)
+
+	assert.NoError(t, err)
+	assert.NotEqual(t, existing.ID, uploadedFile.ID)
+	assert.Equal(t, existing.Url, uploadedFile.Url)
+	assert.Equal(t, existing.Filename, uploadedFile.Filename)
+	assert.Equal(t, existing.Variants, uploadedFile.Variants)
+	mockFileRepository.AssertNotCalled(t, "UploadFile", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestPostService_QueuedVariants(t *testing.T) {
+	large := image.NewRGBA(image.Rect(0, 0, 1600, 900))
+	buf := new(bytes.Buffer)
+	require.NoError(t, png.Encode(buf, large))
+	encoded := buf.Bytes()
+
+	// newService returns the service and the handler it registered for variant jobs
+	newService := func(postRepository *mocks.PostRepository, fileRepository *mocks.FileRepository, jobService *mocks.JobService) (*postService, *model.JobHandler) {
+		var handler model.JobHandler
+		jobService.
+			On("Handle", model.JobMediaVariants, mock.AnythingOfType("model.JobHandler")).
+			Run(func(args mock.Arguments) {
+				handler = args.Get(1).(model.JobHandler)
+			})
+
+		ps := NewPostService(&PSConfig{
+			PostRepository: postRepository,
+			FileRepository: fileRepository,
+			JobService:     jobService,
+		})
+
+		return ps.(*postService), &handler
+	}
+
+	t.Run("Upload only stores the original", func(t *testing.T) {
+		mockPostRepository := new(mocks.PostRepository)
+		mockFileRepository := new(mocks.FileRepository)
+		ps, _ := newService(mockPostRepository, mockFileRepository, new(mocks.JobService))
+
+		mockPostRepository.On("ClaimFileHash", mock.AnythingOfType("string")).Return(nil)
+		mockPostRepository.On("FindFileByHash", mock.AnythingOfType("string")).Return(nil, apperrors.NewNotFound("file", "hash"))
+		mockFileRepository.On("UploadFile", mock.Anything, mediaDirectory, mock.AnythingOfType("string"), "image/png").Return("https://imageurl.com/original.png", nil)
+
+		file, err := ps.mediaStore().storeImage(bytes.NewReader(encoded), "image/png", ".png", false)
+
+		assert.NoError(t, err)
+		assert.True(t, file.VariantsPending)
+		assert.Equal(t, "https://imageurl.com/original.png", file.Variants["small"].Url)
+		mockFileRepository.AssertNumberOfCalls(t, "UploadFile", 1)
+	})
+
+	t.Run("Creating the post queues the variants", func(t *testing.T) {
+		mockPostRepository := new(mocks.PostRepository)
+		mockJobService := new(mocks.JobService)
+		ps, _ := newService(mockPostRepository, new(mocks.FileRepository), mockJobService)
+
+		post := &model.Post{
+			UserID: "1",
+			File:   &model.File{Hash: "abc", VariantsPending: true},
+		}
+
+		mockPostRepository.On("Create", post).Return(post, nil)
+		mockJobService.
+			On("Enqueue", model.JobMediaVariants, "1", &variantsJob{Hash: "abc"}).
+			Return(&model.Job{ID: "10"}, nil)
+
+		created, err := ps.CreatePost(post)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "10", created.File.JobID)
+		mockJobService.AssertExpectations(t)
+	})
+
+	t.Run("Job stores the variants", func(t *testing.T) {
+		mockPostRepository := new(mocks.PostRepository)
+		mockFileRepository := new(mocks.FileRepository)
+		_, handler := newService(mockPostRepository, mockFileRepository, new(mocks.JobService))
+
+		original := model.ImageVariant{Url: "https://imageurl.com/abc.png", Filename: "abc.png", FileType: "image/png", Width: 1600, Height: 900}
+		file := &model.File{
+			FileType: "image/png",
+			Filename: "abc.png",
+			Hash:     "abc",
+			Variants: model.ImageVariants{
+				"original": original,
+				"thumb":    original,
+				"small":    original,
+				"large":    original,
+			},
+		}
+
+		mockPostRepository.On("FindFileByHash", "abc").Return(file, nil)
+		mockFileRepository.On("OpenFile", "files/media//abc.png").Return(ioutil.NopCloser(bytes.NewReader(encoded)), nil)
+		mockFileRepository.
+			On("UploadFile", mock.Anything, mediaDirectory, mock.AnythingOfType("string"), "image/png").
+			Return(func(_ io.Reader, _, filename, _ string) string {
+				return "https://imageurl.com/" + filename
+			}, nil)
+		mockPostRepository.On("SetFileVariants", "abc", mock.AnythingOfType("model.ImageVariants")).Return(nil)
+
+		err := (*handler)(json.RawMessage(`{"hash":"abc"}`))
+
+		assert.NoError(t, err)
+		mockFileRepository.AssertNumberOfCalls(t, "UploadFile", 3)
+
+		variants := mockPostRepository.Calls[1].Arguments.Get(1).(model.ImageVariants)
+		assert.Equal(t, original, variants["original"])
+		assert.Equal(t, "https://imageurl.com/abc_small.png", variants["small"].Url)
+		assert.Equal(t, 680, variants["small"].Width)
+		assert.Equal(t, 150, variants["thumb"].Height)
+	})
+}
+
+func TestPostService_LinkCardJob(t *testing.T) {
+	// newService returns the service and the handler it registered for card jobs
+	newService := func(postRepository *mocks.PostRepository, linkService *mocks.LinkService, jobService *mocks.JobService) (model.PostService, *model.JobHandler) {
+		var handler model.JobHandler
+		jobService.On("Handle", model.JobMediaVariants, mock.AnythingOfType("model.JobHandler"))
+		jobService.
+			On("Handle", model.JobLinkCard, mock.AnythingOfType("model.JobHandler")).
+			Run(func(args mock.Arguments) {
+				handler = args.Get(1).(model.JobHandler)
+			})
+
+		ps := NewPostService(&PSConfig{
+			PostRepository: postRepository,
+			LinkService:    linkService,
+			JobService:     jobService,
+		})
+
+		return ps, &handler
+	}
+
+	t.Run("Creating the post queues the card", func(t *testing.T) {
+		text := "Read https://t.co/abc and https://t.co/def"
+		post := &model.Post{UserID: "1", Text: &text}
+
+		mockPostRepository := new(mocks.PostRepository)
+		mockLinkService := new(mocks.LinkService)
+		mockJobService := new(mocks.JobService)
+		ps, _ := newService(mockPostRepository, mockLinkService, mockJobService)
+
+		mockPostRepository.On("Create", post).Return(post, nil)
+		mockJobService.
+			On("Enqueue", model.JobLinkCard, "1", mock.MatchedBy(func(job *linkCardJob) bool {
+				return job.PostID == post.ID && job.URL == "https://t.co/abc"
+			})).
+			Return(&model.Job{ID: "10"}, nil)
+
+		created, err := ps.CreatePost(post)
+
+		assert.NoError(t, err)
+		assert.Nil(t, created.Card)
+		mockJobService.AssertExpectations(t)
+		mockLinkService.AssertNotCalled(t, "Unfurl", mock.Anything)
+	})
+
+	t.Run("Job attaches the card", func(t *testing.T) {
+		card := &model.Card{URL: "https://mirage.xyz/article", Title: "Article"}
+
+		mockPostRepository := new(mocks.PostRepository)
+		mockLinkService := new(mocks.LinkService)
+		_, handler := newService(mockPostRepository, mockLinkService, new(mocks.JobService))
+
+		mockLinkService.On("Unfurl", "https://t.co/abc").Return(card, nil)
+		mockPostRepository.On("SetCard", "1", card).Return(nil)
+
+		err := (*handler)(json.RawMessage(`{"postId":"1","url":"https://t.co/abc"}`))
+
+		assert.NoError(t, err)
+		mockPostRepository.AssertExpectations(t)
+	})
+
+	t.Run("Job gives up on links that can't be unfurled", func(t *testing.T) {
+		mockPostRepository := new(mocks.PostRepository)
+		mockLinkService := new(mocks.LinkService)
+		_, handler := newService(mockPostRepository, mockLinkService, new(mocks.JobService))
+
+		mockLinkService.On("Unfurl", "https://t.co/abc").Return(nil, apperrors.NewBadRequest("unable to fetch link"))
+
+		err := (*handler)(json.RawMessage(`{"postId":"1","url":"https://t.co/abc"}`))
+
+		assert.NoError(t, err)
+		mockPostRepository.AssertNotCalled(t, "SetCard", mock.Anything, mock.Anything)
+	})
+}
+
+func TestPostService_DeletePost(t *testing.T) {
+	t.Run("Leaves shared objects to the media cleanup", func(t *testing.T) {
+		mockPost := fixture.GetMockPost()
+		mockPost.File = &model.File{
+			PostId:   mockPost.ID,
+			Filename: "abc.png",
+			Hash:     "abc",
+		}
+
+		mockPostRepository := new(mocks.PostRepository)
+		mockFileRepository := new(mocks.FileRepository)
+		ps := NewPostService(&PSConfig{
+			PostRepository: mockPostRepository,
+			FileRepository: mockFileRepository,
+		})
+
+		mockPostRepository.On("Delete", mockPost).Return(nil)
+
+		err := ps.DeletePost(mockPost)
+
+		assert.NoError(t, err)
+		mockPostRepository.AssertExpectations(t)
+		mockFileRepository.AssertNotCalled(t, "DeleteImage", mock.Anything)
+	})
+
+	t.Run("Deletes the objects of files without a hash", func(t *testing.T) {
+		mockPost := fixture.GetMockPost()
+		mockPost.File = &model.File{
+			PostId:   mockPost.ID,
+			Filename: "image.png",
+			Variants: model.ImageVariants{
+				"original": {Filename: "image.png"},
+				"thumb":    {Filename: "image_thumb.png"},
+				"small":    {Filename: "image.png"},
+				"large":    {Filename: "image.png"},
+			},
+		}
+
+		mockPostRepository := new(mocks.PostRepository)
+		mockFileRepository := new(mocks.FileRepository)
+		ps := NewPostService(&PSConfig{
+			PostRepository: mockPostRepository,
+			FileRepository: mockFileRepository,
+		})
+
+		mockFileRepository.On("DeleteImage", "files/media//image.png").Return(nil).Once()
+		mockFileRepository.On("DeleteImage", "files/media//image_thumb.png").Return(nil).Once()
+		mockPostRepository.On("Delete", mockPost).Return(nil)
+
+		err := ps.DeletePost(mockPost)
+
+		assert.NoError(t, err)
+		mockFileRepository.AssertExpectations(t)
+		mockPostRepository.AssertExpectations(t)
+	})
+
+	t.Run("Removes the hashtags from the trends", func(t *testing.T) {
+		mockPost := fixture.GetMockPost()
+		mockPost.HashTags = []string{"#go"}
+
+		mockPostRepository := new(mocks.PostRepository)
+		mockTrendService := new(mocks.TrendService)
+		ps := NewPostService(&PSConfig{
+			PostRepository: mockPostRepository,
+			TrendService:   mockTrendService,
+		})
+
+		mockPostRepository.On("Delete", mockPost).Return(nil)
+		mockTrendService.On("Remove", []string{"#go"}, mockPost.CreatedAt).Return(nil)
+
+		err := ps.DeletePost(mockPost)
+
+		assert.NoError(t, err)
+		mockPostRepository.AssertExpectations(t)
+		mockTrendService.AssertExpectations(t)
+	})
+}
+
+func TestPostService_UpdateAltText(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		mockPost := fixture.GetMockPost()
+		mockPost.File = &model.File{PostId: mockPost.ID, FileType: "image/png"}
+
+		mockPostRepository := new(mocks.PostRepository)
+		ps := NewPostService(&PSConfig{
+			PostRepository: mockPostRepository,
+		})
+		mockPostRepository.On("UpdateFile", mockPost.File).Return(nil)
+
+		err := ps.UpdateAltText(mockPost, "A cat sleeping on a keyboard")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "A cat sleeping on a keyboard", mockPost.File.AltText)
+		mockPostRepository.AssertExpectations(t)
+	})
+
+	t.Run("Post without file", func(t *testing.T) {
+		mockPost := fixture.GetMockPost()
+
+		mockPostRepository := new(mocks.PostRepository)
+		ps := NewPostService(&PSConfig{
+			PostRepository: mockPostRepository,
+		})
+
+		err := ps.UpdateAltText(mockPost, "A cat")
+
+		assert.Error(t, err)
+		assert.Equal(t, http.StatusNotFound, apperrors.Status(err))
+		mockPostRepository.AssertNotCalled(t, "UpdateFile", mock.Anything)
+	})
+}
+
 func TestPostService_ToggleLike(t *testing.T) {
 	t.Run("Success change to liked", func(t *testing.T) {
 		uid, _ := GenerateId()
@@ -400,29 +862,55 @@ func TestPostService_ProfilePosts(t *testing.T) {
 		profile.Posts = append(profile.Posts, *mockPost)
 	}
 
+	entries := make([]model.TimelineEntry, 0)
+	ids := make([]string, 0)
+	for _, post := range profile.Posts {
+		entries = append(entries, model.TimelineEntry{PostID: post.ID, ActorID: authUser.ID, CreatedAt: post.CreatedAt})
+		ids = append(ids, post.ID)
+	}
+
 	t.Run("Success", func(t *testing.T) {
 		mockPostRepository := new(mocks.PostRepository)
 		ps := NewPostService(&PSConfig{
 			PostRepository: mockPostRepository,
 		})
-		mockPostRepository.On("List", authUser.ID, "").Return(&profile.Posts, nil)
+		mockPostRepository.On("ActivityEntries", []string{authUser.ID}, (*model.TimelineEntry)(nil), timelinePageEntries).Return(entries, nil)
+		mockPostRepository.On("FindByIDs", ids).Return(&profile.Posts, nil)
 
-		posts, err := ps.ProfilePosts(authUser.ID, "")
+		posts, next, err := ps.ProfilePosts(authUser.ID, "", model.TimeRange{})
 
 		assert.NoError(t, err)
 		assert.Equal(t, len(*posts), 5)
+		assert.Empty(t, next)
 		mockPostRepository.AssertExpectations(t)
 	})
 
+	t.Run("Retweet", func(t *testing.T) {
+		post := model.Post{ID: "1", UserID: "a", Retweets: []model.User{*authUser}}
+		mockPostRepository := new(mocks.PostRepository)
+		ps := NewPostService(&PSConfig{
+			PostRepository: mockPostRepository,
+		})
+		mockPostRepository.
+			On("ActivityEntries", []string{authUser.ID}, (*model.TimelineEntry)(nil), timelinePageEntries).
+			Return([]model.TimelineEntry{{PostID: "1", ActorID: authUser.ID, CreatedAt: time.Now()}}, nil)
+		mockPostRepository.On("FindByIDs", []string{"1"}).Return(&[]model.Post{post}, nil)
+
+		posts, _, err := ps.ProfilePosts(authUser.ID, "", model.TimeRange{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []model.User{*authUser}, (*posts)[0].RetweetedBy)
+	})
+
 	t.Run("Error", func(t *testing.T) {
 		mockPostRepository := new(mocks.PostRepository)
 		ps := NewPostService(&PSConfig{
 			PostRepository: mockPostRepository,
 		})
 
-		mockPostRepository.On("List", authUser.ID, "").Return(nil, fmt.Errorf("some error down the call chain"))
+		mockPostRepository.On("ActivityEntries", []string{authUser.ID}, (*model.TimelineEntry)(nil), timelinePageEntries).Return(nil, fmt.Errorf("some error down the call chain"))
 
-		posts, err := ps.ProfilePosts(authUser.ID, "")
+		posts, _, err := ps.ProfilePosts(authUser.ID, "", model.TimeRange{})
 
 		assert.Nil(t, posts)
 		assert.Error(t, err)
@@ -441,14 +929,22 @@ func TestPostService_GetUserFeed(t *testing.T) {
 		profile.Posts = append(profile.Posts, *mockPost)
 	}
 
+	entries := make([]model.TimelineEntry, 0)
+	ids := make([]string, 0)
+	for _, post := range profile.Posts {
+		entries = append(entries, model.TimelineEntry{PostID: post.ID, ActorID: post.UserID, CreatedAt: post.CreatedAt})
+		ids = append(ids, post.ID)
+	}
+
 	t.Run("Success", func(t *testing.T) {
 		mockPostRepository := new(mocks.PostRepository)
 		ps := NewPostService(&PSConfig{
 			PostRepository: mockPostRepository,
 		})
-		mockPostRepository.On("Feed", authUser.ID, "").Return(&profile.Posts, nil)
+		mockPostRepository.On("FeedEntries", authUser.ID, (*model.TimelineEntry)(nil), timelinePageEntries).Return(entries, nil)
+		mockPostRepository.On("FindByIDs", ids).Return(&profile.Posts, nil)
 
-		posts, err := ps.GetUserFeed(authUser.ID, "")
+		posts, _, err := ps.GetUserFeed(authUser.ID, "", "", model.TimeRange{})
 
 		assert.NoError(t, err)
 		assert.Equal(t, len(*posts), 5)
@@ -461,9 +957,9 @@ func TestPostService_GetUserFeed(t *testing.T) {
 			PostRepository: mockPostRepository,
 		})
 
-		mockPostRepository.On("Feed", authUser.ID, "").Return(nil, fmt.Errorf("some error down the call chain"))
+		mockPostRepository.On("FeedEntries", authUser.ID, (*model.TimelineEntry)(nil), timelinePageEntries).Return(nil, fmt.Errorf("some error down the call chain"))
 
-		posts, err := ps.GetUserFeed(authUser.ID, "")
+		posts, _, err := ps.GetUserFeed(authUser.ID, "", "", model.TimeRange{})
 
 		assert.Nil(t, posts)
 		assert.Error(t, err)
@@ -482,17 +978,37 @@ func TestPostService_ProfileLikes(t *testing.T) {
 		posts = append(posts, *mockPost)
 	}
 
+	likedAt := time.Date(2021, 5, 1, 12, 0, 0, 0, time.UTC)
+	entries := make([]model.TimelineEntry, 0)
+	ids := make([]string, 0)
+	for i, post := range posts {
+		entries = append(entries, model.TimelineEntry{
+			PostID:    post.ID,
+			ActorID:   authUser.ID,
+			CreatedAt: likedAt.Add(-time.Duration(i) * time.Minute),
+			Reason:    model.ReasonLikedBy,
+		})
+		ids = append(ids, post.ID)
+	}
+
 	t.Run("Success", func(t *testing.T) {
 		mockPostRepository := new(mocks.PostRepository)
 		ps := NewPostService(&PSConfig{
 			PostRepository: mockPostRepository,
 		})
-		mockPostRepository.On("Likes", authUser.ID, "").Return(&posts, nil)
+		mockPostRepository.On("LikeEntries", authUser.ID, (*model.TimelineEntry)(nil), model.LIMIT+1).Return(entries, nil)
+		mockPostRepository.On("FindByIDs", ids).Return(&posts, nil)
 
-		rsp, err := ps.ProfileLikes(authUser.ID, "")
+		rsp, next, err := ps.ProfileLikes(authUser.ID, "", model.TimeRange{})
 
 		assert.NoError(t, err)
 		assert.Equal(t, len(*rsp), 5)
+		assert.Empty(t, next)
+		// the likes are attributed to the user and paged by the time of the like
+		assert.Equal(t, model.ReasonLikedBy, (*rsp)[1].Activity.Reason)
+		assert.Equal(t, authUser.ID, (*rsp)[1].Activity.Actor.ID)
+		assert.Equal(t, entries[1].CreatedAt, (*rsp)[1].Activity.At)
+		assert.Empty(t, (*rsp)[1].RetweetedBy)
 		mockPostRepository.AssertExpectations(t)
 	})
 
@@ -502,9 +1018,9 @@ func TestPostService_ProfileLikes(t *testing.T) {
 			PostRepository: mockPostRepository,
 		})
 
-		mockPostRepository.On("Likes", authUser.ID, "").Return(nil, fmt.Errorf("some error down the call chain"))
+		mockPostRepository.On("LikeEntries", authUser.ID, (*model.TimelineEntry)(nil), model.LIMIT+1).Return(nil, fmt.Errorf("some error down the call chain"))
 
-		rsp, err := ps.ProfileLikes(authUser.ID, "")
+		rsp, _, err := ps.ProfileLikes(authUser.ID, "", model.TimeRange{})
 
 		assert.Nil(t, rsp)
 		assert.Error(t, err)
@@ -529,27 +1045,111 @@ func TestPostService_SearchPosts(t *testing.T) {
 			PostRepository: mockPostRepository,
 		})
 
-		term := "tes"
+		query := model.HashtagQuery{Tags: []string{"#tes"}, MatchAll: true}
 
-		mockPostRepository.On("GetPostsForHashtag", term, "").Return(&posts, nil)
+		mockPostRepository.On("GetPostsForHashtags", query, "").Return(&posts, nil)
+		mockPostRepository.On("CountHashtagPosts", query).Return(int64(42), nil)
 
-		rsp, err := ps.SearchPosts(term, "")
+		rsp, total, err := ps.SearchPosts("tes", "")
 
 		assert.NoError(t, err)
 		assert.Equal(t, 5, len(*rsp))
+		assert.Equal(t, int64(42), total)
+		mockPostRepository.AssertExpectations(t)
+	})
+
+	t.Run("Counts only on the first page", func(t *testing.T) {
+		mockPostRepository := new(mocks.PostRepository)
+		ps := NewPostService(&PSConfig{
+			PostRepository: mockPostRepository,
+		})
+
+		query := model.HashtagQuery{Tags: []string{"#tes"}, MatchAll: true}
+		cursor := "2021-05-01T11:00:00Z"
+
+		mockPostRepository.On("GetPostsForHashtags", query, cursor).Return(&posts, nil)
+
+		rsp, total, err := ps.SearchPosts("tes", cursor)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 5, len(*rsp))
+		assert.Equal(t, int64(0), total)
+		mockPostRepository.AssertNotCalled(t, "CountHashtagPosts", mock.Anything)
+	})
+
+	t.Run("All tags", func(t *testing.T) {
+		mockPostRepository := new(mocks.PostRepository)
+		ps := NewPostService(&PSConfig{
+			PostRepository: mockPostRepository,
+		})
+
+		query := model.HashtagQuery{Tags: []string{"#go", "#rust"}, MatchAll: true}
+
+		mockPostRepository.On("GetPostsForHashtags", query, "").Return(&posts, nil)
+		mockPostRepository.On("CountHashtagPosts", query).Return(int64(5), nil)
+
+		_, _, err := ps.SearchPosts("#Go AND #rust, #go", "")
+
+		assert.NoError(t, err)
+		mockPostRepository.AssertExpectations(t)
+	})
+
+	t.Run("Any tag", func(t *testing.T) {
+		mockPostRepository := new(mocks.PostRepository)
+		ps := NewPostService(&PSConfig{
+			PostRepository: mockPostRepository,
+		})
+
+		query := model.HashtagQuery{Tags: []string{"#go", "#rust"}, MatchAll: false}
+
+		mockPostRepository.On("GetPostsForHashtags", query, "").Return(&posts, nil)
+		mockPostRepository.On("CountHashtagPosts", query).Return(int64(5), nil)
+
+		_, _, err := ps.SearchPosts("#go OR #rust", "")
+
+		assert.NoError(t, err)
 		mockPostRepository.AssertExpectations(t)
 	})
 
+	t.Run("Invalid searches", func(t *testing.T) {
+		mockPostRepository := new(mocks.PostRepository)
+		ps := NewPostService(&PSConfig{
+			PostRepository: mockPostRepository,
+		})
+
+		for _, search := range []string{"#te%", "#go #r-ust", "#a AND #b OR #c", "#a #b #c #d #e #f", "#123"} {
+			_, _, err := ps.SearchPosts(search, "")
+
+			assert.Equal(t, http.StatusBadRequest, apperrors.Status(err), search)
+		}
+
+		mockPostRepository.AssertNotCalled(t, "GetPostsForHashtags")
+	})
+
+	t.Run("Empty search", func(t *testing.T) {
+		mockPostRepository := new(mocks.PostRepository)
+		ps := NewPostService(&PSConfig{
+			PostRepository: mockPostRepository,
+		})
+
+		rsp, total, err := ps.SearchPosts(" ", "")
+
+		assert.NoError(t, err)
+		assert.Empty(t, *rsp)
+		assert.Equal(t, int64(0), total)
+		mockPostRepository.AssertNotCalled(t, "GetPostsForHashtags")
+	})
+
 	t.Run("Error", func(t *testing.T) {
 		mockPostRepository := new(mocks.PostRepository)
 		ps := NewPostService(&PSConfig{
 			PostRepository: mockPostRepository,
 		})
 
-		term := "tes"
-		mockPostRepository.On("GetPostsForHashtag", term, "").Return(nil, fmt.Errorf("some error down the call chain"))
+		query := model.HashtagQuery{Tags: []string{"#tes"}, MatchAll: true}
+		mockPostRepository.On("GetPostsForHashtags", query, "").Return(nil, fmt.Errorf("some error down the call chain"))
 
-		rsp, err := ps.SearchPosts(term, "")
+		rsp, _, err := ps.SearchPosts("tes", "")
 
 		assert.Nil(t, rsp)
 		assert.Error(t, err)
@@ -557,6 +1157,39 @@ func TestPostService_SearchPosts(t *testing.T) {
 	})
 }
 
+func TestPostService_RelatedHashtags(t *testing.T) {
+	now := time.Date(2021, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Success", func(t *testing.T) {
+		mockPostRepository := new(mocks.PostRepository)
+		ps := NewPostService(&PSConfig{
+			PostRepository: mockPostRepository,
+			Clock:          func() time.Time { return now },
+		})
+
+		related := []model.RelatedHashtag{{Hashtag: "#rust", Count: 3}}
+		mockPostRepository.On("RelatedHashtags", "#go", now.Add(-relatedHashtagWindow), relatedHashtagLimit).Return(&related, nil)
+
+		rsp, err := ps.RelatedHashtags("Go")
+
+		assert.NoError(t, err)
+		assert.Equal(t, &related, rsp)
+		mockPostRepository.AssertExpectations(t)
+	})
+
+	t.Run("Invalid hashtag", func(t *testing.T) {
+		mockPostRepository := new(mocks.PostRepository)
+		ps := NewPostService(&PSConfig{
+			PostRepository: mockPostRepository,
+		})
+
+		_, err := ps.RelatedHashtags("go%")
+
+		assert.Equal(t, http.StatusBadRequest, apperrors.Status(err))
+		mockPostRepository.AssertNotCalled(t, "RelatedHashtags")
+	})
+}
+
 func TestPostService_ProfileMedia(t *testing.T) {
 
 	profile := fixture.GetMockUser()
@@ -574,9 +1207,9 @@ func TestPostService_ProfileMedia(t *testing.T) {
 		ps := NewPostService(&PSConfig{
 			PostRepository: mockPostRepository,
 		})
-		mockPostRepository.On("Media", profile.ID, "").Return(&posts, nil)
+		mockPostRepository.On("Media", profile.ID, "", time.Time{}).Return(&posts, nil)
 
-		rsp, err := ps.ProfileMedia(profile.ID, "")
+		rsp, err := ps.ProfileMedia(profile.ID, "", model.TimeRange{})
 
 		assert.NoError(t, err)
 		assert.Equal(t, len(*rsp), 5)
@@ -589,12 +1222,205 @@ func TestPostService_ProfileMedia(t *testing.T) {
 			PostRepository: mockPostRepository,
 		})
 
-		mockPostRepository.On("Media", profile.ID, "").Return(nil, fmt.Errorf("some error down the call chain"))
+		mockPostRepository.On("Media", profile.ID, "", time.Time{}).Return(nil, fmt.Errorf("some error down the call chain"))
 
-		rsp, err := ps.ProfileMedia(profile.ID, "")
+		rsp, err := ps.ProfileMedia(profile.ID, "", model.TimeRange{})
 
 		assert.Nil(t, rsp)
 		assert.Error(t, err)
 		mockPostRepository.AssertExpectations(t)
 	})
 }
+
+func TestPostService_ProfilePosts_TimeRange(t *testing.T) {
+	now := time.Date(2022, 2, 10, 12, 0, 0, 0, time.UTC)
+	entries := []model.TimelineEntry{
+		{PostID: "A", ActorID: "u", CreatedAt: now},
+		{PostID: "B", ActorID: "u", CreatedAt: now.AddDate(0, 0, -2)},
+		{PostID: "C", ActorID: "u", CreatedAt: now.AddDate(0, 0, -20)},
+	}
+
+	t.Run("Until bounds the first page", func(t *testing.T) {
+		until := now.AddDate(0, 0, 1)
+		mockPostRepository := new(mocks.PostRepository)
+		ps := NewPostService(&PSConfig{PostRepository: mockPostRepository})
+		mockPostRepository.On("ActivityEntries", []string{"u"}, &model.TimelineEntry{CreatedAt: until}, timelinePageEntries).Return(entries, nil)
+		mockPostRepository.On("FindByIDs", []string{"A", "B", "C"}).Return(&[]model.Post{{ID: "A"}, {ID: "B"}, {ID: "C"}}, nil)
+
+		posts, next, err := ps.ProfilePosts("u", "", model.TimeRange{Until: until})
+
+		assert.NoError(t, err)
+		assert.Len(t, *posts, 3)
+		assert.Empty(t, next)
+		mockPostRepository.AssertExpectations(t)
+	})
+
+	t.Run("Earlier cursor wins over until", func(t *testing.T) {
+		cursor := now.AddDate(0, 0, -1)
+		mockPostRepository := new(mocks.PostRepository)
+		ps := NewPostService(&PSConfig{PostRepository: mockPostRepository})
+		mockPostRepository.On("ActivityEntries", []string{"u"}, &model.TimelineEntry{CreatedAt: cursor}, timelinePageEntries).Return(entries[1:], nil)
+		mockPostRepository.On("FindByIDs", []string{"B", "C"}).Return(&[]model.Post{{ID: "B"}, {ID: "C"}}, nil)
+
+		posts, _, err := ps.ProfilePosts("u", formatTimelineCursor(cursor), model.TimeRange{Until: now.AddDate(0, 0, 1)})
+
+		assert.NoError(t, err)
+		assert.Len(t, *posts, 2)
+		mockPostRepository.AssertExpectations(t)
+	})
+
+	t.Run("Since drops older posts", func(t *testing.T) {
+		mockPostRepository := new(mocks.PostRepository)
+		ps := NewPostService(&PSConfig{PostRepository: mockPostRepository})
+		mockPostRepository.On("ActivityEntries", []string{"u"}, (*model.TimelineEntry)(nil), timelinePageEntries).Return(entries, nil)
+		mockPostRepository.On("FindByIDs", []string{"A", "B"}).Return(&[]model.Post{{ID: "A"}, {ID: "B"}}, nil)
+
+		posts, next, err := ps.ProfilePosts("u", "", model.TimeRange{Since: now.AddDate(0, 0, -7)})
+
+		assert.NoError(t, err)
+		assert.Len(t, *posts, 2)
+		assert.Empty(t, next)
+		mockPostRepository.AssertExpectations(t)
+	})
+
+	t.Run("Invalid cursor", func(t *testing.T) {
+		mockPostRepository := new(mocks.PostRepository)
+		ps := NewPostService(&PSConfig{PostRepository: mockPostRepository})
+
+		_, _, err := ps.ProfilePosts("u", "yesterday", model.TimeRange{Until: now})
+
+		assert.Equal(t, http.StatusBadRequest, apperrors.Status(err))
+		mockPostRepository.AssertNotCalled(t, "ActivityEntries")
+	})
+}
+
+func TestPostService_ProfileMedia_TimeRange(t *testing.T) {
+	now := time.Date(2022, 2, 10, 12, 0, 0, 0, time.UTC)
+	posts := []model.Post{
+		{ID: "A", CreatedAt: now},
+		{ID: "B", CreatedAt: now.AddDate(0, -1, 0)},
+	}
+
+	mockPostRepository := new(mocks.PostRepository)
+	ps := NewPostService(&PSConfig{PostRepository: mockPostRepository})
+	until := now.AddDate(0, 0, 1)
+	since := now.AddDate(0, 0, -7)
+	mockPostRepository.On("Media", "u", formatTimelineCursor(until), since).Return(&posts, nil)
+
+	rsp, err := ps.ProfileMedia("u", "", model.TimeRange{Since: since, Until: until})
+
+	assert.NoError(t, err)
+	assert.Equal(t, posts, *rsp)
+	mockPostRepository.AssertExpectations(t)
+}
+
+func TestPostService_Calendar(t *testing.T) {
+	now := time.Date(2022, 2, 10, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+
+	t.Run("Months", func(t *testing.T) {
+		buckets := []model.CalendarBucket{{Period: "2022-02", Count: 3}, {Period: "2021-11", Count: 1}}
+		mockPostRepository := new(mocks.PostRepository)
+		ps := NewPostService(&PSConfig{PostRepository: mockPostRepository, Clock: clock})
+		mockPostRepository.On("PostCalendar", "u", model.CalendarMonth, time.Time{}, now.Add(24*time.Hour)).Return(buckets, nil)
+
+		rsp, err := ps.Calendar("u", 0)
+
+		assert.NoError(t, err)
+		assert.Equal(t, buckets, rsp)
+		mockPostRepository.AssertExpectations(t)
+	})
+
+	t.Run("Days of a year", func(t *testing.T) {
+		from := time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)
+		mockPostRepository := new(mocks.PostRepository)
+		ps := NewPostService(&PSConfig{PostRepository: mockPostRepository, Clock: clock})
+		mockPostRepository.On("PostCalendar", "u", model.CalendarDay, from, from.AddDate(1, 0, 0)).Return(nil, nil)
+
+		rsp, err := ps.Calendar("u", 2021)
+
+		assert.NoError(t, err)
+		assert.NotNil(t, rsp)
+		assert.Empty(t, rsp)
+		mockPostRepository.AssertExpectations(t)
+	})
+
+	t.Run("Invalid year", func(t *testing.T) {
+		mockPostRepository := new(mocks.PostRepository)
+		ps := NewPostService(&PSConfig{PostRepository: mockPostRepository, Clock: clock})
+
+		_, err := ps.Calendar("u", 10000)
+
+		assert.Equal(t, http.StatusBadRequest, apperrors.Status(err))
+		mockPostRepository.AssertNotCalled(t, "PostCalendar")
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		mockPostRepository := new(mocks.PostRepository)
+		ps := NewPostService(&PSConfig{PostRepository: mockPostRepository, Clock: clock})
+		mockPostRepository.On("PostCalendar", "u", model.CalendarMonth, time.Time{}, now.Add(24*time.Hour)).Return(nil, fmt.Errorf("some error down the call chain"))
+
+		rsp, err := ps.Calendar("u", 0)
+
+		assert.Nil(t, rsp)
+		assert.Equal(t, http.StatusInternalServerError, apperrors.Status(err))
+	})
+}
+
+func TestPostService_OnThisDay(t *testing.T) {
+	now := time.Date(2022, 2, 10, 12, 0, 0, 0, time.UTC)
+	today := time.Date(2022, 2, 10, 0, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	posts := []model.Post{{ID: "A", CreatedAt: time.Date(2021, 2, 10, 9, 0, 0, 0, time.UTC)}}
+
+	t.Run("Defaults to today", func(t *testing.T) {
+		mockPostRepository := new(mocks.PostRepository)
+		ps := NewPostService(&PSConfig{PostRepository: mockPostRepository, Clock: clock})
+		mockPostRepository.On("OnThisDay", "u", time.February, []int{10}, today, model.LIMIT+1).Return(&posts, nil)
+
+		rsp, err := ps.OnThisDay("u", time.Time{}, "")
+
+		assert.NoError(t, err)
+		assert.Equal(t, posts, *rsp)
+		mockPostRepository.AssertExpectations(t)
+	})
+
+	t.Run("Given date and cursor", func(t *testing.T) {
+		date := time.Date(2020, 7, 4, 0, 0, 0, 0, time.UTC)
+		cursor := time.Date(2018, 7, 4, 8, 0, 0, 0, time.UTC)
+		mockPostRepository := new(mocks.PostRepository)
+		ps := NewPostService(&PSConfig{PostRepository: mockPostRepository, Clock: clock})
+		mockPostRepository.On("OnThisDay", "u", time.July, []int{4}, cursor, model.LIMIT+1).Return(&[]model.Post{}, nil)
+
+		rsp, err := ps.OnThisDay("u", date, formatTimelineCursor(cursor))
+
+		assert.NoError(t, err)
+		assert.Empty(t, *rsp)
+		mockPostRepository.AssertExpectations(t)
+	})
+
+	t.Run("February 29 shows on the 28th of other years", func(t *testing.T) {
+		for year, days := range map[int][]int{2023: {28, 29}, 2024: {28}, 2100: {28, 29}, 2000: {28}} {
+			date := time.Date(year, 2, 28, 0, 0, 0, 0, time.UTC)
+			mockPostRepository := new(mocks.PostRepository)
+			ps := NewPostService(&PSConfig{PostRepository: mockPostRepository, Clock: clock})
+			mockPostRepository.On("OnThisDay", "u", time.February, days, date, model.LIMIT+1).Return(&[]model.Post{}, nil)
+
+			_, err := ps.OnThisDay("u", date, "")
+
+			assert.NoError(t, err)
+			mockPostRepository.AssertExpectations(t)
+		}
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		mockPostRepository := new(mocks.PostRepository)
+		ps := NewPostService(&PSConfig{PostRepository: mockPostRepository, Clock: clock})
+		mockPostRepository.On("OnThisDay", "u", time.February, []int{10}, today, model.LIMIT+1).Return(nil, fmt.Errorf("some error down the call chain"))
+
+		rsp, err := ps.OnThisDay("u", time.Time{}, "")
+
+		assert.Nil(t, rsp)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"crypto/rand"
+	"strings"
+)
+
+// recoveryCodeCount is the number of recovery codes
+// a user gets when enabling two-factor authentication
+const recoveryCodeCount = 10
+
+// recoveryAlphabet leaves out characters that are easily confused
+const recoveryAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+
+// generateRecoveryCodes returns a set of one-time recovery codes
+// formatted as xxxxx-xxxxx together with their hashes
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+
+	for i := range codes {
+		b := make([]byte, 10)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+
+		for j := range b {
+			b[j] = recoveryAlphabet[int(b[j])%len(recoveryAlphabet)]
+		}
+
+		codes[i] = string(b[:5]) + "-" + string(b[5:])
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode hashes the code ignoring case, spaces and dashes
+func hashRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.NewReplacer("-", "", " ", "").Replace(code)
+	return hashToken(code)
+}
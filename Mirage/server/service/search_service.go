@@ -0,0 +1,215 @@
+package service
+
+import (
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"log"
+	"strings"
+	"time"
+)
+
+type searchService struct {
+	SavedSearchRepository   model.SavedSearchRepository
+	SearchHistoryRepository model.SearchHistoryRepository
+	PostRepository          model.PostRepository
+	Clock                   func() time.Time
+}
+
+// SeSConfig will hold repositories that will eventually be injected into this
+// this service layer
+type SeSConfig struct {
+	SavedSearchRepository   model.SavedSearchRepository
+	SearchHistoryRepository model.SearchHistoryRepository
+	// PostRepository counts the new results of saved post searches
+	PostRepository model.PostRepository
+	// Clock returns the current time. Defaults to time.Now
+	Clock func() time.Time
+}
+
+// NewSearchService is a factory function for
+// initializing a SearchService with its repository layer dependencies
+func NewSearchService(c *SeSConfig) model.SearchService {
+	clock := c.Clock
+	if clock == nil {
+		clock = time.Now
+	}
+
+	return &searchService{
+		SavedSearchRepository:   c.SavedSearchRepository,
+		SearchHistoryRepository: c.SearchHistoryRepository,
+		PostRepository:          c.PostRepository,
+		Clock:                   clock,
+	}
+}
+
+// SavedSearches returns the saved searches of the user. With counts, every saved
+// post search includes the number of matching posts created since its last visit.
+func (s *searchService) SavedSearches(uid string, withCounts bool) (*[]model.SavedSearch, error) {
+	searches, err := s.SavedSearchRepository.FindAllForUser(uid)
+
+	if err != nil {
+		log.Printf("Unable to get saved searches of user: %v\n%v", uid, err)
+		return nil, apperrors.NewInternal()
+	}
+
+	if !withCounts {
+		return searches, nil
+	}
+
+	queries := make([]model.HashtagQuery, 0)
+	counted := make([]*model.SavedSearch, 0)
+
+	for i := range *searches {
+		search := &(*searches)[i]
+
+		if search.Kind != model.SearchPosts {
+			continue
+		}
+
+		query, err := parseHashtagQuery(search.Query)
+
+		if err != nil || len(query.Tags) == 0 {
+			continue
+		}
+
+		query.Since = search.LastVisitedAt
+		queries = append(queries, query)
+		counted = append(counted, search)
+	}
+
+	if len(queries) == 0 {
+		return searches, nil
+	}
+
+	counts, err := s.PostRepository.CountHashtagPostsBatch(queries)
+
+	if err != nil {
+		log.Printf("Unable to count new results of saved searches of user: %v\n%v", uid, err)
+		return nil, apperrors.NewInternal()
+	}
+
+	for i, search := range counted {
+		search.NewResults = &counts[i]
+	}
+
+	return searches, nil
+}
+
+// SaveSearch saves the query under the given name. Post queries have
+// to be valid hashtag searches. A user can save MaxSavedSearches searches.
+func (s *searchService) SaveSearch(uid, name, query string, kind model.SearchKind) (*model.SavedSearch, error) {
+	query, err := normalizeSearchQuery(query, kind)
+
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := GenerateId()
+
+	if err != nil {
+		log.Printf("Unable to save search for user: %v\n", uid)
+		return nil, apperrors.NewInternal()
+	}
+
+	search := &model.SavedSearch{
+		ID:            id,
+		UserID:        uid,
+		Name:          name,
+		Query:         query,
+		Kind:          kind,
+		LastVisitedAt: s.Clock(),
+	}
+
+	if err := s.SavedSearchRepository.Create(search, model.MaxSavedSearches); err != nil {
+		return nil, err
+	}
+
+	return search, nil
+}
+
+// RenameSavedSearch changes the name of the user's saved search
+func (s *searchService) RenameSavedSearch(uid, id, name string) (*model.SavedSearch, error) {
+	search, err := s.SavedSearchRepository.FindByID(uid, id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	search.Name = name
+
+	if err := s.SavedSearchRepository.Update(search); err != nil {
+		return nil, err
+	}
+
+	return search, nil
+}
+
+// VisitSavedSearch marks the user's saved search as visited,
+// so its new results are counted from now on
+func (s *searchService) VisitSavedSearch(uid, id string) (*model.SavedSearch, error) {
+	search, err := s.SavedSearchRepository.FindByID(uid, id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	search.LastVisitedAt = s.Clock()
+
+	if err := s.SavedSearchRepository.Update(search); err != nil {
+		return nil, err
+	}
+
+	return search, nil
+}
+
+// DeleteSavedSearch removes the user's saved search
+func (s *searchService) DeleteSavedSearch(uid, id string) error {
+	return s.SavedSearchRepository.Delete(uid, id)
+}
+
+// RecordSearch adds the query to the user's recent searches. Post queries are stored
+// in the form parseHashtagQuery reads them, so that the same search is kept once.
+func (s *searchService) RecordSearch(uid, query string, kind model.SearchKind) error {
+	query, err := normalizeSearchQuery(query, kind)
+
+	if err != nil || query == "" {
+		return nil
+	}
+
+	return s.SearchHistoryRepository.Add(uid, model.SearchHistoryEntry{
+		Query:      query,
+		Kind:       kind,
+		SearchedAt: s.Clock(),
+	}, model.MaxSearchHistory)
+}
+
+// History returns the user's recent searches, newest first
+func (s *searchService) History(uid string) ([]model.SearchHistoryEntry, error) {
+	return s.SearchHistoryRepository.List(uid)
+}
+
+// ClearHistory removes all of the user's recent searches
+func (s *searchService) ClearHistory(uid string) error {
+	return s.SearchHistoryRepository.Clear(uid)
+}
+
+// normalizeSearchQuery trims the query. Post queries have to be valid
+// hashtag searches and get the lower cased tags without duplicates.
+func normalizeSearchQuery(query string, kind model.SearchKind) (string, error) {
+	query = strings.TrimSpace(query)
+
+	if kind != model.SearchPosts {
+		return query, nil
+	}
+
+	parsed, err := parseHashtagQuery(query)
+
+	if err != nil {
+		return "", err
+	}
+
+	if parsed.MatchAll {
+		return strings.Join(parsed.Tags, " "), nil
+	}
+	return strings.Join(parsed.Tags, " OR "), nil
+}
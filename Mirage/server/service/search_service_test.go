@@ -0,0 +1,248 @@
+package service
+
+import (
+	"fmt"
+	"github.com/sentrionic/mirage/mocks"
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestSearchService_SavedSearches(t *testing.T) {
+	visited := time.Date(2021, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	searches := func() *[]model.SavedSearch {
+		return &[]model.SavedSearch{
+			{ID: "1", UserID: "u", Query: "#go OR #rust", Kind: model.SearchPosts, LastVisitedAt: visited},
+			{ID: "2", UserID: "u", Query: "tong", Kind: model.SearchProfiles, LastVisitedAt: visited},
+		}
+	}
+
+	t.Run("Without counts", func(t *testing.T) {
+		mockSavedSearchRepository := new(mocks.SavedSearchRepository)
+		mockPostRepository := new(mocks.PostRepository)
+		ss := NewSearchService(&SeSConfig{
+			SavedSearchRepository: mockSavedSearchRepository,
+			PostRepository:        mockPostRepository,
+		})
+
+		mockSavedSearchRepository.On("FindAllForUser", "u").Return(searches(), nil)
+
+		rsp, err := ss.SavedSearches("u", false)
+
+		assert.NoError(t, err)
+		assert.Nil(t, (*rsp)[0].NewResults)
+		mockPostRepository.AssertNotCalled(t, "CountHashtagPostsBatch", mock.Anything)
+	})
+
+	t.Run("With counts", func(t *testing.T) {
+		mockSavedSearchRepository := new(mocks.SavedSearchRepository)
+		mockPostRepository := new(mocks.PostRepository)
+		ss := NewSearchService(&SeSConfig{
+			SavedSearchRepository: mockSavedSearchRepository,
+			PostRepository:        mockPostRepository,
+		})
+
+		query := model.HashtagQuery{Tags: []string{"#go", "#rust"}, MatchAll: false, Since: visited}
+
+		mockSavedSearchRepository.On("FindAllForUser", "u").Return(searches(), nil)
+		mockPostRepository.On("CountHashtagPostsBatch", []model.HashtagQuery{query}).Return([]int64{4}, nil)
+
+		rsp, err := ss.SavedSearches("u", true)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(4), *(*rsp)[0].NewResults)
+		assert.Nil(t, (*rsp)[1].NewResults)
+		mockPostRepository.AssertExpectations(t)
+	})
+
+	t.Run("Counts all searches at once", func(t *testing.T) {
+		mockSavedSearchRepository := new(mocks.SavedSearchRepository)
+		mockPostRepository := new(mocks.PostRepository)
+		ss := NewSearchService(&SeSConfig{
+			SavedSearchRepository: mockSavedSearchRepository,
+			PostRepository:        mockPostRepository,
+		})
+
+		all := append(*searches(), model.SavedSearch{ID: "3", UserID: "u", Query: "#tong", Kind: model.SearchPosts, LastVisitedAt: visited.Add(time.Hour)})
+		queries := []model.HashtagQuery{
+			{Tags: []string{"#go", "#rust"}, MatchAll: false, Since: visited},
+			{Tags: []string{"#tong"}, MatchAll: true, Since: visited.Add(time.Hour)},
+		}
+
+		mockSavedSearchRepository.On("FindAllForUser", "u").Return(&all, nil)
+		mockPostRepository.On("CountHashtagPostsBatch", queries).Return([]int64{4, 0}, nil).Once()
+
+		rsp, err := ss.SavedSearches("u", true)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(4), *(*rsp)[0].NewResults)
+		assert.Nil(t, (*rsp)[1].NewResults)
+		assert.Equal(t, int64(0), *(*rsp)[2].NewResults)
+		mockPostRepository.AssertExpectations(t)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		mockSavedSearchRepository := new(mocks.SavedSearchRepository)
+		ss := NewSearchService(&SeSConfig{
+			SavedSearchRepository: mockSavedSearchRepository,
+		})
+
+		mockSavedSearchRepository.On("FindAllForUser", "u").Return(nil, fmt.Errorf("some error down the call chain"))
+
+		rsp, err := ss.SavedSearches("u", false)
+
+		assert.Nil(t, rsp)
+		assert.Equal(t, http.StatusInternalServerError, apperrors.Status(err))
+	})
+}
+
+func TestSearchService_SaveSearch(t *testing.T) {
+	now := time.Date(2021, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Success", func(t *testing.T) {
+		mockSavedSearchRepository := new(mocks.SavedSearchRepository)
+		ss := NewSearchService(&SeSConfig{
+			SavedSearchRepository: mockSavedSearchRepository,
+			Clock:                 func() time.Time { return now },
+		})
+
+		mockSavedSearchRepository.On("Create", mock.AnythingOfType("*model.SavedSearch"), model.MaxSavedSearches).Return(nil)
+
+		search, err := ss.SaveSearch("u", "Go", " #Go, #rust #go ", model.SearchPosts)
+
+		assert.NoError(t, err)
+		assert.NotEmpty(t, search.ID)
+		assert.Equal(t, "u", search.UserID)
+		assert.Equal(t, "#go #rust", search.Query)
+		assert.Equal(t, now, search.LastVisitedAt)
+		mockSavedSearchRepository.AssertExpectations(t)
+	})
+
+	t.Run("Invalid post query", func(t *testing.T) {
+		mockSavedSearchRepository := new(mocks.SavedSearchRepository)
+		ss := NewSearchService(&SeSConfig{
+			SavedSearchRepository: mockSavedSearchRepository,
+		})
+
+		_, err := ss.SaveSearch("u", "Go", "#go%", model.SearchPosts)
+
+		assert.Equal(t, http.StatusBadRequest, apperrors.Status(err))
+		mockSavedSearchRepository.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Too many searches", func(t *testing.T) {
+		mockSavedSearchRepository := new(mocks.SavedSearchRepository)
+		ss := NewSearchService(&SeSConfig{
+			SavedSearchRepository: mockSavedSearchRepository,
+		})
+
+		mockSavedSearchRepository.
+			On("Create", mock.AnythingOfType("*model.SavedSearch"), model.MaxSavedSearches).
+			Return(apperrors.NewBadRequest("you can save at most 25 searches"))
+
+		search, err := ss.SaveSearch("u", "Tong", "tong", model.SearchProfiles)
+
+		assert.Nil(t, search)
+		assert.Equal(t, http.StatusBadRequest, apperrors.Status(err))
+	})
+}
+
+func TestSearchService_VisitSavedSearch(t *testing.T) {
+	now := time.Date(2021, 5, 2, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Success", func(t *testing.T) {
+		mockSavedSearchRepository := new(mocks.SavedSearchRepository)
+		ss := NewSearchService(&SeSConfig{
+			SavedSearchRepository: mockSavedSearchRepository,
+			Clock:                 func() time.Time { return now },
+		})
+
+		search := &model.SavedSearch{ID: "1", UserID: "u", LastVisitedAt: now.Add(-time.Hour)}
+		mockSavedSearchRepository.On("FindByID", "u", "1").Return(search, nil)
+		mockSavedSearchRepository.On("Update", search).Return(nil)
+
+		rsp, err := ss.VisitSavedSearch("u", "1")
+
+		assert.NoError(t, err)
+		assert.Equal(t, now, rsp.LastVisitedAt)
+		mockSavedSearchRepository.AssertExpectations(t)
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		mockSavedSearchRepository := new(mocks.SavedSearchRepository)
+		ss := NewSearchService(&SeSConfig{
+			SavedSearchRepository: mockSavedSearchRepository,
+		})
+
+		mockSavedSearchRepository.On("FindByID", "u", "1").Return(nil, apperrors.NewNotFound("saved search", "1"))
+
+		_, err := ss.VisitSavedSearch("u", "1")
+
+		assert.Equal(t, http.StatusNotFound, apperrors.Status(err))
+		mockSavedSearchRepository.AssertNotCalled(t, "Update", mock.Anything)
+	})
+}
+
+func TestSearchService_RecordSearch(t *testing.T) {
+	now := time.Date(2021, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Success", func(t *testing.T) {
+		mockSearchHistoryRepository := new(mocks.SearchHistoryRepository)
+		ss := NewSearchService(&SeSConfig{
+			SearchHistoryRepository: mockSearchHistoryRepository,
+			Clock:                   func() time.Time { return now },
+		})
+
+		entry := model.SearchHistoryEntry{Query: "#go", Kind: model.SearchPosts, SearchedAt: now}
+		mockSearchHistoryRepository.On("Add", "u", entry, model.MaxSearchHistory).Return(nil)
+
+		err := ss.RecordSearch("u", " #go ", model.SearchPosts)
+
+		assert.NoError(t, err)
+		mockSearchHistoryRepository.AssertExpectations(t)
+	})
+
+	t.Run("Normalizes post queries", func(t *testing.T) {
+		mockSearchHistoryRepository := new(mocks.SearchHistoryRepository)
+		ss := NewSearchService(&SeSConfig{
+			SearchHistoryRepository: mockSearchHistoryRepository,
+			Clock:                   func() time.Time { return now },
+		})
+
+		entry := model.SearchHistoryEntry{Query: "#go OR #rust", Kind: model.SearchPosts, SearchedAt: now}
+		mockSearchHistoryRepository.On("Add", "u", entry, model.MaxSearchHistory).Return(nil).Twice()
+
+		assert.NoError(t, ss.RecordSearch("u", "#Go OR #rust", model.SearchPosts))
+		assert.NoError(t, ss.RecordSearch("u", "#go, #RUST OR #go", model.SearchPosts))
+		mockSearchHistoryRepository.AssertExpectations(t)
+	})
+
+	t.Run("Invalid post query", func(t *testing.T) {
+		mockSearchHistoryRepository := new(mocks.SearchHistoryRepository)
+		ss := NewSearchService(&SeSConfig{
+			SearchHistoryRepository: mockSearchHistoryRepository,
+		})
+
+		err := ss.RecordSearch("u", "#go%", model.SearchPosts)
+
+		assert.NoError(t, err)
+		mockSearchHistoryRepository.AssertNotCalled(t, "Add", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Empty query", func(t *testing.T) {
+		mockSearchHistoryRepository := new(mocks.SearchHistoryRepository)
+		ss := NewSearchService(&SeSConfig{
+			SearchHistoryRepository: mockSearchHistoryRepository,
+		})
+
+		err := ss.RecordSearch("u", " ", model.SearchPosts)
+
+		assert.NoError(t, err)
+		mockSearchHistoryRepository.AssertNotCalled(t, "Add", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
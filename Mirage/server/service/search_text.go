@@ -0,0 +1,37 @@
+package service
+
+import (
+	"github.com/sentrionic/mirage/model"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+	"strings"
+	"unicode"
+)
+
+// normalizeSearchText folds the text for matching: compatibility forms
+// become their plain letters, accents get removed and the case is folded,
+// so "Ｔｏｎｇ", "Tóng" and "tong" all match each other.
+func normalizeSearchText(text string) string {
+	t := transform.Chain(norm.NFKD, runes.Remove(runes.In(unicode.Mn)), norm.NFKC)
+
+	result, _, err := transform.String(t, text)
+
+	if err != nil {
+		result = text
+	}
+
+	return strings.Join(strings.Fields(cases.Fold().String(result)), " ")
+}
+
+// setSearchText sets the normalized copies of the user's
+// display name and bio that profile search matches against
+func setSearchText(user *model.User) {
+	user.SearchName = normalizeSearchText(user.DisplayName)
+	user.SearchBio = ""
+
+	if user.Bio != nil {
+		user.SearchBio = normalizeSearchText(*user.Bio)
+	}
+}
@@ -0,0 +1,192 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"log"
+	"time"
+)
+
+// lastSeenInterval limits how often a session's last seen
+// time gets written back to the store
+const lastSeenInterval = time.Minute
+
+// pendingLoginTTL is how long a user has to enter their
+// two-factor code after entering their password
+const pendingLoginTTL = 5 * time.Minute
+
+// maxPendingAttempts is the number of wrong two-factor codes
+// after which the user has to enter their password again
+const maxPendingAttempts = 5
+
+type sessionService struct {
+	SessionRepository model.SessionRepository
+}
+
+// SSConfig will hold repositories that will eventually be injected into this
+// this service layer
+type SSConfig struct {
+	SessionRepository model.SessionRepository
+}
+
+// NewSessionService is a factory function for
+// initializing a SessionService with its repository layer dependencies
+func NewSessionService(c *SSConfig) model.SessionService {
+	return &sessionService{
+		SessionRepository: c.SessionRepository,
+	}
+}
+
+// Create records a new login for the given user
+func (s *sessionService) Create(uid, device, ip string) (*model.Session, error) {
+	id, err := GenerateId()
+
+	if err != nil {
+		log.Printf("Unable to create session for user: %v\n", uid)
+		return nil, apperrors.NewInternal()
+	}
+
+	now := time.Now()
+	session := &model.Session{
+		ID:        id,
+		UserID:    uid,
+		Device:    device,
+		IP:        ip,
+		CreatedAt: now,
+		LastSeen:  now,
+	}
+
+	if err := s.SessionRepository.Create(session); err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// UpgradeLegacy records a session for a cookie from before session records
+// existed. Such a cookie predates any revoke, so it is rejected once the
+// user revoked all of their sessions.
+func (s *sessionService) UpgradeLegacy(uid, device, ip string) (*model.Session, error) {
+	revoked, err := s.SessionRepository.RevokedAll(uid)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if revoked {
+		return nil, apperrors.NewAuthorization("Session expired, please sign in again")
+	}
+
+	return s.Create(uid, device, ip)
+}
+
+// Verify checks that the session still exists and belongs to the given user.
+// It also refreshes the session's last seen time and IP unless the
+// session got revoked in the meantime.
+func (s *sessionService) Verify(uid, id, ip string) (bool, error) {
+	session, err := s.SessionRepository.FindByID(id)
+
+	if err != nil {
+		var e *apperrors.Error
+		if errors.As(err, &e) && e.Type == apperrors.NotFound {
+			return false, nil
+		}
+		return false, err
+	}
+
+	if session.UserID != uid {
+		return false, nil
+	}
+
+	if time.Since(session.LastSeen) > lastSeenInterval || session.IP != ip {
+		session.LastSeen = time.Now()
+		session.IP = ip
+		active, err := s.SessionRepository.Update(session)
+
+		if err != nil {
+			log.Printf("Unable to update session: %v\n", id)
+		} else if !active {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// List returns all active sessions of the given user
+func (s *sessionService) List(uid string) (*[]model.Session, error) {
+	return s.SessionRepository.FindAllForUser(uid)
+}
+
+// Revoke removes a single session of the given user
+func (s *sessionService) Revoke(uid, id string) error {
+	session, err := s.SessionRepository.FindByID(id)
+
+	if err != nil {
+		return err
+	}
+
+	if session.UserID != uid {
+		return apperrors.NewNotFound("session", id)
+	}
+
+	return s.SessionRepository.Delete(uid, id)
+}
+
+// RevokeAll removes every session of the given user
+func (s *sessionService) RevokeAll(uid string) error {
+	return s.SessionRepository.DeleteAllForUser(uid)
+}
+
+// CreatePending stores a login that passed the password check
+// but still has to pass two-factor authentication.
+// It returns the token the client has to send along with the code.
+func (s *sessionService) CreatePending(uid string) (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		log.Printf("Unable to create pending login for user: %v\n", uid)
+		return "", apperrors.NewInternal()
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(secret)
+
+	if err := s.SessionRepository.CreatePending(token, uid, pendingLoginTTL); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// FindPending returns the user ID of the pending login
+func (s *sessionService) FindPending(token string) (string, error) {
+	uid, err := s.SessionRepository.FindPending(token)
+
+	if err != nil {
+		return "", apperrors.NewAuthorization("Login expired, please sign in again")
+	}
+
+	return uid, nil
+}
+
+// FailPending records a wrong two-factor code and
+// removes the pending login after too many attempts
+func (s *sessionService) FailPending(token string) error {
+	attempts, err := s.SessionRepository.IncrementPendingAttempts(token)
+
+	if err != nil {
+		return err
+	}
+
+	if attempts >= maxPendingAttempts {
+		return s.SessionRepository.DeletePending(token)
+	}
+
+	return nil
+}
+
+func (s *sessionService) DeletePending(token string) error {
+	return s.SessionRepository.DeletePending(token)
+}
@@ -0,0 +1,312 @@
+package service
+
+import (
+	"github.com/sentrionic/mirage/mocks"
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"github.com/sentrionic/mirage/model/fixture"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+	"time"
+)
+
+func TestSessionService_Create(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		uid := fixture.RandID()
+
+		mockSessionRepository := new(mocks.SessionRepository)
+		ss := NewSessionService(&SSConfig{
+			SessionRepository: mockSessionRepository,
+		})
+
+		mockSessionRepository.On("Create", mock.AnythingOfType("*model.Session")).Return(nil)
+
+		session, err := ss.Create(uid, "Mirage/1.0", "10.0.0.1")
+
+		assert.NoError(t, err)
+		assert.NotEmpty(t, session.ID)
+		assert.Equal(t, uid, session.UserID)
+		assert.Equal(t, "Mirage/1.0", session.Device)
+		assert.Equal(t, "10.0.0.1", session.IP)
+		mockSessionRepository.AssertExpectations(t)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		mockSessionRepository := new(mocks.SessionRepository)
+		ss := NewSessionService(&SSConfig{
+			SessionRepository: mockSessionRepository,
+		})
+
+		mockSessionRepository.On("Create", mock.AnythingOfType("*model.Session")).Return(apperrors.NewInternal())
+
+		session, err := ss.Create(fixture.RandID(), "Mirage/1.0", "10.0.0.1")
+
+		assert.Error(t, err)
+		assert.Nil(t, session)
+		mockSessionRepository.AssertExpectations(t)
+	})
+}
+
+func TestSessionService_UpgradeLegacy(t *testing.T) {
+	uid := fixture.RandID()
+
+	t.Run("Success", func(t *testing.T) {
+		mockSessionRepository := new(mocks.SessionRepository)
+		ss := NewSessionService(&SSConfig{
+			SessionRepository: mockSessionRepository,
+		})
+
+		mockSessionRepository.On("RevokedAll", uid).Return(false, nil)
+		mockSessionRepository.On("Create", mock.AnythingOfType("*model.Session")).Return(nil)
+
+		session, err := ss.UpgradeLegacy(uid, "Mirage/1.0", "10.0.0.1")
+
+		assert.NoError(t, err)
+		assert.Equal(t, uid, session.UserID)
+		mockSessionRepository.AssertExpectations(t)
+	})
+
+	t.Run("Rejected after revoking all sessions", func(t *testing.T) {
+		mockSessionRepository := new(mocks.SessionRepository)
+		ss := NewSessionService(&SSConfig{
+			SessionRepository: mockSessionRepository,
+		})
+
+		mockSessionRepository.On("RevokedAll", uid).Return(true, nil)
+
+		session, err := ss.UpgradeLegacy(uid, "Mirage/1.0", "10.0.0.1")
+
+		assert.Nil(t, session)
+		assert.Equal(t, apperrors.Authorization, err.(*apperrors.Error).Type)
+		mockSessionRepository.AssertNotCalled(t, "Create", mock.Anything)
+	})
+}
+
+func TestSessionService_Verify(t *testing.T) {
+	uid := fixture.RandID()
+
+	t.Run("Active session", func(t *testing.T) {
+		session := &model.Session{ID: fixture.RandID(), UserID: uid, IP: "10.0.0.1", LastSeen: time.Now()}
+
+		mockSessionRepository := new(mocks.SessionRepository)
+		ss := NewSessionService(&SSConfig{
+			SessionRepository: mockSessionRepository,
+		})
+
+		mockSessionRepository.On("FindByID", session.ID).Return(session, nil)
+
+		valid, err := ss.Verify(uid, session.ID, "10.0.0.1")
+
+		assert.NoError(t, err)
+		assert.True(t, valid)
+		mockSessionRepository.AssertNotCalled(t, "Update", session)
+	})
+
+	t.Run("Refreshes the last seen time", func(t *testing.T) {
+		session := &model.Session{ID: fixture.RandID(), UserID: uid, IP: "10.0.0.1", LastSeen: time.Now().Add(-time.Hour)}
+
+		mockSessionRepository := new(mocks.SessionRepository)
+		ss := NewSessionService(&SSConfig{
+			SessionRepository: mockSessionRepository,
+		})
+
+		mockSessionRepository.On("FindByID", session.ID).Return(session, nil)
+		mockSessionRepository.On("Update", session).Return(true, nil)
+
+		valid, err := ss.Verify(uid, session.ID, "10.0.0.2")
+
+		assert.NoError(t, err)
+		assert.True(t, valid)
+		assert.Equal(t, "10.0.0.2", session.IP)
+		assert.WithinDuration(t, time.Now(), session.LastSeen, time.Second)
+		mockSessionRepository.AssertExpectations(t)
+	})
+
+	t.Run("Revoked while refreshing", func(t *testing.T) {
+		session := &model.Session{ID: fixture.RandID(), UserID: uid, IP: "10.0.0.1", LastSeen: time.Now().Add(-time.Hour)}
+
+		mockSessionRepository := new(mocks.SessionRepository)
+		ss := NewSessionService(&SSConfig{
+			SessionRepository: mockSessionRepository,
+		})
+
+		mockSessionRepository.On("FindByID", session.ID).Return(session, nil)
+		mockSessionRepository.On("Update", session).Return(false, nil)
+
+		valid, err := ss.Verify(uid, session.ID, "10.0.0.1")
+
+		assert.NoError(t, err)
+		assert.False(t, valid)
+		mockSessionRepository.AssertExpectations(t)
+	})
+
+	t.Run("Revoked session", func(t *testing.T) {
+		id := fixture.RandID()
+
+		mockSessionRepository := new(mocks.SessionRepository)
+		ss := NewSessionService(&SSConfig{
+			SessionRepository: mockSessionRepository,
+		})
+
+		mockSessionRepository.On("FindByID", id).Return(nil, apperrors.NewNotFound("session", id))
+
+		valid, err := ss.Verify(uid, id, "10.0.0.1")
+
+		assert.NoError(t, err)
+		assert.False(t, valid)
+	})
+
+	t.Run("Session of another user", func(t *testing.T) {
+		session := &model.Session{ID: fixture.RandID(), UserID: fixture.RandID(), LastSeen: time.Now()}
+
+		mockSessionRepository := new(mocks.SessionRepository)
+		ss := NewSessionService(&SSConfig{
+			SessionRepository: mockSessionRepository,
+		})
+
+		mockSessionRepository.On("FindByID", session.ID).Return(session, nil)
+
+		valid, err := ss.Verify(uid, session.ID, "10.0.0.1")
+
+		assert.NoError(t, err)
+		assert.False(t, valid)
+	})
+
+	t.Run("Store error", func(t *testing.T) {
+		id := fixture.RandID()
+
+		mockSessionRepository := new(mocks.SessionRepository)
+		ss := NewSessionService(&SSConfig{
+			SessionRepository: mockSessionRepository,
+		})
+
+		mockSessionRepository.On("FindByID", id).Return(nil, apperrors.NewInternal())
+
+		valid, err := ss.Verify(uid, id, "10.0.0.1")
+
+		assert.Error(t, err)
+		assert.False(t, valid)
+	})
+}
+
+func TestSessionService_Revoke(t *testing.T) {
+	uid := fixture.RandID()
+
+	t.Run("Success", func(t *testing.T) {
+		session := &model.Session{ID: fixture.RandID(), UserID: uid}
+
+		mockSessionRepository := new(mocks.SessionRepository)
+		ss := NewSessionService(&SSConfig{
+			SessionRepository: mockSessionRepository,
+		})
+
+		mockSessionRepository.On("FindByID", session.ID).Return(session, nil)
+		mockSessionRepository.On("Delete", uid, session.ID).Return(nil)
+
+		err := ss.Revoke(uid, session.ID)
+
+		assert.NoError(t, err)
+		mockSessionRepository.AssertExpectations(t)
+	})
+
+	t.Run("Cannot revoke another user's session", func(t *testing.T) {
+		session := &model.Session{ID: fixture.RandID(), UserID: fixture.RandID()}
+
+		mockSessionRepository := new(mocks.SessionRepository)
+		ss := NewSessionService(&SSConfig{
+			SessionRepository: mockSessionRepository,
+		})
+
+		mockSessionRepository.On("FindByID", session.ID).Return(session, nil)
+
+		err := ss.Revoke(uid, session.ID)
+
+		assert.Error(t, err)
+		assert.Equal(t, apperrors.NotFound, err.(*apperrors.Error).Type)
+		mockSessionRepository.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+	})
+}
+
+func TestSessionService_RevokeAll(t *testing.T) {
+	uid := fixture.RandID()
+
+	mockSessionRepository := new(mocks.SessionRepository)
+	ss := NewSessionService(&SSConfig{
+		SessionRepository: mockSessionRepository,
+	})
+
+	mockSessionRepository.On("DeleteAllForUser", uid).Return(nil)
+
+	err := ss.RevokeAll(uid)
+
+	assert.NoError(t, err)
+	mockSessionRepository.AssertExpectations(t)
+}
+
+func TestSessionService_PendingLogin(t *testing.T) {
+	uid := fixture.RandID()
+
+	t.Run("Create", func(t *testing.T) {
+		mockSessionRepository := new(mocks.SessionRepository)
+		ss := NewSessionService(&SSConfig{
+			SessionRepository: mockSessionRepository,
+		})
+
+		mockSessionRepository.
+			On("CreatePending", mock.AnythingOfType("string"), uid, pendingLoginTTL).
+			Return(nil)
+
+		token, err := ss.CreatePending(uid)
+
+		assert.NoError(t, err)
+		assert.NotEmpty(t, token)
+		mockSessionRepository.AssertExpectations(t)
+	})
+
+	t.Run("Find expired", func(t *testing.T) {
+		mockSessionRepository := new(mocks.SessionRepository)
+		ss := NewSessionService(&SSConfig{
+			SessionRepository: mockSessionRepository,
+		})
+
+		mockSessionRepository.
+			On("FindPending", "token").
+			Return("", apperrors.NewNotFound("pending login", "token"))
+
+		id, err := ss.FindPending("token")
+
+		assert.Empty(t, id)
+		assert.Error(t, err)
+		assert.Equal(t, apperrors.Authorization, err.(*apperrors.Error).Type)
+	})
+
+	t.Run("Fail keeps the login below the limit", func(t *testing.T) {
+		mockSessionRepository := new(mocks.SessionRepository)
+		ss := NewSessionService(&SSConfig{
+			SessionRepository: mockSessionRepository,
+		})
+
+		mockSessionRepository.On("IncrementPendingAttempts", "token").Return(int64(1), nil)
+
+		err := ss.FailPending("token")
+
+		assert.NoError(t, err)
+		mockSessionRepository.AssertNotCalled(t, "DeletePending", mock.Anything)
+	})
+
+	t.Run("Fail removes the login at the limit", func(t *testing.T) {
+		mockSessionRepository := new(mocks.SessionRepository)
+		ss := NewSessionService(&SSConfig{
+			SessionRepository: mockSessionRepository,
+		})
+
+		mockSessionRepository.On("IncrementPendingAttempts", "token").Return(int64(maxPendingAttempts), nil)
+		mockSessionRepository.On("DeletePending", "token").Return(nil)
+
+		err := ss.FailPending("token")
+
+		assert.NoError(t, err)
+		mockSessionRepository.AssertExpectations(t)
+	})
+}
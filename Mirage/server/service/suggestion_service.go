@@ -0,0 +1,187 @@
+package service
+
+import (
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"log"
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	// suggestionWindow is how far back hashtag usage and activity are looked at
+	suggestionWindow = 30 * 24 * time.Hour
+	// suggestionCandidates is the number of accounts every signal adds to the ranking
+	suggestionCandidates = 200
+	// suggestionCacheSize is the number of ranked accounts that get cached
+	suggestionCacheSize = 50
+	// suggestionTTL is how long suggestions are cached without a refresh
+	suggestionTTL = 6 * time.Hour
+)
+
+// suggestionWeights are how much each signal adds to the score of an account
+type suggestionWeights struct {
+	MutualFollowers float64
+	SharedHashtags  float64
+	RecentPosts     float64
+}
+
+var defaultSuggestionWeights = suggestionWeights{
+	MutualFollowers: 3,
+	SharedHashtags:  2,
+	RecentPosts:     1,
+}
+
+type suggestionService struct {
+	UserRepository  model.UserRepository
+	SuggestionCache model.SuggestionCache
+	Clock           func() time.Time
+	Weights         suggestionWeights
+}
+
+// SgSConfig will hold repositories that will eventually be injected into this
+// this service layer
+type SgSConfig struct {
+	UserRepository  model.UserRepository
+	SuggestionCache model.SuggestionCache
+	// Clock returns the current time. Defaults to time.Now
+	Clock func() time.Time
+}
+
+// NewSuggestionService is a factory function for
+// initializing a SuggestionService with its repository layer dependencies
+func NewSuggestionService(c *SgSConfig) model.SuggestionService {
+	clock := c.Clock
+	if clock == nil {
+		clock = time.Now
+	}
+
+	return &suggestionService{
+		UserRepository:  c.UserRepository,
+		SuggestionCache: c.SuggestionCache,
+		Clock:           clock,
+		Weights:         defaultSuggestionWeights,
+	}
+}
+
+// Suggestions returns the accounts the user might want to follow, best first.
+// They are ranked once and cached until they expire or get refreshed.
+func (s *suggestionService) Suggestions(userId string) (*[]model.Suggestion, error) {
+	suggestions, err := s.SuggestionCache.Get(userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if suggestions == nil {
+		return s.Refresh(userId)
+	}
+
+	return s.withUsers(userId, suggestions)
+}
+
+// Refresh ranks the accounts the user might want to follow again and caches them
+func (s *suggestionService) Refresh(userId string) (*[]model.Suggestion, error) {
+	candidates, err := s.UserRepository.SuggestionCandidates(userId, s.Clock().Add(-suggestionWindow), suggestionCandidates)
+
+	if err != nil {
+		log.Printf("Unable to get suggestion candidates for user: %v\n%v", userId, err)
+		return nil, apperrors.NewInternal()
+	}
+
+	suggestions := rankSuggestions(candidates, s.Weights)
+
+	if len(suggestions) > suggestionCacheSize {
+		suggestions = suggestions[:suggestionCacheSize]
+	}
+
+	if err := s.SuggestionCache.Set(userId, suggestions, suggestionTTL); err != nil {
+		return nil, err
+	}
+
+	return s.withUsers(userId, suggestions)
+}
+
+// withUsers loads the suggested users and returns up to LIMIT suggestions.
+// Accounts the user followed or blocked since the suggestions were cached are skipped.
+func (s *suggestionService) withUsers(userId string, suggestions []model.Suggestion) (*[]model.Suggestion, error) {
+	blockedIds, err := s.UserRepository.BlockedIDs(userId)
+
+	if err != nil {
+		log.Printf("Unable to get blocked users of user: %v\n%v", userId, err)
+		return nil, apperrors.NewInternal()
+	}
+
+	blocked := make(map[string]bool, len(blockedIds))
+	for _, id := range blockedIds {
+		blocked[id] = true
+	}
+
+	ids := make([]string, len(suggestions))
+	for i, suggestion := range suggestions {
+		ids[i] = suggestion.UserID
+	}
+
+	found, err := s.UserRepository.FindByIDs(ids)
+
+	if err != nil {
+		log.Printf("Unable to load suggested users for user: %v\n%v", userId, err)
+		return nil, apperrors.NewInternal()
+	}
+
+	users := make(map[string]model.User, len(*found))
+	for _, user := range *found {
+		users[user.ID] = user
+	}
+
+	result := make([]model.Suggestion, 0, model.LIMIT)
+
+	for _, suggestion := range suggestions {
+		user, ok := users[suggestion.UserID]
+		if !ok || user.ID == userId || user.IsFollowing(userId) || blocked[user.ID] {
+			continue
+		}
+
+		suggestion.User = &user
+		result = append(result, suggestion)
+
+		if len(result) == model.LIMIT {
+			break
+		}
+	}
+
+	return &result, nil
+}
+
+// rankSuggestions scores the candidates and sorts them best first, highest ID first on ties
+func rankSuggestions(candidates []model.SuggestionCandidate, w suggestionWeights) []model.Suggestion {
+	suggestions := make([]model.Suggestion, len(candidates))
+
+	for i, c := range candidates {
+		suggestions[i] = model.Suggestion{
+			UserID:          c.UserID,
+			Score:           scoreSuggestion(c, w),
+			MutualFollowers: c.MutualFollowers,
+			SharedHashtags:  c.SharedHashtags,
+		}
+	}
+
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		a, b := suggestions[i], suggestions[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		return a.UserID > b.UserID
+	})
+
+	return suggestions
+}
+
+// scoreSuggestion adds up the signals of the candidate. Every signal grows
+// logarithmically so that no single one dominates the ranking.
+func scoreSuggestion(c model.SuggestionCandidate, w suggestionWeights) float64 {
+	return w.MutualFollowers*math.Log1p(float64(c.MutualFollowers)) +
+		w.SharedHashtags*math.Log1p(float64(c.SharedHashtags)) +
+		w.RecentPosts*math.Log1p(float64(c.RecentPosts))
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"github.com/sentrionic/mirage/mocks"
+	"github.com/sentrionic/mirage/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+	"time"
+)
+
+func TestSuggestionService_Suggestions(t *testing.T) {
+	now := time.Date(2021, 5, 1, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+
+	t.Run("Ranks and caches on a miss", func(t *testing.T) {
+		mockUserRepository := new(mocks.UserRepository)
+		mockSuggestionCache := new(mocks.SuggestionCache)
+		ss := NewSuggestionService(&SgSConfig{
+			UserRepository:  mockUserRepository,
+			SuggestionCache: mockSuggestionCache,
+			Clock:           clock,
+		})
+
+		candidates := []model.SuggestionCandidate{
+			{UserID: "active", RecentPosts: 40},
+			{UserID: "friend", MutualFollowers: 12, RecentPosts: 2},
+			{UserID: "tagger", SharedHashtags: 5},
+		}
+
+		mockSuggestionCache.On("Get", "u").Return(nil, nil)
+		mockUserRepository.On("SuggestionCandidates", "u", now.Add(-suggestionWindow), suggestionCandidates).Return(candidates, nil)
+		mockSuggestionCache.On("Set", "u", mock.AnythingOfType("[]model.Suggestion"), suggestionTTL).Return(nil)
+		mockUserRepository.On("BlockedIDs", "u").Return([]string{}, nil)
+		mockUserRepository.On("FindByIDs", []string{"friend", "active", "tagger"}).Return(&[]model.User{
+			{ID: "active"}, {ID: "friend"}, {ID: "tagger"},
+		}, nil)
+
+		suggestions, err := ss.Suggestions("u")
+
+		assert.NoError(t, err)
+		assert.Len(t, *suggestions, 3)
+		// followees' follows outweigh plain activity
+		assert.Equal(t, "friend", (*suggestions)[0].UserID)
+		assert.Equal(t, "friend", (*suggestions)[0].User.ID)
+		assert.Equal(t, int64(12), (*suggestions)[0].MutualFollowers)
+		mockSuggestionCache.AssertExpectations(t)
+	})
+
+	t.Run("Cached suggestions skip followed and blocked accounts", func(t *testing.T) {
+		mockUserRepository := new(mocks.UserRepository)
+		mockSuggestionCache := new(mocks.SuggestionCache)
+		ss := NewSuggestionService(&SgSConfig{
+			UserRepository:  mockUserRepository,
+			SuggestionCache: mockSuggestionCache,
+			Clock:           clock,
+		})
+
+		cached := []model.Suggestion{{UserID: "a", Score: 3}, {UserID: "b", Score: 2}, {UserID: "c", Score: 1}}
+		viewer := &model.User{ID: "u"}
+
+		mockSuggestionCache.On("Get", "u").Return(cached, nil)
+		// the user followed "a" and blocked "c" after the suggestions were cached
+		mockUserRepository.On("BlockedIDs", "u").Return([]string{"c"}, nil)
+		mockUserRepository.On("FindByIDs", []string{"a", "b", "c"}).Return(&[]model.User{
+			{ID: "a", Followers: []*model.User{viewer}}, {ID: "b"}, {ID: "c"},
+		}, nil)
+
+		suggestions, err := ss.Suggestions("u")
+
+		assert.NoError(t, err)
+		assert.Len(t, *suggestions, 1)
+		assert.Equal(t, "b", (*suggestions)[0].UserID)
+		mockUserRepository.AssertNotCalled(t, "SuggestionCandidates", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Refresh ignores the cache", func(t *testing.T) {
+		mockUserRepository := new(mocks.UserRepository)
+		mockSuggestionCache := new(mocks.SuggestionCache)
+		ss := NewSuggestionService(&SgSConfig{
+			UserRepository:  mockUserRepository,
+			SuggestionCache: mockSuggestionCache,
+			Clock:           clock,
+		})
+
+		mockUserRepository.On("SuggestionCandidates", "u", now.Add(-suggestionWindow), suggestionCandidates).Return([]model.SuggestionCandidate{}, nil)
+		mockSuggestionCache.On("Set", "u", []model.Suggestion{}, suggestionTTL).Return(nil)
+		mockUserRepository.On("BlockedIDs", "u").Return([]string{}, nil)
+		mockUserRepository.On("FindByIDs", []string{}).Return(&[]model.User{}, nil)
+
+		suggestions, err := ss.Refresh("u")
+
+		assert.NoError(t, err)
+		assert.Empty(t, *suggestions)
+		mockSuggestionCache.AssertNotCalled(t, "Get", "u")
+	})
+}
+
+func TestRankSuggestions(t *testing.T) {
+	suggestions := rankSuggestions([]model.SuggestionCandidate{
+		{UserID: "1", SharedHashtags: 1},
+		{UserID: "2", SharedHashtags: 1},
+		{UserID: "3", MutualFollowers: 1},
+	}, defaultSuggestionWeights)
+
+	ids := make([]string, len(suggestions))
+	for i, s := range suggestions {
+		ids[i] = s.UserID
+	}
+
+	// ties are ordered by the highest ID
+	assert.Equal(t, []string{"3", "2", "1"}, ids)
+}
@@ -0,0 +1,307 @@
+package service
+
+import (
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+// timelinePageEntries is the number of entries read for one page. A post
+// retweeted by several users has several entries that get collapsed,
+// so a page needs more entries than posts.
+const timelinePageEntries = 4 * (model.LIMIT + 1)
+
+// parseTimelineCursor parses the time of a timeline cursor. An empty cursor is the zero time.
+func parseTimelineCursor(cursor string) (time.Time, error) {
+	if cursor == "" {
+		return time.Time{}, nil
+	}
+
+	// a "+" in the query string turns into a space
+	t, err := time.Parse(time.RFC3339Nano, strings.Replace(cursor, " ", "+", 1))
+
+	if err != nil {
+		return time.Time{}, apperrors.NewBadRequest("invalid cursor")
+	}
+
+	return t, nil
+}
+
+// formatTimelineCursor formats the time like the timeline stores it
+func formatTimelineCursor(t time.Time) string {
+	return t.Truncate(time.Millisecond).UTC().Format(time.RFC3339Nano)
+}
+
+// pageBefore returns the time a page of the range starts before:
+// the time of the cursor or the end of the range, whichever is earlier
+func pageBefore(cursor string, r model.TimeRange) (time.Time, error) {
+	before, err := parseTimelineCursor(cursor)
+
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if !r.Until.IsZero() && (before.IsZero() || r.Until.Before(before)) {
+		before = r.Until
+	}
+
+	return before, nil
+}
+
+// parseEntryCursor parses the last entry of the previous page of a timeline.
+// An empty cursor is the first page. Cursors of only a time start before it.
+func parseEntryCursor(cursor string) (*model.TimelineEntry, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+
+	parts := strings.Split(cursor, "_")
+
+	if len(parts) != 1 && len(parts) != 3 {
+		return nil, apperrors.NewBadRequest("invalid cursor")
+	}
+
+	t, err := parseTimelineCursor(parts[0])
+
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &model.TimelineEntry{CreatedAt: t}
+	if len(parts) == 3 {
+		entry.PostID, entry.ActorID = parts[1], parts[2]
+	}
+
+	return entry, nil
+}
+
+// formatEntryCursor formats the entry with the full precision of its time
+func formatEntryCursor(entry model.TimelineEntry) string {
+	return entry.CreatedAt.UTC().Format(time.RFC3339Nano) + "_" + entry.PostID + "_" + entry.ActorID
+}
+
+// entriesBefore returns the entry a page of the range starts after:
+// the cursor's entry or the end of the range, whichever is earlier
+func entriesBefore(after *model.TimelineEntry, r model.TimeRange) *model.TimelineEntry {
+	if !r.Until.IsZero() && (after == nil || r.Until.Before(after.CreatedAt)) {
+		return &model.TimelineEntry{CreatedAt: r.Until}
+	}
+	return after
+}
+
+// newerEntry reports whether a comes before b in a newest first timeline
+func newerEntry(a, b model.TimelineEntry) bool {
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.After(b.CreatedAt)
+	}
+	if a.PostID != b.PostID {
+		return a.PostID > b.PostID
+	}
+	return a.ActorID > b.ActorID
+}
+
+// entryActivity returns all entries of the posts in a timeline
+type entryActivity func(postIds []string) ([]model.TimelineEntry, error)
+
+// shownBefore returns the posts of the entries that were on an earlier page,
+// placed at a newer entry than the cursor's. Without activity only the post of
+// the cursor is known. Entries after the end of the range were never shown.
+func shownBefore(after *model.TimelineEntry, until time.Time, entries []model.TimelineEntry, activity entryActivity) (map[string]bool, error) {
+	shown := make(map[string]bool)
+
+	if after == nil {
+		return shown, nil
+	}
+
+	shown[after.PostID] = true
+
+	if activity == nil {
+		return shown, nil
+	}
+
+	ids := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, entry := range entries {
+		if !seen[entry.PostID] && !shown[entry.PostID] {
+			seen[entry.PostID] = true
+			ids = append(ids, entry.PostID)
+		}
+	}
+
+	all, err := activity(ids)
+
+	if err != nil {
+		log.Printf("Unable to get activity of timeline posts: %v\n", err)
+		return nil, apperrors.NewInternal()
+	}
+
+	for _, entry := range all {
+		if newerEntry(entry, *after) && (until.IsZero() || entry.CreatedAt.Before(until)) {
+			shown[entry.PostID] = true
+		}
+	}
+
+	return shown, nil
+}
+
+// entriesSince drops the entries, which are ordered newest first, created before
+// the given time. It reports whether it dropped any, as then there are no more pages.
+func entriesSince(entries []model.TimelineEntry, since time.Time) ([]model.TimelineEntry, bool) {
+	if since.IsZero() {
+		return entries, false
+	}
+
+	for i, entry := range entries {
+		if entry.CreatedAt.Before(since) {
+			return entries[:i], true
+		}
+	}
+
+	return entries, false
+}
+
+// mergeTimelineEntries merges the entries newest first, drops duplicates
+// and keeps at most limit entries
+func mergeTimelineEntries(a, b []model.TimelineEntry, limit int) []model.TimelineEntry {
+	seen := make(map[model.TimelineEntry]bool)
+	merged := make([]model.TimelineEntry, 0, len(a)+len(b))
+
+	for _, entry := range append(append([]model.TimelineEntry{}, a...), b...) {
+		entry.CreatedAt = entry.CreatedAt.Truncate(time.Microsecond).UTC()
+		key := model.TimelineEntry{PostID: entry.PostID, ActorID: entry.ActorID}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		merged = append(merged, entry)
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		return newerEntry(merged[i], merged[j])
+	})
+
+	if len(merged) > limit {
+		merged = merged[:limit]
+	}
+
+	return merged
+}
+
+// timelineGroup is a post with all of its entries on a page
+type timelineGroup struct {
+	Newest model.TimelineEntry
+	Actors []string
+}
+
+// buildTimelinePage collapses the newest first entries into one post each, placed
+// at its newest entry, and records who retweeted it. Deleted posts and the shown
+// ones, which were on an earlier page, are skipped. It returns up to LIMIT+1 posts
+// and the cursor of the next page. Full tells if there were as many entries
+// as requested, so that more might follow.
+func buildTimelinePage(repo model.PostRepository, entries []model.TimelineEntry, full bool, shown map[string]bool) (*[]model.Post, string, error) {
+	groups := make([]*timelineGroup, 0)
+	byPost := make(map[string]*timelineGroup)
+
+	for _, entry := range entries {
+		if shown[entry.PostID] {
+			continue
+		}
+
+		group, ok := byPost[entry.PostID]
+		if !ok {
+			group = &timelineGroup{Newest: entry}
+			byPost[entry.PostID] = group
+			groups = append(groups, group)
+		}
+		if entry.Reason != model.ReasonLikedBy {
+			group.Actors = append(group.Actors, entry.ActorID)
+		}
+	}
+
+	ids := make([]string, len(groups))
+	for i, group := range groups {
+		ids[i] = group.Newest.PostID
+	}
+
+	found, err := repo.FindByIDs(ids)
+
+	if err != nil {
+		log.Printf("Unable to load timeline posts: %v\n", err)
+		return nil, "", apperrors.NewInternal()
+	}
+
+	loaded := make(map[string]model.Post, len(*found))
+	for _, post := range *found {
+		loaded[post.ID] = post
+	}
+
+	posts := make([]model.Post, 0, model.LIMIT+1)
+	var placed []*timelineGroup
+
+	for _, group := range groups {
+		post, ok := loaded[group.Newest.PostID]
+		if !ok {
+			continue
+		}
+
+		post.RetweetedBy = retweeters(&post, group.Actors)
+		post.Activity = activity(&post, group.Newest)
+		posts = append(posts, post)
+		placed = append(placed, group)
+
+		if len(posts) > model.LIMIT {
+			break
+		}
+	}
+
+	next := ""
+
+	switch {
+	case len(posts) > model.LIMIT:
+		next = formatEntryCursor(placed[model.LIMIT-1].Newest)
+	case full && len(entries) > 0:
+		// deleted, shown or collapsed posts left the page short
+		next = formatEntryCursor(entries[len(entries)-1])
+	}
+
+	return &posts, next, nil
+}
+
+// activity returns why the entry put the post into the timeline. Entries
+// without a reason are the author's post or a retweet by the actor.
+func activity(post *model.Post, entry model.TimelineEntry) *model.TimelineActivity {
+	switch entry.Reason {
+	case model.ReasonLikedBy:
+		for _, user := range post.Likes {
+			if user.ID == entry.ActorID {
+				return &model.TimelineActivity{Reason: model.ReasonLikedBy, Actor: user, At: entry.CreatedAt}
+			}
+		}
+	case "", model.ReasonRetweetedBy:
+		if len(post.RetweetedBy) > 0 && post.RetweetedBy[0].ID == entry.ActorID {
+			return &model.TimelineActivity{Reason: model.ReasonRetweetedBy, Actor: post.RetweetedBy[0], At: entry.CreatedAt}
+		}
+	}
+
+	return &model.TimelineActivity{Reason: model.ReasonAuthored, Actor: post.User, At: post.CreatedAt}
+}
+
+// retweeters returns the users among the actors that retweeted the post, in the actors' order
+func retweeters(post *model.Post, actors []string) []model.User {
+	users := make(map[string]model.User, len(post.Retweets))
+	for _, user := range post.Retweets {
+		users[user.ID] = user
+	}
+
+	var retweeted []model.User
+	for _, id := range actors {
+		if user, ok := users[id]; ok && id != post.UserID {
+			retweeted = append(retweeted, user)
+		}
+	}
+
+	return retweeted
+}
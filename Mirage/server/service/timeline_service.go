@@ -0,0 +1,428 @@
+package service
+
+import (
+	"encoding/json"
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"log"
+	"net/http"
+	"time"
+)
+
+const (
+	// timelineFanoutLimit is the follower count from which posts are no longer
+	// written to every follower's timeline. Their followers read them instead.
+	timelineFanoutLimit = 10000
+	// timelineRebuildSize is the number of entries a rebuilt timeline starts with
+	timelineRebuildSize = 800
+	// timelineRebuildBatch is the number of users RebuildAll loads at once
+	timelineRebuildBatch = 500
+)
+
+type timelineService struct {
+	TimelineRepository model.TimelineRepository
+	PostRepository     model.PostRepository
+	UserRepository     model.UserRepository
+	JobService         model.JobService
+	FanoutLimit        int
+}
+
+// TlSConfig will hold repositories that will eventually be injected into this
+// this service layer
+type TlSConfig struct {
+	TimelineRepository model.TimelineRepository
+	PostRepository     model.PostRepository
+	UserRepository     model.UserRepository
+	// JobService fans out posts in the background. Optional
+	JobService model.JobService
+	// FanoutLimit defaults to timelineFanoutLimit
+	FanoutLimit int
+}
+
+// NewTimelineService is a factory function for
+// initializing a TimelineService with its repository layer dependencies
+func NewTimelineService(c *TlSConfig) model.TimelineService {
+	limit := c.FanoutLimit
+	if limit == 0 {
+		limit = timelineFanoutLimit
+	}
+
+	s := &timelineService{
+		TimelineRepository: c.TimelineRepository,
+		PostRepository:     c.PostRepository,
+		UserRepository:     c.UserRepository,
+		JobService:         c.JobService,
+		FanoutLimit:        limit,
+	}
+
+	if s.JobService != nil {
+		s.JobService.Handle(model.JobTimelineFanout, s.entryJob(s.fanout))
+		s.JobService.Handle(model.JobTimelineRetract, s.entryJob(s.retract))
+	}
+
+	return s
+}
+
+// Timeline returns a page of the user's home timeline and the cursor of the next page.
+// Missing timelines get rebuilt and the posts of popular accounts are merged in.
+// Pages past the entries kept in the timeline are read from the database.
+func (s *timelineService) Timeline(userId, cursor string) (*[]model.Post, string, error) {
+	after, err := parseEntryCursor(cursor)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	exists, err := s.TimelineRepository.Exists(userId)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	if !exists {
+		if err := s.Rebuild(userId); err != nil {
+			return nil, "", err
+		}
+	}
+
+	entries, err := s.TimelineRepository.Range(userId, after, timelinePageEntries)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	// timelines only keep the most recent entries, so older
+	// pages continue with the entries from the database
+	if len(entries) < timelinePageEntries {
+		before := after
+		if len(entries) > 0 {
+			before = &entries[len(entries)-1]
+		}
+
+		older, err := s.PostRepository.FeedEntries(userId, before, timelinePageEntries-len(entries))
+
+		if err != nil {
+			log.Printf("Unable to get feed entries of user: %v\n%v", userId, err)
+			return nil, "", apperrors.NewInternal()
+		}
+
+		entries = append(entries, older...)
+	}
+
+	popular, err := s.popularFollowees(userId)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	full := len(entries) == timelinePageEntries
+
+	if len(popular) > 0 {
+		pulled, err := s.PostRepository.ActivityEntries(popular, after, timelinePageEntries)
+
+		if err != nil {
+			log.Printf("Unable to get posts of popular followees of user: %v\n%v", userId, err)
+			return nil, "", apperrors.NewInternal()
+		}
+
+		full = full || len(pulled) == timelinePageEntries
+		entries = mergeTimelineEntries(entries, pulled, timelinePageEntries)
+	}
+
+	shown, err := shownBefore(after, time.Time{}, entries, func(postIds []string) ([]model.TimelineEntry, error) {
+		return s.PostRepository.FeedActivity(userId, postIds)
+	})
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	return buildTimelinePage(s.PostRepository, entries, full, shown)
+}
+
+// Publish adds a new post or retweet to the timelines of the actor and their followers
+func (s *timelineService) Publish(entry model.TimelineEntry) error {
+	if s.JobService != nil {
+		_, err := s.JobService.Enqueue(model.JobTimelineFanout, "", &entry)
+		return err
+	}
+	return s.fanout(entry)
+}
+
+// Retract removes a deleted post or retweet from the timelines of the actor and their followers
+func (s *timelineService) Retract(entry model.TimelineEntry) error {
+	if s.JobService != nil {
+		_, err := s.JobService.Enqueue(model.JobTimelineRetract, "", &entry)
+		return err
+	}
+	return s.retract(entry)
+}
+
+// entryJob turns a fan-out function into a job handler
+func (s *timelineService) entryJob(run func(model.TimelineEntry) error) model.JobHandler {
+	return func(payload json.RawMessage) error {
+		var entry model.TimelineEntry
+
+		if err := json.Unmarshal(payload, &entry); err != nil {
+			return err
+		}
+
+		return run(entry)
+	}
+}
+
+func (s *timelineService) fanout(entry model.TimelineEntry) error {
+	recipients, err := s.recipients(entry.ActorID)
+
+	if err != nil {
+		return err
+	}
+
+	return s.TimelineRepository.Add(recipients, []model.TimelineEntry{entry})
+}
+
+func (s *timelineService) retract(entry model.TimelineEntry) error {
+	recipients, err := s.recipients(entry.ActorID)
+
+	if err != nil {
+		return err
+	}
+
+	return s.TimelineRepository.Remove(recipients, entry)
+}
+
+// recipients returns the actor and, unless the actor is
+// too popular for fan-out on write, their followers
+func (s *timelineService) recipients(actorId string) ([]string, error) {
+	recipients := []string{actorId}
+
+	popular, err := s.popularActors()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if popular[actorId] {
+		return recipients, nil
+	}
+
+	followers, err := s.UserRepository.FollowerIDs(actorId)
+
+	if err != nil {
+		log.Printf("Unable to get followers of user: %v\n%v", actorId, err)
+		return nil, apperrors.NewInternal()
+	}
+
+	return append(recipients, followers...), nil
+}
+
+// Follow adds the recent posts and retweets of the followee to the user's timeline
+func (s *timelineService) Follow(userId, followeeId string) error {
+	popular, err := s.updatePopular(followeeId)
+
+	if err != nil {
+		return err
+	}
+
+	// popular accounts get merged in when reading
+	if popular {
+		return nil
+	}
+
+	entries, err := s.PostRepository.ActivityEntries([]string{followeeId}, nil, timelineRebuildSize)
+
+	if err != nil {
+		log.Printf("Unable to get posts of user: %v\n%v", followeeId, err)
+		return apperrors.NewInternal()
+	}
+
+	return s.TimelineRepository.Add([]string{userId}, entries)
+}
+
+// Unfollow removes the posts and retweets of the followee from the user's timeline
+func (s *timelineService) Unfollow(userId, followeeId string) error {
+	if err := s.TimelineRepository.RemoveActor(userId, followeeId); err != nil {
+		return err
+	}
+
+	_, err := s.updatePopular(followeeId)
+	return err
+}
+
+// updatePopular adds the user to or removes them from the popular actors after
+// their follower count changed and returns whether they are popular. Followers of
+// a user that is no longer popular get their recent posts written to their timelines.
+func (s *timelineService) updatePopular(actorId string) (bool, error) {
+	count, err := s.UserRepository.FollowerCount(actorId)
+
+	if err != nil {
+		log.Printf("Unable to count followers of user: %v\n%v", actorId, err)
+		return false, apperrors.NewInternal()
+	}
+
+	popular := count >= int64(s.FanoutLimit)
+	changed, err := s.TimelineRepository.SetPopular(actorId, popular)
+
+	if err != nil {
+		return false, err
+	}
+
+	if !changed || popular {
+		return popular, nil
+	}
+
+	followers, err := s.UserRepository.FollowerIDs(actorId)
+
+	if err != nil {
+		log.Printf("Unable to get followers of user: %v\n%v", actorId, err)
+		return false, apperrors.NewInternal()
+	}
+
+	entries, err := s.PostRepository.ActivityEntries([]string{actorId}, nil, timelineRebuildSize)
+
+	if err != nil {
+		log.Printf("Unable to get posts of user: %v\n%v", actorId, err)
+		return false, apperrors.NewInternal()
+	}
+
+	return false, s.TimelineRepository.Add(followers, entries)
+}
+
+// popularActors returns the users whose posts are merged in when reading.
+// The set is built from the database if it's missing.
+func (s *timelineService) popularActors() (map[string]bool, error) {
+	ids, err := s.TimelineRepository.PopularActors()
+
+	if apperrors.Status(err) == http.StatusNotFound {
+		ids, err = s.refreshPopular()
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	popular := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		popular[id] = true
+	}
+
+	return popular, nil
+}
+
+// refreshPopular rebuilds the popular actors from the follower counts
+func (s *timelineService) refreshPopular() ([]string, error) {
+	ids, err := s.UserRepository.PopularUsers(s.FanoutLimit)
+
+	if err != nil {
+		log.Printf("Unable to get popular users: %v\n", err)
+		return nil, apperrors.NewInternal()
+	}
+
+	return ids, s.TimelineRepository.ReplacePopular(ids)
+}
+
+// popularFollowees returns the popular actors the user follows
+func (s *timelineService) popularFollowees(userId string) ([]string, error) {
+	popular, err := s.popularActors()
+
+	if err != nil || len(popular) == 0 {
+		return nil, err
+	}
+
+	followees, err := s.UserRepository.FolloweeIDs(userId)
+
+	if err != nil {
+		log.Printf("Unable to get followees of user: %v\n%v", userId, err)
+		return nil, apperrors.NewInternal()
+	}
+
+	ids := make([]string, 0)
+	for _, id := range followees {
+		if popular[id] {
+			ids = append(ids, id)
+		}
+	}
+
+	return ids, nil
+}
+
+// Rebuild recreates the user's timeline from their own and their followees' posts and retweets
+func (s *timelineService) Rebuild(userId string) error {
+	followees, err := s.UserRepository.FolloweeIDs(userId)
+
+	if err != nil {
+		log.Printf("Unable to get followees of user: %v\n%v", userId, err)
+		return apperrors.NewInternal()
+	}
+
+	popular, err := s.popularActors()
+
+	if err != nil {
+		return err
+	}
+
+	actors := []string{userId}
+	for _, id := range followees {
+		if !popular[id] {
+			actors = append(actors, id)
+		}
+	}
+
+	entries, err := s.PostRepository.ActivityEntries(actors, nil, timelineRebuildSize)
+
+	if err != nil {
+		log.Printf("Unable to get timeline entries of user: %v\n%v", userId, err)
+		return apperrors.NewInternal()
+	}
+
+	return s.TimelineRepository.Replace(userId, entries)
+}
+
+// RebuildAll counts the followers of every user again, recomputes the popular
+// actors and rebuilds every existing timeline. It returns how many timelines got rebuilt.
+func (s *timelineService) RebuildAll() (int, error) {
+	if err := s.UserRepository.RecountFollowers(); err != nil {
+		log.Printf("Unable to count followers: %v\n", err)
+		return 0, apperrors.NewInternal()
+	}
+
+	if _, err := s.refreshPopular(); err != nil {
+		return 0, err
+	}
+
+	rebuilt := 0
+	after := ""
+
+	for {
+		users, err := s.UserRepository.FindAfter(after, timelineRebuildBatch)
+
+		if err != nil {
+			log.Printf("Unable to get users after: %v\n%v", after, err)
+			return rebuilt, apperrors.NewInternal()
+		}
+
+		for _, user := range *users {
+			// missing timelines get built when they are read
+			exists, err := s.TimelineRepository.Exists(user.ID)
+
+			if err != nil {
+				return rebuilt, err
+			}
+
+			if !exists {
+				continue
+			}
+
+			if err := s.Rebuild(user.ID); err != nil {
+				return rebuilt, err
+			}
+			rebuilt++
+		}
+
+		if len(*users) < timelineRebuildBatch {
+			return rebuilt, nil
+		}
+
+		after = (*users)[len(*users)-1].ID
+	}
+}
@@ -0,0 +1,533 @@
+package service
+
+import (
+	"github.com/sentrionic/mirage/mocks"
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestTimelineService_Timeline(t *testing.T) {
+	now := time.Date(2021, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Rebuilds a missing timeline", func(t *testing.T) {
+		mockTimelineRepository := new(mocks.TimelineRepository)
+		mockPostRepository := new(mocks.PostRepository)
+		mockUserRepository := new(mocks.UserRepository)
+
+		ts := NewTimelineService(&TlSConfig{
+			TimelineRepository: mockTimelineRepository,
+			PostRepository:     mockPostRepository,
+			UserRepository:     mockUserRepository,
+			FanoutLimit:        100,
+		})
+
+		entries := []model.TimelineEntry{
+			{PostID: "2", ActorID: "b", CreatedAt: now},
+			{PostID: "1", ActorID: "c", CreatedAt: now.Add(-time.Minute)},
+		}
+
+		mockTimelineRepository.On("Exists", "u").Return(false, nil)
+		mockUserRepository.On("FolloweeIDs", "u").Return([]string{"b", "c", "p"}, nil)
+		mockTimelineRepository.On("PopularActors").Return([]string{"p", "x"}, nil)
+		mockPostRepository.On("ActivityEntries", []string{"u", "b", "c"}, (*model.TimelineEntry)(nil), timelineRebuildSize).Return(entries, nil)
+		mockTimelineRepository.On("Replace", "u", entries).Return(nil)
+		mockTimelineRepository.On("Range", "u", (*model.TimelineEntry)(nil), timelinePageEntries).Return(entries, nil)
+		// the timeline ran out before the page was full
+		mockPostRepository.On("FeedEntries", "u", &entries[1], timelinePageEntries-2).Return([]model.TimelineEntry{}, nil)
+		// popular accounts are merged in when reading
+		mockPostRepository.
+			On("ActivityEntries", []string{"p"}, (*model.TimelineEntry)(nil), timelinePageEntries).
+			Return([]model.TimelineEntry{{PostID: "3", ActorID: "p", CreatedAt: now.Add(-30 * time.Second)}}, nil)
+		mockPostRepository.On("FindByIDs", []string{"2", "3", "1"}).Return(&[]model.Post{{ID: "1"}, {ID: "2"}, {ID: "3"}}, nil)
+
+		posts, next, err := ts.Timeline("u", "")
+
+		assert.NoError(t, err)
+		assert.Empty(t, next)
+		ids := make([]string, 0)
+		for _, post := range *posts {
+			ids = append(ids, post.ID)
+		}
+		assert.Equal(t, []string{"2", "3", "1"}, ids)
+		mockTimelineRepository.AssertExpectations(t)
+		mockPostRepository.AssertExpectations(t)
+	})
+
+	t.Run("Skips deleted posts", func(t *testing.T) {
+		mockTimelineRepository := new(mocks.TimelineRepository)
+		mockPostRepository := new(mocks.PostRepository)
+		mockUserRepository := new(mocks.UserRepository)
+
+		ts := NewTimelineService(&TlSConfig{
+			TimelineRepository: mockTimelineRepository,
+			PostRepository:     mockPostRepository,
+			UserRepository:     mockUserRepository,
+		})
+
+		cursor := now.Format(time.RFC3339Nano)
+		entries := make([]model.TimelineEntry, 0)
+		found := make([]model.Post, 0)
+		for i := 0; i <= model.LIMIT; i++ {
+			id := string(rune('A' + i))
+			entries = append(entries, model.TimelineEntry{PostID: id, ActorID: "b", CreatedAt: now.Add(-time.Duration(i+1) * time.Minute)})
+			// the last two posts got deleted
+			if i < model.LIMIT-1 {
+				found = append(found, model.Post{ID: id})
+			}
+		}
+
+		mockTimelineRepository.On("Exists", "u").Return(true, nil)
+		mockTimelineRepository.On("Range", "u", &model.TimelineEntry{CreatedAt: now}, timelinePageEntries).Return(entries, nil)
+		mockPostRepository.On("FeedEntries", "u", mock.Anything, mock.Anything).Return([]model.TimelineEntry{}, nil)
+		mockTimelineRepository.On("PopularActors").Return([]string{}, nil)
+		mockPostRepository.On("FeedActivity", "u", mock.Anything).Return([]model.TimelineEntry{}, nil)
+		mockPostRepository.On("FindByIDs", mock.Anything).Return(&found, nil)
+
+		posts, next, err := ts.Timeline("u", cursor)
+
+		assert.NoError(t, err)
+		assert.Len(t, *posts, model.LIMIT-1)
+		// the timeline had no more entries
+		assert.Empty(t, next)
+	})
+
+	t.Run("Collapses retweets of the same post", func(t *testing.T) {
+		mockTimelineRepository := new(mocks.TimelineRepository)
+		mockPostRepository := new(mocks.PostRepository)
+		mockUserRepository := new(mocks.UserRepository)
+
+		ts := NewTimelineService(&TlSConfig{
+			TimelineRepository: mockTimelineRepository,
+			PostRepository:     mockPostRepository,
+			UserRepository:     mockUserRepository,
+		})
+
+		b, c, d := model.User{ID: "b"}, model.User{ID: "c"}, model.User{ID: "d"}
+		entries := []model.TimelineEntry{
+			{PostID: "1", ActorID: "d", CreatedAt: now},
+			{PostID: "2", ActorID: "b", CreatedAt: now.Add(-time.Minute)},
+			{PostID: "1", ActorID: "b", CreatedAt: now.Add(-2 * time.Minute)},
+			{PostID: "1", ActorID: "c", CreatedAt: now.Add(-3 * time.Minute)},
+			{PostID: "1", ActorID: "a", CreatedAt: now.Add(-4 * time.Minute)},
+		}
+
+		mockTimelineRepository.On("Exists", "u").Return(true, nil)
+		mockTimelineRepository.On("Range", "u", (*model.TimelineEntry)(nil), timelinePageEntries).Return(entries, nil)
+		mockPostRepository.On("FeedEntries", "u", mock.Anything, mock.Anything).Return([]model.TimelineEntry{}, nil)
+		mockTimelineRepository.On("PopularActors").Return([]string{}, nil)
+		mockPostRepository.On("FindByIDs", []string{"1", "2"}).Return(&[]model.Post{
+			{ID: "1", UserID: "a", Retweets: []model.User{b, c, d}},
+			{ID: "2", UserID: "b"},
+		}, nil)
+
+		posts, _, err := ts.Timeline("u", "")
+
+		assert.NoError(t, err)
+		assert.Len(t, *posts, 2)
+		assert.Equal(t, []model.User{d, b, c}, (*posts)[0].RetweetedBy)
+		assert.Empty(t, (*posts)[1].RetweetedBy)
+		assert.Equal(t, &model.TimelineActivity{Reason: model.ReasonRetweetedBy, Actor: d, At: now}, (*posts)[0].Activity)
+		assert.Equal(t, model.ReasonAuthored, (*posts)[1].Activity.Reason)
+
+		response := (*posts)[0].NewFeedResponse("u")
+		assert.True(t, response.IsRetweet)
+		assert.Equal(t, "d", response.RetweetedBy.User.ID)
+		assert.Equal(t, 2, response.RetweetedBy.Others)
+	})
+
+	t.Run("Cursor continues after a full page", func(t *testing.T) {
+		mockTimelineRepository := new(mocks.TimelineRepository)
+		mockPostRepository := new(mocks.PostRepository)
+		mockUserRepository := new(mocks.UserRepository)
+
+		ts := NewTimelineService(&TlSConfig{
+			TimelineRepository: mockTimelineRepository,
+			PostRepository:     mockPostRepository,
+			UserRepository:     mockUserRepository,
+		})
+
+		// every post was retweeted by so many followees that the entries run out first
+		entries := make([]model.TimelineEntry, 0)
+		for i := 0; i < timelinePageEntries; i++ {
+			id := string(rune('A' + i/10))
+			entries = append(entries, model.TimelineEntry{PostID: id, ActorID: "b", CreatedAt: now.Add(-time.Duration(i) * time.Minute)})
+		}
+
+		mockTimelineRepository.On("Exists", "u").Return(true, nil)
+		mockTimelineRepository.On("Range", "u", (*model.TimelineEntry)(nil), timelinePageEntries).Return(entries, nil)
+		mockTimelineRepository.On("PopularActors").Return([]string{}, nil)
+		mockPostRepository.On("FindByIDs", mock.Anything).Return(&[]model.Post{{ID: "A"}, {ID: "B"}, {ID: "C"}, {ID: "D"}}, nil)
+
+		posts, next, err := ts.Timeline("u", "")
+
+		assert.NoError(t, err)
+		assert.Len(t, *posts, 4)
+		// the next page starts after the last entry that was read
+		assert.Equal(t, formatEntryCursor(entries[len(entries)-1]), next)
+	})
+
+	t.Run("Skips posts shown on an earlier page", func(t *testing.T) {
+		mockTimelineRepository := new(mocks.TimelineRepository)
+		mockPostRepository := new(mocks.PostRepository)
+		mockUserRepository := new(mocks.UserRepository)
+
+		ts := NewTimelineService(&TlSConfig{
+			TimelineRepository: mockTimelineRepository,
+			PostRepository:     mockPostRepository,
+			UserRepository:     mockUserRepository,
+		})
+
+		after := model.TimelineEntry{PostID: "1", ActorID: "b", CreatedAt: now.Add(-time.Minute)}
+		entries := []model.TimelineEntry{
+			{PostID: "2", ActorID: "c", CreatedAt: now.Add(-2 * time.Minute)},
+			{PostID: "3", ActorID: "b", CreatedAt: now.Add(-3 * time.Minute)},
+			{PostID: "1", ActorID: "c", CreatedAt: now.Add(-4 * time.Minute)},
+		}
+
+		mockTimelineRepository.On("Exists", "u").Return(true, nil)
+		mockTimelineRepository.On("Range", "u", &after, timelinePageEntries).Return(entries, nil)
+		mockPostRepository.On("FeedEntries", "u", mock.Anything, mock.Anything).Return([]model.TimelineEntry{}, nil)
+		mockTimelineRepository.On("PopularActors").Return([]string{}, nil)
+		// post 2 was retweeted by d on the previous page
+		mockPostRepository.On("FeedActivity", "u", []string{"2", "3"}).Return([]model.TimelineEntry{
+			entries[0],
+			{PostID: "2", ActorID: "d", CreatedAt: now.Add(-30 * time.Second)},
+			entries[1],
+		}, nil)
+		mockPostRepository.On("FindByIDs", []string{"3"}).Return(&[]model.Post{{ID: "3"}}, nil)
+
+		posts, next, err := ts.Timeline("u", formatEntryCursor(after))
+
+		assert.NoError(t, err)
+		assert.Len(t, *posts, 1)
+		assert.Equal(t, "3", (*posts)[0].ID)
+		assert.Empty(t, next)
+		mockPostRepository.AssertExpectations(t)
+	})
+
+	t.Run("Continues from the database when the timeline runs out", func(t *testing.T) {
+		mockTimelineRepository := new(mocks.TimelineRepository)
+		mockPostRepository := new(mocks.PostRepository)
+		mockUserRepository := new(mocks.UserRepository)
+
+		ts := NewTimelineService(&TlSConfig{
+			TimelineRepository: mockTimelineRepository,
+			PostRepository:     mockPostRepository,
+			UserRepository:     mockUserRepository,
+		})
+
+		after := model.TimelineEntry{PostID: "1", ActorID: "b", CreatedAt: now}
+		// the timeline was trimmed after its last entry
+		entries := []model.TimelineEntry{
+			{PostID: "2", ActorID: "b", CreatedAt: now.Add(-time.Minute)},
+		}
+		older := make([]model.TimelineEntry, 0)
+		for i := 0; i < timelinePageEntries-1; i++ {
+			id := string(rune('A' + i))
+			older = append(older, model.TimelineEntry{PostID: id, ActorID: "c", CreatedAt: now.Add(-time.Duration(i+2) * time.Minute)})
+		}
+
+		mockTimelineRepository.On("Exists", "u").Return(true, nil)
+		mockTimelineRepository.On("Range", "u", &after, timelinePageEntries).Return(entries, nil)
+		mockPostRepository.On("FeedEntries", "u", &entries[0], timelinePageEntries-1).Return(older, nil)
+		mockTimelineRepository.On("PopularActors").Return([]string{}, nil)
+		mockPostRepository.On("FeedActivity", "u", mock.Anything).Return([]model.TimelineEntry{}, nil)
+		mockPostRepository.On("FindByIDs", mock.Anything).Return(&[]model.Post{{ID: "2"}, {ID: "A"}, {ID: "B"}}, nil)
+
+		posts, next, err := ts.Timeline("u", formatEntryCursor(after))
+
+		assert.NoError(t, err)
+		assert.Equal(t, "2", (*posts)[0].ID)
+		assert.Equal(t, "A", (*posts)[1].ID)
+		assert.NotEmpty(t, next)
+		mockPostRepository.AssertExpectations(t)
+	})
+
+	t.Run("Invalid cursor", func(t *testing.T) {
+		ts := NewTimelineService(&TlSConfig{})
+
+		_, _, err := ts.Timeline("u", "20")
+
+		assert.Equal(t, http.StatusBadRequest, apperrors.Status(err))
+	})
+}
+
+func TestTimelineService_Publish(t *testing.T) {
+	entry := model.TimelineEntry{PostID: "1", ActorID: "a", CreatedAt: time.Now()}
+
+	t.Run("Fans out to followers", func(t *testing.T) {
+		mockTimelineRepository := new(mocks.TimelineRepository)
+		mockUserRepository := new(mocks.UserRepository)
+
+		ts := NewTimelineService(&TlSConfig{
+			TimelineRepository: mockTimelineRepository,
+			UserRepository:     mockUserRepository,
+			FanoutLimit:        100,
+		})
+
+		mockTimelineRepository.On("PopularActors").Return([]string{}, nil)
+		mockUserRepository.On("FollowerIDs", "a").Return([]string{"b", "c"}, nil)
+		mockTimelineRepository.On("Add", []string{"a", "b", "c"}, []model.TimelineEntry{entry}).Return(nil)
+
+		err := ts.Publish(entry)
+
+		assert.NoError(t, err)
+		mockTimelineRepository.AssertExpectations(t)
+	})
+
+	t.Run("Popular accounts only reach their own timeline", func(t *testing.T) {
+		mockTimelineRepository := new(mocks.TimelineRepository)
+		mockUserRepository := new(mocks.UserRepository)
+
+		ts := NewTimelineService(&TlSConfig{
+			TimelineRepository: mockTimelineRepository,
+			UserRepository:     mockUserRepository,
+			FanoutLimit:        100,
+		})
+
+		mockTimelineRepository.On("PopularActors").Return([]string{"a"}, nil)
+		mockTimelineRepository.On("Add", []string{"a"}, []model.TimelineEntry{entry}).Return(nil)
+
+		err := ts.Publish(entry)
+
+		assert.NoError(t, err)
+		mockUserRepository.AssertNotCalled(t, "FollowerIDs", "a")
+		mockTimelineRepository.AssertExpectations(t)
+	})
+
+	t.Run("Queued with a job service", func(t *testing.T) {
+		mockJobService := new(mocks.JobService)
+		mockJobService.On("Handle", mock.AnythingOfType("string"), mock.AnythingOfType("model.JobHandler"))
+		mockJobService.On("Enqueue", model.JobTimelineFanout, "", &entry).Return(&model.Job{ID: "10"}, nil)
+
+		ts := NewTimelineService(&TlSConfig{
+			TimelineRepository: new(mocks.TimelineRepository),
+			JobService:         mockJobService,
+		})
+
+		err := ts.Publish(entry)
+
+		assert.NoError(t, err)
+		mockJobService.AssertExpectations(t)
+	})
+}
+
+func TestTimelineService_Follow(t *testing.T) {
+	t.Run("Adds the followee's posts", func(t *testing.T) {
+		mockTimelineRepository := new(mocks.TimelineRepository)
+		mockPostRepository := new(mocks.PostRepository)
+		mockUserRepository := new(mocks.UserRepository)
+
+		ts := NewTimelineService(&TlSConfig{
+			TimelineRepository: mockTimelineRepository,
+			PostRepository:     mockPostRepository,
+			UserRepository:     mockUserRepository,
+		})
+
+		entries := []model.TimelineEntry{{PostID: "1", ActorID: "b", CreatedAt: time.Now()}}
+
+		mockUserRepository.On("FollowerCount", "b").Return(int64(1), nil)
+		mockTimelineRepository.On("SetPopular", "b", false).Return(false, nil)
+		mockPostRepository.On("ActivityEntries", []string{"b"}, (*model.TimelineEntry)(nil), timelineRebuildSize).Return(entries, nil)
+		mockTimelineRepository.On("Add", []string{"u"}, entries).Return(nil)
+
+		err := ts.Follow("u", "b")
+
+		assert.NoError(t, err)
+		mockTimelineRepository.AssertExpectations(t)
+	})
+
+	t.Run("Popular followee", func(t *testing.T) {
+		mockTimelineRepository := new(mocks.TimelineRepository)
+		mockUserRepository := new(mocks.UserRepository)
+
+		ts := NewTimelineService(&TlSConfig{
+			TimelineRepository: mockTimelineRepository,
+			UserRepository:     mockUserRepository,
+			FanoutLimit:        100,
+		})
+
+		mockUserRepository.On("FollowerCount", "b").Return(int64(100), nil)
+		mockTimelineRepository.On("SetPopular", "b", true).Return(true, nil)
+
+		err := ts.Follow("u", "b")
+
+		assert.NoError(t, err)
+		mockTimelineRepository.AssertExpectations(t)
+		mockTimelineRepository.AssertNotCalled(t, "Add", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Unfollow removes the followee's entries", func(t *testing.T) {
+		mockTimelineRepository := new(mocks.TimelineRepository)
+		mockUserRepository := new(mocks.UserRepository)
+
+		ts := NewTimelineService(&TlSConfig{
+			TimelineRepository: mockTimelineRepository,
+			UserRepository:     mockUserRepository,
+		})
+
+		mockTimelineRepository.On("RemoveActor", "u", "b").Return(nil)
+		mockUserRepository.On("FollowerCount", "b").Return(int64(1), nil)
+		mockTimelineRepository.On("SetPopular", "b", false).Return(false, nil)
+
+		err := ts.Unfollow("u", "b")
+
+		assert.NoError(t, err)
+		mockTimelineRepository.AssertExpectations(t)
+	})
+
+	t.Run("Followee that is no longer popular", func(t *testing.T) {
+		mockTimelineRepository := new(mocks.TimelineRepository)
+		mockPostRepository := new(mocks.PostRepository)
+		mockUserRepository := new(mocks.UserRepository)
+
+		ts := NewTimelineService(&TlSConfig{
+			TimelineRepository: mockTimelineRepository,
+			PostRepository:     mockPostRepository,
+			UserRepository:     mockUserRepository,
+			FanoutLimit:        100,
+		})
+
+		entries := []model.TimelineEntry{{PostID: "1", ActorID: "b", CreatedAt: time.Now()}}
+
+		mockTimelineRepository.On("RemoveActor", "u", "b").Return(nil)
+		mockUserRepository.On("FollowerCount", "b").Return(int64(99), nil)
+		mockTimelineRepository.On("SetPopular", "b", false).Return(true, nil)
+		// the remaining followers no longer read the posts, so they get written
+		mockUserRepository.On("FollowerIDs", "b").Return([]string{"c", "d"}, nil)
+		mockPostRepository.On("ActivityEntries", []string{"b"}, (*model.TimelineEntry)(nil), timelineRebuildSize).Return(entries, nil)
+		mockTimelineRepository.On("Add", []string{"c", "d"}, entries).Return(nil)
+
+		err := ts.Unfollow("u", "b")
+
+		assert.NoError(t, err)
+		mockTimelineRepository.AssertExpectations(t)
+	})
+}
+
+func TestTimelineService_PopularActors(t *testing.T) {
+	t.Run("Builds the missing set", func(t *testing.T) {
+		mockTimelineRepository := new(mocks.TimelineRepository)
+		mockUserRepository := new(mocks.UserRepository)
+
+		ts := NewTimelineService(&TlSConfig{
+			TimelineRepository: mockTimelineRepository,
+			UserRepository:     mockUserRepository,
+			FanoutLimit:        100,
+		})
+
+		entry := model.TimelineEntry{PostID: "1", ActorID: "a", CreatedAt: time.Now()}
+
+		mockTimelineRepository.On("PopularActors").Return(nil, apperrors.NewNotFound("popular actors", "timelines:popular"))
+		mockUserRepository.On("PopularUsers", 100).Return([]string{"a"}, nil)
+		mockTimelineRepository.On("ReplacePopular", []string{"a"}).Return(nil)
+		mockTimelineRepository.On("Add", []string{"a"}, []model.TimelineEntry{entry}).Return(nil)
+
+		err := ts.Publish(entry)
+
+		assert.NoError(t, err)
+		mockTimelineRepository.AssertExpectations(t)
+	})
+
+	t.Run("RebuildAll rebuilds the existing timelines", func(t *testing.T) {
+		mockTimelineRepository := new(mocks.TimelineRepository)
+		mockPostRepository := new(mocks.PostRepository)
+		mockUserRepository := new(mocks.UserRepository)
+
+		ts := NewTimelineService(&TlSConfig{
+			TimelineRepository: mockTimelineRepository,
+			PostRepository:     mockPostRepository,
+			UserRepository:     mockUserRepository,
+			FanoutLimit:        100,
+		})
+
+		entries := []model.TimelineEntry{{PostID: "1", ActorID: "b", CreatedAt: time.Now()}}
+
+		mockUserRepository.On("RecountFollowers").Return(nil)
+		mockUserRepository.On("PopularUsers", 100).Return([]string{"p"}, nil)
+		mockTimelineRepository.On("ReplacePopular", []string{"p"}).Return(nil)
+		mockUserRepository.On("FindAfter", "", timelineRebuildBatch).Return(&[]model.User{{ID: "u"}, {ID: "v"}}, nil)
+		mockTimelineRepository.On("Exists", "u").Return(true, nil)
+		mockTimelineRepository.On("Exists", "v").Return(false, nil)
+		mockUserRepository.On("FolloweeIDs", "u").Return([]string{"b", "p"}, nil)
+		mockTimelineRepository.On("PopularActors").Return([]string{"p"}, nil)
+		mockPostRepository.On("ActivityEntries", []string{"u", "b"}, (*model.TimelineEntry)(nil), timelineRebuildSize).Return(entries, nil)
+		mockTimelineRepository.On("Replace", "u", entries).Return(nil)
+
+		rebuilt, err := ts.RebuildAll()
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, rebuilt)
+		mockUserRepository.AssertExpectations(t)
+		mockTimelineRepository.AssertExpectations(t)
+		mockTimelineRepository.AssertNotCalled(t, "Replace", "v", mock.Anything)
+	})
+}
+
+func TestPostService_Timeline(t *testing.T) {
+	now := time.Date(2021, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Create publishes the post", func(t *testing.T) {
+		mockPostRepository := new(mocks.PostRepository)
+		mockTimelineService := new(mocks.TimelineService)
+		ps := NewPostService(&PSConfig{
+			PostRepository:  mockPostRepository,
+			TimelineService: mockTimelineService,
+		})
+
+		post := &model.Post{UserID: "a", CreatedAt: now}
+		mockPostRepository.On("Create", post).Return(post, nil)
+		mockTimelineService.On("Publish", mock.AnythingOfType("model.TimelineEntry")).Return(nil)
+
+		created, err := ps.CreatePost(post)
+
+		assert.NoError(t, err)
+		mockTimelineService.AssertCalled(t, "Publish", model.TimelineEntry{PostID: created.ID, ActorID: "a", CreatedAt: now})
+	})
+
+	t.Run("Retweet is published and retracted", func(t *testing.T) {
+		mockPostRepository := new(mocks.PostRepository)
+		mockTimelineService := new(mocks.TimelineService)
+		ps := NewPostService(&PSConfig{
+			PostRepository:  mockPostRepository,
+			TimelineService: mockTimelineService,
+			Clock:           func() time.Time { return now },
+		})
+
+		post := &model.Post{ID: "1", UserID: "a"}
+		entry := model.TimelineEntry{PostID: "1", ActorID: "b", CreatedAt: now}
+
+		mockPostRepository.On("AddRetweet", post, "b").Return(nil)
+		mockTimelineService.On("Publish", entry).Return(nil)
+
+		assert.NoError(t, ps.ToggleRetweet(post, "b"))
+
+		post.Retweets = []model.User{{ID: "b"}}
+		mockPostRepository.On("RemoveRetweet", post, "b").Return(nil)
+		mockTimelineService.On("Retract", entry).Return(nil)
+
+		assert.NoError(t, ps.ToggleRetweet(post, "b"))
+		mockTimelineService.AssertExpectations(t)
+	})
+
+	t.Run("Latest feed reads the timeline", func(t *testing.T) {
+		mockTimelineService := new(mocks.TimelineService)
+		ps := NewPostService(&PSConfig{
+			PostRepository:  new(mocks.PostRepository),
+			TimelineService: mockTimelineService,
+		})
+
+		posts := []model.Post{{ID: "1"}}
+		mockTimelineService.On("Timeline", "u", "").Return(&posts, "", nil)
+
+		feed, _, err := ps.GetUserFeed("u", model.FeedLatest, "", model.TimeRange{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, &posts, feed)
+	})
+}
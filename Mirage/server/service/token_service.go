@@ -0,0 +1,107 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"log"
+	"strings"
+	"time"
+)
+
+// tokenPrefix makes access tokens easy to recognize,
+// e.g. when they are accidentally committed
+const tokenPrefix = "mrg_"
+
+type tokenService struct {
+	TokenRepository model.TokenRepository
+}
+
+// TSConfig will hold repositories that will eventually be injected into this
+// this service layer
+type TSConfig struct {
+	TokenRepository model.TokenRepository
+}
+
+// NewTokenService is a factory function for
+// initializing a TokenService with its repository layer dependencies
+func NewTokenService(c *TSConfig) model.TokenService {
+	return &tokenService{
+		TokenRepository: c.TokenRepository,
+	}
+}
+
+// Create generates a new access token for the given user.
+// It returns the stored token and the raw token, which
+// is shown to the user exactly once.
+func (s *tokenService) Create(uid, name string, scopes []string) (*model.AccessToken, string, error) {
+	id, err := GenerateId()
+
+	if err != nil {
+		log.Printf("Unable to create token for user: %v\n", uid)
+		return nil, "", apperrors.NewInternal()
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		log.Printf("Unable to create token for user: %v\n", uid)
+		return nil, "", apperrors.NewInternal()
+	}
+
+	raw := tokenPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	token := &model.AccessToken{
+		ID:     id,
+		UserID: uid,
+		Name:   name,
+		Hash:   hashToken(raw),
+		Scopes: scopes,
+	}
+
+	if err := s.TokenRepository.Create(token); err != nil {
+		return nil, "", err
+	}
+
+	return token, raw, nil
+}
+
+// Authenticate returns the token matching the raw token
+func (s *tokenService) Authenticate(raw string) (*model.AccessToken, error) {
+	if !strings.HasPrefix(raw, tokenPrefix) {
+		return nil, apperrors.NewAuthorization("provided token is invalid")
+	}
+
+	token, err := s.TokenRepository.FindByHash(hashToken(raw))
+
+	if err != nil {
+		return nil, apperrors.NewAuthorization("provided token is invalid")
+	}
+
+	if token.LastUsedAt == nil || time.Since(*token.LastUsedAt) > lastSeenInterval {
+		now := time.Now()
+		token.LastUsedAt = &now
+		if err := s.TokenRepository.UpdateLastUsed(token.ID, now); err != nil {
+			log.Printf("Unable to update token: %v\n", token.ID)
+		}
+	}
+
+	return token, nil
+}
+
+func (s *tokenService) List(uid string) (*[]model.AccessToken, error) {
+	return s.TokenRepository.FindAllForUser(uid)
+}
+
+func (s *tokenService) Revoke(uid, id string) error {
+	return s.TokenRepository.Delete(uid, id)
+}
+
+// hashToken returns the hex encoded SHA-256 hash of the token.
+// Tokens are random, so they don't need a slow password hash.
+func hashToken(raw string) string {
+	hash := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"github.com/sentrionic/mirage/mocks"
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"github.com/sentrionic/mirage/model/fixture"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTokenService_Create(t *testing.T) {
+	t.Run("Stores only the hash", func(t *testing.T) {
+		uid := fixture.RandID()
+		scopes := []string{model.ScopeRead}
+
+		mockTokenRepository := new(mocks.TokenRepository)
+		ts := NewTokenService(&TSConfig{
+			TokenRepository: mockTokenRepository,
+		})
+
+		mockTokenRepository.On("Create", mock.AnythingOfType("*model.AccessToken")).Return(nil)
+
+		token, raw, err := ts.Create(uid, "script", scopes)
+
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(raw, tokenPrefix))
+		assert.Equal(t, hashToken(raw), token.Hash)
+		assert.NotContains(t, token.Hash, raw)
+		assert.Equal(t, uid, token.UserID)
+		assert.Equal(t, "script", token.Name)
+		assert.Equal(t, scopes, []string(token.Scopes))
+		mockTokenRepository.AssertExpectations(t)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		mockTokenRepository := new(mocks.TokenRepository)
+		ts := NewTokenService(&TSConfig{
+			TokenRepository: mockTokenRepository,
+		})
+
+		mockTokenRepository.On("Create", mock.AnythingOfType("*model.AccessToken")).Return(apperrors.NewInternal())
+
+		token, raw, err := ts.Create(fixture.RandID(), "script", []string{model.ScopeRead})
+
+		assert.Error(t, err)
+		assert.Nil(t, token)
+		assert.Empty(t, raw)
+	})
+}
+
+func TestTokenService_Authenticate(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		raw := tokenPrefix + fixture.RandStringRunes(43)
+		token := &model.AccessToken{ID: fixture.RandID(), UserID: fixture.RandID(), Hash: hashToken(raw)}
+
+		mockTokenRepository := new(mocks.TokenRepository)
+		ts := NewTokenService(&TSConfig{
+			TokenRepository: mockTokenRepository,
+		})
+
+		mockTokenRepository.On("FindByHash", hashToken(raw)).Return(token, nil)
+		mockTokenRepository.On("UpdateLastUsed", token.ID, mock.AnythingOfType("time.Time")).Return(nil)
+
+		result, err := ts.Authenticate(raw)
+
+		assert.NoError(t, err)
+		assert.Equal(t, token, result)
+		assert.NotNil(t, result.LastUsedAt)
+		mockTokenRepository.AssertExpectations(t)
+	})
+
+	t.Run("Recently used tokens are not updated", func(t *testing.T) {
+		raw := tokenPrefix + fixture.RandStringRunes(43)
+		now := time.Now()
+		token := &model.AccessToken{ID: fixture.RandID(), Hash: hashToken(raw), LastUsedAt: &now}
+
+		mockTokenRepository := new(mocks.TokenRepository)
+		ts := NewTokenService(&TSConfig{
+			TokenRepository: mockTokenRepository,
+		})
+
+		mockTokenRepository.On("FindByHash", hashToken(raw)).Return(token, nil)
+
+		_, err := ts.Authenticate(raw)
+
+		assert.NoError(t, err)
+		mockTokenRepository.AssertNotCalled(t, "UpdateLastUsed", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Unknown token", func(t *testing.T) {
+		raw := tokenPrefix + fixture.RandStringRunes(43)
+
+		mockTokenRepository := new(mocks.TokenRepository)
+		ts := NewTokenService(&TSConfig{
+			TokenRepository: mockTokenRepository,
+		})
+
+		mockTokenRepository.On("FindByHash", hashToken(raw)).Return(nil, apperrors.NewNotFound("token", "hash"))
+
+		token, err := ts.Authenticate(raw)
+
+		assert.Nil(t, token)
+		assert.Equal(t, apperrors.Authorization, err.(*apperrors.Error).Type)
+	})
+
+	t.Run("Malformed token", func(t *testing.T) {
+		mockTokenRepository := new(mocks.TokenRepository)
+		ts := NewTokenService(&TSConfig{
+			TokenRepository: mockTokenRepository,
+		})
+
+		token, err := ts.Authenticate(fixture.RandStringRunes(40))
+
+		assert.Nil(t, token)
+		assert.Error(t, err)
+		mockTokenRepository.AssertNotCalled(t, "FindByHash", mock.Anything)
+	})
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the base32 encoded secret "12345678901234567890" used
+// for the SHA1 test vectors of RFC 6238
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTotpCode(t *testing.T) {
+	vectors := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+
+	for unix, expected := range vectors {
+		code, err := totpCode(rfcSecret, totpStep(time.Unix(unix, 0)))
+		require.NoError(t, err)
+		assert.Equal(t, expected, code)
+	}
+
+	_, err := totpCode("not base32!", 1)
+	assert.Error(t, err)
+}
+
+func TestValidateTOTP(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+
+	t.Run("Accepts the current code", func(t *testing.T) {
+		step, ok := validateTOTP(rfcSecret, "005924", now, 0)
+		assert.True(t, ok)
+		assert.Equal(t, totpStep(now), step)
+	})
+
+	t.Run("Accepts codes from adjacent periods", func(t *testing.T) {
+		previous, _ := totpCode(rfcSecret, totpStep(now)-1)
+		next, _ := totpCode(rfcSecret, totpStep(now)+1)
+
+		_, ok := validateTOTP(rfcSecret, previous, now, 0)
+		assert.True(t, ok)
+
+		_, ok = validateTOTP(rfcSecret, next, now, 0)
+		assert.True(t, ok)
+	})
+
+	t.Run("Rejects codes outside of the window", func(t *testing.T) {
+		old, _ := totpCode(rfcSecret, totpStep(now)-2)
+		_, ok := validateTOTP(rfcSecret, old, now, 0)
+		assert.False(t, ok)
+	})
+
+	t.Run("Rejects a code that was already used", func(t *testing.T) {
+		_, ok := validateTOTP(rfcSecret, "005924", now, totpStep(now))
+		assert.False(t, ok)
+	})
+
+	t.Run("Rejects malformed codes", func(t *testing.T) {
+		_, ok := validateTOTP(rfcSecret, "5924", now, 0)
+		assert.False(t, ok)
+	})
+}
+
+func TestTotpURI(t *testing.T) {
+	uri := totpURI(rfcSecret, "bob@example.com")
+
+	parsed, err := url.Parse(uri)
+	require.NoError(t, err)
+
+	assert.Equal(t, "otpauth", parsed.Scheme)
+	assert.Equal(t, "totp", parsed.Host)
+	assert.True(t, strings.HasSuffix(parsed.Path, "Mirage:bob@example.com"))
+	assert.Equal(t, rfcSecret, parsed.Query().Get("secret"))
+	assert.Equal(t, "Mirage", parsed.Query().Get("issuer"))
+}
+
+func TestGenerateRecoveryCodes(t *testing.T) {
+	codes, hashes, err := generateRecoveryCodes()
+	require.NoError(t, err)
+
+	assert.Len(t, codes, recoveryCodeCount)
+	assert.Len(t, hashes, recoveryCodeCount)
+
+	for i, code := range codes {
+		assert.Len(t, code, 11)
+		assert.Equal(t, hashes[i], hashRecoveryCode(code))
+		assert.Equal(t, hashes[i], hashRecoveryCode(strings.ToUpper(strings.Replace(code, "-", " ", 1))))
+	}
+}
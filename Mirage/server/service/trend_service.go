@@ -0,0 +1,197 @@
+package service
+
+import (
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"log"
+	"math"
+	"sort"
+	"time"
+)
+
+// trendResolution is the bucket size of a trend window
+type trendResolution struct {
+	Size time.Duration
+	// TTL keeps the buckets around for the longest window
+	// using them and the baseline window before it
+	TTL time.Duration
+}
+
+var (
+	fineTrendResolution   = trendResolution{Size: 5 * time.Minute, TTL: 2 * time.Hour}
+	coarseTrendResolution = trendResolution{Size: time.Hour, TTL: 15 * 24 * time.Hour}
+)
+
+const (
+	// trendHistory is how far back a rebuild reads posts
+	trendHistory = 14 * 24 * time.Hour
+	// trendSmoothing keeps tags without a baseline from
+	// outranking everything after a handful of uses
+	trendSmoothing = 5
+	// trendMinUses is how often a tag has to be used in the window to trend
+	trendMinUses = 3
+	// trendCandidates is how many tags per requested trend get scored
+	trendCandidates = 5
+)
+
+type trendService struct {
+	TrendRepository model.TrendRepository
+	PostRepository  model.PostRepository
+	Clock           func() time.Time
+}
+
+// TrSConfig will hold repositories that will eventually be injected into this
+// this service layer
+type TrSConfig struct {
+	TrendRepository model.TrendRepository
+	PostRepository  model.PostRepository
+	// Clock returns the current time. Defaults to time.Now
+	Clock func() time.Time
+}
+
+// NewTrendService is a factory function for
+// initializing a TrendService with its repository layer dependencies
+func NewTrendService(c *TrSConfig) model.TrendService {
+	clock := c.Clock
+	if clock == nil {
+		clock = time.Now
+	}
+
+	return &trendService{
+		TrendRepository: c.TrendRepository,
+		PostRepository:  c.PostRepository,
+		Clock:           clock,
+	}
+}
+
+// Record counts the tags of a post created at the given time
+func (s *trendService) Record(tags []string, at time.Time) error {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	now := s.Clock()
+
+	for _, res := range []trendResolution{fineTrendResolution, coarseTrendResolution} {
+		bucket := at.Truncate(res.Size)
+		ttl := bucket.Add(res.Size + res.TTL).Sub(now)
+
+		// the bucket would already be expired
+		if ttl <= 0 {
+			continue
+		}
+
+		if err := s.TrendRepository.Increment(res.Size, bucket, tags, ttl); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Remove takes back the tags of a deleted post created at the given time
+func (s *trendService) Remove(tags []string, at time.Time) error {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	now := s.Clock()
+
+	for _, res := range []trendResolution{fineTrendResolution, coarseTrendResolution} {
+		bucket := at.Truncate(res.Size)
+
+		if !bucket.Add(res.Size + res.TTL).After(now) {
+			continue
+		}
+
+		if err := s.TrendRepository.Decrement(res.Size, bucket, tags); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetTrends returns the hashtags whose use grew the most in the window
+// compared to the window before it. A steady tag that is used a lot does
+// not trend, while a tag that jumps from a few uses to many does.
+func (s *trendService) GetTrends(window time.Duration, limit int) (*[]model.Trend, error) {
+	res := coarseTrendResolution
+	if window <= time.Hour {
+		res = fineTrendResolution
+	}
+
+	current := s.Clock().Truncate(res.Size)
+	count := int(window / res.Size)
+
+	buckets := make([]time.Time, count)
+	baseline := make([]time.Time, count)
+
+	for i := 0; i < count; i++ {
+		buckets[i] = current.Add(-time.Duration(i) * res.Size)
+		baseline[i] = current.Add(-time.Duration(i+count) * res.Size)
+	}
+
+	counts, err := s.TrendRepository.Counts(res.Size, buckets, baseline, limit*trendCandidates)
+
+	if err != nil {
+		return nil, err
+	}
+
+	trends := make([]model.Trend, 0, len(counts))
+
+	for _, c := range counts {
+		if c.Current < trendMinUses || c.Current <= c.Baseline {
+			continue
+		}
+
+		trends = append(trends, model.Trend{
+			Hashtag:  c.Hashtag,
+			Score:    trendVelocity(c.Current, c.Baseline),
+			Count:    c.Current,
+			Previous: c.Baseline,
+		})
+	}
+
+	sort.SliceStable(trends, func(i, j int) bool {
+		if trends[i].Score != trends[j].Score {
+			return trends[i].Score > trends[j].Score
+		}
+		return trends[i].Count > trends[j].Count
+	})
+
+	if len(trends) > limit {
+		trends = trends[:limit]
+	}
+
+	return &trends, nil
+}
+
+// trendVelocity is the growth from the baseline in standard deviations
+// of the baseline count, so a jump from 2 to 40 uses outranks one from
+// 1000 to 1100.
+func trendVelocity(current, baseline int64) float64 {
+	return float64(current-baseline) / math.Sqrt(float64(baseline)+trendSmoothing)
+}
+
+// Rebuild recreates all trend buckets from the posts in the database
+func (s *trendService) Rebuild() error {
+	if err := s.TrendRepository.Clear(); err != nil {
+		return err
+	}
+
+	posts, err := s.PostRepository.HashtagsSince(s.Clock().Add(-trendHistory))
+
+	if err != nil {
+		log.Printf("Unable to get posts to rebuild trends: %v\n", err)
+		return apperrors.NewInternal()
+	}
+
+	for _, post := range *posts {
+		if err := s.Record(post.HashTags, post.CreatedAt); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
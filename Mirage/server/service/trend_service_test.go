@@ -0,0 +1,248 @@
+package service
+
+import (
+	"github.com/sentrionic/mirage/mocks"
+	"github.com/sentrionic/mirage/model"
+	"github.com/sentrionic/mirage/model/apperrors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+	"time"
+)
+
+func TestTrendService_Record(t *testing.T) {
+	now := time.Date(2021, 6, 1, 12, 7, 0, 0, time.UTC)
+	tags := []string{"#go", "#mirage"}
+
+	t.Run("Counts tags in both resolutions", func(t *testing.T) {
+		mockTrendRepository := new(mocks.TrendRepository)
+		ts := NewTrendService(&TrSConfig{
+			TrendRepository: mockTrendRepository,
+			Clock:           func() time.Time { return now },
+		})
+
+		mockTrendRepository.
+			On("Increment", 5*time.Minute, time.Date(2021, 6, 1, 12, 5, 0, 0, time.UTC), tags, mock.AnythingOfType("time.Duration")).
+			Return(nil)
+		mockTrendRepository.
+			On("Increment", time.Hour, time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC), tags, mock.AnythingOfType("time.Duration")).
+			Return(nil)
+
+		err := ts.Record(tags, now)
+
+		assert.NoError(t, err)
+		mockTrendRepository.AssertExpectations(t)
+	})
+
+	t.Run("Skips expired buckets", func(t *testing.T) {
+		mockTrendRepository := new(mocks.TrendRepository)
+		ts := NewTrendService(&TrSConfig{
+			TrendRepository: mockTrendRepository,
+			Clock:           func() time.Time { return now },
+		})
+
+		at := now.Add(-3 * time.Hour)
+		mockTrendRepository.
+			On("Increment", time.Hour, at.Truncate(time.Hour), tags, mock.AnythingOfType("time.Duration")).
+			Return(nil)
+
+		err := ts.Record(tags, at)
+
+		assert.NoError(t, err)
+		mockTrendRepository.AssertNumberOfCalls(t, "Increment", 1)
+	})
+
+	t.Run("Ignores posts without tags", func(t *testing.T) {
+		mockTrendRepository := new(mocks.TrendRepository)
+		ts := NewTrendService(&TrSConfig{
+			TrendRepository: mockTrendRepository,
+		})
+
+		err := ts.Record([]string{}, now)
+
+		assert.NoError(t, err)
+		mockTrendRepository.AssertNotCalled(t, "Increment", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestTrendService_Remove(t *testing.T) {
+	now := time.Date(2021, 6, 1, 12, 7, 0, 0, time.UTC)
+	tags := []string{"#go", "#mirage"}
+
+	t.Run("Decrements both resolutions", func(t *testing.T) {
+		mockTrendRepository := new(mocks.TrendRepository)
+		ts := NewTrendService(&TrSConfig{
+			TrendRepository: mockTrendRepository,
+			Clock:           func() time.Time { return now },
+		})
+
+		mockTrendRepository.
+			On("Decrement", 5*time.Minute, time.Date(2021, 6, 1, 12, 5, 0, 0, time.UTC), tags).
+			Return(nil)
+		mockTrendRepository.
+			On("Decrement", time.Hour, time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC), tags).
+			Return(nil)
+
+		err := ts.Remove(tags, now)
+
+		assert.NoError(t, err)
+		mockTrendRepository.AssertExpectations(t)
+	})
+
+	t.Run("Skips expired buckets", func(t *testing.T) {
+		mockTrendRepository := new(mocks.TrendRepository)
+		ts := NewTrendService(&TrSConfig{
+			TrendRepository: mockTrendRepository,
+			Clock:           func() time.Time { return now },
+		})
+
+		at := now.Add(-20 * 24 * time.Hour)
+
+		err := ts.Remove(tags, at)
+
+		assert.NoError(t, err)
+		mockTrendRepository.AssertNotCalled(t, "Decrement", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestTrendService_GetTrends(t *testing.T) {
+	now := time.Date(2021, 6, 1, 12, 30, 0, 0, time.UTC)
+
+	t.Run("Hour window compares against the hour before", func(t *testing.T) {
+		mockTrendRepository := new(mocks.TrendRepository)
+		ts := NewTrendService(&TrSConfig{
+			TrendRepository: mockTrendRepository,
+			Clock:           func() time.Time { return now },
+		})
+
+		var current, baseline []time.Time
+
+		mockTrendRepository.
+			On("Counts", 5*time.Minute, mock.Anything, mock.Anything, 50).
+			Run(func(args mock.Arguments) {
+				current = args.Get(1).([]time.Time)
+				baseline = args.Get(2).([]time.Time)
+			}).
+			Return([]model.TrendCount{}, nil)
+
+		_, err := ts.GetTrends(time.Hour, 10)
+
+		assert.NoError(t, err)
+		assert.Len(t, current, 12)
+		assert.Len(t, baseline, 12)
+		assert.Equal(t, now, current[0])
+		assert.Equal(t, now.Add(-55*time.Minute), current[11])
+		assert.Equal(t, now.Add(-time.Hour), baseline[0])
+		assert.Equal(t, now.Add(-115*time.Minute), baseline[11])
+	})
+
+	t.Run("Week window uses hourly buckets", func(t *testing.T) {
+		mockTrendRepository := new(mocks.TrendRepository)
+		ts := NewTrendService(&TrSConfig{
+			TrendRepository: mockTrendRepository,
+			Clock:           func() time.Time { return now },
+		})
+
+		week := mock.MatchedBy(func(b []time.Time) bool { return len(b) == 168 })
+		mockTrendRepository.
+			On("Counts", time.Hour, week, week, 100).
+			Return([]model.TrendCount{}, nil)
+
+		_, err := ts.GetTrends(7*24*time.Hour, 20)
+
+		assert.NoError(t, err)
+		mockTrendRepository.AssertExpectations(t)
+	})
+
+	t.Run("Ranks by growth instead of volume", func(t *testing.T) {
+		mockTrendRepository := new(mocks.TrendRepository)
+		ts := NewTrendService(&TrSConfig{
+			TrendRepository: mockTrendRepository,
+			Clock:           func() time.Time { return now },
+		})
+
+		mockTrendRepository.
+			On("Counts", 5*time.Minute, mock.Anything, mock.Anything, 10).
+			Return([]model.TrendCount{
+				{Hashtag: "#steady", Current: 1100, Baseline: 1000},
+				{Hashtag: "#new", Current: 40, Baseline: 2},
+				{Hashtag: "#rare", Current: 2, Baseline: 0},
+				{Hashtag: "#falling", Current: 10, Baseline: 30},
+			}, nil)
+
+		trends, err := ts.GetTrends(time.Hour, 2)
+
+		assert.NoError(t, err)
+		assert.Len(t, *trends, 2)
+		assert.Equal(t, "#new", (*trends)[0].Hashtag)
+		assert.Equal(t, int64(40), (*trends)[0].Count)
+		assert.Equal(t, int64(2), (*trends)[0].Previous)
+		assert.Equal(t, "#steady", (*trends)[1].Hashtag)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		mockTrendRepository := new(mocks.TrendRepository)
+		ts := NewTrendService(&TrSConfig{
+			TrendRepository: mockTrendRepository,
+			Clock:           func() time.Time { return now },
+		})
+
+		mockTrendRepository.
+			On("Counts", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return(nil, apperrors.NewInternal())
+
+		trends, err := ts.GetTrends(time.Hour, 10)
+
+		assert.Error(t, err)
+		assert.Nil(t, trends)
+	})
+}
+
+func TestTrendService_Rebuild(t *testing.T) {
+	now := time.Date(2021, 6, 1, 12, 30, 0, 0, time.UTC)
+
+	t.Run("Success", func(t *testing.T) {
+		mockTrendRepository := new(mocks.TrendRepository)
+		mockPostRepository := new(mocks.PostRepository)
+		ts := NewTrendService(&TrSConfig{
+			TrendRepository: mockTrendRepository,
+			PostRepository:  mockPostRepository,
+			Clock:           func() time.Time { return now },
+		})
+
+		posts := &[]model.Post{
+			{ID: "1", HashTags: []string{"#go"}, CreatedAt: now.Add(-2 * 24 * time.Hour)},
+			{ID: "2", HashTags: []string{"#go", "#mirage"}, CreatedAt: now.Add(-time.Minute)},
+		}
+
+		mockTrendRepository.On("Clear").Return(nil)
+		mockPostRepository.On("HashtagsSince", now.Add(-14*24*time.Hour)).Return(posts, nil)
+		mockTrendRepository.
+			On("Increment", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return(nil)
+
+		err := ts.Rebuild()
+
+		assert.NoError(t, err)
+		mockTrendRepository.AssertNumberOfCalls(t, "Increment", 3)
+		mockPostRepository.AssertExpectations(t)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		mockTrendRepository := new(mocks.TrendRepository)
+		mockPostRepository := new(mocks.PostRepository)
+		ts := NewTrendService(&TrSConfig{
+			TrendRepository: mockTrendRepository,
+			PostRepository:  mockPostRepository,
+			Clock:           func() time.Time { return now },
+		})
+
+		mockTrendRepository.On("Clear").Return(nil)
+		mockPostRepository.On("HashtagsSince", mock.Anything).Return(nil, apperrors.NewInternal())
+
+		err := ts.Rebuild()
+
+		assert.Error(t, err)
+		mockTrendRepository.AssertNotCalled(t, "Increment", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
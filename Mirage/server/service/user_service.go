@@ -1,15 +1,58 @@
 package service
 
 import (
+	"encoding/json"
 	"github.com/sentrionic/mirage/model"
 	"github.com/sentrionic/mirage/model/apperrors"
 	"log"
 	"mime/multipart"
+	"path"
+	"strconv"
+	"strings"
+	"time"
 )
 
+// loginFailureWindow is how long failed logins are remembered
+const loginFailureWindow = time.Hour
+
+// loginFailuresBeforeLock is the number of failed logins
+// after which the account gets locked
+const loginFailuresBeforeLock = 5
+
+// loginLockBase is the first lockout. Every further
+// failed login doubles it up to loginLockMax.
+const loginLockBase = time.Minute
+
+const loginLockMax = time.Hour
+
+// avatarWidth and bannerWidth are the widths profile images get resized to
+const (
+	avatarWidth = 400
+	bannerWidth = 1500
+)
+
+// stagedImageExpiration is how long the unprocessed image
+// of a JobProfileImage job is kept
+const stagedImageExpiration = 24 * time.Hour
+
+// typeaheadLimit is the number of profiles suggested for a mention
+const typeaheadLimit = 8
+
+// maxSearchTermLength is the longest profile search term in runes,
+// longer terms can't match a display name anyway
+const maxSearchTermLength = 50
+
+// reindexBatchSize is the number of users ReindexProfiles loads at once
+const reindexBatchSize = 500
+
 type userService struct {
-	UserRepository model.UserRepository
-	FileRepository model.FileRepository
+	UserRepository   model.UserRepository
+	FileRepository   model.FileRepository
+	UploadRepository model.UploadRepository
+	RateLimiter      model.RateLimiter
+	JobService       model.JobService
+	TimelineService  model.TimelineService
+	Clock            func() time.Time
 }
 
 // USConfig will hold repositories that will eventually be injected into this
@@ -17,15 +60,42 @@ type userService struct {
 type USConfig struct {
 	UserRepository model.UserRepository
 	FileRepository model.FileRepository
+	// RateLimiter locks out repeated failed logins. Optional
+	RateLimiter model.RateLimiter
+	// JobService resizes avatars and banners in the background. Optional,
+	// without it they get resized during the request
+	JobService model.JobService
+	// UploadRepository tracks the images waiting to be resized. Required with JobService
+	UploadRepository model.UploadRepository
+	// TimelineService updates the home timeline after following or unfollowing. Optional
+	TimelineService model.TimelineService
+	// Clock returns the current time. Defaults to time.Now
+	Clock func() time.Time
 }
 
 // NewUserService is a factory function for
 // initializing a UserService with its repository layer dependencies
 func NewUserService(c *USConfig) model.UserService {
-	return &userService{
-		UserRepository: c.UserRepository,
-		FileRepository: c.FileRepository,
+	clock := c.Clock
+	if clock == nil {
+		clock = time.Now
 	}
+
+	us := &userService{
+		UserRepository:   c.UserRepository,
+		FileRepository:   c.FileRepository,
+		UploadRepository: c.UploadRepository,
+		RateLimiter:      c.RateLimiter,
+		JobService:       c.JobService,
+		TimelineService:  c.TimelineService,
+		Clock:            clock,
+	}
+
+	if us.JobService != nil {
+		us.JobService.Handle(model.JobProfileImage, us.resizeProfileImage)
+	}
+
+	return us
 }
 
 // Get retrieves a user based on their uuid
@@ -54,15 +124,26 @@ func (s *userService) Register(user *model.User) (*model.User, error) {
 	user.ID = id
 
 	user.Image = GetGravatar(user.Email)
+	setSearchText(user)
 
 	return s.UserRepository.Create(user)
 }
 
-func (s *userService) Login(email, password string) (*model.User, error) {
+// Login checks the user's credentials. Failed logins are counted per
+// email and IP, so that others cannot lock the account out. For users
+// with two-factor authentication the count only resets in LoginTwoFactor.
+func (s *userService) Login(email, password, ip string) (*model.User, error) {
+	key := loginKey(email, ip)
+
+	if err := s.checkLoginLock(key); err != nil {
+		return nil, err
+	}
+
 	user, err := s.UserRepository.FindByEmail(email)
 
 	// Will return NotAuthorized to client to omit details of why
 	if err != nil {
+		s.recordFailedLogin(key)
 		return nil, apperrors.NewAuthorization("Invalid email and password combination")
 	}
 
@@ -74,22 +155,323 @@ func (s *userService) Login(email, password string) (*model.User, error) {
 	}
 
 	if !match {
+		s.recordFailedLogin(key)
 		return nil, apperrors.NewAuthorization("Invalid email and password combination")
 	}
 
+	if passwordNeedsRehash(user.Password) {
+		s.rehashPassword(user, password)
+	}
+
+	if !user.TwoFactorEnabled {
+		s.resetFailedLogins(key)
+	}
+
 	return user, nil
 }
 
+// LoginTwoFactor checks the two-factor code of a login that passed the
+// password check. Wrong codes count towards the same lockout as wrong passwords.
+func (s *userService) LoginTwoFactor(user *model.User, code, ip string) (bool, error) {
+	key := loginKey(user.Email, ip)
+
+	if err := s.checkLoginLock(key); err != nil {
+		return false, err
+	}
+
+	valid, err := s.VerifyTwoFactor(user, code)
+
+	if err != nil {
+		return false, err
+	}
+
+	if !valid {
+		s.recordFailedLogin(key)
+		return false, nil
+	}
+
+	s.resetFailedLogins(key)
+
+	return true, nil
+}
+
+// rehashPassword upgrades the user's password hash to the current
+// format and parameters. Failures are logged since the login itself succeeded.
+func (s *userService) rehashPassword(user *model.User, password string) {
+	pw, err := hashPassword(password)
+
+	if err != nil {
+		log.Printf("Unable to rehash password for user: %v\n", user.ID)
+		return
+	}
+
+	previous := user.Password
+	user.Password = pw
+
+	if err := s.UserRepository.Update(user); err != nil {
+		log.Printf("Unable to store rehashed password for user: %v\n", user.ID)
+		user.Password = previous
+	}
+}
+
+func loginKey(email, ip string) string {
+	return "login:" + ip + ":" + strings.ToLower(strings.TrimSpace(email))
+}
+
+// resetFailedLogins removes the failed logins after a complete login
+func (s *userService) resetFailedLogins(key string) {
+	if s.RateLimiter == nil {
+		return
+	}
+
+	if err := s.RateLimiter.Reset(key); err != nil {
+		log.Printf("Unable to reset failed logins: %v\n", err)
+	}
+}
+
+// checkLoginLock returns a TooManyRequests error
+// while the account is locked out
+func (s *userService) checkLoginLock(key string) error {
+	if s.RateLimiter == nil {
+		return nil
+	}
+
+	locked, err := s.RateLimiter.LockedFor(key)
+
+	if err != nil {
+		return err
+	}
+
+	if locked > 0 {
+		return apperrors.NewTooManyRequests(locked)
+	}
+
+	return nil
+}
+
+// recordFailedLogin counts the failed login and locks the email for the
+// IP once there were too many. The lockout doubles with every further failure.
+func (s *userService) recordFailedLogin(key string) {
+	if s.RateLimiter == nil {
+		return
+	}
+
+	failures, _, err := s.RateLimiter.Hit(key, loginFailureWindow)
+
+	if err != nil {
+		log.Printf("Unable to record failed login: %v\n", err)
+		return
+	}
+
+	if failures < loginFailuresBeforeLock {
+		return
+	}
+
+	lock := loginLockMax
+	if shift := failures - loginFailuresBeforeLock; shift < 6 {
+		lock = loginLockBase << shift
+		if lock > loginLockMax {
+			lock = loginLockMax
+		}
+	}
+
+	if err := s.RateLimiter.Lock(key, lock); err != nil {
+		log.Printf("Unable to lock login: %v\n", err)
+	}
+}
+
 func (s *userService) Update(user *model.User) error {
+	setSearchText(user)
+
 	return s.UserRepository.Update(user)
 }
 
-func (s *userService) ChangeAvatar(header *multipart.FileHeader, directory string) (string, error) {
-	return s.FileRepository.UploadAvatar(header, directory)
+// ChangeAvatar replaces the avatar of the user. With a JobService the image
+// gets resized in the background and the avatar only changes once the job ran.
+func (s *userService) ChangeAvatar(user *model.User, header *multipart.FileHeader, directory string) error {
+	if s.JobService != nil {
+		job, err := s.queueProfileImage(user.ID, model.AvatarImage, header, directory, avatarWidth)
+
+		if err != nil {
+			return err
+		}
+
+		user.ImageJobID = job.ID
+		return nil
+	}
+
+	url, err := s.FileRepository.UploadAvatar(header, directory)
+
+	if err != nil {
+		return err
+	}
+
+	if err := s.setProfileImage(user.ID, model.AvatarImage, user.Image, url); err != nil {
+		return err
+	}
+
+	user.Image = url
+	return nil
 }
 
-func (s *userService) ChangeBanner(header *multipart.FileHeader, directory string) (string, error) {
-	return s.FileRepository.UploadBanner(header, directory)
+// ChangeBanner replaces the banner of the user the same way ChangeAvatar does
+func (s *userService) ChangeBanner(user *model.User, header *multipart.FileHeader, directory string) error {
+	if s.JobService != nil {
+		job, err := s.queueProfileImage(user.ID, model.BannerImage, header, directory, bannerWidth)
+
+		if err != nil {
+			return err
+		}
+
+		user.BannerJobID = job.ID
+		return nil
+	}
+
+	url, err := s.FileRepository.UploadBanner(header, directory)
+
+	if err != nil {
+		return err
+	}
+
+	if err := s.setProfileImage(user.ID, model.BannerImage, bannerOf(user), url); err != nil {
+		return err
+	}
+
+	user.Banner = &url
+	return nil
+}
+
+// setProfileImage saves the new image and deletes the old one
+func (s *userService) setProfileImage(userId string, kind model.ProfileImage, old, url string) error {
+	if err := s.UserRepository.SetProfileImage(userId, kind, url); err != nil {
+		return err
+	}
+
+	if old != "" {
+		if err := s.FileRepository.DeleteImage(old); err != nil {
+			log.Printf("Unable to delete old profile image: %v\n%v", old, err)
+		}
+	}
+
+	return nil
+}
+
+func bannerOf(user *model.User) string {
+	if user.Banner == nil {
+		return ""
+	}
+	return *user.Banner
+}
+
+// profileImageJob is the payload of JobProfileImage
+type profileImageJob struct {
+	UserID    string             `json:"userId"`
+	Kind      model.ProfileImage `json:"kind"`
+	UploadID  string             `json:"uploadId"`
+	Source    string             `json:"source"`
+	Directory string             `json:"directory"`
+	Filename  string             `json:"filename"`
+	Width     int                `json:"width"`
+}
+
+// queueProfileImage stages the unprocessed image and queues its resizing.
+// The staged image is tracked as an upload, so CleanupUploads
+// removes it if the job never succeeds.
+func (s *userService) queueProfileImage(userId string, kind model.ProfileImage, header *multipart.FileHeader, directory string, width int) (*model.Job, error) {
+	id, err := GenerateId()
+	if err != nil {
+		return nil, err
+	}
+
+	src, err := header.Open()
+
+	if err != nil {
+		log.Printf("Unable to open file: %v\n", err)
+		return nil, apperrors.NewInternal()
+	}
+
+	defer src.Close()
+
+	mimetype := header.Header.Get("Content-Type")
+	staged := id + path.Ext(header.Filename)
+
+	upload := &model.Upload{
+		ID:        id,
+		UserID:    userId,
+		Key:       uploadDirectory + "/" + staged,
+		FileType:  mimetype,
+		Size:      header.Size,
+		ExpiresAt: s.Clock().Add(stagedImageExpiration),
+	}
+
+	if err := s.UploadRepository.Create(upload); err != nil {
+		log.Printf("Unable to create upload: %v\n", err)
+		return nil, apperrors.NewInternal()
+	}
+
+	if _, err := s.FileRepository.UploadFile(src, "uploads", staged, mimetype); err != nil {
+		return nil, err
+	}
+
+	job := &profileImageJob{
+		UserID:    userId,
+		Kind:      kind,
+		UploadID:  upload.ID,
+		Source:    upload.Key,
+		Directory: directory,
+		Filename:  id + ".jpeg",
+		Width:     width,
+	}
+
+	return s.JobService.Enqueue(model.JobProfileImage, userId, job)
+}
+
+// resizeProfileImage processes a JobProfileImage job
+func (s *userService) resizeProfileImage(payload json.RawMessage) error {
+	var job profileImageJob
+
+	if err := json.Unmarshal(payload, &job); err != nil {
+		return err
+	}
+
+	src, err := s.FileRepository.OpenFile(job.Source)
+
+	if err != nil {
+		return err
+	}
+
+	defer src.Close()
+
+	url, err := s.FileRepository.UploadResizedImage(src, job.Directory, job.Filename, job.Width)
+
+	if err != nil {
+		return err
+	}
+
+	user, err := s.UserRepository.FindByID(job.UserID)
+
+	if err != nil {
+		return err
+	}
+
+	old := user.Image
+	if job.Kind == model.BannerImage {
+		old = bannerOf(user)
+	}
+
+	if err := s.setProfileImage(user.ID, job.Kind, old, url); err != nil {
+		return err
+	}
+
+	if err := s.FileRepository.DeleteImage(job.Source); err != nil {
+		log.Printf("Unable to delete unprocessed image: %v\n%v", job.Source, err)
+	}
+
+	if err := s.UploadRepository.Delete(&model.Upload{ID: job.UploadID}); err != nil {
+		log.Printf("Unable to delete upload: %v\n%v", job.UploadID, err)
+	}
+
+	return nil
 }
 
 func (s *userService) DeleteImage(key string) error {
@@ -102,12 +484,454 @@ func (s *userService) FindByUsername(username string) (*model.User, error) {
 
 func (s *userService) ChangeFollow(user *model.User, current string) error {
 	if user.IsFollowing(current) {
-		return s.UserRepository.RemoveFollow(user.ID, current)
-	} else {
-		return s.UserRepository.AddFollow(user.ID, current)
+		if err := s.UserRepository.RemoveFollow(user.ID, current); err != nil {
+			return err
+		}
+
+		if s.TimelineService != nil {
+			if err := s.TimelineService.Unfollow(current, user.ID); err != nil {
+				log.Printf("Unable to remove posts of %v from timeline of %v\n%v", user.ID, current, err)
+			}
+		}
+
+		return nil
+	}
+
+	if err := s.UserRepository.AddFollow(user.ID, current); err != nil {
+		return err
+	}
+
+	if s.TimelineService != nil {
+		if err := s.TimelineService.Follow(current, user.ID); err != nil {
+			log.Printf("Unable to add posts of %v to timeline of %v\n%v", user.ID, current, err)
+		}
+	}
+
+	return nil
+}
+
+// Followers returns a page of the users following the user, newest follow first, and the cursor of the next page
+func (s *userService) Followers(userId, cursor string) (*[]model.User, string, error) {
+	var before *model.Follow
+
+	if cursor != "" {
+		t, id, err := parseFollowCursor(cursor)
+
+		if err != nil {
+			return nil, "", err
+		}
+
+		before = &model.Follow{FollowerID: id, FolloweeID: userId, CreatedAt: t}
+	}
+
+	edges, err := s.UserRepository.FollowerEdges(userId, before, model.LIMIT+1)
+
+	if err != nil {
+		log.Printf("Unable to get followers of user: %v\n%v", userId, err)
+		return nil, "", apperrors.NewInternal()
 	}
+
+	return s.followPage(edges, func(f model.Follow) string { return f.FollowerID })
 }
 
-func (s *userService) Search(term string) (*[]model.User, error) {
-	return s.UserRepository.SearchProfiles(term)
+// Following returns a page of the users the user follows, newest follow first, and the cursor of the next page
+func (s *userService) Following(userId, cursor string) (*[]model.User, string, error) {
+	var before *model.Follow
+
+	if cursor != "" {
+		t, id, err := parseFollowCursor(cursor)
+
+		if err != nil {
+			return nil, "", err
+		}
+
+		before = &model.Follow{FollowerID: userId, FolloweeID: id, CreatedAt: t}
+	}
+
+	edges, err := s.UserRepository.FollowingEdges(userId, before, model.LIMIT+1)
+
+	if err != nil {
+		log.Printf("Unable to get followees of user: %v\n%v", userId, err)
+		return nil, "", apperrors.NewInternal()
+	}
+
+	return s.followPage(edges, func(f model.Follow) string { return f.FolloweeID })
+}
+
+// MutualFollowers returns a page of the users following the user that the viewer follows,
+// newest follow of the user first, and the cursor of the next page
+func (s *userService) MutualFollowers(userId, viewerId, cursor string) (*[]model.User, string, error) {
+	var before *model.Follow
+
+	if cursor != "" {
+		t, id, err := parseFollowCursor(cursor)
+
+		if err != nil {
+			return nil, "", err
+		}
+
+		before = &model.Follow{FollowerID: id, FolloweeID: userId, CreatedAt: t}
+	}
+
+	edges, err := s.UserRepository.MutualFollowerEdges(userId, viewerId, before, model.LIMIT+1)
+
+	if err != nil {
+		log.Printf("Unable to get mutual followers of user: %v\n%v", userId, err)
+		return nil, "", apperrors.NewInternal()
+	}
+
+	return s.followPage(edges, func(f model.Follow) string { return f.FollowerID })
+}
+
+// followPage loads the listed user of every edge in the order of the edges.
+// It returns up to LIMIT+1 users and the cursor after the LIMIT-th one.
+func (s *userService) followPage(edges []model.Follow, listed func(model.Follow) string) (*[]model.User, string, error) {
+	ids := make([]string, len(edges))
+	for i, edge := range edges {
+		ids[i] = listed(edge)
+	}
+
+	found, err := s.UserRepository.FindByIDs(ids)
+
+	if err != nil {
+		log.Printf("Unable to load users: %v\n%v", ids, err)
+		return nil, "", apperrors.NewInternal()
+	}
+
+	byId := make(map[string]model.User, len(*found))
+	for _, user := range *found {
+		byId[user.ID] = user
+	}
+
+	users := make([]model.User, 0, len(edges))
+	for _, id := range ids {
+		if user, ok := byId[id]; ok {
+			users = append(users, user)
+		}
+	}
+
+	next := ""
+	if len(edges) > model.LIMIT {
+		last := edges[model.LIMIT-1]
+		next = formatFollowCursor(last.CreatedAt, listed(last))
+	}
+
+	return &users, next, nil
+}
+
+// formatFollowCursor returns the cursor after the follow of the listed user at the given time.
+// The ID keeps follows with the same time apart.
+func formatFollowCursor(t time.Time, id string) string {
+	return t.UTC().Format(time.RFC3339Nano) + "_" + id
+}
+
+// parseFollowCursor returns the time and the listed user of a follow cursor
+func parseFollowCursor(cursor string) (time.Time, string, error) {
+	i := strings.LastIndex(cursor, "_")
+
+	if i < 0 {
+		return time.Time{}, "", apperrors.NewBadRequest("invalid cursor")
+	}
+
+	t, err := parseTimelineCursor(cursor[:i])
+
+	if err != nil {
+		return time.Time{}, "", err
+	}
+
+	return t, cursor[i+1:], nil
+}
+
+// Search returns a page of the users matching the term, best match first, and the cursor
+// of the next page. The cursor holds the rank of the last user on the page. A follow
+// between two pages can move a user across it, so the user is skipped or shown twice,
+// but it doesn't shift every later page like an offset does.
+func (s *userService) Search(term, cursor string) (*[]model.User, string, error) {
+	after, err := parseProfileRank(cursor)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	term = searchTerm(term)
+
+	if term == "" {
+		return &[]model.User{}, "", nil
+	}
+
+	users, ranks, err := s.UserRepository.SearchProfiles(term, after, model.LIMIT+1)
+
+	if err != nil {
+		log.Printf("Unable to search profiles for term: %v\n%v", term, err)
+		return nil, "", apperrors.NewInternal()
+	}
+
+	next := ""
+	if len(ranks) > model.LIMIT {
+		next = formatProfileRank(ranks[model.LIMIT-1])
+	}
+
+	return users, next, nil
+}
+
+// parseProfileRank parses the "match_followers_id" cursor of a profile search.
+// An empty cursor is the first page.
+func parseProfileRank(cursor string) (*model.ProfileRank, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+
+	parts := strings.SplitN(cursor, "_", 3)
+
+	if len(parts) != 3 || parts[2] == "" {
+		return nil, apperrors.NewBadRequest("invalid cursor")
+	}
+
+	match, err := strconv.Atoi(parts[0])
+
+	if err != nil || match < 0 {
+		return nil, apperrors.NewBadRequest("invalid cursor")
+	}
+
+	followers, err := strconv.ParseInt(parts[1], 10, 64)
+
+	if err != nil || followers < 0 {
+		return nil, apperrors.NewBadRequest("invalid cursor")
+	}
+
+	return &model.ProfileRank{Match: match, Followers: followers, ID: parts[2]}, nil
+}
+
+func formatProfileRank(rank model.ProfileRank) string {
+	return strconv.Itoa(rank.Match) + "_" + strconv.FormatInt(rank.Followers, 10) + "_" + rank.ID
+}
+
+// Typeahead returns the few users whose username or display name starts with
+// the term, for autocompleting a mention. A leading @ gets ignored.
+func (s *userService) Typeahead(term string) (*[]model.User, error) {
+	term = searchTerm(strings.TrimPrefix(strings.TrimSpace(term), "@"))
+
+	if term == "" {
+		return &[]model.User{}, nil
+	}
+
+	users, err := s.UserRepository.TypeaheadProfiles(term, typeaheadLimit)
+
+	if err != nil {
+		log.Printf("Unable to find typeahead profiles for term: %v\n%v", term, err)
+		return nil, apperrors.NewInternal()
+	}
+
+	return users, nil
+}
+
+// ReindexProfiles sets the normalized display name and bio of every user
+// and returns the number of users it updated
+func (s *userService) ReindexProfiles() (int, error) {
+	updated := 0
+	after := ""
+
+	for {
+		users, err := s.UserRepository.FindAfter(after, reindexBatchSize)
+
+		if err != nil {
+			return updated, err
+		}
+
+		for i := range *users {
+			user := &(*users)[i]
+			setSearchText(user)
+
+			if err := s.UserRepository.UpdateSearchText(user); err != nil {
+				return updated, err
+			}
+
+			updated++
+			after = user.ID
+		}
+
+		if len(*users) < reindexBatchSize {
+			return updated, nil
+		}
+	}
+}
+
+// searchTerm returns the normalized term cut to the longest display name
+func searchTerm(term string) string {
+	term = normalizeSearchText(term)
+
+	if runes := []rune(term); len(runes) > maxSearchTermLength {
+		term = strings.TrimSpace(string(runes[:maxSearchTermLength]))
+	}
+
+	return term
+}
+
+// checkPassword makes sure the user entered their current password,
+// so that a stolen session cannot change how the user signs in
+func checkPassword(user *model.User, password string) error {
+	match, err := comparePasswords(user.Password, password)
+
+	if err != nil {
+		log.Printf("Unable to check the password of user: %v\n%v", user.ID, err)
+		return apperrors.NewInternal()
+	}
+
+	if !match {
+		return apperrors.NewBadRequest("invalid password")
+	}
+
+	return nil
+}
+
+// EnrollTwoFactor generates a new TOTP secret and recovery codes for the user.
+// Two-factor authentication is only enabled once the user confirms a code.
+func (s *userService) EnrollTwoFactor(user *model.User, password string) (*model.TwoFactorSetup, error) {
+	if user.TwoFactorEnabled {
+		return nil, apperrors.NewBadRequest("two-factor authentication is already enabled")
+	}
+
+	if err := checkPassword(user, password); err != nil {
+		return nil, err
+	}
+
+	secret, err := generateTOTPSecret()
+
+	if err != nil {
+		log.Printf("Unable to create totp secret for user: %v\n", user.ID)
+		return nil, apperrors.NewInternal()
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+
+	if err != nil {
+		log.Printf("Unable to create recovery codes for user: %v\n", user.ID)
+		return nil, apperrors.NewInternal()
+	}
+
+	user.TwoFactorSecret = &secret
+	user.RecoveryCodes = hashes
+	user.LastTwoFactorStep = 0
+
+	if err := s.UserRepository.Update(user); err != nil {
+		return nil, err
+	}
+
+	return &model.TwoFactorSetup{
+		Secret:        secret,
+		URI:           totpURI(secret, user.Email),
+		RecoveryCodes: codes,
+	}, nil
+}
+
+// ConfirmTwoFactor enables two-factor authentication
+// if the code matches the enrolled secret
+func (s *userService) ConfirmTwoFactor(user *model.User, code string) error {
+	if user.TwoFactorEnabled {
+		return apperrors.NewBadRequest("two-factor authentication is already enabled")
+	}
+
+	if user.TwoFactorSecret == nil {
+		return apperrors.NewBadRequest("two-factor authentication has not been set up")
+	}
+
+	step, ok := validateTOTP(*user.TwoFactorSecret, code, s.Clock(), user.LastTwoFactorStep)
+
+	if !ok {
+		return apperrors.NewBadRequest("invalid two-factor code")
+	}
+
+	user.TwoFactorEnabled = true
+	user.LastTwoFactorStep = step
+
+	return s.UserRepository.Update(user)
+}
+
+// VerifyTwoFactor checks the code against the user's TOTP secret
+// and recovery codes. Used recovery codes are removed.
+func (s *userService) VerifyTwoFactor(user *model.User, code string) (bool, error) {
+	if !user.TwoFactorEnabled || user.TwoFactorSecret == nil {
+		return false, nil
+	}
+
+	if step, ok := validateTOTP(*user.TwoFactorSecret, code, s.Clock(), user.LastTwoFactorStep); ok {
+		consumed, err := s.UserRepository.ConsumeTwoFactorStep(user.ID, step)
+
+		if err != nil || !consumed {
+			return false, err
+		}
+
+		user.LastTwoFactorStep = step
+		return true, nil
+	}
+
+	hash := hashRecoveryCode(code)
+
+	for i, c := range user.RecoveryCodes {
+		if c == hash {
+			consumed, err := s.UserRepository.ConsumeRecoveryCode(user.ID, hash)
+
+			if err != nil || !consumed {
+				return false, err
+			}
+
+			user.RecoveryCodes = append(user.RecoveryCodes[:i:i], user.RecoveryCodes[i+1:]...)
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// DisableTwoFactor turns off two-factor authentication
+// and removes the secret and recovery codes
+func (s *userService) DisableTwoFactor(user *model.User, password, code string) error {
+	if err := checkPassword(user, password); err != nil {
+		return err
+	}
+
+	valid, err := s.VerifyTwoFactor(user, code)
+
+	if err != nil {
+		return err
+	}
+
+	if !valid {
+		return apperrors.NewBadRequest("invalid two-factor code")
+	}
+
+	user.TwoFactorEnabled = false
+	user.TwoFactorSecret = nil
+	user.RecoveryCodes = nil
+	user.LastTwoFactorStep = 0
+
+	return s.UserRepository.Update(user)
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes of the user
+func (s *userService) RegenerateRecoveryCodes(user *model.User, code string) ([]string, error) {
+	valid, err := s.VerifyTwoFactor(user, code)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !valid {
+		return nil, apperrors.NewBadRequest("invalid two-factor code")
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+
+	if err != nil {
+		log.Printf("Unable to create recovery codes for user: %v\n", user.ID)
+		return nil, apperrors.NewInternal()
+	}
+
+	user.RecoveryCodes = hashes
+
+	if err := s.UserRepository.Update(user); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
 }
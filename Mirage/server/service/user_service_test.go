@@ -1,13 +1,18 @@
 package service
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/sentrionic/mirage/mocks"
 	"github.com/sentrionic/mirage/model"
 	"github.com/sentrionic/mirage/model/apperrors"
 	"github.com/sentrionic/mirage/model/fixture"
 	"github.com/stretchr/testify/mock"
+	"io/ioutil"
+	"net/http"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -170,13 +175,37 @@ func TestLogin(t *testing.T) {
 		mockUserRepository.
 			On("FindByEmail", mockUser.Email).Return(mockUser, nil)
 
-		user, err := us.Login(mockUser.Email, validPW)
+		user, err := us.Login(mockUser.Email, validPW, "10.0.0.1")
 
 		assert.NoError(t, err)
 		assert.Equal(t, user, mockUser)
 		mockUserRepository.AssertCalled(t, "FindByEmail", mockUser.Email)
 	})
 
+	t.Run("Legacy hash gets upgraded", func(t *testing.T) {
+		mockUserRepository := new(mocks.UserRepository)
+		us := NewUserService(&USConfig{
+			UserRepository: mockUserRepository,
+		})
+
+		mockUser := fixture.GetMockUser()
+		mockUser.Password = legacyHash(t, validPW)
+
+		mockUserRepository.On("FindByEmail", mockUser.Email).Return(mockUser, nil)
+		mockUserRepository.On("Update", mockUser).Return(nil)
+
+		user, err := us.Login(mockUser.Email, validPW, "10.0.0.1")
+
+		assert.NoError(t, err)
+		assert.Equal(t, mockUser, user)
+		assert.False(t, passwordNeedsRehash(user.Password))
+
+		match, err := comparePasswords(user.Password, validPW)
+		assert.NoError(t, err)
+		assert.True(t, match)
+		mockUserRepository.AssertExpectations(t)
+	})
+
 	t.Run("Invalid email/password combination", func(t *testing.T) {
 		uid, _ := GenerateId()
 		email := "email@example.com"
@@ -196,7 +225,7 @@ func TestLogin(t *testing.T) {
 		mockUserRepository.
 			On("FindByEmail", mockArgs...).Return(mockUserResp, nil)
 
-		user, err := us.Login(email, invalidPW)
+		user, err := us.Login(email, invalidPW, "10.0.0.1")
 
 		assert.Error(t, err)
 		assert.EqualError(t, err, "Invalid email and password combination")
@@ -205,6 +234,206 @@ func TestLogin(t *testing.T) {
 	})
 }
 
+func TestLogin_Lockout(t *testing.T) {
+	validPW := "howdyhoneighbor!"
+	hashedValidPW, _ := hashPassword(validPW)
+
+	mockUser := fixture.GetMockUser()
+	mockUser.Password = hashedValidPW
+	key := loginKey(mockUser.Email, "10.0.0.1")
+
+	t.Run("Locked account", func(t *testing.T) {
+		mockUserRepository := new(mocks.UserRepository)
+		mockRateLimiter := new(mocks.RateLimiter)
+		us := NewUserService(&USConfig{
+			UserRepository: mockUserRepository,
+			RateLimiter:    mockRateLimiter,
+		})
+
+		mockRateLimiter.On("LockedFor", key).Return(90*time.Second, nil)
+
+		user, err := us.Login(mockUser.Email, validPW, "10.0.0.1")
+
+		assert.Nil(t, user)
+		assert.Error(t, err)
+		assert.Equal(t, apperrors.TooManyRequests, err.(*apperrors.Error).Type)
+		assert.Equal(t, 90, apperrors.RetryAfter(err))
+		mockUserRepository.AssertNotCalled(t, "FindByEmail", mock.Anything)
+	})
+
+	t.Run("Failed login below the limit", func(t *testing.T) {
+		mockUserRepository := new(mocks.UserRepository)
+		mockRateLimiter := new(mocks.RateLimiter)
+		us := NewUserService(&USConfig{
+			UserRepository: mockUserRepository,
+			RateLimiter:    mockRateLimiter,
+		})
+
+		mockRateLimiter.On("LockedFor", key).Return(time.Duration(0), nil)
+		mockRateLimiter.On("Hit", key, loginFailureWindow).Return(int64(1), loginFailureWindow, nil)
+		mockUserRepository.On("FindByEmail", mockUser.Email).Return(mockUser, nil)
+
+		_, err := us.Login(mockUser.Email, "wrongpassword!", "10.0.0.1")
+
+		assert.Error(t, err)
+		assert.Equal(t, apperrors.Authorization, err.(*apperrors.Error).Type)
+		mockRateLimiter.AssertExpectations(t)
+		mockRateLimiter.AssertNotCalled(t, "Lock", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Lockout doubles with every failure", func(t *testing.T) {
+		locks := map[int64]time.Duration{
+			loginFailuresBeforeLock:      loginLockBase,
+			loginFailuresBeforeLock + 1:  2 * loginLockBase,
+			loginFailuresBeforeLock + 3:  8 * loginLockBase,
+			loginFailuresBeforeLock + 10: loginLockMax,
+		}
+
+		for failures, lock := range locks {
+			mockUserRepository := new(mocks.UserRepository)
+			mockRateLimiter := new(mocks.RateLimiter)
+			us := NewUserService(&USConfig{
+				UserRepository: mockUserRepository,
+				RateLimiter:    mockRateLimiter,
+			})
+
+			mockRateLimiter.On("LockedFor", key).Return(time.Duration(0), nil)
+			mockRateLimiter.On("Hit", key, loginFailureWindow).Return(failures, loginFailureWindow, nil)
+			mockRateLimiter.On("Lock", key, lock).Return(nil)
+			mockUserRepository.On("FindByEmail", mockUser.Email).Return(mockUser, nil)
+
+			_, err := us.Login(mockUser.Email, "wrongpassword!", "10.0.0.1")
+
+			assert.Error(t, err)
+			mockRateLimiter.AssertExpectations(t)
+		}
+	})
+
+	t.Run("Successful login resets failures", func(t *testing.T) {
+		mockUserRepository := new(mocks.UserRepository)
+		mockRateLimiter := new(mocks.RateLimiter)
+		us := NewUserService(&USConfig{
+			UserRepository: mockUserRepository,
+			RateLimiter:    mockRateLimiter,
+		})
+
+		mockRateLimiter.On("LockedFor", key).Return(time.Duration(0), nil)
+		mockRateLimiter.On("Reset", key).Return(nil)
+		mockUserRepository.On("FindByEmail", mockUser.Email).Return(mockUser, nil)
+
+		user, err := us.Login(mockUser.Email, validPW, "10.0.0.1")
+
+		assert.NoError(t, err)
+		assert.Equal(t, mockUser, user)
+		mockRateLimiter.AssertExpectations(t)
+	})
+}
+
+func TestLogin_TwoFactorLockout(t *testing.T) {
+	validPW := "howdyhoneighbor!"
+	hashedValidPW, _ := hashPassword(validPW)
+	now := time.Unix(1600000000, 0)
+
+	newUser := func() *model.User {
+		mockUser := fixture.GetMockUser()
+		mockUser.Password = hashedValidPW
+		secret, _ := generateTOTPSecret()
+		mockUser.TwoFactorEnabled = true
+		mockUser.TwoFactorSecret = &secret
+		return mockUser
+	}
+
+	t.Run("Password keeps the failures", func(t *testing.T) {
+		mockUser := newUser()
+		key := loginKey(mockUser.Email, "10.0.0.1")
+		mockUserRepository := new(mocks.UserRepository)
+		mockRateLimiter := new(mocks.RateLimiter)
+		us := NewUserService(&USConfig{
+			UserRepository: mockUserRepository,
+			RateLimiter:    mockRateLimiter,
+		})
+
+		mockRateLimiter.On("LockedFor", key).Return(time.Duration(0), nil)
+		mockUserRepository.On("FindByEmail", mockUser.Email).Return(mockUser, nil)
+
+		user, err := us.Login(mockUser.Email, validPW, "10.0.0.1")
+
+		assert.NoError(t, err)
+		assert.Equal(t, mockUser, user)
+		mockRateLimiter.AssertNotCalled(t, "Reset", mock.Anything)
+	})
+
+	t.Run("Wrong code counts as a failed login", func(t *testing.T) {
+		mockUser := newUser()
+		key := loginKey(mockUser.Email, "10.0.0.1")
+		mockUserRepository := new(mocks.UserRepository)
+		mockRateLimiter := new(mocks.RateLimiter)
+		us := NewUserService(&USConfig{
+			UserRepository: mockUserRepository,
+			RateLimiter:    mockRateLimiter,
+			Clock:          func() time.Time { return now },
+		})
+
+		mockRateLimiter.On("LockedFor", key).Return(time.Duration(0), nil)
+		mockRateLimiter.On("Hit", key, loginFailureWindow).Return(int64(loginFailuresBeforeLock), loginFailureWindow, nil)
+		mockRateLimiter.On("Lock", key, loginLockBase).Return(nil)
+
+		valid, err := us.LoginTwoFactor(mockUser, "000000", "10.0.0.1")
+
+		assert.NoError(t, err)
+		assert.False(t, valid)
+		mockRateLimiter.AssertExpectations(t)
+		mockRateLimiter.AssertNotCalled(t, "Reset", mock.Anything)
+	})
+
+	t.Run("Locked out", func(t *testing.T) {
+		mockUser := newUser()
+		key := loginKey(mockUser.Email, "10.0.0.1")
+		mockRateLimiter := new(mocks.RateLimiter)
+		us := NewUserService(&USConfig{
+			RateLimiter: mockRateLimiter,
+			Clock:       func() time.Time { return now },
+		})
+
+		mockRateLimiter.On("LockedFor", key).Return(time.Minute, nil)
+
+		code := codeAt(t, *mockUser.TwoFactorSecret, totpStep(now))
+		valid, err := us.LoginTwoFactor(mockUser, code, "10.0.0.1")
+
+		assert.False(t, valid)
+		assert.Equal(t, apperrors.TooManyRequests, err.(*apperrors.Error).Type)
+		mockRateLimiter.AssertNotCalled(t, "Reset", mock.Anything)
+	})
+
+	t.Run("Correct code resets the failures", func(t *testing.T) {
+		mockUser := newUser()
+		key := loginKey(mockUser.Email, "10.0.0.1")
+		mockUserRepository := new(mocks.UserRepository)
+		mockRateLimiter := new(mocks.RateLimiter)
+		us := NewUserService(&USConfig{
+			UserRepository: mockUserRepository,
+			RateLimiter:    mockRateLimiter,
+			Clock:          func() time.Time { return now },
+		})
+
+		mockRateLimiter.On("LockedFor", key).Return(time.Duration(0), nil)
+		mockRateLimiter.On("Reset", key).Return(nil)
+		mockUserRepository.On("ConsumeTwoFactorStep", mockUser.ID, totpStep(now)).Return(true, nil)
+
+		code := codeAt(t, *mockUser.TwoFactorSecret, totpStep(now))
+		valid, err := us.LoginTwoFactor(mockUser, code, "10.0.0.1")
+
+		assert.NoError(t, err)
+		assert.True(t, valid)
+		mockRateLimiter.AssertExpectations(t)
+	})
+
+	t.Run("Keys are per IP and email", func(t *testing.T) {
+		assert.NotEqual(t, loginKey("bob@example.com", "10.0.0.1"), loginKey("bob@example.com", "10.0.0.2"))
+		assert.Equal(t, loginKey("bob@example.com", "10.0.0.1"), loginKey(" Bob@example.com", "10.0.0.1"))
+	})
+}
+
 func TestUpdateDetails(t *testing.T) {
 	mockUserRepository := new(mocks.UserRepository)
 	us := NewUserService(&USConfig{
@@ -258,20 +487,17 @@ func TestUpdateDetails(t *testing.T) {
 }
 
 func TestUserService_ChangeAvatar(t *testing.T) {
-	mockUserRepository := new(mocks.UserRepository)
-	mockFileRepository := new(mocks.FileRepository)
-
-	us := NewUserService(&USConfig{
-		UserRepository: mockUserRepository,
-		FileRepository: mockFileRepository,
-	})
-
 	t.Run("Successful new image", func(t *testing.T) {
-		uid, _ := GenerateId()
+		mockUserRepository := new(mocks.UserRepository)
+		mockFileRepository := new(mocks.FileRepository)
+
+		us := NewUserService(&USConfig{
+			UserRepository: mockUserRepository,
+			FileRepository: mockFileRepository,
+		})
 
 		// does not have have imageURL
 		mockUser := fixture.GetMockUser()
-		mockUser.ID = uid
 		mockUser.Image = ""
 
 		multipartImageFixture := fixture.NewMultipartImage("image.png", "image/png")
@@ -290,105 +516,58 @@ func TestUserService_ChangeAvatar(t *testing.T) {
 			On("UploadAvatar", uploadFileArgs...).
 			Return(imageURL, nil)
 
-		updateArgs := mock.Arguments{
-			mockUser,
-		}
-
-		mockUpdatedUser := &model.User{
-			ID:          uid,
-			Email:       mockUser.Email,
-			Bio:         mockUser.Bio,
-			Username:    mockUser.Username,
-			DisplayName: mockUser.DisplayName,
-			Image:       imageURL,
-			Password:    mockUser.Password,
-			CreatedAt:   mockUser.CreatedAt,
-			UpdatedAt:   mockUser.UpdatedAt,
-		}
-
 		mockUserRepository.
-			On("Update", updateArgs...).
+			On("SetProfileImage", mockUser.ID, model.AvatarImage, imageURL).
 			Return(nil)
 
-		url, err := us.ChangeAvatar(imageFileHeader, directory)
-		assert.NoError(t, err)
-		mockUser.Image = url
-
-		err = us.Update(mockUser)
+		err := us.ChangeAvatar(mockUser, imageFileHeader, directory)
 
 		assert.NoError(t, err)
-		assert.Equal(t, mockUpdatedUser, mockUser)
+		assert.Equal(t, imageURL, mockUser.Image)
 		mockFileRepository.AssertCalled(t, "UploadAvatar", uploadFileArgs...)
-		mockUserRepository.AssertCalled(t, "Update", updateArgs...)
+		mockFileRepository.AssertNotCalled(t, "DeleteImage", mock.Anything)
+		mockUserRepository.AssertExpectations(t)
 	})
 
 	t.Run("Successful update image", func(t *testing.T) {
+		mockUserRepository := new(mocks.UserRepository)
+		mockFileRepository := new(mocks.FileRepository)
+
+		us := NewUserService(&USConfig{
+			UserRepository: mockUserRepository,
+			FileRepository: mockFileRepository,
+		})
+
+		oldURL := "https://imageurl.com/old"
 		imageURL := "https://imageurl.com/jdfkj34kljl"
-		uid, _ := GenerateId()
 
-		mockUser := &model.User{
-			Email:    "new@bob.com",
-			Username: "NewRobert",
-			Image:    imageURL,
-		}
-		mockUser.ID = uid
+		mockUser := fixture.GetMockUser()
+		mockUser.Image = oldURL
 
 		multipartImageFixture := fixture.NewMultipartImage("image.png", "image/png")
 		defer multipartImageFixture.Close()
 		imageFileHeader := multipartImageFixture.GetFormFile()
 		directory := "test_dir"
 
-		uploadFileArgs := mock.Arguments{
-			imageFileHeader,
-			directory,
-		}
-
-		deleteImageArgs := mock.Arguments{
-			imageURL,
-		}
-
 		mockFileRepository.
-			On("UploadAvatar", uploadFileArgs...).
+			On("UploadAvatar", imageFileHeader, directory).
 			Return(imageURL, nil)
-
 		mockFileRepository.
-			On("DeleteImage", deleteImageArgs...).
+			On("DeleteImage", oldURL).
 			Return(nil)
-
-		mockUpdatedUser := &model.User{
-			Email:    "new@bob.com",
-			Username: "NewRobert",
-			Image:    imageURL,
-		}
-		mockUpdatedUser.ID = uid
-
-		updateArgs := mock.Arguments{
-			mockUser,
-		}
-
 		mockUserRepository.
-			On("Update", updateArgs...).
+			On("SetProfileImage", mockUser.ID, model.AvatarImage, imageURL).
 			Return(nil)
 
-		url, err := us.ChangeAvatar(imageFileHeader, directory)
-		assert.NoError(t, err)
-		err = us.DeleteImage(mockUser.Image)
-		assert.NoError(t, err)
+		err := us.ChangeAvatar(mockUser, imageFileHeader, directory)
 
-		mockUser.Image = url
-		err = us.Update(mockUser)
 		assert.NoError(t, err)
-
-		assert.Equal(t, mockUpdatedUser, mockUser)
-		mockFileRepository.AssertCalled(t, "UploadAvatar", uploadFileArgs...)
-		mockFileRepository.AssertCalled(t, "DeleteImage", imageURL)
-		mockUserRepository.AssertCalled(t, "Update", updateArgs...)
+		assert.Equal(t, imageURL, mockUser.Image)
+		mockFileRepository.AssertExpectations(t)
+		mockUserRepository.AssertExpectations(t)
 	})
 
 	t.Run("FileRepository Error", func(t *testing.T) {
-		// need to create a new UserService and repository
-		// because testify has no way to overwrite a mock's
-		// "On" call.
 		mockUserRepository := new(mocks.UserRepository)
 		mockFileRepository := new(mocks.FileRepository)
 
@@ -397,74 +576,152 @@ func TestUserService_ChangeAvatar(t *testing.T) {
 			FileRepository: mockFileRepository,
 		})
 
+		mockUser := fixture.GetMockUser()
+		image := mockUser.Image
+
 		multipartImageFixture := fixture.NewMultipartImage("image.png", "image/png")
 		defer multipartImageFixture.Close()
 		imageFileHeader := multipartImageFixture.GetFormFile()
 		directory := "file_directory"
 
-		uploadFileArgs := mock.Arguments{
-			imageFileHeader,
-			directory,
-		}
-
 		mockError := apperrors.NewInternal()
 		mockFileRepository.
-			On("UploadAvatar", uploadFileArgs...).
+			On("UploadAvatar", imageFileHeader, directory).
 			Return("", mockError)
 
-		url, err := us.ChangeAvatar(imageFileHeader, directory)
-		assert.Equal(t, "", url)
-		assert.Error(t, err)
+		err := us.ChangeAvatar(mockUser, imageFileHeader, directory)
 
-		mockFileRepository.AssertCalled(t, "UploadAvatar", uploadFileArgs...)
-		mockUserRepository.AssertNotCalled(t, "Update")
+		assert.Error(t, err)
+		assert.Equal(t, image, mockUser.Image)
+		mockUserRepository.AssertNotCalled(t, "SetProfileImage", mock.Anything, mock.Anything, mock.Anything)
 	})
 
-	t.Run("UserRepository UpdateImage Error", func(t *testing.T) {
-		uid, _ := GenerateId()
+	t.Run("UserRepository SetProfileImage Error", func(t *testing.T) {
+		mockUserRepository := new(mocks.UserRepository)
+		mockFileRepository := new(mocks.FileRepository)
+
+		us := NewUserService(&USConfig{
+			UserRepository: mockUserRepository,
+			FileRepository: mockFileRepository,
+		})
+
 		imageURL := "https://imageurl.com/jdfkj34kljl"
 
-		// has imageURL
-		mockUser := &model.User{
-			Email:    "new@bob.com",
-			Username: "A New Bob!",
-			Image:    imageURL,
-		}
-		mockUser.ID = uid
+		mockUser := fixture.GetMockUser()
+		image := mockUser.Image
 
 		multipartImageFixture := fixture.NewMultipartImage("image.png", "image/png")
 		defer multipartImageFixture.Close()
 		imageFileHeader := multipartImageFixture.GetFormFile()
 		directory := "file_dir"
 
-		uploadFileArgs := mock.Arguments{
-			imageFileHeader,
-			directory,
-		}
-
 		mockFileRepository.
-			On("UploadAvatar", uploadFileArgs...).
+			On("UploadAvatar", imageFileHeader, directory).
 			Return(imageURL, nil)
 
-		updateArgs := mock.Arguments{
-			mockUser,
-		}
-
 		mockError := apperrors.NewInternal()
 		mockUserRepository.
-			On("Update", updateArgs...).
+			On("SetProfileImage", mockUser.ID, model.AvatarImage, imageURL).
 			Return(mockError)
 
-		url, err := us.ChangeAvatar(imageFileHeader, directory)
-		assert.NoError(t, err)
-		assert.Equal(t, imageURL, url)
-
-		err = us.Update(mockUser)
+		err := us.ChangeAvatar(mockUser, imageFileHeader, directory)
 
 		assert.Error(t, err)
-		mockFileRepository.AssertCalled(t, "UploadAvatar", uploadFileArgs...)
-		mockUserRepository.AssertCalled(t, "Update", updateArgs...)
+		assert.Equal(t, image, mockUser.Image)
+		// the old image is kept while the user still uses it
+		mockFileRepository.AssertNotCalled(t, "DeleteImage", mock.Anything)
+	})
+}
+
+func TestUserService_QueuedProfileImage(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+
+	mockUserRepository := new(mocks.UserRepository)
+	mockFileRepository := new(mocks.FileRepository)
+	mockUploadRepository := new(mocks.UploadRepository)
+	mockJobService := new(mocks.JobService)
+
+	var handler model.JobHandler
+	mockJobService.
+		On("Handle", model.JobProfileImage, mock.AnythingOfType("model.JobHandler")).
+		Run(func(args mock.Arguments) {
+			handler = args.Get(1).(model.JobHandler)
+		})
+
+	us := NewUserService(&USConfig{
+		UserRepository:   mockUserRepository,
+		FileRepository:   mockFileRepository,
+		UploadRepository: mockUploadRepository,
+		JobService:       mockJobService,
+		Clock:            func() time.Time { return now },
 	})
+
+	oldURL := "https://imageurl.com/old"
+	mockUser := fixture.GetMockUser()
+	mockUser.Banner = &oldURL
+
+	multipartImageFixture := fixture.NewMultipartImage("image.png", "image/png")
+	defer multipartImageFixture.Close()
+	imageFileHeader := multipartImageFixture.GetFormFile()
+
+	var upload *model.Upload
+	mockUploadRepository.
+		On("Create", mock.AnythingOfType("*model.Upload")).
+		Run(func(args mock.Arguments) {
+			upload = args.Get(0).(*model.Upload)
+		}).
+		Return(nil)
+	mockFileRepository.
+		On("UploadFile", mock.Anything, "uploads", mock.AnythingOfType("string"), "image/png").
+		Return("https://imageurl.com/files/uploads/raw.png", nil)
+
+	var payload json.RawMessage
+	mockJobService.
+		On("Enqueue", model.JobProfileImage, mockUser.ID, mock.AnythingOfType("*service.profileImageJob")).
+		Run(func(args mock.Arguments) {
+			payload, _ = json.Marshal(args.Get(2))
+		}).
+		Return(&model.Job{ID: "10"}, nil)
+
+	err := us.ChangeBanner(mockUser, imageFileHeader, "test_dir")
+
+	assert.NoError(t, err)
+	mockFileRepository.AssertNotCalled(t, "UploadBanner", mock.Anything, mock.Anything)
+	mockUserRepository.AssertNotCalled(t, "SetProfileImage", mock.Anything, mock.Anything, mock.Anything)
+
+	// the banner only changes once the job ran
+	assert.Equal(t, "10", mockUser.BannerJobID)
+	assert.Equal(t, &oldURL, mockUser.Banner)
+
+	var job profileImageJob
+	assert.NoError(t, json.Unmarshal(payload, &job))
+	assert.Equal(t, mockUser.ID, job.UserID)
+	assert.Equal(t, model.BannerImage, job.Kind)
+	assert.Equal(t, bannerWidth, job.Width)
+
+	// the staged image is removed by CleanupUploads if the job fails
+	assert.Equal(t, upload.ID, job.UploadID)
+	assert.Equal(t, upload.Key, job.Source)
+	assert.Equal(t, mockUser.ID, upload.UserID)
+	assert.Equal(t, now.Add(stagedImageExpiration), upload.ExpiresAt)
+	assert.True(t, strings.HasPrefix(job.Source, "files/uploads/"))
+
+	// the worker resizes the staged image, sets it and removes the staged one
+	url := "https://imageurl.com/files/test_dir/" + job.Filename
+	mockFileRepository.On("OpenFile", job.Source).Return(ioutil.NopCloser(strings.NewReader("image")), nil)
+	mockFileRepository.On("UploadResizedImage", mock.Anything, "test_dir", job.Filename, bannerWidth).Return(url, nil)
+	mockUserRepository.On("FindByID", mockUser.ID).Return(mockUser, nil)
+	mockUserRepository.On("SetProfileImage", mockUser.ID, model.BannerImage, url).Return(nil)
+	mockFileRepository.On("DeleteImage", oldURL).Return(nil)
+	mockFileRepository.On("DeleteImage", job.Source).Return(nil)
+	mockUploadRepository.On("Delete", &model.Upload{ID: upload.ID}).Return(nil)
+
+	err = handler(payload)
+
+	assert.NoError(t, err)
+	mockFileRepository.AssertExpectations(t)
+	mockUserRepository.AssertExpectations(t)
+	mockUploadRepository.AssertExpectations(t)
 }
 
 func TestUserService_ChangeFollow(t *testing.T) {
@@ -503,6 +760,29 @@ func TestUserService_ChangeFollow(t *testing.T) {
 		mockUserRepository.AssertNotCalled(t, "AddFollow", mockUser, current.ID)
 	})
 
+	t.Run("Updates the timeline", func(t *testing.T) {
+		current := fixture.GetMockUser()
+		mockUser := fixture.GetMockUser()
+
+		mockUserRepository := new(mocks.UserRepository)
+		mockTimelineService := new(mocks.TimelineService)
+		us := NewUserService(&USConfig{
+			UserRepository:  mockUserRepository,
+			TimelineService: mockTimelineService,
+		})
+		mockUserRepository.On("AddFollow", mockUser.ID, current.ID).Return(nil)
+		mockUserRepository.On("RemoveFollow", mockUser.ID, current.ID).Return(nil)
+		mockTimelineService.On("Follow", current.ID, mockUser.ID).Return(nil)
+		mockTimelineService.On("Unfollow", current.ID, mockUser.ID).Return(nil)
+
+		assert.NoError(t, us.ChangeFollow(mockUser, current.ID))
+
+		mockUser.Followers = append(mockUser.Followers, current)
+		assert.NoError(t, us.ChangeFollow(mockUser, current.ID))
+
+		mockTimelineService.AssertExpectations(t)
+	})
+
 	t.Run("Error from AddFollow", func(t *testing.T) {
 		current := fixture.GetMockUser()
 		mockUser := fixture.GetMockUser()
@@ -539,6 +819,94 @@ func TestUserService_ChangeFollow(t *testing.T) {
 	})
 }
 
+func TestUserService_Followers(t *testing.T) {
+	now := time.Date(2021, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	edges := make([]model.Follow, 0)
+	users := make([]model.User, 0)
+	ids := make([]string, 0)
+	for i := 0; i <= model.LIMIT; i++ {
+		user := fixture.GetMockUser()
+		// the follows moved from the old tables share their time
+		edges = append(edges, model.Follow{FollowerID: user.ID, FolloweeID: "u", CreatedAt: now})
+		users = append(users, *user)
+		ids = append(ids, user.ID)
+	}
+
+	t.Run("First page", func(t *testing.T) {
+		mockUserRepository := new(mocks.UserRepository)
+		us := NewUserService(&USConfig{UserRepository: mockUserRepository})
+
+		var nilFollow *model.Follow
+		mockUserRepository.On("FollowerEdges", "u", nilFollow, model.LIMIT+1).Return(edges, nil)
+		// the users are loaded in any order
+		reversed := make([]model.User, len(users))
+		for i, user := range users {
+			reversed[len(users)-1-i] = user
+		}
+		mockUserRepository.On("FindByIDs", ids).Return(&reversed, nil)
+
+		found, next, err := us.Followers("u", "")
+
+		assert.NoError(t, err)
+		assert.Equal(t, users, *found)
+		assert.Equal(t, formatFollowCursor(now, users[model.LIMIT-1].ID), next)
+	})
+
+	t.Run("Next page", func(t *testing.T) {
+		mockUserRepository := new(mocks.UserRepository)
+		us := NewUserService(&USConfig{UserRepository: mockUserRepository})
+
+		before := &model.Follow{FollowerID: "a", FolloweeID: "u", CreatedAt: now}
+		mockUserRepository.On("FollowerEdges", "u", before, model.LIMIT+1).Return(edges[:1], nil)
+		mockUserRepository.On("FindByIDs", ids[:1]).Return(&[]model.User{users[0]}, nil)
+
+		found, next, err := us.Followers("u", formatFollowCursor(now, "a"))
+
+		assert.NoError(t, err)
+		assert.Len(t, *found, 1)
+		assert.Empty(t, next)
+	})
+
+	t.Run("Following", func(t *testing.T) {
+		mockUserRepository := new(mocks.UserRepository)
+		us := NewUserService(&USConfig{UserRepository: mockUserRepository})
+
+		before := &model.Follow{FollowerID: "u", FolloweeID: "a", CreatedAt: now}
+		edge := model.Follow{FollowerID: "u", FolloweeID: users[0].ID, CreatedAt: now}
+		mockUserRepository.On("FollowingEdges", "u", before, model.LIMIT+1).Return([]model.Follow{edge}, nil)
+		mockUserRepository.On("FindByIDs", ids[:1]).Return(&[]model.User{users[0]}, nil)
+
+		found, _, err := us.Following("u", formatFollowCursor(now, "a"))
+
+		assert.NoError(t, err)
+		assert.Equal(t, []model.User{users[0]}, *found)
+	})
+
+	t.Run("Mutual followers", func(t *testing.T) {
+		mockUserRepository := new(mocks.UserRepository)
+		us := NewUserService(&USConfig{UserRepository: mockUserRepository})
+
+		var nilFollow *model.Follow
+		mockUserRepository.On("MutualFollowerEdges", "u", "v", nilFollow, model.LIMIT+1).Return(edges[:2], nil)
+		mockUserRepository.On("FindByIDs", ids[:2]).Return(&[]model.User{users[1], users[0]}, nil)
+
+		found, next, err := us.MutualFollowers("u", "v", "")
+
+		assert.NoError(t, err)
+		assert.Equal(t, users[:2], *found)
+		assert.Empty(t, next)
+	})
+
+	t.Run("Invalid cursor", func(t *testing.T) {
+		us := NewUserService(&USConfig{UserRepository: new(mocks.UserRepository)})
+
+		_, _, err := us.Followers("u", "20")
+
+		assert.Equal(t, http.StatusBadRequest, apperrors.Status(err))
+	})
+}
+
 func TestUserService_Search(t *testing.T) {
 
 	users := make([]model.User, 0)
@@ -554,27 +922,86 @@ func TestUserService_Search(t *testing.T) {
 			UserRepository: mockUserRepository,
 		})
 
-		term := "tes"
+		mockUserRepository.On("SearchProfiles", "tes", (*model.ProfileRank)(nil), model.LIMIT+1).Return(&users, make([]model.ProfileRank, len(users)), nil)
 
-		mockUserRepository.On("SearchProfiles", term).Return(&users, nil)
-
-		rsp, err := us.Search(term)
+		rsp, next, err := us.Search("tes", "")
 
 		assert.NoError(t, err)
 		assert.Equal(t, 5, len(*rsp))
+		assert.Equal(t, "", next)
+		mockUserRepository.AssertExpectations(t)
+	})
+
+	t.Run("Normalizes the term", func(t *testing.T) {
+		mockUserRepository := new(mocks.UserRepository)
+		us := NewUserService(&USConfig{
+			UserRepository: mockUserRepository,
+		})
+
+		mockUserRepository.On("SearchProfiles", "tong bingxue 仝冰雪", (*model.ProfileRank)(nil), model.LIMIT+1).Return(&users, make([]model.ProfileRank, len(users)), nil)
+
+		_, _, err := us.Search("  Ｔóng   BINGXUE 仝冰雪 ", "")
+
+		assert.NoError(t, err)
+		mockUserRepository.AssertExpectations(t)
+	})
+
+	t.Run("Next cursor", func(t *testing.T) {
+		mockUserRepository := new(mocks.UserRepository)
+		us := NewUserService(&USConfig{
+			UserRepository: mockUserRepository,
+		})
+
+		after := &model.ProfileRank{Match: 1, Followers: 20, ID: "9"}
+		page := make([]model.User, model.LIMIT+1)
+		ranks := make([]model.ProfileRank, model.LIMIT+1)
+		ranks[model.LIMIT-1] = model.ProfileRank{Match: 3, Followers: 0, ID: "42"}
+		mockUserRepository.On("SearchProfiles", "tes", after, model.LIMIT+1).Return(&page, ranks, nil)
+
+		_, next, err := us.Search("tes", "1_20_9")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "3_0_42", next)
 		mockUserRepository.AssertExpectations(t)
 	})
 
+	t.Run("Empty term", func(t *testing.T) {
+		mockUserRepository := new(mocks.UserRepository)
+		us := NewUserService(&USConfig{
+			UserRepository: mockUserRepository,
+		})
+
+		rsp, next, err := us.Search("  ", "")
+
+		assert.NoError(t, err)
+		assert.Empty(t, *rsp)
+		assert.Equal(t, "", next)
+		mockUserRepository.AssertNotCalled(t, "SearchProfiles")
+	})
+
+	t.Run("Invalid cursor", func(t *testing.T) {
+		mockUserRepository := new(mocks.UserRepository)
+		us := NewUserService(&USConfig{
+			UserRepository: mockUserRepository,
+		})
+
+		for _, cursor := range []string{"abc", "20", "1_x_9", "-1_20_9", "1_20_"} {
+			_, _, err := us.Search("tes", cursor)
+
+			assert.Equal(t, http.StatusBadRequest, apperrors.Status(err), cursor)
+		}
+		mockUserRepository.AssertNotCalled(t, "SearchProfiles")
+	})
+
 	t.Run("Error", func(t *testing.T) {
 		mockUserRepository := new(mocks.UserRepository)
 		us := NewUserService(&USConfig{
 			UserRepository: mockUserRepository,
 		})
 
-		term := "tes"
-		mockUserRepository.On("SearchProfiles", term).Return(nil, fmt.Errorf("some error down the call chain"))
+		mockUserRepository.On("SearchProfiles", "tes", (*model.ProfileRank)(nil), model.LIMIT+1).Return(nil, nil, fmt.Errorf("some error down the call chain"))
 
-		rsp, err := us.Search(term)
+		rsp, _, err := us.Search("tes", "")
 
 		assert.Nil(t, rsp)
 		assert.Error(t, err)
@@ -582,18 +1009,77 @@ func TestUserService_Search(t *testing.T) {
 	})
 }
 
-func TestUserService_ChangeBanner(t *testing.T) {
-	mockUserRepository := new(mocks.UserRepository)
-	mockFileRepository := new(mocks.FileRepository)
+func TestUserService_Typeahead(t *testing.T) {
+	t.Run("Strips the mention sign", func(t *testing.T) {
+		mockUserRepository := new(mocks.UserRepository)
+		us := NewUserService(&USConfig{
+			UserRepository: mockUserRepository,
+		})
+
+		users := []model.User{*fixture.GetMockUser()}
+		mockUserRepository.On("TypeaheadProfiles", "tong", typeaheadLimit).Return(&users, nil)
+
+		rsp, err := us.Typeahead("@Tong")
+
+		assert.NoError(t, err)
+		assert.Equal(t, &users, rsp)
+		mockUserRepository.AssertExpectations(t)
+	})
+
+	t.Run("Empty term", func(t *testing.T) {
+		mockUserRepository := new(mocks.UserRepository)
+		us := NewUserService(&USConfig{
+			UserRepository: mockUserRepository,
+		})
+
+		rsp, err := us.Typeahead("@")
 
+		assert.NoError(t, err)
+		assert.Empty(t, *rsp)
+		mockUserRepository.AssertNotCalled(t, "TypeaheadProfiles")
+	})
+}
+
+func TestUserService_ReindexProfiles(t *testing.T) {
+	mockUserRepository := new(mocks.UserRepository)
 	us := NewUserService(&USConfig{
 		UserRepository: mockUserRepository,
-		FileRepository: mockFileRepository,
 	})
 
+	bio := "Café owner"
+	first := make([]model.User, reindexBatchSize)
+	for i := range first {
+		first[i] = model.User{ID: fmt.Sprintf("%04d", i), DisplayName: "Tóng"}
+	}
+	second := []model.User{{ID: "9999", DisplayName: "ＢＩＮＧＸＵＥ", Bio: &bio}}
+
+	mockUserRepository.On("FindAfter", "", reindexBatchSize).Return(&first, nil)
+	mockUserRepository.On("FindAfter", fmt.Sprintf("%04d", reindexBatchSize-1), reindexBatchSize).Return(&second, nil)
+	mockUserRepository.On("UpdateSearchText", mock.AnythingOfType("*model.User")).Return(nil)
+
+	updated, err := us.ReindexProfiles()
+
+	assert.NoError(t, err)
+	assert.Equal(t, reindexBatchSize+1, updated)
+	assert.Equal(t, "tong", first[0].SearchName)
+	assert.Equal(t, "bingxue", second[0].SearchName)
+	assert.Equal(t, "cafe owner", second[0].SearchBio)
+	mockUserRepository.AssertExpectations(t)
+}
+
+func TestUserService_ChangeBanner(t *testing.T) {
 	t.Run("Successful new banner", func(t *testing.T) {
+		mockUserRepository := new(mocks.UserRepository)
+		mockFileRepository := new(mocks.FileRepository)
+
+		us := NewUserService(&USConfig{
+			UserRepository: mockUserRepository,
+			FileRepository: mockFileRepository,
+		})
+
 		// does not have have banner
 		mockUser := fixture.GetMockUser()
+		mockUser.Banner = nil
 
 		multipartImageFixture := fixture.NewMultipartImage("image.png", "image/png")
 		defer multipartImageFixture.Close()
@@ -611,107 +1097,58 @@ func TestUserService_ChangeBanner(t *testing.T) {
 			On("UploadBanner", uploadFileArgs...).
 			Return(imageURL, nil)
 
-		updateArgs := mock.Arguments{
-			mockUser,
-		}
-
-		mockUpdatedUser := &model.User{
-			ID:          mockUser.ID,
-			Email:       mockUser.Email,
-			Bio:         mockUser.Bio,
-			Username:    mockUser.Username,
-			DisplayName: mockUser.DisplayName,
-			Image:       mockUser.Image,
-			Banner:      &imageURL,
-			Password:    mockUser.Password,
-			CreatedAt:   mockUser.CreatedAt,
-			UpdatedAt:   mockUser.UpdatedAt,
-		}
-
 		mockUserRepository.
-			On("Update", updateArgs...).
+			On("SetProfileImage", mockUser.ID, model.BannerImage, imageURL).
 			Return(nil)
 
-		url, err := us.ChangeBanner(imageFileHeader, directory)
-		assert.NoError(t, err)
-		mockUser.Banner = &url
-
-		err = us.Update(mockUser)
+		err := us.ChangeBanner(mockUser, imageFileHeader, directory)
 
 		assert.NoError(t, err)
-		assert.Equal(t, mockUpdatedUser, mockUser)
+		assert.Equal(t, &imageURL, mockUser.Banner)
 		mockFileRepository.AssertCalled(t, "UploadBanner", uploadFileArgs...)
-		mockUserRepository.AssertCalled(t, "Update", updateArgs...)
+		mockFileRepository.AssertNotCalled(t, "DeleteImage", mock.Anything)
+		mockUserRepository.AssertExpectations(t)
 	})
 
 	t.Run("Successful update banner", func(t *testing.T) {
+		mockUserRepository := new(mocks.UserRepository)
+		mockFileRepository := new(mocks.FileRepository)
+
+		us := NewUserService(&USConfig{
+			UserRepository: mockUserRepository,
+			FileRepository: mockFileRepository,
+		})
+
+		oldURL := "https://imageurl.com/old"
 		imageURL := "https://imageurl.com/jdfkj34kljl"
 
 		mockUser := fixture.GetMockUser()
-		mockUser.Banner = &imageURL
+		mockUser.Banner = &oldURL
 
 		multipartImageFixture := fixture.NewMultipartImage("image.png", "image/png")
 		defer multipartImageFixture.Close()
 		imageFileHeader := multipartImageFixture.GetFormFile()
 		directory := "test_dir"
 
-		uploadFileArgs := mock.Arguments{
-			imageFileHeader,
-			directory,
-		}
-
-		deleteImageArgs := mock.Arguments{
-			imageURL,
-		}
-
 		mockFileRepository.
-			On("UploadBanner", uploadFileArgs...).
+			On("UploadBanner", imageFileHeader, directory).
 			Return(imageURL, nil)
-
 		mockFileRepository.
-			On("DeleteImage", deleteImageArgs...).
+			On("DeleteImage", oldURL).
 			Return(nil)
-
-		mockUpdatedUser := &model.User{
-			ID:          mockUser.ID,
-			Email:       mockUser.Email,
-			Bio:         mockUser.Bio,
-			Username:    mockUser.Username,
-			DisplayName: mockUser.DisplayName,
-			Image:       mockUser.Image,
-			Banner:      &imageURL,
-			Password:    mockUser.Password,
-			CreatedAt:   mockUser.CreatedAt,
-			UpdatedAt:   mockUser.UpdatedAt,
-		}
-
-		updateArgs := mock.Arguments{
-			mockUser,
-		}
-
 		mockUserRepository.
-			On("Update", updateArgs...).
+			On("SetProfileImage", mockUser.ID, model.BannerImage, imageURL).
 			Return(nil)
 
-		url, err := us.ChangeBanner(imageFileHeader, directory)
-		assert.NoError(t, err)
-		err = us.DeleteImage(*mockUser.Banner)
-		assert.NoError(t, err)
+		err := us.ChangeBanner(mockUser, imageFileHeader, directory)
 
-		mockUser.Banner = &url
-		err = us.Update(mockUser)
 		assert.NoError(t, err)
-
-		assert.Equal(t, mockUpdatedUser, mockUser)
-		mockFileRepository.AssertCalled(t, "UploadBanner", uploadFileArgs...)
-		mockFileRepository.AssertCalled(t, "DeleteImage", imageURL)
-		mockUserRepository.AssertCalled(t, "Update", updateArgs...)
+		assert.Equal(t, &imageURL, mockUser.Banner)
+		mockFileRepository.AssertExpectations(t)
+		mockUserRepository.AssertExpectations(t)
 	})
 
 	t.Run("FileRepository Error", func(t *testing.T) {
-		// need to create a new UserService and repository
-		// because testify has no way to overwrite a mock's
-		// "On" call.
 		mockUserRepository := new(mocks.UserRepository)
 		mockFileRepository := new(mocks.FileRepository)
 
@@ -720,66 +1157,224 @@ func TestUserService_ChangeBanner(t *testing.T) {
 			FileRepository: mockFileRepository,
 		})
 
+		mockUser := fixture.GetMockUser()
+		banner := mockUser.Banner
+
 		multipartImageFixture := fixture.NewMultipartImage("image.png", "image/png")
 		defer multipartImageFixture.Close()
 		imageFileHeader := multipartImageFixture.GetFormFile()
 		directory := "file_directory"
 
-		uploadFileArgs := mock.Arguments{
-			imageFileHeader,
-			directory,
-		}
-
 		mockError := apperrors.NewInternal()
 		mockFileRepository.
-			On("UploadBanner", uploadFileArgs...).
+			On("UploadBanner", imageFileHeader, directory).
 			Return("", mockError)
 
-		url, err := us.ChangeBanner(imageFileHeader, directory)
-		assert.Equal(t, "", url)
-		assert.Error(t, err)
+		err := us.ChangeBanner(mockUser, imageFileHeader, directory)
 
-		mockFileRepository.AssertCalled(t, "UploadBanner", uploadFileArgs...)
-		mockUserRepository.AssertNotCalled(t, "Update")
+		assert.Error(t, err)
+		assert.Equal(t, banner, mockUser.Banner)
+		mockUserRepository.AssertNotCalled(t, "SetProfileImage", mock.Anything, mock.Anything, mock.Anything)
 	})
 
-	t.Run("UserRepository Update Error", func(t *testing.T) {
+	t.Run("UserRepository SetProfileImage Error", func(t *testing.T) {
+		mockUserRepository := new(mocks.UserRepository)
+		mockFileRepository := new(mocks.FileRepository)
+
+		us := NewUserService(&USConfig{
+			UserRepository: mockUserRepository,
+			FileRepository: mockFileRepository,
+		})
+
 		imageURL := "https://imageurl.com/jdfkj34kljl"
 
 		mockUser := fixture.GetMockUser()
-		mockUser.Banner = &imageURL
+		banner := mockUser.Banner
 
 		multipartImageFixture := fixture.NewMultipartImage("image.png", "image/png")
 		defer multipartImageFixture.Close()
 		imageFileHeader := multipartImageFixture.GetFormFile()
 		directory := "file_dir"
 
-		uploadFileArgs := mock.Arguments{
-			imageFileHeader,
-			directory,
-		}
-
 		mockFileRepository.
-			On("UploadBanner", uploadFileArgs...).
+			On("UploadBanner", imageFileHeader, directory).
 			Return(imageURL, nil)
 
-		updateArgs := mock.Arguments{
-			mockUser,
-		}
-
 		mockError := apperrors.NewInternal()
 		mockUserRepository.
-			On("Update", updateArgs...).
+			On("SetProfileImage", mockUser.ID, model.BannerImage, imageURL).
 			Return(mockError)
 
-		url, err := us.ChangeBanner(imageFileHeader, directory)
+		err := us.ChangeBanner(mockUser, imageFileHeader, directory)
+
+		assert.Error(t, err)
+		assert.Equal(t, banner, mockUser.Banner)
+		// the old image is kept while the user still uses it
+		mockFileRepository.AssertNotCalled(t, "DeleteImage", mock.Anything)
+	})
+}
+
+func TestUserService_TwoFactor(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+
+	mockUserRepository := new(mocks.UserRepository)
+	us := NewUserService(&USConfig{
+		UserRepository: mockUserRepository,
+		Clock:          func() time.Time { return now },
+	})
+
+	mockUserRepository.On("Update", mock.AnythingOfType("*model.User")).Return(nil)
+	mockUserRepository.On("ConsumeTwoFactorStep", mock.AnythingOfType("string"), mock.AnythingOfType("int64")).Return(true, nil)
+	mockUserRepository.On("ConsumeRecoveryCode", mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(true, nil)
+
+	t.Run("Enroll and confirm", func(t *testing.T) {
+		mockUser := passwordUser(t)
+
+		setup, err := us.EnrollTwoFactor(mockUser, "password")
 		assert.NoError(t, err)
-		assert.Equal(t, imageURL, url)
+		assert.NotEmpty(t, setup.Secret)
+		assert.Contains(t, setup.URI, "otpauth://totp/")
+		assert.Len(t, setup.RecoveryCodes, recoveryCodeCount)
+		assert.False(t, mockUser.TwoFactorEnabled)
 
-		err = us.Update(mockUser)
+		err = us.ConfirmTwoFactor(mockUser, "000000")
+		assert.Error(t, err)
+		assert.False(t, mockUser.TwoFactorEnabled)
+
+		code := codeAt(t, setup.Secret, totpStep(now))
+		err = us.ConfirmTwoFactor(mockUser, code)
+		assert.NoError(t, err)
+		assert.True(t, mockUser.TwoFactorEnabled)
+		assert.Equal(t, totpStep(now), mockUser.LastTwoFactorStep)
+	})
 
+	t.Run("Enroll when already enabled", func(t *testing.T) {
+		mockUser := passwordUser(t)
+		mockUser.TwoFactorEnabled = true
+
+		setup, err := us.EnrollTwoFactor(mockUser, "password")
+		assert.Nil(t, setup)
 		assert.Error(t, err)
-		mockFileRepository.AssertCalled(t, "UploadBanner", uploadFileArgs...)
-		mockUserRepository.AssertCalled(t, "Update", updateArgs...)
 	})
+
+	t.Run("Enroll with a wrong password", func(t *testing.T) {
+		mockUser := passwordUser(t)
+
+		setup, err := us.EnrollTwoFactor(mockUser, "wrong password")
+		assert.Nil(t, setup)
+		assert.Equal(t, http.StatusBadRequest, apperrors.Status(err))
+		assert.Nil(t, mockUser.TwoFactorSecret)
+	})
+
+	t.Run("Verify rejects a reused code", func(t *testing.T) {
+		mockUser := enabledTwoFactorUser(t, us, now)
+		code := codeAt(t, *mockUser.TwoFactorSecret, totpStep(now)+1)
+
+		valid, err := us.VerifyTwoFactor(mockUser, code)
+		assert.NoError(t, err)
+		assert.True(t, valid)
+
+		valid, err = us.VerifyTwoFactor(mockUser, code)
+		assert.NoError(t, err)
+		assert.False(t, valid)
+	})
+
+	t.Run("Recovery code can only be used once", func(t *testing.T) {
+		mockUser := passwordUser(t)
+		setup, _ := us.EnrollTwoFactor(mockUser, "password")
+		_ = us.ConfirmTwoFactor(mockUser, codeAt(t, setup.Secret, totpStep(now)))
+
+		recovery := strings.ToUpper(setup.RecoveryCodes[0])
+
+		valid, err := us.VerifyTwoFactor(mockUser, recovery)
+		assert.NoError(t, err)
+		assert.True(t, valid)
+		assert.Len(t, mockUser.RecoveryCodes, recoveryCodeCount-1)
+
+		valid, err = us.VerifyTwoFactor(mockUser, recovery)
+		assert.NoError(t, err)
+		assert.False(t, valid)
+	})
+
+	t.Run("Verify rejects a code used by a concurrent request", func(t *testing.T) {
+		mockUser := enabledTwoFactorUser(t, us, now)
+		step := totpStep(now) + 1
+
+		concurrentRepository := new(mocks.UserRepository)
+		concurrentRepository.On("ConsumeTwoFactorStep", mockUser.ID, step).Return(false, nil)
+		cs := NewUserService(&USConfig{
+			UserRepository: concurrentRepository,
+			Clock:          func() time.Time { return now },
+		})
+
+		valid, err := cs.VerifyTwoFactor(mockUser, codeAt(t, *mockUser.TwoFactorSecret, step))
+		assert.NoError(t, err)
+		assert.False(t, valid)
+		assert.Equal(t, totpStep(now), mockUser.LastTwoFactorStep)
+		concurrentRepository.AssertExpectations(t)
+	})
+
+	t.Run("Verify without two-factor", func(t *testing.T) {
+		mockUser := fixture.GetMockUser()
+
+		valid, err := us.VerifyTwoFactor(mockUser, "123456")
+		assert.NoError(t, err)
+		assert.False(t, valid)
+	})
+
+	t.Run("Disable", func(t *testing.T) {
+		mockUser := enabledTwoFactorUser(t, us, now)
+
+		code := codeAt(t, *mockUser.TwoFactorSecret, totpStep(now)+1)
+
+		err := us.DisableTwoFactor(mockUser, "password", "000000")
+		assert.Error(t, err)
+		assert.True(t, mockUser.TwoFactorEnabled)
+
+		err = us.DisableTwoFactor(mockUser, "wrong password", code)
+		assert.Equal(t, http.StatusBadRequest, apperrors.Status(err))
+		assert.True(t, mockUser.TwoFactorEnabled)
+
+		err = us.DisableTwoFactor(mockUser, "password", code)
+		assert.NoError(t, err)
+		assert.False(t, mockUser.TwoFactorEnabled)
+		assert.Nil(t, mockUser.TwoFactorSecret)
+		assert.Nil(t, mockUser.RecoveryCodes)
+	})
+
+	t.Run("Regenerate recovery codes", func(t *testing.T) {
+		mockUser := enabledTwoFactorUser(t, us, now)
+		old := append([]string{}, mockUser.RecoveryCodes...)
+
+		codes, err := us.RegenerateRecoveryCodes(mockUser, codeAt(t, *mockUser.TwoFactorSecret, totpStep(now)+1))
+		assert.NoError(t, err)
+		assert.Len(t, codes, recoveryCodeCount)
+		assert.NotEqual(t, old, []string(mockUser.RecoveryCodes))
+	})
+}
+
+// codeAt returns the TOTP code of the secret for the given step
+func codeAt(t *testing.T, secret string, step int64) string {
+	code, err := totpCode(secret, step)
+	assert.NoError(t, err)
+	return code
+}
+
+// enabledTwoFactorUser returns a user that confirmed two-factor
+// authentication at the given time
+func enabledTwoFactorUser(t *testing.T, us model.UserService, now time.Time) *model.User {
+	mockUser := passwordUser(t)
+	setup, err := us.EnrollTwoFactor(mockUser, "password")
+	assert.NoError(t, err)
+	assert.NoError(t, us.ConfirmTwoFactor(mockUser, codeAt(t, setup.Secret, totpStep(now))))
+	return mockUser
+}
+
+// passwordUser returns a user whose password is "password"
+func passwordUser(t *testing.T) *model.User {
+	mockUser := fixture.GetMockUser()
+	hash, err := hashPassword("password")
+	assert.NoError(t, err)
+	mockUser.Password = hash
+	return mockUser
 }